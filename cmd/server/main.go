@@ -231,7 +231,7 @@ func createRouterAndStore(config *config.Config, node routing.LocalNode) (router
 		}
 
 		router = routing.NewRedisRouter(node, rc)
-		store = service.NewRedisRoomStore(rc)
+		store = service.NewRedisRoomStore(rc, config.Redis.StoreFormat)
 	} else {
 		// local routing and store
 		logger.Infow("using single-node routing")
@@ -200,6 +200,14 @@ func TestAll() error {
 	return cmd.Run()
 }
 
+// run integration tests with WebRTC connections over an in-memory network
+func TestLoopback() error {
+	mg.Deps(Proto)
+	cmd := exec.Command("go", "test", "-tags", "loopback", "./test/...", "-count=1", "-timeout=3m")
+	connectStd(cmd)
+	return cmd.Run()
+}
+
 // cleans up builds
 func Clean() {
 	fmt.Println("cleaning...")
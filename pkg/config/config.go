@@ -2,7 +2,10 @@ package config
 
 import (
 	"fmt"
+	"net/url"
 	"os"
+	"path"
+	"strings"
 	"time"
 
 	"github.com/mitchellh/go-homedir"
@@ -20,9 +23,13 @@ type Config struct {
 	Audio          AudioConfig       `yaml:"audio"`
 	Room           RoomConfig        `yaml:"room"`
 	TURN           TURNConfig        `yaml:"turn"`
+	WebHook        WebHookConfig     `yaml:"webhook"`
 	KeyFile        string            `yaml:"key_file"`
 	Keys           map[string]string `yaml:"keys"`
 	LogLevel       string            `yaml:"log_level"`
+	// how long debug messages of a participant are logged regardless of log_level, once enabled for it with
+	// RoomService.DebugParticipant
+	ParticipantDebugDuration time.Duration `yaml:"participant_debug_duration"`
 
 	Development bool `yaml:"development"`
 }
@@ -37,15 +44,267 @@ type RTCConfig struct {
 	ForceTCP      bool     `yaml:"force_tcp"`
 	StunServers   []string `yaml:"stun_servers"`
 	UseExternalIP bool     `yaml:"use_external_ip"`
+	// IP families candidates are gathered for, ordered by preference
+	IPFamilies []string `yaml:"ip_families"`
 
 	// Number of packets to buffer for NACK
 	PacketBufferSize int `yaml:"packet_buffer_size"`
+	// Subscribers could ask for a shorter retransmission window when connecting or mid-session, down to
+	// MinNackWindow packets. It's PacketBufferSize otherwise, packets older than that can't be retransmitted
+	MinNackWindow int `yaml:"min_nack_window"`
+
+	// Max number of sender reports and source description chunks sent in a single RTCP packet,
+	// lower it when down track reports exceed the MTU
+	SDESBatchSize int `yaml:"sdes_batch_size"`
+	// Interval of sender reports sent to subscribers for each subscribed track
+	SenderReportInterval time.Duration `yaml:"sender_report_interval"`
+	// Source descriptions are sent once a subscribed track is bound, so the subscriber could map its SSRC to the
+	// stream. They're sent BindingReportAttempts times, BindingReportInterval apart, in case some are lost
+	BindingReportInterval time.Duration `yaml:"binding_report_interval"`
+	BindingReportAttempts int           `yaml:"binding_report_attempts"`
+
+	// Number of goroutines running RTCP and reporting work of participants in each room
+	ReportWorkers int `yaml:"report_workers"`
+	// Number of RTCP batches queued for each publisher. Once it's full, feedback is dropped rather than holding up
+	// the media it's about, keyframe requests wait for room
+	RTCPQueueSize int `yaml:"rtcp_queue_size"`
+
+	// Memory limits of receive buffers
+	BufferLimits BufferLimitsConfig `yaml:"buffer_limits"`
+
+	// Handling of participants that don't answer server offers
+	Negotiation NegotiationConfig `yaml:"negotiation"`
+
+	// Smoothing of bitrate spikes in video sent to subscribers
+	KeyframePacing KeyframePacingConfig `yaml:"keyframe_pacing"`
+
+	// Detection of participants speaking, from the audio level of their Opus packets
+	Speaking SpeakingConfig `yaml:"speaking"`
+	// detection of publishers sending silent audio, or black video from a covered camera
+	SilentMedia SilentMediaConfig `yaml:"silent_media"`
+
+	// Samples of each subscriber's layers and network feedback, to debug bitrate adaptation
+	SubscriberTelemetry SubscriberTelemetryConfig `yaml:"subscriber_telemetry"`
+
+	// Reception quality of subscribers included in receiver reports sent to publishers
+	SubscriberReports SubscriberReportsConfig `yaml:"subscriber_reports"`
+	// rates the network of each participant from loss, jitter and round trip time of its streams, participants
+	// are told when the rating of one changes
+	ConnectionQuality ConnectionQualityConfig `yaml:"connection_quality"`
+	// tells subscribers which layer of each video track they're receiving when it changes
+	ForwardedLayers ForwardedLayersConfig `yaml:"forwarded_layers"`
+	// lowers layers of video sent to subscribers whose estimated bandwidth doesn't fit them, and raises them again
+	// once it recovers
+	BandwidthAdaptation BandwidthAdaptationConfig `yaml:"bandwidth_adaptation"`
+
+	// Handling of subscriptions whose DownTrack codec doesn't match the kind of the published track,
+	// media sent on a transceiver of the wrong kind is dropped by the subscriber
+	TrackKindMismatch string `yaml:"track_kind_mismatch"`
+	// Handling of subscriptions whose media section the subscriber rejects or leaves out of its answer, their
+	// DownTracks would never be bound
+	RejectedSubscriptions string `yaml:"rejected_subscriptions"`
+
+	// Max DownTracks across all rooms on this node, video subscriptions are rejected once reached. 0 to disable
+	MaxSubscriptions int `yaml:"max_subscriptions"`
+	// Subscriptions each participant could keep disabled, they stay bound so they're resumed without
+	// renegotiation. Beyond it, those disabled the longest are unsubscribed. 0 to disable
+	MaxWarmStandbys int `yaml:"max_warm_standbys"`
 
 	// Max bitrate for REMB
 	MaxBitrate uint64 `yaml:"max_bitrate"`
 
 	// Throttle periods for pli/fir rtcp packets
 	PLIThrottle PLIThrottleConfig `yaml:"pli_throttle"`
+
+	// Limits on trickle ICE candidates accepted from each participant
+	TrickleLimit TrickleLimitConfig `yaml:"trickle_limit"`
+
+	// Handling of participants that don't gather a viable ICE candidate in time
+	ICEGathering ICEGatheringConfig `yaml:"ice_gathering"`
+
+	// When a participant resumes on a new signal connection, negotiation messages still arriving from
+	// the previous connection are ignored. Set to reject them with an error, ending the stale session
+	RejectStaleSignal bool `yaml:"reject_stale_signal"`
+
+	// Time participants have to recover after losing the signal connection or ICE connectivity before they're
+	// closed. 0 closes them as soon as the signal connection ends or ICE fails.
+	// Clients could request their own grace period when connecting, up to MaxReconnectGrace
+	ReconnectGrace    time.Duration `yaml:"reconnect_grace"`
+	MaxReconnectGrace time.Duration `yaml:"max_reconnect_grace"`
+
+	// Max bitrate of video published by each participant, in bits per second. Publishers are asked to stay
+	// below it with REMB, audio is allowed on top of it. 0 for unlimited
+	MaxUploadBitrate uint64 `yaml:"max_upload_bitrate"`
+
+	// Max bitrate of media sent to each participant, in bits per second. 0 for unlimited
+	MaxDownloadBitrate uint64 `yaml:"max_download_bitrate"`
+	// What's done when the lowest layers of the video a participant subscribed to don't fit in its max download
+	// bitrate, one of audio_only, exceed_pinned or reject
+	DownloadBudgetPolicy string `yaml:"download_budget_policy"`
+
+	// Detection of publishers that can't send their target bitrate, reported to them and to the server
+	PublisherCongestion PublisherCongestionConfig `yaml:"publisher_congestion"`
+
+	// Reordering of RTP published tracks receive out of order, by source
+	ReorderBuffer ReorderBufferConfig `yaml:"reorder_buffer"`
+
+	// Depth of subscribers' jitter buffers, audio and video separately
+	JitterBuffer JitterBufferConfig `yaml:"jitter_buffer"`
+
+	// Limits on data channel messages
+	SCTP SCTPConfig `yaml:"sctp"`
+}
+
+type SCTPConfig struct {
+	// largest data message accepted from participants, in bytes. reliable messages sent by the server are split to fit
+	MaxMessageSize uint32 `yaml:"max_message_size"`
+	// once this many bytes are buffered for a receiver, messages to it are dropped
+	// until its buffer drains below BufferedAmountLowThreshold
+	MaxBufferedAmount          uint64 `yaml:"max_buffered_amount"`
+	BufferedAmountLowThreshold uint64 `yaml:"buffered_amount_low_threshold"`
+}
+
+type BufferLimitsConfig struct {
+	// max bytes buffered for each published video stream, reduces packet_buffer_size when lower. 0 to disable
+	TrackBytes int `yaml:"track_bytes"`
+	// max bytes buffered for all video streams published by a participant, streams exceeding it are dropped.
+	// audio isn't limited. 0 to disable
+	ParticipantBytes int `yaml:"participant_bytes"`
+}
+
+type KeyframePacingConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// rate packets are paced out at during a spike, as a multiple of the stream's average bitrate
+	RateMultiplier float64 `yaml:"rate_multiplier"`
+	// bursts up to this long at the paced rate are sent right away
+	Burst time.Duration `yaml:"burst"`
+	// packets are never held longer than this
+	MaxDelay time.Duration `yaml:"max_delay"`
+	// switch subscribers of simulcast tracks a layer down while their stream is spiking
+	DowngradeLayer bool `yaml:"downgrade_layer"`
+}
+
+type SpeakingConfig struct {
+	// audio level packets have to reach to be voiced, 0-127, where 0 is loudest
+	ActiveLevel uint8 `yaml:"active_level"`
+	// participants start speaking once packets stayed voiced this long
+	ActivateAfter time.Duration `yaml:"activate_after"`
+	// and stop once no packet was voiced for this long
+	DeactivateAfter time.Duration `yaml:"deactivate_after"`
+}
+
+type SilentMediaConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// audio is silent once no packet reached this audio level for After, 0-127, where 0 is loudest
+	AudioLevel uint8 `yaml:"audio_level"`
+	// camera video is black once it's been received below this bitrate for After, in bits per second.
+	// Screen shares aren't checked, a paused slide is legitimately sent at a very low bitrate
+	VideoBitrate uint64        `yaml:"video_bitrate"`
+	After        time.Duration `yaml:"after"`
+}
+
+type SubscriberTelemetryConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// time between samples of each subscribed track
+	Interval time.Duration `yaml:"interval"`
+	// where samples are written, one of log, file or metrics
+	Sink string `yaml:"sink"`
+	// samples are appended to it as JSON lines with the file sink
+	File string `yaml:"file"`
+	// the file sink stops writing once the file has grown by this many bytes
+	MaxFileBytes int64 `yaml:"max_file_bytes"`
+}
+
+type SubscriberReportsConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// how reports of subscribers of a stream are combined, average or worst
+	Aggregation string `yaml:"aggregation"`
+	// reports older than this are ignored, subscribers that stopped reporting don't affect the publisher
+	MaxAge time.Duration `yaml:"max_age"`
+}
+
+type ConnectionQualityConfig struct {
+	Enabled bool `yaml:"enabled"`
+}
+
+type ForwardedLayersConfig struct {
+	Enabled bool `yaml:"enabled"`
+}
+
+// BandwidthAdaptationConfig estimates the bandwidth of each subscriber from the REMB it sends and the loss it
+// reports. Layers are lowered as soon as they don't fit the estimate, and raised a layer at a time once the
+// estimate fits the higher one with UpgradeHeadroom to spare, and nothing has been lowered for UpgradeHold
+type BandwidthAdaptationConfig struct {
+	Enabled         bool          `yaml:"enabled"`
+	UpgradeHeadroom float64       `yaml:"upgrade_headroom"`
+	UpgradeHold     time.Duration `yaml:"upgrade_hold"`
+}
+
+type PublisherCongestionConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// a publisher is congested once its estimated bitrate stays below this fraction of its target bitrate
+	// for CongestedAfter
+	Threshold      float64       `yaml:"threshold"`
+	CongestedAfter time.Duration `yaml:"congested_after"`
+	// it recovers once its estimated bitrate stays at or above this fraction of its target bitrate
+	// for RecoveredAfter
+	RecoveryThreshold float64       `yaml:"recovery_threshold"`
+	RecoveredAfter    time.Duration `yaml:"recovered_after"`
+}
+
+// ReorderBufferConfig sets how long RTP of published tracks is held when it arrives out of order, waiting for the
+// packets missing before it, so it's forwarded in sequence. Longer delays reorder more at the cost of latency, so
+// they're set by source: screen shares tolerate more latency than cameras and microphones
+type ReorderBufferConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// 0 forwards packets of the source as they arrive. tracks of unknown sources use Camera or Audio by kind
+	Audio       time.Duration `yaml:"audio"`
+	Camera      time.Duration `yaml:"camera"`
+	ScreenShare time.Duration `yaml:"screen_share"`
+	// packets held per stream at most, missing ones are given up on past it
+	MaxPackets int `yaml:"max_packets"`
+}
+
+// MaxJitterBufferDelay is the longest delay the playout-delay extension could carry, 4095 10ms units
+const MaxJitterBufferDelay = 40950 * time.Millisecond
+
+// JitterBufferConfig sets how long subscribers hold media in their jitter buffers, with the playout-delay header
+// extension on streams sent to them. Audio tolerates less latency than video, so their depths are set apart
+type JitterBufferConfig struct {
+	Enabled bool              `yaml:"enabled"`
+	Audio   JitterBufferDepth `yaml:"audio"`
+	Video   JitterBufferDepth `yaml:"video"`
+	// depths of some participants, the first one matching the identity applies
+	Participants []JitterBufferOverride `yaml:"participants"`
+}
+
+// JitterBufferDepth bounds the delay between receiving and playing out media, receivers pick one within it.
+// Delays are sent in 10ms units, rounded down
+type JitterBufferDepth struct {
+	Min time.Duration `yaml:"min"`
+	Max time.Duration `yaml:"max"`
+}
+
+type JitterBufferOverride struct {
+	// names or path.Match patterns
+	Identities []string `yaml:"identities"`
+	// depths left unset are the defaults
+	Audio JitterBufferDepth `yaml:"audio"`
+	Video JitterBufferDepth `yaml:"video"`
+}
+
+type NegotiationConfig struct {
+	// time to wait for an answer to a server offer before sending it again, 0 to wait indefinitely
+	AnswerTimeout time.Duration `yaml:"answer_timeout"`
+	// number of times an unanswered offer is sent again before giving up
+	MaxRetries int `yaml:"max_retries"`
+	// what happens once retries are exhausted, rollback or disconnect
+	OnTimeout string `yaml:"on_timeout"`
+	// time the signaling state could stay outside of stable before negotiation is considered stuck and recovered,
+	// 0 to disable. offers that are still being sent again aren't stuck
+	StuckTimeout time.Duration `yaml:"stuck_timeout"`
+	// how stuck offers are recovered, rollback or ice_restart
+	StuckRecovery string `yaml:"stuck_recovery"`
 }
 
 type PLIThrottleConfig struct {
@@ -54,6 +313,22 @@ type PLIThrottleConfig struct {
 	HighQuality time.Duration `yaml:"high_quality"`
 }
 
+type TrickleLimitConfig struct {
+	// max number of candidates accepted per PeerConnection within Period, 0 to disable
+	MaxCandidates int           `yaml:"max_candidates"`
+	Period        time.Duration `yaml:"period"`
+}
+
+type ICEGatheringConfig struct {
+	// time a viable candidate pair has to be gathered after the client's offer, 0 to disable.
+	// candidates still arriving push it back
+	Timeout time.Duration `yaml:"timeout"`
+	// what happens once it times out, retry or close
+	OnTimeout string `yaml:"on_timeout"`
+	// number of times the client is asked to reconnect before the participant is closed
+	MaxRetries int `yaml:"max_retries"`
+}
+
 type AudioConfig struct {
 	// minimum level to be considered active, 0-127, where 0 is loudest
 	ActiveLevel uint8 `yaml:"active_level"`
@@ -72,12 +347,378 @@ type RedisConfig struct {
 	Username string `yaml:"username"`
 	Password string `yaml:"password"`
 	DB       int    `yaml:"db"`
+	// format rooms and participants are stored in, protobuf or json. Either could be read whatever it's set to,
+	// so it could be changed on a running cluster
+	StoreFormat string `yaml:"store_format"`
 }
 
 type RoomConfig struct {
 	EnabledCodecs   []CodecSpec `yaml:"enabled_codecs"`
 	MaxParticipants uint32      `yaml:"max_participants"`
 	EmptyTimeout    uint32      `yaml:"empty_timeout"`
+	// seconds a room could stay open since its creation, 0 for no limit
+	MaxDuration uint32 `yaml:"max_duration"`
+	// seconds a participant's presence has to hold before it's reported as away or offline
+	PresenceDebounce uint32 `yaml:"presence_debounce"`
+	// hold participants that aren't room admins until they are admitted by a host
+	WaitingRoom bool `yaml:"waiting_room"`
+	// simulcast layers expected from publishers
+	Simulcast SimulcastConfig `yaml:"simulcast"`
+	// RTCP feedback negotiated for published and subscribed tracks
+	RTCPFeedback RTCPFeedbackConfig `yaml:"rtcp_feedback"`
+	// records data packets published in rooms
+	DataRecording DataRecordingConfig `yaml:"data_recording"`
+	// who participants could send data packets to, one of open, host_only or moderated
+	DataPolicy string `yaml:"data_policy"`
+	// participants that are hosts of rooms besides room admins
+	Hosts HostsConfig `yaml:"hosts"`
+	// routing of caption data packets by the preferred language of subscribers
+	Captions CaptionsConfig `yaml:"captions"`
+	// max rate of data packets relayed in each room, excess packets are dropped
+	DataRateLimit DataRateLimitConfig `yaml:"data_rate_limit"`
+	// rooms whose subscribers receive streams on SSRCs derived from the publisher's identity and the track,
+	// stable across reconnects, for recorders that depend on them. names or path.Match patterns
+	StableSSRCRooms []string `yaml:"stable_ssrc_rooms"`
+	// device class => quality video subscriptions of participants on it start at, low, medium or high
+	DeviceClasses map[string]string `yaml:"device_classes"`
+	// subscribers recording rooms, whose video is pinned to a quality rather than adapted
+	Recorders RecordersConfig `yaml:"recorders"`
+	// samples loss and jitter of each participant in rooms, for quality analysis
+	QualitySampling QualitySamplingConfig `yaml:"quality_sampling"`
+	// codec conversions allowed for subscribers that can't decode the codec a track is published in,
+	// other mismatches are rejected. transcoding is costly, so it's limited to the pairs and rooms listed
+	Transcoding []TranscodingRule `yaml:"transcoding"`
+	// mixes the loudest speakers of rooms into a single track for subscribers that can't decode many audio streams
+	AudioMixing AudioMixingConfig `yaml:"audio_mixing"`
+	// delays forwarding newly published tracks in large rooms, so subscriptions and the keyframes they request
+	// are coalesced when many subscribers attach at once
+	FanoutDelay FanoutDelayConfig `yaml:"fanout_delay"`
+	// what's done with participants that keep failing to publish
+	PublishFailures PublishFailuresConfig `yaml:"publish_failures"`
+	// retries of participant writes to the room store that failed, e.g. while redis is briefly unavailable
+	StoreRetry StoreRetryConfig `yaml:"store_retry"`
+	// max bytes of metadata of a participant, larger updates are rejected. 0 for unlimited
+	MaxMetadataSize int `yaml:"max_metadata_size"`
+	// max bytes of metadata of all participants sent in a single participant update. Metadata of the largest is
+	// left out beyond it, clients could fetch it with GetParticipant. 0 for unlimited
+	MaxRosterMetadataSize int `yaml:"max_roster_metadata_size"`
+
+	// past sessions of participants kept in the room store once they leave
+	SessionHistory SessionHistoryConfig `yaml:"session_history"`
+	// subscriptions of participants that leave are kept this long, and restored when they join the room again
+	// in the meantime. 0 to forget them right away
+	SubscriptionRestoreWindow time.Duration `yaml:"subscription_restore_window"`
+
+	// how the download budget of subscribers is shared among their video subscriptions, per room. the first rule
+	// matching a room applies, rooms without one have their video paused following rtc.download_budget_policy
+	SubscriptionAllocation []SubscriptionAllocationRule `yaml:"subscription_allocation"`
+}
+
+// SubscriptionAllocationRule picks the layers of video subscriptions so they fit in the max download bitrate of
+// subscribers, rather than only pausing them
+type SubscriptionAllocationRule struct {
+	// greedy, proportional_fair or priority_strict
+	Strategy string `yaml:"strategy"`
+	// rooms it applies to, names or path.Match patterns. empty for all rooms
+	Rooms []string `yaml:"rooms"`
+}
+
+// SessionHistoryConfig keeps the last Depth sessions of participants of each room in the room store, with their join
+// and leave times and why they left, for up to Retention after they ended. It outlives the room, so participants of
+// earlier sessions of a room name could be looked up
+type SessionHistoryConfig struct {
+	// sessions kept per room, 0 to disable
+	Depth     int           `yaml:"depth"`
+	Retention time.Duration `yaml:"retention"`
+}
+
+// StoreRetryConfig retries participant updates and removals that failed to be written to the room store in the
+// background, waiting RetryBackoff before the first retry and twice as long before each following one, up to
+// MaxBackoff. Writes are dropped once they've been failing for MaxAge, or when QueueSize writes are pending
+type StoreRetryConfig struct {
+	// 0 to disable retries
+	QueueSize    int           `yaml:"queue_size"`
+	RetryBackoff time.Duration `yaml:"retry_backoff"`
+	MaxBackoff   time.Duration `yaml:"max_backoff"`
+	MaxAge       time.Duration `yaml:"max_age"`
+}
+
+const (
+	PublishFailureActionNone          = "none"
+	PublishFailureActionNotify        = "notify"
+	PublishFailureActionSubscribeOnly = "subscribe_only"
+	PublishFailureActionDisconnect    = "disconnect"
+)
+
+// PublishFailuresConfig acts on participants whose offers fail, or whose tracks are rejected, MaxFailures times
+// within Window. Fewer failures are taken as transient, clients retry them on their own
+type PublishFailuresConfig struct {
+	// none, notify, subscribe_only or disconnect. the participant is notified of the failures with all but none
+	Action      string        `yaml:"action"`
+	MaxFailures int           `yaml:"max_failures"`
+	Window      time.Duration `yaml:"window"`
+	// rooms it applies to, names or path.Match patterns. empty for all rooms
+	Rooms []string `yaml:"rooms"`
+}
+
+type FanoutDelayConfig struct {
+	// time newly published tracks wait before subscribers are added, 0 to disable
+	Delay time.Duration `yaml:"delay"`
+	// rooms it applies to, names or path.Match patterns. other rooms aren't delayed
+	Rooms []string `yaml:"rooms"`
+	// rooms with fewer participants aren't delayed, as there's little to coalesce
+	MinParticipants int `yaml:"min_participants"`
+}
+
+type TranscodingRule struct {
+	// mime types converted from and to, of the same kind
+	From string `yaml:"from"`
+	To   string `yaml:"to"`
+	// rooms it applies to, names or path.Match patterns. empty for all rooms
+	Rooms []string `yaml:"rooms"`
+}
+
+// AudioMixingConfig sends participants joining with the mixed_audio connection parameter a single track mixing the
+// MaxSpeakers loudest speakers, instead of a track per speaker. Mixing decodes and encodes Opus, with the codec
+// supplied to the room manager
+type AudioMixingConfig struct {
+	// speakers mixed, 0 to disable
+	MaxSpeakers int `yaml:"max_speakers"`
+}
+
+type QualitySamplingConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// time between samples of each participant
+	Interval time.Duration `yaml:"interval"`
+	// where samples are written, log or file
+	Sink string `yaml:"sink"`
+	// samples are appended to it as JSON lines with the file sink
+	File string `yaml:"file"`
+	// the file sink stops writing once the file has grown by this many bytes
+	MaxFileBytes int64 `yaml:"max_file_bytes"`
+}
+
+type RecordersConfig struct {
+	// identities of recorders, names or path.Match patterns
+	Identities []string `yaml:"identities"`
+	// quality of video recorders receive, low, medium or high, the nearest layer is used when the publisher
+	// doesn't send it. empty to adapt it like for any other subscriber
+	VideoQuality string `yaml:"video_quality"`
+	// keyframes are requested for video sent to recorders at this interval, so recordings have regular seek
+	// points. requests go through the PLI throttle of the publisher. 0 to disable
+	KeyframeInterval time.Duration `yaml:"keyframe_interval"`
+}
+
+type CaptionsConfig struct {
+	// when disabled, caption packets are forwarded like any other data packet
+	Enabled bool `yaml:"enabled"`
+	// language of captions sent to subscribers without a preferred language, or whose preferred language
+	// isn't captioned by the publisher. empty to send those subscribers captions in all languages
+	DefaultLanguage string `yaml:"default_language"`
+}
+
+type HostsConfig struct {
+	// the first participant to join a room is a host, including when it leaves and rejoins
+	FirstParticipant bool `yaml:"first_participant"`
+	// when the last host leaves, the participant that's been in the room the longest becomes host. a participant
+	// joining a room without hosts becomes one
+	Transfer bool `yaml:"transfer"`
+}
+
+// Enabled is true when hosts are assigned to rooms, besides room admins
+func (c HostsConfig) Enabled() bool {
+	return c.FirstParticipant || c.Transfer
+}
+
+type DataRateLimitConfig struct {
+	// reliable and lossy channels are limited separately
+	Reliable DataRateLimit `yaml:"reliable"`
+	Lossy    DataRateLimit `yaml:"lossy"`
+}
+
+// DataRateLimit is a token bucket of packets, refilled at the rate and holding up to the burst, so bursts
+// that stay within it on average aren't throttled
+type DataRateLimit struct {
+	// packets per second each participant could send, 0 for unlimited
+	ParticipantRate  float64 `yaml:"participant_rate"`
+	ParticipantBurst int     `yaml:"participant_burst"`
+	// packets per second all participants of a room could send together, 0 for unlimited.
+	// packets dropped by the participant limit don't count towards it
+	RoomRate  float64 `yaml:"room_rate"`
+	RoomBurst int     `yaml:"room_burst"`
+}
+
+func (l DataRateLimit) enabled() bool {
+	return l.ParticipantRate > 0 || l.RoomRate > 0
+}
+
+// Enabled returns true when either channel is limited
+func (c DataRateLimitConfig) Enabled() bool {
+	return c.Reliable.enabled() || c.Lossy.enabled()
+}
+
+type DataRecordingConfig struct {
+	// directory each room's data packets are written to, as <room>-<start time>.data.log. disabled when empty
+	Directory string `yaml:"directory"`
+}
+
+type SimulcastConfig struct {
+	// target bitrate of each layer, ordered from lowest to highest quality.
+	// the number of entries is the number of layers publishers are expected to send, up to 3
+	TargetBitrates []uint64 `yaml:"target_bitrates"`
+	// what subscribers receive while the layer they want isn't being sent, e.g. when the publisher is CPU throttled
+	Fallback SimulcastFallbackConfig `yaml:"fallback"`
+	// handling of streams whose RID isn't q, h or f, one of ignore or reconcile
+	UnknownLayers string `yaml:"unknown_layers"`
+}
+
+type SimulcastFallbackConfig struct {
+	// one of nearest, lower or none
+	Policy string `yaml:"policy"`
+	// how long the wanted layer has to be sent again before subscribers are switched back to it
+	StableFor time.Duration `yaml:"stable_for"`
+}
+
+type RTCPFeedbackConfig struct {
+	RTCPFeedbackTypes `yaml:",inline"`
+	// overrides keyed by codec mime type, an entry replaces the defaults above for that codec
+	Codecs map[string]RTCPFeedbackTypes `yaml:"codecs"`
+}
+
+type RTCPFeedbackTypes struct {
+	NACK bool `yaml:"nack"`
+	PLI  bool `yaml:"pli"`
+	REMB bool `yaml:"remb"`
+	TWCC bool `yaml:"twcc"`
+}
+
+const (
+	IPFamilyV4 = "ipv4"
+	IPFamilyV6 = "ipv6"
+)
+
+const (
+	// pending subscriptions of the unanswered offer are removed
+	NegotiationTimeoutRollback = "rollback"
+	// the participant is disconnected
+	NegotiationTimeoutDisconnect = "disconnect"
+)
+
+const (
+	// the client is asked to reconnect, gathering candidates again
+	ICEGatheringRetry = "retry"
+	// the participant is closed
+	ICEGatheringClose = "close"
+)
+
+const (
+	// video subscriptions are paused, audio is still sent
+	DownloadBudgetAudioOnly = "audio_only"
+	// video subscriptions are paused but the one with the highest priority, pinned first, which exceeds the budget
+	DownloadBudgetExceedPinned = "exceed_pinned"
+	// video subscriptions that don't fit are rejected, those that no longer fit are paused, lowest priority first
+	DownloadBudgetReject = "reject"
+)
+
+const (
+	// tracks get the highest layer that fits in what's left, pinned first then by target layer
+	AllocationGreedy = "greedy"
+	// every track gets its lowest layer first, then they're raised a layer at a time in turns
+	AllocationProportionalFair = "proportional_fair"
+	// tracks are served in priority order, a track only gets bandwidth once those before it reached their target
+	AllocationPriorityStrict = "priority_strict"
+)
+
+const (
+	// the last negotiated state is restored, and pending changes are offered again
+	StuckRecoveryRollback = "rollback"
+	// same as rollback, with an offer that restarts ICE
+	StuckRecoveryICERestart = "ice_restart"
+)
+
+const (
+	// loss and jitter averaged across subscribers
+	SubscriberReportsAverage = "average"
+	// highest loss and jitter of any subscriber
+	SubscriberReportsWorst = "worst"
+)
+
+const (
+	// the subscriber's transceiver is created with the kind of the published track
+	TrackKindMismatchFix = "fix"
+	// the subscription fails with an error
+	TrackKindMismatchReject = "reject"
+)
+
+const (
+	// the DownTrack is closed and the subscription removed, as when unsubscribing
+	RejectedSubscriptionsRemove = "remove"
+	// the subscription is left in place, the subscriber could accept it in a later answer
+	RejectedSubscriptionsKeep = "keep"
+)
+
+const (
+	// the layer closest to the wanted one is forwarded, the lower one when two are as close
+	SimulcastFallbackNearest = "nearest"
+	// only lower layers are forwarded, so subscribers never receive more than they asked for
+	SimulcastFallbackLower = "lower"
+	// subscribers wait for the wanted layer to be sent again
+	SimulcastFallbackNone = "none"
+)
+
+const (
+	// streams with unknown RIDs are dropped
+	SimulcastUnknownLayersIgnore = "ignore"
+	// a stream with an unknown RID is received as the lowest layer, unless another stream already is
+	SimulcastUnknownLayersReconcile = "reconcile"
+)
+
+const (
+	// compact binary encoding
+	StoreFormatProtobuf = "protobuf"
+	// readable with redis-cli, to debug room state
+	StoreFormatJSON = "json"
+)
+
+const (
+	TelemetrySinkLog     = "log"
+	TelemetrySinkFile    = "file"
+	TelemetrySinkMetrics = "metrics"
+)
+
+const (
+	// participants could send data to anyone
+	DataPolicyOpen = "open"
+	// only hosts could send data
+	DataPolicyHostOnly = "host_only"
+	// hosts could send data to anyone, other participants only to hosts
+	DataPolicyModerated = "moderated"
+)
+
+const (
+	// bytes taken by each packet in receive buffers
+	BufferSlotSize = 1500
+	// fewer packets wouldn't leave enough time to retransmit lost ones
+	MinBufferPackets = 100
+)
+
+var DefaultRTCPFeedback = RTCPFeedbackTypes{
+	NACK: true,
+	PLI:  true,
+	REMB: true,
+}
+
+// ForCodec returns feedback types enabled for the codec, defaults are used when the config is nil
+func (c *RTCPFeedbackConfig) ForCodec(mimeType string) RTCPFeedbackTypes {
+	if c == nil {
+		return DefaultRTCPFeedback
+	}
+	for mime, types := range c.Codecs {
+		if strings.EqualFold(mime, mimeType) {
+			return types
+		}
+	}
+	return c.RTCPFeedbackTypes
 }
 
 type CodecSpec struct {
@@ -91,6 +732,25 @@ type TURNConfig struct {
 	CertFile string `yaml:"cert_file"`
 	KeyFile  string `yaml:"key_file"`
 	TLSPort  int    `yaml:"tls_port"`
+
+	// external TURN servers sharing Secret, like turn:turn.example.com:3478?transport=udp. Each participant gets
+	// its own credentials for them, valid for CredentialTTL (RFC 5766 long-term credentials derived with the
+	// TURN REST API scheme, as with coturn's use-auth-secret)
+	URLs          []string      `yaml:"urls"`
+	Secret        string        `yaml:"secret"`
+	CredentialTTL time.Duration `yaml:"credential_ttl"`
+}
+
+// WebHookConfig posts room and participant events to URL, signed with the secret of APIKey
+type WebHookConfig struct {
+	URL    string `yaml:"url"`
+	APIKey string `yaml:"api_key"`
+	// timeout of each request
+	Timeout time.Duration `yaml:"timeout"`
+	// events are posted again after non-2xx responses, up to MaxRetries times, waiting RetryBackoff before the
+	// first retry and twice as long before each following one
+	MaxRetries   int           `yaml:"max_retries"`
+	RetryBackoff time.Duration `yaml:"retry_backoff"`
 }
 
 func NewConfig(confString string, c *cli.Context) (*Config, error) {
@@ -107,13 +767,89 @@ func NewConfig(confString string, c *cli.Context) (*Config, error) {
 				"stun.l.google.com:19302",
 				"stun1.l.google.com:19302",
 			},
+			IPFamilies:       []string{IPFamilyV4},
 			MaxBitrate:       3 * 1024 * 1024, // 3 mbps
 			PacketBufferSize: 500,
+			MinNackWindow:    50,
+			SDESBatchSize:    20,
+			ReportWorkers:    4,
+			RTCPQueueSize:    50,
+
+			SenderReportInterval:  5 * time.Second,
+			BindingReportInterval: 20 * time.Millisecond,
+			BindingReportAttempts: 7,
+			Negotiation: NegotiationConfig{
+				MaxRetries:    2,
+				OnTimeout:     NegotiationTimeoutRollback,
+				StuckTimeout:  30 * time.Second,
+				StuckRecovery: StuckRecoveryRollback,
+			},
+			TrackKindMismatch:     TrackKindMismatchFix,
+			RejectedSubscriptions: RejectedSubscriptionsRemove,
+			KeyframePacing: KeyframePacingConfig{
+				RateMultiplier: 2,
+				Burst:          50 * time.Millisecond,
+				MaxDelay:       100 * time.Millisecond,
+			},
+			Speaking: SpeakingConfig{
+				ActiveLevel:     30,
+				ActivateAfter:   200 * time.Millisecond,
+				DeactivateAfter: 500 * time.Millisecond,
+			},
+			SilentMedia: SilentMediaConfig{
+				AudioLevel:   110,
+				VideoBitrate: 30_000,
+				After:        10 * time.Second,
+			},
+			SubscriberTelemetry: SubscriberTelemetryConfig{
+				Interval:     time.Second,
+				Sink:         TelemetrySinkLog,
+				MaxFileBytes: 100 << 20, // 100MB
+			},
+			SubscriberReports: SubscriberReportsConfig{
+				Aggregation: SubscriberReportsAverage,
+				MaxAge:      5 * time.Second,
+			},
 			PLIThrottle: PLIThrottleConfig{
 				LowQuality:  500 * time.Millisecond,
 				MidQuality:  time.Second,
 				HighQuality: time.Second,
 			},
+			TrickleLimit: TrickleLimitConfig{
+				MaxCandidates: 50,
+				Period:        10 * time.Second,
+			},
+			ICEGathering: ICEGatheringConfig{
+				OnTimeout:  ICEGatheringRetry,
+				MaxRetries: 1,
+			},
+			MaxReconnectGrace:    2 * time.Minute,
+			DownloadBudgetPolicy: DownloadBudgetAudioOnly,
+			BandwidthAdaptation: BandwidthAdaptationConfig{
+				UpgradeHeadroom: 0.25,
+				UpgradeHold:     5 * time.Second,
+			},
+			PublisherCongestion: PublisherCongestionConfig{
+				Threshold:         0.7,
+				CongestedAfter:    5 * time.Second,
+				RecoveryThreshold: 0.9,
+				RecoveredAfter:    10 * time.Second,
+			},
+			ReorderBuffer: ReorderBufferConfig{
+				Audio:       20 * time.Millisecond,
+				Camera:      20 * time.Millisecond,
+				ScreenShare: 100 * time.Millisecond,
+				MaxPackets:  100,
+			},
+			JitterBuffer: JitterBufferConfig{
+				Audio: JitterBufferDepth{Max: 100 * time.Millisecond},
+				Video: JitterBufferDepth{Min: 50 * time.Millisecond, Max: 400 * time.Millisecond},
+			},
+			SCTP: SCTPConfig{
+				MaxMessageSize:             65536,
+				MaxBufferedAmount:          1 << 20, // 1MB
+				BufferedAmountLowThreshold: 256 << 10,
+			},
 		},
 		Audio: AudioConfig{
 			ActiveLevel:     30, // -30dBov = 0.03
@@ -121,7 +857,9 @@ func NewConfig(confString string, c *cli.Context) (*Config, error) {
 			UpdateInterval:  500,
 			SmoothIntervals: 4,
 		},
-		Redis: RedisConfig{},
+		Redis: RedisConfig{
+			StoreFormat: StoreFormatProtobuf,
+		},
 		Room: RoomConfig{
 			// by default only enable opus and VP8
 			EnabledCodecs: []CodecSpec{
@@ -130,13 +868,58 @@ func NewConfig(confString string, c *cli.Context) (*Config, error) {
 				//{Mime: webrtc.MimeTypeH264},
 				//{Mime: webrtc.MimeTypeVP9},
 			},
-			EmptyTimeout: 5 * 60,
+			EmptyTimeout:     5 * 60,
+			PresenceDebounce: 5,
+			Simulcast: SimulcastConfig{
+				TargetBitrates: []uint64{150_000, 500_000, 1_500_000},
+				Fallback: SimulcastFallbackConfig{
+					Policy:    SimulcastFallbackNearest,
+					StableFor: 2 * time.Second,
+				},
+				UnknownLayers: SimulcastUnknownLayersIgnore,
+			},
+			RTCPFeedback: RTCPFeedbackConfig{
+				RTCPFeedbackTypes: DefaultRTCPFeedback,
+			},
+			DataPolicy: DataPolicyOpen,
+			StoreRetry: StoreRetryConfig{
+				QueueSize:    1000,
+				RetryBackoff: 500 * time.Millisecond,
+				MaxBackoff:   10 * time.Second,
+				MaxAge:       5 * time.Minute,
+			},
+			FanoutDelay: FanoutDelayConfig{
+				MinParticipants: 10,
+			},
+			SessionHistory: SessionHistoryConfig{
+				Retention: 24 * time.Hour,
+			},
+			SubscriptionRestoreWindow: 30 * time.Second,
+			PublishFailures: PublishFailuresConfig{
+				Action:      PublishFailureActionNone,
+				MaxFailures: 3,
+				Window:      time.Minute,
+			},
+			QualitySampling: QualitySamplingConfig{
+				Interval:     5 * time.Second,
+				Sink:         TelemetrySinkLog,
+				MaxFileBytes: 100 << 20, // 100MB
+			},
 		},
 		TURN: TURNConfig{
 			Enabled: false,
 			TLSPort: 3478,
+
+			CredentialTTL: 24 * time.Hour,
+		},
+		WebHook: WebHookConfig{
+			Timeout:      5 * time.Second,
+			MaxRetries:   3,
+			RetryBackoff: 500 * time.Millisecond,
 		},
 		Keys: map[string]string{},
+
+		ParticipantDebugDuration: 10 * time.Minute,
 	}
 	if confString != "" {
 		if err := yaml.Unmarshal([]byte(confString), conf); err != nil {
@@ -150,6 +933,200 @@ func NewConfig(confString string, c *cli.Context) (*Config, error) {
 		}
 	}
 
+	if sctp := conf.RTC.SCTP; sctp.MaxBufferedAmount > 0 && sctp.BufferedAmountLowThreshold > sctp.MaxBufferedAmount {
+		return nil, errors.New("sctp buffered_amount_low_threshold cannot exceed max_buffered_amount")
+	}
+
+	if conf.RTC.SDESBatchSize < 0 {
+		return nil, errors.New("sdes_batch_size cannot be negative")
+	}
+	if conf.RTC.ReportWorkers < 0 {
+		return nil, errors.New("report_workers cannot be negative")
+	}
+	if conf.RTC.SenderReportInterval < 0 || conf.RTC.BindingReportInterval < 0 || conf.RTC.BindingReportAttempts < 0 {
+		return nil, errors.New("sender_report_interval, binding_report_interval and binding_report_attempts cannot be negative")
+	}
+
+	if err := validateIPFamilies(conf.RTC.IPFamilies); err != nil {
+		return nil, err
+	}
+
+	if err := validateBufferLimits(conf.RTC); err != nil {
+		return nil, err
+	}
+
+	if conf.RTC.MinNackWindow <= 0 || conf.RTC.MinNackWindow > conf.RTC.PacketBufferSize {
+		return nil, errors.New("min_nack_window must be between 1 and packet_buffer_size")
+	}
+
+	if conf.RTC.RTCPQueueSize <= 0 {
+		return nil, errors.New("rtcp_queue_size must be positive")
+	}
+
+	if err := validateNegotiation(conf.RTC.Negotiation); err != nil {
+		return nil, err
+	}
+
+	if err := validateICEGathering(conf.RTC.ICEGathering); err != nil {
+		return nil, err
+	}
+
+	if err := validateSpeaking(conf.RTC.Speaking); err != nil {
+		return nil, err
+	}
+
+	if err := validateSilentMedia(conf.RTC.SilentMedia); err != nil {
+		return nil, err
+	}
+
+	if err := validateKeyframePacing(conf.RTC.KeyframePacing); err != nil {
+		return nil, err
+	}
+
+	if err := validateSubscriberTelemetry(conf.RTC.SubscriberTelemetry); err != nil {
+		return nil, err
+	}
+
+	if err := validateSubscriberReports(conf.RTC.SubscriberReports); err != nil {
+		return nil, err
+	}
+
+	if conf.RTC.TrackKindMismatch != TrackKindMismatchFix && conf.RTC.TrackKindMismatch != TrackKindMismatchReject {
+		return nil, fmt.Errorf("track_kind_mismatch must be %s or %s", TrackKindMismatchFix, TrackKindMismatchReject)
+	}
+	if conf.RTC.RejectedSubscriptions != RejectedSubscriptionsRemove && conf.RTC.RejectedSubscriptions != RejectedSubscriptionsKeep {
+		return nil, fmt.Errorf("rejected_subscriptions must be %s or %s", RejectedSubscriptionsRemove,
+			RejectedSubscriptionsKeep)
+	}
+
+	if conf.Redis.StoreFormat != StoreFormatProtobuf && conf.Redis.StoreFormat != StoreFormatJSON {
+		return nil, fmt.Errorf("redis store_format must be %s or %s", StoreFormatProtobuf, StoreFormatJSON)
+	}
+
+	if conf.RTC.MaxSubscriptions < 0 {
+		return nil, errors.New("max_subscriptions cannot be negative")
+	}
+
+	if conf.RTC.MaxWarmStandbys < 0 {
+		return nil, errors.New("max_warm_standbys cannot be negative")
+	}
+
+	if conf.RTC.ReconnectGrace < 0 || conf.RTC.ReconnectGrace > conf.RTC.MaxReconnectGrace {
+		return nil, errors.New("reconnect_grace must be between 0 and max_reconnect_grace")
+	}
+
+	if conf.RTC.BandwidthAdaptation.UpgradeHeadroom < 0 || conf.RTC.BandwidthAdaptation.UpgradeHold < 0 {
+		return nil, errors.New("bandwidth_adaptation upgrade_headroom and upgrade_hold cannot be negative")
+	}
+	if err := validatePublisherCongestion(conf.RTC.PublisherCongestion); err != nil {
+		return nil, err
+	}
+
+	switch conf.RTC.DownloadBudgetPolicy {
+	case DownloadBudgetAudioOnly, DownloadBudgetExceedPinned, DownloadBudgetReject:
+	default:
+		return nil, fmt.Errorf("download_budget_policy must be %s, %s or %s",
+			DownloadBudgetAudioOnly, DownloadBudgetExceedPinned, DownloadBudgetReject)
+	}
+
+	if rb := conf.RTC.ReorderBuffer; rb.Enabled {
+		if rb.Audio < 0 || rb.Camera < 0 || rb.ScreenShare < 0 {
+			return nil, errors.New("reorder_buffer delays must not be negative")
+		}
+		if rb.MaxPackets <= 0 {
+			return nil, errors.New("reorder_buffer max_packets must be positive")
+		}
+	}
+
+	if err := validateJitterBuffer(conf.RTC.JitterBuffer); err != nil {
+		return nil, err
+	}
+
+	if err := ValidateDataPolicy(conf.Room.DataPolicy); err != nil {
+		return nil, err
+	}
+
+	if err := validateDataRateLimit(conf.Room.DataRateLimit); err != nil {
+		return nil, err
+	}
+
+	if err := validateQualitySampling(conf.Room.QualitySampling); err != nil {
+		return nil, err
+	}
+
+	for _, pattern := range conf.Room.StableSSRCRooms {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid stable_ssrc_rooms pattern %q: %v", pattern, err)
+		}
+	}
+
+	for class, quality := range conf.Room.DeviceClasses {
+		switch quality {
+		case "low", "medium", "high":
+		default:
+			return nil, fmt.Errorf("device_classes quality of %s must be low, medium or high", class)
+		}
+	}
+
+	if err := validateRecorders(conf.Room.Recorders); err != nil {
+		return nil, err
+	}
+
+	if len(conf.TURN.URLs) > 0 && (conf.TURN.Secret == "" || conf.TURN.CredentialTTL <= 0) {
+		return nil, errors.New("turn urls require a secret and a positive credential_ttl")
+	}
+
+	if err := validateStoreRetry(conf.Room.StoreRetry); err != nil {
+		return nil, err
+	}
+
+	if conf.Room.MaxMetadataSize < 0 || conf.Room.MaxRosterMetadataSize < 0 {
+		return nil, errors.New("max_metadata_size and max_roster_metadata_size cannot be negative")
+	}
+
+	if err := validateTranscoding(conf.Room.Transcoding); err != nil {
+		return nil, err
+	}
+
+	if err := validateWebHook(conf.WebHook); err != nil {
+		return nil, err
+	}
+
+	if err := validateFanoutDelay(conf.Room.FanoutDelay); err != nil {
+		return nil, err
+	}
+
+	if conf.Room.SessionHistory.Depth < 0 {
+		return nil, errors.New("session_history depth cannot be negative")
+	}
+	if conf.Room.SessionHistory.Depth > 0 && conf.Room.SessionHistory.Retention <= 0 {
+		return nil, errors.New("session_history requires a positive retention")
+	}
+
+	if conf.Room.SubscriptionRestoreWindow < 0 {
+		return nil, errors.New("subscription_restore_window cannot be negative")
+	}
+	if err := validateSubscriptionAllocation(conf.Room.SubscriptionAllocation); err != nil {
+		return nil, err
+	}
+
+	if err := validatePublishFailures(conf.Room.PublishFailures); err != nil {
+		return nil, err
+	}
+
+	if len(conf.Room.Simulcast.TargetBitrates) > 3 {
+		return nil, errors.New("at most 3 simulcast layers are supported")
+	}
+	if err := validateSimulcastFallback(conf.Room.Simulcast.Fallback); err != nil {
+		return nil, err
+	}
+	switch conf.Room.Simulcast.UnknownLayers {
+	case SimulcastUnknownLayersIgnore, SimulcastUnknownLayersReconcile:
+	default:
+		return nil, fmt.Errorf("simulcast unknown_layers must be %s or %s",
+			SimulcastUnknownLayersIgnore, SimulcastUnknownLayersReconcile)
+	}
+
 	// expand env vars in filenames
 	file, err := homedir.Expand(os.ExpandEnv(conf.KeyFile))
 	if err != nil {
@@ -176,6 +1153,501 @@ func NewConfig(confString string, c *cli.Context) (*Config, error) {
 	return conf, nil
 }
 
+func validateBufferLimits(rtc RTCConfig) error {
+	limits := rtc.BufferLimits
+	if limits.TrackBytes < 0 || limits.ParticipantBytes < 0 {
+		return errors.New("buffer_limits cannot be negative")
+	}
+	if limits.TrackBytes > 0 && limits.TrackBytes < MinBufferPackets*BufferSlotSize {
+		return fmt.Errorf("buffer_limits.track_bytes must be at least %d to retransmit lost packets",
+			MinBufferPackets*BufferSlotSize)
+	}
+
+	// participants need to be able to publish at least one video stream
+	trackBytes := rtc.PacketBufferSize * BufferSlotSize
+	if limits.TrackBytes > 0 && limits.TrackBytes < trackBytes {
+		trackBytes = limits.TrackBytes
+	}
+	if limits.ParticipantBytes > 0 && limits.ParticipantBytes < trackBytes {
+		return fmt.Errorf("buffer_limits.participant_bytes must be at least %d, the size of a video stream buffer",
+			trackBytes)
+	}
+	return nil
+}
+
+func validateNegotiation(conf NegotiationConfig) error {
+	if conf.AnswerTimeout < 0 || conf.MaxRetries < 0 || conf.StuckTimeout < 0 {
+		return errors.New("negotiation answer_timeout, max_retries and stuck_timeout cannot be negative")
+	}
+	switch conf.OnTimeout {
+	case NegotiationTimeoutRollback, NegotiationTimeoutDisconnect:
+	default:
+		return fmt.Errorf("negotiation on_timeout must be %s or %s",
+			NegotiationTimeoutRollback, NegotiationTimeoutDisconnect)
+	}
+	switch conf.StuckRecovery {
+	case StuckRecoveryRollback, StuckRecoveryICERestart:
+		return nil
+	default:
+		return fmt.Errorf("negotiation stuck_recovery must be %s or %s",
+			StuckRecoveryRollback, StuckRecoveryICERestart)
+	}
+}
+
+// UsesStableSSRCs returns true when subscribers of the room receive streams on stable SSRCs
+func (conf *RoomConfig) UsesStableSSRCs(roomName string) bool {
+	for _, pattern := range conf.StableSSRCRooms {
+		if matched, _ := path.Match(pattern, roomName); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// AllocationStrategy returns the subscription allocation strategy of the room, empty when none applies
+func (conf *RoomConfig) AllocationStrategy(roomName string) string {
+	for _, rule := range conf.SubscriptionAllocation {
+		if len(rule.Rooms) == 0 {
+			return rule.Strategy
+		}
+		for _, pattern := range rule.Rooms {
+			if matched, _ := path.Match(pattern, roomName); matched {
+				return rule.Strategy
+			}
+		}
+	}
+	return ""
+}
+
+// TranscodingTargets returns the codecs tracks published in the room could be transcoded to, keyed by the
+// lowercase mime type of their codec. nil when transcoding isn't allowed in the room
+func (conf *RoomConfig) TranscodingTargets(roomName string) map[string][]string {
+	var targets map[string][]string
+	for _, rule := range conf.Transcoding {
+		if !rule.appliesTo(roomName) {
+			continue
+		}
+		if targets == nil {
+			targets = make(map[string][]string)
+		}
+		from := strings.ToLower(rule.From)
+		targets[from] = append(targets[from], strings.ToLower(rule.To))
+	}
+	return targets
+}
+
+// DelayFor returns how long newly published tracks of the room wait before they're forwarded, 0 when it isn't
+// delayed
+func (conf *FanoutDelayConfig) DelayFor(roomName string) time.Duration {
+	for _, pattern := range conf.Rooms {
+		if matched, _ := path.Match(pattern, roomName); matched {
+			return conf.Delay
+		}
+	}
+	return 0
+}
+
+// For returns the config of a room, whose action is none when it doesn't apply to the room
+func (conf PublishFailuresConfig) For(roomName string) PublishFailuresConfig {
+	if len(conf.Rooms) == 0 {
+		return conf
+	}
+	for _, pattern := range conf.Rooms {
+		if matched, _ := path.Match(pattern, roomName); matched {
+			return conf
+		}
+	}
+	conf.Action = PublishFailureActionNone
+	return conf
+}
+
+func (rule *TranscodingRule) appliesTo(roomName string) bool {
+	if len(rule.Rooms) == 0 {
+		return true
+	}
+	for _, pattern := range rule.Rooms {
+		if matched, _ := path.Match(pattern, roomName); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// For returns the config of a participant, with the depths of the first override matching its identity
+func (conf JitterBufferConfig) For(identity string) JitterBufferConfig {
+	for _, override := range conf.Participants {
+		for _, pattern := range override.Identities {
+			if matched, _ := path.Match(pattern, identity); matched {
+				return conf.withOverride(override)
+			}
+		}
+	}
+	conf.Participants = nil
+	return conf
+}
+
+func (conf JitterBufferConfig) withOverride(override JitterBufferOverride) JitterBufferConfig {
+	if override.Audio != (JitterBufferDepth{}) {
+		conf.Audio = override.Audio
+	}
+	if override.Video != (JitterBufferDepth{}) {
+		conf.Video = override.Video
+	}
+	conf.Participants = nil
+	return conf
+}
+
+// IsRecorder returns true when the participant records rooms it joins
+func (conf *RecordersConfig) IsRecorder(identity string) bool {
+	for _, pattern := range conf.Identities {
+		if matched, _ := path.Match(pattern, identity); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// KeyframeIntervalFor returns the interval keyframes are requested at for video sent to the participant, 0 when
+// it isn't a recorder or it's disabled
+func (conf *RecordersConfig) KeyframeIntervalFor(identity string) time.Duration {
+	if !conf.IsRecorder(identity) {
+		return 0
+	}
+	return conf.KeyframeInterval
+}
+
+// PinnedVideoQuality returns the quality video the participant receives is pinned to, empty when it isn't a
+// recorder or recorders aren't pinned
+func (conf *RecordersConfig) PinnedVideoQuality(identity string) string {
+	if !conf.IsRecorder(identity) {
+		return ""
+	}
+	return conf.VideoQuality
+}
+
+func ValidateDataPolicy(policy string) error {
+	switch policy {
+	case DataPolicyOpen, DataPolicyHostOnly, DataPolicyModerated:
+		return nil
+	default:
+		return fmt.Errorf("data_policy must be %s, %s or %s", DataPolicyOpen, DataPolicyHostOnly, DataPolicyModerated)
+	}
+}
+
+func validateSpeaking(conf SpeakingConfig) error {
+	if conf.ActiveLevel > 127 {
+		return errors.New("speaking active_level must be between 0 and 127")
+	}
+	if conf.ActivateAfter < 0 || conf.DeactivateAfter < 0 {
+		return errors.New("speaking activate_after and deactivate_after cannot be negative")
+	}
+	return nil
+}
+
+func validateSilentMedia(conf SilentMediaConfig) error {
+	if !conf.Enabled {
+		return nil
+	}
+	if conf.AudioLevel > 127 {
+		return errors.New("silent_media audio_level must be between 0 and 127")
+	}
+	if conf.After <= 0 {
+		return errors.New("silent_media after must be positive")
+	}
+	return nil
+}
+
+func validateKeyframePacing(conf KeyframePacingConfig) error {
+	if !conf.Enabled {
+		return nil
+	}
+	if conf.RateMultiplier <= 1 {
+		return errors.New("keyframe_pacing rate_multiplier must be greater than 1")
+	}
+	if conf.Burst <= 0 || conf.MaxDelay <= 0 {
+		return errors.New("keyframe_pacing burst and max_delay must be positive")
+	}
+	return nil
+}
+
+func validateSubscriberReports(conf SubscriberReportsConfig) error {
+	if !conf.Enabled {
+		return nil
+	}
+	if conf.Aggregation != SubscriberReportsAverage && conf.Aggregation != SubscriberReportsWorst {
+		return fmt.Errorf("subscriber_reports aggregation must be %s or %s",
+			SubscriberReportsAverage, SubscriberReportsWorst)
+	}
+	if conf.MaxAge <= 0 {
+		return errors.New("subscriber_reports max_age must be positive")
+	}
+	return nil
+}
+
+func validateSimulcastFallback(conf SimulcastFallbackConfig) error {
+	switch conf.Policy {
+	case SimulcastFallbackNearest, SimulcastFallbackLower, SimulcastFallbackNone:
+	default:
+		return fmt.Errorf("simulcast fallback policy must be %s, %s or %s",
+			SimulcastFallbackNearest, SimulcastFallbackLower, SimulcastFallbackNone)
+	}
+	if conf.StableFor < 0 {
+		return errors.New("simulcast fallback stable_for cannot be negative")
+	}
+	return nil
+}
+
+func validateRecorders(conf RecordersConfig) error {
+	for _, pattern := range conf.Identities {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid recorders identities pattern %q: %v", pattern, err)
+		}
+	}
+	// keyframes are much larger than other frames, requesting them more often would bloat the stream of every
+	// subscriber of the layer
+	if conf.KeyframeInterval != 0 && conf.KeyframeInterval < time.Second {
+		return errors.New("recorders keyframe_interval must be at least 1s")
+	}
+	switch conf.VideoQuality {
+	case "", "low", "medium", "high":
+		return nil
+	default:
+		return errors.New("recorders video_quality must be low, medium or high")
+	}
+}
+
+func validateStoreRetry(conf StoreRetryConfig) error {
+	if conf.QueueSize < 0 {
+		return errors.New("store_retry queue_size cannot be negative")
+	}
+	if conf.QueueSize == 0 {
+		return nil
+	}
+	if conf.RetryBackoff <= 0 || conf.MaxBackoff < conf.RetryBackoff || conf.MaxAge <= 0 {
+		return errors.New("store_retry requires a positive retry_backoff and max_age, and max_backoff of at least retry_backoff")
+	}
+	return nil
+}
+
+func validateDataRateLimit(conf DataRateLimitConfig) error {
+	for channel, limit := range map[string]DataRateLimit{"reliable": conf.Reliable, "lossy": conf.Lossy} {
+		if limit.ParticipantRate < 0 || limit.RoomRate < 0 || limit.ParticipantBurst < 0 || limit.RoomBurst < 0 {
+			return fmt.Errorf("data_rate_limit %s rates and bursts cannot be negative", channel)
+		}
+		if (limit.ParticipantRate > 0 && limit.ParticipantBurst == 0) || (limit.RoomRate > 0 && limit.RoomBurst == 0) {
+			return fmt.Errorf("data_rate_limit %s needs a burst of at least 1 packet for each rate", channel)
+		}
+	}
+	return nil
+}
+
+func validateICEGathering(conf ICEGatheringConfig) error {
+	if conf.Timeout < 0 {
+		return errors.New("ice_gathering timeout cannot be negative")
+	}
+	if conf.OnTimeout != ICEGatheringRetry && conf.OnTimeout != ICEGatheringClose {
+		return fmt.Errorf("ice_gathering on_timeout must be %s or %s", ICEGatheringRetry, ICEGatheringClose)
+	}
+	if conf.MaxRetries < 0 {
+		return errors.New("ice_gathering max_retries cannot be negative")
+	}
+	return nil
+}
+
+func validatePublisherCongestion(conf PublisherCongestionConfig) error {
+	if !conf.Enabled {
+		return nil
+	}
+	if conf.Threshold <= 0 || conf.Threshold > conf.RecoveryThreshold {
+		return errors.New("publisher_congestion threshold must be positive and at most recovery_threshold")
+	}
+	if conf.CongestedAfter < 0 || conf.RecoveredAfter < 0 {
+		return errors.New("publisher_congestion congested_after and recovered_after cannot be negative")
+	}
+	return nil
+}
+
+func validateJitterBuffer(conf JitterBufferConfig) error {
+	if !conf.Enabled {
+		return nil
+	}
+	depths := []JitterBufferConfig{conf}
+	for _, override := range conf.Participants {
+		for _, pattern := range override.Identities {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("invalid jitter_buffer participants identities pattern %q: %v", pattern, err)
+			}
+		}
+		depths = append(depths, conf.withOverride(override))
+	}
+	for _, d := range depths {
+		for _, depth := range []JitterBufferDepth{d.Audio, d.Video} {
+			if depth.Min < 0 || depth.Min > depth.Max || depth.Max > MaxJitterBufferDelay {
+				return fmt.Errorf("jitter_buffer min must be between 0 and max, and max at most %s", MaxJitterBufferDelay)
+			}
+		}
+		// receivers keep audio in sync by delaying it as long as video, which they can't do past its max
+		if d.Audio.Max < d.Video.Min {
+			return errors.New("jitter_buffer audio max must be at least video min to keep audio in sync with video")
+		}
+	}
+	return nil
+}
+
+func validateSubscriberTelemetry(conf SubscriberTelemetryConfig) error {
+	if !conf.Enabled {
+		return nil
+	}
+	if conf.Interval <= 0 {
+		return errors.New("subscriber_telemetry interval must be positive")
+	}
+	switch conf.Sink {
+	case TelemetrySinkLog, TelemetrySinkMetrics:
+		return nil
+	case TelemetrySinkFile:
+		if conf.File == "" || conf.MaxFileBytes <= 0 {
+			return errors.New("subscriber_telemetry file sink requires file and a positive max_file_bytes")
+		}
+		return nil
+	default:
+		return fmt.Errorf("subscriber_telemetry sink must be %s, %s or %s",
+			TelemetrySinkLog, TelemetrySinkFile, TelemetrySinkMetrics)
+	}
+}
+
+func validateWebHook(conf WebHookConfig) error {
+	if conf.URL == "" {
+		return nil
+	}
+	if u, err := url.Parse(conf.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("webhook url must be an http or https URL, got %q", conf.URL)
+	}
+	if conf.APIKey == "" {
+		return errors.New("webhook requires an api_key to sign events with")
+	}
+	if conf.Timeout <= 0 {
+		return errors.New("webhook timeout must be positive")
+	}
+	if conf.MaxRetries < 0 || conf.RetryBackoff < 0 {
+		return errors.New("webhook max_retries and retry_backoff cannot be negative")
+	}
+	return nil
+}
+
+func validateQualitySampling(conf QualitySamplingConfig) error {
+	if !conf.Enabled {
+		return nil
+	}
+	// each sample walks all tracks of a participant, more often would add up in large rooms
+	if conf.Interval < time.Second {
+		return errors.New("quality_sampling interval must be at least 1s")
+	}
+	switch conf.Sink {
+	case TelemetrySinkLog:
+		return nil
+	case TelemetrySinkFile:
+		if conf.File == "" || conf.MaxFileBytes <= 0 {
+			return errors.New("quality_sampling file sink requires file and a positive max_file_bytes")
+		}
+		return nil
+	default:
+		return fmt.Errorf("quality_sampling sink must be %s or %s", TelemetrySinkLog, TelemetrySinkFile)
+	}
+}
+
+func validateSubscriptionAllocation(rules []SubscriptionAllocationRule) error {
+	for _, rule := range rules {
+		switch rule.Strategy {
+		case AllocationGreedy, AllocationProportionalFair, AllocationPriorityStrict:
+		default:
+			return fmt.Errorf("subscription_allocation strategy must be %s, %s or %s", AllocationGreedy,
+				AllocationProportionalFair, AllocationPriorityStrict)
+		}
+		for _, pattern := range rule.Rooms {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("invalid subscription_allocation rooms pattern %q: %v", pattern, err)
+			}
+		}
+	}
+	return nil
+}
+
+func validatePublishFailures(conf PublishFailuresConfig) error {
+	switch conf.Action {
+	case PublishFailureActionNone, PublishFailureActionNotify, PublishFailureActionSubscribeOnly, PublishFailureActionDisconnect:
+	default:
+		return fmt.Errorf("publish_failures action must be %s, %s, %s or %s", PublishFailureActionNone,
+			PublishFailureActionNotify, PublishFailureActionSubscribeOnly, PublishFailureActionDisconnect)
+	}
+	if conf.Action == PublishFailureActionNone {
+		return nil
+	}
+	if conf.MaxFailures < 1 || conf.Window <= 0 {
+		return errors.New("publish_failures requires a positive max_failures and window")
+	}
+	for _, pattern := range conf.Rooms {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid publish_failures rooms pattern %q: %v", pattern, err)
+		}
+	}
+	return nil
+}
+
+func validateFanoutDelay(conf FanoutDelayConfig) error {
+	// it holds back the first frames of every subscriber, it's meant to be short
+	if conf.Delay < 0 || conf.Delay > 2*time.Second {
+		return errors.New("fanout_delay delay must be between 0 and 2s")
+	}
+	if conf.MinParticipants < 0 {
+		return errors.New("fanout_delay min_participants cannot be negative")
+	}
+	for _, pattern := range conf.Rooms {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid fanout_delay rooms pattern %q: %v", pattern, err)
+		}
+	}
+	return nil
+}
+
+func validateTranscoding(rules []TranscodingRule) error {
+	for _, rule := range rules {
+		from, to := strings.ToLower(rule.From), strings.ToLower(rule.To)
+		fromKind, toKind := strings.Split(from, "/")[0], strings.Split(to, "/")[0]
+		if !strings.Contains(from, "/") || !strings.Contains(to, "/") || (fromKind != "audio" && fromKind != "video") {
+			return fmt.Errorf("transcoding from %q to %q requires audio or video mime types", rule.From, rule.To)
+		}
+		if fromKind != toKind {
+			return fmt.Errorf("transcoding from %s to %s changes the kind of tracks", rule.From, rule.To)
+		}
+		if from == to {
+			return fmt.Errorf("transcoding from %s to itself", rule.From)
+		}
+		for _, pattern := range rule.Rooms {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("invalid transcoding rooms pattern %q: %v", pattern, err)
+			}
+		}
+	}
+	return nil
+}
+
+func validateIPFamilies(families []string) error {
+	if len(families) == 0 {
+		return errors.New("at least one IP family is required")
+	}
+	seen := make(map[string]bool)
+	for _, family := range families {
+		if family != IPFamilyV4 && family != IPFamilyV6 {
+			return fmt.Errorf("unsupported IP family: %s", family)
+		}
+		if seen[family] {
+			return fmt.Errorf("duplicate IP family: %s", family)
+		}
+		seen[family] = true
+	}
+	return nil
+}
+
 func (conf *Config) HasRedis() bool {
 	return conf.Redis.Address != ""
 }
@@ -2,6 +2,7 @@ package config
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -13,3 +14,605 @@ func TestConfig_UnmarshalKeys(t *testing.T) {
 	require.NoError(t, conf.unmarshalKeys("key1: secret1"))
 	require.Equal(t, "secret1", conf.Keys["key1"])
 }
+
+func TestConfig_IPFamilies(t *testing.T) {
+	conf, err := NewConfig("", nil)
+	require.NoError(t, err)
+	require.Equal(t, []string{IPFamilyV4}, conf.RTC.IPFamilies)
+
+	conf, err = NewConfig("rtc:\n  ip_families: [ipv6, ipv4]", nil)
+	require.NoError(t, err)
+	require.Equal(t, []string{IPFamilyV6, IPFamilyV4}, conf.RTC.IPFamilies)
+
+	_, err = NewConfig("rtc:\n  ip_families: [ipv5]", nil)
+	require.Error(t, err)
+
+	_, err = NewConfig("rtc:\n  ip_families: [ipv4, ipv4]", nil)
+	require.Error(t, err)
+}
+
+func TestConfig_BufferLimits(t *testing.T) {
+	_, err := NewConfig("rtc:\n  buffer_limits:\n    track_bytes: 300000\n    participant_bytes: 900000", nil)
+	require.NoError(t, err)
+
+	// too small for NACK
+	_, err = NewConfig("rtc:\n  buffer_limits:\n    track_bytes: 1500", nil)
+	require.Error(t, err)
+
+	// can't fit a single video stream
+	_, err = NewConfig("rtc:\n  buffer_limits:\n    participant_bytes: 300000", nil)
+	require.Error(t, err)
+	_, err = NewConfig("rtc:\n  buffer_limits:\n    track_bytes: 300000\n    participant_bytes: 200000", nil)
+	require.Error(t, err)
+
+	_, err = NewConfig("rtc:\n  buffer_limits:\n    participant_bytes: -1", nil)
+	require.Error(t, err)
+}
+
+func TestConfig_Negotiation(t *testing.T) {
+	conf, err := NewConfig("rtc:\n  negotiation:\n    answer_timeout: 5s\n    on_timeout: disconnect", nil)
+	require.NoError(t, err)
+	require.Equal(t, 5*time.Second, conf.RTC.Negotiation.AnswerTimeout)
+	require.Equal(t, 2, conf.RTC.Negotiation.MaxRetries)
+
+	_, err = NewConfig("rtc:\n  negotiation:\n    on_timeout: ignore", nil)
+	require.Error(t, err)
+
+	conf, err = NewConfig("rtc:\n  negotiation:\n    stuck_timeout: 1m\n    stuck_recovery: ice_restart", nil)
+	require.NoError(t, err)
+	require.Equal(t, time.Minute, conf.RTC.Negotiation.StuckTimeout)
+	require.Equal(t, StuckRecoveryICERestart, conf.RTC.Negotiation.StuckRecovery)
+
+	_, err = NewConfig("rtc:\n  negotiation:\n    stuck_recovery: restart", nil)
+	require.Error(t, err)
+}
+
+func TestConfig_DataPolicy(t *testing.T) {
+	conf, err := NewConfig("", nil)
+	require.NoError(t, err)
+	require.Equal(t, DataPolicyOpen, conf.Room.DataPolicy)
+
+	conf, err = NewConfig("room:\n  data_policy: moderated", nil)
+	require.NoError(t, err)
+	require.Equal(t, DataPolicyModerated, conf.Room.DataPolicy)
+
+	_, err = NewConfig("room:\n  data_policy: hosts", nil)
+	require.Error(t, err)
+}
+
+func TestConfig_StableSSRCRooms(t *testing.T) {
+	conf, err := NewConfig("room:\n  stable_ssrc_rooms: [recorded-*, lobby]", nil)
+	require.NoError(t, err)
+	require.True(t, conf.Room.UsesStableSSRCs("recorded-1"))
+	require.True(t, conf.Room.UsesStableSSRCs("lobby"))
+	require.False(t, conf.Room.UsesStableSSRCs("lobby-2"))
+
+	_, err = NewConfig("room:\n  stable_ssrc_rooms: [\"recorded-[\"]", nil)
+	require.Error(t, err)
+}
+
+func TestConfig_DeviceClasses(t *testing.T) {
+	conf, err := NewConfig("room:\n  device_classes:\n    mobile: low\n    desktop: high", nil)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"mobile": "low", "desktop": "high"}, conf.Room.DeviceClasses)
+
+	_, err = NewConfig("room:\n  device_classes:\n    mobile: lowest", nil)
+	require.Error(t, err)
+}
+
+func TestConfig_KeyframePacing(t *testing.T) {
+	conf, err := NewConfig("rtc:\n  keyframe_pacing:\n    enabled: true\n    max_delay: 200ms", nil)
+	require.NoError(t, err)
+	require.True(t, conf.RTC.KeyframePacing.Enabled)
+	require.Equal(t, 200*time.Millisecond, conf.RTC.KeyframePacing.MaxDelay)
+	require.Equal(t, float64(2), conf.RTC.KeyframePacing.RateMultiplier)
+
+	_, err = NewConfig("rtc:\n  keyframe_pacing:\n    enabled: true\n    rate_multiplier: 1", nil)
+	require.Error(t, err)
+
+	// settings aren't checked while disabled
+	_, err = NewConfig("rtc:\n  keyframe_pacing:\n    rate_multiplier: 1", nil)
+	require.NoError(t, err)
+}
+
+func TestConfig_SubscriberTelemetry(t *testing.T) {
+	conf, err := NewConfig("rtc:\n  subscriber_telemetry:\n    enabled: true", nil)
+	require.NoError(t, err)
+	require.Equal(t, TelemetrySinkLog, conf.RTC.SubscriberTelemetry.Sink)
+	require.Equal(t, time.Second, conf.RTC.SubscriberTelemetry.Interval)
+
+	_, err = NewConfig("rtc:\n  subscriber_telemetry:\n    enabled: true\n    sink: file", nil)
+	require.Error(t, err)
+
+	_, err = NewConfig("rtc:\n  subscriber_telemetry:\n    enabled: true\n    sink: statsd", nil)
+	require.Error(t, err)
+}
+
+func TestConfig_SubscriberReports(t *testing.T) {
+	conf, err := NewConfig("rtc:\n  subscriber_reports:\n    enabled: true", nil)
+	require.NoError(t, err)
+	require.Equal(t, SubscriberReportsAverage, conf.RTC.SubscriberReports.Aggregation)
+	require.Equal(t, 5*time.Second, conf.RTC.SubscriberReports.MaxAge)
+
+	_, err = NewConfig("rtc:\n  subscriber_reports:\n    enabled: true\n    aggregation: median", nil)
+	require.Error(t, err)
+}
+
+func TestConfig_TrackKindMismatch(t *testing.T) {
+	conf, err := NewConfig("", nil)
+	require.NoError(t, err)
+	require.Equal(t, TrackKindMismatchFix, conf.RTC.TrackKindMismatch)
+
+	conf, err = NewConfig("rtc:\n  track_kind_mismatch: reject", nil)
+	require.NoError(t, err)
+	require.Equal(t, TrackKindMismatchReject, conf.RTC.TrackKindMismatch)
+
+	_, err = NewConfig("rtc:\n  track_kind_mismatch: ignore", nil)
+	require.Error(t, err)
+}
+
+func TestConfig_DataRateLimit(t *testing.T) {
+	conf, err := NewConfig("", nil)
+	require.NoError(t, err)
+	require.False(t, conf.Room.DataRateLimit.Enabled())
+
+	conf, err = NewConfig("room:\n  data_rate_limit:\n    lossy:\n      participant_rate: 30\n      participant_burst: 60", nil)
+	require.NoError(t, err)
+	require.True(t, conf.Room.DataRateLimit.Enabled())
+	require.Equal(t, float64(30), conf.Room.DataRateLimit.Lossy.ParticipantRate)
+	require.Equal(t, 60, conf.Room.DataRateLimit.Lossy.ParticipantBurst)
+
+	_, err = NewConfig("room:\n  data_rate_limit:\n    reliable:\n      room_rate: 100", nil)
+	require.Error(t, err)
+}
+
+func TestConfig_RTCPReports(t *testing.T) {
+	conf, err := NewConfig("", nil)
+	require.NoError(t, err)
+	require.Equal(t, 5*time.Second, conf.RTC.SenderReportInterval)
+	require.Equal(t, 20*time.Millisecond, conf.RTC.BindingReportInterval)
+	require.Equal(t, 7, conf.RTC.BindingReportAttempts)
+
+	conf, err = NewConfig("rtc:\n  sender_report_interval: 10s\n  binding_report_attempts: 3", nil)
+	require.NoError(t, err)
+	require.Equal(t, 10*time.Second, conf.RTC.SenderReportInterval)
+	require.Equal(t, 3, conf.RTC.BindingReportAttempts)
+
+	_, err = NewConfig("rtc:\n  sender_report_interval: -1s", nil)
+	require.Error(t, err)
+}
+
+func TestConfig_MaxWarmStandbys(t *testing.T) {
+	conf, err := NewConfig("rtc:\n  max_warm_standbys: 20", nil)
+	require.NoError(t, err)
+	require.Equal(t, 20, conf.RTC.MaxWarmStandbys)
+
+	_, err = NewConfig("rtc:\n  max_warm_standbys: -1", nil)
+	require.Error(t, err)
+}
+
+func TestConfig_QualitySampling(t *testing.T) {
+	conf, err := NewConfig("", nil)
+	require.NoError(t, err)
+	require.False(t, conf.Room.QualitySampling.Enabled)
+	require.Equal(t, 5*time.Second, conf.Room.QualitySampling.Interval)
+
+	_, err = NewConfig("room:\n  quality_sampling:\n    enabled: true\n    interval: 100ms", nil)
+	require.Error(t, err)
+
+	_, err = NewConfig("room:\n  quality_sampling:\n    enabled: true\n    sink: file", nil)
+	require.Error(t, err)
+
+	_, err = NewConfig("room:\n  quality_sampling:\n    enabled: true\n    sink: metrics", nil)
+	require.Error(t, err)
+
+	conf, err = NewConfig("room:\n  quality_sampling:\n    enabled: true\n    sink: file\n    file: quality.log", nil)
+	require.NoError(t, err)
+	require.Equal(t, TelemetrySinkFile, conf.Room.QualitySampling.Sink)
+}
+
+func TestConfig_MinNackWindow(t *testing.T) {
+	conf, err := NewConfig("", nil)
+	require.NoError(t, err)
+	require.Equal(t, 50, conf.RTC.MinNackWindow)
+
+	_, err = NewConfig("rtc:\n  packet_buffer_size: 200\n  min_nack_window: 300", nil)
+	require.Error(t, err)
+
+	_, err = NewConfig("rtc:\n  min_nack_window: 0", nil)
+	require.Error(t, err)
+}
+
+func TestConfig_Speaking(t *testing.T) {
+	conf, err := NewConfig("", nil)
+	require.NoError(t, err)
+	require.Equal(t, uint8(30), conf.RTC.Speaking.ActiveLevel)
+	require.Equal(t, 200*time.Millisecond, conf.RTC.Speaking.ActivateAfter)
+	require.Equal(t, 500*time.Millisecond, conf.RTC.Speaking.DeactivateAfter)
+
+	conf, err = NewConfig("rtc:\n  speaking:\n    active_level: 40\n    deactivate_after: 1s", nil)
+	require.NoError(t, err)
+	require.Equal(t, uint8(40), conf.RTC.Speaking.ActiveLevel)
+	require.Equal(t, 200*time.Millisecond, conf.RTC.Speaking.ActivateAfter)
+	require.Equal(t, time.Second, conf.RTC.Speaking.DeactivateAfter)
+
+	_, err = NewConfig("rtc:\n  speaking:\n    active_level: 200", nil)
+	require.Error(t, err)
+}
+
+func TestConfig_SimulcastFallback(t *testing.T) {
+	conf, err := NewConfig("", nil)
+	require.NoError(t, err)
+	require.Equal(t, SimulcastFallbackNearest, conf.Room.Simulcast.Fallback.Policy)
+	require.Equal(t, 2*time.Second, conf.Room.Simulcast.Fallback.StableFor)
+
+	conf, err = NewConfig("room:\n  simulcast:\n    fallback:\n      policy: lower\n      stable_for: 5s", nil)
+	require.NoError(t, err)
+	require.Equal(t, SimulcastFallbackLower, conf.Room.Simulcast.Fallback.Policy)
+	require.Equal(t, 5*time.Second, conf.Room.Simulcast.Fallback.StableFor)
+	// target bitrates keep their defaults
+	require.Len(t, conf.Room.Simulcast.TargetBitrates, 3)
+
+	_, err = NewConfig("room:\n  simulcast:\n    fallback:\n      policy: higher", nil)
+	require.Error(t, err)
+}
+
+func TestConfig_SimulcastUnknownLayers(t *testing.T) {
+	conf, err := NewConfig("", nil)
+	require.NoError(t, err)
+	require.Equal(t, SimulcastUnknownLayersIgnore, conf.Room.Simulcast.UnknownLayers)
+
+	conf, err = NewConfig("room:\n  simulcast:\n    unknown_layers: reconcile", nil)
+	require.NoError(t, err)
+	require.Equal(t, SimulcastUnknownLayersReconcile, conf.Room.Simulcast.UnknownLayers)
+
+	_, err = NewConfig("room:\n  simulcast:\n    unknown_layers: forward", nil)
+	require.Error(t, err)
+}
+
+func TestConfig_Recorders(t *testing.T) {
+	conf, err := NewConfig("room:\n  recorders:\n    identities: [recorder-*]\n    video_quality: low", nil)
+	require.NoError(t, err)
+	require.True(t, conf.Room.Recorders.IsRecorder("recorder-1"))
+	require.Equal(t, "low", conf.Room.Recorders.PinnedVideoQuality("recorder-1"))
+	require.False(t, conf.Room.Recorders.IsRecorder("alice"))
+	require.Empty(t, conf.Room.Recorders.PinnedVideoQuality("alice"))
+
+	_, err = NewConfig("room:\n  recorders:\n    video_quality: best", nil)
+	require.Error(t, err)
+	_, err = NewConfig("room:\n  recorders:\n    identities: [\"recorder-[\"]", nil)
+	require.Error(t, err)
+
+	conf, err = NewConfig("room:\n  recorders:\n    identities: [recorder-*]\n    keyframe_interval: 10s", nil)
+	require.NoError(t, err)
+	require.Equal(t, 10*time.Second, conf.Room.Recorders.KeyframeIntervalFor("recorder-1"))
+	require.Zero(t, conf.Room.Recorders.KeyframeIntervalFor("alice"))
+	_, err = NewConfig("room:\n  recorders:\n    keyframe_interval: 100ms", nil)
+	require.Error(t, err)
+}
+
+func TestConfig_ICEGathering(t *testing.T) {
+	conf, err := NewConfig("", nil)
+	require.NoError(t, err)
+	require.Zero(t, conf.RTC.ICEGathering.Timeout)
+	require.Equal(t, ICEGatheringRetry, conf.RTC.ICEGathering.OnTimeout)
+	require.Equal(t, 1, conf.RTC.ICEGathering.MaxRetries)
+
+	conf, err = NewConfig("rtc:\n  ice_gathering:\n    timeout: 15s\n    on_timeout: close", nil)
+	require.NoError(t, err)
+	require.Equal(t, 15*time.Second, conf.RTC.ICEGathering.Timeout)
+	require.Equal(t, ICEGatheringClose, conf.RTC.ICEGathering.OnTimeout)
+
+	_, err = NewConfig("rtc:\n  ice_gathering:\n    on_timeout: wait", nil)
+	require.Error(t, err)
+}
+
+func TestConfig_PublisherCongestion(t *testing.T) {
+	conf, err := NewConfig("rtc:\n  publisher_congestion:\n    enabled: true", nil)
+	require.NoError(t, err)
+	require.Equal(t, 0.7, conf.RTC.PublisherCongestion.Threshold)
+	require.Equal(t, 0.9, conf.RTC.PublisherCongestion.RecoveryThreshold)
+	require.Equal(t, 5*time.Second, conf.RTC.PublisherCongestion.CongestedAfter)
+	require.Equal(t, 10*time.Second, conf.RTC.PublisherCongestion.RecoveredAfter)
+
+	// recovering below the congestion threshold would flap
+	_, err = NewConfig("rtc:\n  publisher_congestion:\n    enabled: true\n    threshold: 0.95", nil)
+	require.Error(t, err)
+
+	_, err = NewConfig("rtc:\n  publisher_congestion:\n    enabled: true\n    recovered_after: -1s", nil)
+	require.Error(t, err)
+}
+
+func TestConfig_MaxSubscriptions(t *testing.T) {
+	conf, err := NewConfig("rtc:\n  max_subscriptions: 100", nil)
+	require.NoError(t, err)
+	require.Equal(t, 100, conf.RTC.MaxSubscriptions)
+
+	_, err = NewConfig("rtc:\n  max_subscriptions: -1", nil)
+	require.Error(t, err)
+}
+
+func TestConfig_ReconnectGrace(t *testing.T) {
+	conf, err := NewConfig("rtc:\n  reconnect_grace: 30s", nil)
+	require.NoError(t, err)
+	require.Equal(t, 30*time.Second, conf.RTC.ReconnectGrace)
+	require.Equal(t, 2*time.Minute, conf.RTC.MaxReconnectGrace)
+
+	_, err = NewConfig("rtc:\n  reconnect_grace: 5m", nil)
+	require.Error(t, err)
+}
+
+func TestConfig_StoreRetry(t *testing.T) {
+	conf, err := NewConfig("", nil)
+	require.NoError(t, err)
+	require.Equal(t, 1000, conf.Room.StoreRetry.QueueSize)
+
+	_, err = NewConfig("room:\n  store_retry:\n    queue_size: 0\n    retry_backoff: 0s", nil)
+	require.NoError(t, err)
+
+	_, err = NewConfig("room:\n  store_retry:\n    retry_backoff: 20s", nil)
+	require.Error(t, err)
+}
+
+func TestConfig_DownloadBudgetPolicy(t *testing.T) {
+	conf, err := NewConfig("", nil)
+	require.NoError(t, err)
+	require.Equal(t, DownloadBudgetAudioOnly, conf.RTC.DownloadBudgetPolicy)
+
+	conf, err = NewConfig("rtc:\n  max_download_bitrate: 2000000\n  download_budget_policy: reject", nil)
+	require.NoError(t, err)
+	require.Equal(t, uint64(2_000_000), conf.RTC.MaxDownloadBitrate)
+	require.Equal(t, DownloadBudgetReject, conf.RTC.DownloadBudgetPolicy)
+
+	_, err = NewConfig("rtc:\n  download_budget_policy: drop", nil)
+	require.Error(t, err)
+}
+
+func TestConfig_MaxMetadataSize(t *testing.T) {
+	conf, err := NewConfig("room:\n  max_metadata_size: 4096\n  max_roster_metadata_size: 65536", nil)
+	require.NoError(t, err)
+	require.Equal(t, 4096, conf.Room.MaxMetadataSize)
+	require.Equal(t, 65536, conf.Room.MaxRosterMetadataSize)
+
+	_, err = NewConfig("room:\n  max_metadata_size: -1", nil)
+	require.Error(t, err)
+}
+
+func TestConfig_Transcoding(t *testing.T) {
+	conf, err := NewConfig(`room:
+  transcoding:
+    - from: video/VP8
+      to: video/H264
+      rooms: [interop-*]
+    - from: video/VP8
+      to: video/VP9
+`, nil)
+	require.NoError(t, err)
+	require.Equal(t, map[string][]string{"video/vp8": {"video/h264", "video/vp9"}}, conf.Room.TranscodingTargets("interop-1"))
+	require.Equal(t, map[string][]string{"video/vp8": {"video/vp9"}}, conf.Room.TranscodingTargets("other"))
+
+	conf, err = NewConfig("", nil)
+	require.NoError(t, err)
+	require.Nil(t, conf.Room.TranscodingTargets("other"))
+
+	_, err = NewConfig("room:\n  transcoding:\n    - from: video/VP8\n      to: audio/opus", nil)
+	require.Error(t, err)
+
+	_, err = NewConfig("room:\n  transcoding:\n    - from: video/VP8\n      to: video/vp8", nil)
+	require.Error(t, err)
+}
+
+func TestConfig_RedisStoreFormat(t *testing.T) {
+	conf, err := NewConfig("", nil)
+	require.NoError(t, err)
+	require.Equal(t, StoreFormatProtobuf, conf.Redis.StoreFormat)
+
+	conf, err = NewConfig("redis:\n  store_format: json", nil)
+	require.NoError(t, err)
+	require.Equal(t, StoreFormatJSON, conf.Redis.StoreFormat)
+
+	_, err = NewConfig("redis:\n  store_format: yaml", nil)
+	require.Error(t, err)
+}
+
+func TestConfig_SilentMedia(t *testing.T) {
+	conf, err := NewConfig("rtc:\n  silent_media:\n    enabled: true", nil)
+	require.NoError(t, err)
+	require.Equal(t, 10*time.Second, conf.RTC.SilentMedia.After)
+
+	_, err = NewConfig("rtc:\n  silent_media:\n    enabled: true\n    audio_level: 200", nil)
+	require.Error(t, err)
+
+	_, err = NewConfig("rtc:\n  silent_media:\n    enabled: true\n    after: 0s", nil)
+	require.Error(t, err)
+}
+
+func TestConfig_FanoutDelay(t *testing.T) {
+	conf, err := NewConfig("room:\n  fanout_delay:\n    delay: 300ms\n    rooms: [webinar-*]", nil)
+	require.NoError(t, err)
+	require.Equal(t, 10, conf.Room.FanoutDelay.MinParticipants)
+	require.Equal(t, 300*time.Millisecond, conf.Room.FanoutDelay.DelayFor("webinar-1"))
+	require.Zero(t, conf.Room.FanoutDelay.DelayFor("standup"))
+
+	_, err = NewConfig("room:\n  fanout_delay:\n    delay: 5s", nil)
+	require.Error(t, err)
+}
+
+func TestConfig_TURNURLs(t *testing.T) {
+	conf, err := NewConfig("turn:\n  urls: [\"turn:turn.example.com:3478\"]\n  secret: secret", nil)
+	require.NoError(t, err)
+	require.Equal(t, 24*time.Hour, conf.TURN.CredentialTTL)
+
+	_, err = NewConfig("turn:\n  urls: [\"turn:turn.example.com:3478\"]", nil)
+	require.Error(t, err)
+}
+
+func TestConfig_SessionHistory(t *testing.T) {
+	conf, err := NewConfig("room:\n  session_history:\n    depth: 100", nil)
+	require.NoError(t, err)
+	require.Equal(t, 100, conf.Room.SessionHistory.Depth)
+	require.Equal(t, 24*time.Hour, conf.Room.SessionHistory.Retention)
+
+	_, err = NewConfig("room:\n  session_history:\n    depth: 100\n    retention: 0s", nil)
+	require.Error(t, err)
+
+	_, err = NewConfig("room:\n  session_history:\n    depth: -1", nil)
+	require.Error(t, err)
+}
+
+func TestConfig_SubscriptionRestoreWindow(t *testing.T) {
+	conf, err := NewConfig("", nil)
+	require.NoError(t, err)
+	require.Equal(t, 30*time.Second, conf.Room.SubscriptionRestoreWindow)
+
+	_, err = NewConfig("room:\n  subscription_restore_window: -1s", nil)
+	require.Error(t, err)
+}
+
+func TestConfig_SubscriptionAllocation(t *testing.T) {
+	conf, err := NewConfig(`room:
+  subscription_allocation:
+    - strategy: priority_strict
+      rooms: [webinar-*]
+    - strategy: greedy`, nil)
+	require.NoError(t, err)
+	require.Equal(t, AllocationPriorityStrict, conf.Room.AllocationStrategy("webinar-1"))
+	require.Equal(t, AllocationGreedy, conf.Room.AllocationStrategy("meeting"))
+
+	conf, err = NewConfig("", nil)
+	require.NoError(t, err)
+	require.Empty(t, conf.Room.AllocationStrategy("meeting"))
+
+	_, err = NewConfig("room:\n  subscription_allocation:\n    - strategy: fastest", nil)
+	require.Error(t, err)
+	_, err = NewConfig("room:\n  subscription_allocation:\n    - strategy: greedy\n      rooms: ['[']", nil)
+	require.Error(t, err)
+}
+
+func TestConfig_BandwidthAdaptation(t *testing.T) {
+	conf, err := NewConfig("rtc:\n  bandwidth_adaptation:\n    enabled: true", nil)
+	require.NoError(t, err)
+	require.Equal(t, 0.25, conf.RTC.BandwidthAdaptation.UpgradeHeadroom)
+	require.Equal(t, 5*time.Second, conf.RTC.BandwidthAdaptation.UpgradeHold)
+
+	_, err = NewConfig("rtc:\n  bandwidth_adaptation:\n    upgrade_hold: -1s", nil)
+	require.Error(t, err)
+}
+
+func TestConfig_WebHook(t *testing.T) {
+	conf, err := NewConfig("webhook:\n  url: https://example.com/hook\n  api_key: key1", nil)
+	require.NoError(t, err)
+	require.Equal(t, 5*time.Second, conf.WebHook.Timeout)
+	require.Equal(t, 3, conf.WebHook.MaxRetries)
+
+	_, err = NewConfig("webhook:\n  url: https://example.com/hook", nil)
+	require.Error(t, err)
+
+	_, err = NewConfig("webhook:\n  url: example.com/hook\n  api_key: key1", nil)
+	require.Error(t, err)
+
+	_, err = NewConfig("webhook:\n  url: https://example.com/hook\n  api_key: key1\n  max_retries: -1", nil)
+	require.Error(t, err)
+}
+
+func TestConfig_RejectedSubscriptions(t *testing.T) {
+	conf, err := NewConfig("", nil)
+	require.NoError(t, err)
+	require.Equal(t, RejectedSubscriptionsRemove, conf.RTC.RejectedSubscriptions)
+
+	conf, err = NewConfig("rtc:\n  rejected_subscriptions: keep", nil)
+	require.NoError(t, err)
+	require.Equal(t, RejectedSubscriptionsKeep, conf.RTC.RejectedSubscriptions)
+
+	_, err = NewConfig("rtc:\n  rejected_subscriptions: retry", nil)
+	require.Error(t, err)
+}
+
+func TestConfig_PublishFailures(t *testing.T) {
+	conf, err := NewConfig("", nil)
+	require.NoError(t, err)
+	require.Equal(t, PublishFailureActionNone, conf.Room.PublishFailures.Action)
+
+	conf, err = NewConfig("room:\n  publish_failures:\n    action: subscribe_only\n    rooms: [classroom-*]", nil)
+	require.NoError(t, err)
+	require.Equal(t, PublishFailureActionSubscribeOnly, conf.Room.PublishFailures.For("classroom-1").Action)
+	require.Equal(t, 3, conf.Room.PublishFailures.For("classroom-1").MaxFailures)
+	require.Equal(t, PublishFailureActionNone, conf.Room.PublishFailures.For("standup").Action)
+
+	_, err = NewConfig("room:\n  publish_failures:\n    action: kick", nil)
+	require.Error(t, err)
+
+	_, err = NewConfig("room:\n  publish_failures:\n    action: notify\n    max_failures: 0", nil)
+	require.Error(t, err)
+}
+
+func TestConfig_ReorderBuffer(t *testing.T) {
+	conf, err := NewConfig("rtc:\n  reorder_buffer:\n    enabled: true\n    screen_share: 200ms", nil)
+	require.NoError(t, err)
+	require.Equal(t, 200*time.Millisecond, conf.RTC.ReorderBuffer.ScreenShare)
+	require.Equal(t, 20*time.Millisecond, conf.RTC.ReorderBuffer.Camera)
+	require.Equal(t, 100, conf.RTC.ReorderBuffer.MaxPackets)
+
+	_, err = NewConfig("rtc:\n  reorder_buffer:\n    enabled: true\n    camera: -1ms", nil)
+	require.Error(t, err)
+	_, err = NewConfig("rtc:\n  reorder_buffer:\n    enabled: true\n    max_packets: 0", nil)
+	require.Error(t, err)
+}
+
+func TestConfig_RTCPQueueSize(t *testing.T) {
+	conf, err := NewConfig("", nil)
+	require.NoError(t, err)
+	require.Equal(t, 50, conf.RTC.RTCPQueueSize)
+
+	conf, err = NewConfig("rtc:\n  rtcp_queue_size: 200", nil)
+	require.NoError(t, err)
+	require.Equal(t, 200, conf.RTC.RTCPQueueSize)
+
+	_, err = NewConfig("rtc:\n  rtcp_queue_size: -1", nil)
+	require.Error(t, err)
+}
+
+func TestConfig_JitterBuffer(t *testing.T) {
+	conf, err := NewConfig(`rtc:
+  jitter_buffer:
+    enabled: true
+    participants:
+      - identities: ["recorder-*"]
+        video:
+          min: 500ms
+          max: 2s
+        audio:
+          max: 1s
+      - identities: ["mobile-*"]
+        video:
+          max: 200ms`, nil)
+	require.NoError(t, err)
+	recorder := conf.RTC.JitterBuffer.For("recorder-1")
+	require.Equal(t, JitterBufferDepth{Min: 500 * time.Millisecond, Max: 2 * time.Second}, recorder.Video)
+	require.Equal(t, JitterBufferDepth{Max: time.Second}, recorder.Audio)
+	require.Empty(t, recorder.Participants)
+	mobile := conf.RTC.JitterBuffer.For("mobile-1")
+	require.Equal(t, JitterBufferDepth{Max: 200 * time.Millisecond}, mobile.Video)
+	require.Equal(t, JitterBufferDepth{Max: 100 * time.Millisecond}, mobile.Audio)
+	require.Equal(t, JitterBufferDepth{Min: 50 * time.Millisecond, Max: 400 * time.Millisecond},
+		conf.RTC.JitterBuffer.For("viewer").Video)
+
+	// audio couldn't be delayed as long as video
+	_, err = NewConfig(`rtc:
+  jitter_buffer:
+    enabled: true
+    participants:
+      - identities: ["recorder-*"]
+        video:
+          min: 500ms
+          max: 2s
+        audio:
+          max: 200ms`, nil)
+	require.Error(t, err)
+
+	_, err = NewConfig("rtc:\n  jitter_buffer:\n    enabled: true\n    video:\n      min: 1s\n      max: 500ms", nil)
+	require.Error(t, err)
+
+	_, err = NewConfig("rtc:\n  jitter_buffer:\n    enabled: true\n    video:\n      max: 1m", nil)
+	require.Error(t, err)
+}
@@ -1,6 +1,9 @@
 package logger
 
 import (
+	"sync/atomic"
+	"time"
+
 	"github.com/go-logr/logr"
 	"github.com/go-logr/zapr"
 	"github.com/pion/ion-sfu/pkg/buffer"
@@ -13,6 +16,8 @@ import (
 var (
 	// pion/ion-sfu
 	defaultLogger = logr.Discard()
+	// logs debug messages regardless of the log level, for scopes being debugged
+	verboseLogger = logr.Discard()
 	// pion/webrtc, pion/turn
 	defaultFactory logging.LoggerFactory
 )
@@ -31,6 +36,12 @@ func SetLogger(l logr.Logger) {
 	buffer.Logger = sfu.Logger
 
 	defaultLogger = l.WithName("livekit").WithCallDepth(1)
+	verboseLogger = defaultLogger
+}
+
+// setVerboseLogger sets the logger used for debug messages of scopes being debugged, with default depth
+func setVerboseLogger(l logr.Logger) {
+	verboseLogger = l.WithName("livekit").WithCallDepth(1)
 }
 
 func LoggerFactory() logging.LoggerFactory {
@@ -55,6 +66,11 @@ func initLogger(config zap.Config, level string) {
 
 	logger, _ := config.Build()
 	SetLogger(zapr.NewLogger(logger))
+
+	config.Level = zap.NewAtomicLevelAt(zapcore.DebugLevel)
+	if verbose, err := config.Build(); err == nil {
+		setVerboseLogger(zapr.NewLogger(verbose))
+	}
 }
 
 func InitProduction(logLevel string) {
@@ -83,3 +99,40 @@ func Warnw(msg string, err error, keysAndValues ...interface{}) {
 func Errorw(msg string, err error, keysAndValues ...interface{}) {
 	defaultLogger.Error(err, msg, keysAndValues...)
 }
+
+// Scope logs debug messages of a single participant, or anything else worth debugging on its own. While it's
+// debugged they're logged regardless of the log level, until it reverts on its own
+type Scope struct {
+	// unix nanos debugging stops at, 0 when not debugged
+	until int64
+}
+
+// Debug logs debug messages of the scope regardless of the log level for d, 0 to stop right away
+func (s *Scope) Debug(d time.Duration) {
+	if d <= 0 {
+		atomic.StoreInt64(&s.until, 0)
+		return
+	}
+	atomic.StoreInt64(&s.until, time.Now().Add(d).UnixNano())
+}
+
+// IsDebugged returns true while debug messages of the scope are logged regardless of the log level
+func (s *Scope) IsDebugged() bool {
+	until := atomic.LoadInt64(&s.until)
+	if until == 0 {
+		return false
+	}
+	if time.Now().UnixNano() < until {
+		return true
+	}
+	atomic.CompareAndSwapInt64(&s.until, until, 0)
+	return false
+}
+
+func (s *Scope) Debugw(msg string, keysAndValues ...interface{}) {
+	if s != nil && s.IsDebugged() {
+		verboseLogger.V(1).Info(msg, keysAndValues...)
+		return
+	}
+	defaultLogger.V(1).Info(msg, keysAndValues...)
+}
@@ -0,0 +1,26 @@
+package logger
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestScope(t *testing.T) {
+	var s Scope
+	require.False(t, s.IsDebugged())
+
+	s.Debug(time.Hour)
+	require.True(t, s.IsDebugged())
+	s.Debug(0)
+	require.False(t, s.IsDebugged())
+
+	t.Run("reverts on its own", func(t *testing.T) {
+		s.Debug(10 * time.Millisecond)
+		require.True(t, s.IsDebugged())
+		require.Eventually(t, func() bool {
+			return !s.IsDebugged()
+		}, time.Second, time.Millisecond)
+	})
+}
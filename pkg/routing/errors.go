@@ -12,4 +12,8 @@ var (
 	errInvalidRouterMessage = errors.New("invalid router message")
 	ErrChannelClosed        = errors.New("channel closed")
 	ErrChannelFull          = errors.New("channel is full")
+	ErrRoomOperationTimeout = errors.New("timed out waiting for room operation")
+	// returned by handlers of broadcast operations on nodes that don't have what the operation is for,
+	// e.g. a participant connected to another node
+	ErrRoomOperationSkipped = errors.New("room operation skipped")
 )
@@ -1,6 +1,10 @@
 package routing
 
 import (
+	"context"
+	"encoding/json"
+	"time"
+
 	"google.golang.org/protobuf/proto"
 
 	livekit "github.com/livekit/livekit-server/proto"
@@ -10,6 +14,7 @@ import (
 
 // MessageSink is an abstraction for writing protobuf messages and having them read by a MessageSource,
 // potentially on a different node via a transport
+//
 //counterfeiter:generate . MessageSink
 type MessageSink interface {
 	WriteMessage(msg proto.Message) error
@@ -31,12 +36,31 @@ type ParticipantInit struct {
 	ProtocolVersion int32
 	UsePlanB        bool
 	AutoSubscribe   bool
+	// participant has the roomAdmin grant for the room. StartSession has no field for it,
+	// so it's only set when the session is started by the local router
+	Host bool
+	// grace period requested by the client, 0 to use the server's. Only set with the local router
+	ReconnectGrace time.Duration
+	// preferred language of captions, set with the language connection parameter. Only set with the local router
+	Language string
+	// class of device the client is on, set with the device_class connection parameter. Only set with the local router
+	DeviceClass string
+	// packets subscribed streams could be retransmitted for, set with the nack_window connection parameter.
+	// 0 for the server's. Only set with the local router
+	NackWindow int
+	// receive a mix of the loudest speakers rather than a track per speaker, set with the mixed_audio connection
+	// parameter. Only set with the local router
+	MixedAudio bool
+	// lowercase mime types of codecs the client could decode, set with the codecs connection parameter.
+	// empty when it decodes any enabled codec. Only set with the local router
+	Codecs []string
 }
 
 type NewParticipantCallback func(roomName string, pi ParticipantInit, requestSource MessageSource, responseSink MessageSink)
 type RTCMessageCallback func(roomName, identity string, msg *livekit.RTCNodeMessage)
 
 // Router allows multiple nodes to coordinate the participant session
+//
 //counterfeiter:generate . Router
 type Router interface {
 	GetNodeForRoom(roomName string) (*livekit.Node, error)
@@ -60,11 +84,19 @@ type Router interface {
 	// OnRTCMessage is called to execute actions on the RTC node
 	OnRTCMessage(callback RTCMessageCallback)
 
+	// ExecuteRoomOperation carries out op on the node hosting its room, and returns its result
+	ExecuteRoomOperation(ctx context.Context, op *RoomOperation) (json.RawMessage, error)
+	// BroadcastRoomOperation carries out op on every node, and returns results of the nodes that didn't skip it
+	BroadcastRoomOperation(ctx context.Context, op *RoomOperation) ([]json.RawMessage, error)
+	// OnRoomOperation sets the handler carrying out operations named op on this node
+	OnRoomOperation(op string, handler RoomOperationHandler)
+
 	Start() error
 	Stop()
 }
 
 // NodeSelector selects an appropriate node to run the current session
+//
 //counterfeiter:generate . NodeSelector
 type NodeSelector interface {
 	SelectNode(nodes []*livekit.Node, room *livekit.Room) (*livekit.Node, error)
@@ -1,6 +1,8 @@
 package routing
 
 import (
+	"context"
+	"encoding/json"
 	"sync"
 	"time"
 
@@ -24,6 +26,7 @@ type LocalRouter struct {
 
 	onNewParticipant NewParticipantCallback
 	onRTCMessage     RTCMessageCallback
+	roomOpHandlers   map[string]RoomOperationHandler
 }
 
 func NewLocalRouter(currentNode LocalNode) *LocalRouter {
@@ -32,6 +35,7 @@ func NewLocalRouter(currentNode LocalNode) *LocalRouter {
 		requestChannels:  make(map[string]*MessageChannel),
 		responseChannels: make(map[string]*MessageChannel),
 		rtcMessageChan:   NewMessageChannel(),
+		roomOpHandlers:   make(map[string]RoomOperationHandler),
 	}
 }
 
@@ -83,10 +87,11 @@ func (r *LocalRouter) StartParticipantSignal(roomName string, pi ParticipantInit
 		return
 	}
 
-	// index channels by roomName | identity
-	key := participantKey(roomName, pi.Identity)
-	reqChan := r.getOrCreateMessageChannel(r.requestChannels, key)
-	resChan := r.getOrCreateMessageChannel(r.responseChannels, key)
+	// index channels by connection, so that messages from a superseded connection do not mix
+	// with the ones from the connection a participant has resumed on
+	connectionId = utils.NewGuid("CO_")
+	reqChan := r.getOrCreateMessageChannel(r.requestChannels, connectionId)
+	resChan := r.getOrCreateMessageChannel(r.responseChannels, connectionId)
 
 	r.onNewParticipant(
 		roomName,
@@ -96,7 +101,7 @@ func (r *LocalRouter) StartParticipantSignal(roomName string, pi ParticipantInit
 		// response sink
 		resChan,
 	)
-	return pi.Identity, reqChan, resChan, nil
+	return connectionId, reqChan, resChan, nil
 }
 
 func (r *LocalRouter) CreateRTCSink(roomName, identity string) (MessageSink, error) {
@@ -115,6 +120,26 @@ func (r *LocalRouter) OnRTCMessage(callback RTCMessageCallback) {
 	r.onRTCMessage = callback
 }
 
+func (r *LocalRouter) ExecuteRoomOperation(ctx context.Context, op *RoomOperation) (json.RawMessage, error) {
+	return r.handleRoomOperation(op)
+}
+
+func (r *LocalRouter) BroadcastRoomOperation(ctx context.Context, op *RoomOperation) ([]json.RawMessage, error) {
+	result, err := r.handleRoomOperation(op)
+	if err == ErrRoomOperationSkipped {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return []json.RawMessage{result}, nil
+}
+
+func (r *LocalRouter) OnRoomOperation(op string, handler RoomOperationHandler) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.roomOpHandlers[op] = handler
+}
+
 func (r *LocalRouter) Start() error {
 	if !r.isStarted.TrySet(true) {
 		return nil
@@ -173,6 +198,21 @@ func (r *LocalRouter) rtcMessageWorker() {
 	}
 }
 
+// handleRoomOperation carries out op on this node, and encodes its result
+func (r *LocalRouter) handleRoomOperation(op *RoomOperation) (json.RawMessage, error) {
+	r.lock.RLock()
+	handler := r.roomOpHandlers[op.Op]
+	r.lock.RUnlock()
+	if handler == nil {
+		return nil, ErrHandlerNotDefined
+	}
+	result, err := handler(op)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(result)
+}
+
 func (r *LocalRouter) getOrCreateMessageChannel(target map[string]*MessageChannel, key string) *MessageChannel {
 	r.lock.Lock()
 	defer r.lock.Unlock()
@@ -185,7 +225,10 @@ func (r *LocalRouter) getOrCreateMessageChannel(target map[string]*MessageChanne
 	mc = NewMessageChannel()
 	mc.OnClose(func() {
 		r.lock.Lock()
-		delete(target, key)
+		// channel could have been replaced
+		if target[key] == mc {
+			delete(target, key)
+		}
 		r.lock.Unlock()
 	})
 	target[key] = mc
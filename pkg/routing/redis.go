@@ -38,6 +38,17 @@ func signalNodeChannel(nodeId string) string {
 	return "signal_channel:" + nodeId
 }
 
+func roomOperationChannel(nodeId string) string {
+	return "room_op_channel:" + nodeId
+}
+
+func roomOperationReplyChannel(nodeId string) string {
+	return "room_op_reply_channel:" + nodeId
+}
+
+// operations carried out on every node
+const roomOperationBroadcastChannel = "room_op_broadcast"
+
 func publishRTCMessage(rc *redis.Client, nodeId string, participantKey string, msg proto.Message) error {
 	rm := &livekit.RTCNodeMessage{
 		ParticipantKey: participantKey,
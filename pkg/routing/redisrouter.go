@@ -2,6 +2,8 @@ package routing
 
 import (
 	"context"
+	"encoding/json"
+	"sync"
 	"time"
 
 	"github.com/go-redis/redis/v8"
@@ -32,15 +34,25 @@ type RedisRouter struct {
 	// map of connectionId => SignalNodeSink
 	signalSinks map[string]*SignalNodeSink
 
+	// room operations waiting for replies, by operation id
+	opLock     sync.Mutex
+	pendingOps map[string]*pendingRoomOperation
+
 	pubsub *redis.PubSub
 	cancel func()
 }
 
+type pendingRoomOperation struct {
+	replies  []*roomOperationReply
+	received chan struct{}
+}
+
 func NewRedisRouter(currentNode LocalNode, rc *redis.Client) *RedisRouter {
 	rr := &RedisRouter{
 		LocalRouter: *NewLocalRouter(currentNode),
 		rc:          rc,
 		signalSinks: make(map[string]*SignalNodeSink),
+		pendingOps:  make(map[string]*pendingRoomOperation),
 	}
 	rr.ctx, rr.cancel = context.WithCancel(context.Background())
 	return rr
@@ -209,16 +221,16 @@ func (r *RedisRouter) startParticipantRTC(ss *livekit.StartSession, participantK
 		return ErrHandlerNotDefined
 	}
 
-	if !ss.Reconnect {
-		// when it's not reconnecting, we do not want to re-use the same response sink
-		// the previous rtc worker thread is still consuming off of it.
-		// we'll want to sever the connection and switch to the new one
-		r.lock.RLock()
-		requestChan, ok := r.requestChannels[participantKey]
-		r.lock.RUnlock()
-		if ok {
-			requestChan.Close()
-		}
+	// the previous rtc worker thread is still consuming off of the existing request channel,
+	// start the new connection on a fresh one
+	r.lock.Lock()
+	requestChan, ok := r.requestChannels[participantKey]
+	delete(r.requestChannels, participantKey)
+	r.lock.Unlock()
+	if ok && !ss.Reconnect {
+		// when it's not reconnecting, we'll want to sever the connection and switch to the new one.
+		// when reconnecting, the previous worker exits once the participant is bound to the new connection
+		requestChan.Close()
 	}
 
 	pi := ParticipantInit{
@@ -242,6 +254,119 @@ func (r *RedisRouter) startParticipantRTC(ss *livekit.StartSession, participantK
 	return nil
 }
 
+func (r *RedisRouter) ExecuteRoomOperation(ctx context.Context, op *RoomOperation) (json.RawMessage, error) {
+	node, err := r.GetNodeForRoom(op.Room)
+	if err != nil {
+		return nil, err
+	}
+	if node.Id == r.currentNode.Id {
+		return r.handleRoomOperation(op)
+	}
+
+	replies, err := r.requestRoomOperation(ctx, roomOperationChannel(node.Id), op)
+	if err != nil {
+		return nil, err
+	}
+	if len(replies) == 0 {
+		return nil, ErrNodeNotFound
+	}
+	return replies[0].result()
+}
+
+func (r *RedisRouter) BroadcastRoomOperation(ctx context.Context, op *RoomOperation) ([]json.RawMessage, error) {
+	replies, err := r.requestRoomOperation(ctx, roomOperationBroadcastChannel, op)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]json.RawMessage, 0, len(replies))
+	for _, reply := range replies {
+		result, err := reply.result()
+		if err == ErrRoomOperationSkipped {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// requestRoomOperation publishes op to channel, and waits for replies of every node subscribed to it
+func (r *RedisRouter) requestRoomOperation(ctx context.Context, channel string, op *RoomOperation) ([]*roomOperationReply, error) {
+	req := &roomOperationRequest{
+		ID:        utils.NewGuid("RO_"),
+		ReplyNode: r.currentNode.Id,
+		Operation: op,
+	}
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	pending := &pendingRoomOperation{
+		received: make(chan struct{}, 1),
+	}
+	r.opLock.Lock()
+	r.pendingOps[req.ID] = pending
+	r.opLock.Unlock()
+	defer func() {
+		r.opLock.Lock()
+		delete(r.pendingOps, req.ID)
+		r.opLock.Unlock()
+	}()
+
+	// number of nodes that received it
+	numNodes, err := r.rc.Publish(r.ctx, channel, data).Result()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not publish room operation")
+	}
+
+	timeout := time.After(roomOperationTimeout)
+	for {
+		r.opLock.Lock()
+		replies := pending.replies
+		r.opLock.Unlock()
+		if len(replies) >= int(numNodes) {
+			return replies, nil
+		}
+
+		select {
+		case <-pending.received:
+		case <-timeout:
+			return nil, ErrRoomOperationTimeout
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (r *RedisRouter) handleRoomOperationRequest(req *roomOperationRequest) {
+	result, err := r.handleRoomOperation(req.Operation)
+	data, err := json.Marshal(newRoomOperationReply(req.ID, result, err))
+	if err != nil {
+		logger.Errorw("could not marshal room operation reply", err)
+		return
+	}
+	if err := r.rc.Publish(r.ctx, roomOperationReplyChannel(req.ReplyNode), data).Err(); err != nil {
+		logger.Errorw("could not publish room operation reply", err, "node", req.ReplyNode)
+	}
+}
+
+func (r *RedisRouter) handleRoomOperationReply(reply *roomOperationReply) {
+	r.opLock.Lock()
+	defer r.opLock.Unlock()
+	pending := r.pendingOps[reply.ID]
+	if pending == nil {
+		// timed out
+		return
+	}
+	pending.replies = append(pending.replies, reply)
+	select {
+	case pending.received <- struct{}{}:
+	default:
+	}
+}
+
 func (r *RedisRouter) Start() error {
 	if !r.isStarted.TrySet(true) {
 		return nil
@@ -326,7 +451,9 @@ func (r *RedisRouter) redisWorker(startedChan chan struct{}) {
 
 	sigChannel := signalNodeChannel(r.currentNode.Id)
 	rtcChannel := rtcNodeChannel(r.currentNode.Id)
-	r.pubsub = r.rc.Subscribe(r.ctx, sigChannel, rtcChannel)
+	opChannel := roomOperationChannel(r.currentNode.Id)
+	opReplyChannel := roomOperationReplyChannel(r.currentNode.Id)
+	r.pubsub = r.rc.Subscribe(r.ctx, sigChannel, rtcChannel, opChannel, roomOperationBroadcastChannel, opReplyChannel)
 
 	close(startedChan)
 	for msg := range r.pubsub.Channel() {
@@ -354,6 +481,21 @@ func (r *RedisRouter) redisWorker(startedChan chan struct{}) {
 				logger.Errorw("error processing RTC message", err)
 				continue
 			}
+		} else if msg.Channel == opChannel || msg.Channel == roomOperationBroadcastChannel {
+			req := roomOperationRequest{}
+			if err := json.Unmarshal([]byte(msg.Payload), &req); err != nil || req.Operation == nil {
+				logger.Errorw("could not unmarshal room operation", err)
+				continue
+			}
+			// replies are consumed by this worker as well
+			go r.handleRoomOperationRequest(&req)
+		} else if msg.Channel == opReplyChannel {
+			reply := roomOperationReply{}
+			if err := json.Unmarshal([]byte(msg.Payload), &reply); err != nil {
+				logger.Errorw("could not unmarshal room operation reply", err)
+				continue
+			}
+			r.handleRoomOperationReply(&reply)
 		}
 	}
 }
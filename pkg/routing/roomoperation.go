@@ -0,0 +1,93 @@
+package routing
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// time to wait for nodes to carry out a room operation
+const roomOperationTimeout = 5 * time.Second
+
+// RoomOperation is carried out on a room, or on a participant in it, by the node hosting the room. Operations on
+// state kept by the node participants are connected to are broadcast to every node.
+// The protocol has no RTCNodeMessage for them, nodes exchange them as JSON
+type RoomOperation struct {
+	Op       string          `json:"op"`
+	Room     string          `json:"room"`
+	Identity string          `json:"identity,omitempty"`
+	Params   json.RawMessage `json:"params,omitempty"`
+}
+
+// NewRoomOperation encodes params of an operation
+func NewRoomOperation(op, room, identity string, params interface{}) (*RoomOperation, error) {
+	ro := &RoomOperation{
+		Op:       op,
+		Room:     room,
+		Identity: identity,
+	}
+	if params != nil {
+		b, err := json.Marshal(params)
+		if err != nil {
+			return nil, err
+		}
+		ro.Params = b
+	}
+	return ro, nil
+}
+
+// DecodeParams decodes params of the operation into v
+func (o *RoomOperation) DecodeParams(v interface{}) error {
+	if len(o.Params) == 0 {
+		return nil
+	}
+	return json.Unmarshal(o.Params, v)
+}
+
+// RoomOperationHandler carries out an operation, and returns its result, which is encoded as JSON
+type RoomOperationHandler func(op *RoomOperation) (interface{}, error)
+
+// RoomOperationError is returned for operations that failed on another node, with the message of the error
+type RoomOperationError struct {
+	Message string
+}
+
+func (e *RoomOperationError) Error() string {
+	return e.Message
+}
+
+// roomOperationRequest is sent to nodes carrying out an operation, they reply to replyNode
+type roomOperationRequest struct {
+	ID        string         `json:"id"`
+	ReplyNode string         `json:"replyNode"`
+	Operation *RoomOperation `json:"operation"`
+}
+
+type roomOperationReply struct {
+	ID      string          `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   string          `json:"error,omitempty"`
+	Skipped bool            `json:"skipped,omitempty"`
+}
+
+func newRoomOperationReply(id string, result json.RawMessage, err error) *roomOperationReply {
+	reply := &roomOperationReply{
+		ID:     id,
+		Result: result,
+	}
+	if err == ErrRoomOperationSkipped {
+		reply.Skipped = true
+	} else if err != nil {
+		reply.Error = err.Error()
+	}
+	return reply
+}
+
+func (r *roomOperationReply) result() (json.RawMessage, error) {
+	if r.Skipped {
+		return nil, ErrRoomOperationSkipped
+	}
+	if r.Error != "" {
+		return nil, &RoomOperationError{Message: r.Error}
+	}
+	return r.Result, nil
+}
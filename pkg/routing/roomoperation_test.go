@@ -0,0 +1,53 @@
+package routing_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/livekit/livekit-server/pkg/routing"
+	livekit "github.com/livekit/livekit-server/proto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalRoomOperations(t *testing.T) {
+	type params struct {
+		Name string `json:"name"`
+	}
+	r := routing.NewLocalRouter(&livekit.Node{Id: "node"})
+	r.OnRoomOperation("rename", func(op *routing.RoomOperation) (interface{}, error) {
+		p := params{}
+		if err := op.DecodeParams(&p); err != nil {
+			return nil, err
+		}
+		if op.Identity == "" {
+			return nil, routing.ErrRoomOperationSkipped
+		}
+		return op.Identity + ":" + p.Name, nil
+	})
+
+	t.Run("executes with params", func(t *testing.T) {
+		op, err := routing.NewRoomOperation("rename", "room", "alice", params{Name: "Alice"})
+		require.NoError(t, err)
+		res, err := r.ExecuteRoomOperation(context.Background(), op)
+		require.NoError(t, err)
+		var name string
+		require.NoError(t, json.Unmarshal(res, &name))
+		require.Equal(t, "alice:Alice", name)
+	})
+
+	t.Run("broadcast leaves out skipping nodes", func(t *testing.T) {
+		op, err := routing.NewRoomOperation("rename", "room", "", params{Name: "Alice"})
+		require.NoError(t, err)
+		res, err := r.BroadcastRoomOperation(context.Background(), op)
+		require.NoError(t, err)
+		require.Empty(t, res)
+	})
+
+	t.Run("operations without handler", func(t *testing.T) {
+		op, err := routing.NewRoomOperation("unknown", "room", "", nil)
+		require.NoError(t, err)
+		_, err = r.ExecuteRoomOperation(context.Background(), op)
+		require.Equal(t, routing.ErrHandlerNotDefined, err)
+	})
+}
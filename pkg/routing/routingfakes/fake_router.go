@@ -2,6 +2,8 @@
 package routingfakes
 
 import (
+	"context"
+	"encoding/json"
 	"sync"
 
 	"github.com/livekit/livekit-server/pkg/routing"
@@ -9,6 +11,20 @@ import (
 )
 
 type FakeRouter struct {
+	BroadcastRoomOperationStub        func(context.Context, *routing.RoomOperation) ([]json.RawMessage, error)
+	broadcastRoomOperationMutex       sync.RWMutex
+	broadcastRoomOperationArgsForCall []struct {
+		arg1 context.Context
+		arg2 *routing.RoomOperation
+	}
+	broadcastRoomOperationReturns struct {
+		result1 []json.RawMessage
+		result2 error
+	}
+	broadcastRoomOperationReturnsOnCall map[int]struct {
+		result1 []json.RawMessage
+		result2 error
+	}
 	ClearRoomStateStub        func(string) error
 	clearRoomStateMutex       sync.RWMutex
 	clearRoomStateArgsForCall []struct {
@@ -34,6 +50,20 @@ type FakeRouter struct {
 		result1 routing.MessageSink
 		result2 error
 	}
+	ExecuteRoomOperationStub        func(context.Context, *routing.RoomOperation) (json.RawMessage, error)
+	executeRoomOperationMutex       sync.RWMutex
+	executeRoomOperationArgsForCall []struct {
+		arg1 context.Context
+		arg2 *routing.RoomOperation
+	}
+	executeRoomOperationReturns struct {
+		result1 json.RawMessage
+		result2 error
+	}
+	executeRoomOperationReturnsOnCall map[int]struct {
+		result1 json.RawMessage
+		result2 error
+	}
 	GetNodeStub        func(string) (*livekit.Node, error)
 	getNodeMutex       sync.RWMutex
 	getNodeArgsForCall []struct {
@@ -82,6 +112,12 @@ type FakeRouter struct {
 	onRTCMessageArgsForCall []struct {
 		arg1 routing.RTCMessageCallback
 	}
+	OnRoomOperationStub        func(string, routing.RoomOperationHandler)
+	onRoomOperationMutex       sync.RWMutex
+	onRoomOperationArgsForCall []struct {
+		arg1 string
+		arg2 routing.RoomOperationHandler
+	}
 	RegisterNodeStub        func() error
 	registerNodeMutex       sync.RWMutex
 	registerNodeArgsForCall []struct {
@@ -160,6 +196,71 @@ type FakeRouter struct {
 	invocationsMutex sync.RWMutex
 }
 
+func (fake *FakeRouter) BroadcastRoomOperation(arg1 context.Context, arg2 *routing.RoomOperation) ([]json.RawMessage, error) {
+	fake.broadcastRoomOperationMutex.Lock()
+	ret, specificReturn := fake.broadcastRoomOperationReturnsOnCall[len(fake.broadcastRoomOperationArgsForCall)]
+	fake.broadcastRoomOperationArgsForCall = append(fake.broadcastRoomOperationArgsForCall, struct {
+		arg1 context.Context
+		arg2 *routing.RoomOperation
+	}{arg1, arg2})
+	stub := fake.BroadcastRoomOperationStub
+	fakeReturns := fake.broadcastRoomOperationReturns
+	fake.recordInvocation("BroadcastRoomOperation", []interface{}{arg1, arg2})
+	fake.broadcastRoomOperationMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeRouter) BroadcastRoomOperationCallCount() int {
+	fake.broadcastRoomOperationMutex.RLock()
+	defer fake.broadcastRoomOperationMutex.RUnlock()
+	return len(fake.broadcastRoomOperationArgsForCall)
+}
+
+func (fake *FakeRouter) BroadcastRoomOperationCalls(stub func(context.Context, *routing.RoomOperation) ([]json.RawMessage, error)) {
+	fake.broadcastRoomOperationMutex.Lock()
+	defer fake.broadcastRoomOperationMutex.Unlock()
+	fake.BroadcastRoomOperationStub = stub
+}
+
+func (fake *FakeRouter) BroadcastRoomOperationArgsForCall(i int) (context.Context, *routing.RoomOperation) {
+	fake.broadcastRoomOperationMutex.RLock()
+	defer fake.broadcastRoomOperationMutex.RUnlock()
+	argsForCall := fake.broadcastRoomOperationArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeRouter) BroadcastRoomOperationReturns(result1 []json.RawMessage, result2 error) {
+	fake.broadcastRoomOperationMutex.Lock()
+	defer fake.broadcastRoomOperationMutex.Unlock()
+	fake.BroadcastRoomOperationStub = nil
+	fake.broadcastRoomOperationReturns = struct {
+		result1 []json.RawMessage
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeRouter) BroadcastRoomOperationReturnsOnCall(i int, result1 []json.RawMessage, result2 error) {
+	fake.broadcastRoomOperationMutex.Lock()
+	defer fake.broadcastRoomOperationMutex.Unlock()
+	fake.BroadcastRoomOperationStub = nil
+	if fake.broadcastRoomOperationReturnsOnCall == nil {
+		fake.broadcastRoomOperationReturnsOnCall = make(map[int]struct {
+			result1 []json.RawMessage
+			result2 error
+		})
+	}
+	fake.broadcastRoomOperationReturnsOnCall[i] = struct {
+		result1 []json.RawMessage
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeRouter) ClearRoomState(arg1 string) error {
 	fake.clearRoomStateMutex.Lock()
 	ret, specificReturn := fake.clearRoomStateReturnsOnCall[len(fake.clearRoomStateArgsForCall)]
@@ -286,6 +387,71 @@ func (fake *FakeRouter) CreateRTCSinkReturnsOnCall(i int, result1 routing.Messag
 	}{result1, result2}
 }
 
+func (fake *FakeRouter) ExecuteRoomOperation(arg1 context.Context, arg2 *routing.RoomOperation) (json.RawMessage, error) {
+	fake.executeRoomOperationMutex.Lock()
+	ret, specificReturn := fake.executeRoomOperationReturnsOnCall[len(fake.executeRoomOperationArgsForCall)]
+	fake.executeRoomOperationArgsForCall = append(fake.executeRoomOperationArgsForCall, struct {
+		arg1 context.Context
+		arg2 *routing.RoomOperation
+	}{arg1, arg2})
+	stub := fake.ExecuteRoomOperationStub
+	fakeReturns := fake.executeRoomOperationReturns
+	fake.recordInvocation("ExecuteRoomOperation", []interface{}{arg1, arg2})
+	fake.executeRoomOperationMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeRouter) ExecuteRoomOperationCallCount() int {
+	fake.executeRoomOperationMutex.RLock()
+	defer fake.executeRoomOperationMutex.RUnlock()
+	return len(fake.executeRoomOperationArgsForCall)
+}
+
+func (fake *FakeRouter) ExecuteRoomOperationCalls(stub func(context.Context, *routing.RoomOperation) (json.RawMessage, error)) {
+	fake.executeRoomOperationMutex.Lock()
+	defer fake.executeRoomOperationMutex.Unlock()
+	fake.ExecuteRoomOperationStub = stub
+}
+
+func (fake *FakeRouter) ExecuteRoomOperationArgsForCall(i int) (context.Context, *routing.RoomOperation) {
+	fake.executeRoomOperationMutex.RLock()
+	defer fake.executeRoomOperationMutex.RUnlock()
+	argsForCall := fake.executeRoomOperationArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeRouter) ExecuteRoomOperationReturns(result1 json.RawMessage, result2 error) {
+	fake.executeRoomOperationMutex.Lock()
+	defer fake.executeRoomOperationMutex.Unlock()
+	fake.ExecuteRoomOperationStub = nil
+	fake.executeRoomOperationReturns = struct {
+		result1 json.RawMessage
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeRouter) ExecuteRoomOperationReturnsOnCall(i int, result1 json.RawMessage, result2 error) {
+	fake.executeRoomOperationMutex.Lock()
+	defer fake.executeRoomOperationMutex.Unlock()
+	fake.ExecuteRoomOperationStub = nil
+	if fake.executeRoomOperationReturnsOnCall == nil {
+		fake.executeRoomOperationReturnsOnCall = make(map[int]struct {
+			result1 json.RawMessage
+			result2 error
+		})
+	}
+	fake.executeRoomOperationReturnsOnCall[i] = struct {
+		result1 json.RawMessage
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeRouter) GetNode(arg1 string) (*livekit.Node, error) {
 	fake.getNodeMutex.Lock()
 	ret, specificReturn := fake.getNodeReturnsOnCall[len(fake.getNodeArgsForCall)]
@@ -534,6 +700,39 @@ func (fake *FakeRouter) OnRTCMessageArgsForCall(i int) routing.RTCMessageCallbac
 	return argsForCall.arg1
 }
 
+func (fake *FakeRouter) OnRoomOperation(arg1 string, arg2 routing.RoomOperationHandler) {
+	fake.onRoomOperationMutex.Lock()
+	fake.onRoomOperationArgsForCall = append(fake.onRoomOperationArgsForCall, struct {
+		arg1 string
+		arg2 routing.RoomOperationHandler
+	}{arg1, arg2})
+	stub := fake.OnRoomOperationStub
+	fake.recordInvocation("OnRoomOperation", []interface{}{arg1, arg2})
+	fake.onRoomOperationMutex.Unlock()
+	if stub != nil {
+		fake.OnRoomOperationStub(arg1, arg2)
+	}
+}
+
+func (fake *FakeRouter) OnRoomOperationCallCount() int {
+	fake.onRoomOperationMutex.RLock()
+	defer fake.onRoomOperationMutex.RUnlock()
+	return len(fake.onRoomOperationArgsForCall)
+}
+
+func (fake *FakeRouter) OnRoomOperationCalls(stub func(string, routing.RoomOperationHandler)) {
+	fake.onRoomOperationMutex.Lock()
+	defer fake.onRoomOperationMutex.Unlock()
+	fake.OnRoomOperationStub = stub
+}
+
+func (fake *FakeRouter) OnRoomOperationArgsForCall(i int) (string, routing.RoomOperationHandler) {
+	fake.onRoomOperationMutex.RLock()
+	defer fake.onRoomOperationMutex.RUnlock()
+	argsForCall := fake.onRoomOperationArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
 func (fake *FakeRouter) RegisterNode() error {
 	fake.registerNodeMutex.Lock()
 	ret, specificReturn := fake.registerNodeReturnsOnCall[len(fake.registerNodeArgsForCall)]
@@ -906,10 +1105,14 @@ func (fake *FakeRouter) UnregisterNodeReturnsOnCall(i int, result1 error) {
 func (fake *FakeRouter) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
+	fake.broadcastRoomOperationMutex.RLock()
+	defer fake.broadcastRoomOperationMutex.RUnlock()
 	fake.clearRoomStateMutex.RLock()
 	defer fake.clearRoomStateMutex.RUnlock()
 	fake.createRTCSinkMutex.RLock()
 	defer fake.createRTCSinkMutex.RUnlock()
+	fake.executeRoomOperationMutex.RLock()
+	defer fake.executeRoomOperationMutex.RUnlock()
 	fake.getNodeMutex.RLock()
 	defer fake.getNodeMutex.RUnlock()
 	fake.getNodeForRoomMutex.RLock()
@@ -920,6 +1123,8 @@ func (fake *FakeRouter) Invocations() map[string][][]interface{} {
 	defer fake.onNewParticipantRTCMutex.RUnlock()
 	fake.onRTCMessageMutex.RLock()
 	defer fake.onRTCMessageMutex.RUnlock()
+	fake.onRoomOperationMutex.RLock()
+	defer fake.onRoomOperationMutex.RUnlock()
 	fake.registerNodeMutex.RLock()
 	defer fake.registerNodeMutex.RUnlock()
 	fake.removeDeadNodesMutex.RLock()
@@ -0,0 +1,158 @@
+package rtc
+
+import (
+	"sort"
+	"time"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+// how often subscriptions are allocated again, as bitrates of layers change
+const subscriptionAllocationInterval = 2 * time.Second
+
+// LayerRequest is a video subscription competing for the download bandwidth of a subscriber
+type LayerRequest struct {
+	TrackID string
+	// pinned subscriptions go first, then those asking for higher layers
+	Pinned bool
+	// highest spatial layer the subscriber wants
+	TargetLayer int32
+	// bps of each spatial layer, 0 for layers that aren't published
+	Bitrates [3]uint64
+}
+
+// SubscriptionAllocator shares the download bandwidth of a subscriber among its video subscriptions
+type SubscriptionAllocator interface {
+	// Allocate returns the spatial layer of each track so they fit in bandwidth (bps), -1 for tracks that are paused
+	Allocate(bandwidth uint64, tracks []LayerRequest) map[string]int32
+}
+
+// NewSubscriptionAllocator returns the allocator of a strategy, nil when it's empty
+func NewSubscriptionAllocator(strategy string) SubscriptionAllocator {
+	switch strategy {
+	case config.AllocationGreedy:
+		return greedyAllocator{}
+	case config.AllocationProportionalFair:
+		return proportionalFairAllocator{}
+	case config.AllocationPriorityStrict:
+		return priorityStrictAllocator{}
+	default:
+		return nil
+	}
+}
+
+// greedyAllocator gives each track in priority order the highest layer that fits in what's left
+type greedyAllocator struct{}
+
+func (greedyAllocator) Allocate(bandwidth uint64, tracks []LayerRequest) map[string]int32 {
+	layers := make(map[string]int32, len(tracks))
+	remaining := bandwidth
+	for _, t := range byPriority(tracks) {
+		layer := t.highestFitting(t.TargetLayer, remaining)
+		layers[t.TrackID] = layer
+		if layer >= 0 {
+			remaining -= t.Bitrates[layer]
+		}
+	}
+	return layers
+}
+
+// proportionalFairAllocator starts every track that fits at its lowest layer, then raises them a layer at a time
+// in turns, so bandwidth is spread evenly rather than spent on the first tracks
+type proportionalFairAllocator struct{}
+
+func (proportionalFairAllocator) Allocate(bandwidth uint64, tracks []LayerRequest) map[string]int32 {
+	ordered := byPriority(tracks)
+	layers := make(map[string]int32, len(tracks))
+	remaining := bandwidth
+	for _, t := range ordered {
+		layers[t.TrackID] = -1
+		if lowest := t.nextLayer(-1); lowest >= 0 && t.Bitrates[lowest] <= remaining {
+			layers[t.TrackID] = lowest
+			remaining -= t.Bitrates[lowest]
+		}
+	}
+	for raised := true; raised; {
+		raised = false
+		for _, t := range ordered {
+			current := layers[t.TrackID]
+			if current < 0 {
+				continue
+			}
+			next := t.nextLayer(current)
+			if next < 0 || next > t.TargetLayer {
+				continue
+			}
+			// the next layer replaces the current one
+			var extra uint64
+			if t.Bitrates[next] > t.Bitrates[current] {
+				extra = t.Bitrates[next] - t.Bitrates[current]
+			}
+			if extra > remaining {
+				continue
+			}
+			remaining -= extra
+			layers[t.TrackID] = next
+			raised = true
+		}
+	}
+	return layers
+}
+
+// priorityStrictAllocator serves tracks in priority order, a track only gets bandwidth once all the ones before it
+// are at their target layer
+type priorityStrictAllocator struct{}
+
+func (priorityStrictAllocator) Allocate(bandwidth uint64, tracks []LayerRequest) map[string]int32 {
+	layers := make(map[string]int32, len(tracks))
+	remaining := bandwidth
+	starved := false
+	for _, t := range byPriority(tracks) {
+		if starved {
+			layers[t.TrackID] = -1
+			continue
+		}
+		layer := t.highestFitting(t.TargetLayer, remaining)
+		layers[t.TrackID] = layer
+		if layer >= 0 {
+			remaining -= t.Bitrates[layer]
+		}
+		starved = layer < t.highestFitting(t.TargetLayer, ^uint64(0))
+	}
+	return layers
+}
+
+func byPriority(tracks []LayerRequest) []LayerRequest {
+	ordered := append([]LayerRequest(nil), tracks...)
+	sort.Slice(ordered, func(i, j int) bool {
+		x, y := ordered[i], ordered[j]
+		if x.Pinned != y.Pinned {
+			return x.Pinned
+		}
+		if x.TargetLayer != y.TargetLayer {
+			return x.TargetLayer > y.TargetLayer
+		}
+		return x.TrackID < y.TrackID
+	})
+	return ordered
+}
+
+// highestFitting returns the highest published layer up to target within bandwidth, -1 when none fits
+func (t LayerRequest) highestFitting(target int32, bandwidth uint64) int32 {
+	for layer := target; layer >= 0; layer-- {
+		if layer < int32(len(t.Bitrates)) && t.Bitrates[layer] > 0 && t.Bitrates[layer] <= bandwidth {
+			return layer
+		}
+	}
+	return -1
+}
+
+// nextLayer returns the first published layer above current, -1 when there isn't one
+func (t LayerRequest) nextLayer(current int32) int32 {
+	for layer := current + 1; layer < int32(len(t.Bitrates)); layer++ {
+		if t.Bitrates[layer] > 0 {
+			return layer
+		}
+	}
+	return -1
+}
@@ -0,0 +1,106 @@
+package rtc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+func TestSubscriptionAllocator(t *testing.T) {
+	simulcast := [3]uint64{150_000, 500_000, 1_500_000}
+	tracks := []LayerRequest{
+		{TrackID: "TR_camera", TargetLayer: 1, Bitrates: simulcast},
+		{TrackID: "TR_screen", TargetLayer: 2, Bitrates: simulcast},
+		{TrackID: "TR_pinned", TargetLayer: 2, Bitrates: simulcast, Pinned: true},
+	}
+
+	t.Run("abundant bandwidth", func(t *testing.T) {
+		for _, strategy := range []string{
+			config.AllocationGreedy, config.AllocationProportionalFair, config.AllocationPriorityStrict,
+		} {
+			layers := NewSubscriptionAllocator(strategy).Allocate(5_000_000, tracks)
+			require.Equal(t, map[string]int32{"TR_camera": 1, "TR_screen": 2, "TR_pinned": 2}, layers, strategy)
+		}
+	})
+
+	t.Run("greedy", func(t *testing.T) {
+		layers := NewSubscriptionAllocator(config.AllocationGreedy).Allocate(1_000_000, tracks)
+		require.Equal(t, map[string]int32{"TR_pinned": 1, "TR_screen": 1, "TR_camera": -1}, layers)
+	})
+
+	t.Run("proportional fair", func(t *testing.T) {
+		layers := NewSubscriptionAllocator(config.AllocationProportionalFair).Allocate(1_000_000, tracks)
+		require.Equal(t, map[string]int32{"TR_pinned": 1, "TR_screen": 0, "TR_camera": 0}, layers)
+
+		// not even the lowest layers fit
+		layers = NewSubscriptionAllocator(config.AllocationProportionalFair).Allocate(200_000, tracks)
+		require.Equal(t, map[string]int32{"TR_pinned": 0, "TR_screen": -1, "TR_camera": -1}, layers)
+	})
+
+	t.Run("priority strict", func(t *testing.T) {
+		layers := NewSubscriptionAllocator(config.AllocationPriorityStrict).Allocate(1_000_000, tracks)
+		require.Equal(t, map[string]int32{"TR_pinned": 1, "TR_screen": -1, "TR_camera": -1}, layers)
+
+		layers = NewSubscriptionAllocator(config.AllocationPriorityStrict).Allocate(2_500_000, tracks)
+		require.Equal(t, map[string]int32{"TR_pinned": 2, "TR_screen": 1, "TR_camera": -1}, layers)
+	})
+
+	t.Run("layers that aren't published", func(t *testing.T) {
+		single := []LayerRequest{
+			{TrackID: "TR_single", TargetLayer: 2, Bitrates: [3]uint64{300_000}},
+			{TrackID: "TR_camera", TargetLayer: 1, Bitrates: simulcast},
+		}
+		// the single layer is all the first track could get, so the next one is served
+		layers := NewSubscriptionAllocator(config.AllocationPriorityStrict).Allocate(1_000_000, single)
+		require.Equal(t, map[string]int32{"TR_single": 0, "TR_camera": 1}, layers)
+	})
+
+	t.Run("none", func(t *testing.T) {
+		require.Nil(t, NewSubscriptionAllocator(""))
+	})
+}
+
+func TestDownloadBudgetAllocateLayers(t *testing.T) {
+	simulcast := [3]uint64{150_000, 500_000, 1_500_000}
+	tracks := []budgetedTrack{
+		{id: "TR_audio", minBitrate: 32_000},
+		{id: "TR_camera", video: true, minBitrate: 150_000, targetLayer: 2, bitrates: simulcast},
+		{id: "TR_screen", video: true, minBitrate: 150_000, targetLayer: 2, bitrates: simulcast},
+	}
+	b := newDownloadBudget(0, config.DownloadBudgetAudioOnly)
+	b.setAllocationStrategy(config.AllocationGreedy)
+
+	layers, signal, changed := b.allocateLayers(tracks)
+	require.Empty(t, layers)
+	require.False(t, changed)
+	require.Equal(t, downloadBudgetNone, signal.Policy)
+	require.True(t, b.idle())
+
+	// audio is kept out of what's shared
+	b.setMaxBitrate(2_032_000)
+	layers, signal, changed = b.allocateLayers(tracks)
+	require.True(t, changed)
+	require.Equal(t, map[string]int32{"TR_camera": 2, "TR_screen": 1}, layers)
+	require.Equal(t, config.AllocationGreedy, signal.Policy)
+	require.Equal(t, layers, signal.Layers)
+	require.Empty(t, signal.PausedTracks)
+
+	_, _, changed = b.allocateLayers(tracks)
+	require.False(t, changed)
+
+	b.setMaxBitrate(600_000)
+	_, signal, changed = b.allocateLayers(tracks)
+	require.True(t, changed)
+	require.Equal(t, []string{"TR_screen"}, signal.PausedTracks)
+
+	// limits are lifted once the budget is removed
+	b.setMaxBitrate(0)
+	require.False(t, b.idle())
+	layers, signal, changed = b.allocateLayers(tracks)
+	require.True(t, changed)
+	require.Empty(t, layers)
+	require.Equal(t, downloadBudgetNone, signal.Policy)
+	require.True(t, b.idle())
+}
@@ -0,0 +1,316 @@
+package rtc
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v3"
+	"github.com/pion/webrtc/v3/pkg/media"
+
+	"github.com/livekit/livekit-server/pkg/logger"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+	livekit "github.com/livekit/livekit-server/proto"
+)
+
+const (
+	// decoded frames held for each speaker, older ones are dropped so a late speaker doesn't add latency to the mix
+	mixerMaxQueuedFrames = 5
+	// samples in a 20ms mono Opus frame at 48kHz
+	mixerFrameSamples = 960
+	// an Opus frame is at most 1275 bytes
+	mixerMaxFrameSize  = 1275
+	mixerFrameDuration = 20 * time.Millisecond
+
+	// stream and track ID of the mixed track, clients tell it apart from tracks of participants by it
+	MixedAudioStreamID = "mixed_audio"
+)
+
+var ErrNoAudioCodec = errors.New("audio mixing requires an Opus codec")
+
+// AudioCodec decodes and encodes the Opus frames of mixed audio. The server doesn't bundle one, as the available
+// implementations depend on cgo, so it has to be supplied to enable mixing
+type AudioCodec interface {
+	// Decode decodes a frame into pcm, returning the number of samples
+	Decode(frame []byte, pcm []int16) (int, error)
+	// Encode encodes samples of pcm into frame, returning its size
+	Encode(pcm []int16, frame []byte) (int, error)
+}
+
+// AudioMixer combines the audio of the loudest speakers of a room into a single stream, for subscribers that
+// can't decode a stream per speaker. It costs a decode per speaker and an encode per mixed frame, and subscribers
+// lose control over the volume of each speaker, so it's only used for those opted in
+type AudioMixer interface {
+	// SetSpeakers changes who's mixed to the first speakers, ordered from the loudest
+	SetSpeakers(speakers []*livekit.SpeakerInfo)
+	// Speakers returns the sids of participants mixed
+	Speakers() []string
+	// Push adds a frame of a participant's audio, it's ignored unless the participant is mixed
+	Push(sid string, frame []byte) error
+	// Mix returns the next mixed frame, nil when no one mixed has audio queued
+	Mix() ([]byte, error)
+}
+
+// pcmMixer is an AudioMixer summing decoded samples of each speaker
+type pcmMixer struct {
+	codec       AudioCodec
+	maxSpeakers int
+
+	lock sync.Mutex
+	// sid => decoded frames, oldest first
+	queues map[string][][]int16
+	order  []string
+}
+
+// NewAudioMixer returns a mixer of up to maxSpeakers speakers
+func NewAudioMixer(codec AudioCodec, maxSpeakers int) (AudioMixer, error) {
+	if codec == nil {
+		return nil, ErrNoAudioCodec
+	}
+	return &pcmMixer{
+		codec:       codec,
+		maxSpeakers: maxSpeakers,
+		queues:      make(map[string][][]int16),
+	}, nil
+}
+
+func (m *pcmMixer) SetSpeakers(speakers []*livekit.SpeakerInfo) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	order := make([]string, 0, m.maxSpeakers)
+	queues := make(map[string][][]int16, m.maxSpeakers)
+	for _, speaker := range speakers {
+		if len(order) == m.maxSpeakers {
+			break
+		}
+		if !speaker.Active {
+			continue
+		}
+		order = append(order, speaker.Sid)
+		// speakers still mixed keep their queued audio, new ones start with what they send from now on
+		queues[speaker.Sid] = m.queues[speaker.Sid]
+	}
+	m.order = order
+	m.queues = queues
+}
+
+func (m *pcmMixer) Speakers() []string {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return append([]string(nil), m.order...)
+}
+
+func (m *pcmMixer) Push(sid string, frame []byte) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	queue, ok := m.queues[sid]
+	if !ok {
+		return nil
+	}
+	pcm := make([]int16, mixerFrameSamples)
+	n, err := m.codec.Decode(frame, pcm)
+	if err != nil {
+		return err
+	}
+	if len(queue) == mixerMaxQueuedFrames {
+		queue = queue[1:]
+	}
+	m.queues[sid] = append(queue, pcm[:n])
+	return nil
+}
+
+func (m *pcmMixer) Mix() ([]byte, error) {
+	m.lock.Lock()
+	var sum []int32
+	for _, sid := range m.order {
+		queue := m.queues[sid]
+		if len(queue) == 0 {
+			continue
+		}
+		pcm := queue[0]
+		m.queues[sid] = queue[1:]
+		if len(pcm) > len(sum) {
+			sum = append(sum, make([]int32, len(pcm)-len(sum))...)
+		}
+		for i, sample := range pcm {
+			sum[i] += int32(sample)
+		}
+	}
+	m.lock.Unlock()
+
+	if sum == nil {
+		return nil, nil
+	}
+	mixed := make([]int16, len(sum))
+	for i, sample := range sum {
+		// clipped rather than scaled down, so a single speaker is as loud as when forwarded on its own
+		switch {
+		case sample > math.MaxInt16:
+			mixed[i] = math.MaxInt16
+		case sample < math.MinInt16:
+			mixed[i] = math.MinInt16
+		default:
+			mixed[i] = int16(sample)
+		}
+	}
+	frame := make([]byte, mixerMaxFrameSize)
+	n, err := m.codec.Encode(mixed, frame)
+	if err != nil {
+		return nil, err
+	}
+	return frame[:n], nil
+}
+
+// mixedAudio sends the mix of the loudest speakers of a room to subscribers opted in to it, as a single track
+// replacing their audio subscriptions. Published audio tracks are tapped with sinks, frames are only decoded while
+// someone receives the mix
+type mixedAudio struct {
+	mixer AudioMixer
+	track *webrtc.TrackLocalStaticSample
+	// writes mixed frames to the track, replaced in tests
+	writeSample func(sample media.Sample) error
+
+	lock sync.Mutex
+	// sid => sender of the mixed track to the subscriber
+	subscribers map[string]*webrtc.RTPSender
+	done        chan struct{}
+}
+
+func newMixedAudio(codec AudioCodec, maxSpeakers int) (*mixedAudio, error) {
+	mixer, err := NewAudioMixer(codec, maxSpeakers)
+	if err != nil {
+		return nil, err
+	}
+	track, err := webrtc.NewTrackLocalStaticSample(webrtc.RTPCodecCapability{
+		MimeType:    webrtc.MimeTypeOpus,
+		ClockRate:   48000,
+		Channels:    2,
+		SDPFmtpLine: "minptime=10;useinbandfec=1",
+	}, MixedAudioStreamID, MixedAudioStreamID)
+	if err != nil {
+		return nil, err
+	}
+	m := &mixedAudio{
+		mixer:       mixer,
+		track:       track,
+		writeSample: track.WriteSample,
+		subscribers: make(map[string]*webrtc.RTPSender),
+		done:        make(chan struct{}),
+	}
+	return m, nil
+}
+
+// addTrack taps an audio track published by participant, its frames are mixed while it's among the loudest
+func (m *mixedAudio) addTrack(participant types.Participant, track types.PublishedTrack) {
+	if err := track.AddSink(&mixedAudioSink{mixing: m, sid: participant.ID()}); err != nil {
+		logger.Warnw("could not mix audio track", err,
+			"participant", participant.Identity(),
+			"track", track.ID())
+	}
+}
+
+func (m *mixedAudio) setSpeakers(speakers []*livekit.SpeakerInfo) {
+	m.mixer.SetSpeakers(speakers)
+}
+
+// addSubscriber sends the mixed track to sub, whose audio subscriptions are skipped
+func (m *mixedAudio) addSubscriber(sub types.Participant) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.subscribers[sub.ID()] != nil {
+		return nil
+	}
+	sender, err := sub.SubscriberPC().AddTrack(m.track)
+	if err != nil {
+		return err
+	}
+	m.subscribers[sub.ID()] = sender
+	go func() {
+		// RTCP has to be read for interceptors to process it
+		buf := make([]byte, 1500)
+		for {
+			if _, _, err := sender.Read(buf); err != nil {
+				return
+			}
+		}
+	}()
+	go sub.Negotiate()
+	logger.Debugw("sending mixed audio", "participant", sub.Identity())
+	return nil
+}
+
+func (m *mixedAudio) removeSubscriber(sub types.Participant) {
+	m.lock.Lock()
+	sender := m.subscribers[sub.ID()]
+	delete(m.subscribers, sub.ID())
+	m.lock.Unlock()
+	if sender == nil || sub.SubscriberPC().ConnectionState() == webrtc.PeerConnectionStateClosed {
+		return
+	}
+	if err := sub.SubscriberPC().RemoveTrack(sender); err != nil {
+		logger.Debugw("could not remove mixed audio", "error", err, "participant", sub.Identity())
+		return
+	}
+	sub.Negotiate()
+}
+
+func (m *mixedAudio) hasSubscribers() bool {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return len(m.subscribers) > 0
+}
+
+func (m *mixedAudio) close() {
+	close(m.done)
+}
+
+// mixWorker writes a mixed frame every frame duration while anyone receives the mix, until it's closed
+func (m *mixedAudio) mixWorker() {
+	ticker := time.NewTicker(mixerFrameDuration)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-m.done:
+			return
+		case <-ticker.C:
+			if m.hasSubscribers() {
+				m.mixOnce()
+			}
+		}
+	}
+}
+
+// mixOnce writes the next mixed frame, when any speaker mixed has audio queued
+func (m *mixedAudio) mixOnce() {
+	frame, err := m.mixer.Mix()
+	if err != nil {
+		logger.Warnw("could not mix audio", err)
+		return
+	}
+	if frame == nil {
+		return
+	}
+	if err := m.writeSample(media.Sample{Data: frame, Duration: mixerFrameDuration}); err != nil {
+		logger.Debugw("could not write mixed audio", "error", err)
+	}
+}
+
+// mixedAudioSink pushes frames of a published audio track to the mixer
+type mixedAudioSink struct {
+	mixing *mixedAudio
+	sid    string
+}
+
+func (s *mixedAudioSink) WriteRTP(pkt *rtp.Packet) error {
+	if len(pkt.Payload) == 0 || !s.mixing.hasSubscribers() {
+		return nil
+	}
+	return s.mixing.mixer.Push(s.sid, pkt.Payload)
+}
+
+func (s *mixedAudioSink) Close() error {
+	return nil
+}
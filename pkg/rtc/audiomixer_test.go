@@ -0,0 +1,190 @@
+package rtc
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v3"
+	"github.com/pion/webrtc/v3/pkg/media"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/rtc/types/typesfakes"
+	livekit "github.com/livekit/livekit-server/proto"
+)
+
+func TestAudioMixer(t *testing.T) {
+	newMixer := func(t *testing.T, maxSpeakers int) AudioMixer {
+		m, err := NewAudioMixer(pcmCodec{}, maxSpeakers)
+		require.NoError(t, err)
+		return m
+	}
+	speakers := func(sids ...string) []*livekit.SpeakerInfo {
+		infos := make([]*livekit.SpeakerInfo, 0, len(sids))
+		for _, sid := range sids {
+			infos = append(infos, &livekit.SpeakerInfo{Sid: sid, Active: true})
+		}
+		return infos
+	}
+	mix := func(t *testing.T, m AudioMixer) []int16 {
+		frame, err := m.Mix()
+		require.NoError(t, err)
+		if frame == nil {
+			return nil
+		}
+		return decodePCM(frame)
+	}
+
+	t.Run("requires a codec", func(t *testing.T) {
+		_, err := NewAudioMixer(nil, 3)
+		require.Equal(t, ErrNoAudioCodec, err)
+	})
+
+	t.Run("mixes the loudest speakers", func(t *testing.T) {
+		m := newMixer(t, 2)
+		m.SetSpeakers(speakers("PA_1", "PA_2", "PA_3"))
+		require.Equal(t, []string{"PA_1", "PA_2"}, m.Speakers())
+
+		require.NoError(t, m.Push("PA_1", encodePCM(100, -100)))
+		require.NoError(t, m.Push("PA_2", encodePCM(20, 30)))
+		require.NoError(t, m.Push("PA_3", encodePCM(1000, 1000)))
+		require.Equal(t, []int16{120, -70}, mix(t, m))
+		require.Nil(t, mix(t, m))
+	})
+
+	t.Run("follows speaker changes", func(t *testing.T) {
+		m := newMixer(t, 2)
+		m.SetSpeakers(speakers("PA_1", "PA_2"))
+		require.NoError(t, m.Push("PA_1", encodePCM(1)))
+		require.NoError(t, m.Push("PA_2", encodePCM(2)))
+
+		// PA_1 keeps its queued audio, PA_2 is dropped right away
+		m.SetSpeakers([]*livekit.SpeakerInfo{
+			{Sid: "PA_3", Active: true},
+			{Sid: "PA_2", Active: false},
+			{Sid: "PA_1", Active: true},
+		})
+		require.Equal(t, []string{"PA_3", "PA_1"}, m.Speakers())
+		require.NoError(t, m.Push("PA_3", encodePCM(3)))
+		require.Equal(t, []int16{4}, mix(t, m))
+	})
+
+	t.Run("clips and bounds queued audio", func(t *testing.T) {
+		m := newMixer(t, 2)
+		m.SetSpeakers(speakers("PA_1", "PA_2"))
+		require.NoError(t, m.Push("PA_1", encodePCM(30000, -30000)))
+		require.NoError(t, m.Push("PA_2", encodePCM(30000, -30000)))
+		require.Equal(t, []int16{math.MaxInt16, math.MinInt16}, mix(t, m))
+
+		for i := 0; i < mixerMaxQueuedFrames+2; i++ {
+			require.NoError(t, m.Push("PA_1", encodePCM(int16(i))))
+		}
+		require.Equal(t, []int16{2}, mix(t, m))
+	})
+}
+
+func TestMixedAudio(t *testing.T) {
+	newSubscriber := func(t *testing.T, sid string) *typesfakes.FakeParticipant {
+		pc, err := webrtc.NewPeerConnection(webrtc.Configuration{})
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = pc.Close() })
+		sub := &typesfakes.FakeParticipant{}
+		sub.IDReturns(sid)
+		sub.SubscriberPCReturns(pc)
+		sub.SubscribesToMixedAudioReturns(true)
+		sub.CanSubscribeReturns(true)
+		return sub
+	}
+	newMixing := func(t *testing.T) (*mixedAudio, *[]media.Sample) {
+		m, err := newMixedAudio(pcmCodec{}, 2)
+		require.NoError(t, err)
+		t.Cleanup(m.close)
+		samples := &[]media.Sample{}
+		m.writeSample = func(sample media.Sample) error {
+			*samples = append(*samples, sample)
+			return nil
+		}
+		return m, samples
+	}
+
+	t.Run("sends the mix of published tracks to subscribers", func(t *testing.T) {
+		m, samples := newMixing(t)
+		sub := newSubscriber(t, "PA_sub")
+		require.NoError(t, m.addSubscriber(sub))
+		require.Len(t, sub.SubscriberPC().GetSenders(), 1)
+		require.Equal(t, MixedAudioStreamID, sub.SubscriberPC().GetSenders()[0].Track().StreamID())
+
+		m.setSpeakers([]*livekit.SpeakerInfo{
+			{Sid: "PA_1", Active: true},
+			{Sid: "PA_2", Active: true},
+		})
+		speaker1 := &mixedAudioSink{mixing: m, sid: "PA_1"}
+		speaker2 := &mixedAudioSink{mixing: m, sid: "PA_2"}
+		require.NoError(t, speaker1.WriteRTP(&rtp.Packet{Payload: encodePCM(100, -100)}))
+		require.NoError(t, speaker2.WriteRTP(&rtp.Packet{Payload: encodePCM(20, 30)}))
+
+		m.mixOnce()
+		require.Len(t, *samples, 1)
+		require.Equal(t, []int16{120, -70}, decodePCM((*samples)[0].Data))
+		require.Equal(t, mixerFrameDuration, (*samples)[0].Duration)
+
+		// nothing queued, nothing written
+		m.mixOnce()
+		require.Len(t, *samples, 1)
+	})
+
+	t.Run("doesn't mix without subscribers", func(t *testing.T) {
+		m, samples := newMixing(t)
+		sub := newSubscriber(t, "PA_sub")
+		m.setSpeakers([]*livekit.SpeakerInfo{{Sid: "PA_1", Active: true}})
+		speaker := &mixedAudioSink{mixing: m, sid: "PA_1"}
+		require.NoError(t, speaker.WriteRTP(&rtp.Packet{Payload: encodePCM(100)}))
+
+		require.NoError(t, m.addSubscriber(sub))
+		m.mixOnce()
+		require.Empty(t, *samples)
+
+		m.removeSubscriber(sub)
+		require.False(t, m.hasSubscribers())
+	})
+
+	t.Run("audio tracks aren't subscribed to by subscribers of the mix", func(t *testing.T) {
+		track := &MediaTrack{
+			kind:             livekit.TrackType_AUDIO,
+			subscribedTracks: make(map[string]*SubscribedTrack),
+		}
+		sub := newSubscriber(t, "PA_sub")
+		require.NoError(t, track.AddSubscriber(sub))
+		require.False(t, track.IsSubscriber(sub.ID()))
+		require.Empty(t, sub.SubscriberPC().GetSenders())
+	})
+}
+
+// pcmCodec stands in for Opus, frames are little endian samples
+type pcmCodec struct{}
+
+func (pcmCodec) Decode(frame []byte, pcm []int16) (int, error) {
+	samples := decodePCM(frame)
+	return copy(pcm, samples), nil
+}
+
+func (pcmCodec) Encode(pcm []int16, frame []byte) (int, error) {
+	return copy(frame, encodePCM(pcm...)), nil
+}
+
+func encodePCM(samples ...int16) []byte {
+	frame := make([]byte, 2*len(samples))
+	for i, sample := range samples {
+		binary.LittleEndian.PutUint16(frame[2*i:], uint16(sample))
+	}
+	return frame
+}
+
+func decodePCM(frame []byte) []int16 {
+	samples := make([]int16, len(frame)/2)
+	for i := range samples {
+		samples[i] = int16(binary.LittleEndian.Uint16(frame[2*i:]))
+	}
+	return samples
+}
@@ -0,0 +1,190 @@
+package rtc
+
+import (
+	"io"
+	"sync"
+	"time"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/transport/packetio"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+const (
+	// interval the estimate of each subscriber is checked against the layers it's sent
+	bandwidthAdaptationInterval = time.Second
+	// loss reports of streams are ignored once they're older, the stream has likely gone
+	lossReportMaxAge = 5 * time.Second
+	// fraction lost (out of 256) above which the estimate is lowered, as with the loss-based controller of GCC
+	highLossFraction = 26
+)
+
+type lossReport struct {
+	fractionLost uint8
+	at           time.Time
+}
+
+// bandwidthAdaptation estimates the bandwidth of a subscriber, and limits the layers of its video subscriptions to
+// fit it. The estimate is the latest REMB of the subscriber, lowered by half the loss it reports when it's over
+// 10%. Transport-wide congestion control isn't negotiated with subscribers, as there's no send-side estimator.
+// Layers are lowered as soon as they don't fit, and raised a layer at a time once the higher one fits with
+// headroom, and nothing has been lowered for UpgradeHold, so they don't flap
+type bandwidthAdaptation struct {
+	conf config.BandwidthAdaptationConfig
+
+	lock sync.Mutex
+	// bps, 0 until the subscriber sends a REMB
+	remb uint64
+	// SSRC => latest loss the subscriber reported
+	loss map[uint32]lossReport
+	// track => layer it's limited to
+	layers       map[string]int32
+	downgradedAt time.Time
+}
+
+func newBandwidthAdaptation(conf config.BandwidthAdaptationConfig) *bandwidthAdaptation {
+	return &bandwidthAdaptation{
+		conf:   conf,
+		loss:   make(map[uint32]lossReport),
+		layers: make(map[string]int32),
+	}
+}
+
+func (a *bandwidthAdaptation) handleRTCP(ssrc uint32, pkts []rtcp.Packet, now time.Time) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	for _, pkt := range pkts {
+		switch p := pkt.(type) {
+		case *rtcp.ReceiverEstimatedMaximumBitrate:
+			a.remb = p.Bitrate
+		case *rtcp.ReceiverReport:
+			for _, report := range p.Reports {
+				if report.SSRC == ssrc {
+					a.loss[ssrc] = lossReport{fractionLost: report.FractionLost, at: now}
+				}
+			}
+		}
+	}
+}
+
+// getEstimate returns the estimated bandwidth of the subscriber in bps, 0 until it's known
+func (a *bandwidthAdaptation) getEstimate() uint64 {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	return a.estimateLocked(time.Now())
+}
+
+func (a *bandwidthAdaptation) estimateLocked(now time.Time) uint64 {
+	var maxLoss uint8
+	for ssrc, report := range a.loss {
+		if now.Sub(report.at) > lossReportMaxAge {
+			delete(a.loss, ssrc)
+			continue
+		}
+		if report.fractionLost > maxLoss {
+			maxLoss = report.fractionLost
+		}
+	}
+	if maxLoss <= highLossFraction {
+		return a.remb
+	}
+	return uint64(float64(a.remb) * (1 - 0.5*float64(maxLoss)/256))
+}
+
+// getLayers returns the layers video tracks are limited to
+func (a *bandwidthAdaptation) getLayers() map[string]int32 {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	layers := make(map[string]int32, len(a.layers))
+	for id, layer := range a.layers {
+		layers[id] = layer
+	}
+	return layers
+}
+
+// update returns the layers video tracks are limited to so they fit the estimate, tracks left out aren't limited.
+// Audio is taken out of the estimate first
+func (a *bandwidthAdaptation) update(tracks []budgetedTrack, now time.Time) map[string]int32 {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	estimate := a.estimateLocked(now)
+	if estimate == 0 {
+		a.layers = make(map[string]int32)
+		return a.layers
+	}
+	var requests []LayerRequest
+	for _, t := range tracks {
+		if t.video {
+			requests = append(requests, t.layerRequest())
+		} else if t.minBitrate < estimate {
+			estimate -= t.minBitrate
+		} else {
+			estimate = 0
+		}
+	}
+	fitting := greedyAllocator{}.Allocate(estimate, requests)
+	withHeadroom := greedyAllocator{}.Allocate(uint64(float64(estimate)/(1+a.conf.UpgradeHeadroom)), requests)
+
+	layers := make(map[string]int32, len(requests))
+	for _, t := range requests {
+		current, ok := a.layers[t.TrackID]
+		if !ok || current > t.TargetLayer {
+			current = t.TargetLayer
+		}
+		layer := current
+		if fit := t.lowestIfNone(fitting[t.TrackID]); fit < current {
+			layer = fit
+			a.downgradedAt = now
+		} else if up := t.lowestIfNone(withHeadroom[t.TrackID]); up > current && now.Sub(a.downgradedAt) >= a.conf.UpgradeHold {
+			if next := t.nextLayer(current); next >= 0 && next <= up {
+				layer = next
+			}
+		}
+		layers[t.TrackID] = layer
+	}
+	a.layers = layers
+	return layers
+}
+
+// lowestIfNone returns the lowest published layer in place of -1, video isn't paused for bandwidth
+func (t LayerRequest) lowestIfNone(layer int32) int32 {
+	if layer >= 0 {
+		return layer
+	}
+	if lowest := t.nextLayer(-1); lowest >= 0 {
+		return lowest
+	}
+	return 0
+}
+
+// wrapBufferFactory observes RTCP sent by the subscriber, before it's read by DownTracks
+func (a *bandwidthAdaptation) wrapBufferFactory(
+	createBufferFunc func(packetType packetio.BufferPacketType, ssrc uint32) io.ReadWriteCloser,
+) func(packetType packetio.BufferPacketType, ssrc uint32) io.ReadWriteCloser {
+	return func(packetType packetio.BufferPacketType, ssrc uint32) io.ReadWriteCloser {
+		writer := createBufferFunc(packetType, ssrc)
+		if packetType == packetio.RTCPBufferPacket {
+			return &bandwidthRTCPWriter{
+				ReadWriteCloser: writer,
+				ssrc:            ssrc,
+				adaptation:      a,
+			}
+		}
+		return writer
+	}
+}
+
+type bandwidthRTCPWriter struct {
+	io.ReadWriteCloser
+	ssrc       uint32
+	adaptation *bandwidthAdaptation
+}
+
+func (w *bandwidthRTCPWriter) Write(p []byte) (int, error) {
+	if pkts, err := rtcp.Unmarshal(p); err == nil {
+		w.adaptation.handleRTCP(w.ssrc, pkts, time.Now())
+	}
+	return w.ReadWriteCloser.Write(p)
+}
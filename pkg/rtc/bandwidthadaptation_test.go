@@ -0,0 +1,78 @@
+package rtc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pion/rtcp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+func TestBandwidthAdaptation(t *testing.T) {
+	simulcast := [3]uint64{150_000, 500_000, 1_500_000}
+	tracks := []budgetedTrack{
+		{id: "TR_audio", minBitrate: 50_000},
+		{id: "TR_camera", video: true, minBitrate: 150_000, targetLayer: 2, bitrates: simulcast},
+	}
+	remb := func(a *bandwidthAdaptation, bitrate uint64, now time.Time) {
+		a.handleRTCP(1, []rtcp.Packet{&rtcp.ReceiverEstimatedMaximumBitrate{Bitrate: bitrate, SSRCs: []uint32{1}}}, now)
+	}
+	a := newBandwidthAdaptation(config.BandwidthAdaptationConfig{
+		Enabled:         true,
+		UpgradeHeadroom: 0.25,
+		UpgradeHold:     5 * time.Second,
+	})
+	now := time.Now()
+
+	// not limited until there's an estimate
+	require.Empty(t, a.update(tracks, now))
+	require.Zero(t, a.getEstimate())
+
+	remb(a, 3_000_000, now)
+	require.Equal(t, map[string]int32{"TR_camera": 2}, a.update(tracks, now))
+
+	// lowered right away, to the lowest layer when none fits
+	now = now.Add(time.Second)
+	remb(a, 600_000, now)
+	require.Equal(t, map[string]int32{"TR_camera": 1}, a.update(tracks, now))
+	remb(a, 100_000, now)
+	require.Equal(t, map[string]int32{"TR_camera": 0}, a.update(tracks, now))
+
+	// raised once nothing has been lowered for the hold, a layer at a time
+	remb(a, 3_000_000, now)
+	now = now.Add(time.Second)
+	require.Equal(t, map[string]int32{"TR_camera": 0}, a.update(tracks, now))
+	now = now.Add(5 * time.Second)
+	require.Equal(t, map[string]int32{"TR_camera": 1}, a.update(tracks, now))
+	now = now.Add(time.Second)
+	require.Equal(t, map[string]int32{"TR_camera": 2}, a.update(tracks, now))
+
+	t.Run("upgrades need headroom", func(t *testing.T) {
+		a := newBandwidthAdaptation(config.BandwidthAdaptationConfig{UpgradeHeadroom: 0.25})
+		remb(a, 600_000, now)
+		require.Equal(t, map[string]int32{"TR_camera": 1}, a.update(tracks, now))
+		// fits the top layer, but not with headroom
+		remb(a, 1_600_000, now)
+		require.Equal(t, map[string]int32{"TR_camera": 1}, a.update(tracks, now))
+		remb(a, 2_000_000, now)
+		require.Equal(t, map[string]int32{"TR_camera": 2}, a.update(tracks, now))
+	})
+
+	t.Run("loss lowers the estimate", func(t *testing.T) {
+		a := newBandwidthAdaptation(config.BandwidthAdaptationConfig{})
+		remb(a, 2_000_000, now)
+		a.handleRTCP(1, []rtcp.Packet{&rtcp.ReceiverReport{
+			Reports: []rtcp.ReceptionReport{{SSRC: 1, FractionLost: 128}},
+		}}, now)
+		require.Equal(t, uint64(1_500_000), a.getEstimate())
+		require.Equal(t, map[string]int32{"TR_camera": 1}, a.update(tracks, now))
+
+		// reports of other streams are left to their own
+		a.handleRTCP(2, []rtcp.Packet{&rtcp.ReceiverReport{
+			Reports: []rtcp.ReceptionReport{{SSRC: 1, FractionLost: 255}},
+		}}, now)
+		require.Equal(t, uint64(1_500_000), a.getEstimate())
+	})
+}
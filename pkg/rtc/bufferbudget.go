@@ -0,0 +1,131 @@
+package rtc
+
+import (
+	"io"
+	"sync"
+	"sync/atomic"
+
+	"github.com/pion/transport/packetio"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+// bufferBudget limits memory taken by receive buffers of video streams published by a participant.
+// Each bound stream takes a fixed size ring buffer, which keeps its latest packets and drops the oldest.
+// Streams that don't fit in the budget aren't bound, their packets are dropped before reaching the buffer.
+// Audio streams take little memory and are never limited
+type bufferBudget struct {
+	limit       int
+	streamBytes int
+	dropped     uint64
+
+	lock sync.Mutex
+	used int
+	// ssrc => stream
+	streams map[uint32]*budgetedStream
+}
+
+type budgetedStream struct {
+	trackID  string
+	buffered bool
+}
+
+// newBufferBudget returns nil when participants aren't limited
+func newBufferBudget(conf ReceiverConfig) *bufferBudget {
+	if conf.participantBufferBytes <= 0 {
+		return nil
+	}
+	return &bufferBudget{
+		limit:       conf.participantBufferBytes,
+		streamBytes: conf.packetBufferSize * config.BufferSlotSize,
+		streams:     make(map[uint32]*budgetedStream),
+	}
+}
+
+// reserve returns true when the video stream fits in the budget and should be buffered
+func (b *bufferBudget) reserve(trackID string, ssrc uint32) bool {
+	if b == nil {
+		return true
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if stream := b.streams[ssrc]; stream != nil {
+		return stream.buffered
+	}
+	stream := &budgetedStream{trackID: trackID}
+	if b.used+b.streamBytes <= b.limit {
+		stream.buffered = true
+		b.used += b.streamBytes
+	}
+	b.streams[ssrc] = stream
+	return stream.buffered
+}
+
+// releaseTrack frees memory reserved for streams of the track
+func (b *bufferBudget) releaseTrack(trackID string) {
+	if b == nil {
+		return
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	for ssrc, stream := range b.streams {
+		if stream.trackID != trackID {
+			continue
+		}
+		if stream.buffered {
+			b.used -= b.streamBytes
+		}
+		delete(b.streams, ssrc)
+	}
+}
+
+func (b *bufferBudget) isDropped(ssrc uint32) bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	stream := b.streams[ssrc]
+	return stream != nil && !stream.buffered
+}
+
+// wrapBufferFactory drops packets of streams that exceeded the budget
+func (b *bufferBudget) wrapBufferFactory(
+	createBufferFunc func(packetType packetio.BufferPacketType, ssrc uint32) io.ReadWriteCloser,
+) func(packetType packetio.BufferPacketType, ssrc uint32) io.ReadWriteCloser {
+	return func(packetType packetio.BufferPacketType, ssrc uint32) io.ReadWriteCloser {
+		writer := createBufferFunc(packetType, ssrc)
+		if packetType == packetio.RTPBufferPacket {
+			return &budgetedWriter{
+				ReadWriteCloser: writer,
+				ssrc:            ssrc,
+				budget:          b,
+			}
+		}
+		return writer
+	}
+}
+
+func (b *bufferBudget) DebugInfo() map[string]interface{} {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return map[string]interface{}{
+		"Limit":          b.limit,
+		"Used":           b.used,
+		"DroppedPackets": atomic.LoadUint64(&b.dropped),
+	}
+}
+
+type budgetedWriter struct {
+	io.ReadWriteCloser
+	ssrc   uint32
+	budget *bufferBudget
+}
+
+func (w *budgetedWriter) Write(p []byte) (n int, err error) {
+	if w.budget.isDropped(w.ssrc) {
+		atomic.AddUint64(&w.budget.dropped, 1)
+		bufferDroppedPacketTotal.Inc()
+		return len(p), nil
+	}
+	return w.ReadWriteCloser.Write(p)
+}
@@ -0,0 +1,90 @@
+package rtc
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/pion/ion-sfu/pkg/buffer"
+	"github.com/pion/rtp"
+	"github.com/pion/transport/packetio"
+	"github.com/pion/webrtc/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/logger"
+)
+
+func TestBufferBudget(t *testing.T) {
+	t.Run("unlimited without participant limit", func(t *testing.T) {
+		b := newBufferBudget(ReceiverConfig{packetBufferSize: 500})
+		require.Nil(t, b)
+		require.True(t, b.reserve("track", 1))
+		b.releaseTrack("track")
+	})
+
+	t.Run("drops streams exceeding the limit", func(t *testing.T) {
+		streamBytes := 100 * config.BufferSlotSize
+		b := newBufferBudget(ReceiverConfig{packetBufferSize: 100, participantBufferBytes: 2 * streamBytes})
+
+		require.True(t, b.reserve("track1", 1))
+		require.True(t, b.reserve("track1", 2))
+		require.False(t, b.reserve("track1", 3))
+		// decision sticks to the stream
+		require.True(t, b.reserve("track1", 1))
+		require.False(t, b.reserve("track1", 3))
+
+		var written bytes.Buffer
+		create := b.wrapBufferFactory(func(packetType packetio.BufferPacketType, ssrc uint32) io.ReadWriteCloser {
+			return &nopCloser{&written}
+		})
+		_, _ = create(packetio.RTPBufferPacket, 1).Write([]byte{1})
+		_, _ = create(packetio.RTPBufferPacket, 3).Write([]byte{3})
+		require.Equal(t, []byte{1}, written.Bytes())
+		require.Equal(t, uint64(1), b.dropped)
+
+		b.releaseTrack("track1")
+		require.Zero(t, b.used)
+		require.True(t, b.reserve("track2", 4))
+		require.True(t, b.reserve("track2", 5))
+	})
+
+	t.Run("retransmits from a reduced buffer", func(t *testing.T) {
+		factory := buffer.NewBufferFactory(config.MinBufferPackets, logger.GetLogger())
+		buff := factory.GetOrNew(packetio.RTPBufferPacket, 1234).(*buffer.Buffer)
+		buff.Bind(webrtc.RTPParameters{
+			Codecs: []webrtc.RTPCodecParameters{{
+				RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeVP8, ClockRate: 90000},
+				PayloadType:        96,
+			}},
+		}, buffer.Options{MaxBitRate: 1e6})
+		defer buff.Close()
+
+		total := 2 * config.MinBufferPackets
+		for i := 0; i < total; i++ {
+			pkt := rtp.Packet{
+				Header:  rtp.Header{Version: 2, PayloadType: 96, SequenceNumber: uint16(i), Timestamp: uint32(i * 3000), SSRC: 1234},
+				Payload: []byte{0x10, 0x02},
+			}
+			b, err := pkt.Marshal()
+			require.NoError(t, err)
+			_, err = buff.Write(b)
+			require.NoError(t, err)
+		}
+
+		pktBuf := make([]byte, config.BufferSlotSize)
+		_, err := buff.GetPacket(pktBuf, uint16(total-config.MinBufferPackets/2))
+		require.NoError(t, err)
+		// oldest packets are gone
+		_, err = buff.GetPacket(pktBuf, 0)
+		require.Error(t, err)
+	})
+}
+
+type nopCloser struct {
+	io.ReadWriter
+}
+
+func (c *nopCloser) Close() error {
+	return nil
+}
@@ -0,0 +1,126 @@
+package rtc
+
+import (
+	"github.com/livekit/livekit-server/pkg/logger"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+	livekit "github.com/livekit/livekit-server/proto"
+)
+
+// BulkResult is the outcome of an operation applied to many participants of a room. Failing participants
+// don't stop the operation from being applied to the others
+type BulkResult struct {
+	// identities of participants the operation was applied to
+	Applied []string
+	// identity => error of participants it failed for
+	Failed map[string]error
+}
+
+// SetAllTracksMuted mutes or unmutes tracks published by all participants on behalf of the server, except the ones
+// with the given sids. Participants can't unmute tracks muted this way. Participant updates are broadcast once for
+// all of them
+func (r *Room) SetAllTracksMuted(muted bool, exceptSids []string) BulkResult {
+	return r.applyToParticipants(exceptSids, false, func(p types.Participant) error {
+		if p.State() == livekit.ParticipantInfo_DISCONNECTED {
+			return ErrParticipantDisconnected
+		}
+		var firstErr error
+		for _, track := range p.GetPublishedTracks() {
+			// tracks unpublished meanwhile have nothing left to mute
+			if err := p.MutePublishedTrack(track.ID(), muted); err != nil && err != ErrTrackNotPublished && firstErr == nil {
+				firstErr = err
+			}
+		}
+		return firstErr
+	})
+}
+
+// RemoveAll removes all participants except hosts and the ones with the given sids.
+// Their departure is broadcast once for all of them
+func (r *Room) RemoveAll(exceptSids []string) BulkResult {
+	return r.applyToParticipants(exceptSids, true, func(p types.Participant) error {
+		p.SetDisconnectReason(types.DisconnectReasonRemoved)
+		return r.removeParticipant(p.Identity())
+	})
+}
+
+func (r *Room) applyToParticipants(exceptSids []string, exceptHosts bool, op func(p types.Participant) error) BulkResult {
+	excepted := make(map[string]bool, len(exceptSids))
+	for _, sid := range exceptSids {
+		excepted[sid] = true
+	}
+	r.lock.RLock()
+	participants := make([]types.Participant, 0, len(r.participants))
+	for identity, p := range r.participants {
+		if excepted[p.ID()] || (exceptHosts && r.isHost(identity)) {
+			continue
+		}
+		participants = append(participants, p)
+	}
+	r.lock.RUnlock()
+
+	r.startBatch()
+	defer r.flushBatch()
+
+	result := BulkResult{Failed: make(map[string]error)}
+	for _, p := range participants {
+		if err := op(p); err != nil {
+			logger.Warnw("could not apply bulk operation to participant", err,
+				"room", r.Room.Name,
+				"participant", p.Identity())
+			result.Failed[p.Identity()] = err
+			continue
+		}
+		result.Applied = append(result.Applied, p.Identity())
+	}
+	return result
+}
+
+func (r *Room) startBatch() {
+	r.batchLock.Lock()
+	r.batching++
+	r.batchLock.Unlock()
+}
+
+// flushBatch broadcasts updates held during the batch in a single message, once no batch is in progress
+func (r *Room) flushBatch() {
+	r.batchLock.Lock()
+	r.batching--
+	if r.batching > 0 {
+		r.batchLock.Unlock()
+		return
+	}
+	pending := make([]types.Participant, 0, len(r.pendingUpdates))
+	for _, p := range r.pendingUpdates {
+		pending = append(pending, p)
+	}
+	r.pendingUpdates = nil
+	r.batchLock.Unlock()
+	if len(pending) == 0 {
+		return
+	}
+
+	updates := ToProtoParticipants(pending)
+	for _, op := range r.GetParticipants() {
+		if op.State() == livekit.ParticipantInfo_DISCONNECTED {
+			continue
+		}
+		if err := op.SendParticipantUpdate(updates); err != nil {
+			logger.Errorw("could not send update to participant", err,
+				"participant", op.Identity())
+		}
+	}
+}
+
+// holdUpdate returns true when the participant's update is held until the batch in progress is done
+func (r *Room) holdUpdate(p types.Participant) bool {
+	r.batchLock.Lock()
+	defer r.batchLock.Unlock()
+	if r.batching == 0 {
+		return false
+	}
+	if r.pendingUpdates == nil {
+		r.pendingUpdates = make(map[string]types.Participant)
+	}
+	r.pendingUpdates[p.ID()] = p
+	return true
+}
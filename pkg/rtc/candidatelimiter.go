@@ -0,0 +1,74 @@
+package rtc
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pion/webrtc/v3"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+// candidateLimiter limits the number of trickle candidates accepted from a client for a single PeerConnection.
+// duplicate candidates are filtered out and do not count towards the limit
+type candidateLimiter struct {
+	config config.TrickleLimitConfig
+
+	mu          sync.Mutex
+	seen        map[string]struct{}
+	windowStart time.Time
+	count       int
+	dropped     int
+}
+
+func newCandidateLimiter(conf config.TrickleLimitConfig) *candidateLimiter {
+	return &candidateLimiter{
+		config: conf,
+		seen:   make(map[string]struct{}),
+	}
+}
+
+// allow returns true if the candidate should be added to the PeerConnection
+// dropped is incremented for candidates that exceeded the limit
+func (l *candidateLimiter) allow(candidate webrtc.ICECandidateInit) (ok bool, duplicate bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, exists := l.seen[candidate.Candidate]; exists {
+		return false, true
+	}
+
+	if l.config.MaxCandidates > 0 {
+		now := time.Now()
+		if now.Sub(l.windowStart) > l.config.Period {
+			l.windowStart = now
+			l.count = 0
+			l.dropped = 0
+		}
+		if l.count >= l.config.MaxCandidates {
+			l.dropped++
+			return false, false
+		}
+		l.count++
+	}
+
+	l.seen[candidate.Candidate] = struct{}{}
+	return true, false
+}
+
+// numDropped returns the number of candidates dropped in the current window
+func (l *candidateLimiter) numDropped() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.dropped
+}
+
+// reset clears state, ICE restarts would generate a new set of candidates
+func (l *candidateLimiter) reset() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.seen = make(map[string]struct{})
+	l.windowStart = time.Time{}
+	l.count = 0
+	l.dropped = 0
+}
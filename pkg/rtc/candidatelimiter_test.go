@@ -0,0 +1,77 @@
+package rtc
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/pion/webrtc/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+func TestCandidateLimiter(t *testing.T) {
+	conf := config.TrickleLimitConfig{
+		MaxCandidates: 3,
+		Period:        time.Minute,
+	}
+
+	t.Run("drops candidates over the limit", func(t *testing.T) {
+		l := newCandidateLimiter(conf)
+		for i := 0; i < 3; i++ {
+			ok, _ := l.allow(candidateForTest(i))
+			require.True(t, ok)
+		}
+		ok, duplicate := l.allow(candidateForTest(3))
+		require.False(t, ok)
+		require.False(t, duplicate)
+		require.Equal(t, 1, l.numDropped())
+	})
+
+	t.Run("duplicates are ignored and not counted", func(t *testing.T) {
+		l := newCandidateLimiter(conf)
+		ok, _ := l.allow(candidateForTest(0))
+		require.True(t, ok)
+		for i := 0; i < 5; i++ {
+			ok, duplicate := l.allow(candidateForTest(0))
+			require.False(t, ok)
+			require.True(t, duplicate)
+		}
+		ok, _ = l.allow(candidateForTest(1))
+		require.True(t, ok)
+		require.Zero(t, l.numDropped())
+	})
+
+	t.Run("reset allows a new set of candidates", func(t *testing.T) {
+		l := newCandidateLimiter(conf)
+		for i := 0; i < 4; i++ {
+			l.allow(candidateForTest(i))
+		}
+		l.reset()
+		for i := 0; i < 3; i++ {
+			ok, _ := l.allow(candidateForTest(i))
+			require.True(t, ok)
+		}
+	})
+
+	t.Run("no limit when disabled", func(t *testing.T) {
+		l := newCandidateLimiter(config.TrickleLimitConfig{})
+		for i := 0; i < 100; i++ {
+			ok, _ := l.allow(candidateForTest(i))
+			require.True(t, ok)
+		}
+	})
+}
+
+func TestICEUfrag(t *testing.T) {
+	sdp := "v=0\r\no=- 0 0 IN IP4 127.0.0.1\r\na=ice-ufrag:abcd\r\na=ice-pwd:efgh\r\n"
+	require.Equal(t, "abcd", ICEUfrag(sdp))
+	require.Equal(t, "", ICEUfrag("v=0\r\n"))
+}
+
+func candidateForTest(i int) webrtc.ICECandidateInit {
+	return webrtc.ICECandidateInit{
+		Candidate: fmt.Sprintf("candidate:%d 1 udp 2130706431 10.0.0.1 %d typ host", i, 50000+i),
+	}
+}
@@ -0,0 +1,62 @@
+package rtc
+
+import (
+	"net"
+	"sync"
+
+	"github.com/pion/webrtc/v3"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+// candidateQueue orders local candidates sent to the client by IP family preference.
+// candidates of other families are held back until gathering completes, so clients try the preferred family
+// first, while the rest are still sent as a fallback
+type candidateQueue struct {
+	preferred string
+
+	mu       sync.Mutex
+	deferred []*webrtc.ICECandidate
+}
+
+func newCandidateQueue(ipFamilies []string) *candidateQueue {
+	q := &candidateQueue{}
+	// nothing to order when only a single family is gathered
+	if len(ipFamilies) > 1 {
+		q.preferred = ipFamilies[0]
+	}
+	return q
+}
+
+// push returns candidates that should be sent to the client, c is nil once gathering has completed
+func (q *candidateQueue) push(c *webrtc.ICECandidate) []*webrtc.ICECandidate {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if c == nil {
+		deferred := q.deferred
+		q.deferred = nil
+		return deferred
+	}
+	if family := candidateIPFamily(c); q.preferred == "" || family == "" || family == q.preferred {
+		return []*webrtc.ICECandidate{c}
+	}
+	q.deferred = append(q.deferred, c)
+	return nil
+}
+
+// candidateIPFamily returns the IP family of the candidate, or an empty string when the address isn't an IP
+func candidateIPFamily(c *webrtc.ICECandidate) string {
+	return ipFamily(net.ParseIP(c.Address))
+}
+
+func ipFamily(ip net.IP) string {
+	switch {
+	case ip == nil:
+		return ""
+	case ip.To4() != nil:
+		return config.IPFamilyV4
+	default:
+		return config.IPFamilyV6
+	}
+}
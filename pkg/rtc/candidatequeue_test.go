@@ -0,0 +1,35 @@
+package rtc
+
+import (
+	"testing"
+
+	"github.com/pion/webrtc/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+func TestCandidateQueue(t *testing.T) {
+	v4 := &webrtc.ICECandidate{Address: "10.0.0.1", Port: 5000, Protocol: webrtc.ICEProtocolUDP}
+	v6 := &webrtc.ICECandidate{Address: "fd00::1", Port: 5000, Protocol: webrtc.ICEProtocolUDP}
+	mdns := &webrtc.ICECandidate{Address: "abc.local", Port: 5000, Protocol: webrtc.ICEProtocolUDP}
+
+	t.Run("sends everything with a single family", func(t *testing.T) {
+		q := newCandidateQueue([]string{config.IPFamilyV4})
+		require.Equal(t, []*webrtc.ICECandidate{v6}, q.push(v6))
+		require.Equal(t, []*webrtc.ICECandidate{v4}, q.push(v4))
+		require.Empty(t, q.push(nil))
+	})
+
+	t.Run("holds back other families until gathering completes", func(t *testing.T) {
+		q := newCandidateQueue([]string{config.IPFamilyV6, config.IPFamilyV4})
+		require.Empty(t, q.push(v4))
+		require.Equal(t, []*webrtc.ICECandidate{v6}, q.push(v6))
+		require.Equal(t, []*webrtc.ICECandidate{mdns}, q.push(mdns))
+		require.Equal(t, []*webrtc.ICECandidate{v4}, q.push(nil))
+
+		// gathers again after ICE restart
+		require.Empty(t, q.push(v4))
+		require.Equal(t, []*webrtc.ICECandidate{v4}, q.push(nil))
+	})
+}
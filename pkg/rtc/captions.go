@@ -0,0 +1,115 @@
+package rtc
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	livekit "github.com/livekit/livekit-server/proto"
+)
+
+const (
+	// type of user packets carrying captions, set in their JSON payload
+	captionPacketType = "caption"
+	// a language is available from a publisher while it keeps sending captions in it
+	captionLanguageExpiry = 30 * time.Second
+)
+
+// captionHeader is the part of a caption payload read by the server, the rest is opaque
+type captionHeader struct {
+	Type string `json:"type"`
+	// BCP 47 language tag
+	Language string `json:"language"`
+}
+
+// captionLanguage returns the language of a caption packet, false when the packet isn't a caption
+func captionLanguage(dp *livekit.DataPacket) (string, bool) {
+	payload := dp.GetUser().GetPayload()
+	if len(payload) == 0 || payload[0] != '{' {
+		return "", false
+	}
+	var header captionHeader
+	if err := json.Unmarshal(payload, &header); err != nil {
+		return "", false
+	}
+	if header.Type != captionPacketType || header.Language == "" {
+		return "", false
+	}
+	return header.Language, true
+}
+
+// languageMatches returns true when a caption tag satisfies the preferred one, either exactly or as a regional
+// variant of it, e.g. en-US satisfies en but not the other way around
+func languageMatches(tag, preferred string) bool {
+	tag = strings.ToLower(tag)
+	preferred = strings.ToLower(preferred)
+	return tag == preferred || strings.HasPrefix(tag, preferred+"-")
+}
+
+// captionRouter decides which captions each subscriber receives. Publishers could caption in several languages,
+// subscribers get the ones in their preferred language, or in the default language when the publisher doesn't
+// caption in the preferred one
+type captionRouter struct {
+	defaultLanguage string
+
+	lock sync.Mutex
+	// publisher sid => language => when a caption in it was last received
+	published map[string]map[string]time.Time
+}
+
+func newCaptionRouter(defaultLanguage string) *captionRouter {
+	return &captionRouter{
+		defaultLanguage: defaultLanguage,
+		published:       make(map[string]map[string]time.Time),
+	}
+}
+
+// observe records a caption received from the publisher
+func (c *captionRouter) observe(sourceID, language string, now time.Time) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	languages := c.published[sourceID]
+	if languages == nil {
+		languages = make(map[string]time.Time)
+		c.published[sourceID] = languages
+	}
+	languages[strings.ToLower(language)] = now
+}
+
+func (c *captionRouter) removeSource(sourceID string) {
+	c.lock.Lock()
+	delete(c.published, sourceID)
+	c.lock.Unlock()
+}
+
+// shouldForward returns true when a caption of the publisher should be sent to a subscriber with the preferred
+// language, which is empty when the subscriber has none
+func (c *captionRouter) shouldForward(sourceID, language, preferred string, now time.Time) bool {
+	if preferred == "" {
+		preferred = c.defaultLanguage
+	}
+	if preferred == "" {
+		return true
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.isPublished(sourceID, preferred, now) {
+		return languageMatches(language, preferred)
+	}
+	if c.defaultLanguage != "" && c.isPublished(sourceID, c.defaultLanguage, now) {
+		return languageMatches(language, c.defaultLanguage)
+	}
+	return false
+}
+
+// needs to be called with lock held
+func (c *captionRouter) isPublished(sourceID, preferred string, now time.Time) bool {
+	for language, lastSeen := range c.published[sourceID] {
+		if now.Sub(lastSeen) <= captionLanguageExpiry && languageMatches(language, preferred) {
+			return true
+		}
+	}
+	return false
+}
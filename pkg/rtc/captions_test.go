@@ -0,0 +1,93 @@
+package rtc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	livekit "github.com/livekit/livekit-server/proto"
+)
+
+func TestCaptionLanguage(t *testing.T) {
+	packet := func(payload string) *livekit.DataPacket {
+		return &livekit.DataPacket{Value: &livekit.DataPacket_User{User: &livekit.UserPacket{Payload: []byte(payload)}}}
+	}
+
+	language, ok := captionLanguage(packet(`{"type": "caption", "language": "es", "text": "hola"}`))
+	require.True(t, ok)
+	require.Equal(t, "es", language)
+
+	for _, payload := range []string{
+		"message..",
+		`{"type": "chat", "language": "es"}`,
+		`{"type": "caption"}`,
+		`{"type": "caption", "language": `,
+	} {
+		_, ok = captionLanguage(packet(payload))
+		require.False(t, ok, payload)
+	}
+	_, ok = captionLanguage(&livekit.DataPacket{Value: &livekit.DataPacket_Speaker{}})
+	require.False(t, ok)
+}
+
+func TestCaptionRouter(t *testing.T) {
+	now := time.Now()
+
+	t.Run("sends captions in the preferred language", func(t *testing.T) {
+		c := newCaptionRouter("en")
+		c.observe("pub", "en", now)
+		c.observe("pub", "ES", now)
+		require.True(t, c.shouldForward("pub", "ES", "es", now))
+		require.False(t, c.shouldForward("pub", "en", "es", now))
+		// without a preference, the default language is sent
+		require.True(t, c.shouldForward("pub", "en", "", now))
+		require.False(t, c.shouldForward("pub", "ES", "", now))
+	})
+
+	t.Run("falls back to the default language", func(t *testing.T) {
+		c := newCaptionRouter("en")
+		c.observe("pub", "en-US", now)
+		require.True(t, c.shouldForward("pub", "en-US", "fr", now))
+
+		// until the publisher captions in the preferred language
+		c.observe("pub", "fr", now)
+		require.False(t, c.shouldForward("pub", "en-US", "fr", now))
+		require.True(t, c.shouldForward("pub", "fr", "fr", now))
+
+		// languages that aren't captioned anymore expire
+		later := now.Add(captionLanguageExpiry + time.Second)
+		c.observe("pub", "en-US", later)
+		require.True(t, c.shouldForward("pub", "en-US", "fr", later))
+	})
+
+	t.Run("drops captions when neither language is available", func(t *testing.T) {
+		c := newCaptionRouter("en")
+		c.observe("pub", "de", now)
+		require.False(t, c.shouldForward("pub", "de", "fr", now))
+
+		// without a default language, subscribers without a preference get everything
+		c = newCaptionRouter("")
+		c.observe("pub", "de", now)
+		require.True(t, c.shouldForward("pub", "de", "", now))
+		require.False(t, c.shouldForward("pub", "de", "fr", now))
+	})
+
+	t.Run("tracks languages of each publisher", func(t *testing.T) {
+		c := newCaptionRouter("en")
+		c.observe("pub1", "es", now)
+		c.observe("pub2", "en", now)
+		require.True(t, c.shouldForward("pub1", "es", "es", now))
+		require.True(t, c.shouldForward("pub2", "en", "es", now))
+
+		c.removeSource("pub1")
+		require.Empty(t, c.published["pub1"])
+	})
+}
+
+func TestLanguageMatches(t *testing.T) {
+	require.True(t, languageMatches("en", "en"))
+	require.True(t, languageMatches("en-US", "EN"))
+	require.False(t, languageMatches("en", "en-US"))
+	require.False(t, languageMatches("eng", "en"))
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"net"
 	"syscall"
+	"time"
 
 	"github.com/pion/ice/v2"
 	"github.com/pion/ion-sfu/pkg/buffer"
@@ -26,11 +27,54 @@ type WebRTCConfig struct {
 	UDPMux         ice.UDPMux
 	UDPMuxConn     *net.UDPConn
 	TCPMuxListener *net.TCPListener
+	// IP families candidates are gathered for, ordered by preference
+	IPFamilies []string
+	// max number of sender reports and source description chunks in a single RTCP packet
+	SDESBatchSize int
+	// number of workers in the report pool of each room
+	ReportWorkers int
+	// number of RTCP batches queued for each publisher, see sendRTCP
+	RTCPQueueSize int
+	// interval of sender reports for subscribed tracks
+	SenderReportInterval time.Duration
+	// source descriptions sent when subscribed tracks are bound, repeated in case some are lost
+	BindingReportInterval time.Duration
+	BindingReportAttempts int
+	// pacing of bitrate spikes in video sent to subscribers
+	KeyframePacing config.KeyframePacingConfig
+	// detection of participants speaking
+	Speaking config.SpeakingConfig
+	// detection of publishers sending silent audio or black video
+	SilentMedia config.SilentMediaConfig
+	// reception quality of subscribers reported to publishers
+	SubscriberReports config.SubscriberReportsConfig
+	// config.TrackKindMismatchFix or config.TrackKindMismatchReject
+	TrackKindMismatch string
+	// config.RejectedSubscriptionsRemove or config.RejectedSubscriptionsKeep
+	RejectedSubscriptions string
+	// reordering of RTP published tracks receive out of order
+	ReorderBuffer config.ReorderBufferConfig
 }
 
 type ReceiverConfig struct {
 	packetBufferSize int
-	maxBitrate       uint64
+	// shortest retransmission window subscribers could ask for
+	minNackWindow int
+	maxBitrate    uint64
+	// bytes buffered for video streams of each participant, 0 when unlimited
+	participantBufferBytes int
+}
+
+// nackWindow bounds the retransmission window asked for by a subscriber, 0 for the full packet buffer.
+// A longer window than the buffer wouldn't help, older packets can't be retransmitted
+func (c ReceiverConfig) nackWindow(requested int) int {
+	if requested <= 0 || requested > c.packetBufferSize {
+		return c.packetBufferSize
+	}
+	if requested < c.minNackWindow {
+		return c.minNackWindow
+	}
+	return requested
 }
 
 // number of packets to buffer up
@@ -53,12 +97,50 @@ func NewWebRTCConfig(conf *config.Config, externalIP string) (*WebRTCConfig, err
 	if rtcConf.PacketBufferSize == 0 {
 		rtcConf.PacketBufferSize = 500
 	}
+	if limit := rtcConf.BufferLimits.TrackBytes / config.BufferSlotSize; limit > 0 && limit < rtcConf.PacketBufferSize {
+		rtcConf.PacketBufferSize = limit
+	}
+	if rtcConf.SDESBatchSize == 0 {
+		rtcConf.SDESBatchSize = defaultSDESBatchSize
+	}
+	if rtcConf.ReportWorkers == 0 {
+		rtcConf.ReportWorkers = defaultReportWorkers
+	}
+	if rtcConf.RTCPQueueSize == 0 {
+		rtcConf.RTCPQueueSize = defaultRTCPQueueSize
+	}
+	if rtcConf.SenderReportInterval == 0 {
+		rtcConf.SenderReportInterval = defaultSenderReportInterval
+	}
+	if rtcConf.BindingReportInterval == 0 {
+		rtcConf.BindingReportInterval = defaultBindingReportInterval
+	}
+	if rtcConf.BindingReportAttempts == 0 {
+		rtcConf.BindingReportAttempts = defaultBindingReportAttempts
+	}
+
+	ipFamilies := rtcConf.IPFamilies
+	if len(ipFamilies) == 0 {
+		ipFamilies = []string{config.IPFamilyV4}
+	}
+	useIPv4, useIPv6 := false, false
+	for _, family := range ipFamilies {
+		switch family {
+		case config.IPFamilyV4:
+			useIPv4 = true
+		case config.IPFamilyV6:
+			useIPv6 = true
+		}
+	}
 
 	networkTypes := make([]webrtc.NetworkType, 0, 4)
 	if !rtcConf.ForceTCP {
-		networkTypes = append(networkTypes,
-			webrtc.NetworkTypeUDP4,
-		)
+		if useIPv4 {
+			networkTypes = append(networkTypes, webrtc.NetworkTypeUDP4)
+		}
+		if useIPv6 {
+			networkTypes = append(networkTypes, webrtc.NetworkTypeUDP6)
+		}
 	}
 
 	var udpMux *ice.UDPMuxDefault
@@ -70,6 +152,10 @@ func NewWebRTCConfig(conf *config.Config, externalIP string) (*WebRTCConfig, err
 			return nil, err
 		}
 	} else if rtcConf.UDPPort != 0 {
+		// UDP mux only advertises IPv4 host candidates
+		if !useIPv4 {
+			return nil, errors.New("UDP port requires the ipv4 IP family, use a port range for ipv6")
+		}
 		udpMuxConn, err = net.ListenUDP("udp4", &net.UDPAddr{
 			Port: int(rtcConf.UDPPort),
 		})
@@ -101,10 +187,18 @@ func NewWebRTCConfig(conf *config.Config, externalIP string) (*WebRTCConfig, err
 	// use TCP mux when it's set
 	var tcpListener *net.TCPListener
 	if rtcConf.TCPPort != 0 {
-		networkTypes = append(networkTypes,
-			webrtc.NetworkTypeTCP4,
-		)
-		tcpListener, err = net.ListenTCP("tcp4", &net.TCPAddr{
+		tcpNetwork := "tcp"
+		if useIPv4 {
+			networkTypes = append(networkTypes, webrtc.NetworkTypeTCP4)
+		} else {
+			tcpNetwork = "tcp6"
+		}
+		if useIPv6 {
+			networkTypes = append(networkTypes, webrtc.NetworkTypeTCP6)
+		} else {
+			tcpNetwork = "tcp4"
+		}
+		tcpListener, err = net.ListenTCP(tcpNetwork, &net.TCPAddr{
 			Port: int(rtcConf.TCPPort),
 		})
 		if err != nil {
@@ -124,12 +218,30 @@ func NewWebRTCConfig(conf *config.Config, externalIP string) (*WebRTCConfig, err
 		Configuration: c,
 		SettingEngine: s,
 		Receiver: ReceiverConfig{
-			packetBufferSize: rtcConf.PacketBufferSize,
-			maxBitrate:       rtcConf.MaxBitrate,
+			packetBufferSize:       rtcConf.PacketBufferSize,
+			minNackWindow:          rtcConf.MinNackWindow,
+			maxBitrate:             rtcConf.MaxBitrate,
+			participantBufferBytes: rtcConf.BufferLimits.ParticipantBytes,
 		},
 		UDPMux:         udpMux,
 		UDPMuxConn:     udpMuxConn,
 		TCPMuxListener: tcpListener,
+		IPFamilies:     ipFamilies,
+		SDESBatchSize:  rtcConf.SDESBatchSize,
+		ReportWorkers:  rtcConf.ReportWorkers,
+		RTCPQueueSize:  rtcConf.RTCPQueueSize,
+
+		SenderReportInterval:  rtcConf.SenderReportInterval,
+		BindingReportInterval: rtcConf.BindingReportInterval,
+		BindingReportAttempts: rtcConf.BindingReportAttempts,
+		KeyframePacing:        rtcConf.KeyframePacing,
+		Speaking:              rtcConf.Speaking,
+		SilentMedia:           rtcConf.SilentMedia,
+
+		SubscriberReports:     rtcConf.SubscriberReports,
+		TrackKindMismatch:     rtcConf.TrackKindMismatch,
+		RejectedSubscriptions: rtcConf.RejectedSubscriptions,
+		ReorderBuffer:         rtcConf.ReorderBuffer,
 	}, nil
 }
 
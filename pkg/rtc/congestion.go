@@ -0,0 +1,104 @@
+package rtc
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pion/rtcp"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+const (
+	// interval the estimate of each publisher is checked against its target
+	congestionCheckInterval = time.Second
+	// type of user packets telling publishers about their congestion state, set in their JSON payload
+	congestionPacketType = "publisher_congestion"
+)
+
+// congestionSignal is the payload of data packets sent to a publisher when its congestion state changes
+type congestionSignal struct {
+	Type      string `json:"type"`
+	Congested bool   `json:"congested"`
+}
+
+// publisherCongestion tells when a publisher can't send its target bitrate. The estimate of each stream is the
+// latest REMB sent to the publisher, or the bitrate received from it when it uses transport-wide congestion
+// control, as its estimate is then only known to the publisher.
+// Dips shorter than CongestedAfter are ignored, and the publisher recovers once it has had headroom for
+// RecoveredAfter, so the state doesn't flap
+type publisherCongestion struct {
+	conf config.PublisherCongestionConfig
+
+	lock sync.Mutex
+	// SSRC => latest REMB estimate, in bits per second
+	remb      map[uint32]uint64
+	congested bool
+	// when the estimate crossed the threshold towards the other state, zero while it's on the side of the current one
+	crossedAt time.Time
+}
+
+func newPublisherCongestion(conf config.PublisherCongestionConfig) *publisherCongestion {
+	return &publisherCongestion{
+		conf: conf,
+		remb: make(map[uint32]uint64),
+	}
+}
+
+func (c *publisherCongestion) observeREMB(remb *rtcp.ReceiverEstimatedMaximumBitrate) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for _, ssrc := range remb.SSRCs {
+		c.remb[ssrc] = remb.Bitrate
+	}
+}
+
+// update compares the estimate of the streams the publisher sends against their target bitrate, and returns the
+// congestion state, with changed set when it's different from the last update
+func (c *publisherCongestion) update(streams []types.BufferStats, target uint64, now time.Time) (congested bool, changed bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	var estimate uint64
+	seen := make(map[uint32]bool, len(streams))
+	for _, stream := range streams {
+		seen[stream.SSRC] = true
+		if remb, ok := c.remb[stream.SSRC]; ok {
+			estimate += remb
+		} else {
+			estimate += stream.Bitrate
+		}
+	}
+	// streams that are gone
+	for ssrc := range c.remb {
+		if !seen[ssrc] {
+			delete(c.remb, ssrc)
+		}
+	}
+
+	var crossed bool
+	if c.congested {
+		// nothing to send is plenty of headroom
+		crossed = target == 0 || float64(estimate) >= float64(target)*c.conf.RecoveryThreshold
+	} else {
+		crossed = target > 0 && float64(estimate) < float64(target)*c.conf.Threshold
+	}
+	if !crossed {
+		c.crossedAt = time.Time{}
+		return c.congested, false
+	}
+	if c.crossedAt.IsZero() {
+		c.crossedAt = now
+	}
+	hold := c.conf.CongestedAfter
+	if c.congested {
+		hold = c.conf.RecoveredAfter
+	}
+	if now.Sub(c.crossedAt) < hold {
+		return c.congested, false
+	}
+	c.congested = !c.congested
+	c.crossedAt = time.Time{}
+	return c.congested, true
+}
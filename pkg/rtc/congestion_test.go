@@ -0,0 +1,86 @@
+package rtc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pion/rtcp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+func TestPublisherCongestion(t *testing.T) {
+	conf := config.PublisherCongestionConfig{
+		Enabled:           true,
+		Threshold:         0.7,
+		CongestedAfter:    5 * time.Second,
+		RecoveryThreshold: 0.9,
+		RecoveredAfter:    10 * time.Second,
+	}
+	streams := func(bitrate uint64) []types.BufferStats {
+		return []types.BufferStats{{SSRC: 1000, Bitrate: bitrate}}
+	}
+	now := time.Now()
+
+	t.Run("ignores dips shorter than congested_after", func(t *testing.T) {
+		c := newPublisherCongestion(conf)
+		congested, changed := c.update(streams(500_000), 1_000_000, now)
+		require.False(t, congested)
+		require.False(t, changed)
+		_, changed = c.update(streams(500_000), 1_000_000, now.Add(4*time.Second))
+		require.False(t, changed)
+
+		// back above the threshold restarts the wait
+		_, changed = c.update(streams(800_000), 1_000_000, now.Add(5*time.Second))
+		require.False(t, changed)
+		_, changed = c.update(streams(500_000), 1_000_000, now.Add(6*time.Second))
+		require.False(t, changed)
+		congested, changed = c.update(streams(500_000), 1_000_000, now.Add(11*time.Second))
+		require.True(t, congested)
+		require.True(t, changed)
+	})
+
+	t.Run("recovers once it has headroom for recovered_after", func(t *testing.T) {
+		c := newPublisherCongestion(conf)
+		c.update(streams(500_000), 1_000_000, now)
+		congested, _ := c.update(streams(500_000), 1_000_000, now.Add(5*time.Second))
+		require.True(t, congested)
+
+		// above the congestion threshold, but without enough headroom
+		congested, changed := c.update(streams(800_000), 1_000_000, now.Add(6*time.Second))
+		require.True(t, congested)
+		require.False(t, changed)
+		_, changed = c.update(streams(950_000), 1_000_000, now.Add(7*time.Second))
+		require.False(t, changed)
+		congested, changed = c.update(streams(950_000), 1_000_000, now.Add(17*time.Second))
+		require.False(t, congested)
+		require.True(t, changed)
+	})
+
+	t.Run("recovers when there's no video to send", func(t *testing.T) {
+		c := newPublisherCongestion(conf)
+		c.update(streams(0), 1_000_000, now)
+		congested, _ := c.update(streams(0), 1_000_000, now.Add(5*time.Second))
+		require.True(t, congested)
+
+		congested, _ = c.update(nil, 0, now.Add(6*time.Second))
+		require.True(t, congested)
+		congested, changed := c.update(nil, 0, now.Add(16*time.Second))
+		require.False(t, congested)
+		require.True(t, changed)
+	})
+
+	t.Run("prefers REMB estimates over received bitrate", func(t *testing.T) {
+		c := newPublisherCongestion(conf)
+		c.observeREMB(&rtcp.ReceiverEstimatedMaximumBitrate{Bitrate: 300_000, SSRCs: []uint32{1000}})
+		c.update(streams(1_000_000), 1_000_000, now)
+		congested, _ := c.update(streams(1_000_000), 1_000_000, now.Add(5*time.Second))
+		require.True(t, congested)
+
+		// estimates of streams that are gone are dropped
+		c.update([]types.BufferStats{{SSRC: 2000, Bitrate: 1_000_000}}, 1_000_000, now.Add(6*time.Second))
+		require.Empty(t, c.remb)
+	})
+}
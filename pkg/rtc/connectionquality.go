@@ -0,0 +1,149 @@
+package rtc
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pion/rtcp"
+
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+const (
+	// connection quality is rated at most this often
+	connectionQualityInterval = time.Second
+	// reports of subscribers older than this aren't rated, they could have stopped reporting on a track
+	connectionQualityReportAge = 5 * time.Second
+	// quality is the median of this many ratings, a single bad report doesn't change it
+	connectionQualityWindow = 3
+)
+
+// type of user packets telling participants the connection quality of one of them changed
+const connectionQualityPacketType = "connection_quality"
+
+// connectionQualitySignal is the payload of data packets sent to participants when the connection quality of one
+// changes, and for those that aren't excellent when they join
+type connectionQualitySignal struct {
+	Type     string `json:"type"`
+	Sid      string `json:"sid"`
+	Identity string `json:"identity"`
+	Quality  string `json:"quality"`
+}
+
+func newConnectionQualitySignal(p types.Participant, quality types.ConnectionQuality) connectionQualitySignal {
+	return connectionQualitySignal{
+		Type:     connectionQualityPacketType,
+		Sid:      p.ID(),
+		Identity: p.Identity(),
+		Quality:  quality.String(),
+	}
+}
+
+// connectionStats are averaged over the streams a participant publishes and subscribes to
+type connectionStats struct {
+	// 0 to 1
+	fractionLost float64
+	jitter       time.Duration
+	// 0 when unknown
+	rtt time.Duration
+}
+
+// rateConnection rates stats by their worst metric
+func rateConnection(stats connectionStats) types.ConnectionQuality {
+	switch {
+	case stats.fractionLost >= 0.08 || stats.jitter >= 60*time.Millisecond || stats.rtt >= 600*time.Millisecond:
+		return types.ConnectionQualityPoor
+	case stats.fractionLost >= 0.02 || stats.jitter >= 30*time.Millisecond || stats.rtt >= 300*time.Millisecond:
+		return types.ConnectionQualityGood
+	default:
+		return types.ConnectionQualityExcellent
+	}
+}
+
+// connectionQuality is the median of the latest ratings of a participant's connection
+type connectionQuality struct {
+	lock    sync.Mutex
+	ratings []types.ConnectionQuality
+	quality types.ConnectionQuality
+}
+
+// add rates stats, changed is true when it changes the quality
+func (c *connectionQuality) add(stats connectionStats) (quality types.ConnectionQuality, changed bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.ratings = append(c.ratings, rateConnection(stats))
+	if len(c.ratings) > connectionQualityWindow {
+		c.ratings = c.ratings[1:]
+	}
+	sorted := append([]types.ConnectionQuality(nil), c.ratings...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i] < sorted[j]
+	})
+	quality = sorted[(len(sorted)-1)/2]
+	changed = quality != c.quality
+	c.quality = quality
+	return
+}
+
+func (c *connectionQuality) get() types.ConnectionQuality {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.quality
+}
+
+// connectionStatsAggregate averages stats of streams
+type connectionStatsAggregate struct {
+	streams      int
+	fractionLost float64
+	jitter       time.Duration
+	rtts         int
+	rtt          time.Duration
+}
+
+func (a *connectionStatsAggregate) add(fractionLost float64, jitter time.Duration, rtt time.Duration) {
+	a.streams++
+	a.fractionLost += fractionLost
+	a.jitter += jitter
+	if rtt > 0 {
+		a.rtts++
+		a.rtt += rtt
+	}
+}
+
+func (a *connectionStatsAggregate) stats() (connectionStats, bool) {
+	if a.streams == 0 {
+		return connectionStats{}, false
+	}
+	stats := connectionStats{
+		fractionLost: a.fractionLost / float64(a.streams),
+		jitter:       a.jitter / time.Duration(a.streams),
+	}
+	if a.rtts > 0 {
+		stats.rtt = a.rtt / time.Duration(a.rtts)
+	}
+	return stats, true
+}
+
+// jitterDuration converts interarrival jitter in RTP timestamp units to a duration, 0 when the clock rate is unknown
+func jitterDuration(jitter float64, clockRate uint32) time.Duration {
+	if clockRate == 0 {
+		return 0
+	}
+	return time.Duration(jitter / float64(clockRate) * float64(time.Second))
+}
+
+// roundTripTime returns the round trip time of a reception report received at now, from the sender report it
+// refers to. 0 when it doesn't refer to one
+func roundTripTime(report rtcp.ReceptionReport, now time.Time) time.Duration {
+	if report.LastSenderReport == 0 {
+		return 0
+	}
+	// middle 32 bits of the NTP timestamp, in 1/65536 seconds
+	arrival := uint32(toNtpTime(now) >> 16)
+	rtt := arrival - report.LastSenderReport - report.Delay
+	if int32(rtt) <= 0 {
+		return 0
+	}
+	return time.Duration(uint64(rtt) * uint64(time.Second) >> 16)
+}
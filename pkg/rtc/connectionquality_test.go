@@ -0,0 +1,62 @@
+package rtc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pion/rtcp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+func TestConnectionQuality(t *testing.T) {
+	excellent := connectionStats{fractionLost: 0.01, jitter: 10 * time.Millisecond, rtt: 80 * time.Millisecond}
+	good := connectionStats{fractionLost: 0.01, jitter: 10 * time.Millisecond, rtt: 400 * time.Millisecond}
+	poor := connectionStats{fractionLost: 0.2}
+	require.Equal(t, types.ConnectionQualityExcellent, rateConnection(excellent))
+	require.Equal(t, types.ConnectionQualityGood, rateConnection(good))
+	require.Equal(t, types.ConnectionQualityPoor, rateConnection(poor))
+
+	c := &connectionQuality{}
+	quality, changed := c.add(excellent)
+	require.Equal(t, types.ConnectionQualityExcellent, quality)
+	require.False(t, changed)
+
+	// a single bad report doesn't change it
+	_, changed = c.add(poor)
+	require.False(t, changed)
+	_, changed = c.add(excellent)
+	require.False(t, changed)
+
+	_, changed = c.add(excellent)
+	require.False(t, changed)
+
+	_, changed = c.add(poor)
+	require.False(t, changed)
+	quality, changed = c.add(poor)
+	require.True(t, changed)
+	require.Equal(t, types.ConnectionQualityPoor, quality)
+	require.Equal(t, types.ConnectionQualityPoor, c.get())
+
+	_, changed = c.add(good)
+	require.False(t, changed)
+	quality, changed = c.add(good)
+	require.True(t, changed)
+	require.Equal(t, types.ConnectionQualityGood, quality)
+}
+
+func TestRoundTripTime(t *testing.T) {
+	now := time.Now()
+	sentAt := now.Add(-300 * time.Millisecond)
+	report := rtcp.ReceptionReport{
+		LastSenderReport: uint32(toNtpTime(sentAt) >> 16),
+		// held by the receiver for 100ms, in 1/65536 seconds
+		Delay: 65536 / 10,
+	}
+	require.InDelta(t, 200*time.Millisecond, roundTripTime(report, now), float64(time.Millisecond))
+
+	require.Zero(t, roundTripTime(rtcp.ReceptionReport{}, now))
+	report.Delay = 65536
+	require.Zero(t, roundTripTime(report, now))
+}
@@ -0,0 +1,106 @@
+package rtc
+
+import (
+	"sync"
+
+	"github.com/livekit/protocol/utils"
+	"github.com/pion/webrtc/v3"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/logger"
+)
+
+// dataChannel wraps a DataChannel to avoid queueing up messages indefinitely for slow receivers.
+// once too much data is buffered, messages are dropped until the receiver catches up
+type dataChannel struct {
+	dc        *webrtc.DataChannel
+	conf      config.SCTPConfig
+	congested utils.AtomicFlag
+}
+
+func newDataChannel(dc *webrtc.DataChannel, conf config.SCTPConfig) *dataChannel {
+	d := &dataChannel{
+		dc:   dc,
+		conf: conf,
+	}
+	if dc != nil && conf.MaxBufferedAmount > 0 {
+		dc.SetBufferedAmountLowThreshold(conf.BufferedAmountLowThreshold)
+		dc.OnBufferedAmountLow(func() {
+			if d.congested.TrySet(false) {
+				logger.Debugw("data channel drained", "label", dc.Label())
+			}
+		})
+	}
+	return d
+}
+
+func (d *dataChannel) Send(data []byte) error {
+	if d.conf.MaxMessageSize > 0 && len(data) > int(d.conf.MaxMessageSize) {
+		return ErrDataPacketTooLarge
+	}
+	if d.conf.MaxBufferedAmount > 0 {
+		if d.congested.Get() {
+			return ErrDataChannelCongested
+		}
+		if d.dc.BufferedAmount()+uint64(len(data)) > d.conf.MaxBufferedAmount {
+			if d.congested.TrySet(true) {
+				logger.Debugw("data channel congested, dropping messages", "label", d.dc.Label())
+			}
+			return ErrDataChannelCongested
+		}
+	}
+	return d.dc.Send(data)
+}
+
+// serverDataChannel is a data channel opened by the server, messages sent before it's open are held and sent
+// in order once it is
+type serverDataChannel struct {
+	*dataChannel
+
+	lock         sync.Mutex
+	opened       bool
+	pending      [][]byte
+	pendingBytes uint64
+}
+
+func newServerDataChannel(dc *webrtc.DataChannel, conf config.SCTPConfig) *serverDataChannel {
+	d := &serverDataChannel{
+		dataChannel: newDataChannel(dc, conf),
+	}
+	dc.OnOpen(d.flush)
+	return d
+}
+
+func (d *serverDataChannel) Send(data []byte) error {
+	d.lock.Lock()
+	if !d.opened {
+		defer d.lock.Unlock()
+		if d.conf.MaxMessageSize > 0 && len(data) > int(d.conf.MaxMessageSize) {
+			return ErrDataPacketTooLarge
+		}
+		// held messages count against the same budget as buffered ones
+		if d.conf.MaxBufferedAmount > 0 && d.pendingBytes+uint64(len(data)) > d.conf.MaxBufferedAmount {
+			return ErrDataChannelCongested
+		}
+		d.pending = append(d.pending, data)
+		d.pendingBytes += uint64(len(data))
+		return nil
+	}
+	d.lock.Unlock()
+	return d.dataChannel.Send(data)
+}
+
+func (d *serverDataChannel) flush() {
+	// sends are held until the pending messages are out, so they stay in order
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.opened = true
+	for _, data := range d.pending {
+		if err := d.dataChannel.Send(data); err != nil {
+			logger.Warnw("could not send pending data message", err, "label", d.dc.Label())
+			break
+		}
+	}
+	d.pending = nil
+	d.pendingBytes = 0
+}
@@ -0,0 +1,35 @@
+package rtc
+
+import (
+	"testing"
+
+	"github.com/pion/webrtc/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+func TestDataChannelSend(t *testing.T) {
+	pc, err := webrtc.NewPeerConnection(webrtc.Configuration{})
+	require.NoError(t, err)
+	defer pc.Close()
+	dc, err := pc.CreateDataChannel(reliableDataChannel, nil)
+	require.NoError(t, err)
+
+	t.Run("rejects messages over max size", func(t *testing.T) {
+		d := newDataChannel(dc, config.SCTPConfig{MaxMessageSize: 10})
+		require.Equal(t, ErrDataPacketTooLarge, d.Send(make([]byte, 11)))
+	})
+
+	t.Run("drops messages until buffer drains", func(t *testing.T) {
+		d := newDataChannel(dc, config.SCTPConfig{
+			MaxMessageSize:             100,
+			MaxBufferedAmount:          10,
+			BufferedAmountLowThreshold: 5,
+		})
+		require.Equal(t, ErrDataChannelCongested, d.Send(make([]byte, 20)))
+		require.True(t, d.congested.Get())
+		// small messages are dropped as well while congested
+		require.Equal(t, ErrDataChannelCongested, d.Send(make([]byte, 1)))
+	})
+}
@@ -0,0 +1,95 @@
+package rtc
+
+import (
+	"encoding/base64"
+	"encoding/json"
+
+	"google.golang.org/protobuf/proto"
+
+	livekit "github.com/livekit/livekit-server/proto"
+)
+
+// type of user packets carrying a part of a server message too large for a single packet, set in their JSON payload
+const dataChunkPacketType = "data_chunk"
+
+// dataChunkSignal is a part of a reliable server message split to fit SCTP.MaxMessageSize. Clients put the message
+// back together by concatenating Data of the Count chunks with the same ID, in Index order. Chunks are sent in order
+// on an ordered channel
+type dataChunkSignal struct {
+	Type  string `json:"type"`
+	ID    uint32 `json:"id"`
+	Index int    `json:"index"`
+	Count int    `json:"count"`
+	// base64 in JSON
+	Data []byte `json:"data"`
+}
+
+// newUserDataPacket encodes a user packet sent by the server, without a sender
+func newUserDataPacket(kind livekit.DataPacket_Kind, payload []byte) *livekit.DataPacket {
+	return &livekit.DataPacket{
+		Kind: kind,
+		Value: &livekit.DataPacket_User{
+			User: &livekit.UserPacket{Payload: payload},
+		},
+	}
+}
+
+// splitDataMessage splits payload into chunk packets of message id whose encoding fits in maxSize, nil when even
+// a chunk without data wouldn't
+func splitDataMessage(id uint32, payload []byte, maxSize int) []*livekit.DataPacket {
+	count := 1
+	var capacity int
+	for {
+		capacity = dataChunkCapacity(id, count, maxSize)
+		if capacity <= 0 {
+			return nil
+		}
+		// more chunks could make their headers larger, and their capacity smaller
+		needed := (len(payload) + capacity - 1) / capacity
+		if needed <= count {
+			break
+		}
+		count = needed
+	}
+
+	packets := make([]*livekit.DataPacket, 0, count)
+	for index := 0; index < count; index++ {
+		data := payload
+		if len(data) > capacity {
+			data = data[:capacity]
+		}
+		payload = payload[len(data):]
+		chunk, _ := json.Marshal(dataChunkSignal{
+			Type:  dataChunkPacketType,
+			ID:    id,
+			Index: index,
+			Count: count,
+			Data:  data,
+		})
+		packets = append(packets, newUserDataPacket(livekit.DataPacket_RELIABLE, chunk))
+	}
+	return packets
+}
+
+// dataChunkCapacity returns the bytes of a message each of count chunks could carry, once encoded in packets of at
+// most maxSize bytes
+func dataChunkCapacity(id uint32, count int, maxSize int) int {
+	// largest JSON payload fitting in a packet, the size of its length prefix depends on it
+	payloadSize := maxSize
+	for payloadSize > 0 && proto.Size(newUserDataPacket(livekit.DataPacket_RELIABLE, make([]byte, payloadSize))) > maxSize {
+		payloadSize--
+	}
+	// the last chunk has the largest index
+	header, _ := json.Marshal(dataChunkSignal{
+		Type:  dataChunkPacketType,
+		ID:    id,
+		Index: count - 1,
+		Count: count,
+	})
+	// data is null in header, and a quoted base64 string once set
+	encodedSize := payloadSize - len(header) + len("null") - len(`""`)
+	if encodedSize < base64.StdEncoding.EncodedLen(1) {
+		return 0
+	}
+	return encodedSize / 4 * 3
+}
@@ -0,0 +1,144 @@
+package rtc
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	livekit "github.com/livekit/livekit-server/proto"
+)
+
+// type of user packets telling senders their data packets are being dropped, set in their JSON payload
+const dataRateLimitedPacketType = "data_rate_limited"
+
+const (
+	dataLimitParticipant = "participant"
+	dataLimitRoom        = "room"
+)
+
+// dataRateLimitedSignal is the payload of data packets sent to a participant once its packets start being dropped
+type dataRateLimitedSignal struct {
+	Type string `json:"type"`
+	// channel the packets were sent on, reliable or lossy
+	Kind string `json:"kind"`
+	// participant when the sender exceeded its own limit, room when the room did
+	Scope string `json:"scope"`
+}
+
+func dataPacketKindName(kind livekit.DataPacket_Kind) string {
+	if kind == livekit.DataPacket_LOSSY {
+		return "lossy"
+	}
+	return "reliable"
+}
+
+// tokenBucket allows up to burst packets at once, refilled at rate packets per second
+type tokenBucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// newTokenBucket returns nil when the rate is unlimited
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	if rate <= 0 {
+		return nil
+	}
+	return &tokenBucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+	}
+}
+
+func (b *tokenBucket) allow(now time.Time) bool {
+	if b == nil {
+		return true
+	}
+	if !b.last.IsZero() {
+		b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	}
+	b.last = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// participantDataLimit is the bucket of a participant for one channel
+type participantDataLimit struct {
+	bucket *tokenBucket
+	// set while packets of the participant are dropped, it's told once each time it starts
+	limited bool
+}
+
+// dataRateLimiter limits data packets relayed in a room, per participant and for the whole room, with separate
+// limits for reliable and lossy channels. Participant limits are checked first, so a participant flooding the
+// room doesn't use up the room's limit for everyone else
+type dataRateLimiter struct {
+	conf config.DataRateLimitConfig
+
+	lock sync.Mutex
+	room map[livekit.DataPacket_Kind]*tokenBucket
+	// sid => kind => limit
+	participants map[string]map[livekit.DataPacket_Kind]*participantDataLimit
+}
+
+func newDataRateLimiter(conf config.DataRateLimitConfig) *dataRateLimiter {
+	return &dataRateLimiter{
+		conf: conf,
+		room: map[livekit.DataPacket_Kind]*tokenBucket{
+			livekit.DataPacket_RELIABLE: newTokenBucket(conf.Reliable.RoomRate, conf.Reliable.RoomBurst),
+			livekit.DataPacket_LOSSY:    newTokenBucket(conf.Lossy.RoomRate, conf.Lossy.RoomBurst),
+		},
+		participants: make(map[string]map[livekit.DataPacket_Kind]*participantDataLimit),
+	}
+}
+
+func (l *dataRateLimiter) limitFor(kind livekit.DataPacket_Kind) config.DataRateLimit {
+	if kind == livekit.DataPacket_LOSSY {
+		return l.conf.Lossy
+	}
+	return l.conf.Reliable
+}
+
+// allow returns true when a packet of the participant could be relayed. Otherwise scope tells which limit it
+// exceeded, and notify is set when it's the first packet dropped since the participant was last allowed to send
+func (l *dataRateLimiter) allow(sid string, kind livekit.DataPacket_Kind, now time.Time) (ok bool, scope string, notify bool) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	limits := l.participants[sid]
+	if limits == nil {
+		limits = make(map[livekit.DataPacket_Kind]*participantDataLimit)
+		l.participants[sid] = limits
+	}
+	limit := limits[kind]
+	if limit == nil {
+		conf := l.limitFor(kind)
+		limit = &participantDataLimit{bucket: newTokenBucket(conf.ParticipantRate, conf.ParticipantBurst)}
+		limits[kind] = limit
+	}
+
+	switch {
+	case !limit.bucket.allow(now):
+		scope = dataLimitParticipant
+	case !l.room[kind].allow(now):
+		scope = dataLimitRoom
+	default:
+		limit.limited = false
+		return true, "", false
+	}
+	notify = !limit.limited
+	limit.limited = true
+	return false, scope, notify
+}
+
+func (l *dataRateLimiter) removeParticipant(sid string) {
+	l.lock.Lock()
+	delete(l.participants, sid)
+	l.lock.Unlock()
+}
@@ -0,0 +1,93 @@
+package rtc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	livekit "github.com/livekit/livekit-server/proto"
+)
+
+func TestDataRateLimiter(t *testing.T) {
+	const reliable, lossy = livekit.DataPacket_RELIABLE, livekit.DataPacket_LOSSY
+	now := time.Now()
+	// sends n packets at once, returning how many were allowed
+	send := func(l *dataRateLimiter, sid string, kind livekit.DataPacket_Kind, n int, at time.Time) int {
+		allowed := 0
+		for i := 0; i < n; i++ {
+			if ok, _, _ := l.allow(sid, kind, at); ok {
+				allowed++
+			}
+		}
+		return allowed
+	}
+
+	t.Run("allows bursts and refills over time", func(t *testing.T) {
+		l := newDataRateLimiter(config.DataRateLimitConfig{
+			Reliable: config.DataRateLimit{ParticipantRate: 10, ParticipantBurst: 100},
+		})
+		// a transfer sending its window at once isn't throttled
+		require.Equal(t, 100, send(l, "PA_1", reliable, 100, now))
+		ok, scope, _ := l.allow("PA_1", reliable, now)
+		require.False(t, ok)
+		require.Equal(t, dataLimitParticipant, scope)
+
+		require.Equal(t, 5, send(l, "PA_1", reliable, 10, now.Add(500*time.Millisecond)))
+		// never refills past the burst
+		require.Equal(t, 100, send(l, "PA_1", reliable, 200, now.Add(time.Hour)))
+	})
+
+	t.Run("limits reliable and lossy channels separately", func(t *testing.T) {
+		l := newDataRateLimiter(config.DataRateLimitConfig{
+			Reliable: config.DataRateLimit{ParticipantRate: 10, ParticipantBurst: 20},
+			Lossy:    config.DataRateLimit{ParticipantRate: 1, ParticipantBurst: 2},
+		})
+		require.Equal(t, 2, send(l, "PA_1", lossy, 10, now))
+		require.Equal(t, 20, send(l, "PA_1", reliable, 30, now))
+	})
+
+	t.Run("packets dropped by participant limits don't count towards the room", func(t *testing.T) {
+		l := newDataRateLimiter(config.DataRateLimitConfig{
+			Reliable: config.DataRateLimit{
+				ParticipantRate:  1,
+				ParticipantBurst: 5,
+				RoomRate:         1,
+				RoomBurst:        10,
+			},
+		})
+		require.Equal(t, 5, send(l, "PA_1", reliable, 100, now))
+		require.Equal(t, 5, send(l, "PA_2", reliable, 5, now))
+
+		ok, scope, _ := l.allow("PA_3", reliable, now)
+		require.False(t, ok)
+		require.Equal(t, dataLimitRoom, scope)
+	})
+
+	t.Run("notifies once each time the sender starts exceeding it", func(t *testing.T) {
+		l := newDataRateLimiter(config.DataRateLimitConfig{
+			Lossy: config.DataRateLimit{ParticipantRate: 1, ParticipantBurst: 1},
+		})
+		ok, _, _ := l.allow("PA_1", lossy, now)
+		require.True(t, ok)
+		_, _, notify := l.allow("PA_1", lossy, now)
+		require.True(t, notify)
+		_, _, notify = l.allow("PA_1", lossy, now)
+		require.False(t, notify)
+
+		ok, _, _ = l.allow("PA_1", lossy, now.Add(time.Second))
+		require.True(t, ok)
+		_, _, notify = l.allow("PA_1", lossy, now.Add(time.Second))
+		require.True(t, notify)
+	})
+
+	t.Run("forgets participants that left", func(t *testing.T) {
+		l := newDataRateLimiter(config.DataRateLimitConfig{
+			Reliable: config.DataRateLimit{ParticipantRate: 1, ParticipantBurst: 1},
+		})
+		require.Equal(t, 1, send(l, "PA_1", reliable, 2, now))
+		l.removeParticipant("PA_1")
+		require.Empty(t, l.participants)
+	})
+}
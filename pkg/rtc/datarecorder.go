@@ -0,0 +1,92 @@
+package rtc
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/livekit/livekit-server/pkg/logger"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+	livekit "github.com/livekit/livekit-server/proto"
+)
+
+// DataRecord is a data packet written to the data recording of a room, one JSON object per line
+type DataRecord struct {
+	// unix timestamp in milliseconds of when the server received the packet
+	Timestamp           int64    `json:"timestamp"`
+	ParticipantSid      string   `json:"participantSid"`
+	ParticipantIdentity string   `json:"participantIdentity"`
+	Kind                string   `json:"kind"`
+	DestinationSids     []string `json:"destinationSids,omitempty"`
+	// base64 encoded
+	Payload []byte `json:"payload"`
+}
+
+// DataRecorder writes data packets exchanged in a room to a timestamped log file
+type DataRecorder struct {
+	lock    sync.Mutex
+	file    *os.File
+	encoder *json.Encoder
+	closed  bool
+}
+
+// NewDataRecorder creates <directory>/<room>-<start time>.data.log for the room
+func NewDataRecorder(directory string, roomName string) (*DataRecorder, error) {
+	if err := os.MkdirAll(directory, 0755); err != nil {
+		return nil, err
+	}
+	// room names could contain path separators
+	name := fmt.Sprintf("%s-%s.data.log", url.PathEscape(roomName), time.Now().UTC().Format("20060102T150405Z"))
+	f, err := os.OpenFile(filepath.Join(directory, name), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &DataRecorder{
+		file:    f,
+		encoder: json.NewEncoder(f),
+	}, nil
+}
+
+func (r *DataRecorder) Path() string {
+	return r.file.Name()
+}
+
+func (r *DataRecorder) Record(source types.Participant, dp *livekit.DataPacket) {
+	user := dp.GetUser()
+	if user == nil {
+		return
+	}
+	record := DataRecord{
+		Timestamp:           time.Now().UnixNano() / int64(time.Millisecond),
+		ParticipantSid:      source.ID(),
+		ParticipantIdentity: source.Identity(),
+		Kind:                dp.Kind.String(),
+		DestinationSids:     user.DestinationSids,
+		Payload:             user.Payload,
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.closed {
+		return
+	}
+	if err := r.encoder.Encode(&record); err != nil {
+		logger.Warnw("could not record data packet", err,
+			"participant", source.Identity(),
+			"path", r.file.Name())
+	}
+}
+
+func (r *DataRecorder) Close() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+	return r.file.Close()
+}
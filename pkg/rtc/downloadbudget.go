@@ -0,0 +1,248 @@
+package rtc
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+// type of user packets telling participants their subscriptions don't fit in their max download bitrate
+const downloadBudgetPacketType = "download_budget"
+
+// policy reported once subscriptions fit in the budget again
+const downloadBudgetNone = "none"
+
+// downloadBudgetSignal is the payload of data packets sent to a participant when the policy applied to its
+// subscriptions changes
+type downloadBudgetSignal struct {
+	Type string `json:"type"`
+	// policy that took effect, none when subscriptions fit in the budget
+	Policy string `json:"policy"`
+	// bps
+	MaxBitrate      uint64 `json:"maxBitrate"`
+	RequiredBitrate uint64 `json:"requiredBitrate"`
+	// video tracks paused to fit in the budget
+	PausedTracks []string `json:"pausedTracks,omitempty"`
+	// video track whose subscription was refused, with the reject policy
+	RejectedTrack string `json:"rejectedTrack,omitempty"`
+	// spatial layers video tracks were limited to, with a subscription allocation strategy
+	Layers map[string]int32 `json:"layers,omitempty"`
+}
+
+// budgetedTrack is a subscription competing for the download budget of a participant
+type budgetedTrack struct {
+	id    string
+	video bool
+	// bps of its lowest layer, the least it could be sent at
+	minBitrate uint64
+	pinned     bool
+	// layer the subscriber asked for, higher ones are kept first
+	targetLayer int32
+	// bps of each spatial layer of video, 0 for layers that aren't published
+	bitrates [3]uint64
+}
+
+// downloadBudget applies the download budget policy to the subscriptions of a participant
+type downloadBudget struct {
+	policy string
+	// picks layers of video rather than only pausing it when set, following strategy
+	strategy  string
+	allocator SubscriptionAllocator
+
+	lock       sync.Mutex
+	maxBitrate uint64
+	// policy applied and tracks paused the last time it was allocated
+	applied string
+	paused  map[string]bool
+	// layers video was limited to the last time it was allocated, with an allocator
+	layers map[string]int32
+}
+
+func newDownloadBudget(maxBitrate uint64, policy string) *downloadBudget {
+	if policy == "" {
+		policy = config.DownloadBudgetAudioOnly
+	}
+	return &downloadBudget{
+		policy:     policy,
+		maxBitrate: maxBitrate,
+		applied:    downloadBudgetNone,
+		paused:     make(map[string]bool),
+	}
+}
+
+// setAllocationStrategy shares the budget among video tracks following strategy, picking their layers, rather than
+// pausing them following the policy. Only set before the budget is used
+func (b *downloadBudget) setAllocationStrategy(strategy string) {
+	b.strategy = strategy
+	b.allocator = NewSubscriptionAllocator(strategy)
+}
+
+func (b *downloadBudget) getMaxBitrate() uint64 {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.maxBitrate
+}
+
+func (b *downloadBudget) setMaxBitrate(bitrate uint64) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.maxBitrate = bitrate
+}
+
+// idle returns true when there's no budget, and nothing has been paused or limited for one before
+func (b *downloadBudget) idle() bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.maxBitrate == 0 && b.applied == downloadBudgetNone && len(b.layers) == 0
+}
+
+// admit returns false when a video subscription of bitrate doesn't fit next to tracks, with the reject policy
+func (b *downloadBudget) admit(tracks []budgetedTrack, bitrate uint64) bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.policy != config.DownloadBudgetReject || b.maxBitrate == 0 {
+		return true
+	}
+	return requiredBitrate(tracks, b.paused)+bitrate <= b.maxBitrate
+}
+
+// allocate returns the video tracks to pause so subscriptions fit in the budget, as far as the policy allows.
+// changed is true when the outcome differs from the previous allocation, signal describes it
+func (b *downloadBudget) allocate(tracks []budgetedTrack) (paused map[string]bool, signal downloadBudgetSignal, changed bool) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	required := requiredBitrate(tracks, nil)
+	applied := downloadBudgetNone
+	paused = make(map[string]bool)
+	if b.maxBitrate > 0 && required > b.maxBitrate {
+		applied = b.policy
+		var videos []budgetedTrack
+		remaining := b.maxBitrate
+		for _, t := range tracks {
+			if t.video {
+				videos = append(videos, t)
+			} else if t.minBitrate < remaining {
+				remaining -= t.minBitrate
+			} else {
+				remaining = 0
+			}
+		}
+		// highest priority first
+		sort.Slice(videos, func(i, j int) bool {
+			x, y := videos[i], videos[j]
+			if x.pinned != y.pinned {
+				return x.pinned
+			}
+			if x.targetLayer != y.targetLayer {
+				return x.targetLayer > y.targetLayer
+			}
+			return x.id < y.id
+		})
+		for i, t := range videos {
+			switch {
+			case b.policy == config.DownloadBudgetExceedPinned && i == 0:
+				// kept even when it doesn't fit
+			case b.policy == config.DownloadBudgetReject && t.minBitrate <= remaining:
+				remaining -= t.minBitrate
+			default:
+				paused[t.id] = true
+			}
+		}
+	}
+
+	changed = applied != b.applied || len(paused) != len(b.paused)
+	for id := range paused {
+		changed = changed || !b.paused[id]
+	}
+	b.applied = applied
+	b.paused = paused
+
+	signal = downloadBudgetSignal{
+		Policy:          applied,
+		MaxBitrate:      b.maxBitrate,
+		RequiredBitrate: required,
+	}
+	for id := range paused {
+		signal.PausedTracks = append(signal.PausedTracks, id)
+	}
+	sort.Strings(signal.PausedTracks)
+	return
+}
+
+// allocateLayers returns the spatial layers video tracks are limited to so subscriptions fit in the budget, -1 for
+// those that are paused, following the allocation strategy. Tracks left out aren't limited. changed is true when
+// the outcome differs from the previous allocation, signal describes it
+func (b *downloadBudget) allocateLayers(tracks []budgetedTrack) (layers map[string]int32, signal downloadBudgetSignal, changed bool) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	required := requiredBitrate(tracks, nil)
+	applied := downloadBudgetNone
+	paused := make(map[string]bool)
+	layers = make(map[string]int32)
+	if b.maxBitrate > 0 {
+		var requests []LayerRequest
+		remaining := b.maxBitrate
+		for _, t := range tracks {
+			if t.video {
+				requests = append(requests, t.layerRequest())
+			} else if t.minBitrate < remaining {
+				remaining -= t.minBitrate
+			} else {
+				remaining = 0
+			}
+		}
+		layers = b.allocator.Allocate(remaining, requests)
+		for _, r := range requests {
+			layer := layers[r.TrackID]
+			if layer < 0 {
+				paused[r.TrackID] = true
+			}
+			if layer < r.highestFitting(r.TargetLayer, ^uint64(0)) {
+				applied = b.strategy
+			}
+		}
+	}
+
+	// layers of tracks that fit only matter to the subscriber while others don't
+	changed = applied != b.applied || (applied != downloadBudgetNone && !sameForwardedLayers(layers, b.layers))
+	b.applied = applied
+	b.paused = paused
+	b.layers = layers
+
+	signal = downloadBudgetSignal{
+		Policy:          applied,
+		MaxBitrate:      b.maxBitrate,
+		RequiredBitrate: required,
+	}
+	if applied != downloadBudgetNone {
+		signal.Layers = layers
+	}
+	for id := range paused {
+		signal.PausedTracks = append(signal.PausedTracks, id)
+	}
+	sort.Strings(signal.PausedTracks)
+	return
+}
+
+func (t budgetedTrack) layerRequest() LayerRequest {
+	return LayerRequest{
+		TrackID:     t.id,
+		Pinned:      t.pinned,
+		TargetLayer: t.targetLayer,
+		Bitrates:    t.bitrates,
+	}
+}
+
+// requiredBitrate sums the lowest bitrates of tracks, but those excluded
+func requiredBitrate(tracks []budgetedTrack, excluded map[string]bool) uint64 {
+	var required uint64
+	for _, t := range tracks {
+		if !excluded[t.id] {
+			required += t.minBitrate
+		}
+	}
+	return required
+}
@@ -0,0 +1,70 @@
+package rtc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+func TestDownloadBudget(t *testing.T) {
+	tracks := []budgetedTrack{
+		{id: "TR_audio", minBitrate: 32_000},
+		{id: "TR_camera", video: true, minBitrate: 150_000},
+		{id: "TR_screen", video: true, minBitrate: 300_000, targetLayer: 2},
+		{id: "TR_pinned", video: true, minBitrate: 150_000, pinned: true},
+	}
+
+	t.Run("unlimited", func(t *testing.T) {
+		b := newDownloadBudget(0, config.DownloadBudgetAudioOnly)
+		paused, signal, changed := b.allocate(tracks)
+		require.Empty(t, paused)
+		require.Equal(t, downloadBudgetNone, signal.Policy)
+		require.False(t, changed)
+		require.True(t, b.admit(tracks, 10_000_000))
+	})
+
+	t.Run("audio only", func(t *testing.T) {
+		b := newDownloadBudget(500_000, config.DownloadBudgetAudioOnly)
+		paused, signal, changed := b.allocate(tracks)
+		require.True(t, changed)
+		require.Len(t, paused, 3)
+		require.Equal(t, config.DownloadBudgetAudioOnly, signal.Policy)
+		require.Equal(t, uint64(632_000), signal.RequiredBitrate)
+		require.Equal(t, []string{"TR_camera", "TR_pinned", "TR_screen"}, signal.PausedTracks)
+		// other policies don't reject subscriptions
+		require.True(t, b.admit(tracks, 10_000_000))
+
+		_, _, changed = b.allocate(tracks)
+		require.False(t, changed)
+
+		b.setMaxBitrate(1_000_000)
+		paused, signal, changed = b.allocate(tracks)
+		require.True(t, changed)
+		require.Empty(t, paused)
+		require.Equal(t, downloadBudgetNone, signal.Policy)
+	})
+
+	t.Run("exceed pinned", func(t *testing.T) {
+		b := newDownloadBudget(100_000, config.DownloadBudgetExceedPinned)
+		paused, _, _ := b.allocate(tracks)
+		require.Equal(t, map[string]bool{"TR_camera": true, "TR_screen": true}, paused)
+
+		// highest quality without a pinned track
+		paused, _, _ = b.allocate(tracks[:3])
+		require.Equal(t, map[string]bool{"TR_camera": true}, paused)
+	})
+
+	t.Run("reject", func(t *testing.T) {
+		b := newDownloadBudget(500_000, config.DownloadBudgetReject)
+		paused, signal, _ := b.allocate(tracks)
+		// audio, pinned and screen fit
+		require.Equal(t, map[string]bool{"TR_camera": true}, paused)
+		require.Equal(t, config.DownloadBudgetReject, signal.Policy)
+
+		// paused tracks don't count
+		require.True(t, b.admit(tracks, 18_000))
+		require.False(t, b.admit(tracks, 150_000))
+	})
+}
@@ -1,13 +1,134 @@
 package rtc
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pion/webrtc/v3"
+)
 
 var (
 	ErrRoomClosed              = errors.New("room has already closed")
 	ErrPermissionDenied        = errors.New("no permissions to access the room")
 	ErrMaxParticipantsExceeded = errors.New("room has exceeded its max participants")
+	ErrMaxDurationExceeded     = errors.New("room has exceeded its max duration")
 	ErrAlreadyJoined           = errors.New("a participant with the same identity is already in the room")
 	ErrUnexpectedOffer         = errors.New("expected answer SDP, received offer")
 	ErrDataChannelUnavailable  = errors.New("data channel is not available")
+	ErrDataChannelCongested    = errors.New("data channel has too much data buffered")
+	ErrDataPacketTooLarge      = errors.New("data packet exceeds max message size")
+	ErrForwarderNotFound       = errors.New("track is not being forwarded to the address")
+	ErrSignalSuperseded        = errors.New("signal connection has been superseded by a newer one")
 	ErrCannotSubscribe         = errors.New("participant does not have permission to subscribe")
+	ErrNegotiationTimeout      = errors.New("participant did not answer the offer in time")
+	ErrNegotiationStuck        = errors.New("signaling state did not get back to stable in time")
+	ErrSubscriptionLimit       = errors.New("node has reached its max subscriptions")
+	ErrReconnectGraceExpired   = errors.New("participant did not reconnect within its grace period")
+	ErrTrackKindMismatch       = errors.New("subscribed track doesn't match the kind of the published track")
+	ErrParticipantDisconnected = errors.New("participant has disconnected")
+	ErrICEGatheringTimeout     = errors.New("no viable ICE candidate was gathered in time, TURN may be required to connect")
+	ErrTrackNotPublished       = errors.New("participant has not published the track")
+	ErrDownloadBudgetExceeded  = errors.New("subscription exceeds the max download bitrate of the participant")
+	ErrMetadataTooLarge        = errors.New("participant metadata exceeds the max size")
+	ErrCodecNotSupported       = errors.New("subscriber can't decode the codec of the track")
+	ErrTrackNotSubscribed      = errors.New("participant is not subscribed to the track")
+	ErrSinkNotAttached         = errors.New("sink is not attached to the track")
 )
+
+// TrackKindMismatchError is returned when the codec of a subscription doesn't match the kind of the published
+// track, it matches ErrTrackKindMismatch with errors.Is
+type TrackKindMismatchError struct {
+	TrackID string
+	// kind of the published track
+	Expected webrtc.RTPCodecType
+	// kind of the DownTrack, derived from its codec
+	Actual webrtc.RTPCodecType
+}
+
+func (e *TrackKindMismatchError) Error() string {
+	return fmt.Sprintf("%s: track %s is %s, subscription is %s", ErrTrackKindMismatch, e.TrackID, e.Expected, e.Actual)
+}
+
+func (e *TrackKindMismatchError) Is(target error) bool {
+	return target == ErrTrackKindMismatch
+}
+
+// SubscriptionErrors is returned when subscribing to several tracks and some of them were rejected, the others are
+// subscribed to regardless. It matches the errors of each rejected subscription with errors.Is
+type SubscriptionErrors struct {
+	// track ID => why its subscription was rejected
+	Errors map[string]error
+}
+
+func (e *SubscriptionErrors) Error() string {
+	trackIDs := make([]string, 0, len(e.Errors))
+	for trackID := range e.Errors {
+		trackIDs = append(trackIDs, trackID)
+	}
+	sort.Strings(trackIDs)
+	msgs := make([]string, 0, len(trackIDs))
+	for _, trackID := range trackIDs {
+		msgs = append(msgs, fmt.Sprintf("%s: %v", trackID, e.Errors[trackID]))
+	}
+	return fmt.Sprintf("%d subscriptions were rejected: %s", len(e.Errors), strings.Join(msgs, "; "))
+}
+
+func (e *SubscriptionErrors) Is(target error) bool {
+	for _, err := range e.Errors {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *SubscriptionErrors) add(trackID string, err error) {
+	if e.Errors == nil {
+		e.Errors = make(map[string]error)
+	}
+	e.Errors[trackID] = err
+}
+
+// errorOrNil returns nil when no subscription was rejected
+func (e *SubscriptionErrors) errorOrNil() error {
+	if len(e.Errors) == 0 {
+		return nil
+	}
+	return e
+}
+
+// MetadataTooLargeError is returned when metadata of a participant exceeds the max size, it matches
+// ErrMetadataTooLarge with errors.Is
+type MetadataTooLargeError struct {
+	// bytes
+	Size int
+	Max  int
+}
+
+func (e *MetadataTooLargeError) Error() string {
+	return fmt.Sprintf("%s: %d bytes, at most %d are allowed", ErrMetadataTooLarge, e.Size, e.Max)
+}
+
+func (e *MetadataTooLargeError) Is(target error) bool {
+	return target == ErrMetadataTooLarge
+}
+
+// CodecMismatchError is returned when a subscriber can't decode the codec of a track and it couldn't be
+// transcoded to one it does, it matches ErrCodecNotSupported with errors.Is
+type CodecMismatchError struct {
+	TrackID string
+	// mime type the track is published in
+	Codec string
+	// why it isn't transcoded
+	Reason string
+}
+
+func (e *CodecMismatchError) Error() string {
+	return fmt.Sprintf("%s: track %s is %s, %s", ErrCodecNotSupported, e.TrackID, e.Codec, e.Reason)
+}
+
+func (e *CodecMismatchError) Is(target error) bool {
+	return target == ErrCodecNotSupported
+}
@@ -0,0 +1,66 @@
+package rtc
+
+import (
+	"time"
+
+	"github.com/livekit/livekit-server/pkg/logger"
+)
+
+const (
+	// type of user packets telling participants media of a published track started flowing
+	firstMediaPacketType = "first_media"
+	// type of user packets telling a publisher no media of one of its tracks has been received
+	noMediaPacketType = "no_media"
+)
+
+// firstMediaSignal is the payload of data packets sent to participants once the first packet of a published track
+// has been received, or to its publisher when none was within firstMediaTimeout
+type firstMediaSignal struct {
+	Type           string `json:"type"`
+	ParticipantSid string `json:"participantSid"`
+	TrackSid       string `json:"trackSid"`
+}
+
+// watchFirstMedia tells when the first packet of a stream of the track has been received, or that the track
+// hasn't received any within timeout. Buffers don't signal packets, so their packet count is polled
+func (t *MediaTrack) watchFirstMedia(packets func() uint32, timeout time.Duration) {
+	ticker := time.NewTicker(firstMediaPollInterval)
+	defer ticker.Stop()
+	expired := time.After(timeout)
+	for {
+		select {
+		case <-expired:
+			t.lock.RLock()
+			closed := t.receiver == nil
+			t.lock.RUnlock()
+			// other streams of the track time out as well
+			if !closed && !t.firstMedia.Get() && t.noMedia.TrySet(true) {
+				logger.Warnw("no media received for published track", nil,
+					"track", t.params.TrackID,
+					"participant", t.params.Identity,
+					"timeout", timeout)
+				if t.onNoMedia != nil {
+					t.onNoMedia()
+				}
+			}
+			return
+		case <-ticker.C:
+			if t.firstMedia.Get() {
+				// received on another stream of the track
+				return
+			}
+			if packets() == 0 {
+				continue
+			}
+			if t.firstMedia.TrySet(true) {
+				logger.Debugw("received first media of published track",
+					"track", t.params.TrackID,
+					"participant", t.params.Identity)
+				if t.onFirstMedia != nil {
+					t.onFirstMedia()
+				}
+			}
+			return
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package rtc
+
+import (
+	"time"
+)
+
+// forwarded layers are checked for changes this often, DownTracks switch layers on their own once a keyframe of
+// the new layer arrives
+const forwardedLayersInterval = time.Second
+
+// type of user packets telling a subscriber which layers of its video subscriptions it's receiving
+const forwardedLayersPacketType = "forwarded_layers"
+
+// forwardedLayersSignal is the payload of data packets sent to a subscriber when a layer forwarded to it changes
+type forwardedLayersSignal struct {
+	Type string `json:"type"`
+	// track sid => spatial layer, -1 while the track is paused or muted
+	Layers map[string]int32 `json:"layers"`
+}
+
+func sameForwardedLayers(a, b map[string]int32) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for sid, layer := range a {
+		if l, ok := b[sid]; !ok || l != layer {
+			return false
+		}
+	}
+	return true
+}
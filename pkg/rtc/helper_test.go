@@ -14,9 +14,10 @@ func newMockParticipant(identity string, protocol types.ProtocolVersion) *typesf
 	p.StateReturns(livekit.ParticipantInfo_JOINED)
 	p.ProtocolVersionReturns(protocol)
 	p.CanSubscribeReturns(true)
+	p.SupportsCodecReturns(true)
 	p.CanPublishReturns(true)
 
-	p.SetMetadataStub = func(m string) {
+	p.SetMetadataStub = func(m string) error {
 		var f func(participant types.Participant)
 		if p.OnMetadataUpdateCallCount() > 0 {
 			f = p.OnMetadataUpdateArgsForCall(p.OnMetadataUpdateCallCount() - 1)
@@ -24,6 +25,7 @@ func newMockParticipant(identity string, protocol types.ProtocolVersion) *typesf
 		if f != nil {
 			f(p)
 		}
+		return nil
 	}
 	updateTrack := func() {
 		var f func(participant types.Participant, track types.PublishedTrack)
@@ -38,6 +40,10 @@ func newMockParticipant(identity string, protocol types.ProtocolVersion) *typesf
 	p.SetTrackMutedStub = func(sid string, muted bool) {
 		updateTrack()
 	}
+	p.MutePublishedTrackStub = func(sid string, muted bool) error {
+		updateTrack()
+		return nil
+	}
 	p.AddTrackStub = func(req *livekit.AddTrackRequest) {
 		updateTrack()
 	}
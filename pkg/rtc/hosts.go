@@ -0,0 +1,23 @@
+package rtc
+
+import ()
+
+// type of user packets telling participants who the hosts of the room are, ParticipantInfo has no field for it
+const hostsPacketType = "hosts"
+
+// hostsSignal is the payload of data packets sent to participants when they join, and to everyone when hosts
+// change
+type hostsSignal struct {
+	Type       string   `json:"type"`
+	Identities []string `json:"identities"`
+}
+
+func newHostsSignal(identities []string) hostsSignal {
+	if identities == nil {
+		identities = []string{}
+	}
+	return hostsSignal{
+		Type:       hostsPacketType,
+		Identities: identities,
+	}
+}
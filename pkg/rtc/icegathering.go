@@ -0,0 +1,152 @@
+package rtc
+
+import (
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pion/webrtc/v3"
+)
+
+// iceGatheringWatch tells when the publisher transport hasn't gathered a viable candidate in time, which happens
+// when gathering stalls behind some NATs and leaves the participant joining forever. A candidate is viable once
+// a local and a remote candidate of the same IP family are known, so a pair could be checked.
+// Trickled candidates push the deadline back, it only times out once candidates stop arriving
+type iceGatheringWatch struct {
+	timeout   time.Duration
+	onTimeout func()
+
+	lock sync.Mutex
+	// IP families of known candidates, empty for candidates without an IP address, such as mDNS ones
+	local  map[string]bool
+	remote map[string]bool
+	timer  *time.Timer
+	// incremented each time the timer is armed, so a timer that fired while being rearmed is ignored
+	timerID uint32
+	// set once connected or closed, nothing is watched anymore
+	stopped bool
+}
+
+func newICEGatheringWatch(timeout time.Duration, onTimeout func()) *iceGatheringWatch {
+	return &iceGatheringWatch{
+		timeout:   timeout,
+		onTimeout: onTimeout,
+		local:     make(map[string]bool),
+		remote:    make(map[string]bool),
+	}
+}
+
+// start begins watching when it isn't already, candidates known from a previous round are forgotten when restart
+// is set, as after an ICE restart
+func (w *iceGatheringWatch) start(restart bool) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if w.stopped {
+		return
+	}
+	if restart {
+		w.local = make(map[string]bool)
+		w.remote = make(map[string]bool)
+	}
+	if w.timer == nil && !w.viableLocked() {
+		w.armLocked()
+	}
+}
+
+// stop ends watching for good, once ICE is connected or the participant is closed
+func (w *iceGatheringWatch) stop() {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.stopped = true
+	w.stopTimerLocked()
+}
+
+func (w *iceGatheringWatch) addLocal(c *webrtc.ICECandidate) {
+	w.add(w.local, candidateIPFamily(c))
+}
+
+// addRemote takes a candidate attribute as trickled by the client, with or without the a= prefix
+func (w *iceGatheringWatch) addRemote(candidate string) {
+	w.add(w.remote, remoteCandidateIPFamily(candidate))
+}
+
+// addRemoteFromSDP adds candidates included in a description of the client
+func (w *iceGatheringWatch) addRemoteFromSDP(sdp string) {
+	for _, line := range strings.Split(sdp, "\r\n") {
+		if strings.HasPrefix(line, "a=candidate:") {
+			w.addRemote(line)
+		}
+	}
+}
+
+func (w *iceGatheringWatch) add(families map[string]bool, family string) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	families[family] = true
+	if w.timer == nil {
+		return
+	}
+	if w.viableLocked() {
+		w.stopTimerLocked()
+		return
+	}
+	// candidates are still arriving, give them time to become viable
+	w.armLocked()
+}
+
+func (w *iceGatheringWatch) expire(id uint32) {
+	w.lock.Lock()
+	if w.timer == nil || id != w.timerID {
+		w.lock.Unlock()
+		return
+	}
+	w.timer = nil
+	viable := w.viableLocked()
+	w.lock.Unlock()
+
+	if !viable {
+		w.onTimeout()
+	}
+}
+
+// needs to be called with lock held
+func (w *iceGatheringWatch) viableLocked() bool {
+	for family := range w.local {
+		for remoteFamily := range w.remote {
+			if family == "" || remoteFamily == "" || family == remoteFamily {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// needs to be called with lock held
+func (w *iceGatheringWatch) armLocked() {
+	w.stopTimerLocked()
+	w.timerID++
+	id := w.timerID
+	w.timer = time.AfterFunc(w.timeout, func() {
+		w.expire(id)
+	})
+}
+
+// needs to be called with lock held
+func (w *iceGatheringWatch) stopTimerLocked() {
+	if w.timer != nil {
+		w.timer.Stop()
+		w.timer = nil
+	}
+}
+
+// remoteCandidateIPFamily returns the IP family of a candidate attribute, or an empty string when its address
+// isn't an IP
+func remoteCandidateIPFamily(candidate string) string {
+	// candidate:<foundation> <component> <protocol> <priority> <address> <port> typ <type> ...
+	fields := strings.Fields(strings.TrimPrefix(candidate, "a="))
+	if len(fields) < 5 {
+		return ""
+	}
+	return ipFamily(net.ParseIP(fields[4]))
+}
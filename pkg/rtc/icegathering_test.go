@@ -0,0 +1,90 @@
+package rtc
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pion/webrtc/v3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestICEGatheringWatch(t *testing.T) {
+	const timeout = 50 * time.Millisecond
+	newWatch := func() (*iceGatheringWatch, *timeoutCount) {
+		timeouts := &timeoutCount{}
+		return newICEGatheringWatch(timeout, timeouts.inc), timeouts
+	}
+	hostV4 := &webrtc.ICECandidate{Address: "10.0.0.1"}
+	hostV6 := &webrtc.ICECandidate{Address: "fd00::1"}
+
+	t.Run("times out without a viable candidate", func(t *testing.T) {
+		w, timeouts := newWatch()
+		w.start(false)
+		w.addLocal(hostV4)
+		w.addRemote("candidate:1 1 udp 2122260223 fd00::2 54321 typ host")
+		require.Eventually(t, func() bool { return timeouts.load() == 1 }, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("stops once a candidate pair could be checked", func(t *testing.T) {
+		w, timeouts := newWatch()
+		w.start(false)
+		w.addRemote("candidate:1 1 udp 2122260223 192.168.1.2 54321 typ host")
+		w.addLocal(hostV6)
+		w.addLocal(hostV4)
+		time.Sleep(2 * timeout)
+		require.Zero(t, timeouts.load())
+	})
+
+	t.Run("remote candidates in the offer and mDNS candidates are viable", func(t *testing.T) {
+		w, timeouts := newWatch()
+		w.start(false)
+		w.addRemoteFromSDP("v=0\r\na=candidate:1 1 udp 2122260223 a1b2c3.local 54321 typ host\r\n")
+		w.addLocal(hostV6)
+		time.Sleep(2 * timeout)
+		require.Zero(t, timeouts.load())
+	})
+
+	t.Run("candidates still arriving push the timeout back", func(t *testing.T) {
+		w, timeouts := newWatch()
+		w.start(false)
+		for i := 0; i < 4; i++ {
+			time.Sleep(timeout / 2)
+			w.addLocal(hostV4)
+		}
+		require.Zero(t, timeouts.load())
+		require.Eventually(t, func() bool { return timeouts.load() == 1 }, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("forgets candidates of the previous round on ICE restart", func(t *testing.T) {
+		w, timeouts := newWatch()
+		w.start(false)
+		w.addLocal(hostV4)
+		require.Eventually(t, func() bool { return timeouts.load() == 1 }, time.Second, 10*time.Millisecond)
+
+		w.addRemote("candidate:1 1 udp 2122260223 192.168.1.2 54321 typ host")
+		w.start(true)
+		require.Eventually(t, func() bool { return timeouts.load() == 2 }, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("doesn't time out once stopped", func(t *testing.T) {
+		w, timeouts := newWatch()
+		w.start(false)
+		w.stop()
+		w.start(true)
+		time.Sleep(2 * timeout)
+		require.Zero(t, timeouts.load())
+	})
+}
+
+type timeoutCount struct {
+	n int32
+}
+
+func (c *timeoutCount) inc() {
+	atomic.AddInt32(&c.n, 1)
+}
+
+func (c *timeoutCount) load() int32 {
+	return atomic.LoadInt32(&c.n)
+}
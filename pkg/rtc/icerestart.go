@@ -0,0 +1,53 @@
+package rtc
+
+import (
+	"github.com/livekit/livekit-server/pkg/logger"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+// type of user packets telling participants another is reconnecting after its network changed. ParticipantInfo
+// has no RECONNECTING state, participants keep their state while they restart ICE
+const participantReconnectingPacketType = "participant_reconnecting"
+
+// participantReconnectingSignal is the payload of data packets sent to participants when another started restarting
+// ICE, and once it's connected again
+type participantReconnectingSignal struct {
+	Type         string `json:"type"`
+	Sid          string `json:"sid"`
+	Identity     string `json:"identity"`
+	Reconnecting bool   `json:"reconnecting"`
+}
+
+func newParticipantReconnectingSignal(p types.Participant) participantReconnectingSignal {
+	return participantReconnectingSignal{
+		Type:         participantReconnectingPacketType,
+		Sid:          p.ID(),
+		Identity:     p.Identity(),
+		Reconnecting: p.IsReconnecting(),
+	}
+}
+
+func (p *ParticipantImpl) IsReconnecting() bool {
+	return p.reconnecting.Get()
+}
+
+func (p *ParticipantImpl) OnReconnectingChange(callback func(types.Participant)) {
+	p.onReconnectingChange = callback
+}
+
+// setReconnecting is set when the client restarts ICE, its tracks and subscriptions are kept until ICE connects again
+func (p *ParticipantImpl) setReconnecting(reconnecting bool) {
+	if !p.reconnecting.TrySet(reconnecting) {
+		return
+	}
+	logger.Infow("participant reconnecting changed", "reconnecting", reconnecting, "participant", p.Identity())
+	p.lock.RLock()
+	onReconnectingChange := p.onReconnectingChange
+	p.lock.RUnlock()
+	if onReconnectingChange != nil {
+		go func() {
+			defer Recover()
+			onReconnectingChange(p)
+		}()
+	}
+}
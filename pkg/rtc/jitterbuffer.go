@@ -0,0 +1,123 @@
+package rtc
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pion/interceptor"
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v3"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+const (
+	playoutDelayURI   = "http://www.webrtc.org/experiments/rtp-hdrext/playout-delay"
+	absCaptureTimeURI = "http://www.webrtc.org/experiments/rtp-hdrext/abs-capture-time"
+
+	// delays of the playout-delay extension are in 10ms units
+	playoutDelayUnit = 10 * time.Millisecond
+)
+
+// jitterBufferHints tells a subscriber how long to buffer media sent to it, with the playout-delay extension on
+// each packet, its depth depending on whether the stream is audio or video. Receivers keep audio and video in sync
+// despite the different depths by delaying the earlier one, from the capture time of packets, which is forwarded
+// with the abs-capture-time extension.
+// Forwarded packets still carry the extensions of the publisher, with the IDs negotiated with it. They're replaced by
+// the ones negotiated with the subscriber, which could be mistaken for other extensions otherwise.
+// It adheres to the Pion interceptor interface
+type jitterBufferHints struct {
+	interceptor.NoOp
+	audio []byte
+	video []byte
+
+	lock sync.RWMutex
+	// ID of abs-capture-time negotiated with the publisher of each stream sent to the subscriber
+	captureTimeIDs map[uint32]uint8
+}
+
+// newJitterBufferHints returns nil when disabled
+func newJitterBufferHints(conf config.JitterBufferConfig) *jitterBufferHints {
+	if !conf.Enabled {
+		return nil
+	}
+	return &jitterBufferHints{
+		audio:          playoutDelay(conf.Audio),
+		video:          playoutDelay(conf.Video),
+		captureTimeIDs: make(map[uint32]uint8),
+	}
+}
+
+// playoutDelay marshals the depth into the payload of the playout-delay extension, 12 bits for the min then 12 for
+// the max
+func playoutDelay(depth config.JitterBufferDepth) []byte {
+	min := uint32(depth.Min / playoutDelayUnit)
+	max := uint32(depth.Max / playoutDelayUnit)
+	return []byte{byte(min >> 4), byte(min<<4) | byte(max>>8&0x0f), byte(max)}
+}
+
+// mapCaptureTime sets the ID of abs-capture-time in packets of the stream sent on ssrc, as negotiated with its
+// publisher. It has to be set before the stream is bound
+func (j *jitterBufferHints) mapCaptureTime(ssrc uint32, sourceID uint8) {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	j.captureTimeIDs[ssrc] = sourceID
+}
+
+func (j *jitterBufferHints) BindLocalStream(info *interceptor.StreamInfo, writer interceptor.RTPWriter) interceptor.RTPWriter {
+	delay := j.video
+	if strings.HasPrefix(strings.ToLower(info.MimeType), "audio/") {
+		delay = j.audio
+	}
+	playoutDelayID := headerExtensionID(info.RTPHeaderExtensions, playoutDelayURI)
+	captureTimeID := headerExtensionID(info.RTPHeaderExtensions, absCaptureTimeURI)
+	j.lock.RLock()
+	sourceID := j.captureTimeIDs[info.SSRC]
+	j.lock.RUnlock()
+
+	return interceptor.RTPWriterFunc(func(header *rtp.Header, payload []byte, attributes interceptor.Attributes) (int, error) {
+		var captureTime []byte
+		if sourceID != 0 {
+			captureTime = header.GetExtension(sourceID)
+		}
+		// the header's extensions are shared with other subscribers of the stream, they're replaced rather than
+		// updated in place
+		hdr := *header
+		hdr.Extension = false
+		hdr.Extensions = nil
+		if playoutDelayID != 0 {
+			_ = hdr.SetExtension(playoutDelayID, delay)
+		}
+		if captureTimeID != 0 && captureTime != nil {
+			_ = hdr.SetExtension(captureTimeID, captureTime)
+		}
+		return writer.Write(&hdr, payload, attributes)
+	})
+}
+
+func (j *jitterBufferHints) UnbindLocalStream(info *interceptor.StreamInfo) {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	delete(j.captureTimeIDs, info.SSRC)
+}
+
+// headerExtensionID returns the ID an extension was negotiated with, 0 when it wasn't
+func headerExtensionID(extensions []interceptor.RTPHeaderExtension, uri string) uint8 {
+	for _, ext := range extensions {
+		if ext.URI == uri {
+			return uint8(ext.ID)
+		}
+	}
+	return 0
+}
+
+// receiverExtensionID returns the ID an extension was negotiated with by the publisher, 0 when it wasn't
+func receiverExtensionID(receiver *webrtc.RTPReceiver, uri string) uint8 {
+	for _, ext := range receiver.GetParameters().HeaderExtensions {
+		if ext.URI == uri {
+			return uint8(ext.ID)
+		}
+	}
+	return 0
+}
@@ -0,0 +1,74 @@
+package rtc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pion/interceptor"
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+func TestJitterBufferHints(t *testing.T) {
+	require.Nil(t, newJitterBufferHints(config.JitterBufferConfig{}))
+
+	hints := newJitterBufferHints(config.JitterBufferConfig{
+		Enabled: true,
+		Audio:   config.JitterBufferDepth{Max: 100 * time.Millisecond},
+		Video:   config.JitterBufferDepth{Min: 50 * time.Millisecond, Max: 40950 * time.Millisecond},
+	})
+	// 12 bits each, in 10ms units
+	require.Equal(t, []byte{0x00, 0x00, 0x0a}, hints.audio)
+	require.Equal(t, []byte{0x00, 0x5f, 0xff}, hints.video)
+
+	bind := func(ssrc uint32, mimeType string) (interceptor.RTPWriter, *rtp.Header) {
+		var written rtp.Header
+		writer := hints.BindLocalStream(&interceptor.StreamInfo{
+			SSRC:     ssrc,
+			MimeType: mimeType,
+			RTPHeaderExtensions: []interceptor.RTPHeaderExtension{
+				{URI: playoutDelayURI, ID: 2},
+				{URI: absCaptureTimeURI, ID: 5},
+			},
+		}, interceptor.RTPWriterFunc(func(header *rtp.Header, _ []byte, _ interceptor.Attributes) (int, error) {
+			written = *header
+			return 0, nil
+		}))
+		return writer, &written
+	}
+
+	t.Run("replaces the publisher's extensions", func(t *testing.T) {
+		hints.mapCaptureTime(1000, 3)
+		writer, written := bind(1000, "video/VP8")
+
+		captureTime := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+		header := &rtp.Header{Version: 2, SSRC: 1000}
+		require.NoError(t, header.SetExtension(2, []byte{9}))
+		require.NoError(t, header.SetExtension(3, captureTime))
+		_, err := writer.Write(header, nil, nil)
+		require.NoError(t, err)
+
+		require.ElementsMatch(t, []uint8{2, 5}, written.GetExtensionIDs())
+		require.Equal(t, hints.video, written.GetExtension(2))
+		require.Equal(t, captureTime, written.GetExtension(5))
+		// shared with other subscribers, left as is
+		require.Equal(t, []byte{9}, header.GetExtension(2))
+		require.Equal(t, captureTime, header.GetExtension(3))
+	})
+
+	t.Run("sets the audio depth on audio streams", func(t *testing.T) {
+		writer, written := bind(2000, "audio/opus")
+		_, err := writer.Write(&rtp.Header{Version: 2, SSRC: 2000}, nil, nil)
+		require.NoError(t, err)
+		require.Equal(t, []uint8{2}, written.GetExtensionIDs())
+		require.Equal(t, hints.audio, written.GetExtension(2))
+	})
+
+	t.Run("forgets streams once unbound", func(t *testing.T) {
+		hints.mapCaptureTime(3000, 3)
+		hints.UnbindLocalStream(&interceptor.StreamInfo{SSRC: 3000})
+		require.NotContains(t, hints.captureTimeIDs, uint32(3000))
+	})
+}
@@ -0,0 +1,213 @@
+package rtc
+
+import (
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pion/ion-sfu/pkg/buffer"
+	"github.com/pion/rtp"
+	"github.com/pion/transport/packetio"
+	"github.com/pion/webrtc/v3"
+)
+
+// keyframe requests still unanswered after this long are given up on, and counted as unanswered
+const keyframeRequestTimeout = 5 * time.Second
+
+// KeyframeLatencyStats aggregates how long a published track took to send a keyframe after one was requested
+type KeyframeLatencyStats struct {
+	// requests answered with a keyframe, and given up on after keyframeRequestTimeout
+	Answered   uint64        `json:"answered"`
+	Unanswered uint64        `json:"unanswered"`
+	Last       time.Duration `json:"last"`
+	Max        time.Duration `json:"max"`
+	Mean       time.Duration `json:"mean"`
+	total      time.Duration
+}
+
+type keyframeStream struct {
+	trackID  string
+	mimeType string
+	// when the oldest request yet to be answered was sent, zero when there's none
+	requestedAt time.Time
+}
+
+// keyframeLatency measures the time from a PLI or FIR sent to the publisher to the next keyframe it sends on the
+// stream, for each published video track. Later requests sent while one is pending are covered by the same
+// keyframe, so latency is measured from the first of them
+type keyframeLatency struct {
+	lock sync.Mutex
+	// SSRC => stream
+	streams map[uint32]*keyframeStream
+	// track => stats of its streams
+	tracks map[string]*KeyframeLatencyStats
+	// requests waiting for a keyframe, packets aren't parsed while there are none
+	pending int32
+}
+
+func newKeyframeLatency() *keyframeLatency {
+	return &keyframeLatency{
+		streams: make(map[uint32]*keyframeStream),
+		tracks:  make(map[string]*KeyframeLatencyStats),
+	}
+}
+
+// addStream measures keyframes of a published stream, only VP8 and H264 keyframes are detected
+func (k *keyframeLatency) addStream(ssrc uint32, trackID string, codec webrtc.RTPCodecParameters) {
+	mimeType := strings.ToLower(codec.MimeType)
+	if mimeType != strings.ToLower(webrtc.MimeTypeVP8) && mimeType != strings.ToLower(webrtc.MimeTypeH264) {
+		return
+	}
+	k.lock.Lock()
+	defer k.lock.Unlock()
+	k.streams[ssrc] = &keyframeStream{trackID: trackID, mimeType: mimeType}
+	if k.tracks[trackID] == nil {
+		k.tracks[trackID] = &KeyframeLatencyStats{}
+	}
+}
+
+// requested is called when a PLI or FIR for ssrc is sent to the publisher
+func (k *keyframeLatency) requested(ssrc uint32, now time.Time) {
+	k.lock.Lock()
+	defer k.lock.Unlock()
+	s := k.streams[ssrc]
+	if s == nil {
+		return
+	}
+	k.expireLocked(s, now)
+	if s.requestedAt.IsZero() {
+		s.requestedAt = now
+		atomic.AddInt32(&k.pending, 1)
+	}
+}
+
+// observe checks whether a packet received on ssrc starts a keyframe that was requested
+func (k *keyframeLatency) observe(ssrc uint32, payload []byte, now time.Time) {
+	if atomic.LoadInt32(&k.pending) == 0 {
+		return
+	}
+	k.lock.Lock()
+	defer k.lock.Unlock()
+	s := k.streams[ssrc]
+	if s == nil || s.requestedAt.IsZero() || k.expireLocked(s, now) || !isKeyframe(s.mimeType, payload) {
+		return
+	}
+	latency := now.Sub(s.requestedAt)
+	s.requestedAt = time.Time{}
+	atomic.AddInt32(&k.pending, -1)
+
+	stats := k.tracks[s.trackID]
+	stats.Answered++
+	stats.Last = latency
+	if latency > stats.Max {
+		stats.Max = latency
+	}
+	stats.total += latency
+	stats.Mean = stats.total / time.Duration(stats.Answered)
+	keyframeLatencySeconds.Observe(latency.Seconds())
+}
+
+// expireLocked gives up on the pending request of s once it timed out, and returns true when it did
+func (k *keyframeLatency) expireLocked(s *keyframeStream, now time.Time) bool {
+	if s.requestedAt.IsZero() || now.Sub(s.requestedAt) < keyframeRequestTimeout {
+		return false
+	}
+	s.requestedAt = time.Time{}
+	atomic.AddInt32(&k.pending, -1)
+	k.tracks[s.trackID].Unanswered++
+	keyframeRequestUnansweredTotal.Inc()
+	return true
+}
+
+// stats returns the keyframe latency of each published video track
+func (k *keyframeLatency) stats() map[string]KeyframeLatencyStats {
+	k.lock.Lock()
+	defer k.lock.Unlock()
+	stats := make(map[string]KeyframeLatencyStats, len(k.tracks))
+	for trackID, s := range k.tracks {
+		stats[trackID] = *s
+	}
+	return stats
+}
+
+// wrapBufferFactory observes RTP of each published stream for requested keyframes
+func (k *keyframeLatency) wrapBufferFactory(
+	createBufferFunc func(packetType packetio.BufferPacketType, ssrc uint32) io.ReadWriteCloser,
+) func(packetType packetio.BufferPacketType, ssrc uint32) io.ReadWriteCloser {
+	return func(packetType packetio.BufferPacketType, ssrc uint32) io.ReadWriteCloser {
+		writer := createBufferFunc(packetType, ssrc)
+		if packetType != packetio.RTPBufferPacket {
+			return writer
+		}
+		return &keyframeLatencyWriter{
+			ReadWriteCloser: writer,
+			ssrc:            ssrc,
+			latency:         k,
+		}
+	}
+}
+
+type keyframeLatencyWriter struct {
+	io.ReadWriteCloser
+	ssrc    uint32
+	latency *keyframeLatency
+}
+
+func (w *keyframeLatencyWriter) Write(p []byte) (int, error) {
+	if atomic.LoadInt32(&w.latency.pending) > 0 {
+		var pkt rtp.Packet
+		if err := pkt.Unmarshal(p); err == nil {
+			w.latency.observe(w.ssrc, pkt.Payload, time.Now())
+		}
+	}
+	return w.ReadWriteCloser.Write(p)
+}
+
+// isKeyframe returns true when an RTP payload of the lowercase mime type starts a keyframe
+func isKeyframe(mimeType string, payload []byte) bool {
+	switch mimeType {
+	case strings.ToLower(webrtc.MimeTypeVP8):
+		var vp8 buffer.VP8
+		return vp8.Unmarshal(payload) == nil && vp8.IsKeyFrame
+	case strings.ToLower(webrtc.MimeTypeH264):
+		return isH264Keyframe(payload)
+	default:
+		return false
+	}
+}
+
+// isH264Keyframe returns true when a payload carries an IDR slice or SPS, alone, aggregated in a STAP-A, or at the
+// start of a FU-A
+func isH264Keyframe(payload []byte) bool {
+	if len(payload) < 1 {
+		return false
+	}
+	isKey := func(nalType byte) bool {
+		return nalType == 5 || nalType == 7
+	}
+	switch nalType := payload[0] & 0x1F; {
+	case nalType >= 1 && nalType <= 23:
+		return isKey(nalType)
+	case nalType == 24:
+		// STAP-A, 2 bytes of size before each NAL unit
+		for i := 1; i+2 < len(payload); {
+			size := int(payload[i])<<8 | int(payload[i+1])
+			i += 2
+			if size == 0 || i+size > len(payload) {
+				return false
+			}
+			if isKey(payload[i] & 0x1F) {
+				return true
+			}
+			i += size
+		}
+		return false
+	case nalType == 28:
+		// FU-A, start of the fragmented NAL unit
+		return len(payload) > 1 && payload[1]&0x80 != 0 && isKey(payload[1]&0x1F)
+	default:
+		return false
+	}
+}
@@ -0,0 +1,73 @@
+package rtc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pion/webrtc/v3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyframeLatency(t *testing.T) {
+	vp8 := webrtc.RTPCodecParameters{RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeVP8}}
+	// descriptor, then a payload header with the inverse keyframe bit
+	vp8Key := []byte{0x10, 0x00, 0x00, 0x00}
+	vp8Delta := []byte{0x10, 0x01, 0x00, 0x00}
+	now := time.Now()
+
+	t.Run("measures from the first request to the next keyframe", func(t *testing.T) {
+		k := newKeyframeLatency()
+		k.addStream(1, "video", vp8)
+		k.observe(1, vp8Key, now)
+		k.requested(1, now)
+		k.requested(1, now.Add(100*time.Millisecond))
+		k.observe(1, vp8Delta, now.Add(200*time.Millisecond))
+		k.observe(1, vp8Key, now.Add(300*time.Millisecond))
+		// not requested
+		k.observe(1, vp8Key, now.Add(400*time.Millisecond))
+
+		k.requested(1, now.Add(time.Second))
+		k.observe(1, vp8Key, now.Add(time.Second+100*time.Millisecond))
+
+		stats := k.stats()["video"]
+		require.EqualValues(t, 2, stats.Answered)
+		require.Equal(t, 100*time.Millisecond, stats.Last)
+		require.Equal(t, 300*time.Millisecond, stats.Max)
+		require.Equal(t, 200*time.Millisecond, stats.Mean)
+	})
+
+	t.Run("counts requests that time out", func(t *testing.T) {
+		k := newKeyframeLatency()
+		k.addStream(1, "video", vp8)
+		k.requested(1, now)
+		k.observe(1, vp8Key, now.Add(keyframeRequestTimeout))
+		k.requested(1, now.Add(2*keyframeRequestTimeout))
+		k.requested(1, now.Add(3*keyframeRequestTimeout))
+
+		stats := k.stats()["video"]
+		require.EqualValues(t, 0, stats.Answered)
+		require.EqualValues(t, 2, stats.Unanswered)
+		require.EqualValues(t, 1, k.pending)
+	})
+
+	t.Run("ignores streams it can't detect keyframes of", func(t *testing.T) {
+		k := newKeyframeLatency()
+		k.addStream(1, "video", webrtc.RTPCodecParameters{
+			RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeVP9},
+		})
+		k.requested(1, now)
+		require.Empty(t, k.stats())
+		require.EqualValues(t, 0, k.pending)
+	})
+}
+
+func TestIsH264Keyframe(t *testing.T) {
+	require.True(t, isH264Keyframe([]byte{0x65, 0x88}))
+	require.False(t, isH264Keyframe([]byte{0x41, 0x9a}))
+	// STAP-A of SPS and PPS
+	require.True(t, isH264Keyframe([]byte{0x78, 0x00, 0x02, 0x67, 0x42, 0x00, 0x02, 0x68, 0xce}))
+	// FU-A, start and middle of an IDR slice
+	require.True(t, isH264Keyframe([]byte{0x7c, 0x85, 0x88}))
+	require.False(t, isH264Keyframe([]byte{0x7c, 0x05, 0x88}))
+	require.False(t, isH264Keyframe(nil))
+}
@@ -0,0 +1,258 @@
+package rtc
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pion/interceptor"
+	"github.com/pion/rtp"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/logger"
+)
+
+const (
+	// window the average bitrate of a stream is measured over
+	pacerRateWindow = 500 * time.Millisecond
+	// weight of the latest window in the average, keyframes only move it slightly
+	pacerRateSmoothing = 0.2
+)
+
+// KeyframePacer smooths out bitrate spikes of video streams sent to a subscriber, typically caused by large
+// keyframes after scene changes. Packets within a burst allowance at a multiple of the stream's average bitrate
+// are sent right away, the rest are queued and paced out. No packet is held longer than the configured max delay,
+// so pacing doesn't add latency once the spike has passed. Audio streams are never paced.
+// It adheres to the Pion interceptor interface
+type KeyframePacer struct {
+	interceptor.NoOp
+	conf config.KeyframePacingConfig
+
+	lock    sync.Mutex
+	streams map[uint32]*pacedStream
+	onSpike func(ssrc uint32, spiking bool)
+}
+
+func NewKeyframePacer(conf config.KeyframePacingConfig) *KeyframePacer {
+	return &KeyframePacer{
+		conf:    conf,
+		streams: make(map[uint32]*pacedStream),
+	}
+}
+
+// OnSpike is called when a stream starts queueing packets because of a spike, and again once the queue has drained
+func (p *KeyframePacer) OnSpike(f func(ssrc uint32, spiking bool)) {
+	p.lock.Lock()
+	p.onSpike = f
+	p.lock.Unlock()
+}
+
+// BindLocalStream paces outgoing RTP packets of video streams
+func (p *KeyframePacer) BindLocalStream(info *interceptor.StreamInfo, writer interceptor.RTPWriter) interceptor.RTPWriter {
+	if !strings.HasPrefix(strings.ToLower(info.MimeType), "video/") {
+		return writer
+	}
+	s := &pacedStream{
+		pacer:  p,
+		ssrc:   info.SSRC,
+		writer: writer,
+	}
+	p.lock.Lock()
+	p.streams[info.SSRC] = s
+	p.lock.Unlock()
+	return s
+}
+
+func (p *KeyframePacer) UnbindLocalStream(info *interceptor.StreamInfo) {
+	p.lock.Lock()
+	s := p.streams[info.SSRC]
+	delete(p.streams, info.SSRC)
+	p.lock.Unlock()
+	if s != nil {
+		s.close()
+	}
+}
+
+func (p *KeyframePacer) Close() error {
+	p.lock.Lock()
+	streams := p.streams
+	p.streams = make(map[uint32]*pacedStream)
+	p.lock.Unlock()
+	for _, s := range streams {
+		s.close()
+	}
+	return nil
+}
+
+func (p *KeyframePacer) spikeChanged(ssrc uint32, spiking bool) {
+	p.lock.Lock()
+	onSpike := p.onSpike
+	p.lock.Unlock()
+	logger.Debugw("video stream bitrate spike", "ssrc", ssrc, "spiking", spiking)
+	if onSpike != nil {
+		onSpike(ssrc, spiking)
+	}
+}
+
+type queuedPacket struct {
+	packet     *rtp.Packet
+	attributes interceptor.Attributes
+	queuedAt   time.Time
+}
+
+type pacedStream struct {
+	pacer  *KeyframePacer
+	ssrc   uint32
+	writer interceptor.RTPWriter
+
+	lock sync.Mutex
+	// average bitrate, in bytes per second. nothing is paced until it's known
+	rate        float64
+	windowStart time.Time
+	windowBytes int
+	// bytes that could be sent right away
+	tokens     float64
+	lastRefill time.Time
+	queue      []queuedPacket
+	// set while the queue is being sent, packets have to be queued behind it to keep their order
+	draining bool
+	spiking  bool
+	closed   bool
+}
+
+func (s *pacedStream) Write(header *rtp.Header, payload []byte, attributes interceptor.Attributes) (int, error) {
+	now := time.Now()
+	size := len(payload)
+
+	s.lock.Lock()
+	if s.closed {
+		s.lock.Unlock()
+		return s.writer.Write(header, payload, attributes)
+	}
+	s.measure(now, size)
+	s.refill(now)
+	if !s.draining && (s.rate == 0 || s.tokens >= float64(size)) {
+		s.tokens -= float64(size)
+		s.lock.Unlock()
+		return s.writer.Write(header, payload, attributes)
+	}
+
+	// the payload is owned by the sender once Write returns
+	b, err := (&rtp.Packet{Header: *header, Payload: payload}).Marshal()
+	if err != nil {
+		s.lock.Unlock()
+		return 0, err
+	}
+	pkt := &rtp.Packet{}
+	if err := pkt.Unmarshal(b); err != nil {
+		s.lock.Unlock()
+		return 0, err
+	}
+	s.queue = append(s.queue, queuedPacket{packet: pkt, attributes: attributes, queuedAt: now})
+	startSpike := !s.spiking
+	s.spiking = true
+	if !s.draining {
+		s.draining = true
+		go s.drain()
+	}
+	s.lock.Unlock()
+
+	if startSpike {
+		s.pacer.spikeChanged(s.ssrc, true)
+	}
+	return size, nil
+}
+
+func (s *pacedStream) drain() {
+	for {
+		s.lock.Lock()
+		if len(s.queue) == 0 || s.closed {
+			s.queue = nil
+			s.draining = false
+			endSpike := s.spiking
+			s.spiking = false
+			s.lock.Unlock()
+			if endSpike {
+				s.pacer.spikeChanged(s.ssrc, false)
+			}
+			return
+		}
+
+		now := time.Now()
+		s.refill(now)
+		next := s.queue[0]
+		size := float64(len(next.packet.Payload))
+		if s.tokens < size {
+			// wait for enough tokens, unless the packet has been held for too long
+			wait := time.Duration((size - s.tokens) / s.paceRate() * float64(time.Second))
+			if remaining := s.pacer.conf.MaxDelay - now.Sub(next.queuedAt); remaining < wait {
+				wait = remaining
+			}
+			if wait > 0 {
+				s.lock.Unlock()
+				time.Sleep(wait)
+				continue
+			}
+		}
+		s.queue = s.queue[1:]
+		s.tokens -= size
+		if s.tokens < 0 {
+			// don't carry over the debt of packets sent past their max delay
+			s.tokens = 0
+		}
+		s.lock.Unlock()
+
+		if _, err := s.writer.Write(&next.packet.Header, next.packet.Payload, next.attributes); err != nil {
+			logger.Debugw("could not write paced packet", "ssrc", s.ssrc, "error", err)
+		}
+	}
+}
+
+func (s *pacedStream) close() {
+	s.lock.Lock()
+	s.closed = true
+	s.lock.Unlock()
+}
+
+// needs to be called with lock held
+func (s *pacedStream) measure(now time.Time, size int) {
+	if s.windowStart.IsZero() {
+		s.windowStart = now
+	}
+	s.windowBytes += size
+	elapsed := now.Sub(s.windowStart)
+	if elapsed < pacerRateWindow {
+		return
+	}
+	windowRate := float64(s.windowBytes) / elapsed.Seconds()
+	if s.rate == 0 {
+		s.rate = windowRate
+		s.tokens = s.burstSize()
+		s.lastRefill = now
+	} else {
+		s.rate = (1-pacerRateSmoothing)*s.rate + pacerRateSmoothing*windowRate
+	}
+	s.windowStart = now
+	s.windowBytes = 0
+}
+
+// needs to be called with lock held
+func (s *pacedStream) refill(now time.Time) {
+	if s.rate == 0 {
+		return
+	}
+	s.tokens += now.Sub(s.lastRefill).Seconds() * s.paceRate()
+	if burst := s.burstSize(); s.tokens > burst {
+		s.tokens = burst
+	}
+	s.lastRefill = now
+}
+
+// bytes per second packets are paced out at
+func (s *pacedStream) paceRate() float64 {
+	return s.rate * s.pacer.conf.RateMultiplier
+}
+
+func (s *pacedStream) burstSize() float64 {
+	return s.paceRate() * s.pacer.conf.Burst.Seconds()
+}
@@ -0,0 +1,123 @@
+package rtc
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pion/interceptor"
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+type rtpRecorder struct {
+	lock      sync.Mutex
+	sequences []uint16
+}
+
+func (r *rtpRecorder) Write(header *rtp.Header, payload []byte, _ interceptor.Attributes) (int, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.sequences = append(r.sequences, header.SequenceNumber)
+	return len(payload), nil
+}
+
+func (r *rtpRecorder) written() []uint16 {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return append([]uint16{}, r.sequences...)
+}
+
+func TestKeyframePacer(t *testing.T) {
+	conf := config.KeyframePacingConfig{
+		Enabled:        true,
+		RateMultiplier: 2,
+		Burst:          50 * time.Millisecond,
+		MaxDelay:       100 * time.Millisecond,
+	}
+	payload := make([]byte, 1000)
+
+	t.Run("doesn't pace audio", func(t *testing.T) {
+		p := NewKeyframePacer(conf)
+		writer := p.BindLocalStream(&interceptor.StreamInfo{SSRC: 1, MimeType: "audio/opus"}, &rtpRecorder{})
+		_, ok := writer.(*pacedStream)
+		require.False(t, ok)
+	})
+
+	t.Run("paces spikes without holding packets past max delay", func(t *testing.T) {
+		p := NewKeyframePacer(conf)
+		var spikeLock sync.Mutex
+		var spikes []bool
+		p.OnSpike(func(ssrc uint32, spiking bool) {
+			spikeLock.Lock()
+			defer spikeLock.Unlock()
+			if ssrc == 1 {
+				spikes = append(spikes, spiking)
+			}
+		})
+		recorder := &rtpRecorder{}
+		s := p.BindLocalStream(&interceptor.StreamInfo{SSRC: 1, MimeType: "video/VP8"}, recorder).(*pacedStream)
+
+		// 100 KB/s average, paced at 200 KB/s with a 10 KB burst
+		s.lock.Lock()
+		s.rate = 100_000
+		s.windowStart = time.Now()
+		s.tokens = s.burstSize()
+		s.lastRefill = time.Now()
+		s.lock.Unlock()
+
+		seq := uint16(0)
+		write := func(n int) {
+			for i := 0; i < n; i++ {
+				_, err := s.Write(&rtp.Header{SequenceNumber: seq}, payload, nil)
+				require.NoError(t, err)
+				seq++
+			}
+		}
+
+		// regular frames are sent right away
+		write(5)
+		require.Len(t, recorder.written(), 5)
+
+		// a 40 KB keyframe takes 200ms at the paced rate, it's capped by max delay
+		start := time.Now()
+		write(40)
+		require.Less(t, len(recorder.written()), 45)
+		require.Eventually(t, func() bool {
+			return len(recorder.written()) == 45
+		}, time.Second, 5*time.Millisecond)
+		require.Less(t, time.Since(start), conf.MaxDelay+50*time.Millisecond)
+		for i, sn := range recorder.written() {
+			require.Equal(t, uint16(i), sn)
+		}
+		require.Eventually(t, func() bool {
+			spikeLock.Lock()
+			defer spikeLock.Unlock()
+			return len(spikes) == 2
+		}, time.Second, 5*time.Millisecond)
+		require.Equal(t, []bool{true, false}, spikes)
+
+		// no added latency once the spike has passed
+		time.Sleep(conf.Burst)
+		write(1)
+		require.Len(t, recorder.written(), 46)
+	})
+
+	t.Run("sends right away once unbound", func(t *testing.T) {
+		p := NewKeyframePacer(conf)
+		recorder := &rtpRecorder{}
+		info := &interceptor.StreamInfo{SSRC: 1, MimeType: "video/VP8"}
+		s := p.BindLocalStream(info, recorder).(*pacedStream)
+		s.lock.Lock()
+		s.rate = 100_000
+		s.lastRefill = time.Now()
+		s.lock.Unlock()
+		p.UnbindLocalStream(info)
+
+		_, err := s.Write(&rtp.Header{}, payload, nil)
+		require.NoError(t, err)
+		require.Len(t, recorder.written(), 1)
+	})
+}
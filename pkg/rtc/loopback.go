@@ -0,0 +1,60 @@
+//go:build loopback
+// +build loopback
+
+package rtc
+
+import (
+	"sync"
+
+	"github.com/pion/ice/v2"
+	"github.com/pion/transport/vnet"
+	"github.com/pion/webrtc/v3"
+
+	"github.com/livekit/livekit-server/pkg/logger"
+)
+
+// Builds with the loopback tag connect every PeerConnection through a shared in-memory network,
+// for integration tests only. ICE and DTLS still take place, but without real sockets and with only
+// host candidates they complete in milliseconds, unaffected by the network of the machine running tests.
+// Since it requires a build tag, it can never be enabled in production builds.
+
+const loopbackCIDR = "10.0.0.0/16"
+
+var (
+	loopbackOnce   sync.Once
+	loopbackRouter *vnet.Router
+)
+
+func getLoopbackRouter() *vnet.Router {
+	loopbackOnce.Do(func() {
+		router, err := vnet.NewRouter(&vnet.RouterConfig{
+			CIDR:          loopbackCIDR,
+			LoggerFactory: logger.LoggerFactory(),
+		})
+		if err != nil {
+			panic(err)
+		}
+		if err := router.Start(); err != nil {
+			panic(err)
+		}
+		loopbackRouter = router
+	})
+	return loopbackRouter
+}
+
+// applyLoopback attaches the PeerConnection to its own host on the loopback network
+func applyLoopback(se *webrtc.SettingEngine) error {
+	n := vnet.NewNet(&vnet.NetConfig{})
+	if err := getLoopbackRouter().AddNet(n); err != nil {
+		return err
+	}
+	se.SetVNet(n)
+	// muxes and NAT mapping are bound to real interfaces
+	se.SetICEUDPMux(nil)
+	se.SetICETCPMux(nil)
+	se.SetNAT1To1IPs(nil, webrtc.ICECandidateTypeHost)
+	se.SetNetworkTypes([]webrtc.NetworkType{webrtc.NetworkTypeUDP4})
+	se.SetICEMulticastDNSMode(ice.MulticastDNSModeDisabled)
+	se.SetHostAcceptanceMinWait(0)
+	return nil
+}
@@ -0,0 +1,11 @@
+//go:build !loopback
+// +build !loopback
+
+package rtc
+
+import "github.com/pion/webrtc/v3"
+
+// applyLoopback is a no-op unless built with the loopback tag, see loopback.go
+func applyLoopback(_ *webrtc.SettingEngine) error {
+	return nil
+}
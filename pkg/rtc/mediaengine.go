@@ -3,6 +3,7 @@ package rtc
 import (
 	"strings"
 
+	"github.com/livekit/livekit-server/pkg/config"
 	livekit "github.com/livekit/livekit-server/proto"
 	"github.com/pion/sdp/v3"
 	"github.com/pion/webrtc/v3"
@@ -12,7 +13,9 @@ const (
 	frameMarking = "urn:ietf:params:rtp-hdrext:framemarking"
 )
 
-func createPubMediaEngine(codecs []*livekit.Codec) (*webrtc.MediaEngine, error) {
+// createPubMediaEngine negotiates abs-capture-time with publishers when captureTime is set, to forward it to
+// subscribers
+func createPubMediaEngine(codecs []*livekit.Codec, feedback *config.RTCPFeedbackConfig, captureTime bool) (*webrtc.MediaEngine, error) {
 	me := &webrtc.MediaEngine{}
 	opusCodec := webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeOpus, ClockRate: 48000, Channels: 2, SDPFmtpLine: "minptime=10;useinbandfec=1", RTCPFeedback: nil}
 	if isCodecEnabled(codecs, opusCodec) {
@@ -24,38 +27,34 @@ func createPubMediaEngine(codecs []*livekit.Codec) (*webrtc.MediaEngine, error)
 		}
 	}
 
-	videoRTCPFeedback := []webrtc.RTCPFeedback{
-		{Type: webrtc.TypeRTCPFBGoogREMB, Parameter: ""},
-		{Type: webrtc.TypeRTCPFBCCM, Parameter: "fir"},
-		{Type: webrtc.TypeRTCPFBNACK, Parameter: ""},
-		{Type: webrtc.TypeRTCPFBNACK, Parameter: "pli"}}
 	for _, codec := range []webrtc.RTPCodecParameters{
 		{
-			RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeVP8, ClockRate: 90000, RTCPFeedback: videoRTCPFeedback},
+			RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeVP8, ClockRate: 90000},
 			PayloadType:        96,
 		},
 		{
-			RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeVP9, ClockRate: 90000, SDPFmtpLine: "profile-id=0", RTCPFeedback: videoRTCPFeedback},
+			RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeVP9, ClockRate: 90000, SDPFmtpLine: "profile-id=0"},
 			PayloadType:        98,
 		},
 		{
-			RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeVP9, ClockRate: 90000, SDPFmtpLine: "profile-id=1", RTCPFeedback: videoRTCPFeedback},
+			RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeVP9, ClockRate: 90000, SDPFmtpLine: "profile-id=1"},
 			PayloadType:        100,
 		},
 		{
-			RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeH264, ClockRate: 90000, SDPFmtpLine: "level-asymmetry-allowed=1;packetization-mode=1;profile-level-id=42e01f", RTCPFeedback: videoRTCPFeedback},
+			RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeH264, ClockRate: 90000, SDPFmtpLine: "level-asymmetry-allowed=1;packetization-mode=1;profile-level-id=42e01f"},
 			PayloadType:        125,
 		},
 		{
-			RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeH264, ClockRate: 90000, SDPFmtpLine: "level-asymmetry-allowed=1;packetization-mode=0;profile-level-id=42e01f", RTCPFeedback: videoRTCPFeedback},
+			RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeH264, ClockRate: 90000, SDPFmtpLine: "level-asymmetry-allowed=1;packetization-mode=0;profile-level-id=42e01f"},
 			PayloadType:        108,
 		},
 		{
-			RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeH264, ClockRate: 90000, SDPFmtpLine: "level-asymmetry-allowed=1;packetization-mode=1;profile-level-id=640032", RTCPFeedback: videoRTCPFeedback},
+			RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeH264, ClockRate: 90000, SDPFmtpLine: "level-asymmetry-allowed=1;packetization-mode=1;profile-level-id=640032"},
 			PayloadType:        123,
 		},
 	} {
 		if isCodecEnabled(codecs, codec.RTPCodecCapability) {
+			codec.RTCPFeedback = publisherRTCPFeedback(feedback.ForCodec(codec.MimeType))
 			if err := me.RegisterCodec(codec, webrtc.RTPCodecTypeVideo); err != nil {
 				return nil, err
 			}
@@ -81,15 +80,88 @@ func createPubMediaEngine(codecs []*livekit.Codec) (*webrtc.MediaEngine, error)
 			return nil, err
 		}
 	}
+	if captureTime {
+		if err := registerHeaderExtension(me, absCaptureTimeURI); err != nil {
+			return nil, err
+		}
+	}
 
 	return me, nil
 }
 
-func createSubMediaEngine() (*webrtc.MediaEngine, error) {
+// createSubMediaEngine negotiates the extensions of jitterBufferHints when jitterBuffer is set, codecs are registered
+// as tracks are subscribed
+func createSubMediaEngine(jitterBuffer bool) (*webrtc.MediaEngine, error) {
 	me := &webrtc.MediaEngine{}
+	if jitterBuffer {
+		for _, extension := range []string{playoutDelayURI, absCaptureTimeURI} {
+			if err := registerHeaderExtension(me, extension); err != nil {
+				return nil, err
+			}
+		}
+	}
 	return me, nil
 }
 
+// registerHeaderExtension registers the extension for both audio and video
+func registerHeaderExtension(me *webrtc.MediaEngine, uri string) error {
+	for _, kind := range []webrtc.RTPCodecType{webrtc.RTPCodecTypeAudio, webrtc.RTPCodecTypeVideo} {
+		if err := me.RegisterHeaderExtension(webrtc.RTPHeaderExtensionCapability{URI: uri}, kind); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// publisherRTCPFeedback returns feedback to negotiate with publishers of a video codec.
+// FIR is always negotiated so keyframes could be requested when both NACK and PLI are disabled
+func publisherRTCPFeedback(types config.RTCPFeedbackTypes) []webrtc.RTCPFeedback {
+	feedback := []webrtc.RTCPFeedback{{Type: webrtc.TypeRTCPFBCCM, Parameter: "fir"}}
+	if types.REMB {
+		feedback = append(feedback, webrtc.RTCPFeedback{Type: webrtc.TypeRTCPFBGoogREMB})
+	}
+	if types.TWCC {
+		feedback = append(feedback, webrtc.RTCPFeedback{Type: webrtc.TypeRTCPFBTransportCC})
+	}
+	if types.NACK {
+		feedback = append(feedback, webrtc.RTCPFeedback{Type: webrtc.TypeRTCPFBNACK})
+	}
+	if types.PLI {
+		feedback = append(feedback, webrtc.RTCPFeedback{Type: webrtc.TypeRTCPFBNACK, Parameter: "pli"})
+	}
+	return feedback
+}
+
+// subscriberRTCPFeedback returns feedback to negotiate on DownTracks of a codec.
+// audio tracks only use NACK, subscribers of video fall back to FIR when PLI is disabled
+func subscriberRTCPFeedback(kind webrtc.RTPCodecType, types config.RTCPFeedbackTypes) []webrtc.RTCPFeedback {
+	var feedback []webrtc.RTCPFeedback
+	if kind == webrtc.RTPCodecTypeVideo && types.REMB {
+		feedback = append(feedback, webrtc.RTCPFeedback{Type: webrtc.TypeRTCPFBGoogREMB})
+	}
+	if types.NACK {
+		feedback = append(feedback, webrtc.RTCPFeedback{Type: webrtc.TypeRTCPFBNACK})
+	}
+	if kind == webrtc.RTPCodecTypeVideo {
+		if types.PLI {
+			feedback = append(feedback, webrtc.RTCPFeedback{Type: webrtc.TypeRTCPFBNACK, Parameter: "pli"})
+		} else {
+			feedback = append(feedback, webrtc.RTCPFeedback{Type: webrtc.TypeRTCPFBCCM, Parameter: "fir"})
+		}
+	}
+	return feedback
+}
+
+// hasRTCPFeedback checks if a feedback type has been negotiated
+func hasRTCPFeedback(feedback []webrtc.RTCPFeedback, fbType, parameter string) bool {
+	for _, fb := range feedback {
+		if fb.Type == fbType && fb.Parameter == parameter {
+			return true
+		}
+	}
+	return false
+}
+
 func isCodecEnabled(codecs []*livekit.Codec, cap webrtc.RTPCodecCapability) bool {
 	for _, codec := range codecs {
 		if !strings.EqualFold(codec.Mime, cap.MimeType) {
@@ -3,6 +3,7 @@ package rtc
 import (
 	"testing"
 
+	"github.com/livekit/livekit-server/pkg/config"
 	livekit "github.com/livekit/livekit-server/proto"
 	"github.com/pion/webrtc/v3"
 	"github.com/stretchr/testify/require"
@@ -23,3 +24,38 @@ func TestIsCodecEnabled(t *testing.T) {
 		require.False(t, isCodecEnabled(enabledCodecs, webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeVP8}))
 	})
 }
+
+func TestRTCPFeedback(t *testing.T) {
+	t.Run("codec overrides replace defaults", func(t *testing.T) {
+		conf := &config.RTCPFeedbackConfig{
+			RTCPFeedbackTypes: config.DefaultRTCPFeedback,
+			Codecs: map[string]config.RTCPFeedbackTypes{
+				"video/H264": {PLI: true, TWCC: true},
+			},
+		}
+		require.Equal(t, config.DefaultRTCPFeedback, conf.ForCodec(webrtc.MimeTypeVP8))
+		require.Equal(t, config.RTCPFeedbackTypes{PLI: true, TWCC: true}, conf.ForCodec(webrtc.MimeTypeH264))
+
+		var nilConf *config.RTCPFeedbackConfig
+		require.Equal(t, config.DefaultRTCPFeedback, nilConf.ForCodec(webrtc.MimeTypeVP8))
+	})
+
+	t.Run("publishers always negotiate FIR", func(t *testing.T) {
+		feedback := publisherRTCPFeedback(config.RTCPFeedbackTypes{TWCC: true})
+		require.True(t, hasRTCPFeedback(feedback, webrtc.TypeRTCPFBCCM, "fir"))
+		require.True(t, hasRTCPFeedback(feedback, webrtc.TypeRTCPFBTransportCC, ""))
+		require.False(t, hasRTCPFeedback(feedback, webrtc.TypeRTCPFBNACK, ""))
+		require.False(t, hasRTCPFeedback(feedback, webrtc.TypeRTCPFBNACK, "pli"))
+		require.False(t, hasRTCPFeedback(feedback, webrtc.TypeRTCPFBGoogREMB, ""))
+	})
+
+	t.Run("subscribers fall back to FIR without PLI", func(t *testing.T) {
+		feedback := subscriberRTCPFeedback(webrtc.RTPCodecTypeVideo, config.RTCPFeedbackTypes{NACK: true})
+		require.True(t, hasRTCPFeedback(feedback, webrtc.TypeRTCPFBNACK, ""))
+		require.True(t, hasRTCPFeedback(feedback, webrtc.TypeRTCPFBCCM, "fir"))
+		require.False(t, hasRTCPFeedback(feedback, webrtc.TypeRTCPFBNACK, "pli"))
+
+		feedback = subscriberRTCPFeedback(webrtc.RTPCodecTypeAudio, config.DefaultRTCPFeedback)
+		require.Equal(t, []webrtc.RTCPFeedback{{Type: webrtc.TypeRTCPFBNACK}}, feedback)
+	})
+}
@@ -2,6 +2,7 @@ package rtc
 
 import (
 	"errors"
+	"strings"
 	"sync"
 	"time"
 
@@ -20,11 +21,21 @@ import (
 	livekit "github.com/livekit/livekit-server/proto"
 )
 
-var (
-	feedbackTypes = []webrtc.RTCPFeedback{
-		{Type: webrtc.TypeRTCPFBGoogREMB},
-		{Type: webrtc.TypeRTCPFBNACK},
-		{Type: webrtc.TypeRTCPFBNACK, Parameter: "pli"}}
+const (
+	firstMediaPollInterval = 10 * time.Millisecond
+	firstMediaTimeout      = 30 * time.Second
+
+	// used for audio tracks that haven't been measured yet
+	estimatedAudioBitrate = 32_000
+
+	// binding reports are repeated in case some are lost, unless configured
+	defaultBindingReportInterval = 20 * time.Millisecond
+	defaultBindingReportAttempts = 7
+
+	// interval subscribers of simulcast tracks are checked for layers the publisher stopped sending
+	layerFallbackInterval = 500 * time.Millisecond
+	// subscribers binding within this interval of a keyframe request share its keyframe
+	bindKeyframeInterval = 500 * time.Millisecond
 )
 
 // MediaTrack represents a WebRTC track that needs to be forwarded
@@ -38,39 +49,105 @@ type MediaTrack struct {
 	codec       webrtc.RTPCodecParameters
 	muted       utils.AtomicFlag
 	simulcasted bool
+	// set once a packet of the track has been received, or reported missing
+	firstMedia utils.AtomicFlag
+	noMedia    utils.AtomicFlag
+	// ID of the track in streams of the publisher, the client track id
+	clientID string
 
 	// channel to send RTCP packets to the source
 	lock sync.RWMutex
 	// map of target participantId -> *SubscribedTrack
 	subscribedTracks map[string]*SubscribedTrack
-	twcc             *twcc.Responder
-	audioLevel       *AudioLevel
-	receiver         sfu.Receiver
-	lastPLI          time.Time
+	// map of sink address -> *UDPForwarder
+	udpForwarders map[string]*UDPForwarder
+	sinks         map[types.TrackSink]*trackSinkForwarder
+	twcc          *twcc.Responder
+	audioLevel    *AudioLevel
+	// set for Opus audio, whose packets carry their audio level
+	speaking *speakingDetector
+	// set when detecting silent audio or black camera video
+	silence  *silenceDetector
+	receiver sfu.Receiver
+	// last keyframe requested for a subscriber that bound
+	lastPLI time.Time
+	layers  *simulcastLayers
+	// receive buffers of each published stream, in the order they were added
+	buffers []*buffer.Buffer
+	// layer => SSRC it was first received on, buffers stay bound to it when the publisher switches to another
+	layerSSRCs map[int32]uint32
+	// highest spatial layer consumed by subscribers, -1 when none is
+	maxConsumedLayer int32
+	// layers of video as the publisher last described them
+	layerInfo []types.VideoLayerInfo
 
-	onClose func()
+	onClose      func()
+	onSpeaking   func(speaking bool)
+	onSilent     func(silent bool)
+	onFirstMedia func()
+	onNoMedia    func()
 }
 
 type MediaTrackParams struct {
 	TrackID        string
 	ParticipantID  string
+	Identity       string
 	RTCPChan       chan []rtcp.Packet
 	BufferFactory  *buffer.Factory
 	ReceiverConfig ReceiverConfig
+	SDESBatchSize  int
+	ReportPool     *WorkerPool
+	BufferBudget   *bufferBudget
 	AudioConfig    config.AudioConfig
+	Simulcast      config.SimulcastConfig
+	RTCPFeedback   *config.RTCPFeedbackConfig
 	Stats          *RoomStatsReporter
 	Width          uint32
 	Height         uint32
+	// what the track captures, inferred from its kind and name
+	Source types.TrackSource
+	// node-wide cap on subscriptions, nil when unlimited
+	SubscriptionLimiter *SubscriptionLimiter
+	// reception quality of subscribers reported to the publisher
+	SubscriberReports config.SubscriberReportsConfig
+	// handling of subscriptions whose codec doesn't match the kind of the track
+	TrackKindMismatch string
+	// detection of the publisher speaking on audio tracks
+	Speaking config.SpeakingConfig
+	// detection of silent audio and black camera video
+	SilentMedia config.SilentMediaConfig
+	// source descriptions sent when a subscriber is bound, BindingReportAttempts times BindingReportInterval apart
+	BindingReportInterval time.Duration
+	BindingReportAttempts int
+	// codecs the track could be transcoded to for subscribers that can't decode it, with Transcoder
+	Transcoding TranscodingMatrix
+	Transcoder  Transcoder
+	// continues layers the publisher switches to a new SSRC, nil when they're dropped
+	SSRCHandoff *ssrcHandoff
+	// reorders RTP of the track that arrives out of order, nil when it's forwarded as it arrives
+	ReorderBuffers *reorderBuffers
+	// how the track is reordered, derived from its Source when unset
+	ReorderBuffer ReorderBufferParams
+	// ID of abs-capture-time negotiated with the publisher, 0 when it isn't
+	CaptureTimeID uint8
 }
 
 func NewMediaTrack(track *webrtc.TrackRemote, params MediaTrackParams) *MediaTrack {
+	if params.ReorderBuffers != nil && params.ReorderBuffer == (ReorderBufferParams{}) {
+		params.ReorderBuffer = params.ReorderBuffers.paramsFor(ToProtoTrackKind(track.Kind()), params.Source)
+	}
 	t := &MediaTrack{
 		params:           params,
 		ssrc:             track.SSRC(),
 		streamID:         track.StreamID(),
+		clientID:         track.ID(),
 		kind:             ToProtoTrackKind(track.Kind()),
 		codec:            track.Codec(),
 		subscribedTracks: make(map[string]*SubscribedTrack),
+		udpForwarders:    make(map[string]*UDPForwarder),
+		sinks:            make(map[types.TrackSink]*trackSinkForwarder),
+		layers:           newSimulcastLayers(params.Simulcast),
+		maxConsumedLayer: -1,
 	}
 
 	return t
@@ -91,12 +168,35 @@ func (t *MediaTrack) Name() string {
 	return t.name
 }
 
+// ReorderBuffer returns how RTP of the track that arrives out of order is reordered, zero when it isn't
+func (t *MediaTrack) ReorderBuffer() ReorderBufferParams {
+	if t.params.ReorderBuffers == nil {
+		return ReorderBufferParams{}
+	}
+	return t.params.ReorderBuffer
+}
+
+func (t *MediaTrack) Source() types.TrackSource {
+	return t.params.Source
+}
+
 func (t *MediaTrack) IsMuted() bool {
 	return t.muted.Get()
 }
 
+// IsSimulcast is true while the publisher sends simulcast layers of the track
+func (t *MediaTrack) IsSimulcast() bool {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	return t.simulcasted
+}
+
 func (t *MediaTrack) SetMuted(muted bool) {
 	t.muted.TrySet(muted)
+	// muted tracks are expected to be silent
+	if muted && t.silence != nil && t.silence.reset() {
+		t.notifySilent(false)
+	}
 
 	// mute all of the subscribedtracks
 	t.lock.RLock()
@@ -110,8 +210,26 @@ func (t *MediaTrack) SetSimulcastLayers(layers []livekit.VideoQuality) {
 	t.lock.RLock()
 	defer t.lock.RUnlock()
 	if t.receiver != nil {
-		layers16 := funk.Map(layers, func(l livekit.VideoQuality) uint16 {
-			return uint16(spatialLayerForQuality(l))
+		spatial := funk.Map(layers, func(l livekit.VideoQuality) int32 {
+			return t.layers.layerForQuality(l)
+		}).([]int32)
+		if t.simulcasted {
+			// layers that aren't received, or whose stream was dropped, can't be switched to
+			received := t.layers.receivedLayers(spatial)
+			if len(received) != len(spatial) {
+				logger.Infow("publisher declared layers it isn't sending",
+					"track", t.params.TrackID,
+					"participantId", t.params.ParticipantID,
+					"declared", spatial,
+					"received", received)
+			}
+			if len(received) == 0 && len(spatial) != 0 {
+				return
+			}
+			spatial = received
+		}
+		layers16 := funk.Map(spatial, func(l int32) uint16 {
+			return uint16(l)
 		}).([]uint16)
 		t.receiver.SetAvailableLayers(layers16)
 	}
@@ -121,6 +239,35 @@ func (t *MediaTrack) OnClose(f func()) {
 	t.onClose = f
 }
 
+// OnSpeaking is called when the publisher starts or stops speaking on an Opus track, it has to be set before the
+// first receiver is added
+func (t *MediaTrack) OnSpeaking(f func(speaking bool)) {
+	t.onSpeaking = f
+}
+
+// OnSilent is called when the track goes silent or black and when it recovers, with silent media detection enabled.
+// It has to be set before the first receiver is added
+func (t *MediaTrack) OnSilent(f func(silent bool)) {
+	t.onSilent = f
+}
+
+// OnFirstMedia is called once, when a buffer of the track received its first packet. It has to be set before the
+// first receiver is added
+func (t *MediaTrack) OnFirstMedia(f func()) {
+	t.onFirstMedia = f
+}
+
+// OnNoMedia is called once when no packet of the track has been received within firstMediaTimeout of adding its
+// receivers. It has to be set before the first receiver is added
+func (t *MediaTrack) OnNoMedia(f func()) {
+	t.onNoMedia = f
+}
+
+// IsSilent is true while the publisher is sending silent audio or black video
+func (t *MediaTrack) IsSilent() bool {
+	return t.silence != nil && t.silence.isSilent()
+}
+
 func (t *MediaTrack) IsSubscriber(subId string) bool {
 	t.lock.RLock()
 	defer t.lock.RUnlock()
@@ -132,6 +279,13 @@ func (t *MediaTrack) AddSubscriber(sub types.Participant) error {
 	if !sub.CanSubscribe() {
 		return ErrPermissionDenied
 	}
+	if t.kind == livekit.TrackType_AUDIO && sub.SubscribesToMixedAudio() {
+		// the room sends it the track mixed with other speakers
+		return nil
+	}
+	if !sub.SupportsCodec(t.codec.MimeType) {
+		return t.addTranscodedSubscriber(sub)
+	}
 
 	t.lock.Lock()
 	defer t.lock.Unlock()
@@ -147,7 +301,30 @@ func (t *MediaTrack) AddSubscriber(sub types.Participant) error {
 		return errors.New("cannot subscribe without a receiver in place")
 	}
 
+	if t.kind == livekit.TrackType_VIDEO && sub.MaxDownloadBitrate() > 0 {
+		if err := sub.AdmitSubscription(t.ID(), t.minBitrateLocked()); err != nil {
+			return err
+		}
+	}
+
+	if !t.params.SubscriptionLimiter.acquire(t.kind) {
+		logger.Warnw("rejecting subscription, node is at its max subscriptions", ErrSubscriptionLimit,
+			"track", t.params.TrackID,
+			"participantId", t.params.ParticipantID,
+			"destParticipant", sub.Identity())
+		return ErrSubscriptionLimit
+	}
+	timer := t.params.Stats.StartSubscription(t.kind.String())
+	subscribed := false
+	defer func() {
+		if !subscribed {
+			timer.Stop()
+			t.params.SubscriptionLimiter.release()
+		}
+	}()
+
 	codec := t.receiver.Codec()
+	codec.RTCPFeedback = subscriberRTCPFeedback(t.receiver.Kind(), t.params.RTCPFeedback.ForCodec(codec.MimeType))
 	if err := sub.SubscriberMediaEngine().RegisterCodec(codec, t.receiver.Kind()); err != nil {
 		return err
 	}
@@ -165,14 +342,49 @@ func (t *MediaTrack) AddSubscriber(sub types.Participant) error {
 		ClockRate:    codec.ClockRate,
 		Channels:     codec.Channels,
 		SDPFmtpLine:  codec.SDPFmtpLine,
-		RTCPFeedback: feedbackTypes,
-	}, receiver, t.params.BufferFactory, sub.ID(), t.params.ReceiverConfig.packetBufferSize)
+		RTCPFeedback: codec.RTCPFeedback,
+	}, receiver, t.params.BufferFactory, sub.ID(), t.params.ReceiverConfig.nackWindow(sub.NackWindow()))
 	if err != nil {
 		return err
 	}
-	subTrack := NewSubscribedTrack(downTrack)
+	targetLayer := int32(0)
+	bestQualityFirst := t.shouldStartWithBestQuality()
+	defaultQuality, hasDefault := sub.DefaultVideoQuality()
+	pinnedQuality, pinned := sub.PinnedVideoQuality()
+	pinned = pinned && t.kind == livekit.TrackType_VIDEO
+	if pinned {
+		// recorders keep the same quality, whatever the device class
+		defaultQuality, hasDefault = pinnedQuality, true
+	}
+	hasDefault = hasDefault && t.kind == livekit.TrackType_VIDEO
+	if hasDefault {
+		// quality of the subscriber's device class, until it asks for another one
+		targetLayer = t.layers.layerForQuality(defaultQuality)
+		bestQualityFirst = targetLayer == t.layers.numLayers()-1
+	} else if bestQualityFirst {
+		targetLayer = t.layers.numLayers() - 1
+	}
+	subTrack := NewSubscribedTrack(downTrack, t.receiver, t.layers, targetLayer)
+	subTrack.pinned = pinned
+	subTrack.onConsumedLayerChange = t.updateConsumedLayers
 
-	transceiver, err := sub.SubscriberPC().AddTransceiverFromTrack(downTrack, webrtc.RTPTransceiverInit{
+	// codec and SSRC negotiated with the subscriber are only known once bound
+	wrappedDownTrack := NewWrappedDownTrack(downTrack, subTrack.onBind)
+	// the subscriber's transceiver is created with the kind of the DownTrack, which is derived from its codec
+	if kind := t.receiver.Kind(); downTrack.Kind() != kind {
+		mismatch := &TrackKindMismatchError{TrackID: t.ID(), Expected: kind, Actual: downTrack.Kind()}
+		if t.params.TrackKindMismatch == config.TrackKindMismatchReject {
+			logger.Warnw("rejecting subscription", mismatch,
+				"participantId", t.params.ParticipantID,
+				"destParticipant", sub.Identity())
+			return mismatch
+		}
+		logger.Warnw("correcting kind of subscribed track", mismatch,
+			"participantId", t.params.ParticipantID,
+			"destParticipant", sub.Identity())
+		wrappedDownTrack.kind = kind
+	}
+	transceiver, err := sub.SubscriberPC().AddTransceiverFromTrack(wrappedDownTrack, webrtc.RTPTransceiverInit{
 		Direction: webrtc.RTPTransceiverDirectionSendonly,
 	})
 	if err != nil {
@@ -180,17 +392,31 @@ func (t *MediaTrack) AddSubscriber(sub types.Participant) error {
 	}
 
 	downTrack.SetTransceiver(transceiver)
+	// no-op unless the room sends streams on stable SSRCs, the key has to be the same across reconnects
+	ssrc := uint32(transceiver.Sender().GetParameters().Encodings[0].SSRC)
+	sub.MapSubscriberSSRC(ssrc, t.params.Identity+"/"+t.kind.String()+"/"+t.name)
+	if t.params.CaptureTimeID != 0 {
+		sub.MapSubscriberCaptureTime(ssrc, t.params.CaptureTimeID)
+	}
 	// when outtrack is bound, start loop to send reports
 	downTrack.OnBind(func() {
+		subTrack.bound.TrySet(true)
+		timer.Bound()
 		subTrack.SetPublisherMuted(t.IsMuted())
+		// ask for a keyframe right away rather than once the first packet forwarded turns out not to be one
+		t.requestBindKeyframe(subTrack)
 		go t.sendDownTrackBindingReports(sub)
+		go waitForFirstMedia(downTrack, timer)
 	})
 
-	downTrack.OnCloseHandler(func() {
+	onDownTrackClose := func() {
+		timer.Stop()
+		t.params.SubscriptionLimiter.release()
 		go func() {
 			t.lock.Lock()
 			delete(t.subscribedTracks, sub.ID())
 			t.lock.Unlock()
+			t.updateConsumedLayers()
 
 			t.params.Stats.SubSubscribedTrack(t.kind.String())
 
@@ -223,11 +449,20 @@ func (t *MediaTrack) AddSubscriber(sub types.Participant) error {
 			sub.RemoveSubscribedTrack(t.params.ParticipantID, subTrack)
 			sub.Negotiate()
 		}()
-	})
+	}
+	downTrack.OnCloseHandler(onDownTrackClose)
+	subTrack.moveToReceiver = func(receiver sfu.Receiver) {
+		t.moveSubscription(sub, subTrack, transceiver, receiver, onDownTrackClose)
+	}
 
 	t.subscribedTracks[sub.ID()] = subTrack
+	t.updateConsumedLayersLocked()
 
-	t.receiver.AddDownTrack(downTrack, t.shouldStartWithBestQuality())
+	t.receiver.AddDownTrack(downTrack, bestQualityFirst)
+	if hasDefault {
+		// caps the layer, the receiver starts at either the lowest or highest one
+		subTrack.switchLayer(targetLayer)
+	}
 	// since sub will lock, run it in a gorountine to avoid deadlocks
 	go func() {
 		sub.AddSubscribedTrack(t.params.ParticipantID, subTrack)
@@ -235,6 +470,113 @@ func (t *MediaTrack) AddSubscriber(sub types.Participant) error {
 	}()
 
 	t.params.Stats.AddSubscribedTrack(t.kind.String())
+	subscribed = true
+	return nil
+}
+
+// AddUDPForwarder starts forwarding RTP packets of the track to a UDP sink at addr
+func (t *MediaTrack) AddUDPForwarder(addr string) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	// don't forward to the same sink multiple times
+	if t.udpForwarders[addr] != nil {
+		return nil
+	}
+
+	if t.receiver == nil {
+		return errors.New("cannot forward without a receiver in place")
+	}
+
+	streamId := PackStreamID(t.params.ParticipantID, t.ID())
+	receiver := NewWrappedReceiver(t.receiver, t.ID(), streamId)
+	forwarder, err := NewUDPForwarder(addr, receiver, t.params.BufferFactory, t.params.ReceiverConfig.packetBufferSize)
+	if err != nil {
+		return err
+	}
+	forwarder.OnClose(func() {
+		t.lock.Lock()
+		if t.udpForwarders[addr] == forwarder {
+			delete(t.udpForwarders, addr)
+			t.updateConsumedLayersLocked()
+		}
+		t.lock.Unlock()
+		logger.Debugw("stopped forwarding to UDP sink",
+			"track", t.params.TrackID,
+			"addr", addr)
+	})
+	t.udpForwarders[addr] = forwarder
+	t.updateConsumedLayersLocked()
+	forwarder.Start()
+
+	logger.Debugw("forwarding to UDP sink",
+		"track", t.params.TrackID,
+		"participantId", t.params.ParticipantID,
+		"addr", addr)
+	return nil
+}
+
+// RemoveUDPForwarder stops forwarding to the UDP sink at addr
+func (t *MediaTrack) RemoveUDPForwarder(addr string) error {
+	t.lock.RLock()
+	forwarder := t.udpForwarders[addr]
+	t.lock.RUnlock()
+
+	if forwarder == nil {
+		return ErrForwarderNotFound
+	}
+	forwarder.Stop()
+	return nil
+}
+
+// AddSink starts writing RTP packets of the track to sink, through a DownTrack of its own
+func (t *MediaTrack) AddSink(sink types.TrackSink) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.sinks[sink] != nil {
+		return nil
+	}
+
+	if t.receiver == nil {
+		return errors.New("cannot write to a sink without a receiver in place")
+	}
+
+	streamId := PackStreamID(t.params.ParticipantID, t.ID())
+	receiver := NewWrappedReceiver(t.receiver, t.ID(), streamId)
+	forwarder, err := newTrackSinkForwarder(sink, receiver, t.params.BufferFactory, t.params.ReceiverConfig.packetBufferSize)
+	if err != nil {
+		return err
+	}
+	forwarder.OnClose(func() {
+		t.lock.Lock()
+		if t.sinks[sink] == forwarder {
+			delete(t.sinks, sink)
+			t.updateConsumedLayersLocked()
+		}
+		t.lock.Unlock()
+		logger.Debugw("stopped writing to track sink", "track", t.params.TrackID)
+	})
+	t.sinks[sink] = forwarder
+	t.updateConsumedLayersLocked()
+	forwarder.Start()
+
+	logger.Debugw("writing to track sink",
+		"track", t.params.TrackID,
+		"participantId", t.params.ParticipantID)
+	return nil
+}
+
+// RemoveSink stops writing to sink and closes it
+func (t *MediaTrack) RemoveSink(sink types.TrackSink) error {
+	t.lock.RLock()
+	forwarder := t.sinks[sink]
+	t.lock.RUnlock()
+
+	if forwarder == nil {
+		return ErrSinkNotAttached
+	}
+	forwarder.Stop()
 	return nil
 }
 
@@ -243,19 +585,98 @@ func (t *MediaTrack) AddReceiver(receiver *webrtc.RTPReceiver, track *webrtc.Tra
 	t.lock.Lock()
 	defer t.lock.Unlock()
 
+	// streams of the other kind than those received so far, the publisher started or stopped simulcasting the track
+	restructured := t.receiver != nil && t.simulcasted != (track.RID() != "")
+	if restructured {
+		logger.Infow("publisher changed simulcast of track",
+			"track", t.params.TrackID,
+			"participantId", t.params.ParticipantID,
+			"simulcast", track.RID() != "")
+		t.layers.removeStreams()
+		t.layerSSRCs = nil
+		t.params.BufferBudget.releaseTrack(t.params.TrackID)
+	}
+
+	layer := int32(-1)
+	if track.RID() != "" {
+		var err error
+		if layer, err = t.layers.addStream(track.RID()); err != nil {
+			logger.Warnw("dropping simulcast stream", err,
+				"track", t.params.TrackID,
+				"participantId", t.params.ParticipantID,
+				"rid", track.RID(),
+				"negotiated", negotiatedRIDs(receiver))
+			return
+		}
+		if !isLayerRID(track.RID()) {
+			logger.Infow("receiving unknown RID as lowest layer",
+				"track", t.params.TrackID,
+				"participantId", t.params.ParticipantID,
+				"rid", track.RID(),
+				"negotiated", negotiatedRIDs(receiver))
+		}
+		if received, ok := t.layerSSRCs[layer]; ok {
+			t.handoffLayer(layer, received, track)
+			return
+		}
+	}
+
+	if track.Kind() == webrtc.RTPCodecTypeVideo && !t.params.BufferBudget.reserve(t.params.TrackID, uint32(track.SSRC())) {
+		// subscribers fall back to other layers, as when the publisher stops sending one
+		logger.Warnw("dropping video stream exceeding participant buffer limit", nil,
+			"track", t.params.TrackID,
+			"participantId", t.params.ParticipantID,
+			"rid", track.RID())
+		if layer >= 0 {
+			t.layers.removeStream(layer)
+		}
+		return
+	}
+
 	buff, rtcpReader := t.params.BufferFactory.GetBufferPair(uint32(track.SSRC()))
+	ssrc := uint32(track.SSRC())
+	if t.params.ReorderBuffers != nil {
+		t.params.ReorderBuffers.setStream(ssrc, t.params.ReorderBuffer)
+	}
+	if layer >= 0 {
+		if t.layerSSRCs == nil {
+			t.layerSSRCs = make(map[int32]uint32)
+		}
+		t.layerSSRCs[layer] = ssrc
+	}
 	buff.OnFeedback(func(fb []rtcp.Packet) {
+		if t.params.SubscriberReports.Enabled {
+			t.mergeSubscriberReports(fb, ssrc, layer)
+		}
 		if t.params.Stats != nil {
 			t.params.Stats.incoming.HandleRTCP(fb)
 		}
 		// feedback for the source RTCP
-		t.params.RTCPChan <- fb
+		sendRTCP(t.params.RTCPChan, fb)
 	})
 
+	if t.silence == nil && t.params.SilentMedia.Enabled &&
+		(t.Kind() == livekit.TrackType_AUDIO || t.params.Source != types.TrackSourceScreenShare) {
+		t.silence = newSilenceDetector(t.params.SilentMedia.After)
+	}
+
 	if t.Kind() == livekit.TrackType_AUDIO {
 		t.audioLevel = NewAudioLevel(t.params.AudioConfig.ActiveLevel, t.params.AudioConfig.MinPercentile)
+		if t.speaking == nil && strings.EqualFold(t.codec.MimeType, webrtc.MimeTypeOpus) {
+			t.speaking = newSpeakingDetector(t.params.Speaking)
+		}
 		buff.OnAudioLevel(func(level uint8) {
 			t.audioLevel.Observe(level)
+			if t.speaking != nil {
+				if speaking, changed := t.speaking.observe(level, time.Now()); changed {
+					t.notifySpeaking(speaking)
+				}
+			}
+			if t.silence != nil && !t.IsMuted() {
+				if silent, changed := t.silence.observe(level > t.params.SilentMedia.AudioLevel, time.Now()); changed {
+					t.notifySilent(silent)
+				}
+			}
 		})
 	} else if t.Kind() == livekit.TrackType_VIDEO {
 		if twcc != nil {
@@ -282,29 +703,117 @@ func (t *MediaTrack) AddReceiver(receiver *webrtc.RTPReceiver, track *webrtc.Tra
 		}
 	})
 
+	var replaced sfu.Receiver
+	var replacedBuffers []*buffer.Buffer
+	if restructured {
+		replaced, replacedBuffers = t.receiver, t.buffers
+		t.receiver, t.buffers = nil, nil
+	}
 	if t.receiver == nil {
-		t.receiver = sfu.NewWebRTCReceiver(receiver, track, t.params.ParticipantID, sfu.WithPliThrottle(0))
-		t.receiver.SetRTCPCh(t.params.RTCPChan)
-		t.receiver.OnCloseHandler(func() {
-			t.lock.Lock()
-			t.receiver = nil
-			onclose := t.onClose
-			t.lock.Unlock()
-			t.RemoveAllSubscribers()
-			t.params.Stats.SubPublishedTrack(t.kind.String())
-			if onclose != nil {
-				onclose()
+		t.receiver = t.newReceiver(receiver, track)
+		if replaced == nil {
+			t.params.Stats.AddPublishedTrack(t.kind.String())
+			if t.Kind() == livekit.TrackType_VIDEO && t.params.ReportPool != nil {
+				t.params.ReportPool.Every(layerFallbackInterval, t.checkLayerFallback)
 			}
-		})
-		t.params.Stats.AddPublishedTrack(t.kind.String())
+			if t.speaking != nil && t.params.ReportPool != nil {
+				t.params.ReportPool.Every(speakingCheckInterval, t.checkSpeaking)
+			}
+			if t.silence != nil && t.Kind() == livekit.TrackType_VIDEO && t.params.ReportPool != nil {
+				t.params.ReportPool.Every(silentVideoCheckInterval, t.checkSilentVideo)
+			}
+		}
 	}
 	t.receiver.AddUpTrack(track, buff, t.shouldStartWithBestQuality())
+	t.buffers = append(t.buffers, buff)
 	// when RID is set, track is simulcasted
 	t.simulcasted = track.RID() != ""
 
 	buff.Bind(receiver.GetParameters(), buffer.Options{
 		MaxBitRate: t.params.ReceiverConfig.maxBitrate,
 	})
+	if !t.firstMedia.Get() {
+		go t.watchFirstMedia(func() uint32 {
+			return buff.GetStats().PacketCount
+		}, firstMediaTimeout)
+	}
+	if replaced != nil {
+		t.replaceReceiverLocked(replaced, replacedBuffers)
+	}
+}
+
+// newReceiver creates the receiver forwarding streams of the track, the track is closed with it
+func (t *MediaTrack) newReceiver(receiver *webrtc.RTPReceiver, track *webrtc.TrackRemote) sfu.Receiver {
+	r := sfu.NewWebRTCReceiver(receiver, track, t.params.ParticipantID, sfu.WithPliThrottle(0))
+	r.SetRTCPCh(t.params.RTCPChan)
+	r.OnCloseHandler(func() {
+		t.lock.Lock()
+		t.receiver = nil
+		t.buffers = nil
+		t.layers.removeStreams()
+		onclose := t.onClose
+		t.lock.Unlock()
+		t.params.BufferBudget.releaseTrack(t.params.TrackID)
+		t.RemoveAllSubscribers()
+		t.params.Stats.SubPublishedTrack(t.kind.String())
+		if onclose != nil {
+			onclose()
+		}
+	})
+	return r
+}
+
+// handoffLayer continues a layer the publisher switched to a new SSRC without renegotiating. The receiver keeps
+// forwarding the buffer of the SSRC the layer was first received on, packets of the new one are written to it
+// rewritten to continue its sequence numbers and timestamps, so subscribers keep a single stream.
+// It starts with a keyframe, as the new stream can't be decoded with what subscribers received of the old one
+func (t *MediaTrack) handoffLayer(layer int32, received uint32, track *webrtc.TrackRemote) {
+	ssrc := uint32(track.SSRC())
+	if ssrc == received {
+		return
+	}
+	if t.params.SSRCHandoff == nil || !t.params.SSRCHandoff.replace(received, ssrc, track.Codec().ClockRate) {
+		logger.Warnw("dropping simulcast stream switched to a new SSRC", nil,
+			"track", t.params.TrackID,
+			"participantId", t.params.ParticipantID,
+			"rid", track.RID(),
+			"ssrc", received,
+			"newSSRC", ssrc)
+		return
+	}
+	logger.Infow("publisher switched simulcast stream to a new SSRC",
+		"track", t.params.TrackID,
+		"participantId", t.params.ParticipantID,
+		"rid", track.RID(),
+		"layer", layer,
+		"ssrc", received,
+		"newSSRC", ssrc)
+	// rewritten for the new SSRC on its way to the publisher
+	sendRTCP(t.params.RTCPChan, []rtcp.Packet{
+		&rtcp.PictureLossIndication{MediaSSRC: received},
+	})
+}
+
+// requestBindKeyframe requests a keyframe for a subscriber that bound, unless one was requested for another within
+// bindKeyframeInterval, so subscribers joining together don't each send a PLI
+func (t *MediaTrack) requestBindKeyframe(subTrack *SubscribedTrack) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if time.Since(t.lastPLI) < bindKeyframeInterval {
+		return
+	}
+	if subTrack.requestKeyframe() {
+		t.lastPLI = time.Now()
+	}
+}
+
+// negotiatedRIDs returns the RIDs a simulcast stream could be received on, as negotiated in SDP
+func negotiatedRIDs(receiver *webrtc.RTPReceiver) []string {
+	var rids []string
+	for _, track := range receiver.Tracks() {
+		rids = append(rids, track.RID())
+	}
+	return rids
 }
 
 // RemoveSubscriber removes participant from subscription
@@ -329,7 +838,7 @@ func (t *MediaTrack) RemoveAllSubscribers() {
 }
 
 func (t *MediaTrack) ToProto() *livekit.TrackInfo {
-	return &livekit.TrackInfo{
+	info := &livekit.TrackInfo{
 		Sid:       t.ID(),
 		Type:      t.Kind(),
 		Name:      t.Name(),
@@ -338,6 +847,270 @@ func (t *MediaTrack) ToProto() *livekit.TrackInfo {
 		Height:    t.params.Height,
 		Simulcast: t.simulcasted,
 	}
+	// dimensions the publisher encodes at, rather than those it published with
+	if highest, ok := highestLayer(t.Layers()); ok {
+		info.Width = highest.Width
+		info.Height = highest.Height
+	}
+	return info
+}
+
+// Layers returns the layers of video as the publisher last described them with the bitrates they are received at,
+// empty until it does
+func (t *MediaTrack) Layers() []types.VideoLayerInfo {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	layers := append([]types.VideoLayerInfo{}, t.layerInfo...)
+	if t.receiver == nil {
+		return layers
+	}
+	bitrates := t.receiver.GetBitrate()
+	for i := range layers {
+		if t.simulcasted {
+			layers[i].Bitrate = bitrates[t.layers.layerForQuality(layers[i].Quality)]
+		} else {
+			layers[i].Bitrate = bitrates[0]
+		}
+	}
+	return layers
+}
+
+func (t *MediaTrack) SetLayers(layers []types.VideoLayerInfo) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.layerInfo = append([]types.VideoLayerInfo{}, layers...)
+}
+
+func (t *MediaTrack) EstimateSubscription() (livekit.VideoQuality, uint64) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	if t.muted.Get() {
+		return livekit.VideoQuality_HIGH, 0
+	}
+	var bitrates [3]uint64
+	hasLayer := func(layer int32) bool { return layer == 0 }
+	if t.receiver != nil {
+		bitrates = t.receiver.GetBitrate()
+		hasLayer = t.receiver.HasSpatialLayer
+	}
+
+	if t.Kind() == livekit.TrackType_AUDIO {
+		if bitrates[0] == 0 {
+			return livekit.VideoQuality_HIGH, estimatedAudioBitrate
+		}
+		return livekit.VideoQuality_HIGH, bitrates[0]
+	}
+
+	if !t.simulcasted {
+		if bitrates[0] == 0 {
+			return livekit.VideoQuality_HIGH, t.layers.targetBitrate(t.layers.numLayers() - 1)
+		}
+		return livekit.VideoQuality_HIGH, bitrates[0]
+	}
+	// same layer AddSubscriber starts with
+	target := int32(0)
+	if t.shouldStartWithBestQuality() {
+		target = t.layers.numLayers() - 1
+	}
+	layer := t.layers.selectLayer(target, hasLayer, bitrates)
+	bitrate := bitrates[layer]
+	if bitrate == 0 {
+		bitrate = t.layers.targetBitrate(layer)
+	}
+	return t.layers.qualityForLayer(layer), bitrate
+}
+
+// minBitrateLocked returns the bitrate of the lowest layer of video, the least a subscriber could receive it at
+// must be called with lock held
+func (t *MediaTrack) minBitrateLocked() uint64 {
+	if bitrate := t.receiver.GetBitrate()[0]; bitrate > 0 {
+		return bitrate
+	}
+	if !t.simulcasted {
+		return t.layers.targetBitrate(t.layers.numLayers() - 1)
+	}
+	return t.layers.targetBitrate(0)
+}
+
+// targetBitrate returns the bitrate video of the track is expected to be published at, the sum of target bitrates
+// of the layers the publisher sends for simulcast. 0 for audio and muted tracks
+func (t *MediaTrack) targetBitrate() uint64 {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	if t.kind != livekit.TrackType_VIDEO || t.muted.Get() || t.receiver == nil {
+		return 0
+	}
+	if !t.simulcasted {
+		return t.layers.targetBitrate(t.layers.numLayers() - 1)
+	}
+	var target uint64
+	for layer := int32(0); layer < t.layers.numLayers(); layer++ {
+		if t.receiver.HasSpatialLayer(layer) {
+			target += t.layers.targetBitrate(layer)
+		}
+	}
+	return target
+}
+
+// checkSpeaking stops speaking once the publisher went silent or stopped sending packets, and once the track is
+// closed. Returns false once it is
+func (t *MediaTrack) checkSpeaking() bool {
+	t.lock.RLock()
+	closed := t.receiver == nil
+	t.lock.RUnlock()
+
+	if closed {
+		if t.speaking.stop() {
+			t.notifySpeaking(false)
+		}
+		return false
+	}
+	if speaking, changed := t.speaking.check(time.Now()); changed {
+		t.notifySpeaking(speaking)
+	}
+	return true
+}
+
+// checkSilentVideo tells when the camera has been sending below the black video bitrate, as it does when it's
+// covered. Returns false once the track is closed
+func (t *MediaTrack) checkSilentVideo() bool {
+	t.lock.RLock()
+	receiver := t.receiver
+	t.lock.RUnlock()
+	if receiver == nil {
+		return false
+	}
+	if t.IsMuted() {
+		return true
+	}
+
+	var bitrate uint64
+	for _, layerBitrate := range receiver.GetBitrate() {
+		bitrate += layerBitrate
+	}
+	// nothing received, the publisher could have paused the track
+	if bitrate == 0 {
+		return true
+	}
+	if silent, changed := t.silence.observe(bitrate < t.params.SilentMedia.VideoBitrate, time.Now()); changed {
+		t.notifySilent(silent)
+	}
+	return true
+}
+
+func (t *MediaTrack) notifySilent(silent bool) {
+	logger.Debugw("published track silence changed",
+		"track", t.params.TrackID,
+		"participant", t.params.Identity,
+		"silent", silent)
+	if t.onSilent != nil {
+		t.onSilent(silent)
+	}
+}
+
+func (t *MediaTrack) notifySpeaking(speaking bool) {
+	if t.onSpeaking != nil {
+		t.onSpeaking(speaking)
+	}
+}
+
+// checkLayerFallback moves subscribers off layers the publisher stopped sending, and back once they're sent again.
+// Returns false once the track is closed
+func (t *MediaTrack) checkLayerFallback() bool {
+	t.lock.RLock()
+	if t.receiver == nil {
+		t.lock.RUnlock()
+		return false
+	}
+	if !t.simulcasted {
+		t.lock.RUnlock()
+		return true
+	}
+	subTracks := make([]*SubscribedTrack, 0, len(t.subscribedTracks))
+	for _, st := range t.subscribedTracks {
+		subTracks = append(subTracks, st)
+	}
+	t.lock.RUnlock()
+
+	now := time.Now()
+	for _, st := range subTracks {
+		st.checkFallback(now)
+	}
+	return true
+}
+
+// MaxConsumedLayer returns the highest spatial layer any subscriber wants, -1 when the track isn't consumed
+func (t *MediaTrack) MaxConsumedLayer() int32 {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	return t.maxConsumedLayer
+}
+
+func (t *MediaTrack) updateConsumedLayers() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.updateConsumedLayersLocked()
+}
+
+// updateConsumedLayersLocked counts subscribers of each spatial layer. Layers above the highest one in use
+// aren't needed by anyone, and could be paused by the publisher. Lower layers are still needed as a fallback
+// when bandwidth is constrained.
+// must be called with lock held
+func (t *MediaTrack) updateConsumedLayersLocked() {
+	if !t.simulcasted {
+		return
+	}
+	refs := make([]int, t.layers.numLayers())
+	for _, st := range t.subscribedTracks {
+		if layer := st.consumedLayer(); layer >= 0 && int(layer) < len(refs) {
+			refs[layer]++
+		}
+	}
+	if len(t.udpForwarders) > 0 || len(t.sinks) > 0 {
+		// forwarders and sinks always receive the best quality
+		refs[len(refs)-1]++
+	}
+
+	maxLayer := int32(-1)
+	for layer := len(refs) - 1; layer >= 0; layer-- {
+		if refs[layer] > 0 {
+			maxLayer = int32(layer)
+			break
+		}
+	}
+	if maxLayer == t.maxConsumedLayer {
+		return
+	}
+	logger.Debugw("consumed simulcast layers changed",
+		"track", t.params.TrackID,
+		"participantId", t.params.ParticipantID,
+		"maxLayer", maxLayer,
+		"subscribersPerLayer", refs)
+	t.maxConsumedLayer = maxLayer
+}
+
+// mergeSubscriberReports includes reception quality of subscribers in reports on a published stream.
+// For simulcast, only subscribers receiving the stream's layer are included
+func (t *MediaTrack) mergeSubscriberReports(pkts []rtcp.Packet, ssrc uint32, layer int32) {
+	conf := t.params.SubscriberReports
+	var reports []receptionReport
+	t.lock.RLock()
+	for _, st := range t.subscribedTracks {
+		if layer >= 0 && st.DownTrack().CurrentSpatialLayer() != layer {
+			continue
+		}
+		if report, ok := st.receptionReport(conf.MaxAge); ok {
+			reports = append(reports, report)
+		}
+	}
+	t.lock.RUnlock()
+	if len(reports) == 0 {
+		return
+	}
+
+	fractionLost, jitter := aggregateReceptionReports(reports, conf.Aggregation)
+	mergeReceptionReports(pkts, ssrc, fractionLost, jitter)
 }
 
 // this function assumes caller holds lock
@@ -348,8 +1121,6 @@ func (t *MediaTrack) shouldStartWithBestQuality() bool {
 // TODO: send for all downtracks from the source participant
 // https://tools.ietf.org/html/rfc7941
 func (t *MediaTrack) sendDownTrackBindingReports(sub types.Participant) {
-	var sd []rtcp.SourceDescriptionChunk
-
 	t.lock.RLock()
 	subTrack := t.subscribedTracks[sub.ID()]
 	t.lock.RUnlock()
@@ -362,28 +1133,80 @@ func (t *MediaTrack) sendDownTrackBindingReports(sub types.Participant) {
 	if chunks == nil {
 		return
 	}
-	sd = append(sd, chunks...)
+	batches := batchDownTrackReports(nil, chunks, t.params.SDESBatchSize)
 
-	pkts := []rtcp.Packet{
-		&rtcp.SourceDescription{Chunks: sd},
+	interval, attempts := t.params.BindingReportInterval, t.params.BindingReportAttempts
+	if interval <= 0 {
+		interval = defaultBindingReportInterval
 	}
-
-	go func() {
-		defer RecoverSilent()
-		batch := pkts
-		i := 0
-		for {
+	if attempts <= 0 {
+		attempts = defaultBindingReportAttempts
+	}
+	i := 0
+	t.params.ReportPool.Every(interval, func() bool {
+		for _, batch := range batches {
 			if err := sub.SubscriberPC().WriteRTCP(batch); err != nil {
-				logger.Errorw("could not write RTCP", err)
+				logger.Warnw("could not write binding reports", err,
+					"track", t.params.TrackID,
+					"participantId", t.params.ParticipantID,
+					"destParticipant", sub.Identity())
+				return false
+			}
+		}
+		i++
+		return i < attempts
+	})
+}
+
+// waitForFirstMedia completes the subscription timer once the DownTrack has sent its first packet.
+// DownTrack doesn't signal when it starts writing, so its packet count is polled
+func waitForFirstMedia(downTrack *sfu.DownTrack, timer *SubscriptionTimer) {
+	ticker := time.NewTicker(firstMediaPollInterval)
+	defer ticker.Stop()
+	timeout := time.After(firstMediaTimeout)
+	for {
+		select {
+		case <-timeout:
+			// publisher could be muted, or the subscriber paused
+			timer.Stop()
+			return
+		case <-ticker.C:
+			if timer.done.Get() {
 				return
 			}
-			if i > 5 {
+			sr := downTrack.CreateSenderReport()
+			if sr == nil {
+				// unbound
+				timer.Stop()
+				return
+			}
+			if sr.PacketCount > 0 {
+				timer.FirstMedia()
 				return
 			}
-			i++
-			time.Sleep(20 * time.Millisecond)
 		}
-	}()
+	}
+}
+
+// GetBufferStats returns inbound statistics measured by receive buffers of the track.
+// Buffers guard their stats with the same lock used when processing packets, so it's safe to read anytime
+func (t *MediaTrack) GetBufferStats() []types.BufferStats {
+	t.lock.RLock()
+	buffers := t.buffers
+	t.lock.RUnlock()
+
+	stats := make([]types.BufferStats, 0, len(buffers))
+	for _, buff := range buffers {
+		bs := buff.GetStats()
+		stats = append(stats, types.BufferStats{
+			SSRC:        buff.GetMediaSSRC(),
+			PacketCount: bs.PacketCount,
+			LostRate:    bs.LostRate,
+			Jitter:      bs.Jitter,
+			Bitrate:     buff.Bitrate(),
+		})
+	}
+	return stats
 }
 
 func (t *MediaTrack) DebugInfo() map[string]interface{} {
@@ -393,11 +1216,14 @@ func (t *MediaTrack) DebugInfo() map[string]interface{} {
 		"Kind":     t.kind.String(),
 		"PubMuted": t.muted.Get(),
 	}
+	if reorder := t.ReorderBuffer(); reorder.Delay > 0 {
+		info["ReorderBuffer"] = reorder
+	}
 
 	subscribedTrackInfo := make([]map[string]interface{}, 0)
 	t.lock.RLock()
 	for _, track := range t.subscribedTracks {
-		dt := track.dt.DebugInfo()
+		dt := track.DownTrack().DebugInfo()
 		dt["PubMuted"] = track.pubMuted.Get()
 		dt["SubMuted"] = track.subMuted.Get()
 		subscribedTrackInfo = append(subscribedTrackInfo, dt)
@@ -410,6 +1236,25 @@ func (t *MediaTrack) DebugInfo() map[string]interface{} {
 		for k, v := range receiverInfo {
 			info[k] = v
 		}
+
+		if t.simulcasted {
+			maxConsumedLayer := t.MaxConsumedLayer()
+			bitrates := t.receiver.GetBitrate()
+			layers := make([]map[string]interface{}, 0, t.layers.numLayers())
+			for layer := int32(0); layer < int32(len(bitrates)); layer++ {
+				if !t.receiver.HasSpatialLayer(layer) {
+					continue
+				}
+				layers = append(layers, map[string]interface{}{
+					"Layer":         layer,
+					"Consumed":      layer <= maxConsumedLayer,
+					"Quality":       t.layers.qualityForLayer(layer).String(),
+					"Bitrate":       bitrates[layer],
+					"TargetBitrate": t.layers.targetBitrate(layer),
+				})
+			}
+			info["Layers"] = layers
+		}
 	}
 
 	return info
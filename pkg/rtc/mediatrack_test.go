@@ -0,0 +1,406 @@
+package rtc
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pion/ion-sfu/pkg/buffer"
+	"github.com/pion/ion-sfu/pkg/sfu"
+	"github.com/pion/rtp"
+	"github.com/pion/transport/packetio"
+	"github.com/pion/webrtc/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/logger"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+	"github.com/livekit/livekit-server/pkg/rtc/types/typesfakes"
+	livekit "github.com/livekit/livekit-server/proto"
+)
+
+func TestGetBufferStats(t *testing.T) {
+	factory := buffer.NewBufferFactory(500, logger.GetLogger())
+	buff := factory.GetOrNew(packetio.RTPBufferPacket, 1234).(*buffer.Buffer)
+	buff.Bind(webrtc.RTPParameters{
+		Codecs: []webrtc.RTPCodecParameters{{
+			RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeOpus, ClockRate: 48000},
+			PayloadType:        111,
+		}},
+	}, buffer.Options{MaxBitRate: 1e6})
+	defer buff.Close()
+
+	mt := &MediaTrack{buffers: []*buffer.Buffer{buff}}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			pkt := rtp.Packet{
+				Header:  rtp.Header{Version: 2, PayloadType: 111, SequenceNumber: uint16(i), Timestamp: uint32(i * 960), SSRC: 1234},
+				Payload: []byte{0x01, 0x02},
+			}
+			b, err := pkt.Marshal()
+			require.NoError(t, err)
+			_, err = buff.Write(b)
+			require.NoError(t, err)
+		}
+	}()
+	// reading stats while packets are processed
+	for i := 0; i < 100; i++ {
+		mt.GetBufferStats()
+	}
+	wg.Wait()
+
+	stats := mt.GetBufferStats()
+	require.Len(t, stats, 1)
+	require.Equal(t, uint32(1234), stats[0].SSRC)
+	require.Equal(t, uint32(100), stats[0].PacketCount)
+}
+
+func TestConsumedLayers(t *testing.T) {
+	mt := &MediaTrack{
+		simulcasted:      true,
+		layers:           newSimulcastLayers(config.SimulcastConfig{}),
+		subscribedTracks: make(map[string]*SubscribedTrack),
+		udpForwarders:    make(map[string]*UDPForwarder),
+		maxConsumedLayer: -1,
+	}
+	low := &SubscribedTrack{targetLayer: 0}
+	high := &SubscribedTrack{targetLayer: 2}
+	mt.subscribedTracks["low"] = low
+	mt.subscribedTracks["high"] = high
+	mt.updateConsumedLayers()
+	require.Equal(t, int32(2), mt.MaxConsumedLayer())
+
+	// lower layers remain active while only the high one is unused
+	high.subMuted.TrySet(true)
+	mt.updateConsumedLayers()
+	require.Equal(t, int32(0), mt.MaxConsumedLayer())
+
+	low.paused.TrySet(true)
+	mt.updateConsumedLayers()
+	require.Equal(t, int32(-1), mt.MaxConsumedLayer())
+
+	// forwarders always take the best quality
+	mt.udpForwarders["addr"] = &UDPForwarder{}
+	mt.updateConsumedLayers()
+	require.Equal(t, int32(2), mt.MaxConsumedLayer())
+}
+
+func TestTrackKindMismatch(t *testing.T) {
+	// a video track whose codec was reported as audio
+	receiver := &mismatchedReceiver{
+		kind:  webrtc.RTPCodecTypeVideo,
+		codec: webrtc.RTPCodecParameters{RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeOpus, ClockRate: 48000}},
+	}
+	newTrack := func(behavior string) *MediaTrack {
+		return &MediaTrack{
+			params: MediaTrackParams{
+				TrackID:           "track",
+				ParticipantID:     "pub",
+				BufferFactory:     buffer.NewBufferFactory(500, logger.GetLogger()),
+				TrackKindMismatch: behavior,
+			},
+			kind:             livekit.TrackType_VIDEO,
+			receiver:         receiver,
+			layers:           newSimulcastLayers(config.SimulcastConfig{}),
+			subscribedTracks: make(map[string]*SubscribedTrack),
+			udpForwarders:    make(map[string]*UDPForwarder),
+			maxConsumedLayer: -1,
+		}
+	}
+	newSubscriber := func(t *testing.T) (*typesfakes.FakeParticipant, *PCTransport) {
+		transport, err := NewPCTransport(TransportParams{Target: livekit.SignalTarget_SUBSCRIBER, Config: &WebRTCConfig{}})
+		require.NoError(t, err)
+		sub := &typesfakes.FakeParticipant{}
+		sub.IDReturns("sub")
+		sub.CanSubscribeReturns(true)
+		sub.SupportsCodecReturns(true)
+		sub.SubscriberPCReturns(transport.pc)
+		sub.SubscriberMediaEngineReturns(transport.me)
+		return sub, transport
+	}
+
+	t.Run("creates transceiver with the kind of the track", func(t *testing.T) {
+		sub, transport := newSubscriber(t)
+		defer transport.Close()
+
+		require.NoError(t, newTrack(config.TrackKindMismatchFix).AddSubscriber(sub))
+		transceivers := transport.pc.GetTransceivers()
+		require.Len(t, transceivers, 1)
+		require.Equal(t, webrtc.RTPCodecTypeVideo, transceivers[0].Kind())
+	})
+
+	t.Run("rejects subscription", func(t *testing.T) {
+		sub, transport := newSubscriber(t)
+		defer transport.Close()
+		limiter := NewSubscriptionLimiter(10)
+		mt := newTrack(config.TrackKindMismatchReject)
+		mt.params.SubscriptionLimiter = limiter
+
+		err := mt.AddSubscriber(sub)
+		require.True(t, errors.Is(err, ErrTrackKindMismatch))
+		var mismatch *TrackKindMismatchError
+		require.True(t, errors.As(err, &mismatch))
+		require.Equal(t, webrtc.RTPCodecTypeVideo, mismatch.Expected)
+		require.Equal(t, webrtc.RTPCodecTypeAudio, mismatch.Actual)
+
+		require.Empty(t, transport.pc.GetTransceivers())
+		require.Empty(t, mt.subscribedTracks)
+		require.Zero(t, limiter.Active())
+	})
+}
+
+func TestDefaultQualityByDeviceClass(t *testing.T) {
+	newTrack := func() (*MediaTrack, *layeredReceiver) {
+		receiver := &layeredReceiver{mismatchedReceiver: mismatchedReceiver{
+			kind:  webrtc.RTPCodecTypeVideo,
+			codec: webrtc.RTPCodecParameters{RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeVP8, ClockRate: 90000}},
+		}}
+		return &MediaTrack{
+			params: MediaTrackParams{
+				TrackID:       "track",
+				ParticipantID: "pub",
+				BufferFactory: buffer.NewBufferFactory(500, logger.GetLogger()),
+			},
+			kind:             livekit.TrackType_VIDEO,
+			simulcasted:      true,
+			receiver:         receiver,
+			layers:           newSimulcastLayers(config.SimulcastConfig{}),
+			subscribedTracks: make(map[string]*SubscribedTrack),
+			udpForwarders:    make(map[string]*UDPForwarder),
+			maxConsumedLayer: -1,
+		}, receiver
+	}
+	subscribeWith := func(t *testing.T, mt *MediaTrack, setup func(sub *typesfakes.FakeParticipant)) *SubscribedTrack {
+		transport, err := NewPCTransport(TransportParams{Target: livekit.SignalTarget_SUBSCRIBER, Config: &WebRTCConfig{}})
+		require.NoError(t, err)
+		t.Cleanup(transport.Close)
+		sub := &typesfakes.FakeParticipant{}
+		sub.IDReturns("sub")
+		sub.CanSubscribeReturns(true)
+		sub.SupportsCodecReturns(true)
+		sub.SubscriberPCReturns(transport.pc)
+		sub.SubscriberMediaEngineReturns(transport.me)
+		setup(sub)
+		require.NoError(t, mt.AddSubscriber(sub))
+		return mt.subscribedTracks["sub"]
+	}
+	subscribe := func(t *testing.T, mt *MediaTrack, quality livekit.VideoQuality, hasDefault bool) *SubscribedTrack {
+		return subscribeWith(t, mt, func(sub *typesfakes.FakeParticipant) {
+			sub.DefaultVideoQualityReturns(quality, hasDefault)
+		})
+	}
+
+	t.Run("starts at the quality of the device class", func(t *testing.T) {
+		mt, receiver := newTrack()
+		st := subscribe(t, mt, livekit.VideoQuality_MEDIUM, true)
+		require.Equal(t, int32(1), st.consumedLayer())
+		require.False(t, receiver.bestQualityFirst)
+
+		mt, receiver = newTrack()
+		st = subscribe(t, mt, livekit.VideoQuality_HIGH, true)
+		require.Equal(t, int32(2), st.consumedLayer())
+		require.True(t, receiver.bestQualityFirst)
+	})
+
+	t.Run("starts at the best quality without a device class", func(t *testing.T) {
+		mt, receiver := newTrack()
+		st := subscribe(t, mt, livekit.VideoQuality_HIGH, false)
+		require.Equal(t, int32(2), st.consumedLayer())
+		require.True(t, receiver.bestQualityFirst)
+	})
+
+	t.Run("follows changes of device class until the subscriber asks for a quality", func(t *testing.T) {
+		mt, _ := newTrack()
+		st := subscribe(t, mt, livekit.VideoQuality_LOW, true)
+		require.Equal(t, int32(0), st.consumedLayer())
+
+		st.setDefaultQuality(livekit.VideoQuality_HIGH)
+		require.Equal(t, int32(2), st.consumedLayer())
+		require.Equal(t, int32(2), mt.MaxConsumedLayer())
+
+		st.UpdateSubscriberSettings(true, livekit.VideoQuality_MEDIUM)
+		require.Eventually(t, func() bool {
+			return st.consumedLayer() == 1
+		}, time.Second, 10*time.Millisecond)
+		st.setDefaultQuality(livekit.VideoQuality_LOW)
+		require.Equal(t, int32(1), st.consumedLayer())
+	})
+
+	t.Run("recorders stay at the pinned quality", func(t *testing.T) {
+		mt, receiver := newTrack()
+		st := subscribeWith(t, mt, func(sub *typesfakes.FakeParticipant) {
+			sub.DefaultVideoQualityReturns(livekit.VideoQuality_HIGH, true)
+			sub.PinnedVideoQualityReturns(livekit.VideoQuality_MEDIUM, true)
+		})
+		require.True(t, st.pinned)
+		require.Equal(t, int32(1), st.consumedLayer())
+		require.False(t, receiver.bestQualityFirst)
+
+		st.setDefaultQuality(livekit.VideoQuality_HIGH)
+		st.setSpiking(true)
+		st.UpdateSubscriberSettings(true, livekit.VideoQuality_HIGH)
+		time.Sleep(2 * subscriptionDebounceInterval)
+		require.Equal(t, int32(1), st.consumedLayer())
+		require.False(t, st.spikeDowngraded)
+
+		// still follows whether the recorder wants the track at all
+		st.UpdateSubscriberSettings(false, livekit.VideoQuality_HIGH)
+		require.Eventually(t, func() bool {
+			return st.consumedLayer() == -1
+		}, time.Second, 10*time.Millisecond)
+	})
+}
+
+func TestUndeclaredSimulcastLayers(t *testing.T) {
+	receiver := &layeredReceiver{mismatchedReceiver: mismatchedReceiver{
+		kind:  webrtc.RTPCodecTypeVideo,
+		codec: webrtc.RTPCodecParameters{RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeVP8, ClockRate: 90000}},
+	}}
+	mt := &MediaTrack{
+		params: MediaTrackParams{
+			TrackID:       "track",
+			ParticipantID: "pub",
+		},
+		kind:        livekit.TrackType_VIDEO,
+		simulcasted: true,
+		receiver:    receiver,
+		layers:      newSimulcastLayers(config.SimulcastConfig{UnknownLayers: config.SimulcastUnknownLayersIgnore}),
+	}
+
+	// negotiated q, h and f, but sending h on a RID of its own
+	for _, rid := range []string{quarterResolution, "1", fullResolution} {
+		_, _ = mt.layers.addStream(rid)
+	}
+
+	// only layers that are received could be forwarded
+	mt.SetSimulcastLayers([]livekit.VideoQuality{livekit.VideoQuality_LOW, livekit.VideoQuality_MEDIUM, livekit.VideoQuality_HIGH})
+	require.Equal(t, []uint16{0, 2}, receiver.availableLayers)
+
+	// none of the declared layers are received, what's available is kept
+	mt.SetSimulcastLayers([]livekit.VideoQuality{livekit.VideoQuality_MEDIUM})
+	require.Equal(t, []uint16{0, 2}, receiver.availableLayers)
+}
+
+// a simulcast receiver with all layers available
+type layeredReceiver struct {
+	mismatchedReceiver
+	bestQualityFirst bool
+	availableLayers  []uint16
+}
+
+func (r *layeredReceiver) AddDownTrack(_ *sfu.DownTrack, bestQualityFirst bool) {
+	r.bestQualityFirst = bestQualityFirst
+}
+func (r *layeredReceiver) HasSpatialLayer(_ int32) bool { return true }
+func (r *layeredReceiver) GetBitrate() [3]uint64        { return [3]uint64{} }
+func (r *layeredReceiver) SetAvailableLayers(layers []uint16) {
+	r.availableLayers = layers
+}
+
+type mismatchedReceiver struct {
+	sfu.Receiver
+	kind  webrtc.RTPCodecType
+	codec webrtc.RTPCodecParameters
+}
+
+func (r *mismatchedReceiver) TrackID() string                       { return "track" }
+func (r *mismatchedReceiver) StreamID() string                      { return "stream" }
+func (r *mismatchedReceiver) Kind() webrtc.RTPCodecType             { return r.kind }
+func (r *mismatchedReceiver) Codec() webrtc.RTPCodecParameters      { return r.codec }
+func (r *mismatchedReceiver) AddDownTrack(_ *sfu.DownTrack, _ bool) {}
+
+func TestTrackSource(t *testing.T) {
+	require.Equal(t, types.TrackSourceCamera, types.TrackSourceFor(livekit.TrackType_VIDEO, "webcam"))
+	require.Equal(t, types.TrackSourceScreenShare, types.TrackSourceFor(livekit.TrackType_VIDEO, "Screen 1"))
+	require.Equal(t, types.TrackSourceMicrophone, types.TrackSourceFor(livekit.TrackType_AUDIO, ""))
+	require.Equal(t, types.TrackSourceScreenShareAudio, types.TrackSourceFor(livekit.TrackType_AUDIO, "screen_audio"))
+	require.Equal(t, types.TrackSourceUnknown, types.TrackSourceFor(livekit.TrackType_DATA, "screen"))
+}
+
+func TestFirstMedia(t *testing.T) {
+	newBuffer := func(t *testing.T, ssrc uint32) *buffer.Buffer {
+		factory := buffer.NewBufferFactory(500, logger.GetLogger())
+		buff := factory.GetOrNew(packetio.RTPBufferPacket, ssrc).(*buffer.Buffer)
+		buff.Bind(webrtc.RTPParameters{
+			Codecs: []webrtc.RTPCodecParameters{{
+				RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeVP8, ClockRate: 90000},
+				PayloadType:        96,
+			}},
+		}, buffer.Options{MaxBitRate: 1e6})
+		t.Cleanup(func() { _ = buff.Close() })
+		return buff
+	}
+	write := func(t *testing.T, buff *buffer.Buffer, ssrc uint32) {
+		pkt := rtp.Packet{
+			Header:  rtp.Header{Version: 2, PayloadType: 96, SequenceNumber: 1, Timestamp: 3000, SSRC: ssrc},
+			Payload: []byte{0x10, 0x00},
+		}
+		b, err := pkt.Marshal()
+		require.NoError(t, err)
+		_, err = buff.Write(b)
+		require.NoError(t, err)
+	}
+	newTrack := func() (*MediaTrack, *int32, *int32) {
+		var first, none int32
+		mt := &MediaTrack{
+			params:   MediaTrackParams{TrackID: "track", Identity: "pub"},
+			receiver: &mismatchedReceiver{},
+		}
+		mt.OnFirstMedia(func() { atomic.AddInt32(&first, 1) })
+		mt.OnNoMedia(func() { atomic.AddInt32(&none, 1) })
+		return mt, &first, &none
+	}
+	packets := func(buff *buffer.Buffer) func() uint32 {
+		return func() uint32 {
+			return buff.GetStats().PacketCount
+		}
+	}
+
+	t.Run("fires once on the first packet of any stream", func(t *testing.T) {
+		mt, first, none := newTrack()
+		low, high := newBuffer(t, 1), newBuffer(t, 2)
+		var wg sync.WaitGroup
+		for _, buff := range []*buffer.Buffer{low, high} {
+			wg.Add(1)
+			go func(buff *buffer.Buffer) {
+				defer wg.Done()
+				mt.watchFirstMedia(packets(buff), time.Second)
+			}(buff)
+		}
+		time.Sleep(3 * firstMediaPollInterval)
+		require.Zero(t, atomic.LoadInt32(first))
+
+		write(t, high, 2)
+		write(t, low, 1)
+		wg.Wait()
+		require.Equal(t, int32(1), atomic.LoadInt32(first))
+		require.Zero(t, atomic.LoadInt32(none))
+	})
+
+	t.Run("reports tracks that never send media", func(t *testing.T) {
+		mt, first, none := newTrack()
+		buff := newBuffer(t, 1)
+		mt.watchFirstMedia(packets(buff), 50*time.Millisecond)
+		mt.watchFirstMedia(packets(buff), 50*time.Millisecond)
+		require.Zero(t, atomic.LoadInt32(first))
+		require.Equal(t, int32(1), atomic.LoadInt32(none))
+
+		// media arriving late still counts
+		write(t, buff, 1)
+		mt.watchFirstMedia(packets(buff), time.Second)
+		require.Equal(t, int32(1), atomic.LoadInt32(first))
+	})
+
+	t.Run("doesn't report closed tracks", func(t *testing.T) {
+		mt, _, none := newTrack()
+		mt.receiver = nil
+		mt.watchFirstMedia(packets(newBuffer(t, 1)), 50*time.Millisecond)
+		require.Zero(t, atomic.LoadInt32(none))
+	})
+}
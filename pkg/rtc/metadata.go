@@ -0,0 +1,44 @@
+package rtc
+
+import (
+	"sort"
+
+	"google.golang.org/protobuf/proto"
+
+	livekit "github.com/livekit/livekit-server/proto"
+)
+
+// trimRosterMetadata leaves out metadata of the participants with the largest, until the metadata of all of them
+// fits in maxSize bytes. Participants are copied when trimmed, infos could be shared with other recipients
+func trimRosterMetadata(infos []*livekit.ParticipantInfo, maxSize int) []*livekit.ParticipantInfo {
+	if maxSize <= 0 {
+		return infos
+	}
+	total := 0
+	for _, info := range infos {
+		total += len(info.Metadata)
+	}
+	if total <= maxSize {
+		return infos
+	}
+
+	largest := make([]int, len(infos))
+	for i := range largest {
+		largest[i] = i
+	}
+	sort.SliceStable(largest, func(i, j int) bool {
+		return len(infos[largest[i]].Metadata) > len(infos[largest[j]].Metadata)
+	})
+	trimmed := make([]*livekit.ParticipantInfo, len(infos))
+	copy(trimmed, infos)
+	for _, i := range largest {
+		if total <= maxSize {
+			break
+		}
+		total -= len(infos[i].Metadata)
+		info := proto.Clone(infos[i]).(*livekit.ParticipantInfo)
+		info.Metadata = ""
+		trimmed[i] = info
+	}
+	return trimmed
+}
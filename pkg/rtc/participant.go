@@ -3,6 +3,7 @@ package rtc
 import (
 	"fmt"
 	"io"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -27,7 +28,17 @@ import (
 const (
 	lossyDataChannel    = "_lossy"
 	reliableDataChannel = "_reliable"
-	sdBatchSize         = 20
+	// channels the server opens on the subscriber connection for its own messages
+	serverLossyDataChannel    = "_server_lossy"
+	serverReliableDataChannel = "_server_reliable"
+	// interval of sender reports for subscribed tracks, unless configured
+	defaultSenderReportInterval = 5 * time.Second
+	// interval of REMB sent to publishers with a max upload bitrate
+	uploadCapInterval = time.Second
+	// transport stats are also collected on every connection state change
+	transportStatsInterval = 10 * time.Second
+	// RTCP written by tracks of a closed participant is discarded until none was written for this long
+	rtcpDrainTimeout = time.Second
 )
 
 type ParticipantParams struct {
@@ -37,8 +48,76 @@ type ParticipantParams struct {
 	AudioConfig     config.AudioConfig
 	ProtocolVersion types.ProtocolVersion
 	Stats           *RoomStatsReporter
+	ReportPool      *WorkerPool
 	ThrottleConfig  config.PLIThrottleConfig
+	TrickleLimit    config.TrickleLimitConfig
+	ICEGathering    config.ICEGatheringConfig
+	SCTP            config.SCTPConfig
+	Simulcast       config.SimulcastConfig
 	EnabledCodecs   []*livekit.Codec
+	RTCPFeedback    *config.RTCPFeedbackConfig
+	// return errors for messages from superseded signal connections instead of ignoring them
+	RejectStaleSignal bool
+	Negotiation       config.NegotiationConfig
+	// samples subscribed tracks when set
+	Telemetry *TelemetrySink
+	// node-wide cap on subscriptions to tracks of this participant, nil when unlimited
+	SubscriptionLimiter *SubscriptionLimiter
+	// time to recover after losing the signal connection or ICE connectivity before it's closed,
+	// 0 to close right away
+	ReconnectGrace time.Duration
+	// max bitrate of video published by the participant, in bits per second. 0 when unlimited
+	MaxUploadBitrate uint64
+	// max bitrate of media sent to the participant, in bits per second. 0 when unlimited
+	MaxDownloadBitrate uint64
+	// bytes, see config.RoomConfig.MaxMetadataSize and MaxRosterMetadataSize. 0 when unlimited
+	MaxMetadataSize       int
+	MaxRosterMetadataSize int
+	// what's done when video subscriptions don't fit in MaxDownloadBitrate, see config.DownloadBudgetAudioOnly
+	DownloadBudgetPolicy string
+	// shares MaxDownloadBitrate among video subscriptions by picking their layers, overriding
+	// DownloadBudgetPolicy. see config.AllocationGreedy, empty for none
+	SubscriptionAllocation string
+	// packets subscribed streams could be retransmitted for, bounded by the receiver config. 0 for the full buffer
+	NackWindow int
+	// send streams to the participant on SSRCs derived from their publisher and track, stable across reconnects
+	StableSSRCs bool
+	// class of device the participant is on, picks the default quality of video subscriptions from DeviceClasses
+	DeviceClass string
+	// device class => low, medium or high
+	DeviceClasses map[string]string
+	// low, medium or high to pin the quality of video subscriptions, for recorders. empty to adapt it
+	PinnedVideoQuality string
+	// tells the participant when it can't send its target bitrate, when enabled
+	PublisherCongestion config.PublisherCongestionConfig
+	// subscriptions the participant could keep disabled and bound, 0 when unlimited
+	MaxWarmStandbys int
+	// keep reception reports of subscribed tracks for room quality sampling, even when they aren't sent to
+	// publishers
+	KeepReceptionReports bool
+	// rate the network of the participant from its streams, every connectionQualityInterval
+	ConnectionQuality bool
+	// tell the participant which layers of its video subscriptions it's receiving when they change
+	ForwardedLayers bool
+	// lower layers of video subscriptions that don't fit the estimated bandwidth of the participant, when enabled
+	BandwidthAdaptation config.BandwidthAdaptationConfig
+	// generates the participant's sid, random when nil. Tests could supply deterministic sids
+	IDGenerator func() string
+	// lowercase mime types of codecs the participant could decode, empty when it decodes any enabled codec
+	SupportedCodecs []string
+	// the participant receives the mix of the room's loudest speakers instead of their audio tracks, only set in
+	// rooms mixing audio
+	MixedAudio bool
+	// codecs tracks published by the participant could be transcoded to for subscribers that can't decode them,
+	// transcoded with Transcoder. Other mismatched subscriptions are rejected
+	Transcoding TranscodingMatrix
+	Transcoder  Transcoder
+	// keyframes are requested at this interval for video sent to the participant, for recorders. 0 to disable
+	RecorderKeyframeInterval time.Duration
+	// what's done once the participant keeps failing to publish
+	PublishFailures config.PublishFailuresConfig
+	// depths of the participant's jitter buffers, see config.JitterBufferConfig.For
+	JitterBuffer config.JitterBufferConfig
 }
 
 type ParticipantImpl struct {
@@ -47,59 +126,188 @@ type ParticipantImpl struct {
 	publisher         *PCTransport
 	subscriber        *PCTransport
 	isClosed          utils.AtomicFlag
-	permission        *livekit.ParticipantPermission
-	state             atomic.Value // livekit.ParticipantInfo_State
-	updateAfterActive atomic.Value // bool
+	closed            chan struct{} // closed along with the participant, stops its workers
+	permission        atomic.Value  // *livekit.ParticipantPermission, nil when everything is permitted
+	state             atomic.Value  // livekit.ParticipantInfo_State
+	updateAfterActive atomic.Value  // bool
+	interrupted       utils.AtomicFlag
+	reconnecting      utils.AtomicFlag
 	rtcpCh            chan []rtcp.Packet
 	pliThrottle       *pliThrottle
+	// consecutive RTCP write failures, the participant is closed when either transport keeps failing
+	pubRTCPFailures rtcpWriteFailures
+	subRTCPFailures rtcpWriteFailures
+	reconnectGrace  *reconnectGrace
+	// bits per second, see ParticipantParams.MaxUploadBitrate
+	maxUploadBitrate uint64
+	downloadBudget   *downloadBudget
+	// packets, see ParticipantParams.NackWindow
+	nackWindow int32
+	// string, see ParticipantParams.DeviceClass
+	deviceClass atomic.Value
+
+	// nil when the ICE gathering timeout is disabled
+	iceGathering *iceGatheringWatch
+	// times the client has been asked to reconnect after gathering timed out
+	iceGatheringRetries int32
+
+	// limits trickle candidates accepted from the client
+	pubCandidates *candidateLimiter
+	subCandidates *candidateLimiter
+	// orders candidates sent to the client by IP family preference
+	pubCandidateQueue *candidateQueue
+	subCandidateQueue *candidateQueue
+	// nil when buffers of published streams aren't limited
+	bufferBudget *bufferBudget
+	// nil when subscriber telemetry is disabled
+	telemetry *subscriberTelemetry
+	// nil unless streams are sent on stable SSRCs
+	ssrcRemapper *ssrcRemapper
+	// continues published streams switched to a new SSRC
+	ssrcHandoff *ssrcHandoff
+	// nil when publisher congestion isn't detected
+	congestion *publisherCongestion
+	// nil unless connection quality is rated
+	connectionQuality *connectionQuality
+	// nil unless layers of video subscriptions adapt to the estimated bandwidth
+	bandwidthAdaptation *bandwidthAdaptation
+	// time published video takes to answer keyframe requests
+	keyframeLatency *keyframeLatency
+	// nil unless published RTP is reordered
+	reorderBuffers *reorderBuffers
+	// nil when disabled subscriptions aren't limited
+	warmStandbys *warmStandbys
+	// nil when nothing's done about failures to publish
+	publishFailures *publishFailures
+	// nil unless the depth of jitter buffers is set
+	jitterBuffer *jitterBufferHints
 
 	// reliable and unreliable data channels
-	reliableDC *webrtc.DataChannel
-	lossyDC    *webrtc.DataChannel
+	reliableDC *dataChannel
+	lossyDC    *dataChannel
+
+	// opened on the subscriber connection for server messages, when the participant hasn't opened its own
+	serverReliableDC *serverDataChannel
+	serverLossyDC    *serverDataChannel
+	// ID of the last server message split into chunks
+	lastDataMessageID uint32
+
+	recordingLock sync.Mutex
+	// whether the room is being recorded, and what the participant was last told
+	recording     bool
+	recordingSent bool
+
+	speakingLock sync.Mutex
+	// Opus tracks of the participant currently speaking, it's speaking while any is
+	speakingTracks map[string]bool
 
 	// when first connected
 	connectedAt time.Time
 
 	// JSON encoded metadata to pass to clients
 	metadata string
+	// display name, could change during the session
+	name string
+	// why it's leaving, empty until it's set
+	disconnectReason types.DisconnectReason
+	// set once the participant's been told its data packets are dropped
+	dataDenied utils.AtomicFlag
+	// layers the participant was last told it's receiving, only used by updateForwardedLayers
+	sentForwardedLayers map[string]int32
+	// tracks it was receiving when it was closed, its subscriptions are torn down afterwards
+	closedSubscriptions []string
+	subscriptionsClosed bool
 
 	// hold reference for MediaTrack
 	twcc *twcc.Responder
+	// FIR sequence numbers of published SSRCs that have not negotiated PLI
+	firSeqs map[uint32]uint8
+
+	// debug messages of the participant are logged regardless of the log level while it's debugged
+	log logger.Scope
 
 	// tracks the current participant is subscribed to, map of otherParticipantId => []DownTrack
 	subscribedTracks map[string][]types.SubscribedTrack
 	// publishedTracks that participant is publishing
 	publishedTracks map[string]types.PublishedTrack
+	// published tracks muted by the server, the participant can't unmute them
+	serverMutedTracks map[string]bool
 	// client intended to publish, yet to be reconciled
 	pendingTracks map[string]*livekit.TrackInfo
 
+	// generation of the currently bound signal connection, incremented when a client resumes on a new one.
+	// signalLock is held while handling negotiation messages, so they cannot interleave with a resume
+	signalGeneration uint32
+	signalLock       sync.RWMutex
+
 	lock sync.RWMutex
 	once sync.Once
 
 	// callbacks & handlers
-	onTrackPublished func(types.Participant, types.PublishedTrack)
-	onTrackUpdated   func(types.Participant, types.PublishedTrack)
-	onStateChange    func(p types.Participant, oldState livekit.ParticipantInfo_State)
-	onMetadataUpdate func(types.Participant)
-	onDataPacket     func(types.Participant, *livekit.DataPacket)
-	onClose          func(types.Participant)
+	onTrackPublished     func(types.Participant, types.PublishedTrack)
+	onTrackUpdated       func(types.Participant, types.PublishedTrack)
+	onFirstMediaReceived func(types.Participant, types.PublishedTrack)
+	onTrackSubscribed    func(p types.Participant, pubID string, subTrack types.SubscribedTrack)
+	onTrackUnsubscribed  func(p types.Participant, pubID string, subTrack types.SubscribedTrack)
+	onStateChange        func(p types.Participant, oldState livekit.ParticipantInfo_State)
+	onInterruptionChange func(types.Participant)
+	onReconnectingChange func(types.Participant)
+	onMetadataUpdate     func(types.Participant)
+	onNameUpdate         func(types.Participant)
+	onDataPacket         func(types.Participant, *livekit.DataPacket)
+	onPublisherCongested func(p types.Participant, congested bool)
+	onSpeaking           func(p types.Participant, speaking bool)
+	onClose              func(types.Participant)
+
+	onConnectionQualityChange func(p types.Participant, quality types.ConnectionQuality)
 }
 
 func NewParticipant(params ParticipantParams) (*ParticipantImpl, error) {
 	// TODO: check to ensure params are valid, id and identity can't be empty
 
+	newID := params.IDGenerator
+	if newID == nil {
+		newID = func() string {
+			return utils.NewGuid(utils.ParticipantPrefix)
+		}
+	}
+	rtcpQueueSize := params.Config.RTCPQueueSize
+	if rtcpQueueSize <= 0 {
+		rtcpQueueSize = defaultRTCPQueueSize
+	}
 	p := &ParticipantImpl{
-		params:           params,
-		id:               utils.NewGuid(utils.ParticipantPrefix),
-		rtcpCh:           make(chan []rtcp.Packet, 50),
-		pliThrottle:      newPLIThrottle(params.ThrottleConfig),
-		subscribedTracks: make(map[string][]types.SubscribedTrack),
-		publishedTracks:  make(map[string]types.PublishedTrack, 0),
-		pendingTracks:    make(map[string]*livekit.TrackInfo),
-		connectedAt:      time.Now(),
+		params:            params,
+		id:                newID(),
+		rtcpCh:            make(chan []rtcp.Packet, rtcpQueueSize),
+		closed:            make(chan struct{}),
+		pliThrottle:       newPLIThrottle(params.ThrottleConfig),
+		pubCandidates:     newCandidateLimiter(params.TrickleLimit),
+		subCandidates:     newCandidateLimiter(params.TrickleLimit),
+		subscribedTracks:  make(map[string][]types.SubscribedTrack),
+		publishedTracks:   make(map[string]types.PublishedTrack, 0),
+		serverMutedTracks: make(map[string]bool),
+		pendingTracks:     make(map[string]*livekit.TrackInfo),
+		firSeqs:           make(map[uint32]uint8),
+		connectedAt:       time.Now(),
+		pubCandidateQueue: newCandidateQueue(params.Config.IPFamilies),
+		subCandidateQueue: newCandidateQueue(params.Config.IPFamilies),
+		bufferBudget:      newBufferBudget(params.Config.Receiver),
 	}
 	p.state.Store(livekit.ParticipantInfo_JOINING)
 	p.updateAfterActive.Store(false)
+	p.reconnectGrace = newReconnectGrace(params.ReconnectGrace, p.onReconnectGraceExpired)
+	p.maxUploadBitrate = params.MaxUploadBitrate
+	p.downloadBudget = newDownloadBudget(params.MaxDownloadBitrate, params.DownloadBudgetPolicy)
+	p.downloadBudget.setAllocationStrategy(params.SubscriptionAllocation)
+	p.nackWindow = int32(params.Config.Receiver.nackWindow(params.NackWindow))
+	p.deviceClass.Store(params.DeviceClass)
+	if params.ICEGathering.Timeout > 0 {
+		p.iceGathering = newICEGatheringWatch(params.ICEGathering.Timeout, p.onICEGatheringTimeout)
+	}
+
+	p.ssrcHandoff = newSSRCHandoff()
+	p.keyframeLatency = newKeyframeLatency()
+	p.reorderBuffers = newReorderBuffers(params.Config.ReorderBuffer)
 
 	var err error
 	p.publisher, err = NewPCTransport(TransportParams{
@@ -107,30 +315,64 @@ func NewParticipant(params ParticipantParams) (*ParticipantImpl, error) {
 		Config:        params.Config,
 		Stats:         p.params.Stats,
 		EnabledCodecs: p.params.EnabledCodecs,
+		RTCPFeedback:  p.params.RTCPFeedback,
+		BufferBudget:  p.bufferBudget,
+		Negotiation:   params.Negotiation,
+		SSRCHandoff:   p.ssrcHandoff,
+
+		KeyframeLatency: p.keyframeLatency,
+		ReorderBuffers:  p.reorderBuffers,
+		CaptureTime:     params.JitterBuffer.Enabled,
 	})
 	if err != nil {
 		return nil, err
 	}
-	p.subscriber, err = NewPCTransport(TransportParams{
-		Target: livekit.SignalTarget_SUBSCRIBER,
-		Config: params.Config,
-		Stats:  p.params.Stats,
-	})
+	if params.Telemetry != nil {
+		p.telemetry = newSubscriberTelemetry(params.Telemetry)
+	}
+	subParams := TransportParams{
+		Target:      livekit.SignalTarget_SUBSCRIBER,
+		Config:      params.Config,
+		Stats:       p.params.Stats,
+		Negotiation: params.Negotiation,
+		Telemetry:   p.telemetry,
+	}
+	if params.Config.SubscriberReports.Enabled || params.KeepReceptionReports || params.ConnectionQuality {
+		subParams.OnReceptionReport = p.onSubscriberReceptionReport
+	}
+	if params.ConnectionQuality {
+		p.connectionQuality = &connectionQuality{}
+	}
+	if params.BandwidthAdaptation.Enabled {
+		p.bandwidthAdaptation = newBandwidthAdaptation(params.BandwidthAdaptation)
+		subParams.BandwidthAdaptation = p.bandwidthAdaptation
+	}
+	if params.PublisherCongestion.Enabled {
+		p.congestion = newPublisherCongestion(params.PublisherCongestion)
+	}
+	p.warmStandbys = newWarmStandbys(params.MaxWarmStandbys)
+	p.publishFailures = newPublishFailures(params.PublishFailures)
+	if params.StableSSRCs {
+		p.ssrcRemapper = newSSRCRemapper()
+		subParams.SSRCRemapper = p.ssrcRemapper
+	}
+	if p.jitterBuffer = newJitterBufferHints(params.JitterBuffer); p.jitterBuffer != nil {
+		subParams.JitterBuffer = p.jitterBuffer
+	}
+	p.subscriber, err = NewPCTransport(subParams)
 	if err != nil {
 		return nil, err
 	}
 
+	if p.subscriber.pacer != nil && params.Config.KeyframePacing.DowngradeLayer {
+		p.subscriber.pacer.OnSpike(p.onSubscriberSpike)
+	}
+
 	p.publisher.pc.OnICECandidate(func(c *webrtc.ICECandidate) {
-		if c == nil || p.State() == livekit.ParticipantInfo_DISCONNECTED {
-			return
-		}
-		p.sendIceCandidate(c, livekit.SignalTarget_PUBLISHER)
+		p.onICECandidate(c, livekit.SignalTarget_PUBLISHER)
 	})
 	p.subscriber.pc.OnICECandidate(func(c *webrtc.ICECandidate) {
-		if c == nil || p.State() == livekit.ParticipantInfo_DISCONNECTED {
-			return
-		}
-		p.sendIceCandidate(c, livekit.SignalTarget_SUBSCRIBER)
+		p.onICECandidate(c, livekit.SignalTarget_SUBSCRIBER)
 	})
 
 	p.publisher.pc.OnICEConnectionStateChange(p.handlePublisherICEStateChange)
@@ -138,6 +380,13 @@ func NewParticipant(params ParticipantParams) (*ParticipantImpl, error) {
 	p.publisher.pc.OnDataChannel(p.onDataChannel)
 
 	p.subscriber.OnOffer(p.onOffer)
+	p.subscriber.OnNegotiationFailed(p.onNegotiationFailed)
+	p.publisher.OnAnswer(func(answer webrtc.SessionDescription) {
+		logger.Infow("answering stuck offer again", "participant", p.Identity())
+		if err := p.sendAnswer(answer); err != nil {
+			logger.Warnw("could not send answer", err, "participant", p.Identity())
+		}
+	})
 
 	return p, nil
 }
@@ -167,21 +416,101 @@ func (p *ParticipantImpl) IsReady() bool {
 	return state == livekit.ParticipantInfo_JOINED || state == livekit.ParticipantInfo_ACTIVE
 }
 
+func (p *ParticipantImpl) IsInterrupted() bool {
+	return p.interrupted.Get()
+}
+
 func (p *ParticipantImpl) ConnectedAt() time.Time {
 	return p.connectedAt
 }
 
-// SetMetadata attaches metadata to the participant
-func (p *ParticipantImpl) SetMetadata(metadata string) {
+// Metadata returns the metadata attached to the participant
+func (p *ParticipantImpl) Metadata() string {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	return p.metadata
+}
+
+// SetMetadata attaches metadata to the participant, other participants are updated when it changes.
+// Metadata larger than the max size is rejected with a MetadataTooLargeError
+func (p *ParticipantImpl) SetMetadata(metadata string) error {
+	if max := p.params.MaxMetadataSize; max > 0 && len(metadata) > max {
+		return &MetadataTooLargeError{Size: len(metadata), Max: max}
+	}
+	p.lock.Lock()
+	if p.metadata == metadata {
+		p.lock.Unlock()
+		return nil
+	}
 	p.metadata = metadata
+	p.lock.Unlock()
 
 	if p.onMetadataUpdate != nil {
 		p.onMetadataUpdate(p)
 	}
+	return nil
+}
+
+// Name returns the display name of the participant
+func (p *ParticipantImpl) Name() string {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	return p.name
 }
 
+// SetName renames the participant, other participants are updated when it changes
+func (p *ParticipantImpl) SetName(name string) {
+	p.lock.Lock()
+	if p.name == name {
+		p.lock.Unlock()
+		return
+	}
+	p.name = name
+	p.lock.Unlock()
+
+	if p.onNameUpdate != nil {
+		p.onNameUpdate(p)
+	}
+}
+
+func (p *ParticipantImpl) SetDisconnectReason(reason types.DisconnectReason) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.disconnectReason == "" {
+		p.disconnectReason = reason
+	}
+}
+
+func (p *ParticipantImpl) DisconnectReason() types.DisconnectReason {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	if p.disconnectReason == "" {
+		return types.DisconnectReasonUnknown
+	}
+	return p.disconnectReason
+}
+
+// closeWithReason closes the participant, recording reason unless another one was set before
+func (p *ParticipantImpl) closeWithReason(reason types.DisconnectReason) {
+	p.SetDisconnectReason(reason)
+	_ = p.Close()
+}
+
+// DebugLogging logs debug messages of the participant regardless of the log level for d, 0 to stop right away
+func (p *ParticipantImpl) DebugLogging(d time.Duration) {
+	p.log.Debug(d)
+	logger.Infow("debug logging of participant", "participant", p.Identity(), "duration", d)
+}
+
+// SetPermission could be called mid-session, e.g. when publishing keeps failing, while permissions are checked by
+// signal and data channel handlers
 func (p *ParticipantImpl) SetPermission(permission *livekit.ParticipantPermission) {
-	p.permission = permission
+	p.permission.Store(permission)
+}
+
+func (p *ParticipantImpl) getPermission() *livekit.ParticipantPermission {
+	permission, _ := p.permission.Load().(*livekit.ParticipantPermission)
+	return permission
 }
 
 func (p *ParticipantImpl) RTCPChan() chan []rtcp.Packet {
@@ -192,12 +521,12 @@ func (p *ParticipantImpl) ToProto() *livekit.ParticipantInfo {
 	info := &livekit.ParticipantInfo{
 		Sid:      p.id,
 		Identity: p.params.Identity,
-		Metadata: p.metadata,
 		State:    p.State(),
 		JoinedAt: p.ConnectedAt().Unix(),
 	}
 
 	p.lock.RLock()
+	info.Metadata = p.metadata
 	for _, t := range p.publishedTracks {
 		info.Tracks = append(info.Tracks, t.ToProto())
 	}
@@ -209,8 +538,273 @@ func (p *ParticipantImpl) GetResponseSink() routing.MessageSink {
 	return p.params.Sink
 }
 
+// SetResponseSink binds a new signal connection, messages from previous connections are no longer processed
 func (p *ParticipantImpl) SetResponseSink(sink routing.MessageSink) {
+	p.signalLock.Lock()
+	defer p.signalLock.Unlock()
 	p.params.Sink = sink
+	p.signalGeneration++
+	p.reconnectGrace.setSignalLost(false)
+}
+
+// ReconnectGrace returns the time the participant has to recover after losing its signal connection or ICE
+// connectivity, before it's closed
+func (p *ParticipantImpl) ReconnectGrace() time.Duration {
+	return p.reconnectGrace.Grace()
+}
+
+// SetReconnectGrace changes the grace period, including while the participant is reconnecting.
+// It's closed right away when the new grace period is shorter than the time it has been disconnected
+func (p *ParticipantImpl) SetReconnectGrace(grace time.Duration) {
+	p.log.Debugw("updating reconnect grace", "participant", p.Identity(), "grace", grace)
+	p.reconnectGrace.setGrace(grace)
+}
+
+// HandleSignalLost is called when the signal connection of generation ends. It returns false when the
+// participant doesn't have a grace period and should be closed, otherwise it's kept until it resumes
+// on a new connection or the grace period is over
+func (p *ParticipantImpl) HandleSignalLost(generation uint32) bool {
+	p.signalLock.RLock()
+	defer p.signalLock.RUnlock()
+	if generation != p.signalGeneration {
+		// already resumed on a new connection
+		return true
+	}
+	return p.reconnectGrace.setSignalLost(true)
+}
+
+// MaxUploadBitrate returns the max bitrate of video published by the participant, 0 when unlimited
+func (p *ParticipantImpl) MaxUploadBitrate() uint64 {
+	return atomic.LoadUint64(&p.maxUploadBitrate)
+}
+
+// SetMaxUploadBitrate changes the max bitrate of video published by the participant, 0 to remove the limit.
+// The publisher is asked to stay below it with REMB, audio isn't limited
+func (p *ParticipantImpl) SetMaxUploadBitrate(bitrate uint64) {
+	p.log.Debugw("updating max upload bitrate", "participant", p.Identity(), "bitrate", bitrate)
+	atomic.StoreUint64(&p.maxUploadBitrate, bitrate)
+	if bitrate > 0 && !p.isClosed.Get() {
+		p.params.ReportPool.Submit(func() {
+			p.sendUploadCap()
+		})
+	}
+}
+
+// MaxDownloadBitrate returns the max bitrate of media sent to the participant, 0 when unlimited
+func (p *ParticipantImpl) MaxDownloadBitrate() uint64 {
+	return p.downloadBudget.getMaxBitrate()
+}
+
+// SetMaxDownloadBitrate changes the max bitrate of media sent to the participant, 0 to remove the limit.
+// Video subscriptions are paused or resumed according to the download budget policy
+func (p *ParticipantImpl) SetMaxDownloadBitrate(bitrate uint64) {
+	p.log.Debugw("updating max download bitrate", "participant", p.Identity(), "bitrate", bitrate)
+	p.downloadBudget.setMaxBitrate(bitrate)
+	p.updateDownloadBudget()
+}
+
+// AdmitSubscription returns ErrDownloadBudgetExceeded when a video subscription of bitrate doesn't fit in the
+// download budget, with the reject policy
+func (p *ParticipantImpl) AdmitSubscription(trackID string, bitrate uint64) error {
+	tracks, _ := p.budgetedTracks()
+	if p.downloadBudget.admit(tracks, bitrate) {
+		return nil
+	}
+	logger.Infow("rejecting subscription, it exceeds the max download bitrate",
+		"participant", p.Identity(),
+		"track", trackID,
+		"bitrate", bitrate)
+	if err := p.SendDataPacket(newSignalPacket(downloadBudgetSignal{
+		Type:            downloadBudgetPacketType,
+		Policy:          config.DownloadBudgetReject,
+		MaxBitrate:      p.MaxDownloadBitrate(),
+		RequiredBitrate: requiredBitrate(tracks, nil) + bitrate,
+		RejectedTrack:   trackID,
+	})); err != nil {
+		p.log.Debugw("could not send download budget packet", "error", err, "participant", p.Identity())
+	}
+	return ErrDownloadBudgetExceeded
+}
+
+// budgetedTracks returns subscriptions competing for the download budget
+func (p *ParticipantImpl) budgetedTracks() ([]budgetedTrack, map[string]*SubscribedTrack) {
+	var tracks []budgetedTrack
+	subTracks := make(map[string]*SubscribedTrack)
+	for _, track := range p.GetSubscribedTracks() {
+		st, ok := track.(*SubscribedTrack)
+		if !ok {
+			continue
+		}
+		if bt, ok := st.budgeted(); ok {
+			tracks = append(tracks, bt)
+			subTracks[bt.id] = st
+		}
+	}
+	return tracks, subTracks
+}
+
+// updateDownloadBudget pauses video subscriptions that don't fit in the download budget, and resumes those that
+// fit again. The participant is told when the policy applied changes
+func (p *ParticipantImpl) updateDownloadBudget() {
+	if p.downloadBudget.idle() {
+		return
+	}
+	tracks, subTracks := p.budgetedTracks()
+	var signal downloadBudgetSignal
+	var changed bool
+	if p.downloadBudget.allocator != nil {
+		var layers map[string]int32
+		layers, signal, changed = p.downloadBudget.allocateLayers(tracks)
+		for id, st := range subTracks {
+			layer, ok := layers[id]
+			if !ok {
+				layer = maxSpatialLayer
+			}
+			st.setBudgetPaused(layer < 0)
+			if layer >= 0 {
+				st.setLayerCap(layer)
+			}
+		}
+	} else {
+		var paused map[string]bool
+		paused, signal, changed = p.downloadBudget.allocate(tracks)
+		for id, st := range subTracks {
+			st.setBudgetPaused(paused[id])
+		}
+	}
+	if !changed {
+		return
+	}
+	logger.Infow("applying download budget policy",
+		"participant", p.Identity(),
+		"policy", signal.Policy,
+		"maxBitrate", signal.MaxBitrate,
+		"requiredBitrate", signal.RequiredBitrate,
+		"pausedTracks", signal.PausedTracks)
+	signal.Type = downloadBudgetPacketType
+	if err := p.SendDataPacket(newSignalPacket(signal)); err != nil {
+		p.log.Debugw("could not send download budget packet", "error", err, "participant", p.Identity())
+	}
+}
+
+// BandwidthEstimate returns the estimated bandwidth of the participant's downlink in bps, 0 until it's known or
+// when bandwidth adaptation is disabled
+func (p *ParticipantImpl) BandwidthEstimate() uint64 {
+	if p.bandwidthAdaptation == nil {
+		return 0
+	}
+	return p.bandwidthAdaptation.getEstimate()
+}
+
+// adaptToBandwidth limits the layers of video subscriptions to the estimated bandwidth of the participant
+func (p *ParticipantImpl) adaptToBandwidth() bool {
+	if p.State() == livekit.ParticipantInfo_DISCONNECTED {
+		return false
+	}
+	tracks, subTracks := p.budgetedTracks()
+	layers := p.bandwidthAdaptation.update(tracks, time.Now())
+	for id, st := range subTracks {
+		layer, ok := layers[id]
+		if !ok {
+			layer = maxSpatialLayer
+		}
+		st.setBandwidthLayerCap(layer)
+	}
+	return true
+}
+
+// NackWindow returns the number of packets sent to the participant that could be retransmitted when it asks
+func (p *ParticipantImpl) NackWindow() int {
+	return int(atomic.LoadInt32(&p.nackWindow))
+}
+
+// SetNackWindow changes the retransmission window of the participant's subscriptions, 0 for the full packet
+// buffer. It's bounded by the receiver config. Streams already subscribed keep their window, so retransmissions
+// they were asked for are still served, and the new one applies to those subscribed from now on
+func (p *ParticipantImpl) SetNackWindow(packets int) {
+	window := p.params.Config.Receiver.nackWindow(packets)
+	p.log.Debugw("updating nack window", "participant", p.Identity(), "packets", window)
+	atomic.StoreInt32(&p.nackWindow, int32(window))
+}
+
+// MapSubscriberSSRC returns the stable SSRC derived from key when enabled, ssrc otherwise
+func (p *ParticipantImpl) MapSubscriberSSRC(ssrc uint32, key string) uint32 {
+	if p.ssrcRemapper == nil {
+		return ssrc
+	}
+	return p.ssrcRemapper.register(ssrc, key)
+}
+
+// MapSubscriberCaptureTime forwards the capture time of packets sent on ssrc, carried in the abs-capture-time
+// extension with sourceID, when jitter buffer hints are enabled
+func (p *ParticipantImpl) MapSubscriberCaptureTime(ssrc uint32, sourceID uint8) {
+	if p.jitterBuffer == nil {
+		return
+	}
+	p.jitterBuffer.mapCaptureTime(ssrc, sourceID)
+}
+
+// SupportsCodec returns true when the participant could decode the codec of the mime type
+func (p *ParticipantImpl) SupportsCodec(mimeType string) bool {
+	if len(p.params.SupportedCodecs) == 0 {
+		return true
+	}
+	for _, codec := range p.params.SupportedCodecs {
+		if strings.EqualFold(codec, mimeType) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *ParticipantImpl) SubscribesToMixedAudio() bool {
+	return p.params.MixedAudio
+}
+
+func (p *ParticipantImpl) DeviceClass() string {
+	return p.deviceClass.Load().(string)
+}
+
+// SetDeviceClass changes the class of device the participant is on. Video subscriptions it hasn't asked a quality
+// for are moved to the default quality of the new class
+func (p *ParticipantImpl) SetDeviceClass(class string) {
+	if p.DeviceClass() == class {
+		return
+	}
+	p.deviceClass.Store(class)
+	quality, ok := p.DefaultVideoQuality()
+	p.log.Debugw("updating device class", "participant", p.Identity(), "class", class, "quality", quality)
+	if !ok {
+		return
+	}
+	for _, st := range p.GetSubscribedTracks() {
+		if st, ok := st.(*SubscribedTrack); ok {
+			st.setDefaultQuality(quality)
+		}
+	}
+}
+
+// DefaultVideoQuality returns the quality video subscriptions of the participant start at, false when its device
+// class doesn't have one
+func (p *ParticipantImpl) DefaultVideoQuality() (livekit.VideoQuality, bool) {
+	name, ok := p.params.DeviceClasses[p.DeviceClass()]
+	if !ok {
+		return livekit.VideoQuality_HIGH, false
+	}
+	return videoQualityFromName(name)
+}
+
+func (p *ParticipantImpl) PinnedVideoQuality() (livekit.VideoQuality, bool) {
+	if p.params.PinnedVideoQuality == "" {
+		return livekit.VideoQuality_HIGH, false
+	}
+	return videoQualityFromName(p.params.PinnedVideoQuality)
+}
+
+func (p *ParticipantImpl) SignalGeneration() uint32 {
+	p.signalLock.RLock()
+	defer p.signalLock.RUnlock()
+	return p.signalGeneration
 }
 
 func (p *ParticipantImpl) SubscriberMediaEngine() *webrtc.MediaEngine {
@@ -227,14 +821,146 @@ func (p *ParticipantImpl) OnStateChange(callback func(p types.Participant, oldSt
 	p.onStateChange = callback
 }
 
+func (p *ParticipantImpl) OnInterruptionChange(callback func(types.Participant)) {
+	p.onInterruptionChange = callback
+}
+
 func (p *ParticipantImpl) OnTrackUpdated(callback func(types.Participant, types.PublishedTrack)) {
 	p.onTrackUpdated = callback
 }
 
+// OnFirstMediaReceived is called once for each published track, when a receive buffer of it got its first RTP packet
+func (p *ParticipantImpl) OnFirstMediaReceived(callback func(types.Participant, types.PublishedTrack)) {
+	p.onFirstMediaReceived = callback
+}
+
+// OnTrackSubscribed is called once a subscribed track is negotiated with the client, and again when its codec
+// changes after renegotiation. The negotiated codec is available with subTrack.Codec()
+func (p *ParticipantImpl) OnTrackSubscribed(callback func(p types.Participant, pubID string, subTrack types.SubscribedTrack)) {
+	p.onTrackSubscribed = callback
+}
+
+// OnTrackUnsubscribed is called once a subscribed track is removed, including when the participant rejected it in
+// its answer
+func (p *ParticipantImpl) OnTrackUnsubscribed(callback func(p types.Participant, pubID string, subTrack types.SubscribedTrack)) {
+	p.onTrackUnsubscribed = callback
+}
+
+// OnPublisherCongested is called when the participant becomes unable to send the target bitrate of the video it
+// publishes, and again once it recovers. The participant is also told with a data packet
+func (p *ParticipantImpl) OnPublisherCongested(callback func(p types.Participant, congested bool)) {
+	p.onPublisherCongested = callback
+}
+
+// OnSpeaking is called when the participant starts speaking on any of its audio tracks, and once it stopped on all
+func (p *ParticipantImpl) OnSpeaking(callback func(p types.Participant, speaking bool)) {
+	p.onSpeaking = callback
+}
+
+func (p *ParticipantImpl) OnConnectionQualityChange(callback func(p types.Participant, quality types.ConnectionQuality)) {
+	p.onConnectionQualityChange = callback
+}
+
+// ConnectionQuality rates the network of the participant, excellent until it's been rated or when it isn't
+func (p *ParticipantImpl) ConnectionQuality() types.ConnectionQuality {
+	if p.connectionQuality == nil {
+		return types.ConnectionQualityExcellent
+	}
+	return p.connectionQuality.get()
+}
+
+func (p *ParticipantImpl) updateConnectionQuality() bool {
+	if p.State() == livekit.ParticipantInfo_DISCONNECTED {
+		return false
+	}
+	stats, ok := p.connectionStats()
+	if !ok {
+		// no streams to rate
+		return true
+	}
+	quality, changed := p.connectionQuality.add(stats)
+	if changed {
+		p.log.Debugw("connection quality changed",
+			"participant", p.Identity(),
+			"quality", quality,
+			"fractionLost", stats.fractionLost,
+			"jitter", stats.jitter,
+			"rtt", stats.rtt)
+		if p.onConnectionQualityChange != nil {
+			p.onConnectionQualityChange(p, quality)
+		}
+	}
+	return true
+}
+
+// connectionStats averages loss and jitter measured by receive buffers of published tracks, and reported by the
+// participant on subscribed tracks, with the round trip time of those reports
+func (p *ParticipantImpl) connectionStats() (connectionStats, bool) {
+	var agg connectionStatsAggregate
+	for _, track := range p.GetPublishedTracks() {
+		clockRate := uint32(48000)
+		if track.Kind() == livekit.TrackType_VIDEO {
+			clockRate = 90000
+		}
+		for _, stats := range track.GetBufferStats() {
+			agg.add(float64(stats.LostRate), jitterDuration(stats.Jitter, clockRate), 0)
+		}
+	}
+	for _, track := range p.GetSubscribedTracks() {
+		st, ok := track.(*SubscribedTrack)
+		if !ok {
+			continue
+		}
+		report, ok := st.receptionReport(connectionQualityReportAge)
+		if !ok {
+			continue
+		}
+		agg.add(float64(report.fractionLost)/256, jitterDuration(float64(report.jitter), st.Codec().ClockRate), report.rtt)
+	}
+	return agg.stats()
+}
+
+func (p *ParticipantImpl) ForwardedLayers() map[string]int32 {
+	layers := make(map[string]int32)
+	for _, track := range p.GetSubscribedTracks() {
+		if track.DownTrack().Kind() == webrtc.RTPCodecTypeVideo {
+			layers[track.ID()] = track.ForwardedLayer()
+		}
+	}
+	return layers
+}
+
+// updateForwardedLayers sends the layers forwarded to the participant when they've changed since they were last sent
+func (p *ParticipantImpl) updateForwardedLayers() bool {
+	if p.State() == livekit.ParticipantInfo_DISCONNECTED || !p.ProtocolVersion().HandlesDataPackets() {
+		return false
+	}
+	layers := p.ForwardedLayers()
+	if sameForwardedLayers(layers, p.sentForwardedLayers) {
+		return true
+	}
+	if err := p.SendDataPacket(newSignalPacket(forwardedLayersSignal{Type: forwardedLayersPacketType, Layers: layers})); err != nil {
+		p.log.Debugw("could not send forwarded layers", "error", err, "participant", p.Identity())
+		return true
+	}
+	p.sentForwardedLayers = layers
+	return true
+}
+
+func (p *ParticipantImpl) IsSpeaking() bool {
+	p.speakingLock.Lock()
+	defer p.speakingLock.Unlock()
+	return len(p.speakingTracks) > 0
+}
+
 func (p *ParticipantImpl) OnMetadataUpdate(callback func(types.Participant)) {
 	p.onMetadataUpdate = callback
 }
 
+func (p *ParticipantImpl) OnNameUpdate(callback func(types.Participant)) {
+	p.onNameUpdate = callback
+}
+
 func (p *ParticipantImpl) OnDataPacket(callback func(types.Participant, *livekit.DataPacket)) {
 	p.onDataPacket = callback
 }
@@ -244,12 +970,36 @@ func (p *ParticipantImpl) OnClose(callback func(types.Participant)) {
 }
 
 // HandleOffer an offer from remote participant, used when clients make the initial connection
-func (p *ParticipantImpl) HandleOffer(sdp webrtc.SessionDescription) (answer webrtc.SessionDescription, err error) {
-	logger.Debugw("answering pub offer", "state", p.State().String(),
+func (p *ParticipantImpl) HandleOffer(sdp webrtc.SessionDescription, generation uint32) (answer webrtc.SessionDescription, err error) {
+	if !p.lockSignal(generation, "offer") {
+		err = p.staleSignalError()
+		return
+	}
+	defer p.signalLock.RUnlock()
+	defer func() {
+		if err != nil {
+			p.onPublishFailure(err.Error())
+		}
+	}()
+
+	p.log.Debugw("answering pub offer", "state", p.State().String(),
 		"participant", p.Identity(),
 		//"sdp", sdp.SDP,
 	)
 
+	// client has restarted ICE, expect a new round of candidates
+	current := p.publisher.pc.RemoteDescription()
+	iceRestart := current != nil && ICEUfrag(current.SDP) != ICEUfrag(sdp.SDP)
+	if iceRestart {
+		p.pubCandidates.reset()
+		p.setReconnecting(true)
+	}
+	if p.iceGathering != nil {
+		// gathering starts once the answer is set
+		p.iceGathering.start(iceRestart)
+		p.iceGathering.addRemoteFromSDP(sdp.SDP)
+	}
+
 	if err = p.publisher.SetRemoteDescription(sdp); err != nil {
 		return
 	}
@@ -265,28 +1015,60 @@ func (p *ParticipantImpl) HandleOffer(sdp webrtc.SessionDescription) (answer web
 		return
 	}
 
-	logger.Debugw("sending answer to client",
+	p.log.Debugw("sending answer to client",
 		"participant", p.Identity(),
 		//"sdp", sdp.SDP,
 	)
-	err = p.writeMessage(&livekit.SignalResponse{
-		Message: &livekit.SignalResponse_Answer{
-			Answer: ToProtoSessionDescription(answer),
-		},
-	})
-	if err != nil {
+	if err = p.sendAnswer(answer); err != nil {
 		return
 	}
 
+	if iceRestart {
+		// the network changed, the subscriber connection needs new candidates as well
+		if _, err := p.ICERestart(); err != nil {
+			logger.Warnw("could not restart subscriber ICE", err, "participant", p.Identity())
+		}
+	}
+
 	if p.State() == livekit.ParticipantInfo_JOINING {
 		p.updateState(livekit.ParticipantInfo_JOINED)
 	}
+
+	if p.publishFailures != nil {
+		for i := rejectedMediaSections(sdp.SDP, answer.SDP); i > 0; i-- {
+			p.onPublishFailure("track rejected, none of its codecs are enabled")
+		}
+	}
 	return
 }
 
+func (p *ParticipantImpl) sendAnswer(answer webrtc.SessionDescription) error {
+	return p.writeMessage(&livekit.SignalResponse{
+		Message: &livekit.SignalResponse_Answer{
+			Answer: ToProtoSessionDescription(answer),
+		},
+	})
+}
+
 // AddTrack is called when client intends to publish track.
 // records track details and lets client know it's ok to proceed
 func (p *ParticipantImpl) AddTrack(req *livekit.AddTrackRequest) {
+	// media of the track would be dropped
+	if !p.CanPublish() {
+		logger.Warnw("no permission to publish track", nil,
+			"participant", p.Identity(),
+			"track", req.Cid)
+		p.sendPermissionDenied(permissionPublishTrack, req.Cid)
+		return
+	}
+
+	if req.Type == livekit.TrackType_VIDEO {
+		if mt := p.publishedTrackForCid(req.Cid); mt != nil {
+			p.updateTrackLayers(mt, req.Width, req.Height)
+			return
+		}
+	}
+
 	p.lock.Lock()
 	defer p.lock.Unlock()
 
@@ -303,6 +1085,19 @@ func (p *ParticipantImpl) AddTrack(req *livekit.AddTrackRequest) {
 		Height: req.Height,
 	}
 	p.pendingTracks[req.Cid] = ti
+	// pion surfaces remote tracks once it read their first packet, those never sending any stay pending
+	time.AfterFunc(firstMediaTimeout, func() {
+		p.lock.RLock()
+		pending := p.pendingTracks[req.Cid] == ti
+		p.lock.RUnlock()
+		if pending && p.State() != livekit.ParticipantInfo_DISCONNECTED {
+			logger.Warnw("no media received for pending track", nil,
+				"participant", p.Identity(),
+				"track", ti.Sid,
+				"cid", req.Cid)
+			p.sendNoMedia(ti.Sid)
+		}
+	})
 
 	_ = p.writeMessage(&livekit.SignalResponse{
 		Message: &livekit.SignalResponse_TrackPublished{
@@ -324,29 +1119,78 @@ func (p *ParticipantImpl) GetPublishedTracks() []types.PublishedTrack {
 	return tracks
 }
 
+func (p *ParticipantImpl) GetTrackStats() map[string][]types.BufferStats {
+	stats := make(map[string][]types.BufferStats)
+	for _, t := range p.GetPublishedTracks() {
+		stats[t.ID()] = t.GetBufferStats()
+	}
+	return stats
+}
+
+func (p *ParticipantImpl) GetTransportStats() []types.TransportStats {
+	return []types.TransportStats{p.publisher.Stats(), p.subscriber.Stats()}
+}
+
 // HandleAnswer handles a client answer response, with subscriber PC, server initiates the
 // offer and client answers
-func (p *ParticipantImpl) HandleAnswer(sdp webrtc.SessionDescription) error {
+func (p *ParticipantImpl) HandleAnswer(sdp webrtc.SessionDescription, generation uint32) error {
 	if sdp.Type != webrtc.SDPTypeAnswer {
 		return ErrUnexpectedOffer
 	}
-	logger.Debugw("setting subPC answer",
+	if !p.lockSignal(generation, "answer") {
+		return p.staleSignalError()
+	}
+	defer p.signalLock.RUnlock()
+
+	p.log.Debugw("setting subPC answer",
 		"participant", p.Identity(),
 		//"sdp", sdp.SDP,
 	)
 
+	offer := p.subscriber.pc.PendingLocalDescription()
 	if err := p.subscriber.SetRemoteDescription(sdp); err != nil {
 		return errors.Wrap(err, "could not set remote description")
 	}
+	if offer != nil {
+		p.handleRejectedSubscriptions(offer.SDP, sdp.SDP)
+	}
 
 	return nil
 }
 
 // AddICECandidate adds candidates for remote peer
-func (p *ParticipantImpl) AddICECandidate(candidate webrtc.ICECandidateInit, target livekit.SignalTarget) error {
+func (p *ParticipantImpl) AddICECandidate(candidate webrtc.ICECandidateInit, target livekit.SignalTarget, generation uint32) error {
+	if !p.lockSignal(generation, "trickle") {
+		return p.staleSignalError()
+	}
+	defer p.signalLock.RUnlock()
+
+	limiter := p.subCandidates
+	if target == livekit.SignalTarget_PUBLISHER {
+		limiter = p.pubCandidates
+	}
+	if ok, duplicate := limiter.allow(candidate); !ok {
+		if duplicate {
+			p.log.Debugw("ignoring duplicate ICE candidate",
+				"participant", p.Identity(),
+				"target", target.String())
+		} else if limiter.numDropped() == 1 {
+			// log once per period to avoid misbehaving clients flooding logs
+			logger.Warnw("participant exceeded trickle limit, dropping ICE candidates", nil,
+				"participant", p.Identity(),
+				"target", target.String(),
+				"maxCandidates", p.params.TrickleLimit.MaxCandidates,
+				"period", p.params.TrickleLimit.Period)
+		}
+		return nil
+	}
+
 	var err error
 	if target == livekit.SignalTarget_PUBLISHER {
 		err = p.publisher.AddICECandidate(candidate)
+		if err == nil && p.iceGathering != nil {
+			p.iceGathering.addRemote(candidate.Candidate)
+		}
 	} else {
 		err = p.subscriber.AddICECandidate(candidate)
 	}
@@ -356,7 +1200,41 @@ func (p *ParticipantImpl) AddICECandidate(candidate webrtc.ICECandidateInit, tar
 func (p *ParticipantImpl) Start() {
 	p.once.Do(func() {
 		go p.rtcpSendWorker()
-		go p.downTracksRTCPWorker()
+		senderReportInterval := p.params.Config.SenderReportInterval
+		if senderReportInterval <= 0 {
+			senderReportInterval = defaultSenderReportInterval
+		}
+		p.params.ReportPool.Every(senderReportInterval, p.sendDownTrackReports)
+		p.params.ReportPool.Every(uploadCapInterval, p.sendUploadCap)
+		p.params.ReportPool.Every(transportStatsInterval, p.updateTransportStats)
+		if p.telemetry != nil {
+			p.params.ReportPool.Every(p.params.Telemetry.Interval(), p.sampleTelemetry)
+		}
+		if p.congestion != nil {
+			p.params.ReportPool.Every(congestionCheckInterval, p.checkCongestion)
+		}
+		if p.connectionQuality != nil {
+			p.params.ReportPool.Every(connectionQualityInterval, p.updateConnectionQuality)
+		}
+		if p.params.RecorderKeyframeInterval > 0 {
+			p.params.ReportPool.Every(p.params.RecorderKeyframeInterval, p.requestRecorderKeyframes)
+		}
+		if p.params.ForwardedLayers {
+			p.params.ReportPool.Every(forwardedLayersInterval, p.updateForwardedLayers)
+		}
+		if p.bandwidthAdaptation != nil {
+			p.params.ReportPool.Every(bandwidthAdaptationInterval, p.adaptToBandwidth)
+		}
+		if p.downloadBudget.allocator != nil {
+			// bitrates of layers change as publishers adapt
+			p.params.ReportPool.Every(subscriptionAllocationInterval, func() bool {
+				if p.State() == livekit.ParticipantInfo_DISCONNECTED {
+					return false
+				}
+				p.updateDownloadBudget()
+				return true
+			})
+		}
 	})
 }
 
@@ -365,6 +1243,11 @@ func (p *ParticipantImpl) Close() error {
 		// already closed
 		return nil
 	}
+	close(p.closed)
+	p.reconnectGrace.close()
+	if p.iceGathering != nil {
+		p.iceGathering.stop()
+	}
 
 	// send leave message
 	_ = p.writeMessage(&livekit.SignalResponse{
@@ -375,6 +1258,8 @@ func (p *ParticipantImpl) Close() error {
 
 	// remove all downtracks
 	p.lock.Lock()
+	p.closedSubscriptions = p.subscribedTrackIDsLocked()
+	p.subscriptionsClosed = true
 	for _, t := range p.publishedTracks {
 		// skip updates
 		t.OnClose(nil)
@@ -405,7 +1290,6 @@ func (p *ParticipantImpl) Close() error {
 	}
 	p.publisher.Close()
 	p.subscriber.Close()
-	close(p.rtcpCh)
 	return nil
 }
 
@@ -413,43 +1297,80 @@ func (p *ParticipantImpl) Negotiate() {
 	p.subscriber.Negotiate()
 }
 
-// ICERestart restarts subscriber ICE connections
-func (p *ParticipantImpl) ICERestart() error {
+// ICERestart restarts subscriber ICE connections, and returns the offer sent to the client. It's empty when the
+// subscriber isn't connected yet, or when the offer is sent once ongoing candidate gathering is complete
+func (p *ParticipantImpl) ICERestart() (webrtc.SessionDescription, error) {
 	if p.subscriber.pc.RemoteDescription() == nil {
 		// not connected, skip
-		return nil
+		return webrtc.SessionDescription{}, nil
 	}
-	return p.subscriber.CreateAndSendOffer(&webrtc.OfferOptions{
+	p.subCandidates.reset()
+	if err := p.subscriber.CreateAndSendOffer(&webrtc.OfferOptions{
 		ICERestart: true,
-	})
+	}); err != nil {
+		return webrtc.SessionDescription{}, err
+	}
+	if offer := p.subscriber.pc.PendingLocalDescription(); offer != nil {
+		return *offer, nil
+	}
+	return webrtc.SessionDescription{}, nil
 }
 
 // AddSubscriber subscribes op to all publishedTracks
 func (p *ParticipantImpl) AddSubscriber(op types.Participant) (int, error) {
 	p.lock.RLock()
-	tracks := make([]types.PublishedTrack, 0, len(p.publishedTracks))
-	for _, t := range p.publishedTracks {
-		tracks = append(tracks, t)
+	trackIDs := make([]string, 0, len(p.publishedTracks))
+	for id := range p.publishedTracks {
+		trackIDs = append(trackIDs, id)
 	}
 	p.lock.RUnlock()
 
-	if len(tracks) == 0 {
+	if len(trackIDs) == 0 {
 		return 0, nil
 	}
 
-	logger.Debugw("subscribing new participant to tracks",
+	p.log.Debugw("subscribing new participant to tracks",
 		"srcParticipant", p.Identity(),
 		"newParticipant", op.Identity(),
-		"numTracks", len(tracks))
+		"numTracks", len(trackIDs))
 
 	n := 0
-	for _, track := range tracks {
-		if err := track.AddSubscriber(op); err != nil {
-			return n, err
+	errs := &SubscriptionErrors{}
+	for _, trackID := range trackIDs {
+		if err := p.SubscribeToTrack(op, trackID); err == ErrTrackNotPublished {
+			// unpublished meanwhile
+			continue
+		} else if err != nil {
+			// rejections are specific to the track, e.g. video over the download budget, keep subscribing to others
+			errs.add(trackID, err)
+			continue
 		}
 		n += 1
 	}
-	return n, nil
+	return n, errs.errorOrNil()
+}
+
+// SubscribeToTrack subscribes op to one of publishedTracks, a DownTrack is only created for it
+func (p *ParticipantImpl) SubscribeToTrack(op types.Participant, trackID string) error {
+	p.lock.RLock()
+	track := p.publishedTracks[trackID]
+	p.lock.RUnlock()
+	if track == nil {
+		return ErrTrackNotPublished
+	}
+	return track.AddSubscriber(op)
+}
+
+// UnsubscribeFromTrack removes the subscription of op to one of publishedTracks
+func (p *ParticipantImpl) UnsubscribeFromTrack(op types.Participant, trackID string) error {
+	p.lock.RLock()
+	track := p.publishedTracks[trackID]
+	p.lock.RUnlock()
+	if track == nil {
+		return ErrTrackNotPublished
+	}
+	track.RemoveSubscriber(op.ID())
+	return nil
 }
 
 func (p *ParticipantImpl) RemoveSubscriber(participantId string) {
@@ -463,14 +1384,17 @@ func (p *ParticipantImpl) RemoveSubscriber(participantId string) {
 
 // signal connection methods
 
-func (p *ParticipantImpl) SendJoinResponse(roomInfo *livekit.Room, otherParticipants []types.Participant, iceServers []*livekit.ICEServer) error {
+func (p *ParticipantImpl) SendJoinResponse(roomInfo *livekit.Room, otherParticipants []types.Participant, iceServers []*livekit.ICEServer, recording bool) error {
+	// JoinResponse has no field for it, it follows as a data packet
+	p.SetRecording(recording)
+
 	// send Join response
 	return p.writeMessage(&livekit.SignalResponse{
 		Message: &livekit.SignalResponse_Join{
 			Join: &livekit.JoinResponse{
 				Room:              roomInfo,
 				Participant:       p.ToProto(),
-				OtherParticipants: ToProtoParticipants(otherParticipants),
+				OtherParticipants: trimRosterMetadata(ToProtoParticipants(otherParticipants), p.params.MaxRosterMetadataSize),
 				ServerVersion:     version.Version,
 				IceServers:        iceServers,
 			},
@@ -478,6 +1402,30 @@ func (p *ParticipantImpl) SendJoinResponse(roomInfo *livekit.Room, otherParticip
 	})
 }
 
+// SetRecording tells the participant whether the room is being recorded, with a recording_status data packet.
+// It's sent once the reliable data channel is open when it isn't yet, participants aren't told the room isn't
+// recorded until it has been
+func (p *ParticipantImpl) SetRecording(recording bool) {
+	p.recordingLock.Lock()
+	p.recording = recording
+	p.recordingLock.Unlock()
+	p.sendRecordingStatus()
+}
+
+func (p *ParticipantImpl) sendRecordingStatus() {
+	p.recordingLock.Lock()
+	defer p.recordingLock.Unlock()
+	if p.recording == p.recordingSent {
+		return
+	}
+	if err := p.SendDataPacket(newSignalPacket(recordingSignal{Type: recordingStatusPacketType, Recording: p.recording})); err != nil {
+		// retried once the data channel is open
+		p.log.Debugw("could not send recording status", "error", err, "participant", p.Identity())
+		return
+	}
+	p.recordingSent = p.recording
+}
+
 func (p *ParticipantImpl) SendParticipantUpdate(participants []*livekit.ParticipantInfo) error {
 	participantsToUpdate := participants
 	if p.State() == livekit.ParticipantInfo_JOINING {
@@ -491,7 +1439,7 @@ func (p *ParticipantImpl) SendParticipantUpdate(participants []*livekit.Particip
 	return p.writeMessage(&livekit.SignalResponse{
 		Message: &livekit.SignalResponse_Update{
 			Update: &livekit.ParticipantUpdate{
-				Participants: participantsToUpdate,
+				Participants: trimRosterMetadata(participantsToUpdate, p.params.MaxRosterMetadataSize),
 			},
 		},
 	})
@@ -533,19 +1481,154 @@ func (p *ParticipantImpl) SendDataPacket(dp *livekit.DataPacket) error {
 	}
 }
 
+// SendData sends a message originated by the server to the participant, as a user packet without a sender.
+// It goes over the data channel the participant opened, or one opened by the server on the subscriber
+// connection when it hasn't. Reliable messages are ordered and retransmitted, lossy ones are neither.
+// Encoded packets are limited to SCTP.MaxMessageSize. Larger reliable payloads are split into data_chunk packets
+// sent in order, see dataChunkSignal, while larger lossy ones are rejected as some of their parts could be lost
+func (p *ParticipantImpl) SendData(payload []byte, reliable bool) error {
+	if p.State() != livekit.ParticipantInfo_ACTIVE {
+		return ErrDataChannelUnavailable
+	}
+
+	kind := livekit.DataPacket_LOSSY
+	if reliable {
+		kind = livekit.DataPacket_RELIABLE
+	}
+	packets := []*livekit.DataPacket{newUserDataPacket(kind, payload)}
+	if maxSize := int(p.params.SCTP.MaxMessageSize); maxSize > 0 && proto.Size(packets[0]) > maxSize {
+		if !reliable {
+			return ErrDataPacketTooLarge
+		}
+		packets = splitDataMessage(atomic.AddUint32(&p.lastDataMessageID, 1), payload, maxSize)
+		if packets == nil {
+			return ErrDataPacketTooLarge
+		}
+	}
+
+	send, err := p.serverDataSender(reliable)
+	if err != nil {
+		return err
+	}
+	for _, dp := range packets {
+		data, err := proto.Marshal(dp)
+		if err != nil {
+			return err
+		}
+		if err := send(data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// serverDataSender returns how to send server messages of a kind, opening a channel for them if needed
+func (p *ParticipantImpl) serverDataSender(reliable bool) (func([]byte) error, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	own, server := p.lossyDC, &p.serverLossyDC
+	if reliable {
+		own, server = p.reliableDC, &p.serverReliableDC
+	}
+	if own != nil {
+		return own.Send, nil
+	}
+	if *server == nil {
+		label := serverLossyDataChannel
+		ordered := reliable
+		init := &webrtc.DataChannelInit{Ordered: &ordered}
+		if reliable {
+			label = serverReliableDataChannel
+		} else {
+			maxRetransmits := uint16(0)
+			init.MaxRetransmits = &maxRetransmits
+		}
+		dc, err := p.subscriber.pc.CreateDataChannel(label, init)
+		if err != nil {
+			return nil, err
+		}
+		*server = newServerDataChannel(dc, p.params.SCTP)
+		p.subscriber.Negotiate()
+	}
+	return (*server).Send, nil
+}
+
+// SetTrackMuted mutes or unmutes a published track as requested by the participant. Tracks muted by the server stay
+// muted until the server unmutes them
 func (p *ParticipantImpl) SetTrackMuted(trackId string, muted bool) {
 	p.lock.RLock()
 	track := p.publishedTracks[trackId]
+	serverMuted := p.serverMutedTracks[trackId]
 	p.lock.RUnlock()
 	if track == nil {
 		logger.Warnw("could not locate track", nil, "track", trackId)
 		return
 	}
+	if serverMuted && !muted {
+		logger.Infow("ignoring unmute of track muted by the server",
+			"participant", p.Identity(),
+			"track", trackId)
+		// the client UI could have unmuted it already
+		p.sendServerMuted(trackId, true)
+		return
+	}
+	p.setTrackMuted(track, muted)
+}
+
+// MutePublishedTrack mutes or unmutes a published track on behalf of the server, RTP of a muted track is no longer
+// forwarded to subscribers and the participant can't unmute it
+func (p *ParticipantImpl) MutePublishedTrack(trackID string, muted bool) error {
+	p.lock.Lock()
+	track := p.publishedTracks[trackID]
+	if track == nil {
+		p.lock.Unlock()
+		return ErrTrackNotPublished
+	}
+	if muted {
+		p.serverMutedTracks[trackID] = true
+	} else {
+		delete(p.serverMutedTracks, trackID)
+	}
+	p.lock.Unlock()
+
+	logger.Infow("server muted track",
+		"participant", p.Identity(),
+		"track", trackID,
+		"muted", muted)
+	p.setTrackMuted(track, muted)
+	p.sendServerMuted(trackID, muted)
+	return nil
+}
+
+// sendNoMedia tells the publisher no media of one of its tracks has been received
+func (p *ParticipantImpl) sendNoMedia(trackID string) {
+	if err := p.SendDataPacket(newSignalPacket(firstMediaSignal{
+		Type:           noMediaPacketType,
+		ParticipantSid: p.ID(),
+		TrackSid:       trackID,
+	})); err != nil {
+		p.log.Debugw("could not tell participant its track has no media", "error", err,
+			"participant", p.Identity(),
+			"track", trackID)
+	}
+}
+
+func (p *ParticipantImpl) sendServerMuted(trackID string, muted bool) {
+	if err := p.SendDataPacket(newSignalPacket(serverMutedSignal{Type: serverMutedPacketType, TrackSid: trackID, Muted: muted})); err != nil {
+		p.log.Debugw("could not tell participant its track was muted", "error", err,
+			"participant", p.Identity(),
+			"track", trackID)
+	}
+}
+
+func (p *ParticipantImpl) setTrackMuted(track types.PublishedTrack, muted bool) {
+	trackId := track.ID()
 	currentMuted := track.IsMuted()
 	track.SetMuted(muted)
 
 	if currentMuted != track.IsMuted() && p.onTrackUpdated != nil {
-		logger.Debugw("mute status changed",
+		p.log.Debugw("mute status changed",
 			"participant", p.Identity(),
 			"track", trackId,
 			"muted", track.IsMuted())
@@ -553,6 +1636,26 @@ func (p *ParticipantImpl) SetTrackMuted(trackId string, muted bool) {
 	}
 }
 
+func (p *ParticipantImpl) AttachSink(trackID string, sink types.TrackSink) error {
+	p.lock.RLock()
+	track := p.publishedTracks[trackID]
+	p.lock.RUnlock()
+	if track == nil {
+		return ErrTrackNotPublished
+	}
+	return track.AddSink(sink)
+}
+
+func (p *ParticipantImpl) DetachSink(trackID string, sink types.TrackSink) error {
+	p.lock.RLock()
+	track := p.publishedTracks[trackID]
+	p.lock.RUnlock()
+	if track == nil {
+		return ErrTrackNotPublished
+	}
+	return track.RemoveSink(sink)
+}
+
 func (p *ParticipantImpl) GetAudioLevel() (level uint8, active bool) {
 	p.lock.RLock()
 	defer p.lock.RUnlock()
@@ -575,11 +1678,31 @@ func (p *ParticipantImpl) GetAudioLevel() (level uint8, active bool) {
 }
 
 func (p *ParticipantImpl) CanPublish() bool {
-	return p.permission == nil || p.permission.CanPublish
+	permission := p.getPermission()
+	return permission == nil || permission.CanPublish
 }
 
 func (p *ParticipantImpl) CanSubscribe() bool {
-	return p.permission == nil || p.permission.CanSubscribe
+	permission := p.getPermission()
+	return permission == nil || permission.CanSubscribe
+}
+
+// CanPublishData follows CanPublish, tokens don't grant publishing data on its own
+func (p *ParticipantImpl) CanPublishData() bool {
+	return p.CanPublish()
+}
+
+func (p *ParticipantImpl) sendPermissionDenied(permission, trackCid string) {
+	if !p.ProtocolVersion().HandlesDataPackets() {
+		return
+	}
+	if err := p.SendDataPacket(newSignalPacket(permissionDeniedSignal{
+		Type:       permissionDeniedPacketType,
+		Permission: permission,
+		TrackCid:   trackCid,
+	})); err != nil {
+		p.log.Debugw("could not send permission denied packet", "error", err, "participant", p.Identity())
+	}
 }
 
 func (p *ParticipantImpl) SubscriberPC() *webrtc.PeerConnection {
@@ -598,35 +1721,152 @@ func (p *ParticipantImpl) GetSubscribedTracks() []types.SubscribedTrack {
 	return subscribed
 }
 
+// SubscribedTrackIDs returns sids of tracks the participant is receiving, paused ones aside. Once it's closed,
+// those it was receiving when it was
+func (p *ParticipantImpl) SubscribedTrackIDs() []string {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	if p.subscriptionsClosed {
+		return p.closedSubscriptions
+	}
+	return p.subscribedTrackIDsLocked()
+}
+
+func (p *ParticipantImpl) subscribedTrackIDsLocked() []string {
+	var ids []string
+	for _, pTracks := range p.subscribedTracks {
+		for _, t := range pTracks {
+			if !t.IsPaused() {
+				ids = append(ids, t.ID())
+			}
+		}
+	}
+	return ids
+}
+
+// UpdateSubscribedTrackSettings enables or disables a subscribed track and changes its quality. Disabled tracks
+// are kept as warm standbys, bound and ready to resume, up to MaxWarmStandbys. Beyond it the participant is
+// unsubscribed from those disabled the longest
+func (p *ParticipantImpl) UpdateSubscribedTrackSettings(trackID string, enabled bool, quality livekit.VideoQuality) {
+	subscribed := p.GetSubscribedTracks()
+	for _, subTrack := range subscribed {
+		if subTrack.ID() == trackID {
+			subTrack.UpdateSubscriberSettings(enabled, quality)
+		}
+	}
+	if p.warmStandbys == nil {
+		return
+	}
+	if enabled {
+		p.warmStandbys.remove(trackID)
+		return
+	}
+
+	evicted := p.warmStandbys.disable(trackID, time.Now())
+	for _, subTrack := range subscribed {
+		for _, id := range evicted {
+			if subTrack.ID() == id {
+				p.log.Debugw("unsubscribing from warm standby", "participant", p.Identity(), "track", id)
+				go subTrack.DownTrack().Close()
+			}
+		}
+	}
+}
+
+// SetSubscribedQuality switches video of a subscribed track to the simulcast layer of quality, keeping it enabled
+// or disabled. The DownTrack keeps forwarding the current layer, and a keyframe is requested from the publisher
+// for the new one, until it's received so the switch happens on a keyframe
+func (p *ParticipantImpl) SetSubscribedQuality(trackID string, quality livekit.VideoQuality) error {
+	for _, subTrack := range p.GetSubscribedTracks() {
+		if subTrack.ID() == trackID {
+			subTrack.UpdateSubscriberSettings(!subTrack.IsMuted(), quality)
+			return nil
+		}
+	}
+	return ErrTrackNotSubscribed
+}
+
 // AddSubscribedTrack adds a track to the participant's subscribed list
 func (p *ParticipantImpl) AddSubscribedTrack(pubId string, subTrack types.SubscribedTrack) {
-	logger.Debugw("added subscribedTrack", "srcParticipant", pubId,
+	p.log.Debugw("added subscribedTrack", "srcParticipant", pubId,
 		"participant", p.Identity(), "track", subTrack.ID())
 	p.lock.Lock()
 	p.subscribedTracks[pubId] = append(p.subscribedTracks[pubId], subTrack)
 	p.lock.Unlock()
+	p.updateDownloadBudget()
+
+	subTrack.OnCodecChange(func(codec webrtc.RTPCodecParameters) {
+		p.log.Debugw("subscribed track codec negotiated", "srcParticipant", pubId,
+			"participant", p.Identity(), "track", subTrack.ID(),
+			"codec", codec.MimeType, "payloadType", codec.PayloadType)
+		if p.onTrackSubscribed != nil {
+			p.onTrackSubscribed(p, pubId, subTrack)
+		}
+	})
 }
 
 // RemoveSubscribedTrack removes a track to the participant's subscribed list
 func (p *ParticipantImpl) RemoveSubscribedTrack(pubId string, subTrack types.SubscribedTrack) {
-	logger.Debugw("removed subscribedTrack", "srcParticipant", pubId,
+	p.log.Debugw("removed subscribedTrack", "srcParticipant", pubId,
 		"participant", p.Identity(), "track", subTrack.ID())
+	if p.warmStandbys != nil {
+		p.warmStandbys.remove(subTrack.ID())
+	}
 	p.lock.Lock()
-	defer p.lock.Unlock()
 	tracks := make([]types.SubscribedTrack, 0, len(p.subscribedTracks[pubId]))
+	removed := false
 	for _, tr := range p.subscribedTracks[pubId] {
 		if tr != subTrack {
 			tracks = append(tracks, tr)
+		} else {
+			removed = true
 		}
 	}
 	p.subscribedTracks[pubId] = tracks
+	p.lock.Unlock()
+	p.updateDownloadBudget()
+
+	if removed && p.onTrackUnsubscribed != nil {
+		p.onTrackUnsubscribed(p, pubId, subTrack)
+	}
+}
+
+// onSubscriberSpike downgrades the subscribed track of a video stream with a bitrate spike
+func (p *ParticipantImpl) onSubscriberSpike(ssrc uint32, spiking bool) {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	for _, tracks := range p.subscribedTracks {
+		for _, track := range tracks {
+			if st, ok := track.(*SubscribedTrack); ok && st.SSRC() == ssrc {
+				st.setSpiking(spiking)
+				return
+			}
+		}
+	}
+}
+
+func (p *ParticipantImpl) onICECandidate(c *webrtc.ICECandidate, target livekit.SignalTarget) {
+	queue := p.subCandidateQueue
+	if target == livekit.SignalTarget_PUBLISHER {
+		queue = p.pubCandidateQueue
+		if c != nil && p.iceGathering != nil {
+			p.iceGathering.addLocal(c)
+		}
+	}
+	candidates := queue.push(c)
+	if p.State() == livekit.ParticipantInfo_DISCONNECTED {
+		return
+	}
+	for _, candidate := range candidates {
+		p.sendIceCandidate(candidate, target)
+	}
 }
 
 func (p *ParticipantImpl) sendIceCandidate(c *webrtc.ICECandidate, target livekit.SignalTarget) {
 	ci := c.ToJSON()
 
 	// write candidate
-	logger.Debugw("sending ice candidates",
+	p.log.Debugw("sending ice candidates",
 		"participant", p.Identity(),
 		"candidate", c.String())
 	trickle := ToProtoTrickle(ci)
@@ -644,7 +1884,7 @@ func (p *ParticipantImpl) updateState(state livekit.ParticipantInfo_State) {
 		return
 	}
 	p.state.Store(state)
-	logger.Debugw("updating participant state", "state", state.String(), "participant", p.Identity())
+	p.log.Debugw("updating participant state", "state", state.String(), "participant", p.Identity())
 	p.lock.RLock()
 	onStateChange := p.onStateChange
 	p.lock.RUnlock()
@@ -656,6 +1896,22 @@ func (p *ParticipantImpl) updateState(state livekit.ParticipantInfo_State) {
 	}
 }
 
+func (p *ParticipantImpl) setInterrupted(interrupted bool) {
+	if !p.interrupted.TrySet(interrupted) {
+		return
+	}
+	p.log.Debugw("participant connectivity changed", "interrupted", interrupted, "participant", p.Identity())
+	p.lock.RLock()
+	onInterruptionChange := p.onInterruptionChange
+	p.lock.RUnlock()
+	if onInterruptionChange != nil {
+		go func() {
+			defer Recover()
+			onInterruptionChange(p)
+		}()
+	}
+}
+
 func (p *ParticipantImpl) writeMessage(msg *livekit.SignalResponse) error {
 	if p.State() == livekit.ParticipantInfo_DISCONNECTED {
 		return nil
@@ -672,15 +1928,116 @@ func (p *ParticipantImpl) writeMessage(msg *livekit.SignalResponse) error {
 	return nil
 }
 
+// lockSignal read locks signalLock when generation matches the bound signal connection.
+// caller must unlock when it returns true
+func (p *ParticipantImpl) lockSignal(generation uint32, msgType string) bool {
+	p.signalLock.RLock()
+	if generation == p.signalGeneration {
+		return true
+	}
+	current := p.signalGeneration
+	p.signalLock.RUnlock()
+
+	p.log.Debugw("dropping message from superseded signal connection",
+		"participant", p.Identity(),
+		"type", msgType,
+		"generation", generation,
+		"current", current)
+	return false
+}
+
+// onPublishFailure takes the configured action once the participant has failed to publish too many times
+func (p *ParticipantImpl) onPublishFailure(reason string) {
+	if p.publishFailures == nil {
+		return
+	}
+	failures := p.publishFailures.record(time.Now())
+	if failures == 0 {
+		p.log.Debugw("publish attempt failed", "participant", p.Identity(), "reason", reason)
+		return
+	}
+
+	action := p.params.PublishFailures.Action
+	logger.Infow("participant keeps failing to publish",
+		"participant", p.Identity(),
+		"failures", failures,
+		"reason", reason,
+		"action", action)
+	if err := p.SendDataPacket(newSignalPacket(newPublishFailedSignal(failures, reason, action))); err != nil {
+		p.log.Debugw("could not send publish failed packet", "error", err, "participant", p.Identity())
+	}
+	switch action {
+	case config.PublishFailureActionSubscribeOnly:
+		p.downgradeToSubscriber()
+	case config.PublishFailureActionDisconnect:
+		p.closeWithReason(types.DisconnectReasonPublishFailures)
+	}
+}
+
+// downgradeToSubscriber stops the participant from publishing further tracks, those it already publishes are kept
+func (p *ParticipantImpl) downgradeToSubscriber() {
+	permission := &livekit.ParticipantPermission{CanSubscribe: true}
+	if current := p.getPermission(); current != nil {
+		permission = proto.Clone(current).(*livekit.ParticipantPermission)
+	}
+	permission.CanPublish = false
+	p.SetPermission(permission)
+
+	p.lock.Lock()
+	p.pendingTracks = make(map[string]*livekit.TrackInfo)
+	p.lock.Unlock()
+}
+
+// onNegotiationFailed handles offers the participant never answered
+func (p *ParticipantImpl) onNegotiationFailed() {
+	if p.params.Negotiation.OnTimeout == config.NegotiationTimeoutDisconnect {
+		logger.Infow("closing participant", "participant", p.Identity(), "reason", ErrNegotiationTimeout)
+		p.closeWithReason(types.DisconnectReasonNegotiationTimeout)
+		return
+	}
+
+	// subscriptions that were never negotiated are removed, which renegotiates the rest
+	var pending []types.SubscribedTrack
+	p.lock.RLock()
+	for _, tracks := range p.subscribedTracks {
+		for _, st := range tracks {
+			if !st.IsBound() {
+				pending = append(pending, st)
+			}
+		}
+	}
+	p.lock.RUnlock()
+
+	logger.Infow("rolling back pending subscriptions",
+		"participant", p.Identity(),
+		"reason", ErrNegotiationTimeout,
+		"tracks", len(pending))
+	for _, st := range pending {
+		st.DownTrack().Close()
+	}
+}
+
+func (p *ParticipantImpl) onReconnectGraceExpired() {
+	logger.Infow("closing participant", "participant", p.Identity(), "reason", ErrReconnectGraceExpired)
+	p.closeWithReason(types.DisconnectReasonReconnectGraceExpired)
+}
+
+func (p *ParticipantImpl) staleSignalError() error {
+	if p.params.RejectStaleSignal {
+		return ErrSignalSuperseded
+	}
+	return nil
+}
+
 // when the server has an offer for participant
 func (p *ParticipantImpl) onOffer(offer webrtc.SessionDescription) {
 	if p.State() == livekit.ParticipantInfo_DISCONNECTED {
-		logger.Debugw("skipping server offer", "participant", p.Identity())
+		p.log.Debugw("skipping server offer", "participant", p.Identity())
 		// skip when disconnected
 		return
 	}
 
-	logger.Debugw("sending server offer to participant",
+	p.log.Debugw("sending server offer to participant",
 		"participant", p.Identity(),
 		//"sdp", offer.SDP,
 	)
@@ -698,7 +2055,7 @@ func (p *ParticipantImpl) onMediaTrack(track *webrtc.TrackRemote, rtpReceiver *w
 		return
 	}
 
-	logger.Debugw("mediaTrack added",
+	p.log.Debugw("mediaTrack added",
 		"participant", p.Identity(),
 		"remoteTrack", track.ID(),
 		"rid", track.RID())
@@ -706,50 +2063,114 @@ func (p *ParticipantImpl) onMediaTrack(track *webrtc.TrackRemote, rtpReceiver *w
 	if !p.CanPublish() {
 		logger.Warnw("no permission to publish mediaTrack", nil,
 			"participant", p.Identity())
+		// nothing would read from it otherwise
+		if err := rtpReceiver.Stop(); err != nil {
+			p.log.Debugw("could not stop receiver", "error", err, "participant", p.Identity())
+		}
+		p.sendPermissionDenied(permissionPublishTrack, track.ID())
 		return
 	}
 
-	// delete pending track if it's not simulcasting
-	ti := p.getPendingTrack(track.ID(), ToProtoTrackKind(track.Kind()), track.RID() == "")
-	if ti == nil {
-		return
+	var mt *MediaTrack
+	if track.RID() != "" {
+		// the publisher could have started simulcasting a track it published without, its pending info is gone
+		mt = p.getRestructuredTrack(track.ID(), ToProtoTrackKind(track.Kind()))
+	}
+	var ti *livekit.TrackInfo
+	if mt == nil {
+		// delete pending track if it's not simulcasting
+		if ti = p.getPendingTrack(track.ID(), ToProtoTrackKind(track.Kind()), track.RID() == ""); ti == nil {
+			return
+		}
 	}
 
 	// use existing mediatrack to handle simulcast
 	p.lock.Lock()
-	ptrack := p.publishedTracks[ti.Sid]
+	if mt == nil {
+		mt, _ = p.publishedTracks[ti.Sid].(*MediaTrack)
+	}
 
-	var mt *MediaTrack
 	var newTrack bool
-	if trk, ok := ptrack.(*MediaTrack); ok {
-		mt = trk
-	} else {
+	if mt == nil {
 		mt = NewMediaTrack(track, MediaTrackParams{
 			TrackID:        ti.Sid,
 			ParticipantID:  p.id,
+			Identity:       p.Identity(),
 			RTCPChan:       p.rtcpCh,
 			BufferFactory:  p.params.Config.BufferFactory,
 			ReceiverConfig: p.params.Config.Receiver,
+			SDESBatchSize:  p.params.Config.SDESBatchSize,
+			ReportPool:     p.params.ReportPool,
+			BufferBudget:   p.bufferBudget,
 			AudioConfig:    p.params.AudioConfig,
+			Simulcast:      p.params.Simulcast,
+			RTCPFeedback:   p.params.RTCPFeedback,
 			Stats:          p.params.Stats,
 			Width:          ti.Width,
 			Height:         ti.Height,
+			Source:         types.TrackSourceFor(ti.Type, ti.Name),
+
+			SubscriptionLimiter: p.params.SubscriptionLimiter,
+			SubscriberReports:   p.params.Config.SubscriberReports,
+			TrackKindMismatch:   p.params.Config.TrackKindMismatch,
+			Speaking:            p.params.Config.Speaking,
+			SilentMedia:         p.params.Config.SilentMedia,
+
+			BindingReportInterval: p.params.Config.BindingReportInterval,
+			BindingReportAttempts: p.params.Config.BindingReportAttempts,
+			Transcoding:           p.params.Transcoding,
+			Transcoder:            p.params.Transcoder,
+			SSRCHandoff:           p.ssrcHandoff,
+			ReorderBuffers:        p.reorderBuffers,
+			CaptureTimeID:         receiverExtensionID(rtpReceiver, absCaptureTimeURI),
 		})
 		mt.name = ti.Name
+		trackID := ti.Sid
+		mt.OnSpeaking(func(speaking bool) {
+			p.updateSpeaking(trackID, speaking)
+		})
+		mt.OnSilent(func(silent bool) {
+			if err := p.SendDataPacket(newSignalPacket(silentMediaSignal{Type: silentMediaPacketType, TrackSid: trackID, Silent: silent})); err != nil {
+				p.log.Debugw("could not tell participant its track is silent", "error", err,
+					"participant", p.Identity(),
+					"track", trackID)
+			}
+		})
+		mt.OnFirstMedia(func() {
+			if p.onFirstMediaReceived != nil {
+				p.onFirstMediaReceived(p, mt)
+			}
+		})
+		mt.OnNoMedia(func() {
+			p.sendNoMedia(trackID)
+		})
 		newTrack = true
 	}
 
 	ssrc := uint32(track.SSRC())
 	p.pliThrottle.addTrack(ssrc, track.RID())
-	if p.twcc == nil {
+	codec := track.Codec()
+	if track.Kind() == webrtc.RTPCodecTypeVideo {
+		p.keyframeLatency.addStream(ssrc, mt.ID(), codec)
+	}
+	if track.Kind() == webrtc.RTPCodecTypeVideo && !hasRTCPFeedback(codec.RTCPFeedback, webrtc.TypeRTCPFBNACK, "pli") {
+		p.firSeqs[ssrc] = 0
+	}
+	if p.twcc == nil && hasRTCPFeedback(codec.RTCPFeedback, webrtc.TypeRTCPFBTransportCC, "") {
 		p.twcc = twcc.NewTransportWideCCResponder(ssrc)
 		p.twcc.OnFeedback(func(pkt rtcp.RawPacket) {
 			_ = p.publisher.pc.WriteRTCP([]rtcp.Packet{&pkt})
 		})
 	}
+	wasSimulcast := mt.IsSimulcast()
 	mt.AddReceiver(rtpReceiver, track, p.twcc)
 	p.lock.Unlock()
 
+	// others are told the layers of the track changed
+	if !newTrack && mt.IsSimulcast() != wasSimulcast && p.onTrackUpdated != nil {
+		p.onTrackUpdated(p, mt)
+	}
+
 	if newTrack {
 		p.handleTrackPublished(mt)
 	}
@@ -761,12 +2182,13 @@ func (p *ParticipantImpl) onDataChannel(dc *webrtc.DataChannel) {
 	}
 	switch dc.Label() {
 	case reliableDataChannel:
-		p.reliableDC = dc
+		p.reliableDC = newDataChannel(dc, p.params.SCTP)
+		dc.OnOpen(p.sendRecordingStatus)
 		dc.OnMessage(func(msg webrtc.DataChannelMessage) {
 			p.handleDataMessage(livekit.DataPacket_RELIABLE, msg.Data)
 		})
 	case lossyDataChannel:
-		p.lossyDC = dc
+		p.lossyDC = newDataChannel(dc, p.params.SCTP)
 		dc.OnMessage(func(msg webrtc.DataChannelMessage) {
 			p.handleDataMessage(livekit.DataPacket_LOSSY, msg.Data)
 		})
@@ -775,6 +2197,23 @@ func (p *ParticipantImpl) onDataChannel(dc *webrtc.DataChannel) {
 	}
 }
 
+// getRestructuredTrack returns the published track of simulcast streams the publisher started sending for a track
+// it published without simulcast, whose pending info was deleted. Pending info of simulcast tracks is kept, their
+// streams find it
+func (p *ParticipantImpl) getRestructuredTrack(clientId string, kind livekit.TrackType) *MediaTrack {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	if p.pendingTracks[clientId] != nil {
+		return nil
+	}
+	for _, track := range p.publishedTracks {
+		if mt, ok := track.(*MediaTrack); ok && mt.clientID == clientId && mt.Kind() == kind {
+			return mt
+		}
+	}
+	return nil
+}
+
 func (p *ParticipantImpl) getPendingTrack(clientId string, kind livekit.TrackType, deleteAfter bool) *livekit.TrackInfo {
 	p.lock.Lock()
 	defer p.lock.Unlock()
@@ -802,6 +2241,12 @@ func (p *ParticipantImpl) getPendingTrack(clientId string, kind livekit.TrackTyp
 }
 
 func (p *ParticipantImpl) handleDataMessage(kind livekit.DataPacket_Kind, data []byte) {
+	if max := p.params.SCTP.MaxMessageSize; max > 0 && len(data) > int(max) {
+		logger.Warnw("rejecting data packet over max message size", ErrDataPacketTooLarge,
+			"participant", p.Identity(), "size", len(data), "maxSize", max)
+		return
+	}
+
 	dp := livekit.DataPacket{}
 	if err := proto.Unmarshal(data, &dp); err != nil {
 		logger.Warnw("could not parse data packet", err)
@@ -814,6 +2259,14 @@ func (p *ParticipantImpl) handleDataMessage(kind livekit.DataPacket_Kind, data [
 	// only forward on user payloads
 	switch payload := dp.Value.(type) {
 	case *livekit.DataPacket_User:
+		if !p.CanPublishData() {
+			// told once, clients keep sending until they handle it
+			if p.dataDenied.TrySet(true) {
+				logger.Warnw("no permission to publish data", nil, "participant", p.Identity())
+				p.sendPermissionDenied(permissionPublishData, "")
+			}
+			return
+		}
 		if p.onDataPacket != nil {
 			payload.User.ParticipantSid = p.id
 			p.onDataPacket(p, &dp)
@@ -835,6 +2288,7 @@ func (p *ParticipantImpl) handleTrackPublished(track types.PublishedTrack) {
 		// cleanup
 		p.lock.Lock()
 		delete(p.publishedTracks, track.ID())
+		delete(p.serverMutedTracks, track.ID())
 		p.lock.Unlock()
 		// only send this when client is in a ready state
 		if p.IsReady() && p.onTrackUpdated != nil {
@@ -849,93 +2303,137 @@ func (p *ParticipantImpl) handleTrackPublished(track types.PublishedTrack) {
 }
 
 func (p *ParticipantImpl) handlePublisherICEStateChange(state webrtc.ICEConnectionState) {
-	// logger.Debugw("ICE connection state changed", "state", state.String(),
+	// p.log.Debugw("ICE connection state changed", "state", state.String(),
 	//	"participant", p.identity)
 	if state == webrtc.ICEConnectionStateConnected {
+		if p.iceGathering != nil {
+			p.iceGathering.stop()
+		}
 		p.updateState(livekit.ParticipantInfo_ACTIVE)
+		p.setInterrupted(false)
+		p.setReconnecting(false)
+		p.reconnectGrace.setICELost(false)
+	} else if state == webrtc.ICEConnectionStateDisconnected {
+		// consent checks are failing, ICE could still recover on its own
+		p.setInterrupted(true)
+		p.reconnectGrace.setICELost(true)
 	} else if state == webrtc.ICEConnectionStateFailed {
-		// only close when failed, to allow clients opportunity to reconnect
-		go func() {
-			_ = p.Close()
-		}()
+		// only close when failed, to allow clients opportunity to reconnect.
+		// with a grace period, it's kept until the grace period is over, the client could still restart ICE
+		if !p.reconnectGrace.setICELost(true) {
+			go p.closeWithReason(types.DisconnectReasonICEFailed)
+		}
 	}
 }
 
-// downTracksRTCPWorker sends SenderReports periodically when the participant is subscribed to
-// other publishedTracks in the room.
-func (p *ParticipantImpl) downTracksRTCPWorker() {
-	defer Recover()
-	for {
-		time.Sleep(5 * time.Second)
+// onICEGatheringTimeout asks the client to reconnect, which gathers candidates again, until retries are exhausted
+// and the participant is closed. The signal protocol has no error message, the client is told with a leave request
+func (p *ParticipantImpl) onICEGatheringTimeout() {
+	if p.State() == livekit.ParticipantInfo_DISCONNECTED {
+		return
+	}
+	conf := p.params.ICEGathering
+	retry := conf.OnTimeout == config.ICEGatheringRetry &&
+		int(atomic.AddInt32(&p.iceGatheringRetries, 1)) <= conf.MaxRetries
+	logger.Warnw("no viable ICE candidate gathered in time", ErrICEGatheringTimeout,
+		"participant", p.Identity(),
+		"timeout", conf.Timeout,
+		"retry", retry)
+	if !retry {
+		iceGatheringTimeoutTotal.WithLabelValues(config.ICEGatheringClose).Inc()
+		go p.closeWithReason(types.DisconnectReasonICEFailed)
+		return
+	}
+	iceGatheringTimeoutTotal.WithLabelValues(config.ICEGatheringRetry).Inc()
+	_ = p.writeMessage(&livekit.SignalResponse{
+		Message: &livekit.SignalResponse_Leave{
+			Leave: &livekit.LeaveRequest{
+				CanReconnect: true,
+			},
+		},
+	})
+}
 
-		if p.State() == livekit.ParticipantInfo_DISCONNECTED {
-			return
-		}
-		if p.subscriber.pc.ConnectionState() != webrtc.PeerConnectionStateConnected {
-			continue
-		}
+// sendDownTrackReports sends SenderReports for publishedTracks the participant is subscribed to.
+// It runs periodically on the room's report pool, returns false once the participant is disconnected
+func (p *ParticipantImpl) sampleTelemetry() bool {
+	if p.State() == livekit.ParticipantInfo_DISCONNECTED {
+		return false
+	}
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	p.telemetry.sample(p.Identity(), p.subscribedTracks)
+	return true
+}
 
-		var srs []rtcp.Packet
-		var sd []rtcp.SourceDescriptionChunk
-		p.lock.RLock()
-		for _, tracks := range p.subscribedTracks {
-			for _, subTrack := range tracks {
-				sr := subTrack.DownTrack().CreateSenderReport()
-				chunks := subTrack.DownTrack().CreateSourceDescriptionChunks()
-				if sr == nil || chunks == nil {
-					continue
-				}
-				srs = append(srs, sr)
-				sd = append(sd, chunks...)
-			}
-		}
-		p.lock.RUnlock()
+func (p *ParticipantImpl) sendDownTrackReports() bool {
+	if p.State() == livekit.ParticipantInfo_DISCONNECTED {
+		return false
+	}
+	if p.subscriber.pc.ConnectionState() != webrtc.PeerConnectionStateConnected {
+		return true
+	}
 
-		// now send in batches of sdBatchSize
-		var batch []rtcp.SourceDescriptionChunk
-		var pkts []rtcp.Packet
-		batchSize := 0
-		for len(sd) > 0 || len(srs) > 0 {
-			numSRs := len(srs)
-			if numSRs > 0 {
-				if numSRs > sdBatchSize {
-					numSRs = sdBatchSize
-				}
-				pkts = append(pkts, srs[:numSRs]...)
-				srs = srs[numSRs:]
+	var srs []rtcp.Packet
+	var sd []rtcp.SourceDescriptionChunk
+	p.lock.RLock()
+	for _, tracks := range p.subscribedTracks {
+		for _, subTrack := range tracks {
+			if subTrack.IsPublisherMuted() {
+				// nothing is forwarded, reports would only extrapolate RTP time of the last packet
+				continue
 			}
-
-			size := len(sd)
-			spaceRemain := sdBatchSize - batchSize
-			if spaceRemain > 0 && size > 0 {
-				if size > spaceRemain {
-					size = spaceRemain
-				}
-				batch = sd[:size]
-				sd = sd[size:]
-				pkts = append(pkts, &rtcp.SourceDescription{Chunks: batch})
-				if err := p.subscriber.pc.WriteRTCP(pkts); err != nil {
-					if err == io.EOF || err == io.ErrClosedPipe {
-						return
-					}
-					logger.Errorw("could not send downtrack reports", err,
-						"participant", p.Identity())
-				}
+			sr := subTrack.DownTrack().CreateSenderReport()
+			chunks := subTrack.DownTrack().CreateSourceDescriptionChunks()
+			if sr == nil || chunks == nil {
+				continue
 			}
+			srs = append(srs, sr)
+			sd = append(sd, chunks...)
+		}
+	}
+	p.lock.RUnlock()
 
-			pkts = pkts[:0]
-			batchSize = 0
+	for _, batch := range batchDownTrackReports(srs, sd, p.params.Config.SDESBatchSize) {
+		err := p.subscriber.pc.WriteRTCP(batch)
+		if err == io.EOF || err == io.ErrClosedPipe {
+			return false
+		}
+		if !p.handleRTCPWriteResult(&p.subRTCPFailures, "subscriber", err) {
+			return false
+		}
+	}
+	return true
+}
+
+// requestRecorderKeyframes asks publishers for keyframes of video sent to the participant, so its recording has
+// regular seek points
+func (p *ParticipantImpl) requestRecorderKeyframes() bool {
+	if p.State() == livekit.ParticipantInfo_DISCONNECTED {
+		return false
+	}
+	for _, track := range p.GetSubscribedTracks() {
+		if st, ok := track.(*SubscribedTrack); ok {
+			st.requestKeyframe()
 		}
 	}
+	return true
 }
 
 func (p *ParticipantImpl) rtcpSendWorker() {
 	defer Recover()
 
-	// read from rtcpChan
-	for pkts := range p.rtcpCh {
-		if pkts == nil {
+	for {
+		var pkts []rtcp.Packet
+		// rtcpCh isn't closed, as tracks could still be writing to it while the participant closes
+		select {
+		case <-p.closed:
+			p.drainRTCP()
 			return
+		case pkts = <-p.rtcpCh:
+		}
+		if pkts == nil {
+			continue
 		}
 
 		fwdPkts := make([]rtcp.Packet, 0, len(pkts))
@@ -944,27 +2442,237 @@ func (p *ParticipantImpl) rtcpSendWorker() {
 			case *rtcp.PictureLossIndication:
 				mediaSSRC := pkt.(*rtcp.PictureLossIndication).MediaSSRC
 				if p.pliThrottle.canSend(mediaSSRC) {
-					fwdPkts = append(fwdPkts, pkt)
+					fwdPkts = append(fwdPkts, p.keyframeRequest(pkt.(*rtcp.PictureLossIndication)))
+					p.keyframeLatency.requested(mediaSSRC, time.Now())
 				}
 			case *rtcp.FullIntraRequest:
 				mediaSSRC := pkt.(*rtcp.FullIntraRequest).MediaSSRC
 				if p.pliThrottle.canSend(mediaSSRC) {
 					fwdPkts = append(fwdPkts, pkt)
+					p.keyframeLatency.requested(mediaSSRC, time.Now())
+				}
+			case *rtcp.ReceiverEstimatedMaximumBitrate:
+				// congestion could lower the estimate below the cap, never above it
+				remb := pkt.(*rtcp.ReceiverEstimatedMaximumBitrate)
+				if uploadCap := p.uploadCap(); uploadCap > 0 && remb.Bitrate > uploadCap {
+					remb.Bitrate = uploadCap
+				}
+				if p.congestion != nil {
+					p.congestion.observeREMB(remb)
 				}
+				fwdPkts = append(fwdPkts, pkt)
 			default:
 				fwdPkts = append(fwdPkts, pkt)
 			}
 		}
 
-		if len(fwdPkts) > 0 {
-			if err := p.publisher.pc.WriteRTCP(fwdPkts); err != nil {
-				logger.Errorw("could not write RTCP to participant", err,
-					"participant", p.Identity())
+		// keep draining after failures, so tracks writing to rtcpCh never block
+		if len(fwdPkts) > 0 && !p.isClosed.Get() {
+			err := p.publisher.pc.WriteRTCP(fwdPkts)
+			p.handleRTCPWriteResult(&p.pubRTCPFailures, "publisher", err)
+		}
+	}
+}
+
+// drainRTCP discards RTCP written to rtcpCh once the participant is closed. Receivers of its tracks block on
+// writing to it, so it's drained until they stopped, when nothing was written for rtcpDrainTimeout
+func (p *ParticipantImpl) drainRTCP() {
+	timer := time.NewTimer(rtcpDrainTimeout)
+	defer timer.Stop()
+	for {
+		select {
+		case <-p.rtcpCh:
+			if !timer.Stop() {
+				<-timer.C
+			}
+			timer.Reset(rtcpDrainTimeout)
+		case <-timer.C:
+			return
+		}
+	}
+}
+
+// sendUploadCap sends REMB with the max upload bitrate to the publisher. It's sent regardless of the
+// estimates of receive buffers, which aren't sent when the publisher uses transport-wide congestion control.
+// It runs periodically on the room's report pool, returns false once the participant is disconnected
+func (p *ParticipantImpl) sendUploadCap() bool {
+	if p.State() == livekit.ParticipantInfo_DISCONNECTED {
+		return false
+	}
+	uploadCap := p.uploadCap()
+	if uploadCap == 0 || p.publisher.pc.ConnectionState() != webrtc.PeerConnectionStateConnected {
+		return true
+	}
+
+	var ssrcs []uint32
+	for _, track := range p.GetPublishedTracks() {
+		if track.Kind() != livekit.TrackType_VIDEO {
+			continue
+		}
+		for _, stats := range track.GetBufferStats() {
+			ssrcs = append(ssrcs, stats.SSRC)
+		}
+	}
+	if len(ssrcs) == 0 {
+		return true
+	}
+
+	err := p.publisher.pc.WriteRTCP([]rtcp.Packet{&rtcp.ReceiverEstimatedMaximumBitrate{
+		Bitrate: uploadCap,
+		SSRCs:   ssrcs,
+	}})
+	if err == io.EOF || err == io.ErrClosedPipe {
+		return false
+	}
+	return p.handleRTCPWriteResult(&p.pubRTCPFailures, "publisher", err)
+}
+
+// updateTransportStats runs periodically on the room's report pool, returns false once the participant is disconnected
+func (p *ParticipantImpl) updateTransportStats() bool {
+	if p.State() == livekit.ParticipantInfo_DISCONNECTED {
+		return false
+	}
+	p.publisher.updateStats()
+	p.subscriber.updateStats()
+	return true
+}
+
+func (p *ParticipantImpl) updateSpeaking(trackID string, speaking bool) {
+	p.speakingLock.Lock()
+	wasSpeaking := len(p.speakingTracks) > 0
+	if speaking {
+		if p.speakingTracks == nil {
+			p.speakingTracks = make(map[string]bool)
+		}
+		p.speakingTracks[trackID] = true
+	} else {
+		delete(p.speakingTracks, trackID)
+	}
+	isSpeaking := len(p.speakingTracks) > 0
+	p.speakingLock.Unlock()
+
+	if isSpeaking != wasSpeaking && p.onSpeaking != nil {
+		p.onSpeaking(p, isSpeaking)
+	}
+}
+
+// checkCongestion runs periodically on the room's report pool, returns false once the participant is disconnected
+func (p *ParticipantImpl) checkCongestion() bool {
+	if p.State() == livekit.ParticipantInfo_DISCONNECTED {
+		return false
+	}
+
+	var streams []types.BufferStats
+	var target uint64
+	for _, track := range p.GetPublishedTracks() {
+		mt, ok := track.(*MediaTrack)
+		if !ok || mt.Kind() != livekit.TrackType_VIDEO || mt.IsMuted() {
+			continue
+		}
+		target += mt.targetBitrate()
+		streams = append(streams, mt.GetBufferStats()...)
+	}
+	// staying below the max upload bitrate is what the publisher has been asked to do
+	if maxBitrate := p.MaxUploadBitrate(); maxBitrate > 0 && target > maxBitrate {
+		target = maxBitrate
+	}
+
+	congested, changed := p.congestion.update(streams, target, time.Now())
+	if !changed {
+		return true
+	}
+	if congested {
+		publisherCongestedTotal.Inc()
+	}
+	if err := p.SendDataPacket(newSignalPacket(congestionSignal{Type: congestionPacketType, Congested: congested})); err != nil {
+		p.log.Debugw("could not send congestion state to publisher",
+			"participant", p.Identity(),
+			"error", err)
+	}
+	if p.onPublisherCongested != nil {
+		p.onPublisherCongested(p, congested)
+	}
+	return true
+}
+
+// uploadCap returns the bitrate REMB sent to the publisher is capped at, 0 when it isn't.
+// REMB applies to everything the publisher sends, audio is added on top of the max upload bitrate so it
+// isn't throttled
+func (p *ParticipantImpl) uploadCap() uint64 {
+	maxBitrate := p.MaxUploadBitrate()
+	if maxBitrate == 0 {
+		return 0
+	}
+	for _, track := range p.GetPublishedTracks() {
+		if track.Kind() != livekit.TrackType_AUDIO {
+			continue
+		}
+		for _, stats := range track.GetBufferStats() {
+			maxBitrate += stats.Bitrate
+		}
+	}
+	return maxBitrate
+}
+
+// handleRTCPWriteResult closes the participant once RTCP writes to one of its transports keep failing.
+// Failures are contained to this participant, they never affect others in the room or the workers they share.
+// It returns false once the participant is being closed
+func (p *ParticipantImpl) handleRTCPWriteResult(failures *rtcpWriteFailures, transport string, err error) bool {
+	switch n := failures.record(err); {
+	case n == 0:
+		return true
+	case n < maxRTCPWriteFailures:
+		if n == 1 {
+			logger.Warnw("could not write RTCP to participant", err,
+				"participant", p.Identity(),
+				"transport", transport)
+		}
+		return true
+	case n == maxRTCPWriteFailures:
+		logger.Errorw("closing participant after repeated RTCP write failures", err,
+			"participant", p.Identity(),
+			"transport", transport,
+			"failures", n)
+		// Close waits on transports and callbacks, it shouldn't hold up the caller
+		go func() {
+			defer Recover()
+			p.closeWithReason(types.DisconnectReasonRTCPFailures)
+		}()
+	}
+	return false
+}
+
+// onSubscriberReceptionReport keeps the report on the subscribed track, it's aggregated into reports sent
+// to the publisher of the track
+func (p *ParticipantImpl) onSubscriberReceptionReport(ssrc uint32, report rtcp.ReceptionReport) {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	for _, tracks := range p.subscribedTracks {
+		for _, track := range tracks {
+			if st, ok := track.(*SubscribedTrack); ok && st.SSRC() == ssrc {
+				st.setReceptionReport(report)
+				return
 			}
 		}
 	}
 }
 
+// keyframeRequest converts a PLI into FIR when the publisher has not negotiated PLI for the track
+func (p *ParticipantImpl) keyframeRequest(pli *rtcp.PictureLossIndication) rtcp.Packet {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	seq, ok := p.firSeqs[pli.MediaSSRC]
+	if !ok {
+		return pli
+	}
+	p.firSeqs[pli.MediaSSRC] = seq + 1
+	return &rtcp.FullIntraRequest{
+		SenderSSRC: pli.SenderSSRC,
+		MediaSSRC:  pli.MediaSSRC,
+		FIR:        []rtcp.FIREntry{{SSRC: pli.MediaSSRC, SequenceNumber: seq}},
+	}
+}
+
 func (p *ParticipantImpl) DebugInfo() map[string]interface{} {
 	info := map[string]interface{}{
 		"ID":    p.id,
@@ -993,6 +2701,10 @@ func (p *ParticipantImpl) DebugInfo() map[string]interface{} {
 		for _, track := range tracks {
 			dt := track.DownTrack().DebugInfo()
 			dt["SubMuted"] = track.IsMuted()
+			if codec := track.Codec(); codec.MimeType != "" {
+				dt["Codec"] = codec.MimeType
+				dt["PayloadType"] = codec.PayloadType
+			}
 			trackInfo = append(trackInfo, dt)
 		}
 		subscribedTrackInfo[pubID] = trackInfo
@@ -1010,6 +2722,20 @@ func (p *ParticipantImpl) DebugInfo() map[string]interface{} {
 	info["PublishedTracks"] = publishedTrackInfo
 	info["SubscribedTracks"] = subscribedTrackInfo
 	info["PendingTracks"] = pendingTrackInfo
+	info["TrackStats"] = p.GetTrackStats()
+	transportInfo := make(map[string]interface{})
+	for _, stats := range p.GetTransportStats() {
+		transportInfo[stats.Target.String()] = stats
+	}
+	info["Transports"] = transportInfo
+	if p.bufferBudget != nil {
+		info["BufferBudget"] = p.bufferBudget.DebugInfo()
+	}
+	if p.bandwidthAdaptation != nil {
+		info["BandwidthEstimate"] = p.bandwidthAdaptation.getEstimate()
+		info["BandwidthLayers"] = p.bandwidthAdaptation.getLayers()
+	}
+	info["KeyframeLatency"] = p.keyframeLatency.stats()
 
 	return info
 }
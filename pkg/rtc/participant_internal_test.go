@@ -1,11 +1,19 @@
 package rtc
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/livekit/protocol/utils"
+	"github.com/pion/rtcp"
 	"github.com/pion/webrtc/v3"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
 
 	"github.com/livekit/livekit-server/pkg/config"
 	"github.com/livekit/livekit-server/pkg/routing"
@@ -131,6 +139,33 @@ func TestTrackPublishing(t *testing.T) {
 
 		require.Equal(t, 1, sink.WriteMessageCallCount())
 	})
+
+	t.Run("adding a published video track again updates its layers", func(t *testing.T) {
+		p := newParticipantForTest("test")
+		sink := p.params.Sink.(*routingfakes.FakeMessageSink)
+		mt := &MediaTrack{
+			params:   MediaTrackParams{TrackID: "TR_video"},
+			kind:     livekit.TrackType_VIDEO,
+			clientID: "cid",
+			layers:   newSimulcastLayers(config.SimulcastConfig{}),
+		}
+		p.publishedTracks[mt.ID()] = mt
+		var updated types.PublishedTrack
+		p.OnTrackUpdated(func(p types.Participant, track types.PublishedTrack) {
+			updated = track
+		})
+
+		p.AddTrack(&livekit.AddTrackRequest{Cid: "cid", Type: livekit.TrackType_VIDEO, Width: 640, Height: 360})
+		require.Zero(t, sink.WriteMessageCallCount())
+		require.Equal(t, mt, updated)
+		require.Equal(t, []types.VideoLayerInfo{{Quality: livekit.VideoQuality_HIGH, Width: 640, Height: 360}}, mt.Layers())
+		require.Equal(t, uint32(640), mt.ToProto().Width)
+
+		// without dimensions
+		updated = nil
+		p.AddTrack(&livekit.AddTrackRequest{Cid: "cid", Type: livekit.TrackType_VIDEO})
+		require.Nil(t, updated)
+	})
 }
 
 // after disconnection, things should continue to function and not panic
@@ -152,6 +187,651 @@ func TestDisconnectTiming(t *testing.T) {
 	})
 }
 
+func TestNegotiationFailed(t *testing.T) {
+	t.Run("keeps negotiated subscriptions", func(t *testing.T) {
+		p := newParticipantForTest("test")
+		st := &typesfakes.FakeSubscribedTrack{}
+		st.IsBoundReturns(true)
+		p.subscribedTracks["PA_pub"] = []types.SubscribedTrack{st}
+
+		p.onNegotiationFailed()
+		require.Equal(t, 0, st.DownTrackCallCount())
+		require.NotEqual(t, livekit.ParticipantInfo_DISCONNECTED, p.State())
+	})
+
+	t.Run("closes participant when configured", func(t *testing.T) {
+		p := newParticipantForTest("test")
+		p.params.Negotiation.OnTimeout = config.NegotiationTimeoutDisconnect
+
+		p.onNegotiationFailed()
+		require.Equal(t, livekit.ParticipantInfo_DISCONNECTED, p.State())
+	})
+}
+
+func TestStaleSignalConnection(t *testing.T) {
+	t.Run("ignores negotiation from superseded connection", func(t *testing.T) {
+		p := newParticipantForTest("test")
+		stale := p.SignalGeneration()
+		sink := &routingfakes.FakeMessageSink{}
+		p.SetResponseSink(sink)
+		require.NotEqual(t, stale, p.SignalGeneration())
+
+		_, err := p.HandleOffer(webrtc.SessionDescription{Type: webrtc.SDPTypeOffer}, stale)
+		require.NoError(t, err)
+		require.NoError(t, p.HandleAnswer(webrtc.SessionDescription{Type: webrtc.SDPTypeAnswer}, stale))
+		require.NoError(t, p.AddICECandidate(candidateForTest(0), livekit.SignalTarget_PUBLISHER, stale))
+
+		require.Equal(t, 0, sink.WriteMessageCallCount())
+		require.Nil(t, p.subscriber.pc.RemoteDescription())
+		require.Empty(t, p.publisher.pendingCandidates)
+	})
+
+	t.Run("rejects when configured", func(t *testing.T) {
+		p := newParticipantForTest("test")
+		p.params.RejectStaleSignal = true
+		stale := p.SignalGeneration()
+		p.SetResponseSink(&routingfakes.FakeMessageSink{})
+
+		err := p.AddICECandidate(candidateForTest(0), livekit.SignalTarget_PUBLISHER, stale)
+		require.Equal(t, ErrSignalSuperseded, err)
+		require.NoError(t, p.AddICECandidate(candidateForTest(0), livekit.SignalTarget_PUBLISHER, p.SignalGeneration()))
+		require.Len(t, p.publisher.pendingCandidates, 1)
+	})
+
+	t.Run("late messages are not processed after resume", func(t *testing.T) {
+		p := newParticipantForTest("test")
+		p.params.RejectStaleSignal = true
+		stale := p.SignalGeneration()
+
+		var resumed utils.AtomicFlag
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for i := 0; i < 200; i++ {
+				// once resume completed, no stale messages could get through
+				afterResume := resumed.Get()
+				err := p.AddICECandidate(candidateForTest(i), livekit.SignalTarget_PUBLISHER, stale)
+				if afterResume {
+					require.Equal(t, ErrSignalSuperseded, err)
+				}
+			}
+		}()
+
+		time.Sleep(time.Millisecond)
+		p.SetResponseSink(&routingfakes.FakeMessageSink{})
+		resumed.TrySet(true)
+		<-done
+	})
+}
+
+func TestKeyframeRequest(t *testing.T) {
+	p := newParticipantForTest("test")
+	p.firSeqs[2000] = 0
+
+	pli := &rtcp.PictureLossIndication{SenderSSRC: 1, MediaSSRC: 1000}
+	require.Equal(t, pli, p.keyframeRequest(pli))
+
+	// publisher without PLI receives FIRs with increasing sequence numbers
+	for i := uint8(0); i < 2; i++ {
+		pkt := p.keyframeRequest(&rtcp.PictureLossIndication{SenderSSRC: 1, MediaSSRC: 2000})
+		fir, ok := pkt.(*rtcp.FullIntraRequest)
+		require.True(t, ok)
+		require.Equal(t, uint32(2000), fir.MediaSSRC)
+		require.Equal(t, []rtcp.FIREntry{{SSRC: 2000, SequenceNumber: i}}, fir.FIR)
+	}
+}
+
+func TestRTCPWriteFailures(t *testing.T) {
+	t.Run("closes only the failing participant", func(t *testing.T) {
+		pool := NewWorkerPool(1)
+		defer pool.Close()
+		failing := newParticipantForTest("failing")
+		other := newParticipantForTest("other")
+		for _, p := range []*ParticipantImpl{failing, other} {
+			p.params.ReportPool = pool
+			p.Start()
+		}
+		defer other.Close()
+
+		// a report that can't be marshaled fails every write
+		invalid := []rtcp.Packet{&rtcp.ReceiverReport{SSRC: 1, Reports: make([]rtcp.ReceptionReport, 32)}}
+		for i := 0; i < maxRTCPWriteFailures; i++ {
+			failing.rtcpCh <- invalid
+		}
+		require.Eventually(t, func() bool {
+			return failing.State() == livekit.ParticipantInfo_DISCONNECTED
+		}, time.Second, 10*time.Millisecond)
+
+		require.NotEqual(t, livekit.ParticipantInfo_DISCONNECTED, other.State())
+		// the other participant's worker keeps draining, and the shared pool keeps running tasks
+		for i := 0; i < 2*cap(other.rtcpCh); i++ {
+			other.rtcpCh <- []rtcp.Packet{&rtcp.ReceiverReport{SSRC: 1}}
+		}
+		done := make(chan struct{})
+		require.True(t, pool.Submit(func() { close(done) }))
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("worker pool stopped running tasks")
+		}
+	})
+
+	t.Run("resets after a successful write", func(t *testing.T) {
+		p := newParticipantForTest("test")
+		err := errors.New("write failed")
+		for i := 0; i < maxRTCPWriteFailures-1; i++ {
+			require.True(t, p.handleRTCPWriteResult(&p.pubRTCPFailures, "publisher", err))
+		}
+		require.True(t, p.handleRTCPWriteResult(&p.pubRTCPFailures, "publisher", nil))
+		require.True(t, p.handleRTCPWriteResult(&p.pubRTCPFailures, "publisher", err))
+		require.NotEqual(t, livekit.ParticipantInfo_DISCONNECTED, p.State())
+	})
+}
+
+func TestWorkersExitOnClose(t *testing.T) {
+	pool := NewWorkerPool(1)
+	defer pool.Close()
+	churn := func(n int) {
+		for i := 0; i < n; i++ {
+			p := newParticipantForTest(fmt.Sprintf("p%d", i))
+			p.params.ReportPool = pool
+			p.Start()
+			require.NoError(t, p.Close())
+		}
+	}
+	// the first participant starts goroutines shared by all, like the logger's
+	churn(1)
+	time.Sleep(100 * time.Millisecond)
+	baseline := runtime.NumGoroutine()
+
+	churn(1000)
+	require.Eventually(t, func() bool {
+		return runtime.NumGoroutine() <= baseline
+	}, 5*time.Second, 50*time.Millisecond, "goroutines: %d, baseline: %d", runtime.NumGoroutine(), baseline)
+}
+
+func TestRTCPDrainedAfterClose(t *testing.T) {
+	p := newParticipantForTest("test")
+	p.Start()
+	require.NoError(t, p.Close())
+
+	// receivers of its tracks still writing don't block
+	written := make(chan struct{})
+	go func() {
+		for i := 0; i < 2*defaultRTCPQueueSize; i++ {
+			p.rtcpCh <- []rtcp.Packet{&rtcp.PictureLossIndication{MediaSSRC: 1}}
+		}
+		close(written)
+	}()
+	select {
+	case <-written:
+	case <-time.After(time.Second):
+		t.Fatal("writing RTCP blocked after close")
+	}
+}
+
+func TestReconnectGracePeriod(t *testing.T) {
+	t.Run("keeps participant until grace period is over", func(t *testing.T) {
+		p := newParticipantForTest("test")
+		p.SetReconnectGrace(50 * time.Millisecond)
+		require.True(t, p.HandleSignalLost(p.SignalGeneration()))
+		require.NotEqual(t, livekit.ParticipantInfo_DISCONNECTED, p.State())
+		require.Eventually(t, func() bool {
+			return p.State() == livekit.ParticipantInfo_DISCONNECTED
+		}, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("resuming on a new connection stops the grace period", func(t *testing.T) {
+		p := newParticipantForTest("test")
+		p.SetReconnectGrace(50 * time.Millisecond)
+		generation := p.SignalGeneration()
+		require.True(t, p.HandleSignalLost(generation))
+		p.SetResponseSink(&routingfakes.FakeMessageSink{})
+		// previous connection ending after the resume doesn't start it again
+		require.True(t, p.HandleSignalLost(generation))
+		time.Sleep(100 * time.Millisecond)
+		require.NotEqual(t, livekit.ParticipantInfo_DISCONNECTED, p.State())
+	})
+
+	t.Run("closes when grace period is reduced below the time disconnected", func(t *testing.T) {
+		p := newParticipantForTest("test")
+		p.SetReconnectGrace(time.Minute)
+		require.True(t, p.HandleSignalLost(p.SignalGeneration()))
+		time.Sleep(20 * time.Millisecond)
+		p.SetReconnectGrace(10 * time.Millisecond)
+		require.Eventually(t, func() bool {
+			return p.State() == livekit.ParticipantInfo_DISCONNECTED
+		}, 100*time.Millisecond, 5*time.Millisecond)
+	})
+
+	t.Run("closes right away without a grace period", func(t *testing.T) {
+		p := newParticipantForTest("test")
+		require.Zero(t, p.ReconnectGrace())
+		require.False(t, p.HandleSignalLost(p.SignalGeneration()))
+	})
+}
+
+func TestICERestart(t *testing.T) {
+	p := newParticipantForTest("test")
+	sink := p.params.Sink.(*routingfakes.FakeMessageSink)
+	track := &typesfakes.FakePublishedTrack{}
+	track.IDReturns("track")
+	p.publishedTracks["track"] = track
+
+	// client publisher connection
+	pub, err := webrtc.NewPeerConnection(webrtc.Configuration{})
+	require.NoError(t, err)
+	defer pub.Close()
+	_, err = pub.CreateDataChannel("test", nil)
+	require.NoError(t, err)
+	offer := createLocalOffer(t, pub, nil)
+	answer, err := p.HandleOffer(offer, p.SignalGeneration())
+	require.NoError(t, err)
+	require.NoError(t, pub.SetRemoteDescription(answer))
+
+	// client subscriber connection
+	sub, err := webrtc.NewPeerConnection(webrtc.Configuration{})
+	require.NoError(t, err)
+	defer sub.Close()
+	_, err = p.subscriber.pc.CreateDataChannel("test", nil)
+	require.NoError(t, err)
+	require.NoError(t, p.subscriber.CreateAndSendOffer(nil))
+	require.Eventually(t, func() bool {
+		return lastSentOffer(sink) != nil
+	}, time.Second, 10*time.Millisecond)
+	subOffer := lastSentOffer(sink)
+	require.NoError(t, sub.SetRemoteDescription(*subOffer))
+	subAnswer, err := sub.CreateAnswer(nil)
+	require.NoError(t, err)
+	require.NoError(t, sub.SetLocalDescription(subAnswer))
+	require.NoError(t, p.HandleAnswer(subAnswer, p.SignalGeneration()))
+
+	// network changed, the client restarts ICE
+	restartOffer := createLocalOffer(t, pub, &webrtc.OfferOptions{ICERestart: true})
+	require.NotEqual(t, ICEUfrag(offer.SDP), ICEUfrag(restartOffer.SDP))
+	_, err = p.HandleOffer(restartOffer, p.SignalGeneration())
+	require.NoError(t, err)
+	require.True(t, p.IsReconnecting())
+	require.NotEqual(t, livekit.ParticipantInfo_DISCONNECTED, p.State())
+
+	// subscriber ICE is restarted with an offer sent to the client
+	require.Eventually(t, func() bool {
+		offer := lastSentOffer(sink)
+		return offer != nil && ICEUfrag(offer.SDP) != ICEUfrag(subOffer.SDP)
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Contains(t, p.publishedTracks, "track")
+
+	p.handlePublisherICEStateChange(webrtc.ICEConnectionStateConnected)
+	require.False(t, p.IsReconnecting())
+	require.Equal(t, livekit.ParticipantInfo_ACTIVE, p.State())
+}
+
+func createLocalOffer(t *testing.T, pc *webrtc.PeerConnection, options *webrtc.OfferOptions) webrtc.SessionDescription {
+	offer, err := pc.CreateOffer(options)
+	require.NoError(t, err)
+	require.NoError(t, pc.SetLocalDescription(offer))
+	return offer
+}
+
+func lastSentOffer(sink *routingfakes.FakeMessageSink) *webrtc.SessionDescription {
+	for i := sink.WriteMessageCallCount() - 1; i >= 0; i-- {
+		res, ok := sink.WriteMessageArgsForCall(i).(*livekit.SignalResponse)
+		if !ok {
+			continue
+		}
+		if offer, ok := res.Message.(*livekit.SignalResponse_Offer); ok {
+			sd := FromProtoSessionDescription(offer.Offer)
+			return &sd
+		}
+	}
+	return nil
+}
+
+func TestMaxUploadBitrate(t *testing.T) {
+	p := newParticipantForTest("test")
+	video := &typesfakes.FakePublishedTrack{}
+	video.KindReturns(livekit.TrackType_VIDEO)
+	video.GetBufferStatsReturns([]types.BufferStats{{SSRC: 1, Bitrate: 1500000}})
+	audio := &typesfakes.FakePublishedTrack{}
+	audio.KindReturns(livekit.TrackType_AUDIO)
+	audio.GetBufferStatsReturns([]types.BufferStats{{SSRC: 2, Bitrate: 32000}})
+	p.publishedTracks["video"] = video
+	p.publishedTracks["audio"] = audio
+
+	t.Run("unlimited by default", func(t *testing.T) {
+		require.Zero(t, p.MaxUploadBitrate())
+		require.Zero(t, p.uploadCap())
+	})
+
+	t.Run("allows audio on top of the limit", func(t *testing.T) {
+		p.SetMaxUploadBitrate(1000000)
+		require.Equal(t, uint64(1000000), p.MaxUploadBitrate())
+		require.Equal(t, uint64(1032000), p.uploadCap())
+
+		p.SetMaxUploadBitrate(0)
+		require.Zero(t, p.uploadCap())
+	})
+}
+
+func TestSubscribeToTrack(t *testing.T) {
+	p := newParticipantForTest("test")
+	audio := &typesfakes.FakePublishedTrack{}
+	audio.IDReturns("TR_audio")
+	video := &typesfakes.FakePublishedTrack{}
+	video.IDReturns("TR_video")
+	p.publishedTracks["TR_audio"] = audio
+	p.publishedTracks["TR_video"] = video
+	sub := &typesfakes.FakeParticipant{}
+	sub.IDReturns("PA_sub")
+
+	require.NoError(t, p.SubscribeToTrack(sub, "TR_video"))
+	require.Equal(t, 1, video.AddSubscriberCallCount())
+	require.Zero(t, audio.AddSubscriberCallCount())
+	require.Equal(t, ErrTrackNotPublished, p.SubscribeToTrack(sub, "TR_screen"))
+
+	require.NoError(t, p.UnsubscribeFromTrack(sub, "TR_video"))
+	require.Equal(t, 1, video.RemoveSubscriberCallCount())
+	require.Equal(t, "PA_sub", video.RemoveSubscriberArgsForCall(0))
+	require.Equal(t, ErrTrackNotPublished, p.UnsubscribeFromTrack(sub, "TR_screen"))
+
+	// AddSubscriber subscribes to each of them
+	n, err := p.AddSubscriber(sub)
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+	require.Equal(t, 1, audio.AddSubscriberCallCount())
+	require.Equal(t, 2, video.AddSubscriberCallCount())
+}
+
+func TestAddSubscriberRejections(t *testing.T) {
+	p := newParticipantForTest("test")
+	audio := &typesfakes.FakePublishedTrack{}
+	audio.IDReturns("TR_audio")
+	p.publishedTracks["TR_audio"] = audio
+	for _, id := range []string{"TR_webcam", "TR_screen"} {
+		video := &typesfakes.FakePublishedTrack{}
+		video.IDReturns(id)
+		video.AddSubscriberReturns(ErrDownloadBudgetExceeded)
+		p.publishedTracks[id] = video
+	}
+	mismatched := &typesfakes.FakePublishedTrack{}
+	mismatched.IDReturns("TR_mismatched")
+	mismatched.AddSubscriberReturns(&TrackKindMismatchError{TrackID: "TR_mismatched"})
+	p.publishedTracks["TR_mismatched"] = mismatched
+	sub := &typesfakes.FakeParticipant{}
+	sub.IDReturns("PA_sub")
+
+	// rejected tracks don't stop the others from being subscribed to, whatever the order
+	n, err := p.AddSubscriber(sub)
+	require.Equal(t, 1, n)
+	require.Equal(t, 1, audio.AddSubscriberCallCount())
+	require.ErrorIs(t, err, ErrDownloadBudgetExceeded)
+	require.ErrorIs(t, err, ErrTrackKindMismatch)
+	errs, ok := err.(*SubscriptionErrors)
+	require.True(t, ok)
+	require.Len(t, errs.Errors, 3)
+	require.NotContains(t, errs.Errors, "TR_audio")
+}
+
+func TestUpdateSubscribedTrackSettings(t *testing.T) {
+	p := newParticipantForTest("test")
+	p.warmStandbys = newWarmStandbys(5)
+	st := &typesfakes.FakeSubscribedTrack{}
+	st.IDReturns("TR_1")
+	other := &typesfakes.FakeSubscribedTrack{}
+	other.IDReturns("TR_2")
+	p.subscribedTracks["PA_pub"] = []types.SubscribedTrack{st, other}
+
+	p.UpdateSubscribedTrackSettings("TR_1", false, livekit.VideoQuality_LOW)
+	require.Equal(t, 1, st.UpdateSubscriberSettingsCallCount())
+	enabled, quality := st.UpdateSubscriberSettingsArgsForCall(0)
+	require.False(t, enabled)
+	require.Equal(t, livekit.VideoQuality_LOW, quality)
+	require.Zero(t, other.UpdateSubscriberSettingsCallCount())
+	require.Len(t, p.warmStandbys.disabled, 1)
+
+	p.UpdateSubscribedTrackSettings("TR_1", true, livekit.VideoQuality_HIGH)
+	require.Empty(t, p.warmStandbys.disabled)
+}
+
+func TestSetSubscribedQuality(t *testing.T) {
+	p := newParticipantForTest("test")
+	st := &typesfakes.FakeSubscribedTrack{}
+	st.IDReturns("TR_1")
+	st.IsMutedReturns(true)
+	p.subscribedTracks["PA_pub"] = []types.SubscribedTrack{st}
+
+	require.NoError(t, p.SetSubscribedQuality("TR_1", livekit.VideoQuality_MEDIUM))
+	require.Equal(t, 1, st.UpdateSubscriberSettingsCallCount())
+	enabled, quality := st.UpdateSubscriberSettingsArgsForCall(0)
+	// a disabled track stays disabled
+	require.False(t, enabled)
+	require.Equal(t, livekit.VideoQuality_MEDIUM, quality)
+
+	require.Equal(t, ErrTrackNotSubscribed, p.SetSubscribedQuality("TR_2", livekit.VideoQuality_HIGH))
+}
+
+func TestNackWindow(t *testing.T) {
+	p := newParticipantForTest("test")
+
+	t.Run("full packet buffer by default", func(t *testing.T) {
+		require.Equal(t, 500, p.NackWindow())
+	})
+
+	t.Run("bounded by the receiver config", func(t *testing.T) {
+		p.SetNackWindow(100)
+		require.Equal(t, 100, p.NackWindow())
+		p.SetNackWindow(10)
+		require.Equal(t, 50, p.NackWindow())
+		p.SetNackWindow(1000)
+		require.Equal(t, 500, p.NackWindow())
+		p.SetNackWindow(0)
+		require.Equal(t, 500, p.NackWindow())
+	})
+}
+
+func TestPublishFailurePolicy(t *testing.T) {
+	newParticipant := func(action string) *ParticipantImpl {
+		p := newParticipantForTest("test")
+		p.params.PublishFailures = config.PublishFailuresConfig{Action: action, MaxFailures: 2, Window: time.Minute}
+		p.publishFailures = newPublishFailures(p.params.PublishFailures)
+		return p
+	}
+
+	t.Run("subscribe_only stops further publishing", func(t *testing.T) {
+		p := newParticipant(config.PublishFailureActionSubscribeOnly)
+		p.AddTrack(&livekit.AddTrackRequest{Cid: "pending", Type: livekit.TrackType_VIDEO})
+		p.onPublishFailure("track rejected")
+		require.True(t, p.CanPublish())
+
+		p.onPublishFailure("track rejected")
+		require.False(t, p.CanPublish())
+		require.True(t, p.CanSubscribe())
+		require.Empty(t, p.pendingTracks)
+
+		sink := p.params.Sink.(*routingfakes.FakeMessageSink)
+		written := sink.WriteMessageCallCount()
+		p.AddTrack(&livekit.AddTrackRequest{Cid: "another", Type: livekit.TrackType_VIDEO})
+		require.Equal(t, written, sink.WriteMessageCallCount())
+		require.NotEqual(t, livekit.ParticipantInfo_DISCONNECTED, p.State())
+	})
+
+	t.Run("disconnect closes the participant", func(t *testing.T) {
+		p := newParticipant(config.PublishFailureActionDisconnect)
+		p.onPublishFailure("could not set remote description")
+		require.NotEqual(t, livekit.ParticipantInfo_DISCONNECTED, p.State())
+		p.onPublishFailure("could not set remote description")
+		require.Equal(t, livekit.ParticipantInfo_DISCONNECTED, p.State())
+	})
+
+	t.Run("downgrading while tracks are published", func(t *testing.T) {
+		p := newParticipant(config.PublishFailureActionSubscribeOnly)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for i := 0; i < 100; i++ {
+				p.AddTrack(&livekit.AddTrackRequest{Cid: fmt.Sprintf("track%d", i), Type: livekit.TrackType_AUDIO})
+				p.CanPublishData()
+				p.CanSubscribe()
+			}
+		}()
+		p.onPublishFailure("track rejected")
+		p.onPublishFailure("track rejected")
+		<-done
+
+		require.False(t, p.CanPublish())
+		require.True(t, p.CanSubscribe())
+	})
+}
+
+func TestParticipantMetadata(t *testing.T) {
+	p := newParticipantForTest("test")
+	updates := 0
+	p.OnMetadataUpdate(func(types.Participant) {
+		updates++
+	})
+
+	p.SetMetadata(`{"role":"host"}`)
+	require.Equal(t, `{"role":"host"}`, p.Metadata())
+	require.Equal(t, `{"role":"host"}`, p.ToProto().Metadata)
+	require.Equal(t, 1, updates)
+
+	// others aren't updated when it doesn't change
+	p.SetMetadata(`{"role":"host"}`)
+	require.Equal(t, 1, updates)
+}
+
+func TestMutePublishedTrack(t *testing.T) {
+	p := newParticipantForTest("test")
+	track := &typesfakes.FakePublishedTrack{}
+	track.IDReturns("track1")
+	p.publishedTracks["track1"] = track
+
+	require.Equal(t, ErrTrackNotPublished, p.MutePublishedTrack("track2", true))
+	require.NoError(t, p.MutePublishedTrack("track1", true))
+	require.Equal(t, 1, track.SetMutedCallCount())
+	require.True(t, track.SetMutedArgsForCall(0))
+
+	// the participant can't unmute it
+	p.SetTrackMuted("track1", false)
+	require.Equal(t, 1, track.SetMutedCallCount())
+	p.SetTrackMuted("track1", true)
+	require.Equal(t, 2, track.SetMutedCallCount())
+
+	require.NoError(t, p.MutePublishedTrack("track1", false))
+	require.False(t, track.SetMutedArgsForCall(2))
+	p.SetTrackMuted("track1", true)
+	p.SetTrackMuted("track1", false)
+	require.Equal(t, 5, track.SetMutedCallCount())
+	require.False(t, track.SetMutedArgsForCall(4))
+}
+
+func TestSendData(t *testing.T) {
+	newActiveParticipant := func() *ParticipantImpl {
+		p := newParticipantForTest("test")
+		p.params.SCTP = config.SCTPConfig{MaxMessageSize: 100}
+		p.state.Store(livekit.ParticipantInfo_ACTIVE)
+		return p
+	}
+	pendingPayloads := func(t *testing.T, d *serverDataChannel) [][]byte {
+		var payloads [][]byte
+		for _, data := range d.pending {
+			dp := &livekit.DataPacket{}
+			require.NoError(t, proto.Unmarshal(data, dp))
+			require.Empty(t, dp.GetUser().ParticipantSid)
+			payloads = append(payloads, dp.GetUser().Payload)
+		}
+		return payloads
+	}
+
+	t.Run("requires an active participant", func(t *testing.T) {
+		p := newParticipantForTest("test")
+		require.Equal(t, ErrDataChannelUnavailable, p.SendData([]byte("hi"), true))
+	})
+
+	t.Run("opens a channel for each kind and holds messages until it's open", func(t *testing.T) {
+		p := newActiveParticipant()
+		require.NoError(t, p.SendData([]byte("hi"), true))
+		require.NoError(t, p.SendData([]byte("there"), true))
+		require.NoError(t, p.SendData([]byte("lossy"), false))
+
+		require.Equal(t, serverReliableDataChannel, p.serverReliableDC.dc.Label())
+		require.True(t, p.serverReliableDC.dc.Ordered())
+		require.Equal(t, [][]byte{[]byte("hi"), []byte("there")}, pendingPayloads(t, p.serverReliableDC))
+
+		require.Equal(t, serverLossyDataChannel, p.serverLossyDC.dc.Label())
+		require.False(t, p.serverLossyDC.dc.Ordered())
+		require.Equal(t, uint16(0), *p.serverLossyDC.dc.MaxRetransmits())
+		require.Equal(t, [][]byte{[]byte("lossy")}, pendingPayloads(t, p.serverLossyDC))
+	})
+
+	t.Run("sends messages filling a packet whole", func(t *testing.T) {
+		p := newActiveParticipant()
+		// 2 bytes of user packet and 2 of data packet around it
+		payload := []byte(strings.Repeat("a", 96))
+		require.NoError(t, p.SendData(payload, true))
+		require.Equal(t, [][]byte{payload}, pendingPayloads(t, p.serverReliableDC))
+		require.Len(t, p.serverReliableDC.pending[0], 100)
+	})
+
+	t.Run("splits large reliable messages into chunks", func(t *testing.T) {
+		p := newActiveParticipant()
+		payload := []byte(strings.Repeat("abcdefghij", 40))
+		require.NoError(t, p.SendData(payload, true))
+		require.NoError(t, p.SendData([]byte(strings.Repeat("z", 120)), true))
+
+		for _, data := range p.serverReliableDC.pending {
+			require.LessOrEqual(t, len(data), 100)
+		}
+		// chunks of each message, by id
+		messages := make(map[uint32][]dataChunkSignal)
+		for _, chunkPayload := range pendingPayloads(t, p.serverReliableDC) {
+			chunk := dataChunkSignal{}
+			require.NoError(t, json.Unmarshal(chunkPayload, &chunk))
+			require.Equal(t, dataChunkPacketType, chunk.Type)
+			messages[chunk.ID] = append(messages[chunk.ID], chunk)
+		}
+		require.Len(t, messages, 2)
+		reassemble := func(chunks []dataChunkSignal) []byte {
+			var message []byte
+			for i, chunk := range chunks {
+				require.Equal(t, i, chunk.Index)
+				require.Equal(t, len(chunks), chunk.Count)
+				message = append(message, chunk.Data...)
+			}
+			return message
+		}
+		require.Equal(t, payload, reassemble(messages[1]))
+		require.Equal(t, []byte(strings.Repeat("z", 120)), reassemble(messages[2]))
+
+		require.Equal(t, ErrDataPacketTooLarge, p.SendData(payload, false))
+	})
+
+	t.Run("rejects messages when chunks couldn't carry data", func(t *testing.T) {
+		p := newActiveParticipant()
+		p.params.SCTP.MaxMessageSize = 50
+		require.Equal(t, ErrDataPacketTooLarge, p.SendData([]byte(strings.Repeat("a", 100)), true))
+	})
+}
+
+func TestParticipantIDGenerator(t *testing.T) {
+	t.Run("random by default", func(t *testing.T) {
+		first := newParticipantForTest("first")
+		second := newParticipantForTest("second")
+		require.True(t, strings.HasPrefix(first.ID(), utils.ParticipantPrefix))
+		require.NotEqual(t, first.ID(), second.ID())
+	})
+
+	t.Run("uses the injected generator", func(t *testing.T) {
+		var n int
+		idGenerator := func() string {
+			n++
+			return fmt.Sprintf("PA_test%d", n)
+		}
+		require.Equal(t, "PA_test1", newParticipantForTestWithIDs("first", idGenerator).ID())
+		require.Equal(t, "PA_test2", newParticipantForTestWithIDs("second", idGenerator).ID())
+	})
+}
+
 func TestCorrectJoinedAt(t *testing.T) {
 	p := newParticipantForTest("test")
 	info := p.ToProto()
@@ -160,6 +840,10 @@ func TestCorrectJoinedAt(t *testing.T) {
 }
 
 func newParticipantForTest(identity string) *ParticipantImpl {
+	return newParticipantForTestWithIDs(identity, nil)
+}
+
+func newParticipantForTestWithIDs(identity string, idGenerator func() string) *ParticipantImpl {
 	conf, _ := config.NewConfig("", nil)
 	// disable mux, it doesn't play too well with unit test
 	conf.RTC.UDPPort = 0
@@ -174,6 +858,111 @@ func newParticipantForTest(identity string) *ParticipantImpl {
 		Sink:            &routingfakes.FakeMessageSink{},
 		ProtocolVersion: 0,
 		ThrottleConfig:  conf.RTC.PLIThrottle,
+		TrickleLimit:    conf.RTC.TrickleLimit,
+		IDGenerator:     idGenerator,
 	})
 	return p
 }
+
+func TestParticipantMetadataSize(t *testing.T) {
+	p := newParticipantForTest("test")
+	p.params.MaxMetadataSize = 16
+	require.NoError(t, p.SetMetadata(`{"role":"host"}`))
+
+	err := p.SetMetadata(`{"role":"moderator"}`)
+	require.ErrorIs(t, err, ErrMetadataTooLarge)
+	require.Equal(t, `{"role":"host"}`, p.metadata)
+}
+
+func TestParticipantName(t *testing.T) {
+	p := newParticipantForTest("test")
+	updates := 0
+	p.OnNameUpdate(func(types.Participant) {
+		updates++
+	})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			_ = p.Name()
+		}
+	}()
+	p.SetName("Alice")
+	p.SetName("Alice")
+	<-done
+	require.Equal(t, "Alice", p.Name())
+	require.Equal(t, 1, updates)
+}
+
+func TestDisconnectReason(t *testing.T) {
+	p := newParticipantForTest("test")
+	require.Equal(t, types.DisconnectReasonUnknown, p.DisconnectReason())
+
+	// the first reason is kept, e.g. a removed participant's session ending afterwards
+	p.SetDisconnectReason(types.DisconnectReasonRemoved)
+	p.SetDisconnectReason(types.DisconnectReasonSignalClosed)
+	require.Equal(t, types.DisconnectReasonRemoved, p.DisconnectReason())
+}
+
+func TestTrimRosterMetadata(t *testing.T) {
+	infos := []*livekit.ParticipantInfo{
+		{Identity: "a", Metadata: "12345"},
+		{Identity: "b", Metadata: "1234567890"},
+		{Identity: "c", Metadata: "123"},
+	}
+	require.Equal(t, infos, trimRosterMetadata(infos, 0))
+	require.Equal(t, infos, trimRosterMetadata(infos, 18))
+
+	trimmed := trimRosterMetadata(infos, 10)
+	require.Equal(t, "", trimmed[1].Metadata)
+	require.Equal(t, "12345", trimmed[0].Metadata)
+	require.Equal(t, "123", trimmed[2].Metadata)
+	// shared with other recipients, left as is
+	require.Equal(t, "1234567890", infos[1].Metadata)
+
+	trimmed = trimRosterMetadata(infos, 4)
+	require.Equal(t, "", trimmed[0].Metadata)
+	require.Equal(t, "", trimmed[1].Metadata)
+	require.Equal(t, "123", trimmed[2].Metadata)
+}
+
+func TestPublishDataPermission(t *testing.T) {
+	p := newParticipantForTest("test")
+	var received []*livekit.DataPacket
+	p.OnDataPacket(func(_ types.Participant, dp *livekit.DataPacket) {
+		received = append(received, dp)
+	})
+	data, err := proto.Marshal(&livekit.DataPacket{
+		Value: &livekit.DataPacket_User{User: &livekit.UserPacket{Payload: []byte("hi")}},
+	})
+	require.NoError(t, err)
+
+	p.handleDataMessage(livekit.DataPacket_RELIABLE, data)
+	require.Len(t, received, 1)
+
+	p.SetPermission(&livekit.ParticipantPermission{CanSubscribe: true})
+	require.False(t, p.CanPublishData())
+	p.handleDataMessage(livekit.DataPacket_RELIABLE, data)
+	p.handleDataMessage(livekit.DataPacket_LOSSY, data)
+	require.Len(t, received, 1)
+}
+
+func TestSubscribedTrackIDs(t *testing.T) {
+	p := newParticipantForTest("test")
+	st := &typesfakes.FakeSubscribedTrack{}
+	st.IDReturns("TR_1")
+	paused := &typesfakes.FakeSubscribedTrack{}
+	paused.IDReturns("TR_2")
+	paused.IsPausedReturns(true)
+	p.subscribedTracks["PA_pub"] = []types.SubscribedTrack{st, paused}
+	require.Equal(t, []string{"TR_1"}, p.SubscribedTrackIDs())
+
+	// kept once subscriptions are torn down
+	p.lock.Lock()
+	p.closedSubscriptions = p.subscribedTrackIDsLocked()
+	p.subscriptionsClosed = true
+	p.subscribedTracks = make(map[string][]types.SubscribedTrack)
+	p.lock.Unlock()
+	require.Equal(t, []string{"TR_1"}, p.SubscribedTrackIDs())
+}
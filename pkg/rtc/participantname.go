@@ -0,0 +1,26 @@
+package rtc
+
+import (
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+// type of user packets telling participants the name of another, ParticipantInfo has no field for it
+const participantNamePacketType = "participant_name"
+
+// participantNameSignal is the payload of data packets sent to participants when another is renamed, and for
+// those already named when they join
+type participantNameSignal struct {
+	Type     string `json:"type"`
+	Sid      string `json:"sid"`
+	Identity string `json:"identity"`
+	Name     string `json:"name"`
+}
+
+func newParticipantNameSignal(p types.Participant) participantNameSignal {
+	return participantNameSignal{
+		Type:     participantNamePacketType,
+		Sid:      p.ID(),
+		Identity: p.Identity(),
+		Name:     p.Name(),
+	}
+}
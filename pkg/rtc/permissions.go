@@ -0,0 +1,20 @@
+package rtc
+
+import ()
+
+// type of user packets telling participants something they published was refused for lack of permission
+const permissionDeniedPacketType = "permission_denied"
+
+const (
+	permissionPublishTrack = "publish_track"
+	permissionPublishData  = "publish_data"
+)
+
+// permissionDeniedSignal is the payload of data packets sent to a participant publishing without permission
+type permissionDeniedSignal struct {
+	Type string `json:"type"`
+	// publish_track or publish_data
+	Permission string `json:"permission"`
+	// client id of the refused track
+	TrackCid string `json:"trackCid,omitempty"`
+}
@@ -0,0 +1,103 @@
+package rtc
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pion/sdp/v3"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+// type of user packets telling participants they keep failing to publish, set in their JSON payload
+const publishFailedPacketType = "publish_failed"
+
+// publishFailedSignal is the payload of data packets sent to a participant once its failures trip the policy
+type publishFailedSignal struct {
+	Type string `json:"type"`
+	// failures within the window, and the latest of them
+	Failures int    `json:"failures"`
+	Reason   string `json:"reason"`
+	// what's done about it, notify, subscribe_only or disconnect
+	Action   string `json:"action"`
+	Guidance string `json:"guidance"`
+}
+
+func newPublishFailedSignal(failures int, reason, action string) publishFailedSignal {
+	guidance := "publishing keeps failing, check that the browser supports the room's codecs and try again"
+	switch action {
+	case config.PublishFailureActionSubscribeOnly:
+		guidance = "publishing keeps failing, you can keep watching but can no longer publish in this session"
+	case config.PublishFailureActionDisconnect:
+		guidance = "publishing keeps failing, you've been disconnected and could rejoin"
+	}
+	return publishFailedSignal{
+		Type:     publishFailedPacketType,
+		Failures: failures,
+		Reason:   reason,
+		Action:   action,
+		Guidance: guidance,
+	}
+}
+
+// publishFailures counts failed attempts of a participant to publish within the configured window
+type publishFailures struct {
+	conf config.PublishFailuresConfig
+
+	lock sync.Mutex
+	// times of failures within the window, oldest first
+	failures []time.Time
+}
+
+// newPublishFailures returns nil when the action is none
+func newPublishFailures(conf config.PublishFailuresConfig) *publishFailures {
+	if conf.Action == "" || conf.Action == config.PublishFailureActionNone {
+		return nil
+	}
+	return &publishFailures{conf: conf}
+}
+
+// record adds a failure, it returns the number of failures within the window once they reach the max, 0 otherwise.
+// Failures are cleared when it trips, so the action isn't taken again for the same ones
+func (f *publishFailures) record(now time.Time) int {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	expired := 0
+	for expired < len(f.failures) && now.Sub(f.failures[expired]) > f.conf.Window {
+		expired++
+	}
+	f.failures = append(f.failures[expired:], now)
+	if len(f.failures) < f.conf.MaxFailures {
+		return 0
+	}
+	tripped := len(f.failures)
+	f.failures = nil
+	return tripped
+}
+
+// rejectedMediaSections returns the number of audio and video sections offered that the answer rejects, which
+// it does when none of the codecs offered are enabled
+func rejectedMediaSections(offer, answer string) int {
+	var o, a sdp.SessionDescription
+	if err := o.Unmarshal([]byte(offer)); err != nil {
+		return 0
+	}
+	if err := a.Unmarshal([]byte(answer)); err != nil {
+		return 0
+	}
+	rejected := 0
+	for i, m := range o.MediaDescriptions {
+		if i >= len(a.MediaDescriptions) {
+			break
+		}
+		if m.MediaName.Media != "audio" && m.MediaName.Media != "video" {
+			continue
+		}
+		// sections rejected before keep port 0 in later offers
+		if m.MediaName.Port.Value != 0 && a.MediaDescriptions[i].MediaName.Port.Value == 0 {
+			rejected++
+		}
+	}
+	return rejected
+}
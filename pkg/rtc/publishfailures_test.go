@@ -0,0 +1,59 @@
+package rtc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+func TestPublishFailures(t *testing.T) {
+	conf := config.PublishFailuresConfig{
+		Action:      config.PublishFailureActionNotify,
+		MaxFailures: 3,
+		Window:      time.Minute,
+	}
+
+	t.Run("disabled without an action", func(t *testing.T) {
+		require.Nil(t, newPublishFailures(config.PublishFailuresConfig{Action: config.PublishFailureActionNone}))
+	})
+
+	t.Run("trips on failures within the window", func(t *testing.T) {
+		f := newPublishFailures(conf)
+		now := time.Now()
+		require.Zero(t, f.record(now))
+		require.Zero(t, f.record(now.Add(10*time.Second)))
+		require.Equal(t, 3, f.record(now.Add(20*time.Second)))
+		// cleared once tripped
+		require.Zero(t, f.record(now.Add(30*time.Second)))
+	})
+
+	t.Run("transient failures don't add up", func(t *testing.T) {
+		f := newPublishFailures(conf)
+		now := time.Now()
+		require.Zero(t, f.record(now))
+		require.Zero(t, f.record(now.Add(50*time.Second)))
+		// the first two have expired
+		require.Zero(t, f.record(now.Add(2*time.Minute)))
+		require.Zero(t, f.record(now.Add(2*time.Minute+30*time.Second)))
+		require.Equal(t, 3, f.record(now.Add(2*time.Minute+40*time.Second)))
+	})
+}
+
+func TestRejectedMediaSections(t *testing.T) {
+	offer := "v=0\r\no=- 0 0 IN IP4 127.0.0.1\r\ns=-\r\nt=0 0\r\n" +
+		"m=audio 9 UDP/TLS/RTP/SAVPF 111\r\n" +
+		"m=video 9 UDP/TLS/RTP/SAVPF 45\r\n" +
+		"m=video 0 UDP/TLS/RTP/SAVPF 96\r\n" +
+		"m=application 9 UDP/DTLS/SCTP webrtc-datachannel\r\n"
+	answer := "v=0\r\no=- 0 0 IN IP4 127.0.0.1\r\ns=-\r\nt=0 0\r\n" +
+		"m=audio 9 UDP/TLS/RTP/SAVPF 111\r\n" +
+		"m=video 0 UDP/TLS/RTP/SAVPF 0\r\n" +
+		"m=video 0 UDP/TLS/RTP/SAVPF 0\r\n" +
+		"m=application 9 UDP/DTLS/SCTP webrtc-datachannel\r\n"
+	// the section rejected in a previous negotiation isn't counted again
+	require.Equal(t, 1, rejectedMediaSections(offer, answer))
+	require.Zero(t, rejectedMediaSections("invalid", answer))
+}
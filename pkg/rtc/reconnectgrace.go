@@ -0,0 +1,124 @@
+package rtc
+
+import (
+	"sync"
+	"time"
+)
+
+// reconnectGrace closes a participant that hasn't recovered within its grace period after losing its signal
+// connection or ICE connectivity. The grace period could be changed at any time, the deadline always reflects
+// the latest value: reducing it below the time already spent disconnected expires it right away.
+// Without a grace period, participants are closed as soon as the signal connection or ICE fails
+type reconnectGrace struct {
+	onExpired func()
+
+	lock       sync.Mutex
+	grace      time.Duration
+	signalLost bool
+	iceLost    bool
+	// when the grace period started, zero while connected or without a grace period
+	since  time.Time
+	timer  *time.Timer
+	closed bool
+}
+
+func newReconnectGrace(grace time.Duration, onExpired func()) *reconnectGrace {
+	return &reconnectGrace{
+		grace:     grace,
+		onExpired: onExpired,
+	}
+}
+
+func (g *reconnectGrace) Grace() time.Duration {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	return g.grace
+}
+
+func (g *reconnectGrace) setGrace(grace time.Duration) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	g.grace = grace
+	if g.since.IsZero() && grace > 0 && (g.signalLost || g.iceLost) {
+		// disconnected while it didn't have a grace period
+		g.since = time.Now()
+	}
+	g.scheduleLocked()
+}
+
+// setSignalLost returns false when the participant has no grace period and should be closed
+func (g *reconnectGrace) setSignalLost(lost bool) bool {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	g.signalLost = lost
+	g.updateLocked()
+	return !lost || g.grace > 0
+}
+
+// setICELost returns false when the participant has no grace period and should be closed
+func (g *reconnectGrace) setICELost(lost bool) bool {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	g.iceLost = lost
+	g.updateLocked()
+	return !lost || g.grace > 0
+}
+
+// remaining returns the time left to recover, 0 while connected
+func (g *reconnectGrace) remaining() time.Duration {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	if g.since.IsZero() {
+		return 0
+	}
+	if remaining := g.grace - time.Since(g.since); remaining > 0 {
+		return remaining
+	}
+	return 0
+}
+
+func (g *reconnectGrace) close() {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	g.closed = true
+	g.stopLocked()
+}
+
+func (g *reconnectGrace) updateLocked() {
+	if !g.signalLost && !g.iceLost {
+		g.since = time.Time{}
+		g.stopLocked()
+		return
+	}
+	if g.since.IsZero() && g.grace > 0 {
+		g.since = time.Now()
+		g.scheduleLocked()
+	}
+}
+
+func (g *reconnectGrace) scheduleLocked() {
+	g.stopLocked()
+	if g.since.IsZero() || g.closed {
+		return
+	}
+	since := g.since
+	g.timer = time.AfterFunc(g.grace-time.Since(since), func() {
+		g.lock.Lock()
+		// recovered, or rescheduled with a new grace period
+		expired := !g.closed && g.since == since && time.Since(since) >= g.grace
+		if expired {
+			g.closed = true
+		}
+		g.lock.Unlock()
+		if expired {
+			g.onExpired()
+		}
+	})
+}
+
+func (g *reconnectGrace) stopLocked() {
+	if g.timer != nil {
+		g.timer.Stop()
+		g.timer = nil
+	}
+}
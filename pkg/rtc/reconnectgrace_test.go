@@ -0,0 +1,75 @@
+package rtc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/livekit/protocol/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReconnectGrace(t *testing.T) {
+	newGrace := func(grace time.Duration) (*reconnectGrace, *utils.AtomicFlag) {
+		expired := &utils.AtomicFlag{}
+		return newReconnectGrace(grace, func() { expired.TrySet(true) }), expired
+	}
+
+	t.Run("closes right away without a grace period", func(t *testing.T) {
+		g, expired := newGrace(0)
+		require.False(t, g.setSignalLost(true))
+		require.False(t, g.setICELost(true))
+		require.Zero(t, g.remaining())
+		time.Sleep(10 * time.Millisecond)
+		require.False(t, expired.Get())
+	})
+
+	t.Run("expires when not recovered", func(t *testing.T) {
+		g, expired := newGrace(50 * time.Millisecond)
+		require.True(t, g.setSignalLost(true))
+		require.NotZero(t, g.remaining())
+		require.Eventually(t, expired.Get, time.Second, 5*time.Millisecond)
+	})
+
+	t.Run("recovering stops the grace period", func(t *testing.T) {
+		g, expired := newGrace(50 * time.Millisecond)
+		g.setSignalLost(true)
+		g.setICELost(true)
+		g.setSignalLost(false)
+		// still waiting for ICE
+		require.NotZero(t, g.remaining())
+		g.setICELost(false)
+		require.Zero(t, g.remaining())
+		time.Sleep(100 * time.Millisecond)
+		require.False(t, expired.Get())
+	})
+
+	t.Run("deadline reflects the latest grace period", func(t *testing.T) {
+		g, expired := newGrace(50 * time.Millisecond)
+		g.setSignalLost(true)
+		g.setGrace(time.Minute)
+		time.Sleep(100 * time.Millisecond)
+		require.False(t, expired.Get())
+		require.Greater(t, int64(g.remaining()), int64(50*time.Second))
+
+		// already disconnected for longer than the new grace period
+		g.setGrace(50 * time.Millisecond)
+		require.Zero(t, g.remaining())
+		require.Eventually(t, expired.Get, 50*time.Millisecond, time.Millisecond)
+	})
+
+	t.Run("grace period added while disconnected", func(t *testing.T) {
+		g, expired := newGrace(0)
+		require.False(t, g.setICELost(true))
+		g.setGrace(20 * time.Millisecond)
+		require.NotZero(t, g.remaining())
+		require.Eventually(t, expired.Get, time.Second, 5*time.Millisecond)
+	})
+
+	t.Run("doesn't expire once closed", func(t *testing.T) {
+		g, expired := newGrace(20 * time.Millisecond)
+		g.setSignalLost(true)
+		g.close()
+		time.Sleep(50 * time.Millisecond)
+		require.False(t, expired.Get())
+	})
+}
@@ -0,0 +1,12 @@
+package rtc
+
+import ()
+
+// type of user packets telling participants whether the room is being recorded, set in their JSON payload
+const recordingStatusPacketType = "recording_status"
+
+// recordingSignal is the payload of data packets sent to participants when recording starts or stops
+type recordingSignal struct {
+	Type      string `json:"type"`
+	Recording bool   `json:"recording"`
+}
@@ -0,0 +1,98 @@
+package rtc
+
+import (
+	"github.com/pion/sdp/v3"
+	"github.com/pion/webrtc/v3"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/logger"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+// rejectedMids returns the mids of audio and video sections offered that the answer rejects with port 0, or leaves
+// out. Sections the offer had already disabled aren't counted
+func rejectedMids(offer, answer string) map[string]bool {
+	var o, a sdp.SessionDescription
+	if err := o.Unmarshal([]byte(offer)); err != nil {
+		return nil
+	}
+	if err := a.Unmarshal([]byte(answer)); err != nil {
+		return nil
+	}
+	// mid => section of the answer
+	answered := make(map[string]*sdp.MediaDescription, len(a.MediaDescriptions))
+	for _, m := range a.MediaDescriptions {
+		if mid, ok := m.Attribute(sdp.AttrKeyMID); ok {
+			answered[mid] = m
+		}
+	}
+	var rejected map[string]bool
+	for _, m := range o.MediaDescriptions {
+		if m.MediaName.Media != "audio" && m.MediaName.Media != "video" || m.MediaName.Port.Value == 0 {
+			continue
+		}
+		mid, ok := m.Attribute(sdp.AttrKeyMID)
+		if !ok {
+			continue
+		}
+		if am := answered[mid]; am != nil && am.MediaName.Port.Value != 0 {
+			continue
+		}
+		if rejected == nil {
+			rejected = make(map[string]bool)
+		}
+		rejected[mid] = true
+	}
+	return rejected
+}
+
+// rejectedSubscriptions returns subscriptions whose transceivers have one of mids, keyed by publisher
+func rejectedSubscriptions(transceivers []*webrtc.RTPTransceiver, subscribed map[string][]types.SubscribedTrack,
+	mids map[string]bool) map[string][]types.SubscribedTrack {
+	trackIDs := make(map[string]bool)
+	for _, tr := range transceivers {
+		if !mids[tr.Mid()] {
+			continue
+		}
+		if sender := tr.Sender(); sender != nil && sender.Track() != nil {
+			trackIDs[sender.Track().ID()] = true
+		}
+	}
+	rejected := make(map[string][]types.SubscribedTrack)
+	for pubID, tracks := range subscribed {
+		for _, track := range tracks {
+			if trackIDs[track.ID()] {
+				rejected[pubID] = append(rejected[pubID], track)
+			}
+		}
+	}
+	return rejected
+}
+
+// handleRejectedSubscriptions removes subscriptions the participant rejected or left out of its answer to offer,
+// their DownTracks would never be bound. They're removed as when unsubscribing, unless they're kept by config
+func (p *ParticipantImpl) handleRejectedSubscriptions(offer, answer string) {
+	mids := rejectedMids(offer, answer)
+	if len(mids) == 0 {
+		return
+	}
+	p.lock.RLock()
+	rejected := rejectedSubscriptions(p.subscriber.pc.GetTransceivers(), p.subscribedTracks, mids)
+	p.lock.RUnlock()
+
+	keep := p.params.Config.RejectedSubscriptions == config.RejectedSubscriptionsKeep
+	for pubID, tracks := range rejected {
+		for _, track := range tracks {
+			logger.Warnw("subscriber rejected subscribed track in its answer", nil,
+				"participant", p.Identity(),
+				"srcParticipant", pubID,
+				"track", track.ID(),
+				"kept", keep)
+			subscriptionAnswerRejectedTotal.Inc()
+			if !keep {
+				// removed from the participant and the published track once closed, as when unsubscribing
+				go track.DownTrack().Close()
+			}
+		}
+	}
+}
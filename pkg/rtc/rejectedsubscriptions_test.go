@@ -0,0 +1,55 @@
+package rtc
+
+import (
+	"testing"
+
+	"github.com/pion/webrtc/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+	"github.com/livekit/livekit-server/pkg/rtc/types/typesfakes"
+)
+
+func TestRejectedMids(t *testing.T) {
+	offer := "v=0\r\no=- 0 0 IN IP4 127.0.0.1\r\ns=-\r\nt=0 0\r\n" +
+		"m=audio 9 UDP/TLS/RTP/SAVPF 111\r\na=mid:0\r\n" +
+		"m=video 9 UDP/TLS/RTP/SAVPF 96\r\na=mid:1\r\n" +
+		"m=video 0 UDP/TLS/RTP/SAVPF 96\r\na=mid:2\r\n" +
+		"m=video 9 UDP/TLS/RTP/SAVPF 96\r\na=mid:3\r\n" +
+		"m=application 9 UDP/DTLS/SCTP webrtc-datachannel\r\na=mid:4\r\n"
+	// rejects 1, leaves out 3
+	answer := "v=0\r\no=- 0 0 IN IP4 127.0.0.1\r\ns=-\r\nt=0 0\r\n" +
+		"m=audio 9 UDP/TLS/RTP/SAVPF 111\r\na=mid:0\r\n" +
+		"m=video 0 UDP/TLS/RTP/SAVPF 96\r\na=mid:1\r\n" +
+		"m=video 0 UDP/TLS/RTP/SAVPF 96\r\na=mid:2\r\n" +
+		"m=application 9 UDP/DTLS/SCTP webrtc-datachannel\r\na=mid:4\r\n"
+	// the section disabled in the offer isn't counted
+	require.Equal(t, map[string]bool{"1": true, "3": true}, rejectedMids(offer, answer))
+	require.Empty(t, rejectedMids(offer, offer))
+	require.Empty(t, rejectedMids("invalid", answer))
+}
+
+func TestRejectedSubscriptions(t *testing.T) {
+	pc, err := webrtc.NewPeerConnection(webrtc.Configuration{})
+	require.NoError(t, err)
+	defer func() { _ = pc.Close() }()
+	for _, id := range []string{"TR_audio", "TR_video"} {
+		track, err := webrtc.NewTrackLocalStaticRTP(webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeVP8}, id, "stream")
+		require.NoError(t, err)
+		_, err = pc.AddTransceiverFromTrack(track, webrtc.RTPTransceiverInit{Direction: webrtc.RTPTransceiverDirectionSendonly})
+		require.NoError(t, err)
+	}
+	offer, err := pc.CreateOffer(nil)
+	require.NoError(t, err)
+	require.NoError(t, pc.SetLocalDescription(offer))
+
+	audio := &typesfakes.FakeSubscribedTrack{}
+	audio.IDReturns("TR_audio")
+	video := &typesfakes.FakeSubscribedTrack{}
+	video.IDReturns("TR_video")
+	subscribed := map[string][]types.SubscribedTrack{"PA_pub": {audio, video}}
+
+	rejected := rejectedSubscriptions(pc.GetTransceivers(), subscribed, map[string]bool{pc.GetTransceivers()[1].Mid(): true})
+	require.Equal(t, map[string][]types.SubscribedTrack{"PA_pub": {video}}, rejected)
+	require.Empty(t, rejectedSubscriptions(pc.GetTransceivers(), subscribed, map[string]bool{"unknown": true}))
+}
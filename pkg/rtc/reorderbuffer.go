@@ -0,0 +1,248 @@
+package rtc
+
+import (
+	"encoding/binary"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/pion/transport/packetio"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+	livekit "github.com/livekit/livekit-server/proto"
+)
+
+// ReorderBufferParams sets how a published track's RTP that arrives out of order is reordered
+type ReorderBufferParams struct {
+	// longest a packet is held waiting for those missing before it, 0 forwards packets as they arrive
+	Delay time.Duration
+	// packets held at most, missing ones are given up on past it
+	MaxPackets int
+}
+
+// reorderBuffers holds RTP of published streams that arrives ahead of packets missing before it, writing it to the
+// stream's buffer once they arrive, or after the delay of the stream, so DownTracks forward it in sequence.
+// Packets arriving after being given up on, as retransmissions do, are written as they arrive.
+// Buffers see held packets late, so they NACK missing ones once they're given up on rather than when they're
+// first missed
+type reorderBuffers struct {
+	conf config.ReorderBufferConfig
+
+	lock sync.Mutex
+	// SSRC => stream
+	streams map[uint32]*reorderStream
+}
+
+// newReorderBuffers returns nil when disabled
+func newReorderBuffers(conf config.ReorderBufferConfig) *reorderBuffers {
+	if !conf.Enabled {
+		return nil
+	}
+	return &reorderBuffers{
+		conf:    conf,
+		streams: make(map[uint32]*reorderStream),
+	}
+}
+
+// paramsFor returns the parameters of tracks of kind captured from source, by default
+func (r *reorderBuffers) paramsFor(kind livekit.TrackType, source types.TrackSource) ReorderBufferParams {
+	params := ReorderBufferParams{MaxPackets: r.conf.MaxPackets}
+	switch {
+	case source == types.TrackSourceScreenShare:
+		params.Delay = r.conf.ScreenShare
+	case kind == livekit.TrackType_AUDIO:
+		params.Delay = r.conf.Audio
+	default:
+		params.Delay = r.conf.Camera
+	}
+	return params
+}
+
+// setStream reorders RTP of a published stream following params
+func (r *reorderBuffers) setStream(ssrc uint32, params ReorderBufferParams) {
+	r.lock.Lock()
+	s := r.streams[ssrc]
+	r.lock.Unlock()
+	if s == nil {
+		return
+	}
+	s.lock.Lock()
+	s.params = params
+	s.lock.Unlock()
+}
+
+// wrapBufferFactory holds RTP of each published stream, streams are written through until they're set
+func (r *reorderBuffers) wrapBufferFactory(
+	createBufferFunc func(packetType packetio.BufferPacketType, ssrc uint32) io.ReadWriteCloser,
+) func(packetType packetio.BufferPacketType, ssrc uint32) io.ReadWriteCloser {
+	return func(packetType packetio.BufferPacketType, ssrc uint32) io.ReadWriteCloser {
+		writer := createBufferFunc(packetType, ssrc)
+		if packetType != packetio.RTPBufferPacket {
+			return writer
+		}
+		s := &reorderStream{
+			ReadWriteCloser: writer,
+			buffers:         r,
+			ssrc:            ssrc,
+			held:            make(map[uint16]heldPacket),
+		}
+		r.lock.Lock()
+		r.streams[ssrc] = s
+		r.lock.Unlock()
+		return s
+	}
+}
+
+type heldPacket struct {
+	data []byte
+	at   time.Time
+}
+
+type reorderStream struct {
+	io.ReadWriteCloser
+	buffers *reorderBuffers
+	ssrc    uint32
+
+	lock   sync.Mutex
+	params ReorderBufferParams
+	// sequence number of the next packet to be written, once one was
+	started bool
+	next    uint16
+	// sequence number => packet waiting for those before it
+	held map[uint16]heldPacket
+	// gives up on missing packets once the first packet held after them is too old
+	timer  *time.Timer
+	closed bool
+}
+
+func (s *reorderStream) Write(p []byte) (int, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.params.Delay <= 0 || len(p) < 12 {
+		return s.ReadWriteCloser.Write(p)
+	}
+
+	sn := binary.BigEndian.Uint16(p[2:4])
+	if !s.started {
+		s.started = true
+		s.next = sn
+	}
+	ahead := int(int16(sn - s.next))
+	switch {
+	case ahead < 0:
+		// late, or retransmitted
+		return s.ReadWriteCloser.Write(p)
+	case ahead == 0:
+		if _, err := s.ReadWriteCloser.Write(p); err != nil {
+			return 0, err
+		}
+		s.next++
+		s.release()
+	case ahead > s.params.MaxPackets:
+		// too far ahead to wait for those before it, the stream likely jumped
+		for len(s.held) > 0 {
+			s.skip()
+		}
+		s.next = sn + 1
+		if _, err := s.ReadWriteCloser.Write(p); err != nil {
+			return 0, err
+		}
+	default:
+		if _, ok := s.held[sn]; !ok {
+			data := make([]byte, len(p))
+			copy(data, p)
+			s.held[sn] = heldPacket{data: data, at: time.Now()}
+		}
+		if len(s.held) > s.params.MaxPackets {
+			s.skip()
+		}
+	}
+	s.schedule()
+	return len(p), nil
+}
+
+// release writes held packets that are next in sequence, needs to be called with lock held
+func (s *reorderStream) release() {
+	for {
+		pkt, ok := s.held[s.next]
+		if !ok {
+			return
+		}
+		delete(s.held, s.next)
+		s.next++
+		_, _ = s.ReadWriteCloser.Write(pkt.data)
+	}
+}
+
+// skip gives up on packets missing before the first one held, needs to be called with lock held
+func (s *reorderStream) skip() {
+	first, ok := s.firstHeld()
+	if !ok {
+		return
+	}
+	s.next = first
+	s.release()
+}
+
+// firstHeld returns the sequence number of the held packet next in sequence
+func (s *reorderStream) firstHeld() (uint16, bool) {
+	first, ok := uint16(0), false
+	for sn := range s.held {
+		if !ok || int16(sn-first) < 0 {
+			first, ok = sn, true
+		}
+	}
+	return first, ok
+}
+
+// oldestHeld returns when the earliest packet still held arrived
+func (s *reorderStream) oldestHeld() time.Time {
+	var oldest time.Time
+	for _, pkt := range s.held {
+		if oldest.IsZero() || pkt.at.Before(oldest) {
+			oldest = pkt.at
+		}
+	}
+	return oldest
+}
+
+// schedule arms the timer for the oldest held packet, needs to be called with lock held
+func (s *reorderStream) schedule() {
+	if len(s.held) == 0 || s.timer != nil || s.closed {
+		return
+	}
+	wait := s.params.Delay - time.Since(s.oldestHeld())
+	s.timer = time.AfterFunc(wait, s.expire)
+}
+
+func (s *reorderStream) expire() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.timer = nil
+	if s.closed {
+		return
+	}
+	for len(s.held) > 0 && time.Since(s.oldestHeld()) >= s.params.Delay {
+		s.skip()
+	}
+	s.schedule()
+}
+
+func (s *reorderStream) Close() error {
+	s.buffers.lock.Lock()
+	if s.buffers.streams[s.ssrc] == s {
+		delete(s.buffers.streams, s.ssrc)
+	}
+	s.buffers.lock.Unlock()
+
+	s.lock.Lock()
+	s.closed = true
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	s.held = make(map[uint16]heldPacket)
+	s.lock.Unlock()
+	return s.ReadWriteCloser.Close()
+}
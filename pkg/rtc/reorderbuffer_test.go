@@ -0,0 +1,113 @@
+package rtc
+
+import (
+	"encoding/binary"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/pion/transport/packetio"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+	livekit "github.com/livekit/livekit-server/proto"
+)
+
+type snRecorder struct {
+	lock sync.Mutex
+	sns  []uint16
+}
+
+func (r *snRecorder) Read([]byte) (int, error) { return 0, io.EOF }
+func (r *snRecorder) Close() error             { return nil }
+
+func (r *snRecorder) Write(p []byte) (int, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.sns = append(r.sns, binary.BigEndian.Uint16(p[2:4]))
+	return len(p), nil
+}
+
+func (r *snRecorder) written() []uint16 {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return append([]uint16{}, r.sns...)
+}
+
+func TestReorderBuffers(t *testing.T) {
+	conf := config.ReorderBufferConfig{
+		Enabled:     true,
+		Audio:       10 * time.Millisecond,
+		Camera:      20 * time.Millisecond,
+		ScreenShare: 100 * time.Millisecond,
+		MaxPackets:  3,
+	}
+	newStream := func(delay time.Duration) (io.Writer, *snRecorder) {
+		r := newReorderBuffers(conf)
+		recorder := &snRecorder{}
+		factory := r.wrapBufferFactory(func(packetio.BufferPacketType, uint32) io.ReadWriteCloser {
+			return recorder
+		})
+		w := factory(packetio.RTPBufferPacket, 1)
+		r.setStream(1, ReorderBufferParams{Delay: delay, MaxPackets: conf.MaxPackets})
+		return w, recorder
+	}
+	write := func(w io.Writer, sns ...uint16) {
+		for _, sn := range sns {
+			pkt := rtp.Packet{Header: rtp.Header{Version: 2, SequenceNumber: sn, SSRC: 1}, Payload: []byte{1}}
+			data, err := pkt.Marshal()
+			require.NoError(t, err)
+			_, err = w.Write(data)
+			require.NoError(t, err)
+		}
+	}
+
+	t.Run("params by source", func(t *testing.T) {
+		r := newReorderBuffers(conf)
+		require.Equal(t, 100*time.Millisecond, r.paramsFor(livekit.TrackType_VIDEO, types.TrackSourceScreenShare).Delay)
+		require.Equal(t, 20*time.Millisecond, r.paramsFor(livekit.TrackType_VIDEO, types.TrackSourceCamera).Delay)
+		require.Equal(t, 10*time.Millisecond, r.paramsFor(livekit.TrackType_AUDIO, types.TrackSourceMicrophone).Delay)
+		require.Nil(t, newReorderBuffers(config.ReorderBufferConfig{}))
+	})
+
+	t.Run("reorders packets", func(t *testing.T) {
+		w, recorder := newStream(time.Second)
+		write(w, 65534, 0, 65535, 2, 1)
+		require.Equal(t, []uint16{65534, 65535, 0, 1, 2}, recorder.written())
+		// given up on, written as it arrives
+		write(w, 65533)
+		require.Equal(t, []uint16{65534, 65535, 0, 1, 2, 65533}, recorder.written())
+	})
+
+	t.Run("gives up on missing packets after the delay", func(t *testing.T) {
+		w, recorder := newStream(20 * time.Millisecond)
+		write(w, 1, 3, 4)
+		require.Equal(t, []uint16{1}, recorder.written())
+		require.Eventually(t, func() bool {
+			return len(recorder.written()) == 3
+		}, time.Second, 5*time.Millisecond)
+		require.Equal(t, []uint16{1, 3, 4}, recorder.written())
+		write(w, 2, 5)
+		require.Equal(t, []uint16{1, 3, 4, 2, 5}, recorder.written())
+	})
+
+	t.Run("gives up on missing packets past max packets", func(t *testing.T) {
+		w, recorder := newStream(time.Second)
+		write(w, 1, 3, 4, 5)
+		require.Equal(t, []uint16{1}, recorder.written())
+		write(w, 6)
+		require.Equal(t, []uint16{1, 3, 4, 5, 6}, recorder.written())
+		// the stream jumps
+		write(w, 8, 100)
+		require.Equal(t, []uint16{1, 3, 4, 5, 6, 8, 100}, recorder.written())
+	})
+
+	t.Run("written through until set", func(t *testing.T) {
+		w, recorder := newStream(0)
+		write(w, 1, 3, 2)
+		require.Equal(t, []uint16{1, 3, 2}, recorder.written())
+	})
+}
@@ -28,9 +28,13 @@ type Room struct {
 	config     WebRTCConfig
 	iceServers []*livekit.ICEServer
 	lock       sync.RWMutex
+	// ICE servers with credentials of each participant, added to the ones of the room when set
+	participantICEServers func(identity string) []*livekit.ICEServer
 	// map of identity -> Participant
 	participants    map[string]types.Participant
 	participantOpts map[string]*ParticipantOptions
+	// map of identity -> track IDs the participant explicitly subscribed to
+	requestedTracks map[string]map[string]bool
 	bufferFactory   *buffer.Factory
 
 	// time the first participant joined the room
@@ -38,18 +42,70 @@ type Room struct {
 	// time that the last participant left the room
 	leftAt   atomic.Value
 	isClosed utils.AtomicFlag
+	// room level subscription policy, when set participants are never auto subscribed
+	manualSubscription utils.AtomicFlag
+	// who participants could send data packets to, open when empty
+	dataPolicy string
+	// participants that are hosts besides room admins
+	hosts config.HostsConfig
+	// identity of the first participant to join
+	firstParticipant string
+
+	// rooms are closed once they've been open for maxDuration, regardless of activity
+	maxDuration      time.Duration
+	maxDurationTimer *time.Timer
 
 	// for active speaker updates
 	audioConfig *config.AudioConfig
 
 	statsReporter *RoomStatsReporter
+	// runs RTCP and reporting work of participants
+	reportPool *WorkerPool
+	// records data packets exchanged in the room when set
+	dataRecorder *DataRecorder
+	// routes caption packets by language when set
+	captions *captionRouter
+	// set while a recorder is active in the room, recordingLock is held while participants are told it changed
+	recording     bool
+	recordingLock sync.Mutex
+	// limits the rate of data packets relayed when set
+	dataLimiter *dataRateLimiter
+	// participants are sampled into it while set
+	qualitySink *QualitySink
+	// mixes the loudest speakers for participants subscribing to mixed audio, when set
+	audioMixing *mixedAudio
+	// newly published tracks wait fanoutDelay before subscribers are added, once the room has at least
+	// fanoutMinParticipants
+	fanoutDelay           time.Duration
+	fanoutMinParticipants int
+
+	// subscriptions of participants that left are kept this long, in case they join again
+	subscriptionRestoreWindow time.Duration
+	// identity => subscriptions of a participant that left
+	departedSubscriptions map[string]*departedSubscriptions
+
+	// participant updates are held while bulk operations are applied, and broadcast together once they're done
+	batchLock sync.Mutex
+	batching  int
+	// sid => participant
+	pendingUpdates map[string]types.Participant
 
 	onParticipantChanged func(p types.Participant)
 	onClose              func()
+	onMaxDuration        func()
+
+	onParticipantStateChange func(p types.Participant, oldState livekit.ParticipantInfo_State)
+	onTrackPublishedHook     func(p types.Participant, track types.PublishedTrack)
 }
 
 type ParticipantOptions struct {
 	AutoSubscribe bool
+	// hosts aren't restricted by the room's data policy
+	Host bool
+	// preferred language of captions, empty for the room's default
+	Language string
+	// the room is being recorded while a recorder is active in it, recorders themselves aren't told about it
+	Recorder bool
 }
 
 func NewRoom(room *livekit.Room, config WebRTCConfig, iceServers []*livekit.ICEServer, audioConfig *config.AudioConfig) *Room {
@@ -61,8 +117,16 @@ func NewRoom(room *livekit.Room, config WebRTCConfig, iceServers []*livekit.ICES
 		statsReporter:   NewRoomStatsReporter(room.Name),
 		participants:    make(map[string]types.Participant),
 		participantOpts: make(map[string]*ParticipantOptions),
+		requestedTracks: make(map[string]map[string]bool),
 		bufferFactory:   buffer.NewBufferFactory(config.Receiver.packetBufferSize, logger.GetLogger()),
+
+		departedSubscriptions: make(map[string]*departedSubscriptions),
+	}
+	workers := config.ReportWorkers
+	if workers <= 0 {
+		workers = defaultReportWorkers
 	}
+	r.reportPool = NewWorkerPool(workers)
 	if r.Room.EmptyTimeout == 0 {
 		r.Room.EmptyTimeout = DefaultEmptyTimeout
 	}
@@ -80,6 +144,13 @@ func (r *Room) GetParticipant(identity string) types.Participant {
 	return r.participants[identity]
 }
 
+// IsFull returns true when the room has as many participants as it allows
+func (r *Room) IsFull() bool {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.Room.MaxParticipants > 0 && len(r.participants) >= int(r.Room.MaxParticipants)
+}
+
 func (r *Room) GetParticipants() []types.Participant {
 	r.lock.RLock()
 	defer r.lock.RUnlock()
@@ -119,6 +190,10 @@ func (r *Room) GetBufferFactor() *buffer.Factory {
 	return r.bufferFactory
 }
 
+func (r *Room) GetReportPool() *WorkerPool {
+	return r.reportPool
+}
+
 func (r *Room) FirstJoinedAt() int64 {
 	j := r.joinedAt.Load()
 	if t, ok := j.(int64); ok {
@@ -136,24 +211,41 @@ func (r *Room) LastLeftAt() int64 {
 }
 
 func (r *Room) Join(participant types.Participant, opts *ParticipantOptions) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	// checked with the lock held, so no one joins once StartClose returns
 	if r.isClosed.Get() {
 		return ErrRoomClosed
 	}
 
-	r.lock.Lock()
-	defer r.lock.Unlock()
+	if r.maxDuration > 0 && time.Since(r.createdAt()) >= r.maxDuration {
+		return ErrMaxDurationExceeded
+	}
 
 	if r.participants[participant.Identity()] != nil {
 		return ErrAlreadyJoined
 	}
 
-	if r.Room.MaxParticipants > 0 && int(r.Room.MaxParticipants) == len(r.participants) {
+	if r.Room.MaxParticipants > 0 && len(r.participants) >= int(r.Room.MaxParticipants) {
 		return ErrMaxParticipantsExceeded
 	}
 
 	if r.FirstJoinedAt() == 0 {
 		r.joinedAt.Store(time.Now().Unix())
 	}
+	if r.firstParticipant == "" {
+		r.firstParticipant = participant.Identity()
+	}
+	if r.grantsHost(participant.Identity()) && (opts == nil || !opts.Host) {
+		granted := ParticipantOptions{AutoSubscribe: true}
+		if opts != nil {
+			granted = *opts
+		}
+		granted.Host = true
+		opts = &granted
+		logger.Infow("participant is a host of the room", "room", r.Room.Name, "participant", participant.Identity())
+	}
 
 	r.statsReporter.AddParticipant()
 
@@ -165,16 +257,30 @@ func (r *Room) Join(participant types.Participant, opts *ParticipantOptions) err
 		if r.onParticipantChanged != nil {
 			r.onParticipantChanged(participant)
 		}
+		if r.onParticipantStateChange != nil {
+			r.onParticipantStateChange(p, oldState)
+		}
 		r.broadcastParticipantState(p, true)
 
+		if r.isRecorderParticipant(p.Identity()) {
+			r.updateRecording()
+		}
+
 		state := p.State()
 		if state == livekit.ParticipantInfo_ACTIVE {
 			if p.UpdateAfterActive() {
 				_ = p.SendParticipantUpdate(ToProtoParticipants(r.GetParticipants()))
 			}
 
+			// names aren't part of ParticipantInfo, those of others are sent once it could receive data
+			r.sendParticipantNames(p)
+			r.sendHosts(p)
+			r.sendConnectionQualities(p)
+
 			// subscribe participant to existing publishedTracks
+			r.restoreSubscriptions(p)
 			r.subscribeToExistingTracks(p)
+			r.sendMixedAudio(p)
 
 			// start the workers once connectivity is established
 			p.Start()
@@ -184,9 +290,16 @@ func (r *Room) Join(participant types.Participant, opts *ParticipantOptions) err
 			go r.RemoveParticipant(p.Identity())
 		}
 	})
+	participant.OnInterruptionChange(r.onInterruptionChange)
+	participant.OnReconnectingChange(r.onParticipantReconnectingChange)
 	participant.OnTrackUpdated(r.onTrackUpdated)
+	participant.OnFirstMediaReceived(r.onFirstMediaReceived)
 	participant.OnMetadataUpdate(r.onParticipantMetadataUpdate)
+	participant.OnNameUpdate(r.onParticipantNameUpdate)
+	participant.OnConnectionQualityChange(r.onConnectionQualityChange)
 	participant.OnDataPacket(r.onDataPacket)
+	participant.OnPublisherCongested(r.onPublisherCongested)
+	participant.OnSpeaking(r.onSpeaking)
 	logger.Infow("new participant joined",
 		"id", participant.ID(),
 		"participant", participant.Identity(),
@@ -210,37 +323,84 @@ func (r *Room) Join(participant types.Participant, opts *ParticipantOptions) err
 	time.AfterFunc(time.Minute, func() {
 		state := participant.State()
 		if state == livekit.ParticipantInfo_JOINING || state == livekit.ParticipantInfo_JOINED {
+			participant.SetDisconnectReason(types.DisconnectReasonJoinTimeout)
 			r.RemoveParticipant(participant.Identity())
 		}
 	})
 
-	return participant.SendJoinResponse(r.Room, otherParticipants, r.iceServers)
+	recording := r.recording && !r.isRecorder(participant.Identity())
+	iceServers := r.iceServers
+	if r.participantICEServers != nil {
+		iceServers = append(append([]*livekit.ICEServer(nil), iceServers...), r.participantICEServers(participant.Identity())...)
+	}
+	return participant.SendJoinResponse(r.Room, otherParticipants, iceServers, recording)
 }
 
 func (r *Room) RemoveParticipant(identity string) {
+	_ = r.removeParticipant(identity)
+}
+
+// removeParticipant returns the error of closing the participant, it's removed from the room regardless
+func (r *Room) removeParticipant(identity string) error {
 	r.lock.Lock()
 	p, ok := r.participants[identity]
+	wasRecorder := r.isRecorder(identity)
+	wasHost := r.isHost(identity)
+	var newHost types.Participant
+	var requested map[string]bool
 	if ok {
+		requested = r.requestedTracks[identity]
 		delete(r.participants, identity)
 		delete(r.participantOpts, identity)
+		delete(r.requestedTracks, identity)
+		if r.captions != nil {
+			r.captions.removeSource(p.ID())
+		}
+		if r.dataLimiter != nil {
+			r.dataLimiter.removeParticipant(p.ID())
+		}
+		if wasHost && r.hosts.Transfer {
+			newHost = r.transferHostLocked()
+		}
 	}
 	r.lock.Unlock()
 	if !ok {
-		return
+		return nil
 	}
 	r.statsReporter.SubParticipant()
+	r.keepSubscriptions(p, requested)
+	if r.audioMixing != nil && p.SubscribesToMixedAudio() {
+		r.audioMixing.removeSubscriber(p)
+	}
+	if wasRecorder {
+		r.updateRecording()
+	}
+	if newHost != nil {
+		logger.Infow("host left, transferred to participant", "room", r.Room.Name,
+			"participant", newHost.Identity(),
+			"previousHost", identity)
+		r.broadcastHosts()
+	}
 
 	// send broadcast only if it's not already closed
-	sendUpdates := p.State() != livekit.ParticipantInfo_DISCONNECTED
+	oldState := p.State()
+	sendUpdates := oldState != livekit.ParticipantInfo_DISCONNECTED
 
 	p.OnTrackUpdated(nil)
 	p.OnTrackPublished(nil)
+	p.OnFirstMediaReceived(nil)
 	p.OnStateChange(nil)
+	p.OnInterruptionChange(nil)
+	p.OnReconnectingChange(nil)
 	p.OnMetadataUpdate(nil)
+	p.OnNameUpdate(nil)
+	p.OnConnectionQualityChange(nil)
 	p.OnDataPacket(nil)
+	p.OnPublisherCongested(nil)
+	p.OnSpeaking(nil)
 
 	// close participant as well
-	_ = p.Close()
+	err := p.Close()
 
 	r.lock.RLock()
 	if len(r.participants) == 0 {
@@ -252,8 +412,13 @@ func (r *Room) RemoveParticipant(identity string) {
 		if r.onParticipantChanged != nil {
 			r.onParticipantChanged(p)
 		}
+		// state change callbacks of the participant have been cleared before closing it
+		if r.onParticipantStateChange != nil {
+			r.onParticipantStateChange(p, oldState)
+		}
 		r.broadcastParticipantState(p, true)
 	}
+	return err
 }
 
 func (r *Room) UpdateSubscriptions(participant types.Participant, trackIds []string, subscribe bool) error {
@@ -261,30 +426,93 @@ func (r *Room) UpdateSubscriptions(participant types.Participant, trackIds []str
 		return ErrCannotSubscribe
 	}
 
-	// find all matching tracks
+	// find all matching tracks, and who published them
 	var tracks []types.PublishedTrack
+	var publishers []types.Participant
 	participants := r.GetParticipants()
 	for _, p := range participants {
 		for _, sid := range trackIds {
 			for _, track := range p.GetPublishedTracks() {
 				if sid == track.ID() {
 					tracks = append(tracks, track)
+					publishers = append(publishers, p)
 				}
 			}
 		}
 	}
 
-	// handle subscription changes
+	// handle subscription changes, only tracks requested are subscribed to. a track being rejected, e.g. over the
+	// download budget or the node's max subscriptions, doesn't stop others from being subscribed to
+	errs := &SubscriptionErrors{}
+	for i, track := range tracks {
+		var err error
+		if subscribe {
+			err = publishers[i].SubscribeToTrack(participant, track.ID())
+		} else {
+			err = publishers[i].UnsubscribeFromTrack(participant, track.ID())
+		}
+		if err != nil && err != ErrTrackNotPublished {
+			errs.add(track.ID(), err)
+		}
+	}
+
+	r.lock.Lock()
+	requested := r.requestedTracks[participant.Identity()]
+	if requested == nil {
+		requested = make(map[string]bool)
+		r.requestedTracks[participant.Identity()] = requested
+	}
 	for _, track := range tracks {
 		if subscribe {
-			if err := track.AddSubscriber(participant); err != nil {
-				return err
-			}
+			requested[track.ID()] = true
 		} else {
-			track.RemoveSubscriber(participant.ID())
+			delete(requested, track.ID())
+		}
+	}
+	var resumed []types.SubscribedTrack
+	if subscribe {
+		// it could've been paused when the room stopped auto subscribing
+		for _, st := range participant.GetSubscribedTracks() {
+			if requested[st.ID()] && st.IsPaused() {
+				resumed = append(resumed, st)
+			}
+		}
+	}
+	r.lock.Unlock()
+
+	for _, st := range resumed {
+		st.SetPaused(false)
+	}
+	return errs.errorOrNil()
+}
+
+// SetAutoSubscribe changes the subscription policy of the room, and applies it to existing participants.
+// Subscriptions are updated incrementally: when disabled, subscriptions participants haven't explicitly requested are
+// paused instead of removed. When enabled, paused subscriptions are resumed and missing ones are added
+func (r *Room) SetAutoSubscribe(autoSubscribe bool) {
+	if !r.manualSubscription.TrySet(!autoSubscribe) {
+		return
+	}
+	logger.Infow("updating subscription policy", "room", r.Room.Name, "autoSubscribe", autoSubscribe)
+
+	for _, p := range r.GetParticipants() {
+		r.lock.RLock()
+		requested := r.requestedTracks[p.Identity()]
+		autoSubscribed := make([]types.SubscribedTrack, 0)
+		for _, st := range p.GetSubscribedTracks() {
+			if !requested[st.ID()] {
+				autoSubscribed = append(autoSubscribed, st)
+			}
+		}
+		r.lock.RUnlock()
+
+		for _, st := range autoSubscribed {
+			st.SetPaused(!autoSubscribe)
+		}
+		if autoSubscribe && p.State() == livekit.ParticipantInfo_ACTIVE {
+			r.subscribeToExistingTracks(p)
 		}
 	}
-	return nil
 }
 
 // CloseIfEmpty closes the room if all participants had left, or it's still empty past timeout
@@ -318,18 +546,260 @@ func (r *Room) CloseIfEmpty() {
 	}
 }
 
+// SetMaxDuration closes the room once it's been open for maxDuration since creation.
+// CreationTime is persisted with the room, so the limit still applies when the room is loaded after a restart
+func (r *Room) SetMaxDuration(maxDuration time.Duration) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.maxDurationTimer != nil {
+		r.maxDurationTimer.Stop()
+	}
+	r.maxDuration = maxDuration
+	remaining := time.Until(r.createdAt().Add(maxDuration))
+	r.maxDurationTimer = time.AfterFunc(remaining, func() {
+		logger.Infow("room has reached max duration", "room", r.Room.Name, "maxDuration", maxDuration)
+		r.lock.RLock()
+		onMaxDuration := r.onMaxDuration
+		r.lock.RUnlock()
+		if onMaxDuration != nil {
+			onMaxDuration()
+		} else {
+			r.CloseWithParticipants(RoomClosedMaxDuration)
+		}
+	})
+}
+
+// SetDataPolicy changes who participants could send data packets to, one of the config.DataPolicy values.
+// Packets that aren't allowed by it are dropped
+func (r *Room) SetDataPolicy(policy string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.dataPolicy != policy {
+		logger.Infow("updating data policy", "room", r.Room.Name, "policy", policy)
+	}
+	r.dataPolicy = policy
+}
+
+func (r *Room) DataPolicy() string {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	if r.dataPolicy == "" {
+		return config.DataPolicyOpen
+	}
+	return r.dataPolicy
+}
+
+// SetParticipantICEServers adds ICE servers with credentials of their own to the join response of each participant,
+// like TURN servers with time-limited credentials
+func (r *Room) SetParticipantICEServers(f func(identity string) []*livekit.ICEServer) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.participantICEServers = f
+}
+
+// SetHosts changes which participants are hosts besides room admins, it applies to participants joining after
+func (r *Room) SetHosts(conf config.HostsConfig) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.hosts = conf
+}
+
+// Hosts returns the identities of the hosts in the room
+func (r *Room) Hosts() []string {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	var hosts []string
+	for identity, opts := range r.participantOpts {
+		if opts != nil && opts.Host {
+			hosts = append(hosts, identity)
+		}
+	}
+	sort.Strings(hosts)
+	return hosts
+}
+
+// SetCaptions enables routing of caption packets by the preferred language of each subscriber
+func (r *Room) SetCaptions(conf config.CaptionsConfig) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if !conf.Enabled {
+		r.captions = nil
+		return
+	}
+	r.captions = newCaptionRouter(conf.DefaultLanguage)
+}
+
+// SetAudioMixing mixes the maxSpeakers loudest speakers of the room into a single track, sent to participants
+// subscribing to mixed audio instead of a track per speaker. It has to be set before participants join
+func (r *Room) SetAudioMixing(codec AudioCodec, maxSpeakers int) error {
+	mixing, err := newMixedAudio(codec, maxSpeakers)
+	if err != nil {
+		return err
+	}
+	r.audioMixing = mixing
+	go mixing.mixWorker()
+	return nil
+}
+
+// MixesAudio is true when participants could subscribe to mixed audio
+func (r *Room) MixesAudio() bool {
+	return r.audioMixing != nil
+}
+
+// sendMixedAudio sends the mixed track to p when it subscribes to mixed audio
+func (r *Room) sendMixedAudio(p types.Participant) {
+	if r.audioMixing == nil || !p.SubscribesToMixedAudio() || !p.CanSubscribe() {
+		return
+	}
+	if err := r.audioMixing.addSubscriber(p); err != nil {
+		logger.Errorw("could not send mixed audio", err,
+			"room", r.Room.Name,
+			"participant", p.Identity())
+	}
+}
+
+// SetDataRateLimit limits the rate of data packets relayed, per participant and for the whole room.
+// Packets over the limit are dropped, and their sender is told once each time it starts exceeding it
+func (r *Room) SetDataRateLimit(conf config.DataRateLimitConfig) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if !conf.Enabled() {
+		r.dataLimiter = nil
+		return
+	}
+	r.dataLimiter = newDataRateLimiter(conf)
+}
+
+// SetFanoutDelay delays adding subscribers to newly published tracks once the room has minParticipants, so their
+// subscriptions and the keyframes they request are coalesced. 0 to disable
+func (r *Room) SetFanoutDelay(delay time.Duration, minParticipants int) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.fanoutDelay = delay
+	r.fanoutMinParticipants = minParticipants
+}
+
+// SetQualitySink samples loss and jitter of each participant into sink, until the room is closed
+func (r *Room) SetQualitySink(sink *QualitySink) {
+	r.lock.Lock()
+	started := r.qualitySink != nil
+	r.qualitySink = sink
+	r.lock.Unlock()
+	if sink != nil && !started {
+		r.reportPool.Every(sink.Interval(), r.sampleQuality)
+	}
+}
+
+// sampleQuality runs periodically on the report pool, returns false once the room is closed or sampling stopped
+func (r *Room) sampleQuality() bool {
+	r.lock.RLock()
+	sink := r.qualitySink
+	r.lock.RUnlock()
+	if sink == nil || r.isClosed.Get() {
+		return false
+	}
+
+	now := time.Now()
+	// reports are sent every few seconds, older ones are from subscriptions that ended or stalled
+	maxReportAge := 2 * sink.Interval()
+	for _, p := range r.GetParticipants() {
+		for _, sample := range sampleParticipantQuality(r.Room.Name, p, maxReportAge, now) {
+			sink.Emit(sample)
+		}
+	}
+	return true
+}
+
+// SetPreferredLanguage changes the language of captions a participant receives, returns false when it isn't
+// in the room
+func (r *Room) SetPreferredLanguage(identity, language string) bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	opts := r.participantOpts[identity]
+	if opts == nil {
+		return false
+	}
+	opts.Language = language
+	return true
+}
+
+// SetDataRecorder records data packets published by participants for the rest of the room's lifetime,
+// the recorder is closed with the room
+func (r *Room) SetDataRecorder(recorder *DataRecorder) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.dataRecorder = recorder
+}
+
 func (r *Room) Close() {
 	if !r.isClosed.TrySet(true) {
 		return
 	}
+	r.close()
+}
+
+func (r *Room) close() {
 	logger.Infow("closing room", "room", r.Room.Sid, "name", r.Room.Name)
 
+	r.lock.Lock()
+	if r.maxDurationTimer != nil {
+		r.maxDurationTimer.Stop()
+	}
+	r.forgetDepartedSubscriptionsLocked()
+	dataRecorder := r.dataRecorder
+	r.lock.Unlock()
+
+	if r.audioMixing != nil {
+		r.audioMixing.close()
+	}
+
+	if dataRecorder != nil {
+		if err := dataRecorder.Close(); err != nil {
+			logger.Warnw("could not close data recording", err, "room", r.Room.Name)
+		}
+	}
 	r.statsReporter.RoomEnded()
+	r.reportPool.Close()
 	if r.onClose != nil {
 		r.onClose()
 	}
 }
 
+// CloseWithParticipants closes the room and disconnects everyone, participants are told the reason before they
+// receive a leave without being able to reconnect. No one could join from the moment it's called
+func (r *Room) CloseWithParticipants(reason string) {
+	if r.StartClose() {
+		r.FinishClose(reason)
+	}
+}
+
+// StartClose stops participants from joining the room, it returns false when it's already closing. The room is
+// closed with FinishClose
+func (r *Room) StartClose() bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.isClosed.TrySet(true)
+}
+
+// FinishClose disconnects everyone in a room StartClose was called on as CloseWithParticipants does, and closes it
+func (r *Room) FinishClose(reason string) {
+	logger.Infow("disconnecting participants", "room", r.Room.Name, "reason", reason)
+	dp := newSignalPacket(roomClosedSignal{Type: roomClosedPacketType, Reason: reason})
+	for _, p := range r.GetParticipants() {
+		if p.ProtocolVersion().HandlesDataPackets() {
+			_ = p.SendDataPacket(dp)
+		}
+		p.SetDisconnectReason(types.DisconnectReasonRoomClosed)
+		_ = p.Close()
+	}
+	r.close()
+}
+
+func (r *Room) createdAt() time.Time {
+	return time.Unix(r.Room.CreationTime, 0)
+}
+
 func (r *Room) GetIncomingStats() PacketStats {
 	return *r.statsReporter.incoming
 }
@@ -342,13 +812,31 @@ func (r *Room) OnClose(f func()) {
 	r.onClose = f
 }
 
+// OnMaxDuration is called once the room reaches its max duration, instead of the room closing itself
+func (r *Room) OnMaxDuration(f func()) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.onMaxDuration = f
+}
+
 func (r *Room) OnParticipantChanged(f func(participant types.Participant)) {
 	r.onParticipantChanged = f
 }
 
+// OnParticipantStateChange is called after a participant of the room changes state, including when it's closed
+// as it's removed
+func (r *Room) OnParticipantStateChange(f func(participant types.Participant, oldState livekit.ParticipantInfo_State)) {
+	r.onParticipantStateChange = f
+}
+
+// OnTrackPublished is called after a participant of the room publishes a track
+func (r *Room) OnTrackPublished(f func(participant types.Participant, track types.PublishedTrack)) {
+	r.onTrackPublishedHook = f
+}
+
 // checks if participant should be autosubscribed to new tracks, assumes lock is already acquired
 func (r *Room) autoSubscribe(participant types.Participant) bool {
-	if !participant.CanSubscribe() {
+	if !participant.CanSubscribe() || r.manualSubscription.Get() {
 		return false
 	}
 
@@ -365,6 +853,39 @@ func (r *Room) onTrackPublished(participant types.Participant, track types.Publi
 	// publish participant update, since track state is changed
 	r.broadcastParticipantState(participant, true)
 
+	if r.audioMixing != nil && track.Kind() == livekit.TrackType_AUDIO {
+		r.audioMixing.addTrack(participant, track)
+	}
+
+	r.lock.RLock()
+	delay := r.fanoutDelay
+	if len(r.participants) < r.fanoutMinParticipants {
+		delay = 0
+	}
+	r.lock.RUnlock()
+
+	if delay > 0 {
+		// participants becoming active meanwhile subscribe to it right away, like to any other existing track
+		time.AfterFunc(delay, func() {
+			if r.isClosed.Get() || r.GetParticipant(participant.Identity()) != participant || !isPublished(participant, track) {
+				return
+			}
+			r.subscribeToNewTrack(participant, track)
+		})
+	} else {
+		r.subscribeToNewTrack(participant, track)
+	}
+
+	if r.onParticipantChanged != nil {
+		r.onParticipantChanged(participant)
+	}
+	if r.onTrackPublishedHook != nil {
+		r.onTrackPublishedHook(participant, track)
+	}
+}
+
+// subscribeToNewTrack subscribes active participants to a track published by participant
+func (r *Room) subscribeToNewTrack(participant types.Participant, track types.PublishedTrack) {
 	r.lock.RLock()
 	defer r.lock.RUnlock()
 
@@ -394,18 +915,83 @@ func (r *Room) onTrackPublished(participant types.Participant, track types.Publi
 				"dest", existingParticipant.Identity())
 		}
 	}
+}
 
-	if r.onParticipantChanged != nil {
-		r.onParticipantChanged(participant)
+func isPublished(participant types.Participant, track types.PublishedTrack) bool {
+	for _, t := range participant.GetPublishedTracks() {
+		if t == track {
+			return true
+		}
 	}
+	return false
 }
 
-func (r *Room) onTrackUpdated(p types.Participant, _ types.PublishedTrack) {
+func (r *Room) onTrackUpdated(p types.Participant, track types.PublishedTrack) {
 	// send track updates to everyone, especially if track was updated by admin
 	r.broadcastParticipantState(p, false)
 	if r.onParticipantChanged != nil {
 		r.onParticipantChanged(p)
 	}
+	r.sendTrackLayers(p, track)
+}
+
+// sendTrackLayers tells subscribers of a video track how its layers are encoded, once the publisher described them
+func (r *Room) sendTrackLayers(p types.Participant, track types.PublishedTrack) {
+	if track == nil || len(track.Layers()) == 0 {
+		return
+	}
+	dp := newSignalPacket(newTrackLayersSignal(p.ID(), track))
+	for _, op := range r.GetParticipants() {
+		if op.State() != livekit.ParticipantInfo_ACTIVE || !track.IsSubscriber(op.ID()) {
+			continue
+		}
+		if err := op.SendDataPacket(dp); err != nil {
+			logger.Debugw("could not send track layers", "error", err,
+				"participant", op.Identity(),
+				"track", track.ID())
+		}
+	}
+}
+
+func (r *Room) onInterruptionChange(p types.Participant) {
+	if r.onParticipantChanged != nil {
+		r.onParticipantChanged(p)
+	}
+}
+
+// onFirstMediaReceived tells everyone in the room media of a track started flowing, so clients can stop showing it
+// as loading
+func (r *Room) onFirstMediaReceived(p types.Participant, track types.PublishedTrack) {
+	logger.Debugw("received first media of track",
+		"room", r.Room.Name,
+		"participant", p.Identity(),
+		"track", track.ID(),
+		"kind", track.Kind().String())
+	dp := newSignalPacket(firstMediaSignal{Type: firstMediaPacketType, ParticipantSid: p.ID(), TrackSid: track.ID()})
+	for _, op := range r.GetParticipants() {
+		if op.State() != livekit.ParticipantInfo_ACTIVE || !op.ProtocolVersion().HandlesDataPackets() {
+			continue
+		}
+		if err := op.SendDataPacket(dp); err != nil {
+			logger.Debugw("could not send first media", "error", err,
+				"participant", op.Identity(),
+				"track", track.ID())
+		}
+	}
+}
+
+func (r *Room) onPublisherCongested(p types.Participant, congested bool) {
+	logger.Infow("publisher congestion changed",
+		"room", r.Room.Name,
+		"participant", p.Identity(),
+		"congested", congested)
+}
+
+func (r *Room) onSpeaking(p types.Participant, speaking bool) {
+	logger.Debugw("participant speaking changed",
+		"room", r.Room.Name,
+		"participant", p.Identity(),
+		"speaking", speaking)
 }
 
 func (r *Room) onParticipantMetadataUpdate(p types.Participant) {
@@ -415,9 +1001,127 @@ func (r *Room) onParticipantMetadataUpdate(p types.Participant) {
 	}
 }
 
+func (r *Room) onParticipantNameUpdate(p types.Participant) {
+	dp := newSignalPacket(newParticipantNameSignal(p))
+	for _, op := range r.GetParticipants() {
+		if op.ID() == p.ID() || op.State() != livekit.ParticipantInfo_ACTIVE {
+			continue
+		}
+		if err := op.SendDataPacket(dp); err != nil {
+			logger.Debugw("could not send participant name", "error", err,
+				"participant", op.Identity(),
+				"renamed", p.Identity())
+		}
+	}
+}
+
+func (r *Room) onParticipantReconnectingChange(p types.Participant) {
+	dp := newSignalPacket(newParticipantReconnectingSignal(p))
+	for _, op := range r.GetParticipants() {
+		if op.ID() == p.ID() || op.State() != livekit.ParticipantInfo_ACTIVE {
+			continue
+		}
+		if err := op.SendDataPacket(dp); err != nil {
+			logger.Debugw("could not send participant reconnecting", "error", err,
+				"participant", op.Identity(),
+				"reconnecting", p.Identity())
+		}
+	}
+}
+
+func (r *Room) onConnectionQualityChange(p types.Participant, quality types.ConnectionQuality) {
+	dp := newSignalPacket(newConnectionQualitySignal(p, quality))
+	for _, op := range r.GetParticipants() {
+		if op.State() != livekit.ParticipantInfo_ACTIVE {
+			continue
+		}
+		if err := op.SendDataPacket(dp); err != nil {
+			logger.Debugw("could not send connection quality", "error", err,
+				"participant", op.Identity(),
+				"rated", p.Identity())
+		}
+	}
+}
+
+// sendConnectionQualities sends p the connection quality of others that aren't excellent
+func (r *Room) sendConnectionQualities(p types.Participant) {
+	for _, op := range r.GetParticipants() {
+		quality := op.ConnectionQuality()
+		if op.ID() == p.ID() || quality == types.ConnectionQualityExcellent {
+			continue
+		}
+		if err := p.SendDataPacket(newSignalPacket(newConnectionQualitySignal(op, quality))); err != nil {
+			logger.Debugw("could not send connection quality", "error", err,
+				"participant", p.Identity(),
+				"rated", op.Identity())
+			return
+		}
+	}
+}
+
+// sendParticipantNames sends the names of other participants that have one to p
+func (r *Room) sendParticipantNames(p types.Participant) {
+	for _, op := range r.GetParticipants() {
+		if op.ID() == p.ID() || op.Name() == "" {
+			continue
+		}
+		if err := p.SendDataPacket(newSignalPacket(newParticipantNameSignal(op))); err != nil {
+			logger.Debugw("could not send participant name", "error", err,
+				"participant", p.Identity(),
+				"renamed", op.Identity())
+			return
+		}
+	}
+}
+
 func (r *Room) onDataPacket(source types.Participant, dp *livekit.DataPacket) {
 	dest := dp.GetUser().GetDestinationSids()
 
+	policy := r.DataPolicy()
+	r.lock.RLock()
+	dataRecorder := r.dataRecorder
+	captions := r.captions
+	dataLimiter := r.dataLimiter
+	sourceIsHost := r.isHost(source.Identity())
+	r.lock.RUnlock()
+
+	if policy == config.DataPolicyHostOnly && !sourceIsHost {
+		logger.Debugw("dropping data packet not allowed by data policy",
+			"source", source.Identity(), "policy", policy)
+		return
+	}
+
+	now := time.Now()
+	if dataLimiter != nil {
+		if ok, scope, notify := dataLimiter.allow(source.ID(), dp.Kind, now); !ok {
+			dataDroppedTotal.WithLabelValues(dataPacketKindName(dp.Kind), scope).Add(1)
+			if notify {
+				logger.Infow("dropping data packets over the rate limit",
+					"room", r.Room.Name, "source", source.Identity(), "kind", dp.Kind, "scope", scope)
+				if err := source.SendDataPacket(newSignalPacket(dataRateLimitedSignal{
+					Type:  dataRateLimitedPacketType,
+					Kind:  dataPacketKindName(dp.Kind),
+					Scope: scope,
+				})); err != nil {
+					logger.Debugw("could not send data rate limited packet", "error", err,
+						"participant", source.Identity())
+				}
+			}
+			return
+		}
+	}
+
+	if dataRecorder != nil {
+		dataRecorder.Record(source, dp)
+	}
+
+	language, isCaption := "", false
+	if captions != nil {
+		if language, isCaption = captionLanguage(dp); isCaption {
+			captions.observe(source.ID(), language, now)
+		}
+	}
+
 	for _, op := range r.GetParticipants() {
 		if op.State() != livekit.ParticipantInfo_ACTIVE {
 			continue
@@ -425,6 +1129,14 @@ func (r *Room) onDataPacket(source types.Participant, dp *livekit.DataPacket) {
 		if op.ID() == source.ID() {
 			continue
 		}
+		if policy == config.DataPolicyModerated && !sourceIsHost {
+			r.lock.RLock()
+			destIsHost := r.isHost(op.Identity())
+			r.lock.RUnlock()
+			if !destIsHost {
+				continue
+			}
+		}
 		if len(dest) > 0 {
 			found := false
 			for _, dSid := range dest {
@@ -437,10 +1149,158 @@ func (r *Room) onDataPacket(source types.Participant, dp *livekit.DataPacket) {
 				continue
 			}
 		}
-		_ = op.SendDataPacket(dp)
+		if isCaption {
+			r.lock.RLock()
+			preferred := r.preferredLanguage(op.Identity())
+			r.lock.RUnlock()
+			if !captions.shouldForward(source.ID(), language, preferred, now) {
+				continue
+			}
+		}
+		if err := op.SendDataPacket(dp); err != nil {
+			logger.Debugw("could not send data packet", "error", err,
+				"source", source.Identity(), "dest", op.Identity())
+		}
 	}
 }
 
+// IsRecording returns true while a recorder is active in the room
+func (r *Room) IsRecording() bool {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.recording
+}
+
+func (r *Room) isRecorderParticipant(identity string) bool {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.isRecorder(identity)
+}
+
+// updateRecording tells participants when the room starts or stops being recorded, it's recorded while a recorder
+// is active in it rather than whenever one is configured
+func (r *Room) updateRecording() {
+	r.recordingLock.Lock()
+	defer r.recordingLock.Unlock()
+
+	r.lock.Lock()
+	recording := false
+	for identity, p := range r.participants {
+		if r.isRecorder(identity) && p.State() == livekit.ParticipantInfo_ACTIVE {
+			recording = true
+			break
+		}
+	}
+	if recording == r.recording {
+		r.lock.Unlock()
+		return
+	}
+	r.recording = recording
+	participants := make([]types.Participant, 0, len(r.participants))
+	for identity, p := range r.participants {
+		if !r.isRecorder(identity) {
+			participants = append(participants, p)
+		}
+	}
+	r.lock.Unlock()
+
+	logger.Infow("recording status changed", "room", r.Room.Name, "recording", recording)
+	for _, p := range participants {
+		p.SetRecording(recording)
+	}
+}
+
+// needs to be called with lock held
+func (r *Room) isRecorder(identity string) bool {
+	opts := r.participantOpts[identity]
+	return opts != nil && opts.Recorder
+}
+
+// needs to be called with lock held
+func (r *Room) isHost(identity string) bool {
+	opts := r.participantOpts[identity]
+	return opts != nil && opts.Host
+}
+
+// grantsHost returns true when a participant joining is a host by the room's hosts config.
+// needs to be called with lock held
+func (r *Room) grantsHost(identity string) bool {
+	if r.hosts.FirstParticipant && identity == r.firstParticipant {
+		return true
+	}
+	if !r.hosts.Transfer {
+		return false
+	}
+	for _, opts := range r.participantOpts {
+		if opts != nil && opts.Host {
+			return false
+		}
+	}
+	return true
+}
+
+// transferHostLocked makes the participant in the room the longest a host, unless there is one already.
+// needs to be called with lock held
+func (r *Room) transferHostLocked() types.Participant {
+	var next types.Participant
+	for identity, p := range r.participants {
+		if r.isHost(identity) {
+			return nil
+		}
+		if next == nil || p.ConnectedAt().Before(next.ConnectedAt()) {
+			next = p
+		}
+	}
+	if next == nil {
+		return nil
+	}
+	opts := ParticipantOptions{AutoSubscribe: true}
+	if current := r.participantOpts[next.Identity()]; current != nil {
+		opts = *current
+	}
+	opts.Host = true
+	r.participantOpts[next.Identity()] = &opts
+	return next
+}
+
+// sendHosts tells p who the hosts are once it's active, or everyone when p is one of them
+func (r *Room) sendHosts(p types.Participant) {
+	r.lock.RLock()
+	enabled := r.hosts.Enabled()
+	isHost := r.isHost(p.Identity())
+	r.lock.RUnlock()
+	if !enabled {
+		return
+	}
+	if isHost {
+		r.broadcastHosts()
+		return
+	}
+	if err := p.SendDataPacket(newSignalPacket(newHostsSignal(r.Hosts()))); err != nil {
+		logger.Debugw("could not send hosts", "error", err, "participant", p.Identity())
+	}
+}
+
+func (r *Room) broadcastHosts() {
+	dp := newSignalPacket(newHostsSignal(r.Hosts()))
+	for _, p := range r.GetParticipants() {
+		if p.State() != livekit.ParticipantInfo_ACTIVE {
+			continue
+		}
+		if err := p.SendDataPacket(dp); err != nil {
+			logger.Debugw("could not send hosts", "error", err, "participant", p.Identity())
+		}
+	}
+}
+
+// needs to be called with lock held
+func (r *Room) preferredLanguage(identity string) string {
+	if opts := r.participantOpts[identity]; opts != nil {
+		return opts.Language
+	}
+	return ""
+}
+
 func (r *Room) subscribeToExistingTracks(p types.Participant) {
 	r.lock.RLock()
 	shouldSubscribe := r.autoSubscribe(p)
@@ -471,6 +1331,9 @@ func (r *Room) subscribeToExistingTracks(p types.Participant) {
 
 // broadcast an update about participant p
 func (r *Room) broadcastParticipantState(p types.Participant, skipSource bool) {
+	if r.holdUpdate(p) {
+		return
+	}
 	updates := ToProtoParticipants([]types.Participant{p})
 	participants := r.GetParticipants()
 	for _, op := range participants {
@@ -575,6 +1438,9 @@ func (r *Room) audioUpdateWorker() {
 		}
 
 		lastActiveSpeakers = speakers
+		if r.audioMixing != nil {
+			r.audioMixing.setSpeakers(speakers)
+		}
 
 		time.Sleep(time.Duration(r.audioConfig.UpdateInterval) * time.Millisecond)
 	}
@@ -593,6 +1459,7 @@ func (r *Room) DebugInfo() map[string]interface{} {
 		participantInfo[p.Identity()] = p.DebugInfo()
 	}
 	info["Participants"] = participantInfo
+	info["ReportPool"] = r.reportPool.Stats()
 
 	return info
 }
@@ -1,12 +1,18 @@
 package rtc_test
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/livekit/livekit-server/pkg/config"
 	"github.com/livekit/livekit-server/pkg/testutils"
+	"github.com/livekit/protocol/utils"
 	"github.com/stretchr/testify/require"
 
 	"github.com/livekit/livekit-server/pkg/logger"
@@ -64,11 +70,12 @@ func TestRoomJoin(t *testing.T) {
 		rm.Join(pNew, nil)
 
 		// expect new participant to get a JoinReply
-		info, participants, iceServers := pNew.SendJoinResponseArgsForCall(0)
+		info, participants, iceServers, recording := pNew.SendJoinResponseArgsForCall(0)
 		require.Equal(t, info.Sid, rm.Room.Sid)
 		require.Len(t, participants, numParticipants)
 		require.Len(t, rm.GetParticipants(), numParticipants+1)
 		require.NotEmpty(t, iceServers)
+		require.False(t, recording)
 	})
 
 	t.Run("subscribe to existing channels upon join", func(t *testing.T) {
@@ -130,12 +137,76 @@ func TestRoomJoin(t *testing.T) {
 		rm.Room.MaxParticipants = 1
 		p := newMockParticipant("second", types.ProtocolVersion(0))
 
+		require.True(t, rm.IsFull())
 		err := rm.Join(p, nil)
 		require.Equal(t, rtc.ErrMaxParticipantsExceeded, err)
+
+		// lowered below the number of participants already in the room
+		rm = newRoomWithParticipants(t, testRoomOpts{num: 2})
+		rm.Room.MaxParticipants = 1
+		require.True(t, rm.IsFull())
+		require.Equal(t, rtc.ErrMaxParticipantsExceeded, rm.Join(p, nil))
 	})
 }
 
 // various state changes to participant and that others are receiving update
+func TestRecordingStatus(t *testing.T) {
+	joinRecorder := func(t *testing.T, rm *rtc.Room, identity string) *typesfakes.FakeParticipant {
+		recorder := newMockParticipant(identity, types.DefaultProtocol)
+		require.NoError(t, rm.Join(recorder, &rtc.ParticipantOptions{Recorder: true}))
+		return recorder
+	}
+	setState := func(p *typesfakes.FakeParticipant, state livekit.ParticipantInfo_State) {
+		old := p.State()
+		p.StateReturns(state)
+		p.OnStateChangeArgsForCall(0)(p, old)
+	}
+
+	t.Run("recording starts once a recorder is active", func(t *testing.T) {
+		rm := newRoomWithParticipants(t, testRoomOpts{num: 2})
+		recorder := joinRecorder(t, rm, "recorder")
+		require.False(t, rm.IsRecording())
+		for _, p := range rm.GetParticipants() {
+			require.Zero(t, p.(*typesfakes.FakeParticipant).SetRecordingCallCount())
+		}
+
+		setState(recorder, livekit.ParticipantInfo_ACTIVE)
+		require.True(t, rm.IsRecording())
+		for _, p := range rm.GetParticipants() {
+			fp := p.(*typesfakes.FakeParticipant)
+			if fp == recorder {
+				// recorders aren't told about themselves
+				require.Zero(t, fp.SetRecordingCallCount())
+				continue
+			}
+			require.Equal(t, 1, fp.SetRecordingCallCount())
+			require.True(t, fp.SetRecordingArgsForCall(0))
+		}
+
+		// participants joining after are told in their join response
+		pNew := newMockParticipant("new", types.DefaultProtocol)
+		require.NoError(t, rm.Join(pNew, nil))
+		_, _, _, recording := pNew.SendJoinResponseArgsForCall(0)
+		require.True(t, recording)
+		other := joinRecorder(t, rm, "recorder-2")
+		_, _, _, recording = other.SendJoinResponseArgsForCall(0)
+		require.False(t, recording)
+	})
+
+	t.Run("recording stops when the last recorder leaves", func(t *testing.T) {
+		rm := newRoomWithParticipants(t, testRoomOpts{num: 1})
+		p := rm.GetParticipants()[0].(*typesfakes.FakeParticipant)
+		recorder := joinRecorder(t, rm, "recorder")
+		setState(recorder, livekit.ParticipantInfo_ACTIVE)
+		require.True(t, rm.IsRecording())
+
+		rm.RemoveParticipant(recorder.Identity())
+		require.False(t, rm.IsRecording())
+		require.Equal(t, 2, p.SetRecordingCallCount())
+		require.False(t, p.SetRecordingArgsForCall(1))
+	})
+}
+
 func TestParticipantUpdate(t *testing.T) {
 	tests := []struct {
 		name         string
@@ -193,6 +264,169 @@ func TestParticipantUpdate(t *testing.T) {
 	}
 }
 
+func TestParticipantICEServers(t *testing.T) {
+	rm := newRoomWithParticipants(t, testRoomOpts{num: 0})
+	rm.SetParticipantICEServers(func(identity string) []*livekit.ICEServer {
+		return []*livekit.ICEServer{{Urls: []string{"turn:turn.example.com"}, Username: identity}}
+	})
+	p := newMockParticipant("alice", types.DefaultProtocol)
+	require.NoError(t, rm.Join(p, nil))
+
+	_, _, iceServers, _ := p.SendJoinResponseArgsForCall(0)
+	require.Len(t, iceServers, 2)
+	require.Equal(t, "alice", iceServers[1].Username)
+}
+
+func TestParticipantRename(t *testing.T) {
+	rm := newRoomWithParticipants(t, testRoomOpts{num: 3})
+	participants := rm.GetParticipants()
+	renamed := participants[0].(*typesfakes.FakeParticipant)
+	for _, p := range participants {
+		p.(*typesfakes.FakeParticipant).StateReturns(livekit.ParticipantInfo_ACTIVE)
+	}
+
+	renamed.NameReturns("Alice")
+	renamed.OnNameUpdateArgsForCall(0)(renamed)
+	require.Equal(t, 0, renamed.SendDataPacketCallCount())
+	for _, p := range participants[1:] {
+		fp := p.(*typesfakes.FakeParticipant)
+		require.Equal(t, 1, fp.SendDataPacketCallCount())
+		require.JSONEq(t, fmt.Sprintf(`{"type":"participant_name","sid":%q,"identity":%q,"name":"Alice"}`,
+			renamed.ID(), renamed.Identity()),
+			string(fp.SendDataPacketArgsForCall(0).GetUser().Payload))
+	}
+
+	t.Run("names are sent to participants once active", func(t *testing.T) {
+		late := newMockParticipant("late", types.DefaultProtocol)
+		require.NoError(t, rm.Join(late, nil))
+		late.StateReturns(livekit.ParticipantInfo_ACTIVE)
+		late.OnStateChangeArgsForCall(0)(late, livekit.ParticipantInfo_JOINED)
+
+		require.Equal(t, 1, late.SendDataPacketCallCount())
+		require.Contains(t, string(late.SendDataPacketArgsForCall(0).GetUser().Payload), `"name":"Alice"`)
+	})
+}
+
+func TestHosts(t *testing.T) {
+	join := func(t *testing.T, rm *rtc.Room, identity string, connectedAt time.Time) *typesfakes.FakeParticipant {
+		p := newMockParticipant(identity, types.DefaultProtocol)
+		p.ConnectedAtReturns(connectedAt)
+		require.NoError(t, rm.Join(p, nil))
+		p.StateReturns(livekit.ParticipantInfo_ACTIVE)
+		return p
+	}
+	now := time.Now()
+
+	t.Run("first participant is a host when it rejoins", func(t *testing.T) {
+		rm := newRoomWithParticipants(t, testRoomOpts{num: 0})
+		rm.SetHosts(config.HostsConfig{FirstParticipant: true})
+		first := join(t, rm, "first", now)
+		join(t, rm, "second", now.Add(time.Second))
+		require.Equal(t, []string{"first"}, rm.Hosts())
+
+		first.OnStateChangeArgsForCall(0)(first, livekit.ParticipantInfo_JOINED)
+		require.Equal(t, 1, first.SendDataPacketCallCount())
+		require.JSONEq(t, `{"type":"hosts","identities":["first"]}`,
+			string(first.SendDataPacketArgsForCall(0).GetUser().Payload))
+
+		rm.RemoveParticipant("first")
+		require.Empty(t, rm.Hosts())
+		join(t, rm, "first", now.Add(2*time.Second))
+		require.Equal(t, []string{"first"}, rm.Hosts())
+	})
+
+	t.Run("transfers to the participant in the room the longest", func(t *testing.T) {
+		rm := newRoomWithParticipants(t, testRoomOpts{num: 0})
+		rm.SetHosts(config.HostsConfig{FirstParticipant: true, Transfer: true})
+		join(t, rm, "first", now)
+		third := join(t, rm, "third", now.Add(2*time.Second))
+		second := join(t, rm, "second", now.Add(time.Second))
+		require.Equal(t, []string{"first"}, rm.Hosts())
+
+		rm.RemoveParticipant("first")
+		require.Equal(t, []string{"second"}, rm.Hosts())
+		for _, p := range []*typesfakes.FakeParticipant{second, third} {
+			require.Equal(t, 1, p.SendDataPacketCallCount())
+			require.JSONEq(t, `{"type":"hosts","identities":["second"]}`,
+				string(p.SendDataPacketArgsForCall(0).GetUser().Payload))
+		}
+
+		// the first participant is a host again, next to the one it was transferred to
+		join(t, rm, "first", now.Add(3*time.Second))
+		require.Equal(t, []string{"first", "second"}, rm.Hosts())
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		rm := newRoomWithParticipants(t, testRoomOpts{num: 0})
+		first := join(t, rm, "first", now)
+		require.Empty(t, rm.Hosts())
+		first.OnStateChangeArgsForCall(0)(first, livekit.ParticipantInfo_JOINED)
+		require.Equal(t, 0, first.SendDataPacketCallCount())
+	})
+}
+
+func TestBulkOperations(t *testing.T) {
+	t.Run("mutes everyone with a single update", func(t *testing.T) {
+		rm := newRoomWithParticipants(t, testRoomOpts{num: 4})
+		defer rm.Close()
+		excepted := rm.GetParticipant("p3").(*typesfakes.FakeParticipant)
+		callCounts := make(map[string]int)
+		for _, p := range rm.GetParticipants() {
+			fp := p.(*typesfakes.FakeParticipant)
+			fp.GetPublishedTracksReturns([]types.PublishedTrack{newMockTrack(livekit.TrackType_AUDIO, "audio")})
+			callCounts[p.ID()] = fp.SendParticipantUpdateCallCount()
+		}
+		failing := rm.GetParticipant("p1").(*typesfakes.FakeParticipant)
+		muteErr := errors.New("could not mute")
+		failing.MutePublishedTrackReturns(muteErr)
+
+		result := rm.SetAllTracksMuted(true, []string{excepted.ID()})
+		require.ElementsMatch(t, []string{"p0", "p2"}, result.Applied)
+		require.Len(t, result.Failed, 1)
+		require.Equal(t, muteErr, result.Failed["p1"])
+		require.Zero(t, excepted.MutePublishedTrackCallCount())
+		// muted on behalf of the server, so participants can't unmute
+		for _, identity := range []string{"p0", "p2"} {
+			fp := rm.GetParticipant(identity).(*typesfakes.FakeParticipant)
+			require.Equal(t, 1, fp.MutePublishedTrackCallCount())
+			_, muted := fp.MutePublishedTrackArgsForCall(0)
+			require.True(t, muted)
+			require.Zero(t, fp.SetTrackMutedCallCount())
+		}
+
+		for _, p := range rm.GetParticipants() {
+			fp := p.(*typesfakes.FakeParticipant)
+			require.Equal(t, callCounts[p.ID()]+1, fp.SendParticipantUpdateCallCount())
+			require.Len(t, fp.SendParticipantUpdateArgsForCall(callCounts[p.ID()]), 2)
+		}
+
+		// updates are sent right away once the operation is done
+		rm.GetParticipant("p0").SetTrackMuted("", false)
+		require.Equal(t, callCounts[excepted.ID()]+2, excepted.SendParticipantUpdateCallCount())
+	})
+
+	t.Run("removes everyone but hosts", func(t *testing.T) {
+		rm := newRoomWithParticipants(t, testRoomOpts{num: 4, hosts: 1})
+		defer rm.Close()
+		host := rm.GetParticipant("p0").(*typesfakes.FakeParticipant)
+		excepted := rm.GetParticipant("p3").(*typesfakes.FakeParticipant)
+		failing := rm.GetParticipant("p1").(*typesfakes.FakeParticipant)
+		failing.CloseReturns(errors.New("could not close"))
+		hostCount := host.SendParticipantUpdateCallCount()
+
+		result := rm.RemoveAll([]string{excepted.ID()})
+		require.Equal(t, []string{"p2"}, result.Applied)
+		require.Error(t, result.Failed["p1"])
+
+		// removed regardless of the failure
+		require.Len(t, rm.GetParticipants(), 2)
+		require.NotNil(t, rm.GetParticipant("p0"))
+		require.NotNil(t, rm.GetParticipant("p3"))
+		require.Equal(t, hostCount+1, host.SendParticipantUpdateCallCount())
+		require.Len(t, host.SendParticipantUpdateArgsForCall(hostCount), 2)
+	})
+}
+
 func TestRoomClosure(t *testing.T) {
 	t.Run("room closes after participant leaves", func(t *testing.T) {
 		rm := newRoomWithParticipants(t, testRoomOpts{num: 1})
@@ -237,6 +471,76 @@ func TestRoomClosure(t *testing.T) {
 		rm.CloseIfEmpty()
 		require.True(t, isClosed)
 	})
+
+	t.Run("room closes after max duration since creation", func(t *testing.T) {
+		rm := newRoomWithParticipants(t, testRoomOpts{num: 2})
+		var isClosed utils.AtomicFlag
+		rm.OnClose(func() {
+			isClosed.TrySet(true)
+		})
+		// room was created before a restart
+		rm.Room.CreationTime = time.Now().Unix() - 10
+
+		rm.SetMaxDuration(time.Hour)
+		time.Sleep(defaultDelay)
+		require.False(t, isClosed.Get())
+
+		rm.SetMaxDuration(5 * time.Second)
+		require.Eventually(t, isClosed.Get, time.Second, 10*time.Millisecond)
+		for _, p := range rm.GetParticipants() {
+			require.Equal(t, 1, p.(*typesfakes.FakeParticipant).CloseCallCount())
+		}
+	})
+
+	t.Run("leaves closing at max duration to its hook", func(t *testing.T) {
+		rm := newRoomWithParticipants(t, testRoomOpts{num: 1})
+		var reached utils.AtomicFlag
+		rm.OnMaxDuration(func() {
+			reached.TrySet(true)
+		})
+		rm.Room.CreationTime = time.Now().Unix() - 10
+		rm.SetMaxDuration(5 * time.Second)
+		require.Eventually(t, reached.Get, time.Second, 10*time.Millisecond)
+		require.Equal(t, 0, rm.GetParticipants()[0].(*typesfakes.FakeParticipant).CloseCallCount())
+
+		// closed in two steps, no one joins in between
+		require.True(t, rm.StartClose())
+		require.False(t, rm.StartClose())
+		err := rm.Join(newMockParticipant("late", types.DefaultProtocol), nil)
+		require.Equal(t, rtc.ErrRoomClosed, err)
+		rm.FinishClose(rtc.RoomClosedMaxDuration)
+		require.Equal(t, 1, rm.GetParticipants()[0].(*typesfakes.FakeParticipant).CloseCallCount())
+	})
+
+	t.Run("rejects joins after max duration", func(t *testing.T) {
+		rm := newRoomWithParticipants(t, testRoomOpts{num: 0})
+		rm.Room.CreationTime = time.Now().Unix() - 10
+		rm.SetMaxDuration(5 * time.Second)
+		// the timer could have closed the room first
+		err := rm.Join(newMockParticipant("late", types.DefaultProtocol), nil)
+		require.Contains(t, []error{rtc.ErrMaxDurationExceeded, rtc.ErrRoomClosed}, err)
+	})
+
+	t.Run("tells participants why it's closed", func(t *testing.T) {
+		rm := newRoomWithParticipants(t, testRoomOpts{num: 2, protocol: types.DefaultProtocol})
+		var isClosed utils.AtomicFlag
+		rm.OnClose(func() {
+			isClosed.TrySet(true)
+		})
+
+		rm.CloseWithParticipants(rtc.RoomClosedDeleted)
+		require.True(t, isClosed.Get())
+		for _, p := range rm.GetParticipants() {
+			fp := p.(*typesfakes.FakeParticipant)
+			require.Equal(t, 1, fp.SendDataPacketCallCount())
+			require.JSONEq(t, `{"type":"room_closed","reason":"deleted"}`,
+				string(fp.SendDataPacketArgsForCall(0).GetUser().Payload))
+			require.Equal(t, 1, fp.CloseCallCount())
+		}
+
+		err := rm.Join(newMockParticipant("late", types.DefaultProtocol), nil)
+		require.Equal(t, rtc.ErrRoomClosed, err)
+	})
 }
 
 func TestNewTrack(t *testing.T) {
@@ -259,6 +563,218 @@ func TestNewTrack(t *testing.T) {
 		require.Equal(t, 1, track.AddSubscriberCallCount())
 		require.Equal(t, p1, track.AddSubscriberArgsForCall(0))
 	})
+
+	t.Run("fan-out is delayed in large rooms", func(t *testing.T) {
+		const delay = 50 * time.Millisecond
+		publish := func(rm *rtc.Room, published bool) *typesfakes.FakePublishedTrack {
+			participants := rm.GetParticipants()
+			pub := participants[0].(*typesfakes.FakeParticipant)
+			for _, p := range participants[1:] {
+				p.(*typesfakes.FakeParticipant).StateReturns(livekit.ParticipantInfo_ACTIVE)
+			}
+			track := newMockTrack(livekit.TrackType_VIDEO, "webcam")
+			if published {
+				pub.GetPublishedTracksReturns([]types.PublishedTrack{track})
+			}
+			pub.OnTrackPublishedArgsForCall(0)(pub, track)
+			return track
+		}
+
+		rm := newRoomWithParticipants(t, testRoomOpts{num: 3})
+		rm.SetFanoutDelay(delay, 3)
+		track := publish(rm, true)
+		require.Zero(t, track.AddSubscriberCallCount())
+		require.Eventually(t, func() bool { return track.AddSubscriberCallCount() == 2 }, time.Second, 10*time.Millisecond)
+
+		// smaller rooms aren't delayed
+		rm = newRoomWithParticipants(t, testRoomOpts{num: 2})
+		rm.SetFanoutDelay(delay, 3)
+		require.Equal(t, 1, publish(rm, true).AddSubscriberCallCount())
+
+		// tracks unpublished meanwhile aren't forwarded
+		rm = newRoomWithParticipants(t, testRoomOpts{num: 3})
+		rm.SetFanoutDelay(delay, 3)
+		track = publish(rm, false)
+		time.Sleep(2 * delay)
+		require.Zero(t, track.AddSubscriberCallCount())
+	})
+}
+
+func TestSubscriptionPolicy(t *testing.T) {
+	t.Run("switching to manual pauses auto subscriptions", func(t *testing.T) {
+		rm := newRoomWithParticipants(t, testRoomOpts{num: 2})
+		participants := rm.GetParticipants()
+		sub := participants[0].(*typesfakes.FakeParticipant)
+		pub := participants[1].(*typesfakes.FakeParticipant)
+		sub.StateReturns(livekit.ParticipantInfo_ACTIVE)
+
+		requested := newMockTrack(livekit.TrackType_VIDEO, "webcam")
+		auto := newMockTrack(livekit.TrackType_AUDIO, "mic")
+		pub.GetPublishedTracksReturns([]types.PublishedTrack{requested, auto})
+		require.NoError(t, rm.UpdateSubscriptions(sub, []string{requested.ID()}, true))
+
+		requestedSt := &typesfakes.FakeSubscribedTrack{}
+		requestedSt.IDReturns(requested.ID())
+		autoSt := &typesfakes.FakeSubscribedTrack{}
+		autoSt.IDReturns(auto.ID())
+		sub.GetSubscribedTracksReturns([]types.SubscribedTrack{requestedSt, autoSt})
+
+		rm.SetAutoSubscribe(false)
+		require.Equal(t, 0, requestedSt.SetPausedCallCount())
+		require.Equal(t, 1, autoSt.SetPausedCallCount())
+		require.True(t, autoSt.SetPausedArgsForCall(0))
+		// subscriptions are kept
+		require.Equal(t, 0, auto.RemoveSubscriberCallCount())
+
+		// new tracks are no longer subscribed to
+		track := newMockTrack(livekit.TrackType_VIDEO, "screen")
+		pub.OnTrackPublishedArgsForCall(0)(pub, track)
+		require.Equal(t, 0, track.AddSubscriberCallCount())
+
+		// explicitly requesting a paused track resumes it
+		autoSt.IsPausedReturns(true)
+		require.NoError(t, rm.UpdateSubscriptions(sub, []string{auto.ID()}, true))
+		require.Equal(t, 2, autoSt.SetPausedCallCount())
+		require.False(t, autoSt.SetPausedArgsForCall(1))
+	})
+
+	t.Run("switching to auto resumes and fans out subscriptions", func(t *testing.T) {
+		rm := newRoomWithParticipants(t, testRoomOpts{num: 2})
+		participants := rm.GetParticipants()
+		for _, p := range participants {
+			p.(*typesfakes.FakeParticipant).StateReturns(livekit.ParticipantInfo_ACTIVE)
+		}
+		sub := participants[0].(*typesfakes.FakeParticipant)
+		pub := participants[1].(*typesfakes.FakeParticipant)
+		autoSt := &typesfakes.FakeSubscribedTrack{}
+		autoSt.IDReturns("TR_auto")
+		sub.GetSubscribedTracksReturns([]types.SubscribedTrack{autoSt})
+
+		// no-op when policy is unchanged
+		rm.SetAutoSubscribe(true)
+		require.Equal(t, 0, autoSt.SetPausedCallCount())
+
+		rm.SetAutoSubscribe(false)
+		rm.SetAutoSubscribe(true)
+		require.Equal(t, 2, autoSt.SetPausedCallCount())
+		require.False(t, autoSt.SetPausedArgsForCall(1))
+		require.Equal(t, 1, pub.AddSubscriberCallCount())
+		require.Equal(t, sub, pub.AddSubscriberArgsForCall(0))
+	})
+}
+
+func TestUpdateSubscriptionRejections(t *testing.T) {
+	rm := newRoomWithParticipants(t, testRoomOpts{num: 2})
+	sub := rm.GetParticipant("p0").(*typesfakes.FakeParticipant)
+	pub := rm.GetParticipant("p1").(*typesfakes.FakeParticipant)
+	webcam := newMockTrack(livekit.TrackType_VIDEO, "webcam")
+	screen := newMockTrack(livekit.TrackType_VIDEO, "screen")
+	mic := newMockTrack(livekit.TrackType_AUDIO, "mic")
+	pub.GetPublishedTracksReturns([]types.PublishedTrack{webcam, screen, mic})
+	pub.SubscribeToTrackStub = func(_ types.Participant, trackID string) error {
+		if trackID == mic.ID() {
+			return nil
+		}
+		return rtc.ErrDownloadBudgetExceeded
+	}
+
+	err := rm.UpdateSubscriptions(sub, []string{webcam.ID(), screen.ID(), mic.ID()}, true)
+	require.ErrorIs(t, err, rtc.ErrDownloadBudgetExceeded)
+	require.Len(t, err.(*rtc.SubscriptionErrors).Errors, 2)
+	require.Equal(t, 3, pub.SubscribeToTrackCallCount())
+	_, trackID := pub.SubscribeToTrackArgsForCall(2)
+	require.Equal(t, mic.ID(), trackID)
+}
+
+func TestSubscriptionRestore(t *testing.T) {
+	setup := func(t *testing.T, window time.Duration) (*rtc.Room, *typesfakes.FakeParticipant, types.PublishedTrack) {
+		rm := newRoomWithParticipants(t, testRoomOpts{num: 2})
+		rm.SetSubscriptionRestoreWindow(window)
+		rm.SetAutoSubscribe(false)
+		sub := rm.GetParticipant("p0").(*typesfakes.FakeParticipant)
+		pub := rm.GetParticipant("p1").(*typesfakes.FakeParticipant)
+
+		webcam := newMockTrack(livekit.TrackType_VIDEO, "webcam")
+		mic := newMockTrack(livekit.TrackType_AUDIO, "mic")
+		pub.GetPublishedTracksReturns([]types.PublishedTrack{webcam, mic})
+		require.NoError(t, rm.UpdateSubscriptions(sub, []string{webcam.ID()}, true))
+		sub.SubscribedTrackIDsReturns([]string{webcam.ID()})
+		rm.RemoveParticipant(sub.Identity())
+		return rm, pub, webcam
+	}
+	rejoin := func(t *testing.T, rm *rtc.Room) *typesfakes.FakeParticipant {
+		p := newMockParticipant("p0", types.DefaultProtocol)
+		require.NoError(t, rm.Join(p, nil))
+		p.StateReturns(livekit.ParticipantInfo_ACTIVE)
+		p.OnStateChangeArgsForCall(0)(p, livekit.ParticipantInfo_JOINED)
+		return p
+	}
+
+	t.Run("restored when rejoining in time", func(t *testing.T) {
+		rm, pub, webcam := setup(t, time.Minute)
+		p := rejoin(t, rm)
+		require.Equal(t, 2, pub.SubscribeToTrackCallCount())
+		sub, trackID := pub.SubscribeToTrackArgsForCall(1)
+		require.Equal(t, p, sub)
+		require.Equal(t, webcam.ID(), trackID)
+
+		// requests are restored as well, so they're kept if the room auto subscribes again
+		rm.SetAutoSubscribe(true)
+		st := &typesfakes.FakeSubscribedTrack{}
+		st.IDReturns(webcam.ID())
+		p.GetSubscribedTracksReturns([]types.SubscribedTrack{st})
+		rm.SetAutoSubscribe(false)
+		require.Equal(t, 0, st.SetPausedCallCount())
+	})
+
+	t.Run("forgotten after the window", func(t *testing.T) {
+		rm, pub, _ := setup(t, 10*time.Millisecond)
+		time.Sleep(50 * time.Millisecond)
+		rejoin(t, rm)
+		require.Equal(t, 1, pub.SubscribeToTrackCallCount())
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		rm, pub, _ := setup(t, 0)
+		rejoin(t, rm)
+		require.Equal(t, 1, pub.SubscribeToTrackCallCount())
+	})
+}
+
+func TestPreviewSubscription(t *testing.T) {
+	rm := newRoomWithParticipants(t, testRoomOpts{num: 2})
+	participants := rm.GetParticipants()
+	p0 := participants[0].(*typesfakes.FakeParticipant)
+	p1 := participants[1].(*typesfakes.FakeParticipant)
+
+	video := newMockTrack(livekit.TrackType_VIDEO, "webcam")
+	video.EstimateSubscriptionReturns(livekit.VideoQuality_HIGH, 1_500_000)
+	audio := newMockTrack(livekit.TrackType_AUDIO, "mic")
+	audio.EstimateSubscriptionReturns(livekit.VideoQuality_HIGH, 32_000)
+	p1.GetPublishedTracksReturns([]types.PublishedTrack{video, audio})
+	p0.GetPublishedTracksReturns([]types.PublishedTrack{newMockTrack(livekit.TrackType_AUDIO, "mic")})
+
+	preview := rm.PreviewSubscription(p0.Identity())
+	require.True(t, preview.AutoSubscribe)
+	require.Len(t, preview.Tracks, 2)
+	require.Equal(t, uint64(1_532_000), preview.Bitrate)
+	for _, tp := range preview.Tracks {
+		require.Equal(t, p1.Identity(), tp.Participant)
+		if tp.Sid == video.ID() {
+			require.Equal(t, livekit.VideoQuality_HIGH.String(), tp.Quality)
+		} else {
+			require.Empty(t, tp.Quality)
+		}
+	}
+	// nothing is subscribed
+	require.Zero(t, video.AddSubscriberCallCount())
+
+	// new participants receive tracks of everyone
+	preview = rm.PreviewSubscription("newcomer")
+	require.Len(t, preview.Tracks, 3)
+
+	rm.SetAutoSubscribe(false)
+	require.False(t, rm.PreviewSubscription(p0.Identity()).AutoSubscribe)
 }
 
 func TestActiveSpeakers(t *testing.T) {
@@ -392,6 +908,56 @@ func TestActiveSpeakers(t *testing.T) {
 	})
 }
 
+func TestQualitySampling(t *testing.T) {
+	rm := newRoomWithParticipants(t, testRoomOpts{num: 2})
+	p := rm.GetParticipants()[0].(*typesfakes.FakeParticipant)
+	track := &typesfakes.FakePublishedTrack{}
+	track.GetBufferStatsReturns([]types.BufferStats{{SSRC: 1, LostRate: 0.5, Jitter: 10}})
+	p.GetPublishedTracksReturns([]types.PublishedTrack{track})
+
+	file := filepath.Join(t.TempDir(), "quality.log")
+	sink, err := rtc.NewQualitySink(config.QualitySamplingConfig{
+		Enabled:      true,
+		Interval:     10 * time.Millisecond,
+		Sink:         config.TelemetrySinkFile,
+		File:         file,
+		MaxFileBytes: 1 << 20,
+	})
+	require.NoError(t, err)
+	defer sink.Close()
+	rm.SetQualitySink(sink)
+
+	readSamples := func() []rtc.QualitySample {
+		data, err := ioutil.ReadFile(file)
+		require.NoError(t, err)
+		var samples []rtc.QualitySample
+		for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+			if line == "" {
+				continue
+			}
+			var sample rtc.QualitySample
+			require.NoError(t, json.Unmarshal([]byte(line), &sample))
+			samples = append(samples, sample)
+		}
+		return samples
+	}
+	require.Eventually(t, func() bool {
+		return len(readSamples()) >= 2
+	}, time.Second, 10*time.Millisecond)
+	sample := readSamples()[0]
+	require.Equal(t, "room", sample.Room)
+	require.Equal(t, p.Identity(), sample.Participant)
+	require.Equal(t, "inbound", sample.Direction)
+	require.Equal(t, 0.5, sample.FractionLost)
+
+	// sampling stops with the room
+	rm.Close()
+	time.Sleep(50 * time.Millisecond)
+	sampled := len(readSamples())
+	time.Sleep(50 * time.Millisecond)
+	require.Len(t, readSamples(), sampled)
+}
+
 func TestDataChannel(t *testing.T) {
 	t.Parallel()
 
@@ -453,12 +1019,146 @@ func TestDataChannel(t *testing.T) {
 		require.Equal(t, 1, p1.SendDataPacketCallCount())
 		require.Equal(t, packet.Value, p1.SendDataPacketArgsForCall(0).Value)
 	})
+
+	t.Run("host only policy drops data from other participants", func(t *testing.T) {
+		rm := newRoomWithParticipants(t, testRoomOpts{num: 3, hosts: 1})
+		defer rm.Close()
+		rm.SetDataPolicy(config.DataPolicyHostOnly)
+		host := rm.GetParticipant("p0").(*typesfakes.FakeParticipant)
+		p1 := rm.GetParticipant("p1").(*typesfakes.FakeParticipant)
+		p2 := rm.GetParticipant("p2").(*typesfakes.FakeParticipant)
+
+		for _, kind := range []livekit.DataPacket_Kind{livekit.DataPacket_RELIABLE, livekit.DataPacket_LOSSY} {
+			p1.OnDataPacketArgsForCall(0)(p1, newUserPacket(p1, kind))
+		}
+		require.Zero(t, host.SendDataPacketCallCount())
+		require.Zero(t, p2.SendDataPacketCallCount())
+
+		host.OnDataPacketArgsForCall(0)(host, newUserPacket(host, livekit.DataPacket_RELIABLE))
+		require.Equal(t, 1, p1.SendDataPacketCallCount())
+		require.Equal(t, 1, p2.SendDataPacketCallCount())
+	})
+
+	t.Run("moderated policy only lets participants send to hosts", func(t *testing.T) {
+		rm := newRoomWithParticipants(t, testRoomOpts{num: 3, hosts: 1})
+		defer rm.Close()
+		rm.SetDataPolicy(config.DataPolicyModerated)
+		host := rm.GetParticipant("p0").(*typesfakes.FakeParticipant)
+		p1 := rm.GetParticipant("p1").(*typesfakes.FakeParticipant)
+		p2 := rm.GetParticipant("p2").(*typesfakes.FakeParticipant)
+
+		p1.OnDataPacketArgsForCall(0)(p1, newUserPacket(p1, livekit.DataPacket_LOSSY))
+		require.Equal(t, 1, host.SendDataPacketCallCount())
+		require.Zero(t, p2.SendDataPacketCallCount())
+
+		// explicit destinations are restricted as well
+		p1.OnDataPacketArgsForCall(0)(p1, newUserPacket(p1, livekit.DataPacket_RELIABLE, p2.ID()))
+		require.Zero(t, p2.SendDataPacketCallCount())
+
+		host.OnDataPacketArgsForCall(0)(host, newUserPacket(host, livekit.DataPacket_RELIABLE))
+		require.Equal(t, 1, p1.SendDataPacketCallCount())
+		require.Equal(t, 1, p2.SendDataPacketCallCount())
+
+		// back to open
+		rm.SetDataPolicy(config.DataPolicyOpen)
+		p1.OnDataPacketArgsForCall(0)(p1, newUserPacket(p1, livekit.DataPacket_RELIABLE, p2.ID()))
+		require.Equal(t, 2, p2.SendDataPacketCallCount())
+	})
+
+	t.Run("captions are sent in the preferred language", func(t *testing.T) {
+		rm := newRoomWithParticipants(t, testRoomOpts{num: 4})
+		defer rm.Close()
+		rm.SetCaptions(config.CaptionsConfig{Enabled: true, DefaultLanguage: "en"})
+		pub := rm.GetParticipant("p0").(*typesfakes.FakeParticipant)
+		spanish := rm.GetParticipant("p1").(*typesfakes.FakeParticipant)
+		french := rm.GetParticipant("p2").(*typesfakes.FakeParticipant)
+		noPreference := rm.GetParticipant("p3").(*typesfakes.FakeParticipant)
+		require.True(t, rm.SetPreferredLanguage("p1", "es"))
+		require.True(t, rm.SetPreferredLanguage("p2", "fr"))
+		require.False(t, rm.SetPreferredLanguage("unknown", "fr"))
+
+		caption := func(language string) *livekit.DataPacket {
+			dp := newUserPacket(pub, livekit.DataPacket_RELIABLE)
+			dp.GetUser().Payload = []byte(`{"type": "caption", "language": "` + language + `", "text": "..."}`)
+			return dp
+		}
+		// languages become available as they're received
+		pub.OnDataPacketArgsForCall(0)(pub, caption("es"))
+		pub.OnDataPacketArgsForCall(0)(pub, caption("en"))
+
+		require.Equal(t, 1, spanish.SendDataPacketCallCount())
+		require.Equal(t, caption("es").Value, spanish.SendDataPacketArgsForCall(0).Value)
+		// french isn't captioned, default language is sent instead
+		require.Equal(t, 1, french.SendDataPacketCallCount())
+		require.Equal(t, caption("en").Value, french.SendDataPacketArgsForCall(0).Value)
+		require.Equal(t, 1, noPreference.SendDataPacketCallCount())
+		require.Equal(t, caption("en").Value, noPreference.SendDataPacketArgsForCall(0).Value)
+
+		// other packets are sent to everyone
+		pub.OnDataPacketArgsForCall(0)(pub, newUserPacket(pub, livekit.DataPacket_RELIABLE))
+		require.Equal(t, 2, spanish.SendDataPacketCallCount())
+		require.Equal(t, 2, french.SendDataPacketCallCount())
+	})
+
+	t.Run("data packets are recorded", func(t *testing.T) {
+		rm := newRoomWithParticipants(t, testRoomOpts{num: 2})
+		recorder, err := rtc.NewDataRecorder(t.TempDir(), "room/1")
+		require.NoError(t, err)
+		require.Equal(t, "room%2F1-", filepath.Base(recorder.Path())[:len("room%2F1-")])
+		rm.SetDataRecorder(recorder)
+		participants := rm.GetParticipants()
+		p := participants[0].(*typesfakes.FakeParticipant)
+		p1 := participants[1].(*typesfakes.FakeParticipant)
+
+		packet := livekit.DataPacket{
+			Kind: livekit.DataPacket_LOSSY,
+			Value: &livekit.DataPacket_User{
+				User: &livekit.UserPacket{
+					ParticipantSid:  p.ID(),
+					Payload:         []byte("message to p1.."),
+					DestinationSids: []string{p1.ID()},
+				},
+			},
+		}
+		p.OnDataPacketArgsForCall(0)(p, &packet)
+		// closing the room closes the recording, later packets are dropped
+		rm.Close()
+		p.OnDataPacketArgsForCall(0)(p, &packet)
+
+		data, err := ioutil.ReadFile(recorder.Path())
+		require.NoError(t, err)
+		lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+		require.Len(t, lines, 1)
+		var record rtc.DataRecord
+		require.NoError(t, json.Unmarshal([]byte(lines[0]), &record))
+		require.Equal(t, p.ID(), record.ParticipantSid)
+		require.Equal(t, p.Identity(), record.ParticipantIdentity)
+		require.Equal(t, livekit.DataPacket_LOSSY.String(), record.Kind)
+		require.Equal(t, []string{p1.ID()}, record.DestinationSids)
+		require.Equal(t, []byte("message to p1.."), record.Payload)
+		require.NotZero(t, record.Timestamp)
+	})
+}
+
+func newUserPacket(source types.Participant, kind livekit.DataPacket_Kind, destinationSids ...string) *livekit.DataPacket {
+	return &livekit.DataPacket{
+		Kind: kind,
+		Value: &livekit.DataPacket_User{
+			User: &livekit.UserPacket{
+				ParticipantSid:  source.ID(),
+				Payload:         []byte("message.."),
+				DestinationSids: destinationSids,
+			},
+		},
+	}
 }
 
 type testRoomOpts struct {
 	num                  int
 	protocol             types.ProtocolVersion
 	audioSmoothIntervals uint32
+	// number of participants that join as hosts, starting from p0
+	hosts int
 }
 
 func newRoomWithParticipants(t *testing.T, opts testRoomOpts) *rtc.Room {
@@ -480,9 +1180,69 @@ func newRoomWithParticipants(t *testing.T, opts testRoomOpts) *rtc.Room {
 	for i := 0; i < opts.num; i++ {
 		identity := fmt.Sprintf("p%d", i)
 		participant := newMockParticipant(identity, opts.protocol)
-		err := rm.Join(participant, &rtc.ParticipantOptions{AutoSubscribe: true})
+		err := rm.Join(participant, &rtc.ParticipantOptions{AutoSubscribe: true, Host: i < opts.hosts})
 		participant.StateReturns(livekit.ParticipantInfo_ACTIVE)
 		require.NoError(t, err)
 	}
 	return rm
 }
+
+func TestTrackLayersUpdate(t *testing.T) {
+	rm := newRoomWithParticipants(t, testRoomOpts{num: 3})
+	defer rm.Close()
+	pub := rm.GetParticipant("p0").(*typesfakes.FakeParticipant)
+	sub := rm.GetParticipant("p1").(*typesfakes.FakeParticipant)
+	other := rm.GetParticipant("p2").(*typesfakes.FakeParticipant)
+	track := &typesfakes.FakePublishedTrack{}
+	track.IDReturns("TR_video")
+	track.IsSubscriberStub = func(subID string) bool {
+		return subID == sub.ID()
+	}
+
+	// not described by the publisher yet
+	pub.OnTrackUpdatedArgsForCall(0)(pub, track)
+	require.Zero(t, sub.SendDataPacketCallCount())
+
+	layers := []types.VideoLayerInfo{{Quality: livekit.VideoQuality_HIGH, Width: 640, Height: 360, Framerate: 15}}
+	track.LayersReturns(layers)
+	pub.OnTrackUpdatedArgsForCall(0)(pub, track)
+	require.Equal(t, 1, sub.SendDataPacketCallCount())
+	require.Zero(t, other.SendDataPacketCallCount())
+
+	var signal struct {
+		Type           string                 `json:"type"`
+		ParticipantSid string                 `json:"participantSid"`
+		TrackSid       string                 `json:"trackSid"`
+		Layers         []types.VideoLayerInfo `json:"layers"`
+	}
+	require.NoError(t, json.Unmarshal(sub.SendDataPacketArgsForCall(0).GetUser().Payload, &signal))
+	require.Equal(t, "track_layers", signal.Type)
+	require.Equal(t, pub.ID(), signal.ParticipantSid)
+	require.Equal(t, "TR_video", signal.TrackSid)
+	require.Equal(t, layers, signal.Layers)
+	// dimensions reach everyone in participant updates
+	require.NotZero(t, other.SendParticipantUpdateCallCount())
+}
+
+func TestFirstMediaReceived(t *testing.T) {
+	rm := newRoomWithParticipants(t, testRoomOpts{num: 2, protocol: types.DefaultProtocol})
+	defer rm.Close()
+	pub := rm.GetParticipant("p0").(*typesfakes.FakeParticipant)
+	sub := rm.GetParticipant("p1").(*typesfakes.FakeParticipant)
+	track := &typesfakes.FakePublishedTrack{}
+	track.IDReturns("TR_video")
+
+	pub.OnFirstMediaReceivedArgsForCall(0)(pub, track)
+	for _, p := range []*typesfakes.FakeParticipant{pub, sub} {
+		require.Equal(t, 1, p.SendDataPacketCallCount())
+		var signal struct {
+			Type           string `json:"type"`
+			ParticipantSid string `json:"participantSid"`
+			TrackSid       string `json:"trackSid"`
+		}
+		require.NoError(t, json.Unmarshal(p.SendDataPacketArgsForCall(0).GetUser().Payload, &signal))
+		require.Equal(t, "first_media", signal.Type)
+		require.Equal(t, pub.ID(), signal.ParticipantSid)
+		require.Equal(t, "TR_video", signal.TrackSid)
+	}
+}
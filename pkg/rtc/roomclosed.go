@@ -0,0 +1,21 @@
+package rtc
+
+import ()
+
+// type of user packets telling participants why the room is closed, set in their JSON payload
+const roomClosedPacketType = "room_closed"
+
+// reasons rooms are closed with their participants, a leave without one is sent on a network error or when the
+// participant is removed on its own
+const (
+	// deleted with the room service
+	RoomClosedDeleted = "deleted"
+	// open for the max duration of rooms
+	RoomClosedMaxDuration = "max_duration"
+)
+
+// roomClosedSignal is the payload of data packets sent to participants right before they're disconnected
+type roomClosedSignal struct {
+	Type   string `json:"type"`
+	Reason string `json:"reason"`
+}
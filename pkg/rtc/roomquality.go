@@ -0,0 +1,217 @@
+package rtc
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/logger"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+const (
+	// media published by the participant, as received by the SFU
+	qualityInbound = "inbound"
+	// media sent to the participant, as it reported receiving it
+	qualityOutbound = "outbound"
+)
+
+// QualitySample is the loss and jitter of the streams a participant publishes or receives, at one point in time
+type QualitySample struct {
+	// unix timestamp in milliseconds
+	Timestamp      int64  `json:"timestamp"`
+	Room           string `json:"room"`
+	Participant    string `json:"participant"`
+	ParticipantSid string `json:"participantSid"`
+	// inbound or outbound
+	Direction string `json:"direction"`
+	// number of streams sampled
+	Streams int `json:"streams"`
+	// average and worst fraction of packets lost across streams
+	FractionLost    float64 `json:"fractionLost"`
+	MaxFractionLost float64 `json:"maxFractionLost"`
+	// average and worst interarrival jitter across streams, in RTP timestamp units
+	Jitter    float64 `json:"jitter"`
+	MaxJitter float64 `json:"maxJitter"`
+}
+
+// QualitySink writes quality samples of all rooms on this node. Like the TelemetrySink, samples are written by a
+// single goroutine and dropped when it can't keep up, and nothing is kept once written
+type QualitySink struct {
+	conf    config.QualitySamplingConfig
+	samples chan QualitySample
+	done    chan struct{}
+
+	file    *os.File
+	encoder *json.Encoder
+	written int64
+
+	dropped uint64
+	once    sync.Once
+}
+
+// NewQualitySink returns nil when quality sampling is disabled
+func NewQualitySink(conf config.QualitySamplingConfig) (*QualitySink, error) {
+	if !conf.Enabled {
+		return nil, nil
+	}
+	s := &QualitySink{
+		conf:    conf,
+		samples: make(chan QualitySample, telemetryQueueSize),
+		done:    make(chan struct{}),
+	}
+	if conf.Sink == config.TelemetrySinkFile {
+		f, err := os.OpenFile(conf.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, err
+		}
+		s.file = f
+		s.encoder = json.NewEncoder(&countingWriter{w: f, n: &s.written})
+	}
+	go s.worker()
+	return s, nil
+}
+
+func (s *QualitySink) Interval() time.Duration {
+	return s.conf.Interval
+}
+
+// Emit queues the sample, returns false when it's dropped
+func (s *QualitySink) Emit(sample QualitySample) bool {
+	select {
+	case <-s.done:
+		return false
+	default:
+	}
+	select {
+	case s.samples <- sample:
+		return true
+	default:
+		s.drop()
+		return false
+	}
+}
+
+// Dropped returns the number of samples dropped because the sink couldn't keep up
+func (s *QualitySink) Dropped() uint64 {
+	return atomic.LoadUint64(&s.dropped)
+}
+
+func (s *QualitySink) Close() {
+	s.once.Do(func() {
+		close(s.done)
+	})
+}
+
+func (s *QualitySink) drop() {
+	atomic.AddUint64(&s.dropped, 1)
+	qualitySampleDroppedTotal.Inc()
+}
+
+func (s *QualitySink) worker() {
+	defer func() {
+		if s.file != nil {
+			_ = s.file.Close()
+		}
+	}()
+	for {
+		select {
+		case <-s.done:
+			return
+		case sample := <-s.samples:
+			s.write(sample)
+		}
+	}
+}
+
+func (s *QualitySink) write(sample QualitySample) {
+	if s.conf.Sink != config.TelemetrySinkFile {
+		logger.Infow("room quality",
+			"room", sample.Room,
+			"participant", sample.Participant,
+			"direction", sample.Direction,
+			"streams", sample.Streams,
+			"fractionLost", sample.FractionLost,
+			"maxFractionLost", sample.MaxFractionLost,
+			"jitter", sample.Jitter,
+			"maxJitter", sample.MaxJitter)
+		return
+	}
+	if atomic.LoadInt64(&s.written) >= s.conf.MaxFileBytes {
+		s.drop()
+		return
+	}
+	if err := s.encoder.Encode(&sample); err != nil {
+		logger.Warnw("could not write room quality", err, "file", s.conf.File)
+	}
+}
+
+// qualityAggregate accumulates loss and jitter of streams in one direction
+type qualityAggregate struct {
+	streams         int
+	fractionLost    float64
+	maxFractionLost float64
+	jitter          float64
+	maxJitter       float64
+}
+
+func (a *qualityAggregate) add(fractionLost float64, jitter float64) {
+	a.streams++
+	a.fractionLost += fractionLost
+	a.jitter += jitter
+	if fractionLost > a.maxFractionLost {
+		a.maxFractionLost = fractionLost
+	}
+	if jitter > a.maxJitter {
+		a.maxJitter = jitter
+	}
+}
+
+func (a *qualityAggregate) sample(template QualitySample, direction string) QualitySample {
+	template.Direction = direction
+	template.Streams = a.streams
+	template.FractionLost = a.fractionLost / float64(a.streams)
+	template.MaxFractionLost = a.maxFractionLost
+	template.Jitter = a.jitter / float64(a.streams)
+	template.MaxJitter = a.maxJitter
+	return template
+}
+
+// sampleParticipantQuality returns a sample of each direction the participant has streams in. Inbound streams are
+// the receive buffers of its published tracks, outbound ones the subscribed tracks it sent a report on within
+// maxReportAge, so subscriptions it stopped reporting on don't hold onto stale loss
+func sampleParticipantQuality(room string, p types.Participant, maxReportAge time.Duration, now time.Time) []QualitySample {
+	var inbound, outbound qualityAggregate
+	for _, track := range p.GetPublishedTracks() {
+		for _, stats := range track.GetBufferStats() {
+			inbound.add(float64(stats.LostRate), stats.Jitter)
+		}
+	}
+	for _, track := range p.GetSubscribedTracks() {
+		st, ok := track.(*SubscribedTrack)
+		if !ok {
+			continue
+		}
+		if report, ok := st.receptionReport(maxReportAge); ok {
+			outbound.add(float64(report.fractionLost)/256, float64(report.jitter))
+		}
+	}
+
+	template := QualitySample{
+		Timestamp:      now.UnixNano() / int64(time.Millisecond),
+		Room:           room,
+		Participant:    p.Identity(),
+		ParticipantSid: p.ID(),
+	}
+	var samples []QualitySample
+	if inbound.streams > 0 {
+		samples = append(samples, inbound.sample(template, qualityInbound))
+	}
+	if outbound.streams > 0 {
+		samples = append(samples, outbound.sample(template, qualityOutbound))
+	}
+	return samples
+}
@@ -0,0 +1,70 @@
+package rtc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pion/rtcp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+	"github.com/livekit/livekit-server/pkg/rtc/types/typesfakes"
+)
+
+func TestSampleParticipantQuality(t *testing.T) {
+	now := time.Now()
+	newParticipant := func() *typesfakes.FakeParticipant {
+		p := &typesfakes.FakeParticipant{}
+		p.IdentityReturns("alice")
+		p.IDReturns("PA_alice")
+		return p
+	}
+
+	t.Run("aggregates streams of published tracks", func(t *testing.T) {
+		p := newParticipant()
+		video := &typesfakes.FakePublishedTrack{}
+		video.GetBufferStatsReturns([]types.BufferStats{
+			{SSRC: 1, LostRate: 0.1, Jitter: 100},
+			{SSRC: 2, LostRate: 0.3, Jitter: 300},
+		})
+		audio := &typesfakes.FakePublishedTrack{}
+		audio.GetBufferStatsReturns([]types.BufferStats{{SSRC: 3, LostRate: 0.2, Jitter: 20}})
+		p.GetPublishedTracksReturns([]types.PublishedTrack{video, audio})
+
+		samples := sampleParticipantQuality("room", p, time.Second, now)
+		require.Len(t, samples, 1)
+		sample := samples[0]
+		require.Equal(t, "room", sample.Room)
+		require.Equal(t, "alice", sample.Participant)
+		require.Equal(t, "PA_alice", sample.ParticipantSid)
+		require.Equal(t, qualityInbound, sample.Direction)
+		require.Equal(t, 3, sample.Streams)
+		require.InDelta(t, 0.2, sample.FractionLost, 0.001)
+		require.InDelta(t, 0.3, sample.MaxFractionLost, 0.001)
+		require.InDelta(t, 140, sample.Jitter, 0.001)
+		require.Equal(t, float64(300), sample.MaxJitter)
+	})
+
+	t.Run("samples recent reports of subscribed tracks", func(t *testing.T) {
+		p := newParticipant()
+		recent := &SubscribedTrack{}
+		recent.setReceptionReport(rtcp.ReceptionReport{FractionLost: 64, Jitter: 90})
+		stale := &SubscribedTrack{}
+		stale.setReceptionReport(rtcp.ReceptionReport{FractionLost: 255})
+		stale.reception.at = now.Add(-time.Minute)
+		unreported := &SubscribedTrack{}
+		p.GetSubscribedTracksReturns([]types.SubscribedTrack{recent, stale, unreported})
+
+		samples := sampleParticipantQuality("room", p, time.Second, now)
+		require.Len(t, samples, 1)
+		sample := samples[0]
+		require.Equal(t, qualityOutbound, sample.Direction)
+		require.Equal(t, 1, sample.Streams)
+		require.Equal(t, 0.25, sample.FractionLost)
+		require.Equal(t, float64(90), sample.Jitter)
+	})
+
+	t.Run("skips participants without streams", func(t *testing.T) {
+		require.Empty(t, sampleParticipantQuality("room", newParticipant(), time.Second, now))
+	})
+}
@@ -0,0 +1,42 @@
+package rtc
+
+import (
+	"github.com/pion/rtcp"
+)
+
+// default number of sender reports and source description chunks sent in a single RTCP packet
+const defaultSDESBatchSize = 20
+
+// batchDownTrackReports groups sender reports and source description chunks of down tracks into compound
+// RTCP packets, each holding at most batchSize of either.
+// Sender reports are placed first, so the first batches carry them ahead of their source descriptions,
+// as compound packets are expected to start with a report. Remaining chunks are sent in batches of their own
+func batchDownTrackReports(srs []rtcp.Packet, chunks []rtcp.SourceDescriptionChunk, batchSize int) [][]rtcp.Packet {
+	if batchSize <= 0 {
+		batchSize = defaultSDESBatchSize
+	}
+
+	var batches [][]rtcp.Packet
+	for len(srs) > 0 || len(chunks) > 0 {
+		batch := make([]rtcp.Packet, 0, batchSize+1)
+
+		numSRs := len(srs)
+		if numSRs > batchSize {
+			numSRs = batchSize
+		}
+		batch = append(batch, srs[:numSRs]...)
+		srs = srs[numSRs:]
+
+		numChunks := len(chunks)
+		if numChunks > batchSize {
+			numChunks = batchSize
+		}
+		if numChunks > 0 {
+			batch = append(batch, &rtcp.SourceDescription{Chunks: chunks[:numChunks]})
+			chunks = chunks[numChunks:]
+		}
+
+		batches = append(batches, batch)
+	}
+	return batches
+}
@@ -0,0 +1,54 @@
+package rtc
+
+import (
+	"testing"
+
+	"github.com/pion/rtcp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatchDownTrackReports(t *testing.T) {
+	reports := func(n int) ([]rtcp.Packet, []rtcp.SourceDescriptionChunk) {
+		var srs []rtcp.Packet
+		var chunks []rtcp.SourceDescriptionChunk
+		for i := 0; i < n; i++ {
+			srs = append(srs, &rtcp.SenderReport{SSRC: uint32(i)})
+			// down tracks describe CNAME and mid in separate chunks
+			chunks = append(chunks, rtcp.SourceDescriptionChunk{Source: uint32(i)}, rtcp.SourceDescriptionChunk{Source: uint32(i)})
+		}
+		return srs, chunks
+	}
+
+	t.Run("sender reports go out with the first batches", func(t *testing.T) {
+		srs, chunks := reports(3)
+		batches := batchDownTrackReports(srs, chunks, 4)
+		require.Len(t, batches, 2)
+
+		require.Len(t, batches[0], 4)
+		for _, pkt := range batches[0][:3] {
+			require.IsType(t, &rtcp.SenderReport{}, pkt)
+		}
+		require.Len(t, batches[0][3].(*rtcp.SourceDescription).Chunks, 4)
+
+		require.Len(t, batches[1], 1)
+		require.Len(t, batches[1][0].(*rtcp.SourceDescription).Chunks, 2)
+	})
+
+	t.Run("limits sender reports to the batch size", func(t *testing.T) {
+		srs, _ := reports(5)
+		batches := batchDownTrackReports(srs, nil, 2)
+		require.Len(t, batches, 3)
+		require.Len(t, batches[0], 2)
+		require.Len(t, batches[2], 1)
+		require.Equal(t, uint32(4), batches[2][0].(*rtcp.SenderReport).SSRC)
+	})
+
+	t.Run("uses default batch size", func(t *testing.T) {
+		srs, chunks := reports(defaultSDESBatchSize)
+		batches := batchDownTrackReports(srs, chunks, 0)
+		require.Len(t, batches, 2)
+		require.Len(t, batches[0], defaultSDESBatchSize+1)
+
+		require.Empty(t, batchDownTrackReports(nil, nil, 0))
+	})
+}
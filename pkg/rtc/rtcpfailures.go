@@ -0,0 +1,22 @@
+package rtc
+
+import (
+	"sync/atomic"
+)
+
+// consecutive RTCP write failures on one of a participant's transports before the participant is closed
+const maxRTCPWriteFailures = 10
+
+// rtcpWriteFailures counts consecutive failed RTCP writes to a transport, a successful write resets it
+type rtcpWriteFailures struct {
+	count int32
+}
+
+// record returns the number of consecutive failures including this write, 0 when it succeeded
+func (f *rtcpWriteFailures) record(err error) int32 {
+	if err == nil {
+		atomic.StoreInt32(&f.count, 0)
+		return 0
+	}
+	return atomic.AddInt32(&f.count, 1)
+}
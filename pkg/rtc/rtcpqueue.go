@@ -0,0 +1,62 @@
+package rtc
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/pion/rtcp"
+)
+
+const (
+	// size of the RTCP queue of publishers when it isn't configured
+	defaultRTCPQueueSize = 50
+	// longest keyframe requests wait for room in a full RTCP queue
+	rtcpKeyframeRequestTimeout = 500 * time.Millisecond
+	// keyframe requests waiting for room across all queues, further ones are dropped
+	maxRTCPKeyframeWaiters = 256
+)
+
+var rtcpKeyframeWaiters int32
+
+// sendRTCP queues RTCP for a publisher without blocking the caller, which could be reading its media. When the
+// queue is full, feedback is dropped and counted, as later feedback supersedes it, while keyframe requests wait up
+// to rtcpKeyframeRequestTimeout for room on their own goroutine, as subscribers can't recover without them.
+// It returns false when pkts were dropped right away
+func sendRTCP(ch chan []rtcp.Packet, pkts []rtcp.Packet) bool {
+	select {
+	case ch <- pkts:
+		return true
+	default:
+	}
+
+	if !hasKeyframeRequest(pkts) {
+		rtcpDroppedTotal.WithLabelValues("feedback").Inc()
+		return false
+	}
+	if atomic.AddInt32(&rtcpKeyframeWaiters, 1) > maxRTCPKeyframeWaiters {
+		atomic.AddInt32(&rtcpKeyframeWaiters, -1)
+		rtcpDroppedTotal.WithLabelValues("keyframe_request").Inc()
+		return false
+	}
+	go func() {
+		defer atomic.AddInt32(&rtcpKeyframeWaiters, -1)
+		timer := time.NewTimer(rtcpKeyframeRequestTimeout)
+		defer timer.Stop()
+		select {
+		case ch <- pkts:
+		case <-timer.C:
+			rtcpDroppedTotal.WithLabelValues("keyframe_request").Inc()
+		}
+	}()
+	return true
+}
+
+func hasKeyframeRequest(pkts []rtcp.Packet) bool {
+	for _, pkt := range pkts {
+		switch pkt.(type) {
+		case *rtcp.PictureLossIndication, *rtcp.FullIntraRequest:
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+package rtc
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pion/rtcp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSendRTCP(t *testing.T) {
+	feedback := []rtcp.Packet{&rtcp.ReceiverReport{SSRC: 1}}
+	pli := []rtcp.Packet{&rtcp.ReceiverReport{SSRC: 1}, &rtcp.PictureLossIndication{MediaSSRC: 2}}
+
+	t.Run("drops feedback when full", func(t *testing.T) {
+		ch := make(chan []rtcp.Packet, 1)
+		require.True(t, sendRTCP(ch, feedback))
+		require.False(t, sendRTCP(ch, feedback))
+		require.Len(t, ch, 1)
+	})
+
+	t.Run("keyframe requests wait for room without blocking", func(t *testing.T) {
+		ch := make(chan []rtcp.Packet, 1)
+		require.True(t, sendRTCP(ch, feedback))
+		start := time.Now()
+		require.True(t, sendRTCP(ch, pli))
+		require.Less(t, time.Since(start).Nanoseconds(), (50 * time.Millisecond).Nanoseconds())
+
+		time.Sleep(20 * time.Millisecond)
+		require.Equal(t, feedback, <-ch)
+		require.Equal(t, pli, <-ch)
+	})
+
+	t.Run("keyframe requests are dropped once they time out", func(t *testing.T) {
+		ch := make(chan []rtcp.Packet, 1)
+		require.True(t, sendRTCP(ch, feedback))
+		require.True(t, sendRTCP(ch, pli))
+		time.Sleep(rtcpKeyframeRequestTimeout + 50*time.Millisecond)
+		require.Equal(t, feedback, <-ch)
+		require.Len(t, ch, 0)
+		require.Equal(t, int32(0), atomic.LoadInt32(&rtcpKeyframeWaiters))
+	})
+
+	t.Run("drops keyframe requests when too many are waiting", func(t *testing.T) {
+		ch := make(chan []rtcp.Packet, 1)
+		require.True(t, sendRTCP(ch, feedback))
+		for i := 0; i < maxRTCPKeyframeWaiters; i++ {
+			require.True(t, sendRTCP(ch, pli))
+		}
+		require.False(t, sendRTCP(ch, pli))
+		// frees the waiters
+		for i := 0; i <= maxRTCPKeyframeWaiters; i++ {
+			<-ch
+		}
+	})
+}
@@ -0,0 +1,14 @@
+package rtc
+
+import ()
+
+// type of user packets telling participants one of their tracks was muted or unmuted by the server
+const serverMutedPacketType = "server_muted"
+
+// serverMutedSignal is the payload of data packets sent to a participant when the server mutes or unmutes one of
+// its tracks, and when it tries to unmute a track muted by the server
+type serverMutedSignal struct {
+	Type     string `json:"type"`
+	TrackSid string `json:"trackSid"`
+	Muted    bool   `json:"muted"`
+}
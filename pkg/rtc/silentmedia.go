@@ -0,0 +1,73 @@
+package rtc
+
+import (
+	"sync"
+	"time"
+)
+
+// interval the bitrate of camera tracks is checked at for black video
+const silentVideoCheckInterval = time.Second
+
+// type of user packets telling publishers one of their tracks went silent or black, or recovered
+const silentMediaPacketType = "silent_media"
+
+// silentMediaSignal is the payload of data packets sent to a publisher when one of its tracks goes silent or black
+type silentMediaSignal struct {
+	Type     string `json:"type"`
+	TrackSid string `json:"trackSid"`
+	Silent   bool   `json:"silent"`
+}
+
+// silenceDetector tells when a track has been silent, or black, for a while. Any sample that isn't brings it back
+// right away
+type silenceDetector struct {
+	after time.Duration
+
+	lock   sync.Mutex
+	silent bool
+	// start of the current run of silent samples, zero after one that isn't
+	since time.Time
+}
+
+func newSilenceDetector(after time.Duration) *silenceDetector {
+	return &silenceDetector{after: after}
+}
+
+// observe takes whether a sample is silent, and returns whether the track is, with changed set when it just
+// went silent or recovered
+func (d *silenceDetector) observe(quiet bool, now time.Time) (silent bool, changed bool) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if !quiet {
+		d.since = time.Time{}
+		if d.silent {
+			d.silent = false
+			return false, true
+		}
+		return false, false
+	}
+	if d.since.IsZero() {
+		d.since = now
+	}
+	if d.silent || now.Sub(d.since) < d.after {
+		return d.silent, false
+	}
+	d.silent = true
+	return true, true
+}
+
+// reset forgets silent samples, returns true when the track was silent
+func (d *silenceDetector) reset() bool {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.since = time.Time{}
+	wasSilent := d.silent
+	d.silent = false
+	return wasSilent
+}
+
+func (d *silenceDetector) isSilent() bool {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	return d.silent
+}
@@ -0,0 +1,43 @@
+package rtc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSilenceDetector(t *testing.T) {
+	start := time.Now()
+	d := newSilenceDetector(10 * time.Second)
+
+	silent, changed := d.observe(true, start)
+	require.False(t, silent)
+	require.False(t, changed)
+	_, changed = d.observe(true, start.Add(9*time.Second))
+	require.False(t, changed)
+
+	// a single sample that isn't silent starts over
+	_, changed = d.observe(false, start.Add(9500*time.Millisecond))
+	require.False(t, changed)
+	_, changed = d.observe(true, start.Add(10*time.Second))
+	require.False(t, changed)
+	silent, changed = d.observe(true, start.Add(20*time.Second))
+	require.True(t, silent)
+	require.True(t, changed)
+	require.True(t, d.isSilent())
+
+	_, changed = d.observe(true, start.Add(21*time.Second))
+	require.False(t, changed)
+	silent, changed = d.observe(false, start.Add(22*time.Second))
+	require.False(t, silent)
+	require.True(t, changed)
+
+	t.Run("reset", func(t *testing.T) {
+		d.observe(true, start)
+		d.observe(true, start.Add(time.Minute))
+		require.True(t, d.reset())
+		require.False(t, d.isSilent())
+		require.False(t, d.reset())
+	})
+}
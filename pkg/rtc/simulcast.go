@@ -0,0 +1,224 @@
+package rtc
+
+import (
+	"errors"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	livekit "github.com/livekit/livekit-server/proto"
+)
+
+// a layer is considered healthy once it's receiving at least 1/layerBitrateThreshold of its target bitrate
+const layerBitrateThreshold = 4
+
+// highest of the 3 spatial layers sfu.WebRTCReceiver receives
+const maxSpatialLayer = 2
+
+var defaultTargetBitrates = []uint64{150_000, 500_000, 1_500_000}
+
+var (
+	errUnknownRID    = errors.New("RID doesn't match a simulcast layer")
+	errLayerReceived = errors.New("simulcast layer is already received on another RID")
+)
+
+// simulcastLayers describes layers publishers are expected to send, layer 0 being the lowest quality
+type simulcastLayers struct {
+	targetBitrates []uint64
+	fallback       config.SimulcastFallbackConfig
+	unknownLayers  string
+
+	// layer => RID of the stream received for it
+	received map[int32]string
+}
+
+func newSimulcastLayers(conf config.SimulcastConfig) *simulcastLayers {
+	bitrates := conf.TargetBitrates
+	if len(bitrates) == 0 {
+		bitrates = defaultTargetBitrates
+	}
+	if len(bitrates) > 3 {
+		bitrates = bitrates[:3]
+	}
+	return &simulcastLayers{
+		targetBitrates: bitrates,
+		fallback:       conf.Fallback,
+		unknownLayers:  conf.UnknownLayers,
+	}
+}
+
+// addStream returns the layer a simulcast stream is received as. sfu.WebRTCReceiver keeps a single stream per
+// layer and receives RIDs it doesn't know as the lowest one, so a stream that would take the place of another
+// is rejected rather than forwarded as that layer
+func (s *simulcastLayers) addStream(rid string) (int32, error) {
+	layer := layerForRID(rid)
+	if !isLayerRID(rid) && s.unknownLayers != config.SimulcastUnknownLayersReconcile {
+		return -1, errUnknownRID
+	}
+	if received, ok := s.received[layer]; ok && received != rid {
+		return -1, errLayerReceived
+	}
+	if s.received == nil {
+		s.received = make(map[int32]string)
+	}
+	s.received[layer] = rid
+	return layer, nil
+}
+
+func (s *simulcastLayers) removeStream(layer int32) {
+	delete(s.received, layer)
+}
+
+func (s *simulcastLayers) removeStreams() {
+	s.received = nil
+}
+
+// receivedLayers keeps the layers of qualities that are received, the others can't be forwarded
+func (s *simulcastLayers) receivedLayers(layers []int32) []int32 {
+	received := make([]int32, 0, len(layers))
+	for _, layer := range layers {
+		if _, ok := s.received[layer]; ok {
+			received = append(received, layer)
+		}
+	}
+	return received
+}
+
+func isLayerRID(rid string) bool {
+	return rid == quarterResolution || rid == halfResolution || rid == fullResolution
+}
+
+// layerForRID returns the spatial layer of a simulcast stream, as assigned by sfu.WebRTCReceiver
+func layerForRID(rid string) int32 {
+	switch rid {
+	case fullResolution:
+		return 2
+	case halfResolution:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func (s *simulcastLayers) numLayers() int32 {
+	return int32(len(s.targetBitrates))
+}
+
+func (s *simulcastLayers) targetBitrate(layer int32) uint64 {
+	if layer < 0 || layer >= s.numLayers() {
+		return 0
+	}
+	return s.targetBitrates[layer]
+}
+
+// layerForQuality returns the spatial layer that should be used for a requested quality
+func (s *simulcastLayers) layerForQuality(quality livekit.VideoQuality) int32 {
+	top := s.numLayers() - 1
+	switch quality {
+	case livekit.VideoQuality_LOW:
+		return 0
+	case livekit.VideoQuality_MEDIUM:
+		if top < 1 {
+			return top
+		}
+		return 1
+	default:
+		return top
+	}
+}
+
+// qualityForLayer labels a spatial layer, the highest configured layer is always HIGH
+func (s *simulcastLayers) qualityForLayer(layer int32) livekit.VideoQuality {
+	switch {
+	case layer >= s.numLayers()-1:
+		return livekit.VideoQuality_HIGH
+	case layer == 0:
+		return livekit.VideoQuality_LOW
+	default:
+		return livekit.VideoQuality_MEDIUM
+	}
+}
+
+// nearestLayer returns the layer the publisher is sending that's closest to target, the lower one when two are as
+// close, or target when it isn't sending any. Unlike selectLayer it ignores bitrates, for subscribers pinned to
+// a layer that shouldn't move with the publisher's bandwidth
+func nearestLayer(target int32, hasLayer func(layer int32) bool) int32 {
+	const maxLayer = 2
+	for distance := int32(0); distance <= maxLayer; distance++ {
+		if below := target - distance; below >= 0 && below <= maxLayer && hasLayer(below) {
+			return below
+		}
+		if above := target + distance; above <= maxLayer && hasLayer(above) {
+			return above
+		}
+	}
+	return target
+}
+
+// selectLayer picks the layer closest to target that the publisher is sending.
+// Publishers could send fewer layers than configured, or pause layers when constrained, in which case
+// the highest healthy layer below target is used, falling back to the lowest layer above it.
+// bitrates are the measured bitrates of each layer, zero bitrates are treated as not yet measured
+func (s *simulcastLayers) selectLayer(target int32, hasLayer func(layer int32) bool, bitrates [3]uint64) int32 {
+	healthy := func(layer int32) bool {
+		return s.healthy(layer, hasLayer, bitrates)
+	}
+
+	for layer := target; layer >= 0; layer-- {
+		if healthy(layer) {
+			return layer
+		}
+	}
+	for layer := target + 1; layer < int32(len(bitrates)); layer++ {
+		if healthy(layer) {
+			return layer
+		}
+	}
+	// nothing healthy, use any layer that's available
+	for layer := int32(0); layer < int32(len(bitrates)); layer++ {
+		if hasLayer(layer) {
+			return layer
+		}
+	}
+	return target
+}
+
+// healthy returns true when the publisher sends the layer at a usable bitrate, any layer it sends is healthy
+// until bitrates are measured
+func (s *simulcastLayers) healthy(layer int32, hasLayer func(layer int32) bool, bitrates [3]uint64) bool {
+	if layer < 0 || layer >= int32(len(bitrates)) || !hasLayer(layer) {
+		return false
+	}
+	measured := false
+	for _, br := range bitrates {
+		if br != 0 {
+			measured = true
+			break
+		}
+	}
+	if !measured {
+		return true
+	}
+	return bitrates[layer]*layerBitrateThreshold >= s.targetBitrate(layer)
+}
+
+// fallbackLayer returns the layer forwarded while target isn't healthy, following the fallback policy.
+// target is returned when there's nothing to fall back to
+func (s *simulcastLayers) fallbackLayer(target int32, hasLayer func(layer int32) bool, bitrates [3]uint64) int32 {
+	switch s.fallback.Policy {
+	case config.SimulcastFallbackNearest:
+		for distance := int32(1); distance < int32(len(bitrates)); distance++ {
+			if s.healthy(target-distance, hasLayer, bitrates) {
+				return target - distance
+			}
+			if s.healthy(target+distance, hasLayer, bitrates) {
+				return target + distance
+			}
+		}
+	case config.SimulcastFallbackLower:
+		for layer := target - 1; layer >= 0; layer-- {
+			if s.healthy(layer, hasLayer, bitrates) {
+				return layer
+			}
+		}
+	}
+	return target
+}
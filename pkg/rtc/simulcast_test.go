@@ -0,0 +1,104 @@
+package rtc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	livekit "github.com/livekit/livekit-server/proto"
+)
+
+func TestSimulcastLayers(t *testing.T) {
+	t.Run("maps qualities to configured layers", func(t *testing.T) {
+		three := newSimulcastLayers(config.SimulcastConfig{})
+		require.Equal(t, int32(3), three.numLayers())
+		require.Equal(t, int32(0), three.layerForQuality(livekit.VideoQuality_LOW))
+		require.Equal(t, int32(1), three.layerForQuality(livekit.VideoQuality_MEDIUM))
+		require.Equal(t, int32(2), three.layerForQuality(livekit.VideoQuality_HIGH))
+		require.Equal(t, livekit.VideoQuality_MEDIUM, three.qualityForLayer(1))
+
+		two := newSimulcastLayers(config.SimulcastConfig{TargetBitrates: []uint64{200_000, 1_000_000}})
+		require.Equal(t, int32(2), two.numLayers())
+		require.Equal(t, int32(1), two.layerForQuality(livekit.VideoQuality_MEDIUM))
+		require.Equal(t, int32(1), two.layerForQuality(livekit.VideoQuality_HIGH))
+		require.Equal(t, livekit.VideoQuality_LOW, two.qualityForLayer(0))
+		require.Equal(t, livekit.VideoQuality_HIGH, two.qualityForLayer(1))
+	})
+
+	t.Run("falls back when publisher sends fewer layers", func(t *testing.T) {
+		layers := newSimulcastLayers(config.SimulcastConfig{})
+		lowOnly := func(layer int32) bool { return layer == 0 }
+		require.Equal(t, int32(0), layers.selectLayer(2, lowOnly, [3]uint64{}))
+
+		upper := func(layer int32) bool { return layer > 0 }
+		require.Equal(t, int32(1), layers.selectLayer(0, upper, [3]uint64{}))
+	})
+
+	t.Run("pinned subscribers get the nearest layer sent", func(t *testing.T) {
+		all := func(layer int32) bool { return true }
+		require.Equal(t, int32(0), nearestLayer(0, all))
+
+		lowOnly := func(layer int32) bool { return layer == 0 }
+		require.Equal(t, int32(0), nearestLayer(2, lowOnly))
+		upper := func(layer int32) bool { return layer > 0 }
+		require.Equal(t, int32(1), nearestLayer(0, upper))
+		outer := func(layer int32) bool { return layer != 1 }
+		require.Equal(t, int32(0), nearestLayer(1, outer))
+
+		none := func(layer int32) bool { return false }
+		require.Equal(t, int32(2), nearestLayer(2, none))
+	})
+
+	t.Run("falls back following the policy", func(t *testing.T) {
+		all := func(layer int32) bool { return true }
+		nearest := newSimulcastLayers(config.SimulcastConfig{
+			Fallback: config.SimulcastFallbackConfig{Policy: config.SimulcastFallbackNearest},
+		})
+		require.Equal(t, int32(0), nearest.fallbackLayer(1, all, [3]uint64{150_000, 0, 1_500_000}))
+		require.Equal(t, int32(1), nearest.fallbackLayer(0, all, [3]uint64{0, 500_000, 1_500_000}))
+		// nothing to fall back to
+		require.Equal(t, int32(2), nearest.fallbackLayer(2, all, [3]uint64{0, 0, 1_000}))
+
+		lower := newSimulcastLayers(config.SimulcastConfig{
+			Fallback: config.SimulcastFallbackConfig{Policy: config.SimulcastFallbackLower},
+		})
+		require.Equal(t, int32(0), lower.fallbackLayer(0, all, [3]uint64{0, 500_000, 1_500_000}))
+		require.Equal(t, int32(0), lower.fallbackLayer(2, all, [3]uint64{150_000, 0, 0}))
+	})
+
+	t.Run("skips layers below bitrate threshold", func(t *testing.T) {
+		layers := newSimulcastLayers(config.SimulcastConfig{})
+		all := func(layer int32) bool { return true }
+		require.Equal(t, int32(2), layers.selectLayer(2, all, [3]uint64{150_000, 500_000, 1_200_000}))
+
+		// high layer paused by the publisher
+		require.Equal(t, int32(1), layers.selectLayer(2, all, [3]uint64{150_000, 500_000, 1_000}))
+
+		// nothing healthy, stay on an available layer
+		require.Equal(t, int32(0), layers.selectLayer(2, all, [3]uint64{1_000, 1_000, 1_000}))
+	})
+
+	t.Run("receives a stream per layer", func(t *testing.T) {
+		ignore := newSimulcastLayers(config.SimulcastConfig{UnknownLayers: config.SimulcastUnknownLayersIgnore})
+		layer, err := ignore.addStream(halfResolution)
+		require.NoError(t, err)
+		require.Equal(t, int32(1), layer)
+		_, err = ignore.addStream("mid")
+		require.Equal(t, errUnknownRID, err)
+
+		reconcile := newSimulcastLayers(config.SimulcastConfig{UnknownLayers: config.SimulcastUnknownLayersReconcile})
+		layer, err = reconcile.addStream("low")
+		require.NoError(t, err)
+		require.Equal(t, int32(0), layer)
+		// would replace the stream received as the lowest layer
+		_, err = reconcile.addStream(quarterResolution)
+		require.Equal(t, errLayerReceived, err)
+		_, err = reconcile.addStream("lowest")
+		require.Equal(t, errLayerReceived, err)
+
+		reconcile.removeStreams()
+		_, err = reconcile.addStream(quarterResolution)
+		require.NoError(t, err)
+	})
+}
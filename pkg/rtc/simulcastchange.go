@@ -0,0 +1,89 @@
+package rtc
+
+import (
+	"sync/atomic"
+
+	"github.com/pion/ion-sfu/pkg/buffer"
+	"github.com/pion/ion-sfu/pkg/sfu"
+	"github.com/pion/webrtc/v3"
+
+	"github.com/livekit/livekit-server/pkg/logger"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+	livekit "github.com/livekit/livekit-server/proto"
+)
+
+// replaceReceiverLocked moves subscribers off the replaced receiver once the publisher started or stopped
+// simulcasting the track. sfu.WebRTCReceiver forwards either a single stream or simulcast layers, as decided by the
+// first stream it receives, and DownTracks can't be moved to another receiver, so subscribers are sent new ones on
+// the transceivers they already have, without renegotiating.
+// Buffers of the replaced receiver are closed, the publisher no longer sends them, which stops it along with UDP
+// forwarders and sinks of the track.
+// needs to be called with lock held
+func (t *MediaTrack) replaceReceiverLocked(replaced sfu.Receiver, buffers []*buffer.Buffer) {
+	// the track isn't closed with it
+	replaced.OnCloseHandler(nil)
+	for _, st := range t.subscribedTracks {
+		if st.moveToReceiver != nil {
+			st.moveToReceiver(t.receiver)
+		}
+	}
+	// layers consumed of the previous streams no longer apply
+	t.maxConsumedLayer = -1
+	t.updateConsumedLayersLocked()
+	for _, buff := range buffers {
+		_ = buff.Close()
+	}
+}
+
+// moveSubscription forwards the track to sub from receiver through a new DownTrack, sent on the transceiver of the
+// subscription. The subscription is unbound, and its forwarded layer unknown, until the new DownTrack is bound,
+// which requests a keyframe as subscribers can't decode the new stream with what they received of the old one.
+// needs to be called with lock held
+func (t *MediaTrack) moveSubscription(sub types.Participant, subTrack *SubscribedTrack,
+	transceiver *webrtc.RTPTransceiver, receiver sfu.Receiver, onClose func()) {
+	old := subTrack.DownTrack()
+	oldReceiver := subTrack.currentReceiver()
+	downTrack, err := sfu.NewDownTrack(old.Codec(), NewWrappedReceiver(receiver, t.ID(), old.StreamID()),
+		t.params.BufferFactory, sub.ID(), t.params.ReceiverConfig.nackWindow(sub.NackWindow()))
+	if err != nil {
+		logger.Warnw("could not move subscription to new streams", err,
+			"track", t.params.TrackID,
+			"participantId", t.params.ParticipantID,
+			"destParticipant", sub.Identity())
+		// removes the subscription
+		go old.Close()
+		return
+	}
+	wrappedDownTrack := NewWrappedDownTrack(downTrack, subTrack.onBind)
+	if kind := receiver.Kind(); downTrack.Kind() != kind {
+		wrappedDownTrack.kind = kind
+	}
+	downTrack.SetTransceiver(transceiver)
+	downTrack.OnBind(func() {
+		subTrack.bound.TrySet(true)
+		subTrack.updateDownTrackMute()
+		t.requestBindKeyframe(subTrack)
+	})
+	downTrack.OnCloseHandler(onClose)
+
+	// the old DownTrack stops forwarding without removing the subscription
+	old.OnCloseHandler(nil)
+	old.Close()
+	oldReceiver.DeleteDownTrack(sub.ID())
+
+	subTrack.setSource(downTrack, receiver)
+	receiver.AddDownTrack(downTrack, t.shouldStartWithBestQuality())
+	if t.kind == livekit.TrackType_VIDEO && subTrack.consumedLayer() >= 0 {
+		subTrack.switchToTarget(atomic.LoadInt32(&subTrack.targetLayer))
+	}
+
+	go func() {
+		// binds the new DownTrack right away when the transceiver is already sending
+		if err := transceiver.Sender().ReplaceTrack(wrappedDownTrack); err != nil {
+			logger.Warnw("could not replace subscribed track", err,
+				"track", t.params.TrackID,
+				"participantId", t.params.ParticipantID,
+				"destParticipant", sub.Identity())
+		}
+	}()
+}
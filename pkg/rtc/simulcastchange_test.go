@@ -0,0 +1,127 @@
+package rtc
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pion/ion-sfu/pkg/buffer"
+	"github.com/pion/ion-sfu/pkg/sfu"
+	"github.com/pion/webrtc/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/logger"
+	"github.com/livekit/livekit-server/pkg/rtc/types/typesfakes"
+	livekit "github.com/livekit/livekit-server/proto"
+)
+
+func TestSimulcastChange(t *testing.T) {
+	vp8 := webrtc.RTPCodecParameters{RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeVP8, ClockRate: 90000}}
+	newReceiver := func() *switchedReceiver {
+		return &switchedReceiver{
+			stallingReceiver: stallingReceiver{mismatchedReceiver: mismatchedReceiver{kind: webrtc.RTPCodecTypeVideo, codec: vp8}},
+			onClose:          func() {},
+		}
+	}
+	single := newReceiver()
+	limiter := NewSubscriptionLimiter(10)
+	mt := &MediaTrack{
+		params: MediaTrackParams{
+			TrackID:             "track",
+			ParticipantID:       "pub",
+			BufferFactory:       buffer.NewBufferFactory(500, logger.GetLogger()),
+			SubscriptionLimiter: limiter,
+		},
+		kind:             livekit.TrackType_VIDEO,
+		receiver:         single,
+		layers:           newSimulcastLayers(config.SimulcastConfig{}),
+		subscribedTracks: make(map[string]*SubscribedTrack),
+		udpForwarders:    make(map[string]*UDPForwarder),
+		maxConsumedLayer: -1,
+	}
+
+	transport, err := NewPCTransport(TransportParams{Target: livekit.SignalTarget_SUBSCRIBER, Config: &WebRTCConfig{}})
+	require.NoError(t, err)
+	defer transport.Close()
+	sub := &typesfakes.FakeParticipant{}
+	sub.IDReturns("sub")
+	sub.CanSubscribeReturns(true)
+	sub.SupportsCodecReturns(true)
+	sub.SubscriberPCReturns(transport.pc)
+	sub.SubscriberMediaEngineReturns(transport.me)
+	require.NoError(t, mt.AddSubscriber(sub))
+	st := mt.subscribedTracks["sub"]
+	old := st.DownTrack()
+	require.Equal(t, []*sfu.DownTrack{old}, single.downTracks())
+
+	// the publisher starts simulcasting
+	simulcast := newReceiver()
+	mt.lock.Lock()
+	mt.receiver = simulcast
+	mt.simulcasted = true
+	mt.replaceReceiverLocked(single, nil)
+	mt.lock.Unlock()
+
+	// same subscription, forwarded from the new receiver
+	require.Equal(t, st, mt.subscribedTracks["sub"])
+	require.NotEqual(t, old, st.DownTrack())
+	require.Equal(t, []*sfu.DownTrack{st.DownTrack()}, simulcast.downTracks())
+	require.Equal(t, []string{"sub"}, single.deletedDownTracks())
+	require.Nil(t, single.onClose)
+	require.False(t, st.IsBound())
+
+	// sent on the transceiver of the subscription
+	transceivers := transport.pc.GetTransceivers()
+	require.Len(t, transceivers, 1)
+	require.Eventually(t, func() bool {
+		sent, ok := transceivers[0].Sender().Track().(WrappedDownTrack)
+		return ok && sent.DownTrack == st.DownTrack()
+	}, time.Second, 10*time.Millisecond)
+
+	// closing the old DownTrack didn't end the subscription
+	time.Sleep(50 * time.Millisecond)
+	require.Zero(t, sub.RemoveSubscribedTrackCallCount())
+	require.Equal(t, 1, limiter.Active())
+
+	st.DownTrack().Close()
+	require.Eventually(t, func() bool {
+		return sub.RemoveSubscribedTrackCallCount() == 1
+	}, time.Second, 10*time.Millisecond)
+	require.Zero(t, limiter.Active())
+}
+
+// a receiver keeping DownTracks added to it, and subscribers whose DownTrack was deleted
+type switchedReceiver struct {
+	stallingReceiver
+	lock    sync.Mutex
+	tracks  []*sfu.DownTrack
+	deleted []string
+	onClose func()
+}
+
+func (r *switchedReceiver) AddDownTrack(track *sfu.DownTrack, _ bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.tracks = append(r.tracks, track)
+}
+
+func (r *switchedReceiver) DeleteDownTrack(peerID string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.deleted = append(r.deleted, peerID)
+}
+
+func (r *switchedReceiver) OnCloseHandler(fn func()) { r.onClose = fn }
+
+func (r *switchedReceiver) downTracks() []*sfu.DownTrack {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return append([]*sfu.DownTrack{}, r.tracks...)
+}
+
+func (r *switchedReceiver) deletedDownTracks() []string {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return append([]string{}, r.deleted...)
+}
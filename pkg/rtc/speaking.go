@@ -0,0 +1,86 @@
+package rtc
+
+import (
+	"sync"
+	"time"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+// interval speaking tracks are checked for having gone silent, Opus DTX stops sending packets in silence
+const speakingCheckInterval = 100 * time.Millisecond
+
+// speakingDetector tells when a track is speaking from the RFC 6464 audio level of its packets. It starts speaking
+// once levels stayed at or above the active level for ActivateAfter, and stops after DeactivateAfter without one,
+// so brief noises and pauses between words don't make it flicker
+type speakingDetector struct {
+	conf config.SpeakingConfig
+
+	lock     sync.Mutex
+	speaking bool
+	// start of the current run of voiced packets while not speaking, zero after a silent one
+	voicedSince time.Time
+	// last voiced packet while speaking
+	lastVoiced time.Time
+}
+
+func newSpeakingDetector(conf config.SpeakingConfig) *speakingDetector {
+	return &speakingDetector{conf: conf}
+}
+
+// observe takes the level of a packet, 0-127 where 0 is loudest, and returns whether the track is speaking,
+// with changed set when it just started or stopped
+func (d *speakingDetector) observe(level uint8, now time.Time) (speaking bool, changed bool) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	voiced := level <= d.conf.ActiveLevel
+	if d.speaking {
+		if voiced {
+			d.lastVoiced = now
+			return true, false
+		}
+		return d.checkLocked(now)
+	}
+
+	if !voiced {
+		d.voicedSince = time.Time{}
+		return false, false
+	}
+	if d.voicedSince.IsZero() {
+		d.voicedSince = now
+	}
+	if now.Sub(d.voicedSince) < d.conf.ActivateAfter {
+		return false, false
+	}
+	d.speaking = true
+	d.voicedSince = time.Time{}
+	d.lastVoiced = now
+	return true, true
+}
+
+// check stops speaking once nothing voiced was observed for DeactivateAfter, including when packets stopped
+func (d *speakingDetector) check(now time.Time) (speaking bool, changed bool) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	return d.checkLocked(now)
+}
+
+// needs to be called with lock held
+func (d *speakingDetector) checkLocked(now time.Time) (bool, bool) {
+	if !d.speaking || now.Sub(d.lastVoiced) < d.conf.DeactivateAfter {
+		return d.speaking, false
+	}
+	d.speaking = false
+	return false, true
+}
+
+// stop ends speaking when the track is closed, returns true when it was speaking
+func (d *speakingDetector) stop() bool {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	wasSpeaking := d.speaking
+	d.speaking = false
+	d.voicedSince = time.Time{}
+	return wasSpeaking
+}
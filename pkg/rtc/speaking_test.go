@@ -0,0 +1,86 @@
+package rtc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+func TestSpeakingDetector(t *testing.T) {
+	conf := config.SpeakingConfig{
+		ActiveLevel:     30,
+		ActivateAfter:   200 * time.Millisecond,
+		DeactivateAfter: 500 * time.Millisecond,
+	}
+	const (
+		voiced = 20
+		silent = 90
+		frame  = 20 * time.Millisecond
+	)
+	start := time.Now()
+	// feeds a packet every frame from `from` until `to`, returning when speaking first changed, zero when it didn't
+	feed := func(d *speakingDetector, level uint8, from, to time.Duration) time.Duration {
+		var changedAt time.Duration
+		for at := from; at < to; at += frame {
+			if _, changed := d.observe(level, start.Add(at)); changed && changedAt == 0 {
+				changedAt = at
+			}
+		}
+		return changedAt
+	}
+
+	t.Run("starts speaking after sustained voice", func(t *testing.T) {
+		d := newSpeakingDetector(conf)
+		require.Equal(t, 200*time.Millisecond, feed(d, voiced, 0, time.Second))
+		speaking, _ := d.check(start.Add(300 * time.Millisecond))
+		require.True(t, speaking)
+	})
+
+	t.Run("silence restarts the run of voice", func(t *testing.T) {
+		d := newSpeakingDetector(conf)
+		require.Zero(t, feed(d, voiced, 0, 180*time.Millisecond))
+		d.observe(silent, start.Add(180*time.Millisecond))
+		require.Equal(t, 400*time.Millisecond, feed(d, voiced, 200*time.Millisecond, time.Second))
+	})
+
+	t.Run("stops after silence", func(t *testing.T) {
+		d := newSpeakingDetector(conf)
+		feed(d, voiced, 0, 400*time.Millisecond)
+		// brief pauses keep it speaking
+		require.Zero(t, feed(d, silent, 400*time.Millisecond, 800*time.Millisecond))
+		require.Zero(t, feed(d, voiced, 800*time.Millisecond, 820*time.Millisecond))
+		require.Equal(t, 1300*time.Millisecond, feed(d, silent, 820*time.Millisecond, 2*time.Second))
+		require.False(t, d.speaking)
+	})
+
+	t.Run("stops once packets stop", func(t *testing.T) {
+		d := newSpeakingDetector(conf)
+		feed(d, voiced, 0, 400*time.Millisecond)
+		speaking, changed := d.check(start.Add(800 * time.Millisecond))
+		require.True(t, speaking)
+		require.False(t, changed)
+		speaking, changed = d.check(start.Add(900 * time.Millisecond))
+		require.False(t, speaking)
+		require.True(t, changed)
+		_, changed = d.check(start.Add(time.Second))
+		require.False(t, changed)
+	})
+
+	t.Run("stop reports whether it was speaking", func(t *testing.T) {
+		d := newSpeakingDetector(conf)
+		require.False(t, d.stop())
+		feed(d, voiced, 0, 400*time.Millisecond)
+		require.True(t, d.stop())
+		require.False(t, d.speaking)
+	})
+
+	t.Run("speaks on the first voiced packet without a delay", func(t *testing.T) {
+		d := newSpeakingDetector(config.SpeakingConfig{ActiveLevel: 30, DeactivateAfter: conf.DeactivateAfter})
+		speaking, changed := d.observe(voiced, start)
+		require.True(t, speaking)
+		require.True(t, changed)
+	})
+}
@@ -0,0 +1,273 @@
+package rtc
+
+import (
+	"encoding/binary"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/pion/interceptor"
+	"github.com/pion/rtcp"
+	"github.com/pion/transport/packetio"
+)
+
+// ssrcHandoff keeps published streams going when a publisher switches one to a new SSRC without renegotiating,
+// as some do after restarting their encoder. sfu.WebRTCReceiver can't replace the stream of a layer while it's
+// forwarding it, so packets of the new SSRC are written to the buffer of the replaced one instead, rewritten to
+// continue its sequence numbers and timestamps. Subscribers see a single continuous stream.
+// Feedback sent to the publisher on the replaced SSRC is rewritten for the new one, for which it adheres to the
+// Pion interceptor interface
+type ssrcHandoff struct {
+	interceptor.NoOp
+
+	lock sync.RWMutex
+	// ssrc => stream written by the publisher
+	streams map[uint32]*handoffStream
+	// replaced ssrc => redirect of the stream replacing it
+	replacedBy map[uint32]*ssrcRedirect
+}
+
+// ssrcRedirect rewrites packets of a stream to continue the one it replaces
+type ssrcRedirect struct {
+	from     uint32
+	to       *handoffStream
+	snOffset uint16
+	tsOffset uint32
+}
+
+func newSSRCHandoff() *ssrcHandoff {
+	return &ssrcHandoff{
+		streams:    make(map[uint32]*handoffStream),
+		replacedBy: make(map[uint32]*ssrcRedirect),
+	}
+}
+
+// wrapBufferFactory observes RTP of each published stream, so it could be continued by another
+func (h *ssrcHandoff) wrapBufferFactory(
+	createBufferFunc func(packetType packetio.BufferPacketType, ssrc uint32) io.ReadWriteCloser,
+) func(packetType packetio.BufferPacketType, ssrc uint32) io.ReadWriteCloser {
+	return func(packetType packetio.BufferPacketType, ssrc uint32) io.ReadWriteCloser {
+		writer := createBufferFunc(packetType, ssrc)
+		if packetType != packetio.RTPBufferPacket {
+			return writer
+		}
+		s := &handoffStream{
+			ReadWriteCloser: writer,
+			handoff:         h,
+			ssrc:            ssrc,
+		}
+		h.lock.Lock()
+		h.streams[ssrc] = s
+		h.lock.Unlock()
+		return s
+	}
+}
+
+// replace continues the stream of the replaced SSRC with packets of the new one, clockRate is used to carry
+// timestamps over the time between the last packet of one and the first of the other.
+// It returns false when either stream wasn't received
+func (h *ssrcHandoff) replace(replaced, ssrc uint32, clockRate uint32) bool {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	from, to := h.streams[ssrc], h.streams[replaced]
+	if from == nil || to == nil {
+		return false
+	}
+	// a stream replaced before points to the one that was first received, buffers are only bound to that one
+	if r := to.getRedirect(); r != nil {
+		to = r.to
+	}
+
+	toSN, toTS, toArrival, ok := to.last()
+	if !ok {
+		return false
+	}
+	fromSN, fromTS, fromArrival, ok := from.last()
+	if !ok {
+		return false
+	}
+	// the packets the new stream was probed with aren't forwarded, its last one takes the place of the last
+	// packet of the replaced stream, so there's no gap in sequence numbers
+	elapsed := fromArrival.Sub(toArrival)
+	if elapsed < 0 {
+		elapsed = 0
+	}
+	ticks := uint32(elapsed.Seconds() * float64(clockRate))
+	r := &ssrcRedirect{
+		from:     ssrc,
+		to:       to,
+		snOffset: toSN - fromSN,
+		tsOffset: toTS + ticks - fromTS,
+	}
+	from.setRedirect(r)
+	h.replacedBy[to.ssrc] = r
+	return true
+}
+
+// BindRTCPWriter rewrites feedback on replaced streams for the streams replacing them
+func (h *ssrcHandoff) BindRTCPWriter(writer interceptor.RTCPWriter) interceptor.RTCPWriter {
+	return interceptor.RTCPWriterFunc(func(pkts []rtcp.Packet, attributes interceptor.Attributes) (int, error) {
+		h.lock.RLock()
+		replaced := len(h.replacedBy) != 0
+		h.lock.RUnlock()
+		if !replaced {
+			return writer.Write(pkts, attributes)
+		}
+		rewritten := make([]rtcp.Packet, len(pkts))
+		for i, pkt := range pkts {
+			rewritten[i] = h.rewriteOutgoing(pkt)
+		}
+		return writer.Write(rewritten, attributes)
+	})
+}
+
+func (h *ssrcHandoff) redirectFor(ssrc uint32) *ssrcRedirect {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+	return h.replacedBy[ssrc]
+}
+
+func (h *ssrcHandoff) toCurrent(ssrc uint32) uint32 {
+	if r := h.redirectFor(ssrc); r != nil {
+		return r.from
+	}
+	return ssrc
+}
+
+// rewriteOutgoing returns a copy of the packet for the current SSRCs, packets are left alone as they could be shared
+func (h *ssrcHandoff) rewriteOutgoing(pkt rtcp.Packet) rtcp.Packet {
+	rewriteReports := func(reports []rtcp.ReceptionReport) []rtcp.ReceptionReport {
+		rewritten := make([]rtcp.ReceptionReport, len(reports))
+		for i, report := range reports {
+			report.SSRC = h.toCurrent(report.SSRC)
+			rewritten[i] = report
+		}
+		return rewritten
+	}
+	switch p := pkt.(type) {
+	case *rtcp.PictureLossIndication:
+		pli := *p
+		pli.MediaSSRC = h.toCurrent(p.MediaSSRC)
+		return &pli
+	case *rtcp.FullIntraRequest:
+		fir := &rtcp.FullIntraRequest{SenderSSRC: p.SenderSSRC, MediaSSRC: h.toCurrent(p.MediaSSRC)}
+		for _, entry := range p.FIR {
+			entry.SSRC = h.toCurrent(entry.SSRC)
+			fir.FIR = append(fir.FIR, entry)
+		}
+		return fir
+	case *rtcp.TransportLayerNack:
+		r := h.redirectFor(p.MediaSSRC)
+		if r == nil {
+			return pkt
+		}
+		// lost packets are asked for by the sequence numbers the publisher sent them with
+		nack := &rtcp.TransportLayerNack{SenderSSRC: p.SenderSSRC, MediaSSRC: r.from}
+		for _, pair := range p.Nacks {
+			pair.PacketID -= r.snOffset
+			nack.Nacks = append(nack.Nacks, pair)
+		}
+		return nack
+	case *rtcp.ReceiverEstimatedMaximumBitrate:
+		remb := *p
+		remb.SSRCs = make([]uint32, len(p.SSRCs))
+		for i, ssrc := range p.SSRCs {
+			remb.SSRCs[i] = h.toCurrent(ssrc)
+		}
+		return &remb
+	case *rtcp.ReceiverReport:
+		rr := *p
+		rr.Reports = rewriteReports(p.Reports)
+		return &rr
+	}
+	return pkt
+}
+
+// handoffStream is the buffer of a published stream, it records the last packet written so another stream could
+// continue it, and writes to the stream it replaces once it does
+type handoffStream struct {
+	io.ReadWriteCloser
+	handoff *ssrcHandoff
+	ssrc    uint32
+
+	lock        sync.Mutex
+	received    bool
+	lastSN      uint16
+	lastTS      uint32
+	lastArrival time.Time
+	redirect    *ssrcRedirect
+}
+
+func (s *handoffStream) Write(p []byte) (int, error) {
+	if len(p) < 12 {
+		return s.ReadWriteCloser.Write(p)
+	}
+	s.lock.Lock()
+	r := s.redirect
+	if r == nil {
+		s.observe(p)
+	}
+	s.lock.Unlock()
+	if r == nil {
+		return s.ReadWriteCloser.Write(p)
+	}
+
+	rewritten := make([]byte, len(p))
+	copy(rewritten, p)
+	binary.BigEndian.PutUint16(rewritten[2:4], binary.BigEndian.Uint16(p[2:4])+r.snOffset)
+	binary.BigEndian.PutUint32(rewritten[4:8], binary.BigEndian.Uint32(p[4:8])+r.tsOffset)
+	binary.BigEndian.PutUint32(rewritten[8:12], r.to.ssrc)
+	r.to.lock.Lock()
+	r.to.observe(rewritten)
+	r.to.lock.Unlock()
+	if _, err := r.to.ReadWriteCloser.Write(rewritten); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// needs to be called with lock held
+func (s *handoffStream) observe(p []byte) {
+	sn := binary.BigEndian.Uint16(p[2:4])
+	if s.received && (sn-s.lastSN)&0x8000 != 0 {
+		// out of order or retransmitted
+		return
+	}
+	s.received = true
+	s.lastSN = sn
+	s.lastTS = binary.BigEndian.Uint32(p[4:8])
+	s.lastArrival = time.Now()
+}
+
+func (s *handoffStream) last() (sn uint16, ts uint32, arrival time.Time, ok bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.lastSN, s.lastTS, s.lastArrival, s.received
+}
+
+func (s *handoffStream) getRedirect() *ssrcRedirect {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.redirect
+}
+
+func (s *handoffStream) setRedirect(r *ssrcRedirect) {
+	s.lock.Lock()
+	s.redirect = r
+	s.lock.Unlock()
+}
+
+func (s *handoffStream) Close() error {
+	s.handoff.lock.Lock()
+	if s.handoff.streams[s.ssrc] == s {
+		delete(s.handoff.streams, s.ssrc)
+	}
+	delete(s.handoff.replacedBy, s.ssrc)
+	for replaced, r := range s.handoff.replacedBy {
+		if r.from == s.ssrc {
+			delete(s.handoff.replacedBy, replaced)
+		}
+	}
+	s.handoff.lock.Unlock()
+	return s.ReadWriteCloser.Close()
+}
@@ -0,0 +1,128 @@
+package rtc
+
+import (
+	"io"
+	"testing"
+
+	"github.com/pion/interceptor"
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+	"github.com/pion/transport/packetio"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSSRCHandoff(t *testing.T) {
+	setup := func(t *testing.T) (*ssrcHandoff, func(ssrc uint32) io.Writer, map[uint32]*rtpBuffer) {
+		h := newSSRCHandoff()
+		recorders := make(map[uint32]*rtpBuffer)
+		createBuffer := h.wrapBufferFactory(func(_ packetio.BufferPacketType, ssrc uint32) io.ReadWriteCloser {
+			recorders[ssrc] = &rtpBuffer{t: t}
+			return recorders[ssrc]
+		})
+		streams := make(map[uint32]io.Writer)
+		stream := func(ssrc uint32) io.Writer {
+			if streams[ssrc] == nil {
+				streams[ssrc] = createBuffer(packetio.RTPBufferPacket, ssrc)
+			}
+			return streams[ssrc]
+		}
+		return h, stream, recorders
+	}
+	write := func(t *testing.T, w io.Writer, ssrc uint32, sn uint16, ts uint32) {
+		pkt := &rtp.Packet{Header: rtp.Header{Version: 2, SSRC: ssrc, SequenceNumber: sn, Timestamp: ts}, Payload: []byte{1}}
+		b, err := pkt.Marshal()
+		require.NoError(t, err)
+		_, err = w.Write(b)
+		require.NoError(t, err)
+	}
+
+	t.Run("continues the replaced stream", func(t *testing.T) {
+		h, stream, recorders := setup(t)
+		for i := uint16(0); i < 3; i++ {
+			write(t, stream(1000), 1000, 65534+i, 9000+3000*uint32(i))
+		}
+		// the encoder restarts on another SSRC, with its first packet probed
+		write(t, stream(2000), 2000, 500, 100)
+		require.False(t, h.replace(1000, 3000, 90000))
+		require.True(t, h.replace(1000, 2000, 90000))
+
+		write(t, stream(2000), 2000, 501, 3100)
+		write(t, stream(2000), 2000, 502, 6100)
+		pkts := recorders[1000].packets
+		require.Len(t, pkts, 5)
+		for i, pkt := range pkts[3:] {
+			require.Equal(t, uint32(1000), pkt.SSRC)
+			require.Equal(t, uint16(1+i), pkt.SequenceNumber)
+			// timestamps keep increasing by the same amount from where the replaced stream stopped
+			require.GreaterOrEqual(t, pkt.Timestamp-15000, uint32(3000*(i+1)))
+			require.Less(t, pkt.Timestamp-15000, uint32(3000*(i+1)+9000))
+		}
+		require.Equal(t, uint32(3000), pkts[4].Timestamp-pkts[3].Timestamp)
+		require.Len(t, recorders[2000].packets, 1)
+	})
+
+	t.Run("rewrites feedback for the new SSRC", func(t *testing.T) {
+		h, stream, _ := setup(t)
+		write(t, stream(1000), 1000, 100, 0)
+		write(t, stream(2000), 2000, 10, 0)
+		require.True(t, h.replace(1000, 2000, 90000))
+
+		var pkts []rtcp.Packet
+		writer := h.BindRTCPWriter(interceptor.RTCPWriterFunc(func(p []rtcp.Packet, _ interceptor.Attributes) (int, error) {
+			pkts = p
+			return 0, nil
+		}))
+		pli := &rtcp.PictureLossIndication{MediaSSRC: 1000}
+		_, err := writer.Write([]rtcp.Packet{
+			pli,
+			&rtcp.TransportLayerNack{MediaSSRC: 1000, Nacks: []rtcp.NackPair{{PacketID: 102, LostPackets: 1}}},
+			&rtcp.ReceiverEstimatedMaximumBitrate{Bitrate: 1000, SSRCs: []uint32{1000, 5}},
+			&rtcp.ReceiverReport{Reports: []rtcp.ReceptionReport{{SSRC: 1000, LastSequenceNumber: 102}}},
+		}, nil)
+		require.NoError(t, err)
+		require.Equal(t, uint32(2000), pkts[0].(*rtcp.PictureLossIndication).MediaSSRC)
+		nack := pkts[1].(*rtcp.TransportLayerNack)
+		require.Equal(t, uint32(2000), nack.MediaSSRC)
+		require.Equal(t, []rtcp.NackPair{{PacketID: 12, LostPackets: 1}}, nack.Nacks)
+		require.Equal(t, []uint32{2000, 5}, pkts[2].(*rtcp.ReceiverEstimatedMaximumBitrate).SSRCs)
+		require.Equal(t, uint32(2000), pkts[3].(*rtcp.ReceiverReport).Reports[0].SSRC)
+		require.Equal(t, uint32(1000), pli.MediaSSRC)
+	})
+
+	t.Run("continues the first stream after another switch", func(t *testing.T) {
+		h, stream, recorders := setup(t)
+		write(t, stream(1000), 1000, 100, 0)
+		write(t, stream(2000), 2000, 10, 0)
+		require.True(t, h.replace(1000, 2000, 90000))
+		write(t, stream(2000), 2000, 11, 3000)
+		write(t, stream(3000), 3000, 70, 0)
+		require.True(t, h.replace(2000, 3000, 90000))
+		write(t, stream(3000), 3000, 71, 3000)
+
+		pkts := recorders[1000].packets
+		require.Len(t, pkts, 3)
+		require.Equal(t, uint16(102), pkts[2].SequenceNumber)
+		require.Equal(t, uint32(1000), pkts[2].SSRC)
+		require.Equal(t, uint32(3000), h.toCurrent(1000))
+	})
+}
+
+type rtpBuffer struct {
+	t       *testing.T
+	packets []rtp.Packet
+}
+
+func (r *rtpBuffer) Read(_ []byte) (int, error) {
+	return 0, io.EOF
+}
+
+func (r *rtpBuffer) Write(b []byte) (int, error) {
+	var pkt rtp.Packet
+	require.NoError(r.t, pkt.Unmarshal(b))
+	r.packets = append(r.packets, pkt)
+	return len(b), nil
+}
+
+func (r *rtpBuffer) Close() error {
+	return nil
+}
@@ -0,0 +1,308 @@
+package rtc
+
+import (
+	"hash/fnv"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pion/interceptor"
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+	"github.com/pion/transport/packetio"
+
+	"github.com/livekit/livekit-server/pkg/logger"
+)
+
+// remappedStream is a stream sent to the subscriber on a stable SSRC instead of the one picked by its RTPSender
+type remappedStream struct {
+	key      string
+	original uint32
+	stable   uint32
+}
+
+// ssrcRemapper sends streams to a subscriber on SSRCs derived from a stable key, so the same stream keeps its SSRC
+// across reconnects, for recorders that depend on it. RTPSenders pick random SSRCs, they're used as is within the
+// server and replaced on the way out: in offers, in RTP and RTCP sent to the subscriber, and back to the original
+// ones in RTCP received from it.
+// It adheres to the Pion interceptor interface, and has to be the innermost interceptor
+type ssrcRemapper struct {
+	interceptor.NoOp
+
+	lock       sync.RWMutex
+	byOriginal map[uint32]*remappedStream
+	byStable   map[uint32]*remappedStream
+	byKey      map[string]*remappedStream
+}
+
+func newSSRCRemapper() *ssrcRemapper {
+	return &ssrcRemapper{
+		byOriginal: make(map[uint32]*remappedStream),
+		byStable:   make(map[uint32]*remappedStream),
+		byKey:      make(map[string]*remappedStream),
+	}
+}
+
+// stableSSRC derives an SSRC from the key, it's never 0
+func stableSSRC(key string) uint32 {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	if ssrc := h.Sum32(); ssrc != 0 {
+		return ssrc
+	}
+	return 1
+}
+
+// register maps the original SSRC of a stream to the one derived from its key, and returns it. When it's already
+// taken by another stream of the subscriber, the next free one is used, which isn't guaranteed to be stable.
+// A stream registered again with the same key replaces the previous one, as when a track is subscribed again
+func (r *ssrcRemapper) register(original uint32, key string) uint32 {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if prev := r.byKey[key]; prev != nil {
+		r.remove(prev)
+	}
+
+	stable := stableSSRC(key)
+	for stable == 0 || r.byStable[stable] != nil || r.byOriginal[stable] != nil {
+		logger.Infow("stable SSRC taken, trying the next one", "key", key, "ssrc", stable)
+		stable++
+	}
+	s := &remappedStream{
+		key:      key,
+		original: original,
+		stable:   stable,
+	}
+	r.byOriginal[original] = s
+	r.byStable[stable] = s
+	r.byKey[key] = s
+	return stable
+}
+
+func (r *ssrcRemapper) unregister(original uint32) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if s := r.byOriginal[original]; s != nil {
+		r.remove(s)
+	}
+}
+
+// needs to be called with lock held
+func (r *ssrcRemapper) remove(s *remappedStream) {
+	delete(r.byOriginal, s.original)
+	delete(r.byStable, s.stable)
+	if r.byKey[s.key] == s {
+		delete(r.byKey, s.key)
+	}
+}
+
+// toStable returns the SSRC the subscriber knows the stream by
+func (r *ssrcRemapper) toStable(original uint32) uint32 {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	if s := r.byOriginal[original]; s != nil {
+		return s.stable
+	}
+	return original
+}
+
+// toOriginal returns the SSRC the server knows the stream by
+func (r *ssrcRemapper) toOriginal(stable uint32) uint32 {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	if s := r.byStable[stable]; s != nil {
+		return s.original
+	}
+	return stable
+}
+
+func (r *ssrcRemapper) isStable(ssrc uint32) bool {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.byStable[ssrc] != nil
+}
+
+// mungeSDP replaces original SSRCs in ssrc and ssrc-group attributes of a local description sent to the subscriber
+func (r *ssrcRemapper) mungeSDP(sdp string) string {
+	lines := strings.Split(sdp, "\r\n")
+	for i, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "a=ssrc:"):
+			fields := strings.SplitN(strings.TrimPrefix(line, "a=ssrc:"), " ", 2)
+			fields[0] = r.mungeSSRC(fields[0])
+			lines[i] = "a=ssrc:" + strings.Join(fields, " ")
+		case strings.HasPrefix(line, "a=ssrc-group:"):
+			fields := strings.Split(line, " ")
+			for j := 1; j < len(fields); j++ {
+				fields[j] = r.mungeSSRC(fields[j])
+			}
+			lines[i] = strings.Join(fields, " ")
+		}
+	}
+	return strings.Join(lines, "\r\n")
+}
+
+func (r *ssrcRemapper) mungeSSRC(field string) string {
+	ssrc, err := strconv.ParseUint(field, 10, 32)
+	if err != nil {
+		return field
+	}
+	return strconv.FormatUint(uint64(r.toStable(uint32(ssrc))), 10)
+}
+
+// BindLocalStream sends RTP of remapped streams on their stable SSRC
+func (r *ssrcRemapper) BindLocalStream(info *interceptor.StreamInfo, writer interceptor.RTPWriter) interceptor.RTPWriter {
+	stable := r.toStable(info.SSRC)
+	if stable == info.SSRC {
+		return writer
+	}
+	return interceptor.RTPWriterFunc(func(header *rtp.Header, payload []byte, attributes interceptor.Attributes) (int, error) {
+		remapped := *header
+		remapped.SSRC = stable
+		return writer.Write(&remapped, payload, attributes)
+	})
+}
+
+func (r *ssrcRemapper) UnbindLocalStream(info *interceptor.StreamInfo) {
+	r.unregister(info.SSRC)
+}
+
+// BindRTCPWriter replaces original SSRCs in sender reports, source descriptions and goodbyes sent to the subscriber
+func (r *ssrcRemapper) BindRTCPWriter(writer interceptor.RTCPWriter) interceptor.RTCPWriter {
+	return interceptor.RTCPWriterFunc(func(pkts []rtcp.Packet, attributes interceptor.Attributes) (int, error) {
+		remapped := make([]rtcp.Packet, len(pkts))
+		for i, pkt := range pkts {
+			remapped[i] = r.remapOutgoing(pkt)
+		}
+		return writer.Write(remapped, attributes)
+	})
+}
+
+// remapOutgoing returns a copy of the packet with stable SSRCs, packets are left alone as they could be shared
+func (r *ssrcRemapper) remapOutgoing(pkt rtcp.Packet) rtcp.Packet {
+	switch p := pkt.(type) {
+	case *rtcp.SenderReport:
+		sr := *p
+		sr.SSRC = r.toStable(p.SSRC)
+		return &sr
+	case *rtcp.SourceDescription:
+		sdes := &rtcp.SourceDescription{Chunks: make([]rtcp.SourceDescriptionChunk, len(p.Chunks))}
+		for i, chunk := range p.Chunks {
+			chunk.Source = r.toStable(chunk.Source)
+			sdes.Chunks[i] = chunk
+		}
+		return sdes
+	case *rtcp.Goodbye:
+		bye := &rtcp.Goodbye{Sources: make([]uint32, len(p.Sources)), Reason: p.Reason}
+		for i, source := range p.Sources {
+			bye.Sources[i] = r.toStable(source)
+		}
+		return bye
+	}
+	return pkt
+}
+
+// remapIncoming replaces stable SSRCs of streams the subscriber gives feedback on with the original ones
+func (r *ssrcRemapper) remapIncoming(pkts []rtcp.Packet) {
+	remapReports := func(reports []rtcp.ReceptionReport) {
+		for i := range reports {
+			reports[i].SSRC = r.toOriginal(reports[i].SSRC)
+		}
+	}
+	for _, pkt := range pkts {
+		switch p := pkt.(type) {
+		case *rtcp.ReceiverReport:
+			remapReports(p.Reports)
+		case *rtcp.SenderReport:
+			remapReports(p.Reports)
+		case *rtcp.PictureLossIndication:
+			p.MediaSSRC = r.toOriginal(p.MediaSSRC)
+		case *rtcp.FullIntraRequest:
+			p.MediaSSRC = r.toOriginal(p.MediaSSRC)
+			for i := range p.FIR {
+				p.FIR[i].SSRC = r.toOriginal(p.FIR[i].SSRC)
+			}
+		case *rtcp.TransportLayerNack:
+			p.MediaSSRC = r.toOriginal(p.MediaSSRC)
+		case *rtcp.SliceLossIndication:
+			p.MediaSSRC = r.toOriginal(p.MediaSSRC)
+		case *rtcp.RapidResynchronizationRequest:
+			p.MediaSSRC = r.toOriginal(p.MediaSSRC)
+		case *rtcp.TransportLayerCC:
+			p.MediaSSRC = r.toOriginal(p.MediaSSRC)
+		case *rtcp.ReceiverEstimatedMaximumBitrate:
+			for i := range p.SSRCs {
+				p.SSRCs[i] = r.toOriginal(p.SSRCs[i])
+			}
+		}
+	}
+}
+
+// wrapBufferFactory routes RTCP the subscriber sends on stable SSRCs to the buffers of the original ones, which
+// DownTracks read from. It has to wrap all other buffer factories, so they only see original SSRCs
+func (r *ssrcRemapper) wrapBufferFactory(
+	createBufferFunc func(packetType packetio.BufferPacketType, ssrc uint32) io.ReadWriteCloser,
+) func(packetType packetio.BufferPacketType, ssrc uint32) io.ReadWriteCloser {
+	return func(packetType packetio.BufferPacketType, ssrc uint32) io.ReadWriteCloser {
+		if packetType != packetio.RTCPBufferPacket || !r.isStable(ssrc) {
+			return createBufferFunc(packetType, ssrc)
+		}
+		return &remappedRTCPWriter{
+			remapper:         r,
+			stable:           ssrc,
+			createBufferFunc: createBufferFunc,
+		}
+	}
+}
+
+// remappedRTCPWriter looks up the original SSRC on each write, the stable one outlives streams that are subscribed
+// to again
+type remappedRTCPWriter struct {
+	remapper         *ssrcRemapper
+	stable           uint32
+	createBufferFunc func(packetType packetio.BufferPacketType, ssrc uint32) io.ReadWriteCloser
+
+	lock     sync.Mutex
+	original uint32
+	buffer   io.ReadWriteCloser
+}
+
+func (w *remappedRTCPWriter) Write(p []byte) (int, error) {
+	original := w.remapper.toOriginal(w.stable)
+	if original == w.stable {
+		// the stream is gone
+		return len(p), nil
+	}
+	pkts, err := rtcp.Unmarshal(p)
+	if err != nil {
+		return len(p), nil
+	}
+	w.remapper.remapIncoming(pkts)
+	b, err := rtcp.Marshal(pkts)
+	if err != nil {
+		return len(p), nil
+	}
+
+	w.lock.Lock()
+	if w.buffer == nil || w.original != original {
+		w.original = original
+		w.buffer = w.createBufferFunc(packetio.RTCPBufferPacket, original)
+	}
+	buffer := w.buffer
+	w.lock.Unlock()
+	if _, err := buffer.Write(b); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Read isn't used, RTCP is read from the buffer of the original SSRC
+func (w *remappedRTCPWriter) Read(_ []byte) (int, error) {
+	return 0, io.EOF
+}
+
+// Close leaves the buffer of the original SSRC open, it's closed along with the stream
+func (w *remappedRTCPWriter) Close() error {
+	return nil
+}
@@ -0,0 +1,129 @@
+package rtc
+
+import (
+	"io"
+	"strconv"
+	"testing"
+
+	"github.com/pion/interceptor"
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+	"github.com/pion/transport/packetio"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSSRCRemapper(t *testing.T) {
+	t.Run("derives the same SSRC from the same key", func(t *testing.T) {
+		first := newSSRCRemapper().register(1000, "alice/VIDEO/camera")
+		// a reconnect gets a new RTPSender with another random SSRC
+		second := newSSRCRemapper().register(2000, "alice/VIDEO/camera")
+		require.Equal(t, first, second)
+		require.Equal(t, stableSSRC("alice/VIDEO/camera"), first)
+		require.NotEqual(t, first, newSSRCRemapper().register(1000, "alice/AUDIO/microphone"))
+	})
+
+	t.Run("skips SSRCs taken by other streams", func(t *testing.T) {
+		r := newSSRCRemapper()
+		taken := stableSSRC("bob/VIDEO/camera")
+		require.Equal(t, stableSSRC("alice/VIDEO/camera"), r.register(taken, "alice/VIDEO/camera"))
+		require.Equal(t, taken+1, r.register(3000, "bob/VIDEO/camera"))
+		require.Equal(t, uint32(3000), r.toOriginal(taken+1))
+	})
+
+	t.Run("replaces a stream subscribed to again", func(t *testing.T) {
+		r := newSSRCRemapper()
+		stable := r.register(1000, "alice/VIDEO/camera")
+		require.Equal(t, stable, r.register(2000, "alice/VIDEO/camera"))
+		require.Equal(t, uint32(1000), r.toStable(1000))
+		require.Equal(t, uint32(2000), r.toOriginal(stable))
+
+		// unbinding the previous stream leaves the new one alone
+		r.unregister(1000)
+		require.Equal(t, stable, r.toStable(2000))
+		r.unregister(2000)
+		require.False(t, r.isStable(stable))
+	})
+
+	t.Run("munges SSRCs of offers", func(t *testing.T) {
+		r := newSSRCRemapper()
+		stable := r.register(1000, "alice/VIDEO/camera")
+		sdp := "m=video 9 UDP/TLS/RTP/SAVPF 96\r\n" +
+			"a=ssrc-group:FID 1000 1001\r\n" +
+			"a=ssrc:1000 cname:alice\r\n" +
+			"a=ssrc:1001 cname:alice\r\n"
+		require.Equal(t, "m=video 9 UDP/TLS/RTP/SAVPF 96\r\n"+
+			"a=ssrc-group:FID "+itoa(stable)+" 1001\r\n"+
+			"a=ssrc:"+itoa(stable)+" cname:alice\r\n"+
+			"a=ssrc:1001 cname:alice\r\n", r.mungeSDP(sdp))
+	})
+
+	t.Run("rewrites outgoing RTP and RTCP", func(t *testing.T) {
+		r := newSSRCRemapper()
+		stable := r.register(1000, "alice/VIDEO/camera")
+
+		var written uint32
+		writer := r.BindLocalStream(&interceptor.StreamInfo{SSRC: 1000},
+			interceptor.RTPWriterFunc(func(header *rtp.Header, payload []byte, _ interceptor.Attributes) (int, error) {
+				written = header.SSRC
+				return len(payload), nil
+			}))
+		header := &rtp.Header{SSRC: 1000}
+		_, err := writer.Write(header, []byte{1}, nil)
+		require.NoError(t, err)
+		require.Equal(t, stable, written)
+		require.Equal(t, uint32(1000), header.SSRC)
+
+		var pkts []rtcp.Packet
+		rtcpWriter := r.BindRTCPWriter(interceptor.RTCPWriterFunc(func(p []rtcp.Packet, _ interceptor.Attributes) (int, error) {
+			pkts = p
+			return 0, nil
+		}))
+		sr := &rtcp.SenderReport{SSRC: 1000}
+		_, err = rtcpWriter.Write([]rtcp.Packet{
+			sr,
+			&rtcp.SourceDescription{Chunks: []rtcp.SourceDescriptionChunk{{Source: 1000}, {Source: 5}}},
+		}, nil)
+		require.NoError(t, err)
+		require.Equal(t, stable, pkts[0].(*rtcp.SenderReport).SSRC)
+		require.Equal(t, stable, pkts[1].(*rtcp.SourceDescription).Chunks[0].Source)
+		require.Equal(t, uint32(5), pkts[1].(*rtcp.SourceDescription).Chunks[1].Source)
+		require.Equal(t, uint32(1000), sr.SSRC)
+	})
+
+	t.Run("routes incoming RTCP to buffers of original SSRCs", func(t *testing.T) {
+		r := newSSRCRemapper()
+		stable := r.register(1000, "alice/VIDEO/camera")
+		buffers := make(map[uint32]*closingBuffer)
+		createBuffer := r.wrapBufferFactory(func(_ packetio.BufferPacketType, ssrc uint32) io.ReadWriteCloser {
+			if buffers[ssrc] == nil {
+				buffers[ssrc] = &closingBuffer{}
+			}
+			return buffers[ssrc]
+		})
+
+		unmapped := createBuffer(packetio.RTCPBufferPacket, 2000)
+		require.Equal(t, buffers[2000], unmapped)
+		writer := createBuffer(packetio.RTCPBufferPacket, stable)
+		b, err := rtcp.Marshal([]rtcp.Packet{&rtcp.PictureLossIndication{SenderSSRC: 1, MediaSSRC: stable}})
+		require.NoError(t, err)
+		_, err = writer.Write(b)
+		require.NoError(t, err)
+
+		pkts, err := rtcp.Unmarshal(buffers[1000].Bytes())
+		require.NoError(t, err)
+		require.Equal(t, uint32(1000), pkts[0].(*rtcp.PictureLossIndication).MediaSSRC)
+		require.Nil(t, buffers[stable])
+
+		// subscribed to again on another sender
+		buffers[1000].Reset()
+		r.register(3000, "alice/VIDEO/camera")
+		_, err = writer.Write(b)
+		require.NoError(t, err)
+		require.Zero(t, buffers[1000].Len())
+		require.NotZero(t, buffers[3000].Len())
+	})
+}
+
+func itoa(ssrc uint32) string {
+	return strconv.FormatUint(uint64(ssrc), 10)
+}
@@ -5,6 +5,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/livekit/protocol/utils"
 	"github.com/pion/interceptor"
 	"github.com/pion/rtcp"
 	"github.com/pion/rtp"
@@ -69,6 +70,138 @@ var (
 		Subsystem: "track",
 		Name:      "subscribed_total",
 	}, []string{"kind"})
+	// time from AddSubscriber to the DownTrack being bound, and to its first packet being sent,
+	// labeled by the number of subscriptions being set up concurrently in the room
+	subscriptionBindDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: livekitNamespace,
+		Subsystem: "subscription",
+		Name:      "bind_seconds",
+		Buckets:   subscriptionBuckets,
+	}, []string{"kind", "concurrency"})
+	subscriptionFirstMediaDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: livekitNamespace,
+		Subsystem: "subscription",
+		Name:      "first_media_seconds",
+		Buckets:   subscriptionBuckets,
+	}, []string{"kind", "concurrency"})
+	subscriptionBuckets = []float64{.01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+	// utilization of the worker pools running RTCP and reporting work of rooms
+	workerPoolWorkers = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: livekitNamespace,
+		Subsystem: "worker_pool",
+		Name:      "workers",
+	})
+	workerPoolBusy = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: livekitNamespace,
+		Subsystem: "worker_pool",
+		Name:      "busy",
+	})
+	workerPoolDroppedTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: livekitNamespace,
+		Subsystem: "worker_pool",
+		Name:      "dropped_total",
+	})
+	// packets of video streams dropped because they exceeded the participant's buffer limit
+	bufferDroppedPacketTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: livekitNamespace,
+		Subsystem: "buffer",
+		Name:      "dropped_packet_total",
+	})
+	// subscriber telemetry, only observed with the metrics sink
+	subscriberEstimatedBandwidth = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: livekitNamespace,
+		Subsystem: "subscriber",
+		Name:      "estimated_bandwidth_bps",
+		Buckets:   prometheus.ExponentialBuckets(100_000, 2, 8),
+	})
+	subscriberFractionLost = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: livekitNamespace,
+		Subsystem: "subscriber",
+		Name:      "fraction_lost",
+		Buckets:   []float64{0, .01, .02, .05, .1, .2, .5},
+	})
+	subscriberRTT = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: livekitNamespace,
+		Subsystem: "subscriber",
+		Name:      "rtt_seconds",
+		Buckets:   []float64{.01, .025, .05, .1, .2, .4, .8, 1.6},
+	})
+	telemetryDroppedTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: livekitNamespace,
+		Subsystem: "subscriber",
+		Name:      "telemetry_dropped_total",
+	})
+	// room quality samples dropped because the sink couldn't keep up, or its file is full
+	qualitySampleDroppedTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: livekitNamespace,
+		Subsystem: "room",
+		Name:      "quality_sample_dropped_total",
+	})
+	// subscriptions rejected because the node reached max_subscriptions, and the fraction of it in use
+	subscriptionRejectedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: livekitNamespace,
+		Subsystem: "subscription",
+		Name:      "rejected_total",
+	}, []string{"kind"})
+	subscriptionUtilization = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: livekitNamespace,
+		Subsystem: "subscription",
+		Name:      "utilization",
+	})
+	// subscriptions whose media section the subscriber rejected or left out of its answer
+	subscriptionAnswerRejectedTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: livekitNamespace,
+		Subsystem: "subscription",
+		Name:      "answer_rejected_total",
+	})
+	// time from a keyframe request sent to a publisher to the keyframe it sent, and requests it never answered
+	keyframeLatencySeconds = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: livekitNamespace,
+		Subsystem: "publisher",
+		Name:      "keyframe_latency_seconds",
+		Buckets:   []float64{.025, .05, .1, .25, .5, 1, 2, 3, 5},
+	})
+	keyframeRequestUnansweredTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: livekitNamespace,
+		Subsystem: "publisher",
+		Name:      "keyframe_request_unanswered_total",
+	})
+	// RTCP dropped on its way to publishers because their queue was full, feedback or keyframe requests
+	rtcpDroppedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: livekitNamespace,
+		Subsystem: "rtcp",
+		Name:      "dropped_total",
+	}, []string{"kind"})
+	// negotiations recovered after being stuck in a signaling state
+	negotiationStuckTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: livekitNamespace,
+		Subsystem: "negotiation",
+		Name:      "stuck_total",
+	}, []string{"target", "state"})
+	// participants that didn't gather a viable ICE candidate in time, by what was done about it
+	iceGatheringTimeoutTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: livekitNamespace,
+		Subsystem: "ice",
+		Name:      "gathering_timeout_total",
+	}, []string{"action"})
+	// times publishers became unable to send their target bitrate
+	publisherCongestedTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: livekitNamespace,
+		Subsystem: "publisher",
+		Name:      "congested_total",
+	})
+	// times subscribers were forwarded another layer because the publisher stopped sending theirs
+	layerFallbackTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: livekitNamespace,
+		Subsystem: "simulcast",
+		Name:      "layer_fallback_total",
+	})
+	// data packets dropped for exceeding the rate limit of their sender or of the room
+	dataDroppedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: livekitNamespace,
+		Subsystem: "data",
+		Name:      "rate_limited_total",
+	}, []string{"kind", "scope"})
 )
 
 func init() {
@@ -82,6 +215,28 @@ func init() {
 	prometheus.MustRegister(participantTotal)
 	prometheus.MustRegister(trackPublishedTotal)
 	prometheus.MustRegister(trackSubscribedTotal)
+	prometheus.MustRegister(subscriptionBindDuration)
+	prometheus.MustRegister(subscriptionFirstMediaDuration)
+	prometheus.MustRegister(workerPoolWorkers)
+	prometheus.MustRegister(workerPoolBusy)
+	prometheus.MustRegister(workerPoolDroppedTotal)
+	prometheus.MustRegister(bufferDroppedPacketTotal)
+	prometheus.MustRegister(subscriberEstimatedBandwidth)
+	prometheus.MustRegister(subscriberFractionLost)
+	prometheus.MustRegister(subscriberRTT)
+	prometheus.MustRegister(telemetryDroppedTotal)
+	prometheus.MustRegister(qualitySampleDroppedTotal)
+	prometheus.MustRegister(subscriptionRejectedTotal)
+	prometheus.MustRegister(subscriptionUtilization)
+	prometheus.MustRegister(subscriptionAnswerRejectedTotal)
+	prometheus.MustRegister(keyframeLatencySeconds)
+	prometheus.MustRegister(keyframeRequestUnansweredTotal)
+	prometheus.MustRegister(rtcpDroppedTotal)
+	prometheus.MustRegister(negotiationStuckTotal)
+	prometheus.MustRegister(iceGatheringTimeoutTotal)
+	prometheus.MustRegister(publisherCongestedTotal)
+	prometheus.MustRegister(dataDroppedTotal)
+	prometheus.MustRegister(layerFallbackTotal)
 }
 
 // RoomStatsReporter is created for each room
@@ -90,6 +245,9 @@ type RoomStatsReporter struct {
 	startedAt time.Time
 	incoming  *PacketStats
 	outgoing  *PacketStats
+
+	// subscriptions that have yet to send their first packet
+	pendingSubscriptions int32
 }
 
 func NewRoomStatsReporter(roomName string) *RoomStatsReporter {
@@ -136,6 +294,75 @@ func (r *RoomStatsReporter) SubSubscribedTrack(kind string) {
 	trackSubscribedTotal.WithLabelValues(kind).Sub(1)
 }
 
+// StartSubscription begins measuring setup latency of a subscription
+func (r *RoomStatsReporter) StartSubscription(kind string) *SubscriptionTimer {
+	t := &SubscriptionTimer{
+		reporter:  r,
+		kind:      kind,
+		startedAt: time.Now(),
+	}
+	pending := int32(1)
+	if r != nil {
+		pending = atomic.AddInt32(&r.pendingSubscriptions, 1)
+	}
+	t.concurrency = concurrencyLabel(pending)
+	return t
+}
+
+func concurrencyLabel(pending int32) string {
+	switch {
+	case pending <= 1:
+		return "1"
+	case pending <= 5:
+		return "2-5"
+	case pending <= 20:
+		return "6-20"
+	default:
+		return "21+"
+	}
+}
+
+// SubscriptionTimer measures how long it takes for a single subscription to be bound, and to send its first packet
+type SubscriptionTimer struct {
+	reporter    *RoomStatsReporter
+	kind        string
+	concurrency string
+	startedAt   time.Time
+	bound       utils.AtomicFlag
+	done        utils.AtomicFlag
+}
+
+// Bound records the time taken until the DownTrack was bound
+func (t *SubscriptionTimer) Bound() {
+	if t.done.Get() || !t.bound.TrySet(true) {
+		return
+	}
+	subscriptionBindDuration.WithLabelValues(t.kind, t.concurrency).Observe(time.Since(t.startedAt).Seconds())
+}
+
+// FirstMedia records the time taken until the first packet was sent, and completes the measurement
+func (t *SubscriptionTimer) FirstMedia() {
+	if !t.finish() {
+		return
+	}
+	subscriptionFirstMediaDuration.WithLabelValues(t.kind, t.concurrency).Observe(time.Since(t.startedAt).Seconds())
+}
+
+// Stop completes the measurement without recording first media, when the subscription ended or timed out
+func (t *SubscriptionTimer) Stop() {
+	t.finish()
+}
+
+func (t *SubscriptionTimer) finish() bool {
+	if !t.done.TrySet(true) {
+		return false
+	}
+	if t.reporter != nil {
+		atomic.AddInt32(&t.reporter.pendingSubscriptions, -1)
+	}
+	return true
+}
+
 type PacketStats struct {
 	roomName  string
 	direction string // incoming or outgoing
@@ -0,0 +1,54 @@
+package rtc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubscriptionTimer(t *testing.T) {
+	t.Run("labels by concurrent subscriptions", func(t *testing.T) {
+		r := NewRoomStatsReporter("room")
+		timers := make([]*SubscriptionTimer, 0, 6)
+		for i := 0; i < 6; i++ {
+			timers = append(timers, r.StartSubscription("video"))
+		}
+		require.Equal(t, "1", timers[0].concurrency)
+		require.Equal(t, "2-5", timers[1].concurrency)
+		require.Equal(t, "2-5", timers[4].concurrency)
+		require.Equal(t, "6-20", timers[5].concurrency)
+		require.Equal(t, int32(6), r.pendingSubscriptions)
+
+		for _, timer := range timers {
+			timer.Stop()
+		}
+		require.Equal(t, int32(0), r.pendingSubscriptions)
+		require.Equal(t, "1", r.StartSubscription("video").concurrency)
+	})
+
+	t.Run("stops measuring once completed", func(t *testing.T) {
+		r := NewRoomStatsReporter("room")
+		timer := r.StartSubscription("audio")
+		timer.Bound()
+		require.True(t, timer.bound.Get())
+		timer.FirstMedia()
+		require.Equal(t, int32(0), r.pendingSubscriptions)
+		// closing after first media doesn't complete it twice
+		timer.Stop()
+		require.Equal(t, int32(0), r.pendingSubscriptions)
+
+		stopped := r.StartSubscription("audio")
+		stopped.Stop()
+		stopped.Bound()
+		require.False(t, stopped.bound.Get())
+		require.Equal(t, int32(0), r.pendingSubscriptions)
+	})
+
+	t.Run("works without a reporter", func(t *testing.T) {
+		var r *RoomStatsReporter
+		timer := r.StartSubscription("video")
+		timer.Bound()
+		timer.FirstMedia()
+		timer.Stop()
+	})
+}
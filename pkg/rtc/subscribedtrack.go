@@ -1,78 +1,497 @@
 package rtc
 
 import (
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/bep/debounce"
 	"github.com/pion/ion-sfu/pkg/sfu"
+	"github.com/pion/rtcp"
 	"github.com/pion/webrtc/v3"
 
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/logger"
 	livekit "github.com/livekit/livekit-server/proto"
 	"github.com/livekit/protocol/utils"
 )
 
 const (
 	subscriptionDebounceInterval = 100 * time.Millisecond
+	// time without bitrate spikes before a subscriber is switched back to its target layer
+	spikeDowngradeHold = 2 * time.Second
 )
 
 type SubscribedTrack struct {
-	dt        *sfu.DownTrack
+	sourceLock sync.RWMutex
+	// replaced when the publisher starts or stops simulcasting the track
+	dt       *sfu.DownTrack
+	receiver sfu.Receiver
+
+	layers    *simulcastLayers
 	subMuted  utils.AtomicFlag
 	pubMuted  utils.AtomicFlag
+	paused    utils.AtomicFlag
+	bound     utils.AtomicFlag
 	debouncer func(func())
+	// spatial layer the subscriber asked for
+	targetLayer int32
+	// set once the subscriber asks for a quality, defaults of its device class don't apply from then on
+	qualityRequested utils.AtomicFlag
+	// set for recorders, the target layer is kept regardless of quality requests and bitrates. Only set before
+	// the track is used
+	pinned bool
+	// SSRC of the stream sent to the subscriber, 0 until bound
+	ssrc uint32
+
+	onConsumedLayerChange func()
+	// set by the MediaTrack, moves the subscription to another receiver of the track
+	moveToReceiver func(receiver sfu.Receiver)
+
+	// set while video is paused to fit in the subscriber's download budget
+	budgetPaused utils.AtomicFlag
+	// number of spatial layers forwarded from the lowest, lowered by the subscription allocator to fit in the
+	// download budget. 0 while it isn't limited
+	allowedLayers int32
+	// same, lowered to fit the estimated bandwidth of the subscriber
+	bandwidthLayers int32
+
+	spikeLock sync.Mutex
+	// set while the subscriber is switched a layer below its target because of a bitrate spike
+	spikeDowngraded bool
+	spikeRestore    *time.Timer
+
+	fallbackLock sync.Mutex
+	// layer last switched to, another one than the target while falling back
+	forwardedLayer int32
+	// when the target was sent again while falling back, zero otherwise
+	targetRecoveredAt time.Time
+
+	reportLock sync.Mutex
+	// latest reception report of the subscriber
+	reception receptionReport
+
+	codecLock sync.RWMutex
+	// codec negotiated with the subscriber, empty until the track is bound
+	codec         webrtc.RTPCodecParameters
+	onCodecChange func(codec webrtc.RTPCodecParameters)
 }
 
-func NewSubscribedTrack(dt *sfu.DownTrack) *SubscribedTrack {
+func NewSubscribedTrack(dt *sfu.DownTrack, receiver sfu.Receiver, layers *simulcastLayers, targetLayer int32) *SubscribedTrack {
 	return &SubscribedTrack{
-		dt:        dt,
-		debouncer: debounce.New(subscriptionDebounceInterval),
+		dt:             dt,
+		receiver:       receiver,
+		layers:         layers,
+		debouncer:      debounce.New(subscriptionDebounceInterval),
+		targetLayer:    targetLayer,
+		forwardedLayer: targetLayer,
 	}
 }
 
 func (t *SubscribedTrack) ID() string {
-	return t.dt.ID()
+	return t.DownTrack().ID()
 }
 
 func (t *SubscribedTrack) DownTrack() *sfu.DownTrack {
+	t.sourceLock.RLock()
+	defer t.sourceLock.RUnlock()
 	return t.dt
 }
 
+func (t *SubscribedTrack) currentReceiver() sfu.Receiver {
+	t.sourceLock.RLock()
+	defer t.sourceLock.RUnlock()
+	return t.receiver
+}
+
+// setSource forwards the track from receiver through dt, once the publisher started or stopped simulcasting it.
+// The subscription is unbound until dt is
+func (t *SubscribedTrack) setSource(dt *sfu.DownTrack, receiver sfu.Receiver) {
+	t.sourceLock.Lock()
+	t.dt = dt
+	t.receiver = receiver
+	t.sourceLock.Unlock()
+	t.bound.TrySet(false)
+}
+
 // has subscriber indicated it wants to mute this track
 func (t *SubscribedTrack) IsMuted() bool {
 	return t.subMuted.Get()
 }
 
+func (t *SubscribedTrack) IsPublisherMuted() bool {
+	return t.pubMuted.Get()
+}
+
 func (t *SubscribedTrack) SetPublisherMuted(muted bool) {
 	t.pubMuted.TrySet(muted)
 	t.updateDownTrackMute()
 }
 
+func (t *SubscribedTrack) IsPaused() bool {
+	return t.paused.Get()
+}
+
+func (t *SubscribedTrack) IsBound() bool {
+	return t.bound.Get()
+}
+
+// Codec returns the codec negotiated with the subscriber, including its payload type
+func (t *SubscribedTrack) Codec() webrtc.RTPCodecParameters {
+	t.codecLock.RLock()
+	defer t.codecLock.RUnlock()
+	return t.codec
+}
+
+// OnCodecChange is called when the track is bound, and again if it's bound to a different codec after renegotiation.
+// f is called right away when the track is already bound
+func (t *SubscribedTrack) OnCodecChange(f func(codec webrtc.RTPCodecParameters)) {
+	t.codecLock.Lock()
+	t.onCodecChange = f
+	codec := t.codec
+	t.codecLock.Unlock()
+
+	if f != nil && codec.MimeType != "" {
+		f(codec)
+	}
+}
+
+func (t *SubscribedTrack) SSRC() uint32 {
+	return atomic.LoadUint32(&t.ssrc)
+}
+
+func (t *SubscribedTrack) onBind(codec webrtc.RTPCodecParameters, ssrc webrtc.SSRC) {
+	atomic.StoreUint32(&t.ssrc, uint32(ssrc))
+	t.setCodec(codec)
+}
+
+func (t *SubscribedTrack) setReceptionReport(report rtcp.ReceptionReport) {
+	t.reportLock.Lock()
+	defer t.reportLock.Unlock()
+	now := time.Now()
+	t.reception = receptionReport{
+		fractionLost: report.FractionLost,
+		jitter:       report.Jitter,
+		rtt:          roundTripTime(report, now),
+		at:           now,
+	}
+}
+
+// receptionReport returns the latest report of the subscriber, unless it's older than maxAge
+func (t *SubscribedTrack) receptionReport(maxAge time.Duration) (receptionReport, bool) {
+	t.reportLock.Lock()
+	defer t.reportLock.Unlock()
+	if t.reception.at.IsZero() || time.Since(t.reception.at) > maxAge {
+		return receptionReport{}, false
+	}
+	return t.reception, true
+}
+
+func (t *SubscribedTrack) setCodec(codec webrtc.RTPCodecParameters) {
+	t.codecLock.Lock()
+	changed := t.codec.PayloadType != codec.PayloadType || !strings.EqualFold(t.codec.MimeType, codec.MimeType) ||
+		t.codec.SDPFmtpLine != codec.SDPFmtpLine
+	t.codec = codec
+	onCodecChange := t.onCodecChange
+	t.codecLock.Unlock()
+
+	if changed && onCodecChange != nil {
+		onCodecChange(codec)
+	}
+}
+
+// SetPaused stops forwarding without removing the subscription, used when the room stops auto subscribing
+func (t *SubscribedTrack) SetPaused(paused bool) {
+	if t.paused.TrySet(paused) {
+		t.consumedLayerChanged()
+	}
+	t.updateDownTrackMute()
+}
+
+// setBudgetPaused pauses video that doesn't fit in the subscriber's download budget
+func (t *SubscribedTrack) setBudgetPaused(paused bool) {
+	if t.budgetPaused.TrySet(paused) {
+		t.consumedLayerChanged()
+	}
+	t.updateDownTrackMute()
+}
+
+// setLayerCap limits video to layer to fit in the subscriber's download budget, maxSpatialLayer to lift the limit.
+// Recorders aren't limited
+func (t *SubscribedTrack) setLayerCap(layer int32) {
+	t.limitLayers(&t.allowedLayers, layer)
+}
+
+// setBandwidthLayerCap limits video to layer to fit the subscriber's estimated bandwidth, maxSpatialLayer to lift
+// the limit. Recorders aren't limited
+func (t *SubscribedTrack) setBandwidthLayerCap(layer int32) {
+	t.limitLayers(&t.bandwidthLayers, layer)
+}
+
+func (t *SubscribedTrack) limitLayers(limit *int32, layer int32) {
+	allowed := layer + 1
+	if layer >= maxSpatialLayer {
+		allowed = 0
+	}
+	if t.DownTrack().Kind() != webrtc.RTPCodecTypeVideo || t.pinned || atomic.SwapInt32(limit, allowed) == allowed {
+		return
+	}
+	t.consumedLayerChanged()
+	if t.consumedLayer() >= 0 {
+		t.switchToTarget(atomic.LoadInt32(&t.targetLayer))
+	}
+}
+
+// cappedTarget returns the target layer, lowered to the layer caps
+func (t *SubscribedTrack) cappedTarget() int32 {
+	return t.capLayer(atomic.LoadInt32(&t.targetLayer))
+}
+
+func (t *SubscribedTrack) capLayer(layer int32) int32 {
+	for _, limit := range []*int32{&t.allowedLayers, &t.bandwidthLayers} {
+		if allowed := atomic.LoadInt32(limit); allowed > 0 && layer >= allowed {
+			layer = allowed - 1
+		}
+	}
+	return layer
+}
+
+// budgeted returns what the track takes of the subscriber's download budget, at its lowest layer. Tracks the
+// subscriber disabled don't count
+func (t *SubscribedTrack) budgeted() (budgetedTrack, bool) {
+	if t.subMuted.Get() || t.paused.Get() {
+		return budgetedTrack{}, false
+	}
+	bt := budgetedTrack{
+		id:          t.ID(),
+		video:       t.DownTrack().Kind() == webrtc.RTPCodecTypeVideo,
+		pinned:      t.pinned,
+		targetLayer: atomic.LoadInt32(&t.targetLayer),
+	}
+	receiver := t.currentReceiver()
+	bitrates := receiver.GetBitrate()
+	if bt.video {
+		for layer := int32(0); layer <= maxSpatialLayer; layer++ {
+			bt.bitrates[layer] = bitrates[layer]
+			if bt.bitrates[layer] == 0 && receiver.HasSpatialLayer(layer) {
+				bt.bitrates[layer] = t.layers.targetBitrate(layer)
+			}
+		}
+	}
+	bt.minBitrate = bitrates[0]
+	if bt.minBitrate == 0 {
+		if bt.video {
+			bt.minBitrate = t.layers.targetBitrate(0)
+		} else {
+			bt.minBitrate = estimatedAudioBitrate
+		}
+	}
+	return bt, true
+}
+
+// consumedLayer returns the spatial layer the subscriber wants, -1 when it's not receiving the track
+func (t *SubscribedTrack) consumedLayer() int32 {
+	if t.subMuted.Get() || t.paused.Get() || t.budgetPaused.Get() {
+		return -1
+	}
+	return t.cappedTarget()
+}
+
+// ForwardedLayer returns the spatial layer the subscriber is receiving, it could differ from its target while the
+// DownTrack waits for a keyframe, or adapts to the subscriber's bandwidth. -1 while it isn't receiving video
+func (t *SubscribedTrack) ForwardedLayer() int32 {
+	if t.DownTrack().Kind() != webrtc.RTPCodecTypeVideo || !t.IsBound() || t.consumedLayer() < 0 || t.pubMuted.Get() {
+		return -1
+	}
+	return t.DownTrack().CurrentSpatialLayer()
+}
+
+// requestKeyframe sends a PLI for the layer forwarded to the subscriber. It goes through the publisher's PLI
+// throttle, and the keyframe is sent to every subscriber of the layer as with any other PLI.
+// It returns false when the subscriber isn't receiving video
+func (t *SubscribedTrack) requestKeyframe() bool {
+	if t.DownTrack().Kind() != webrtc.RTPCodecTypeVideo || !t.IsBound() || t.IsMuted() || t.pubMuted.Get() {
+		return false
+	}
+	t.sourceLock.RLock()
+	layer := t.dt.CurrentSpatialLayer()
+	receiver := t.receiver
+	t.sourceLock.RUnlock()
+	receiver.SendRTCP([]rtcp.Packet{
+		&rtcp.PictureLossIndication{SenderSSRC: t.SSRC(), MediaSSRC: receiver.SSRC(int(layer))},
+	})
+	return true
+}
+
+func (t *SubscribedTrack) consumedLayerChanged() {
+	if t.onConsumedLayerChange != nil {
+		t.onConsumedLayerChange()
+	}
+}
+
 func (t *SubscribedTrack) UpdateSubscriberSettings(enabled bool, quality livekit.VideoQuality) {
 	t.debouncer(func() {
-		t.subMuted.TrySet(!enabled)
-		t.updateDownTrackMute()
-		if enabled && t.dt.Kind() == webrtc.RTPCodecTypeVideo {
-			err := t.dt.SwitchSpatialLayer(spatialLayerForQuality(quality), true)
-			if err == sfu.ErrSpatialLayerNotFound && quality != livekit.VideoQuality_MEDIUM {
-				// try to switch to middle layer
-				_ = t.dt.SwitchSpatialLayer(spatialLayerForQuality(livekit.VideoQuality_MEDIUM), true)
+		isVideo := t.DownTrack().Kind() == webrtc.RTPCodecTypeVideo
+		changed := t.subMuted.TrySet(!enabled)
+		target := atomic.LoadInt32(&t.targetLayer)
+		if enabled && isVideo && !t.pinned {
+			t.qualityRequested.TrySet(true)
+			target = t.layers.layerForQuality(quality)
+			if atomic.SwapInt32(&t.targetLayer, target) != target {
+				changed = true
 			}
 		}
+		// update consumed layers first, so a layer paused on the publisher is resumed promptly
+		if changed {
+			t.consumedLayerChanged()
+		}
+		t.updateDownTrackMute()
+		if enabled && isVideo {
+			t.switchToTarget(target)
+		}
 	})
 }
 
-func (t *SubscribedTrack) updateDownTrackMute() {
-	muted := t.subMuted.Get() || t.pubMuted.Get()
-	t.dt.Mute(muted)
+// setDefaultQuality switches video to the default quality of the subscriber's device class, unless it has asked
+// for a quality itself
+func (t *SubscribedTrack) setDefaultQuality(quality livekit.VideoQuality) {
+	if t.DownTrack().Kind() != webrtc.RTPCodecTypeVideo || t.qualityRequested.Get() || t.pinned {
+		return
+	}
+	target := t.layers.layerForQuality(quality)
+	if atomic.SwapInt32(&t.targetLayer, target) == target {
+		return
+	}
+	t.consumedLayerChanged()
+	if t.consumedLayer() >= 0 {
+		t.switchToTarget(target)
+	}
+}
+
+// switchToTarget switches to the target layer, or the one below while the stream has a bitrate spike. It's lowered
+// to the layer cap
+func (t *SubscribedTrack) switchToTarget(target int32) {
+	target = t.capLayer(target)
+	t.spikeLock.Lock()
+	if t.spikeDowngraded && target > 0 {
+		target--
+	}
+	t.spikeLock.Unlock()
+	t.switchLayer(target)
+}
+
+// setSpiking switches the subscriber a layer below its target while its stream has a bitrate spike,
+// it's switched back once there hasn't been a spike for spikeDowngradeHold
+func (t *SubscribedTrack) setSpiking(spiking bool) {
+	if t.DownTrack().Kind() != webrtc.RTPCodecTypeVideo || t.pinned {
+		return
+	}
+	t.spikeLock.Lock()
+	defer t.spikeLock.Unlock()
+
+	if spiking {
+		if t.spikeRestore != nil {
+			t.spikeRestore.Stop()
+			t.spikeRestore = nil
+		}
+		target := t.cappedTarget()
+		if t.spikeDowngraded || target == 0 || t.consumedLayer() < 0 {
+			return
+		}
+		t.spikeDowngraded = true
+		t.switchLayer(target - 1)
+		return
+	}
+
+	if !t.spikeDowngraded || t.spikeRestore != nil {
+		return
+	}
+	var restore *time.Timer
+	restore = time.AfterFunc(spikeDowngradeHold, func() {
+		t.spikeLock.Lock()
+		defer t.spikeLock.Unlock()
+		if t.spikeRestore != restore {
+			return
+		}
+		t.spikeRestore = nil
+		t.spikeDowngraded = false
+		t.switchLayer(t.cappedTarget())
+	})
+	t.spikeRestore = restore
+}
+
+func (t *SubscribedTrack) switchLayer(target int32) {
+	receiver := t.currentReceiver()
+	var layer int32
+	if t.pinned {
+		layer = nearestLayer(target, receiver.HasSpatialLayer)
+	} else {
+		layer = t.layers.selectLayer(target, receiver.HasSpatialLayer, receiver.GetBitrate())
+	}
+	t.fallbackLock.Lock()
+	defer t.fallbackLock.Unlock()
+	t.forwardLocked(layer)
 }
 
-func spatialLayerForQuality(quality livekit.VideoQuality) int32 {
-	switch quality {
-	case livekit.VideoQuality_LOW:
-		return 0
-	case livekit.VideoQuality_MEDIUM:
-		return 1
-	default:
-		return 2
+// checkFallback forwards another layer, following the fallback policy, while the publisher doesn't send the one
+// the subscriber wants. It's switched back once the wanted layer has been sent again for StableFor, so layers
+// that come and go don't make the subscriber oscillate between them
+func (t *SubscribedTrack) checkFallback(now time.Time) {
+	policy := t.layers.fallback.Policy
+	if policy == "" || policy == config.SimulcastFallbackNone || t.pinned ||
+		t.DownTrack().Kind() != webrtc.RTPCodecTypeVideo || t.consumedLayer() < 0 {
+		return
+	}
+	target := t.cappedTarget()
+	t.spikeLock.Lock()
+	if t.spikeDowngraded && target > 0 {
+		target--
+	}
+	t.spikeLock.Unlock()
+	receiver := t.currentReceiver()
+	hasLayer := receiver.HasSpatialLayer
+	bitrates := receiver.GetBitrate()
+
+	t.fallbackLock.Lock()
+	defer t.fallbackLock.Unlock()
+	if t.layers.healthy(target, hasLayer, bitrates) {
+		if t.forwardedLayer == target {
+			return
+		}
+		if t.targetRecoveredAt.IsZero() {
+			t.targetRecoveredAt = now
+		}
+		if now.Sub(t.targetRecoveredAt) < t.layers.fallback.StableFor {
+			return
+		}
+		logger.Debugw("switching back to recovered layer", "track", t.ID(), "layer", target)
+		t.forwardLocked(target)
+		return
 	}
+
+	t.targetRecoveredAt = time.Time{}
+	if t.forwardedLayer != target && t.layers.healthy(t.forwardedLayer, hasLayer, bitrates) {
+		// already falling back
+		return
+	}
+	layer := t.layers.fallbackLayer(target, hasLayer, bitrates)
+	if layer == t.forwardedLayer || layer == target {
+		return
+	}
+	logger.Debugw("falling back from stalled layer", "track", t.ID(), "layer", target, "fallback", layer)
+	layerFallbackTotal.Add(1)
+	t.forwardLocked(layer)
+}
+
+// needs to be called with fallbackLock held
+func (t *SubscribedTrack) forwardLocked(layer int32) {
+	t.forwardedLayer = layer
+	t.targetRecoveredAt = time.Time{}
+	_ = t.DownTrack().SwitchSpatialLayer(layer, true)
+}
+
+func (t *SubscribedTrack) updateDownTrackMute() {
+	muted := t.subMuted.Get() || t.pubMuted.Get() || t.paused.Get() || t.budgetPaused.Get()
+	t.DownTrack().Mute(muted)
 }
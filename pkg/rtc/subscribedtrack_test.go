@@ -0,0 +1,214 @@
+package rtc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pion/ion-sfu/pkg/buffer"
+	"github.com/pion/ion-sfu/pkg/sfu"
+	"github.com/pion/rtcp"
+	"github.com/pion/webrtc/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/logger"
+)
+
+func TestSubscribedTrackCodec(t *testing.T) {
+	vp8 := webrtc.RTPCodecParameters{
+		RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeVP8, ClockRate: 90000},
+		PayloadType:        96,
+	}
+
+	t.Run("notifies when bound and when codec changes", func(t *testing.T) {
+		st := NewSubscribedTrack(nil, nil, nil, 0)
+		var codecs []webrtc.RTPCodecParameters
+		st.OnCodecChange(func(codec webrtc.RTPCodecParameters) {
+			codecs = append(codecs, codec)
+		})
+		require.Empty(t, codecs)
+		require.Empty(t, st.Codec().MimeType)
+
+		st.setCodec(vp8)
+		require.Equal(t, vp8, st.Codec())
+		require.Len(t, codecs, 1)
+
+		// rebinding to the same codec isn't a change
+		st.setCodec(vp8)
+		require.Len(t, codecs, 1)
+
+		renegotiated := vp8
+		renegotiated.PayloadType = 98
+		st.setCodec(renegotiated)
+		require.Equal(t, []webrtc.RTPCodecParameters{vp8, renegotiated}, codecs)
+	})
+
+	t.Run("notifies right away when already bound", func(t *testing.T) {
+		st := NewSubscribedTrack(nil, nil, nil, 0)
+		st.setCodec(vp8)
+
+		var codec webrtc.RTPCodecParameters
+		st.OnCodecChange(func(c webrtc.RTPCodecParameters) {
+			codec = c
+		})
+		require.Equal(t, vp8, codec)
+	})
+}
+
+func TestSubscribedTrackFallback(t *testing.T) {
+	const stableFor = 2 * time.Second
+	healthy := [3]uint64{150_000, 500_000, 1_500_000}
+	newTrack := func(t *testing.T, policy string, target int32) (*SubscribedTrack, *stallingReceiver) {
+		receiver := &stallingReceiver{
+			mismatchedReceiver: mismatchedReceiver{kind: webrtc.RTPCodecTypeVideo},
+			bitrates:           healthy,
+		}
+		dt, err := sfu.NewDownTrack(webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeVP8, ClockRate: 90000},
+			receiver, buffer.NewBufferFactory(500, logger.GetLogger()), "sub", 500)
+		require.NoError(t, err)
+		layers := newSimulcastLayers(config.SimulcastConfig{
+			Fallback: config.SimulcastFallbackConfig{Policy: policy, StableFor: stableFor},
+		})
+		return NewSubscribedTrack(dt, receiver, layers, target), receiver
+	}
+	now := time.Now()
+
+	t.Run("forwards the nearest layer while the wanted one stalls", func(t *testing.T) {
+		st, receiver := newTrack(t, config.SimulcastFallbackNearest, 1)
+		st.checkFallback(now)
+		require.Equal(t, int32(1), st.forwardedLayer)
+
+		receiver.bitrates = [3]uint64{150_000, 0, 1_500_000}
+		st.checkFallback(now)
+		require.Equal(t, int32(0), st.forwardedLayer)
+
+		// the fallback stalls as well
+		receiver.bitrates = [3]uint64{0, 0, 1_500_000}
+		st.checkFallback(now)
+		require.Equal(t, int32(2), st.forwardedLayer)
+	})
+
+	t.Run("switches back once the wanted layer is stable", func(t *testing.T) {
+		st, receiver := newTrack(t, config.SimulcastFallbackNearest, 2)
+		receiver.bitrates = [3]uint64{150_000, 500_000, 0}
+		st.checkFallback(now)
+		require.Equal(t, int32(1), st.forwardedLayer)
+
+		receiver.bitrates = healthy
+		st.checkFallback(now.Add(time.Second))
+		require.Equal(t, int32(1), st.forwardedLayer)
+		// stalls again before it's stable, the wait starts over
+		receiver.bitrates = [3]uint64{150_000, 500_000, 0}
+		st.checkFallback(now.Add(2 * time.Second))
+		receiver.bitrates = healthy
+		st.checkFallback(now.Add(3 * time.Second))
+		st.checkFallback(now.Add(3*time.Second + stableFor/2))
+		require.Equal(t, int32(1), st.forwardedLayer)
+
+		st.checkFallback(now.Add(3*time.Second + stableFor))
+		require.Equal(t, int32(2), st.forwardedLayer)
+	})
+
+	t.Run("lower policy never forwards more than wanted", func(t *testing.T) {
+		st, receiver := newTrack(t, config.SimulcastFallbackLower, 0)
+		receiver.bitrates = [3]uint64{0, 500_000, 1_500_000}
+		st.checkFallback(now)
+		require.Equal(t, int32(0), st.forwardedLayer)
+	})
+
+	t.Run("none policy waits for the wanted layer", func(t *testing.T) {
+		st, receiver := newTrack(t, config.SimulcastFallbackNone, 2)
+		receiver.bitrates = [3]uint64{150_000, 500_000, 0}
+		st.checkFallback(now)
+		require.Equal(t, int32(2), st.forwardedLayer)
+	})
+}
+
+// a simulcast receiver sending all layers, at bitrates set by the test
+type stallingReceiver struct {
+	mismatchedReceiver
+	bitrates [3]uint64
+}
+
+func (r *stallingReceiver) HasSpatialLayer(_ int32) bool { return true }
+func (r *stallingReceiver) GetBitrate() [3]uint64        { return r.bitrates }
+
+func TestSubscribedTrackKeyframeRequest(t *testing.T) {
+	receiver := &keyframeReceiver{mismatchedReceiver: mismatchedReceiver{kind: webrtc.RTPCodecTypeVideo}}
+	dt, err := sfu.NewDownTrack(webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeVP8, ClockRate: 90000},
+		receiver, buffer.NewBufferFactory(500, logger.GetLogger()), "sub", 500)
+	require.NoError(t, err)
+	st := NewSubscribedTrack(dt, receiver, newSimulcastLayers(config.SimulcastConfig{}), 0)
+
+	// nothing is recorded until the subscriber is bound
+	st.requestKeyframe()
+	require.Empty(t, receiver.plis)
+
+	st.bound.TrySet(true)
+	st.requestKeyframe()
+	require.Len(t, receiver.plis, 1)
+	require.Equal(t, uint32(1000), receiver.plis[0].MediaSSRC)
+
+	st.SetPublisherMuted(true)
+	st.requestKeyframe()
+	require.Len(t, receiver.plis, 1)
+}
+
+func TestBindKeyframeThrottle(t *testing.T) {
+	receiver := &keyframeReceiver{mismatchedReceiver: mismatchedReceiver{kind: webrtc.RTPCodecTypeVideo}}
+	bind := func(sub string) *SubscribedTrack {
+		dt, err := sfu.NewDownTrack(webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeVP8, ClockRate: 90000},
+			receiver, buffer.NewBufferFactory(500, logger.GetLogger()), sub, 500)
+		require.NoError(t, err)
+		st := NewSubscribedTrack(dt, receiver, newSimulcastLayers(config.SimulcastConfig{}), 0)
+		st.bound.TrySet(true)
+		return st
+	}
+	mt := &MediaTrack{}
+
+	// subscribers binding together share a keyframe
+	mt.requestBindKeyframe(bind("sub1"))
+	mt.requestBindKeyframe(bind("sub2"))
+	require.Len(t, receiver.plis, 1)
+
+	mt.lastPLI = mt.lastPLI.Add(-bindKeyframeInterval)
+	mt.requestBindKeyframe(bind("sub3"))
+	require.Len(t, receiver.plis, 2)
+}
+
+// a receiver keeping PLIs sent to the publisher, its layers are on SSRCs 1000 and up
+type keyframeReceiver struct {
+	mismatchedReceiver
+	plis []*rtcp.PictureLossIndication
+}
+
+func (r *keyframeReceiver) SSRC(layer int) uint32 { return uint32(1000 + layer) }
+func (r *keyframeReceiver) SendRTCP(pkts []rtcp.Packet) {
+	for _, pkt := range pkts {
+		if pli, ok := pkt.(*rtcp.PictureLossIndication); ok {
+			r.plis = append(r.plis, pli)
+		}
+	}
+}
+
+func TestSubscribedTrackForwardedLayer(t *testing.T) {
+	receiver := &stallingReceiver{mismatchedReceiver: mismatchedReceiver{kind: webrtc.RTPCodecTypeVideo}}
+	dt, err := sfu.NewDownTrack(webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeVP8, ClockRate: 90000},
+		receiver, buffer.NewBufferFactory(500, logger.GetLogger()), "sub", 500)
+	require.NoError(t, err)
+	dt.SetInitialLayers(1, 0)
+	st := NewSubscribedTrack(dt, receiver, newSimulcastLayers(config.SimulcastConfig{}), 2)
+
+	// nothing is forwarded until the subscriber is bound
+	require.Equal(t, int32(-1), st.ForwardedLayer())
+
+	// the layer actually forwarded, rather than the target
+	st.bound.TrySet(true)
+	require.Equal(t, int32(1), st.ForwardedLayer())
+
+	st.SetPaused(true)
+	require.Equal(t, int32(-1), st.ForwardedLayer())
+	st.SetPaused(false)
+	st.SetPublisherMuted(true)
+	require.Equal(t, int32(-1), st.ForwardedLayer())
+}
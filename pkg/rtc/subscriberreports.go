@@ -0,0 +1,110 @@
+package rtc
+
+import (
+	"io"
+	"time"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/transport/packetio"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+// receptionReport is the latest report of a subscriber on a stream forwarded to it
+type receptionReport struct {
+	fractionLost uint8
+	// interarrival jitter, in RTP timestamp units
+	jitter uint32
+	// round trip time to the subscriber, 0 when the report doesn't refer to a sender report
+	rtt time.Duration
+	at  time.Time
+}
+
+// aggregateReceptionReports combines reports of the subscribers of a published stream into a single one.
+// Averaging keeps a single subscriber on a poor network from lowering quality for everyone,
+// the worst case favors subscribers that struggle the most
+func aggregateReceptionReports(reports []receptionReport, aggregation string) (fractionLost uint8, jitter uint32) {
+	if aggregation == config.SubscriberReportsWorst {
+		for _, r := range reports {
+			if r.fractionLost > fractionLost {
+				fractionLost = r.fractionLost
+			}
+			if r.jitter > jitter {
+				jitter = r.jitter
+			}
+		}
+		return
+	}
+
+	var lostSum, jitterSum uint64
+	for _, r := range reports {
+		lostSum += uint64(r.fractionLost)
+		jitterSum += uint64(r.jitter)
+	}
+	n := uint64(len(reports))
+	return uint8(lostSum / n), uint32(jitterSum / n)
+}
+
+// mergeReceptionReports raises loss and jitter of the SFU's reports on ssrc to the ones of its subscribers,
+// reports are kept as is when the SFU's own reception is worse
+func mergeReceptionReports(pkts []rtcp.Packet, ssrc uint32, fractionLost uint8, jitter uint32) {
+	for _, pkt := range pkts {
+		rr, ok := pkt.(*rtcp.ReceiverReport)
+		if !ok {
+			continue
+		}
+		for i := range rr.Reports {
+			report := &rr.Reports[i]
+			if report.SSRC != ssrc {
+				continue
+			}
+			if fractionLost > report.FractionLost {
+				report.FractionLost = fractionLost
+			}
+			if jitter > report.Jitter {
+				report.Jitter = jitter
+			}
+		}
+	}
+}
+
+// wrapReceptionReports passes reception reports of a subscriber to onReport, before they're read by DownTracks
+func wrapReceptionReports(
+	createBufferFunc func(packetType packetio.BufferPacketType, ssrc uint32) io.ReadWriteCloser,
+	onReport func(ssrc uint32, report rtcp.ReceptionReport),
+) func(packetType packetio.BufferPacketType, ssrc uint32) io.ReadWriteCloser {
+	return func(packetType packetio.BufferPacketType, ssrc uint32) io.ReadWriteCloser {
+		writer := createBufferFunc(packetType, ssrc)
+		if packetType == packetio.RTCPBufferPacket {
+			return &receptionReportWriter{
+				ReadWriteCloser: writer,
+				ssrc:            ssrc,
+				onReport:        onReport,
+			}
+		}
+		return writer
+	}
+}
+
+type receptionReportWriter struct {
+	io.ReadWriteCloser
+	ssrc     uint32
+	onReport func(ssrc uint32, report rtcp.ReceptionReport)
+}
+
+func (w *receptionReportWriter) Write(p []byte) (int, error) {
+	if pkts, err := rtcp.Unmarshal(p); err == nil {
+		for _, pkt := range pkts {
+			rr, ok := pkt.(*rtcp.ReceiverReport)
+			if !ok {
+				continue
+			}
+			for _, report := range rr.Reports {
+				if report.SSRC == w.ssrc {
+					w.onReport(w.ssrc, report)
+				}
+			}
+		}
+	}
+	return w.ReadWriteCloser.Write(p)
+}
@@ -0,0 +1,118 @@
+package rtc
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/pion/ion-sfu/pkg/sfu"
+	"github.com/pion/rtcp"
+	"github.com/pion/transport/packetio"
+	"github.com/pion/webrtc/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+func TestSubscriberReports(t *testing.T) {
+	reports := []receptionReport{
+		{fractionLost: 10, jitter: 100},
+		{fractionLost: 50, jitter: 20},
+		{fractionLost: 0, jitter: 30},
+	}
+
+	t.Run("aggregates reports", func(t *testing.T) {
+		lost, jitter := aggregateReceptionReports(reports, config.SubscriberReportsAverage)
+		require.Equal(t, uint8(20), lost)
+		require.Equal(t, uint32(50), jitter)
+
+		lost, jitter = aggregateReceptionReports(reports, config.SubscriberReportsWorst)
+		require.Equal(t, uint8(50), lost)
+		require.Equal(t, uint32(100), jitter)
+	})
+
+	t.Run("keeps worse reception of the SFU", func(t *testing.T) {
+		pkts := []rtcp.Packet{&rtcp.ReceiverReport{Reports: []rtcp.ReceptionReport{
+			{SSRC: 1000, FractionLost: 5, Jitter: 200},
+			{SSRC: 2000, FractionLost: 5, Jitter: 5},
+		}}}
+		mergeReceptionReports(pkts, 1000, 20, 50)
+		rr := pkts[0].(*rtcp.ReceiverReport)
+		require.Equal(t, uint8(20), rr.Reports[0].FractionLost)
+		require.Equal(t, uint32(200), rr.Reports[0].Jitter)
+		// other streams are untouched
+		require.Equal(t, uint8(5), rr.Reports[1].FractionLost)
+	})
+
+	t.Run("observes reports of subscribers", func(t *testing.T) {
+		var observed []rtcp.ReceptionReport
+		buf := &closingBuffer{}
+		createBuffer := wrapReceptionReports(func(packetio.BufferPacketType, uint32) io.ReadWriteCloser {
+			return buf
+		}, func(ssrc uint32, report rtcp.ReceptionReport) {
+			require.Equal(t, uint32(1000), ssrc)
+			observed = append(observed, report)
+		})
+
+		w := createBuffer(packetio.RTCPBufferPacket, 1000)
+		b, err := rtcp.Marshal([]rtcp.Packet{&rtcp.ReceiverReport{Reports: []rtcp.ReceptionReport{
+			{SSRC: 1000, FractionLost: 30},
+			{SSRC: 2000, FractionLost: 60},
+		}}})
+		require.NoError(t, err)
+		_, err = w.Write(b)
+		require.NoError(t, err)
+		require.Len(t, observed, 1)
+		require.Equal(t, uint8(30), observed[0].FractionLost)
+		// packets still reach the DownTrack
+		require.Equal(t, b, buf.Bytes())
+
+		require.Equal(t, buf, createBuffer(packetio.RTPBufferPacket, 1000))
+	})
+
+	t.Run("media track merges reports of subscribers of the layer", func(t *testing.T) {
+		conf := config.SubscriberReportsConfig{
+			Enabled:     true,
+			Aggregation: config.SubscriberReportsWorst,
+			MaxAge:      time.Second,
+		}
+		mt := &MediaTrack{
+			params:           MediaTrackParams{SubscriberReports: conf},
+			subscribedTracks: make(map[string]*SubscribedTrack),
+		}
+		addSubscriber := func(id string, layer int32, fractionLost uint8) *SubscribedTrack {
+			dt, err := sfu.NewDownTrack(webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeVP8, ClockRate: 90000},
+				NewWrappedReceiver(nil, "TR_1", "stream"), nil, id, 500)
+			require.NoError(t, err)
+			dt.SetInitialLayers(layer, 0)
+			st := NewSubscribedTrack(dt, nil, nil, layer)
+			st.setReceptionReport(rtcp.ReceptionReport{FractionLost: fractionLost})
+			mt.subscribedTracks[id] = st
+			return st
+		}
+		addSubscriber("low", 0, 40)
+		addSubscriber("high", 2, 10)
+		stale := addSubscriber("stale", 2, 100)
+		stale.reception.at = time.Now().Add(-2 * time.Second)
+
+		report := func(layer int32) uint8 {
+			pkts := []rtcp.Packet{&rtcp.ReceiverReport{Reports: []rtcp.ReceptionReport{{SSRC: 1000}}}}
+			mt.mergeSubscriberReports(pkts, 1000, layer)
+			return pkts[0].(*rtcp.ReceiverReport).Reports[0].FractionLost
+		}
+		require.Equal(t, uint8(10), report(2))
+		require.Equal(t, uint8(40), report(0))
+		require.Equal(t, uint8(0), report(1))
+		// without simulcast all subscribers are included
+		require.Equal(t, uint8(40), report(-1))
+	})
+}
+
+type closingBuffer struct {
+	bytes.Buffer
+}
+
+func (b *closingBuffer) Close() error {
+	return nil
+}
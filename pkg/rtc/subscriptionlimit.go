@@ -0,0 +1,66 @@
+package rtc
+
+import (
+	"sync/atomic"
+
+	livekit "github.com/livekit/livekit-server/proto"
+)
+
+// SubscriptionLimiter caps the number of DownTracks across all rooms on this node. Once the cap is reached,
+// new video subscriptions are rejected so the node sheds load predictably. Audio is cheap and always admitted,
+// it's counted towards the cap so video is rejected earlier on nodes with many audio subscriptions
+type SubscriptionLimiter struct {
+	max    int32
+	active int32
+}
+
+// NewSubscriptionLimiter returns nil when subscriptions aren't limited
+func NewSubscriptionLimiter(max int) *SubscriptionLimiter {
+	if max <= 0 {
+		return nil
+	}
+	subscriptionUtilization.Set(0)
+	return &SubscriptionLimiter{max: int32(max)}
+}
+
+// acquire returns false when the subscription is rejected, subscriptions acquired have to be released
+func (l *SubscriptionLimiter) acquire(kind livekit.TrackType) bool {
+	if l == nil {
+		return true
+	}
+	active := atomic.AddInt32(&l.active, 1)
+	if kind == livekit.TrackType_VIDEO && active > l.max {
+		atomic.AddInt32(&l.active, -1)
+		subscriptionRejectedTotal.WithLabelValues(kind.String()).Inc()
+		return false
+	}
+	l.updateUtilization(active)
+	return true
+}
+
+func (l *SubscriptionLimiter) release() {
+	if l == nil {
+		return
+	}
+	l.updateUtilization(atomic.AddInt32(&l.active, -1))
+}
+
+// Active returns the number of subscriptions on this node
+func (l *SubscriptionLimiter) Active() int {
+	if l == nil {
+		return 0
+	}
+	return int(atomic.LoadInt32(&l.active))
+}
+
+// Utilization returns the fraction of the cap in use, it could exceed 1 with audio subscriptions
+func (l *SubscriptionLimiter) Utilization() float64 {
+	if l == nil {
+		return 0
+	}
+	return float64(l.Active()) / float64(l.max)
+}
+
+func (l *SubscriptionLimiter) updateUtilization(active int32) {
+	subscriptionUtilization.Set(float64(active) / float64(l.max))
+}
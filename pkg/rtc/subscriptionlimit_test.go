@@ -0,0 +1,54 @@
+package rtc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/rtc/types/typesfakes"
+	livekit "github.com/livekit/livekit-server/proto"
+)
+
+func TestSubscriptionLimiter(t *testing.T) {
+	t.Run("rejects video once the cap is reached", func(t *testing.T) {
+		l := NewSubscriptionLimiter(2)
+		require.True(t, l.acquire(livekit.TrackType_VIDEO))
+		require.True(t, l.acquire(livekit.TrackType_VIDEO))
+		require.False(t, l.acquire(livekit.TrackType_VIDEO))
+		require.Equal(t, 2, l.Active())
+		require.Equal(t, float64(1), l.Utilization())
+
+		// audio is still admitted, and counted
+		require.True(t, l.acquire(livekit.TrackType_AUDIO))
+		require.Equal(t, 3, l.Active())
+
+		l.release()
+		l.release()
+		require.True(t, l.acquire(livekit.TrackType_VIDEO))
+	})
+
+	t.Run("doesn't limit when disabled", func(t *testing.T) {
+		l := NewSubscriptionLimiter(0)
+		require.Nil(t, l)
+		require.True(t, l.acquire(livekit.TrackType_VIDEO))
+		l.release()
+	})
+
+	t.Run("media track rejects subscribers", func(t *testing.T) {
+		l := NewSubscriptionLimiter(1)
+		require.True(t, l.acquire(livekit.TrackType_AUDIO))
+
+		mt := &MediaTrack{
+			params:           MediaTrackParams{SubscriptionLimiter: l},
+			kind:             livekit.TrackType_VIDEO,
+			receiver:         &WrappedReceiver{},
+			subscribedTracks: make(map[string]*SubscribedTrack),
+		}
+		sub := &typesfakes.FakeParticipant{}
+		sub.CanSubscribeReturns(true)
+		sub.SupportsCodecReturns(true)
+		require.Equal(t, ErrSubscriptionLimit, mt.AddSubscriber(sub))
+		require.Empty(t, mt.subscribedTracks)
+		require.Equal(t, 1, l.Active())
+	})
+}
@@ -0,0 +1,66 @@
+package rtc
+
+import (
+	"sort"
+
+	livekit "github.com/livekit/livekit-server/proto"
+)
+
+// SubscriptionPreview estimates what a participant would receive when subscribed to all tracks of a room
+type SubscriptionPreview struct {
+	Tracks []TrackPreview `json:"tracks"`
+	// sum of estimated bitrates of all tracks, in bps
+	Bitrate uint64 `json:"bitrate"`
+	// false when the room doesn't subscribe participants automatically, tracks would need to be requested
+	AutoSubscribe bool `json:"autoSubscribe"`
+}
+
+type TrackPreview struct {
+	Sid         string `json:"sid"`
+	Participant string `json:"participant"`
+	Type        string `json:"type"`
+	Muted       bool   `json:"muted"`
+	// layer received by default, only set for video
+	Quality string `json:"quality,omitempty"`
+	Bitrate uint64 `json:"bitrate"`
+}
+
+// PreviewSubscription computes the tracks identity would be subscribed to when joining, and their bitrates at
+// default layers, without subscribing. identity doesn't need to be in the room, its own tracks are excluded
+func (r *Room) PreviewSubscription(identity string) *SubscriptionPreview {
+	preview := &SubscriptionPreview{
+		Tracks:        make([]TrackPreview, 0),
+		AutoSubscribe: !r.manualSubscription.Get(),
+	}
+	for _, p := range r.GetParticipants() {
+		if p.Identity() == identity {
+			continue
+		}
+		for _, track := range p.GetPublishedTracks() {
+			if track.Kind() == livekit.TrackType_DATA {
+				continue
+			}
+			quality, bitrate := track.EstimateSubscription()
+			tp := TrackPreview{
+				Sid:         track.ID(),
+				Participant: p.Identity(),
+				Type:        track.Kind().String(),
+				Muted:       track.IsMuted(),
+				Bitrate:     bitrate,
+			}
+			if track.Kind() == livekit.TrackType_VIDEO {
+				tp.Quality = quality.String()
+			}
+			preview.Tracks = append(preview.Tracks, tp)
+			preview.Bitrate += bitrate
+		}
+	}
+	sort.Slice(preview.Tracks, func(i, j int) bool {
+		a, b := preview.Tracks[i], preview.Tracks[j]
+		if a.Participant != b.Participant {
+			return a.Participant < b.Participant
+		}
+		return a.Sid < b.Sid
+	})
+	return preview
+}
@@ -0,0 +1,121 @@
+package rtc
+
+import (
+	"time"
+
+	"github.com/livekit/livekit-server/pkg/logger"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+// departedSubscriptions are the subscriptions of a participant that left the room, restored if it joins again
+// within the room's subscription restore window
+type departedSubscriptions struct {
+	// sids of tracks it was receiving
+	subscribed map[string]bool
+	// sids of tracks it explicitly subscribed to
+	requested map[string]bool
+	expiry    *time.Timer
+}
+
+// SetSubscriptionRestoreWindow keeps subscriptions of participants that leave for window, they're restored when
+// they join again in the meantime. 0 to forget them right away
+func (r *Room) SetSubscriptionRestoreWindow(window time.Duration) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.subscriptionRestoreWindow = window
+}
+
+// keepSubscriptions remembers subscriptions of p as it leaves, with the tracks it requested
+func (r *Room) keepSubscriptions(p types.Participant, requested map[string]bool) {
+	subscribed := make(map[string]bool)
+	for _, sid := range p.SubscribedTrackIDs() {
+		subscribed[sid] = true
+	}
+	if len(subscribed) == 0 && len(requested) == 0 {
+		return
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.subscriptionRestoreWindow <= 0 || r.isClosed.Get() {
+		return
+	}
+	identity := p.Identity()
+	if prev := r.departedSubscriptions[identity]; prev != nil {
+		prev.expiry.Stop()
+	}
+	departed := &departedSubscriptions{
+		subscribed: subscribed,
+		requested:  requested,
+	}
+	departed.expiry = time.AfterFunc(r.subscriptionRestoreWindow, func() {
+		r.lock.Lock()
+		defer r.lock.Unlock()
+		if r.departedSubscriptions[identity] == departed {
+			delete(r.departedSubscriptions, identity)
+		}
+	})
+	r.departedSubscriptions[identity] = departed
+}
+
+// restoreSubscriptions subscribes p to the tracks it was receiving when it left, if it did within the restore
+// window. Participants that are auto subscribed only get back the tracks they requested, they're subscribed to
+// the rest along with any other track
+func (r *Room) restoreSubscriptions(p types.Participant) {
+	identity := p.Identity()
+	r.lock.Lock()
+	departed := r.departedSubscriptions[identity]
+	if departed == nil {
+		r.lock.Unlock()
+		return
+	}
+	delete(r.departedSubscriptions, identity)
+	departed.expiry.Stop()
+	if len(departed.requested) > 0 {
+		requested := r.requestedTracks[identity]
+		if requested == nil {
+			requested = make(map[string]bool)
+			r.requestedTracks[identity] = requested
+		}
+		for sid := range departed.requested {
+			requested[sid] = true
+		}
+	}
+	autoSubscribe := r.autoSubscribe(p)
+	r.lock.Unlock()
+
+	if autoSubscribe || !p.CanSubscribe() {
+		return
+	}
+	restored := 0
+	for _, op := range r.GetParticipants() {
+		if op.ID() == p.ID() {
+			continue
+		}
+		for _, track := range op.GetPublishedTracks() {
+			if !departed.subscribed[track.ID()] {
+				continue
+			}
+			if err := op.SubscribeToTrack(p, track.ID()); err != nil {
+				logger.Debugw("could not restore subscription", "error", err,
+					"participant", identity,
+					"track", track.ID())
+				continue
+			}
+			restored++
+		}
+	}
+	if restored > 0 {
+		logger.Infow("restored subscriptions of rejoining participant", "room", r.Room.Name,
+			"participant", identity,
+			"tracks", restored)
+	}
+}
+
+// needs to be called with lock held
+func (r *Room) forgetDepartedSubscriptionsLocked() {
+	for identity, departed := range r.departedSubscriptions {
+		departed.expiry.Stop()
+		delete(r.departedSubscriptions, identity)
+	}
+}
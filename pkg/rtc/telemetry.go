@@ -0,0 +1,318 @@
+package rtc
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/transport/packetio"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/logger"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+// samples that could be queued before new ones are dropped
+const telemetryQueueSize = 1024
+
+// SubscriberSample is a sample of a subscribed track's layers and the network feedback of its subscriber
+type SubscriberSample struct {
+	// unix timestamp in milliseconds
+	Timestamp   int64  `json:"timestamp"`
+	Participant string `json:"participant"`
+	// publisher of the track
+	PublisherSid string `json:"publisherSid"`
+	TrackID      string `json:"trackId"`
+	SSRC         uint32 `json:"ssrc"`
+	Kind         string `json:"kind"`
+	// spatial layer the subscriber asked for, -1 when it isn't receiving the track
+	TargetLayer int32 `json:"targetLayer"`
+	// spatial layer being forwarded
+	CurrentLayer int32 `json:"currentLayer"`
+	// latest REMB of the subscriber, in bits per second
+	EstimatedBandwidth uint64 `json:"estimatedBandwidth"`
+	// fraction of packets lost, from the latest receiver report
+	FractionLost float64 `json:"fractionLost"`
+	// interarrival jitter, in RTP timestamp units
+	Jitter uint32 `json:"jitter"`
+	// round trip time in milliseconds, 0 until the subscriber has reported on a sender report
+	RTT int64 `json:"rtt"`
+}
+
+// TelemetrySink writes subscriber samples of all rooms on this node. Samples are written by a single goroutine,
+// they're dropped when it can't keep up, so telemetry never blocks the media path
+type TelemetrySink struct {
+	conf    config.SubscriberTelemetryConfig
+	samples chan SubscriberSample
+	done    chan struct{}
+
+	file    *os.File
+	encoder *json.Encoder
+	written int64
+
+	dropped uint64
+	once    sync.Once
+}
+
+// NewTelemetrySink returns nil when subscriber telemetry is disabled
+func NewTelemetrySink(conf config.SubscriberTelemetryConfig) (*TelemetrySink, error) {
+	if !conf.Enabled {
+		return nil, nil
+	}
+	s := &TelemetrySink{
+		conf:    conf,
+		samples: make(chan SubscriberSample, telemetryQueueSize),
+		done:    make(chan struct{}),
+	}
+	if conf.Sink == config.TelemetrySinkFile {
+		f, err := os.OpenFile(conf.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, err
+		}
+		s.file = f
+		s.encoder = json.NewEncoder(&countingWriter{w: f, n: &s.written})
+	}
+	go s.worker()
+	return s, nil
+}
+
+func (s *TelemetrySink) Interval() time.Duration {
+	return s.conf.Interval
+}
+
+// Emit queues the sample, returns false when it's dropped
+func (s *TelemetrySink) Emit(sample SubscriberSample) bool {
+	select {
+	case <-s.done:
+		return false
+	default:
+	}
+	select {
+	case s.samples <- sample:
+		return true
+	default:
+		atomic.AddUint64(&s.dropped, 1)
+		telemetryDroppedTotal.Inc()
+		return false
+	}
+}
+
+// Dropped returns the number of samples dropped because the sink couldn't keep up
+func (s *TelemetrySink) Dropped() uint64 {
+	return atomic.LoadUint64(&s.dropped)
+}
+
+func (s *TelemetrySink) Close() {
+	s.once.Do(func() {
+		close(s.done)
+	})
+}
+
+func (s *TelemetrySink) worker() {
+	defer func() {
+		if s.file != nil {
+			_ = s.file.Close()
+		}
+	}()
+	for {
+		select {
+		case <-s.done:
+			return
+		case sample := <-s.samples:
+			s.write(sample)
+		}
+	}
+}
+
+func (s *TelemetrySink) write(sample SubscriberSample) {
+	switch s.conf.Sink {
+	case config.TelemetrySinkFile:
+		if atomic.LoadInt64(&s.written) >= s.conf.MaxFileBytes {
+			atomic.AddUint64(&s.dropped, 1)
+			telemetryDroppedTotal.Inc()
+			return
+		}
+		if err := s.encoder.Encode(&sample); err != nil {
+			logger.Warnw("could not write subscriber telemetry", err, "file", s.conf.File)
+		}
+	case config.TelemetrySinkMetrics:
+		subscriberEstimatedBandwidth.Observe(float64(sample.EstimatedBandwidth))
+		subscriberFractionLost.Observe(sample.FractionLost)
+		if sample.RTT > 0 {
+			subscriberRTT.Observe(float64(sample.RTT) / 1000)
+		}
+	default:
+		logger.Infow("subscriber telemetry",
+			"participant", sample.Participant,
+			"publisherSid", sample.PublisherSid,
+			"track", sample.TrackID,
+			"ssrc", sample.SSRC,
+			"kind", sample.Kind,
+			"targetLayer", sample.TargetLayer,
+			"currentLayer", sample.CurrentLayer,
+			"estimatedBandwidth", sample.EstimatedBandwidth,
+			"fractionLost", sample.FractionLost,
+			"jitter", sample.Jitter,
+			"rtt", sample.RTT)
+	}
+}
+
+type countingWriter struct {
+	w io.Writer
+	n *int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	atomic.AddInt64(w.n, int64(n))
+	return n, err
+}
+
+// streamFeedback is the latest feedback of the subscriber on a stream sent to it
+type streamFeedback struct {
+	fractionLost uint8
+	jitter       uint32
+	rtt          time.Duration
+}
+
+// subscriberTelemetry keeps the latest RTCP feedback of a subscriber, it's sampled along with its subscribed tracks
+type subscriberTelemetry struct {
+	sink *TelemetrySink
+
+	lock sync.Mutex
+	// latest REMB, applies to all streams sent to the subscriber
+	estimatedBandwidth uint64
+	// ssrc => feedback, entries are removed when their RTCP buffer is closed
+	streams map[uint32]*streamFeedback
+}
+
+func newSubscriberTelemetry(sink *TelemetrySink) *subscriberTelemetry {
+	return &subscriberTelemetry{
+		sink:    sink,
+		streams: make(map[uint32]*streamFeedback),
+	}
+}
+
+// wrapBufferFactory observes RTCP sent by the subscriber, before it's read by DownTracks
+func (t *subscriberTelemetry) wrapBufferFactory(
+	createBufferFunc func(packetType packetio.BufferPacketType, ssrc uint32) io.ReadWriteCloser,
+) func(packetType packetio.BufferPacketType, ssrc uint32) io.ReadWriteCloser {
+	return func(packetType packetio.BufferPacketType, ssrc uint32) io.ReadWriteCloser {
+		writer := createBufferFunc(packetType, ssrc)
+		if packetType == packetio.RTCPBufferPacket {
+			return &telemetryRTCPWriter{
+				ReadWriteCloser: writer,
+				ssrc:            ssrc,
+				telemetry:       t,
+			}
+		}
+		return writer
+	}
+}
+
+func (t *subscriberTelemetry) handleRTCP(ssrc uint32, pkts []rtcp.Packet, now time.Time) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	for _, pkt := range pkts {
+		switch p := pkt.(type) {
+		case *rtcp.ReceiverEstimatedMaximumBitrate:
+			t.estimatedBandwidth = p.Bitrate
+		case *rtcp.ReceiverReport:
+			for _, report := range p.Reports {
+				if report.SSRC != ssrc {
+					continue
+				}
+				fb := t.streams[ssrc]
+				if fb == nil {
+					fb = &streamFeedback{}
+					t.streams[ssrc] = fb
+				}
+				fb.fractionLost = report.FractionLost
+				fb.jitter = report.Jitter
+				if report.LastSenderReport != 0 {
+					fb.rtt = rttFromReport(now, report.LastSenderReport, report.Delay)
+				}
+			}
+		}
+	}
+}
+
+func (t *subscriberTelemetry) removeStream(ssrc uint32) {
+	t.lock.Lock()
+	delete(t.streams, ssrc)
+	t.lock.Unlock()
+}
+
+// sample emits a sample for each bound track of the subscriber
+func (t *subscriberTelemetry) sample(identity string, subscribedTracks map[string][]types.SubscribedTrack) {
+	now := time.Now()
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	for pubID, tracks := range subscribedTracks {
+		for _, track := range tracks {
+			st, ok := track.(*SubscribedTrack)
+			if !ok || !st.IsBound() {
+				continue
+			}
+			sample := SubscriberSample{
+				Timestamp:          now.UnixNano() / int64(time.Millisecond),
+				Participant:        identity,
+				PublisherSid:       pubID,
+				TrackID:            st.ID(),
+				SSRC:               st.SSRC(),
+				Kind:               st.DownTrack().Kind().String(),
+				TargetLayer:        st.consumedLayer(),
+				CurrentLayer:       st.DownTrack().CurrentSpatialLayer(),
+				EstimatedBandwidth: t.estimatedBandwidth,
+			}
+			if fb := t.streams[st.SSRC()]; fb != nil {
+				sample.FractionLost = float64(fb.fractionLost) / 256
+				sample.Jitter = fb.jitter
+				sample.RTT = fb.rtt.Milliseconds()
+			}
+			t.sink.Emit(sample)
+		}
+	}
+}
+
+// rttFromReport calculates the round trip time from the last sender report and delay fields of a reception report,
+// both in compact NTP format
+func rttFromReport(now time.Time, lastSenderReport uint32, delay uint32) time.Duration {
+	compactNow := uint32(toNtpTime(now) >> 16)
+	rtt := compactNow - lastSenderReport - delay
+	if int32(rtt) < 0 {
+		return 0
+	}
+	return time.Duration(rtt) * time.Second / (1 << 16)
+}
+
+func toNtpTime(t time.Time) uint64 {
+	nsec := uint64(t.Sub(ntpEpoch))
+	sec := nsec / 1e9
+	frac := (nsec % 1e9) << 32 / 1e9
+	return sec<<32 | frac
+}
+
+var ntpEpoch = time.Date(1900, 1, 1, 0, 0, 0, 0, time.UTC)
+
+type telemetryRTCPWriter struct {
+	io.ReadWriteCloser
+	ssrc      uint32
+	telemetry *subscriberTelemetry
+}
+
+func (w *telemetryRTCPWriter) Write(p []byte) (int, error) {
+	if pkts, err := rtcp.Unmarshal(p); err == nil {
+		w.telemetry.handleRTCP(w.ssrc, pkts, time.Now())
+	}
+	return w.ReadWriteCloser.Write(p)
+}
+
+func (w *telemetryRTCPWriter) Close() error {
+	w.telemetry.removeStream(w.ssrc)
+	return w.ReadWriteCloser.Close()
+}
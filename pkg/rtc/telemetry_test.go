@@ -0,0 +1,115 @@
+package rtc
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pion/ion-sfu/pkg/sfu"
+	"github.com/pion/rtcp"
+	"github.com/pion/webrtc/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+func TestSubscriberTelemetry(t *testing.T) {
+	t.Run("samples feedback of bound tracks", func(t *testing.T) {
+		// samples are read here instead of by the sink's worker
+		sink := newQueueOnlySink()
+		telemetry := newSubscriberTelemetry(sink)
+
+		codec := webrtc.RTPCodecParameters{
+			RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeVP8, ClockRate: 90000},
+			PayloadType:        96,
+		}
+		dt, err := sfu.NewDownTrack(codec.RTPCodecCapability, NewWrappedReceiver(nil, "TR_1", "stream"), nil, "PA_sub", 500)
+		require.NoError(t, err)
+		bound := NewSubscribedTrack(dt, nil, nil, 2)
+		bound.onBind(codec, 1234)
+		bound.bound.TrySet(true)
+		unbound := NewSubscribedTrack(dt, nil, nil, 0)
+
+		now := time.Now()
+		telemetry.handleRTCP(1234, []rtcp.Packet{
+			&rtcp.ReceiverEstimatedMaximumBitrate{Bitrate: 1_000_000, SSRCs: []uint32{1234}},
+			&rtcp.ReceiverReport{Reports: []rtcp.ReceptionReport{{
+				SSRC:             1234,
+				FractionLost:     64,
+				Jitter:           90,
+				LastSenderReport: uint32(toNtpTime(now.Add(-100*time.Millisecond)) >> 16),
+				Delay:            uint32(20 * time.Millisecond * (1 << 16) / time.Second),
+			}}},
+		}, now)
+
+		telemetry.sample("sub", map[string][]types.SubscribedTrack{
+			"PA_pub": {bound, unbound},
+		})
+		require.Len(t, sink.samples, 1)
+		sample := <-sink.samples
+		require.Equal(t, "sub", sample.Participant)
+		require.Equal(t, "PA_pub", sample.PublisherSid)
+		require.Equal(t, "TR_1", sample.TrackID)
+		require.Equal(t, uint32(1234), sample.SSRC)
+		require.Equal(t, int32(2), sample.TargetLayer)
+		require.Equal(t, uint64(1_000_000), sample.EstimatedBandwidth)
+		require.Equal(t, 0.25, sample.FractionLost)
+		require.Equal(t, uint32(90), sample.Jitter)
+		require.InDelta(t, 80, sample.RTT, 2)
+
+		// feedback is dropped with the stream
+		telemetry.removeStream(1234)
+		telemetry.sample("sub", map[string][]types.SubscribedTrack{
+			"PA_pub": {bound},
+		})
+		sample = <-sink.samples
+		require.Zero(t, sample.RTT)
+	})
+
+	t.Run("drops samples beyond the queue", func(t *testing.T) {
+		sink := newQueueOnlySink()
+
+		for i := 0; i < telemetryQueueSize+10; i++ {
+			sink.Emit(SubscriberSample{})
+		}
+		require.Equal(t, uint64(10), sink.Dropped())
+	})
+
+	t.Run("file sink stops at max size", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "telemetry")
+		require.NoError(t, err)
+		defer os.RemoveAll(dir)
+		file := filepath.Join(dir, "telemetry.log")
+		sink, err := NewTelemetrySink(config.SubscriberTelemetryConfig{
+			Enabled:      true,
+			Interval:     time.Second,
+			Sink:         config.TelemetrySinkFile,
+			File:         file,
+			MaxFileBytes: 1000,
+		})
+		require.NoError(t, err)
+		defer sink.Close()
+
+		for i := 0; i < 100; i++ {
+			require.True(t, sink.Emit(SubscriberSample{Participant: "sub"}))
+		}
+		require.Eventually(t, func() bool {
+			return sink.Dropped() > 0 && len(sink.samples) == 0
+		}, time.Second, 10*time.Millisecond)
+		info, err := os.Stat(file)
+		require.NoError(t, err)
+		require.Less(t, info.Size(), int64(1300))
+	})
+}
+
+// newQueueOnlySink returns a sink without a worker, samples stay queued
+func newQueueOnlySink() *TelemetrySink {
+	return &TelemetrySink{
+		conf:    config.SubscriberTelemetryConfig{Enabled: true, Interval: time.Second},
+		samples: make(chan SubscriberSample, telemetryQueueSize),
+		done:    make(chan struct{}),
+	}
+}
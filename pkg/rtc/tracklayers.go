@@ -0,0 +1,126 @@
+package rtc
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/livekit/livekit-server/pkg/logger"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+	livekit "github.com/livekit/livekit-server/proto"
+)
+
+// type of user packets telling subscribers of a video track how its layers are encoded. TrackInfo only carries the
+// dimensions of the highest layer
+const trackLayersPacketType = "track_layers"
+
+var errInvalidTrackLayers = errors.New("track layers must have distinct qualities, and dimensions")
+
+// trackLayersSignal is the payload of data packets describing the layers of a published video track to its
+// subscribers
+type trackLayersSignal struct {
+	Type           string                 `json:"type"`
+	ParticipantSid string                 `json:"participantSid"`
+	TrackSid       string                 `json:"trackSid"`
+	Layers         []types.VideoLayerInfo `json:"layers"`
+}
+
+func validateTrackLayers(layers []types.VideoLayerInfo) error {
+	if len(layers) == 0 || len(layers) > maxSpatialLayer+1 {
+		return errInvalidTrackLayers
+	}
+	seen := make(map[livekit.VideoQuality]bool)
+	for _, layer := range layers {
+		if layer.Quality < livekit.VideoQuality_LOW || layer.Quality > livekit.VideoQuality_HIGH || seen[layer.Quality] {
+			return errInvalidTrackLayers
+		}
+		if layer.Width == 0 || layer.Height == 0 || layer.Framerate < 0 {
+			return errInvalidTrackLayers
+		}
+		seen[layer.Quality] = true
+	}
+	return nil
+}
+
+// highestLayer returns the layer of the highest quality
+func highestLayer(layers []types.VideoLayerInfo) (types.VideoLayerInfo, bool) {
+	var highest types.VideoLayerInfo
+	for i, layer := range layers {
+		if i == 0 || layer.Quality > highest.Quality {
+			highest = layer
+		}
+	}
+	return highest, len(layers) > 0
+}
+
+func newTrackLayersSignal(participantSid string, track types.PublishedTrack) trackLayersSignal {
+	return trackLayersSignal{
+		Type:           trackLayersPacketType,
+		ParticipantSid: participantSid,
+		TrackSid:       track.ID(),
+		Layers:         track.Layers(),
+	}
+}
+
+// describeLayers returns the layers of the track when the publisher encodes its highest one at width x height.
+// Simulcast streams are scaled down by the resolution of their RID
+func (t *MediaTrack) describeLayers(width, height uint32) []types.VideoLayerInfo {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	if !t.simulcasted {
+		return []types.VideoLayerInfo{{Quality: livekit.VideoQuality_HIGH, Width: width, Height: height}}
+	}
+	layers := make([]types.VideoLayerInfo, 0, len(t.layers.received))
+	for layer, rid := range t.layers.received {
+		scale := uint32(1)
+		switch rid {
+		case quarterResolution:
+			scale = 4
+		case halfResolution:
+			scale = 2
+		}
+		layers = append(layers, types.VideoLayerInfo{
+			Quality: t.layers.qualityForLayer(layer),
+			Width:   width / scale,
+			Height:  height / scale,
+		})
+	}
+	sort.Slice(layers, func(i, j int) bool {
+		return layers[i].Quality < layers[j].Quality
+	})
+	return layers
+}
+
+// publishedTrackForCid returns the published video track the client added as cid, including simulcast tracks
+// whose pending info is kept
+func (p *ParticipantImpl) publishedTrackForCid(cid string) *MediaTrack {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	for _, track := range p.publishedTracks {
+		if mt, ok := track.(*MediaTrack); ok && mt.clientID == cid && mt.Kind() == livekit.TrackType_VIDEO {
+			return mt
+		}
+	}
+	return nil
+}
+
+// updateTrackLayers stores the dimensions a participant now encodes one of its published video tracks at, and
+// tells others about them. Publishers send them by adding the track again
+func (p *ParticipantImpl) updateTrackLayers(track *MediaTrack, width, height uint32) {
+	layers := track.describeLayers(width, height)
+	if err := validateTrackLayers(layers); err != nil {
+		logger.Warnw("could not update track layers", err,
+			"participant", p.Identity(),
+			"track", track.ID(),
+			"width", width,
+			"height", height)
+		return
+	}
+	logger.Debugw("updating track layers",
+		"participant", p.Identity(),
+		"track", track.ID(),
+		"layers", layers)
+	track.SetLayers(layers)
+	if p.onTrackUpdated != nil {
+		p.onTrackUpdated(p, track)
+	}
+}
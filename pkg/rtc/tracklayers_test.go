@@ -0,0 +1,44 @@
+package rtc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+	livekit "github.com/livekit/livekit-server/proto"
+)
+
+func TestTrackLayers(t *testing.T) {
+	t.Run("describes layers of received streams", func(t *testing.T) {
+		mt := &MediaTrack{layers: newSimulcastLayers(config.SimulcastConfig{})}
+		require.Equal(t, []types.VideoLayerInfo{
+			{Quality: livekit.VideoQuality_HIGH, Width: 1280, Height: 720},
+		}, mt.describeLayers(1280, 720))
+
+		mt.simulcasted = true
+		for _, rid := range []string{fullResolution, quarterResolution} {
+			_, err := mt.layers.addStream(rid)
+			require.NoError(t, err)
+		}
+		require.Equal(t, []types.VideoLayerInfo{
+			{Quality: livekit.VideoQuality_LOW, Width: 320, Height: 180},
+			{Quality: livekit.VideoQuality_HIGH, Width: 1280, Height: 720},
+		}, mt.describeLayers(1280, 720))
+	})
+
+	t.Run("validates layers", func(t *testing.T) {
+		low := types.VideoLayerInfo{Quality: livekit.VideoQuality_LOW, Width: 320, Height: 180}
+		high := types.VideoLayerInfo{Quality: livekit.VideoQuality_HIGH, Width: 1280, Height: 720}
+		require.NoError(t, validateTrackLayers([]types.VideoLayerInfo{high, low}))
+		require.Error(t, validateTrackLayers(nil))
+		require.Error(t, validateTrackLayers([]types.VideoLayerInfo{low, low}))
+		require.Error(t, validateTrackLayers([]types.VideoLayerInfo{{Quality: livekit.VideoQuality(3), Width: 1, Height: 1}}))
+		require.Error(t, validateTrackLayers([]types.VideoLayerInfo{{Quality: livekit.VideoQuality_LOW}}))
+
+		highest, ok := highestLayer([]types.VideoLayerInfo{high, low})
+		require.True(t, ok)
+		require.Equal(t, high, highest)
+	})
+}
@@ -0,0 +1,109 @@
+package rtc
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/livekit/protocol/utils"
+	"github.com/pion/interceptor"
+	"github.com/pion/ion-sfu/pkg/buffer"
+	"github.com/pion/ion-sfu/pkg/sfu"
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v3"
+
+	"github.com/livekit/livekit-server/pkg/logger"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+// trackSinkForwarder is a virtual subscriber that writes RTP packets of a track to a TrackSink, like UDPForwarder.
+// Its DownTrack is added to the receiver as any other, so the sink gets the same packets in the same order
+type trackSinkForwarder struct {
+	interceptor.NoOp
+	sink          types.TrackSink
+	ssrc          uint32
+	receiver      sfu.Receiver
+	bufferFactory *buffer.Factory
+	sender        *webrtc.RTPSender
+	downTrack     *sfu.DownTrack
+	// set while writes to the sink are failing
+	failing utils.AtomicFlag
+
+	onClose func()
+}
+
+func newTrackSinkForwarder(sink types.TrackSink, receiver sfu.Receiver, bufferFactory *buffer.Factory, packetBufferSize int) (*trackSinkForwarder, error) {
+	f := &trackSinkForwarder{
+		sink:          sink,
+		ssrc:          rand.Uint32(),
+		receiver:      receiver,
+		bufferFactory: bufferFactory,
+	}
+	var err error
+	f.downTrack, f.sender, err = bindVirtualDownTrack(receiver, bufferFactory, f.ssrc,
+		fmt.Sprintf("SINK_%d", f.ssrc), packetBufferSize, f)
+	if err != nil {
+		return nil, err
+	}
+	f.downTrack.OnCloseHandler(func() {
+		go f.close()
+	})
+	return f, nil
+}
+
+// Start begins writing to the sink, and requests a keyframe so it could decode right away
+func (f *trackSinkForwarder) Start() {
+	f.receiver.AddDownTrack(f.downTrack, true)
+	if f.receiver.Kind() == webrtc.RTPCodecTypeVideo {
+		f.receiver.SendRTCP([]rtcp.Packet{
+			&rtcp.PictureLossIndication{
+				SenderSSRC: f.ssrc,
+				MediaSSRC:  f.receiver.SSRC(int(f.downTrack.CurrentSpatialLayer())),
+			},
+		})
+	}
+}
+
+// Stop stops writing and closes the sink
+func (f *trackSinkForwarder) Stop() {
+	f.downTrack.Close()
+}
+
+func (f *trackSinkForwarder) OnClose(fn func()) {
+	f.onClose = fn
+}
+
+func (f *trackSinkForwarder) close() {
+	// unbinds DownTrack, removing it from the receiver
+	if err := f.sender.Stop(); err != nil {
+		logger.Debugw("could not stop sink sender", "error", err)
+	}
+	if rr := f.bufferFactory.GetRTCPReader(f.ssrc); rr != nil {
+		_ = rr.Close()
+	}
+	if err := f.sink.Close(); err != nil {
+		logger.Debugw("could not close track sink", "error", err)
+	}
+	if f.onClose != nil {
+		f.onClose()
+	}
+}
+
+// BindLocalStream replaces the SRTP writer of the RTPSender, implements interceptor.Interceptor
+func (f *trackSinkForwarder) BindLocalStream(_ *interceptor.StreamInfo, _ interceptor.RTPWriter) interceptor.RTPWriter {
+	return interceptor.RTPWriterFunc(func(header *rtp.Header, payload []byte, _ interceptor.Attributes) (int, error) {
+		// the payload is shared with other DownTracks of the receiver, the sink could hold on to its copy
+		pkt := &rtp.Packet{Header: *header, Payload: append([]byte(nil), payload...)}
+		if err := f.sink.WriteRTP(pkt); err != nil {
+			// a slow or broken sink shouldn't fail the DownTrack, log once
+			if f.failing.TrySet(true) {
+				logger.Warnw("could not write to track sink", err)
+			}
+			return len(payload), nil
+		}
+		if f.failing.TrySet(false) {
+			logger.Infow("track sink is writable again")
+		}
+		return len(payload), nil
+	})
+}
@@ -0,0 +1,63 @@
+package rtc
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/rtc/types/typesfakes"
+)
+
+func TestTrackSinkWrite(t *testing.T) {
+	sink := &typesfakes.FakeTrackSink{}
+	f := &trackSinkForwarder{sink: sink}
+	writer := f.BindLocalStream(nil, nil)
+
+	payload := []byte{1, 2, 3}
+	for sn := uint16(10); sn < 13; sn++ {
+		header := &rtp.Header{Version: 2, PayloadType: 96, SequenceNumber: sn, SSRC: 1234}
+		_, err := writer.Write(header, payload, nil)
+		require.NoError(t, err)
+	}
+	// buffers of the receiver are reused once written to all DownTracks
+	payload[0] = 9
+
+	require.Equal(t, 3, sink.WriteRTPCallCount())
+	for i := 0; i < 3; i++ {
+		pkt := sink.WriteRTPArgsForCall(i)
+		require.Equal(t, uint16(10+i), pkt.SequenceNumber)
+		require.Equal(t, uint32(1234), pkt.SSRC)
+		require.Equal(t, []byte{1, 2, 3}, pkt.Payload)
+	}
+
+	t.Run("failing sink does not fail writes", func(t *testing.T) {
+		sink := &typesfakes.FakeTrackSink{}
+		sink.WriteRTPReturns(errors.New("disk full"))
+		f := &trackSinkForwarder{sink: sink}
+		writer := f.BindLocalStream(nil, nil)
+		for i := 0; i < 3; i++ {
+			_, err := writer.Write(&rtp.Header{SequenceNumber: uint16(i)}, []byte{1}, nil)
+			require.NoError(t, err)
+		}
+		require.Equal(t, 3, sink.WriteRTPCallCount())
+	})
+}
+
+func TestAttachSink(t *testing.T) {
+	p := newParticipantForTest("test")
+	track := &typesfakes.FakePublishedTrack{}
+	track.IDReturns("track1")
+	p.publishedTracks["track1"] = track
+	sink := &typesfakes.FakeTrackSink{}
+
+	require.NoError(t, p.AttachSink("track1", sink))
+	require.Equal(t, 1, track.AddSinkCallCount())
+	require.Equal(t, sink, track.AddSinkArgsForCall(0))
+	require.Equal(t, ErrTrackNotPublished, p.AttachSink("track2", sink))
+
+	require.NoError(t, p.DetachSink("track1", sink))
+	require.Equal(t, 1, track.RemoveSinkCallCount())
+	require.Equal(t, ErrTrackNotPublished, p.DetachSink("track2", sink))
+}
@@ -0,0 +1,53 @@
+package rtc
+
+import (
+	"strings"
+
+	"github.com/livekit/livekit-server/pkg/logger"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+// Transcoder sends tracks to subscribers in another codec than the one they are published in. The server
+// doesn't bundle one, as encoders depend on cgo, so it has to be supplied to transcode subscriptions
+type Transcoder interface {
+	// AddSubscriber sends the track to sub in the codec of the target mime type
+	AddSubscriber(track *MediaTrack, sub types.Participant, target string) error
+}
+
+// TranscodingMatrix holds codecs tracks could be transcoded to, keyed by the lowercase mime type of their codec
+type TranscodingMatrix map[string][]string
+
+// target returns the first codec the track could be transcoded to that the subscriber decodes
+func (m TranscodingMatrix) target(mimeType string, sub types.Participant) (string, bool) {
+	for _, target := range m[strings.ToLower(mimeType)] {
+		if sub.SupportsCodec(target) {
+			return target, true
+		}
+	}
+	return "", false
+}
+
+// addTranscodedSubscriber subscribes sub, which can't decode the track's codec, through the transcoder when the
+// matrix allows converting it to a codec sub decodes
+func (t *MediaTrack) addTranscodedSubscriber(sub types.Participant) error {
+	mismatch := &CodecMismatchError{TrackID: t.ID(), Codec: t.codec.MimeType}
+	target, ok := t.params.Transcoding.target(t.codec.MimeType, sub)
+	switch {
+	case !ok:
+		mismatch.Reason = "transcoding to a codec the subscriber supports isn't enabled"
+	case t.params.Transcoder == nil:
+		mismatch.Reason = "no transcoder is available"
+	default:
+		logger.Debugw("transcoding subscribed track",
+			"track", t.ID(),
+			"participantId", t.params.ParticipantID,
+			"destParticipant", sub.Identity(),
+			"from", t.codec.MimeType,
+			"to", target)
+		return t.params.Transcoder.AddSubscriber(t, sub, target)
+	}
+	logger.Warnw("rejecting subscription", mismatch,
+		"participantId", t.params.ParticipantID,
+		"destParticipant", sub.Identity())
+	return mismatch
+}
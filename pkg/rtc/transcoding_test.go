@@ -0,0 +1,68 @@
+package rtc
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/pion/webrtc/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+	"github.com/livekit/livekit-server/pkg/rtc/types/typesfakes"
+)
+
+func TestTranscoding(t *testing.T) {
+	newTrack := func(transcoder Transcoder) *MediaTrack {
+		return &MediaTrack{
+			params: MediaTrackParams{
+				TrackID:     "track",
+				Transcoding: TranscodingMatrix{"video/vp8": {"video/vp9", "video/h264"}},
+				Transcoder:  transcoder,
+			},
+			codec: webrtc.RTPCodecParameters{RTPCodecCapability: webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeVP8}},
+		}
+	}
+	newSubscriber := func(codecs ...string) *typesfakes.FakeParticipant {
+		sub := &typesfakes.FakeParticipant{}
+		sub.CanSubscribeReturns(true)
+		sub.SupportsCodecCalls(func(mimeType string) bool {
+			return (&ParticipantImpl{params: ParticipantParams{SupportedCodecs: codecs}}).SupportsCodec(mimeType)
+		})
+		return sub
+	}
+
+	t.Run("transcodes to the first codec the subscriber supports", func(t *testing.T) {
+		transcoder := &recordingTranscoder{}
+		require.NoError(t, newTrack(transcoder).AddSubscriber(newSubscriber("video/h264", "video/vp9")))
+		require.Equal(t, []string{"video/vp9"}, transcoder.targets)
+	})
+
+	t.Run("rejects pairs that aren't enabled", func(t *testing.T) {
+		transcoder := &recordingTranscoder{}
+		err := newTrack(transcoder).AddSubscriber(newSubscriber("video/av1"))
+		require.True(t, errors.Is(err, ErrCodecNotSupported))
+		var mismatch *CodecMismatchError
+		require.True(t, errors.As(err, &mismatch))
+		require.Equal(t, webrtc.MimeTypeVP8, mismatch.Codec)
+		require.Empty(t, transcoder.targets)
+	})
+
+	t.Run("rejects without a transcoder", func(t *testing.T) {
+		err := newTrack(nil).AddSubscriber(newSubscriber("video/h264"))
+		require.True(t, errors.Is(err, ErrCodecNotSupported))
+	})
+
+	t.Run("subscribers declaring no codecs support any", func(t *testing.T) {
+		sub := newSubscriber()
+		require.True(t, sub.SupportsCodec(webrtc.MimeTypeVP8))
+	})
+}
+
+type recordingTranscoder struct {
+	targets []string
+}
+
+func (r *recordingTranscoder) AddSubscriber(_ *MediaTrack, _ types.Participant, target string) error {
+	r.targets = append(r.targets, target)
+	return nil
+}
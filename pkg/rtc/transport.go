@@ -1,14 +1,20 @@
 package rtc
 
 import (
+	"errors"
+	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/bep/debounce"
 	"github.com/pion/interceptor"
+	"github.com/pion/rtcp"
 	"github.com/pion/webrtc/v3"
 
+	"github.com/livekit/livekit-server/pkg/config"
 	"github.com/livekit/livekit-server/pkg/logger"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
 
 	livekit "github.com/livekit/livekit-server/proto"
 )
@@ -27,8 +33,15 @@ const (
 
 // PCTransport is a wrapper around PeerConnection, with some helper methods
 type PCTransport struct {
-	pc *webrtc.PeerConnection
-	me *webrtc.MediaEngine
+	pc     *webrtc.PeerConnection
+	me     *webrtc.MediaEngine
+	target livekit.SignalTarget
+	// paces video sent to subscribers when enabled
+	pacer *KeyframePacer
+	// nil unless streams are sent to the subscriber on stable SSRCs
+	ssrcRemapper *ssrcRemapper
+	// latest types.TransportStats, collected on state changes and periodically
+	stats atomic.Value
 
 	lock                  sync.Mutex
 	pendingCandidates     []webrtc.ICECandidateInit
@@ -36,6 +49,18 @@ type PCTransport struct {
 	onOffer               func(offer webrtc.SessionDescription)
 	restartAfterGathering bool
 	negotiationState      int
+
+	negotiationConfig   config.NegotiationConfig
+	answerTimer         *time.Timer
+	answerTimerID       int
+	offerRetries        int
+	onNegotiationFailed func()
+
+	// fires when the signaling state doesn't get back to stable in time
+	stuckTimer   *time.Timer
+	stuckTimerID int
+	stuckSince   time.Time
+	onAnswer     func(answer webrtc.SessionDescription)
 }
 
 type TransportParams struct {
@@ -43,21 +68,67 @@ type TransportParams struct {
 	Config        *WebRTCConfig
 	Stats         *RoomStatsReporter
 	EnabledCodecs []*livekit.Codec
+	RTCPFeedback  *config.RTCPFeedbackConfig
+	// limits buffers of published streams
+	BufferBudget *bufferBudget
+	// retries of unanswered offers
+	Negotiation config.NegotiationConfig
+	// observes RTCP of subscribers
+	Telemetry *subscriberTelemetry
+	// receives reception reports of subscribers on streams sent to them
+	OnReceptionReport func(ssrc uint32, report rtcp.ReceptionReport)
+	// sends streams to subscribers on stable SSRCs
+	SSRCRemapper *ssrcRemapper
+	// continues published streams the publisher switches to a new SSRC
+	SSRCHandoff *ssrcHandoff
+	// estimates the bandwidth of the subscriber from its RTCP
+	BandwidthAdaptation *bandwidthAdaptation
+	// measures how long the publisher takes to answer keyframe requests
+	KeyframeLatency *keyframeLatency
+	// reorders RTP the publisher sends out of order
+	ReorderBuffers *reorderBuffers
+	// negotiates abs-capture-time with the publisher, for subscribers with jitter buffer hints
+	CaptureTime bool
+	// sets the depth of the subscriber's jitter buffers
+	JitterBuffer *jitterBufferHints
 }
 
-func newPeerConnection(params TransportParams) (*webrtc.PeerConnection, *webrtc.MediaEngine, error) {
+func newPeerConnection(params TransportParams) (*webrtc.PeerConnection, *webrtc.MediaEngine, *KeyframePacer, error) {
 	var me *webrtc.MediaEngine
 	var err error
 	if params.Target == livekit.SignalTarget_PUBLISHER {
-		me, err = createPubMediaEngine(params.EnabledCodecs)
+		me, err = createPubMediaEngine(params.EnabledCodecs, params.RTCPFeedback, params.CaptureTime)
 	} else {
-		me, err = createSubMediaEngine()
+		me, err = createSubMediaEngine(params.JitterBuffer != nil)
 	}
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 	se := params.Config.SettingEngine
 	se.DisableMediaEngineCopy(true)
+	if err := applyLoopback(&se); err != nil {
+		return nil, nil, nil, err
+	}
+	if params.ReorderBuffers != nil && se.BufferFactory != nil {
+		// wrapped first to be the innermost, buffers get packets in sequence while other wrappers see them as they
+		// arrive
+		se.BufferFactory = params.ReorderBuffers.wrapBufferFactory(se.BufferFactory)
+	}
+	if params.BufferBudget != nil && se.BufferFactory != nil {
+		se.BufferFactory = params.BufferBudget.wrapBufferFactory(se.BufferFactory)
+	}
+	if params.Telemetry != nil && se.BufferFactory != nil {
+		se.BufferFactory = params.Telemetry.wrapBufferFactory(se.BufferFactory)
+	}
+	if params.OnReceptionReport != nil && se.BufferFactory != nil {
+		se.BufferFactory = wrapReceptionReports(se.BufferFactory, params.OnReceptionReport)
+	}
+	if params.BandwidthAdaptation != nil && se.BufferFactory != nil {
+		se.BufferFactory = params.BandwidthAdaptation.wrapBufferFactory(se.BufferFactory)
+	}
+	if params.KeyframeLatency != nil && se.BufferFactory != nil {
+		se.BufferFactory = params.KeyframeLatency.wrapBufferFactory(se.BufferFactory)
+	}
 	if params.Stats != nil && se.BufferFactory != nil {
 		wrapper := &StatsBufferWrapper{
 			createBufferFunc: se.BufferFactory,
@@ -65,23 +136,46 @@ func newPeerConnection(params TransportParams) (*webrtc.PeerConnection, *webrtc.
 		}
 		se.BufferFactory = wrapper.CreateBuffer
 	}
+	if params.SSRCRemapper != nil && se.BufferFactory != nil {
+		se.BufferFactory = params.SSRCRemapper.wrapBufferFactory(se.BufferFactory)
+	}
+	if params.SSRCHandoff != nil && se.BufferFactory != nil {
+		// wrapped last to see packets first, redirected ones are seen by other wrappers as the stream they continue
+		se.BufferFactory = params.SSRCHandoff.wrapBufferFactory(se.BufferFactory)
+	}
 
 	ir := &interceptor.Registry{}
+	if params.SSRCRemapper != nil && params.Target == livekit.SignalTarget_SUBSCRIBER {
+		// added first to be the innermost, other interceptors only see original SSRCs
+		ir.Add(params.SSRCRemapper)
+	}
+	if params.SSRCHandoff != nil && params.Target == livekit.SignalTarget_PUBLISHER {
+		ir.Add(params.SSRCHandoff)
+	}
+	if params.JitterBuffer != nil && params.Target == livekit.SignalTarget_SUBSCRIBER {
+		ir.Add(params.JitterBuffer)
+	}
 	if params.Stats != nil && params.Target == livekit.SignalTarget_SUBSCRIBER {
 		// only capture subscriber for outbound streams
 		ir.Add(NewStatsInterceptor(params.Stats))
 	}
+	var pacer *KeyframePacer
+	if params.Config.KeyframePacing.Enabled && params.Target == livekit.SignalTarget_SUBSCRIBER {
+		// added last to be the outermost, so stats count packets when they're actually sent
+		pacer = NewKeyframePacer(params.Config.KeyframePacing)
+		ir.Add(pacer)
+	}
 	api := webrtc.NewAPI(
 		webrtc.WithMediaEngine(me),
 		webrtc.WithSettingEngine(se),
 		webrtc.WithInterceptorRegistry(ir),
 	)
 	pc, err := api.NewPeerConnection(params.Config.Configuration)
-	return pc, me, err
+	return pc, me, pacer, err
 }
 
 func NewPCTransport(params TransportParams) (*PCTransport, error) {
-	pc, me, err := newPeerConnection(params)
+	pc, me, pacer, err := newPeerConnection(params)
 	if err != nil {
 		return nil, err
 	}
@@ -89,8 +183,12 @@ func NewPCTransport(params TransportParams) (*PCTransport, error) {
 	t := &PCTransport{
 		pc:                 pc,
 		me:                 me,
+		target:             params.Target,
+		pacer:              pacer,
+		ssrcRemapper:       params.SSRCRemapper,
 		debouncedNegotiate: debounce.New(negotiationFrequency),
 		negotiationState:   negotiationStateNone,
+		negotiationConfig:  params.Negotiation,
 	}
 	t.pc.OnICEGatheringStateChange(func(state webrtc.ICEGathererState) {
 		if state == webrtc.ICEGathererStateComplete {
@@ -106,6 +204,16 @@ func NewPCTransport(params TransportParams) (*PCTransport, error) {
 			}()
 		}
 	})
+	t.pc.OnConnectionStateChange(func(state webrtc.PeerConnectionState) {
+		t.updateStats()
+	})
+	t.pc.OnSignalingStateChange(func(_ webrtc.SignalingState) {
+		// handlers run in their own goroutine and could be called out of order, the current state is used instead
+		t.lock.Lock()
+		t.watchNegotiation(false)
+		t.lock.Unlock()
+	})
+	t.updateStats()
 
 	return t, nil
 }
@@ -125,7 +233,38 @@ func (t *PCTransport) PeerConnection() *webrtc.PeerConnection {
 	return t.pc
 }
 
+// Stats returns transport stats as of the last state change or periodic update, it doesn't query the peer connection
+func (t *PCTransport) Stats() types.TransportStats {
+	return t.stats.Load().(types.TransportStats)
+}
+
+// updateStats collects ICE and DTLS state, bytes sent and received, and the selected candidate pair
+func (t *PCTransport) updateStats() {
+	stats := types.TransportStats{
+		Target:          t.target,
+		ConnectionState: t.pc.ConnectionState().String(),
+		ICEState:        t.pc.ICEConnectionState().String(),
+		UpdatedAt:       time.Now(),
+	}
+	if dtls := t.pc.SCTP().Transport(); dtls != nil {
+		stats.DTLSState = dtls.State().String()
+		if pair, err := dtls.ICETransport().GetSelectedCandidatePair(); err == nil && pair != nil {
+			stats.LocalCandidate = pair.Local.String()
+			stats.RemoteCandidate = pair.Remote.String()
+		}
+	}
+	if ts, ok := t.pc.GetStats()["iceTransport"].(webrtc.TransportStats); ok {
+		stats.BytesSent = ts.BytesSent
+		stats.BytesReceived = ts.BytesReceived
+	}
+	t.stats.Store(stats)
+}
+
 func (t *PCTransport) Close() {
+	t.lock.Lock()
+	t.stopAnswerTimer()
+	t.stopStuckTimer()
+	t.lock.Unlock()
 	_ = t.pc.Close()
 }
 
@@ -140,6 +279,8 @@ func (t *PCTransport) SetRemoteDescription(sd webrtc.SessionDescription) error {
 	// negotiated, reset flag
 	lastState := t.negotiationState
 	t.negotiationState = negotiationStateNone
+	t.stopAnswerTimer()
+	t.watchNegotiation(true)
 
 	for _, c := range t.pendingCandidates {
 		if err := t.pc.AddICECandidate(c); err != nil {
@@ -163,6 +304,17 @@ func (t *PCTransport) OnOffer(f func(sd webrtc.SessionDescription)) {
 	t.onOffer = f
 }
 
+// OnNegotiationFailed is called when an offer is still unanswered after all retries, or stuck negotiation
+// couldn't be recovered
+func (t *PCTransport) OnNegotiationFailed(f func()) {
+	t.onNegotiationFailed = f
+}
+
+// OnAnswer is called with answers to offers of the client that were stuck without one
+func (t *PCTransport) OnAnswer(f func(sd webrtc.SessionDescription)) {
+	t.onAnswer = f
+}
+
 func (t *PCTransport) Negotiate() {
 	t.debouncedNegotiate(func() {
 		if err := t.CreateAndSendOffer(nil); err != nil {
@@ -231,7 +383,204 @@ func (t *PCTransport) createAndSendOffer(options *webrtc.OfferOptions) error {
 	// indicate waiting for client
 	t.negotiationState = negotiationStateClient
 	t.restartAfterGathering = false
+	t.offerRetries = 0
+	t.startAnswerTimer()
+	t.watchNegotiation(true)
 
-	go t.onOffer(offer)
+	go t.onOffer(t.outgoingDescription(offer))
 	return nil
 }
+
+// outgoingDescription is the local description as it's sent to the client
+func (t *PCTransport) outgoingDescription(sd webrtc.SessionDescription) webrtc.SessionDescription {
+	if t.ssrcRemapper != nil {
+		sd.SDP = t.ssrcRemapper.mungeSDP(sd.SDP)
+	}
+	return sd
+}
+
+// assumes lock has been acquired
+func (t *PCTransport) startAnswerTimer() {
+	if t.negotiationConfig.AnswerTimeout <= 0 {
+		return
+	}
+	t.stopAnswerTimer()
+	t.answerTimerID++
+	id := t.answerTimerID
+	t.answerTimer = time.AfterFunc(t.negotiationConfig.AnswerTimeout, func() {
+		t.handleAnswerTimeout(id)
+	})
+}
+
+// assumes lock has been acquired
+func (t *PCTransport) stopAnswerTimer() {
+	if t.answerTimer != nil {
+		t.answerTimer.Stop()
+		t.answerTimer = nil
+	}
+}
+
+// handleAnswerTimeout sends the pending offer again, and gives up on it once retries are exhausted
+func (t *PCTransport) handleAnswerTimeout(id int) {
+	t.lock.Lock()
+	if t.answerTimer == nil || t.answerTimerID != id || t.negotiationState == negotiationStateNone {
+		// answered in the meantime
+		t.lock.Unlock()
+		return
+	}
+	t.answerTimer = nil
+	if t.pc.ConnectionState() == webrtc.PeerConnectionStateClosed {
+		t.lock.Unlock()
+		return
+	}
+
+	offer := t.pc.PendingLocalDescription()
+	if offer != nil && t.offerRetries < t.negotiationConfig.MaxRetries {
+		t.offerRetries++
+		logger.Debugw("offer not answered, sending again", "attempt", t.offerRetries)
+		t.startAnswerTimer()
+		t.lock.Unlock()
+		t.onOffer(t.outgoingDescription(*offer))
+		return
+	}
+
+	logger.Infow("offer not answered, giving up", "retries", t.offerRetries)
+	// local descriptions can't be rolled back, recover the last negotiated state as ICE restarts do.
+	// the next offer replaces the pending one otherwise
+	if currentSD := t.pc.CurrentRemoteDescription(); offer != nil && currentSD != nil {
+		if err := t.pc.SetRemoteDescription(*currentSD); err != nil {
+			logger.Warnw("could not recover from unanswered offer", err)
+		}
+	}
+	t.negotiationState = negotiationStateNone
+	onNegotiationFailed := t.onNegotiationFailed
+	t.lock.Unlock()
+
+	if onNegotiationFailed != nil {
+		onNegotiationFailed()
+	}
+}
+
+// watchNegotiation starts watching for stuck negotiation when the signaling state leaves stable, and stops once it's
+// back. progressed restarts the watch for a state that changed since it started, assumes lock has been acquired
+func (t *PCTransport) watchNegotiation(progressed bool) {
+	if t.negotiationConfig.StuckTimeout <= 0 {
+		return
+	}
+	if progressed {
+		t.stopStuckTimer()
+	}
+	if t.pc.SignalingState() == webrtc.SignalingStateStable || t.pc.ConnectionState() == webrtc.PeerConnectionStateClosed {
+		t.stopStuckTimer()
+		return
+	}
+	if t.stuckTimer == nil {
+		t.stuckSince = time.Now()
+		t.startStuckTimer()
+	}
+}
+
+// assumes lock has been acquired
+func (t *PCTransport) startStuckTimer() {
+	t.stuckTimerID++
+	id := t.stuckTimerID
+	t.stuckTimer = time.AfterFunc(t.negotiationConfig.StuckTimeout, func() {
+		t.handleStuckNegotiation(id)
+	})
+}
+
+// assumes lock has been acquired
+func (t *PCTransport) stopStuckTimer() {
+	if t.stuckTimer != nil {
+		t.stuckTimer.Stop()
+		t.stuckTimer = nil
+	}
+}
+
+// handleStuckNegotiation recovers a signaling state that didn't get back to stable in time. Offers of the server
+// are recovered by restoring the last negotiated state, those of the client by answering them again
+func (t *PCTransport) handleStuckNegotiation(id int) {
+	t.lock.Lock()
+	if t.stuckTimer == nil || t.stuckTimerID != id {
+		t.lock.Unlock()
+		return
+	}
+	t.stuckTimer = nil
+	state := t.pc.SignalingState()
+	if state == webrtc.SignalingStateStable || t.pc.ConnectionState() == webrtc.PeerConnectionStateClosed {
+		t.lock.Unlock()
+		return
+	}
+	if t.answerTimer != nil {
+		// the offer is still being sent again, it's recovered once retries are exhausted
+		t.startStuckTimer()
+		t.lock.Unlock()
+		return
+	}
+
+	logger.Warnw("negotiation stuck, recovering", ErrNegotiationStuck,
+		"target", t.target.String(),
+		"signalingState", state.String(),
+		"negotiationState", t.negotiationState,
+		"stuckFor", time.Since(t.stuckSince),
+		"recovery", t.negotiationConfig.StuckRecovery)
+	negotiationStuckTotal.WithLabelValues(t.target.String(), state.String()).Inc()
+
+	var answer *webrtc.SessionDescription
+	var err error
+	switch state {
+	case webrtc.SignalingStateHaveLocalOffer:
+		err = t.recoverLocalOffer()
+	case webrtc.SignalingStateHaveRemoteOffer:
+		answer, err = t.answerRemoteOffer()
+	default:
+		err = fmt.Errorf("cannot recover signaling state %s", state)
+	}
+	onAnswer := t.onAnswer
+	onNegotiationFailed := t.onNegotiationFailed
+	t.lock.Unlock()
+
+	if err != nil {
+		logger.Warnw("could not recover stuck negotiation", err, "target", t.target.String())
+		if onNegotiationFailed != nil {
+			onNegotiationFailed()
+		}
+		return
+	}
+	if answer != nil {
+		onAnswer(*answer)
+	}
+}
+
+// recoverLocalOffer restores the last negotiated state, and offers changes that were pending in the stuck offer
+// again. assumes lock has been acquired
+func (t *PCTransport) recoverLocalOffer() error {
+	// local descriptions can't be rolled back, the last answer is set again as ICE restarts do
+	currentSD := t.pc.CurrentRemoteDescription()
+	if currentSD == nil {
+		return errors.New("no negotiated state to recover")
+	}
+	if err := t.pc.SetRemoteDescription(*currentSD); err != nil {
+		return err
+	}
+	t.stopAnswerTimer()
+	t.negotiationState = negotiationStateNone
+	return t.createAndSendOffer(&webrtc.OfferOptions{
+		ICERestart: t.negotiationConfig.StuckRecovery == config.StuckRecoveryICERestart,
+	})
+}
+
+// answerRemoteOffer creates the answer the client is still waiting for, assumes lock has been acquired
+func (t *PCTransport) answerRemoteOffer() (*webrtc.SessionDescription, error) {
+	if t.onAnswer == nil {
+		return nil, errors.New("answers cannot be sent")
+	}
+	answer, err := t.pc.CreateAnswer(nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := t.pc.SetLocalDescription(answer); err != nil {
+		return nil, err
+	}
+	return &answer, nil
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/pion/webrtc/v3"
 	"github.com/stretchr/testify/require"
 
+	"github.com/livekit/livekit-server/pkg/config"
 	"github.com/livekit/livekit-server/pkg/testutils"
 	livekit "github.com/livekit/livekit-server/proto"
 )
@@ -111,6 +112,262 @@ func TestNegotiationTiming(t *testing.T) {
 	require.False(t, offer2 == actualOffer)
 }
 
+func TestUnansweredOffer(t *testing.T) {
+	params := TransportParams{
+		Target: livekit.SignalTarget_SUBSCRIBER,
+		Config: &WebRTCConfig{},
+		Negotiation: config.NegotiationConfig{
+			AnswerTimeout: 20 * time.Millisecond,
+			MaxRetries:    2,
+		},
+	}
+
+	t.Run("retries and gives up", func(t *testing.T) {
+		transportA, err := NewPCTransport(params)
+		require.NoError(t, err)
+		defer transportA.Close()
+		_, err = transportA.pc.CreateDataChannel("test", nil)
+		require.NoError(t, err)
+		transportB, err := NewPCTransport(TransportParams{Target: livekit.SignalTarget_PUBLISHER, Config: &WebRTCConfig{}})
+		require.NoError(t, err)
+		defer transportB.Close()
+
+		var offers int32
+		handleOffer := handleOfferFunc(t, transportA, transportB)
+		transportA.OnOffer(func(sd webrtc.SessionDescription) {
+			// only the first offer is answered
+			if atomic.AddInt32(&offers, 1) == 1 {
+				handleOffer(sd)
+			}
+		})
+		failed := make(chan struct{})
+		transportA.OnNegotiationFailed(func() {
+			close(failed)
+		})
+
+		require.NoError(t, transportA.CreateAndSendOffer(nil))
+		require.Eventually(t, func() bool {
+			return transportA.pc.SignalingState() == webrtc.SignalingStateStable
+		}, time.Second, 5*time.Millisecond)
+
+		require.NoError(t, transportA.CreateAndSendOffer(nil))
+		select {
+		case <-failed:
+		case <-time.After(time.Second):
+			t.Fatal("negotiation did not fail")
+		}
+		// second offer and its retries
+		require.Equal(t, int32(4), atomic.LoadInt32(&offers))
+		transportA.lock.Lock()
+		defer transportA.lock.Unlock()
+		require.Equal(t, webrtc.SignalingStateStable, transportA.pc.SignalingState())
+		require.Equal(t, negotiationStateNone, transportA.negotiationState)
+	})
+
+	t.Run("stops once answered", func(t *testing.T) {
+		transportA, err := NewPCTransport(params)
+		require.NoError(t, err)
+		defer transportA.Close()
+		_, err = transportA.pc.CreateDataChannel("test", nil)
+		require.NoError(t, err)
+		transportB, err := NewPCTransport(TransportParams{Target: livekit.SignalTarget_PUBLISHER, Config: &WebRTCConfig{}})
+		require.NoError(t, err)
+		defer transportB.Close()
+
+		var offers int32
+		handleOffer := handleOfferFunc(t, transportA, transportB)
+		transportA.OnOffer(func(sd webrtc.SessionDescription) {
+			// first offer is lost
+			if atomic.AddInt32(&offers, 1) > 1 {
+				handleOffer(sd)
+			}
+		})
+		transportA.OnNegotiationFailed(func() {
+			t.Error("negotiation should not fail")
+		})
+
+		require.NoError(t, transportA.CreateAndSendOffer(nil))
+		require.Eventually(t, func() bool {
+			return transportA.pc.SignalingState() == webrtc.SignalingStateStable && atomic.LoadInt32(&offers) == 2
+		}, time.Second, 5*time.Millisecond)
+		time.Sleep(100 * time.Millisecond)
+		require.Equal(t, int32(2), atomic.LoadInt32(&offers))
+	})
+}
+
+func TestStuckNegotiation(t *testing.T) {
+	newTransports := func(t *testing.T, negotiation config.NegotiationConfig) (*PCTransport, *PCTransport) {
+		transportA, err := NewPCTransport(TransportParams{
+			Target:      livekit.SignalTarget_SUBSCRIBER,
+			Config:      &WebRTCConfig{},
+			Negotiation: negotiation,
+		})
+		require.NoError(t, err)
+		_, err = transportA.pc.CreateDataChannel("test", nil)
+		require.NoError(t, err)
+		transportB, err := NewPCTransport(TransportParams{
+			Target:      livekit.SignalTarget_PUBLISHER,
+			Config:      &WebRTCConfig{},
+			Negotiation: negotiation,
+		})
+		require.NoError(t, err)
+		return transportA, transportB
+	}
+	negotiation := config.NegotiationConfig{
+		StuckTimeout:  50 * time.Millisecond,
+		StuckRecovery: config.StuckRecoveryRollback,
+	}
+
+	t.Run("offers pending changes again", func(t *testing.T) {
+		transportA, transportB := newTransports(t, negotiation)
+		defer transportA.Close()
+		defer transportB.Close()
+
+		var offers int32
+		handleOffer := handleOfferFunc(t, transportA, transportB)
+		transportA.OnOffer(func(sd webrtc.SessionDescription) {
+			// the second offer is lost
+			if atomic.AddInt32(&offers, 1) != 2 {
+				handleOffer(sd)
+			}
+		})
+		transportA.OnNegotiationFailed(func() {
+			t.Error("negotiation should be recovered")
+		})
+
+		require.NoError(t, transportA.CreateAndSendOffer(nil))
+		require.Eventually(t, func() bool {
+			return transportA.pc.SignalingState() == webrtc.SignalingStateStable
+		}, time.Second, 5*time.Millisecond)
+
+		require.NoError(t, transportA.CreateAndSendOffer(nil))
+		require.Eventually(t, func() bool {
+			return atomic.LoadInt32(&offers) == 3 && transportA.pc.SignalingState() == webrtc.SignalingStateStable
+		}, time.Second, 5*time.Millisecond)
+	})
+
+	t.Run("answers offers of the client again", func(t *testing.T) {
+		transportA, transportB := newTransports(t, negotiation)
+		defer transportA.Close()
+		defer transportB.Close()
+
+		answers := make(chan webrtc.SessionDescription, 1)
+		transportB.OnAnswer(func(sd webrtc.SessionDescription) {
+			answers <- sd
+		})
+		offer, err := transportA.pc.CreateOffer(nil)
+		require.NoError(t, err)
+		require.NoError(t, transportA.pc.SetLocalDescription(offer))
+		// the answer is never created
+		require.NoError(t, transportB.SetRemoteDescription(offer))
+
+		select {
+		case answer := <-answers:
+			require.NoError(t, transportA.SetRemoteDescription(answer))
+		case <-time.After(time.Second):
+			t.Fatal("offer was not answered")
+		}
+		require.Equal(t, webrtc.SignalingStateStable, transportB.pc.SignalingState())
+	})
+
+	t.Run("waits for retries of unanswered offers", func(t *testing.T) {
+		transportA, transportB := newTransports(t, config.NegotiationConfig{
+			AnswerTimeout: 40 * time.Millisecond,
+			MaxRetries:    2,
+			StuckTimeout:  20 * time.Millisecond,
+			StuckRecovery: config.StuckRecoveryRollback,
+		})
+		defer transportA.Close()
+		defer transportB.Close()
+
+		var offers int32
+		handleOffer := handleOfferFunc(t, transportA, transportB)
+		transportA.OnOffer(func(sd webrtc.SessionDescription) {
+			if atomic.AddInt32(&offers, 1) == 1 {
+				handleOffer(sd)
+			}
+		})
+		failed := make(chan struct{})
+		transportA.OnNegotiationFailed(func() {
+			close(failed)
+		})
+
+		require.NoError(t, transportA.CreateAndSendOffer(nil))
+		require.Eventually(t, func() bool {
+			return transportA.pc.SignalingState() == webrtc.SignalingStateStable
+		}, time.Second, 5*time.Millisecond)
+
+		require.NoError(t, transportA.CreateAndSendOffer(nil))
+		select {
+		case <-failed:
+		case <-time.After(time.Second):
+			t.Fatal("negotiation did not fail")
+		}
+		// only the offer and its retries, none from recovering it
+		time.Sleep(100 * time.Millisecond)
+		require.Equal(t, int32(4), atomic.LoadInt32(&offers))
+	})
+
+	t.Run("doesn't fire during negotiation", func(t *testing.T) {
+		transportA, transportB := newTransports(t, negotiation)
+		defer transportA.Close()
+		defer transportB.Close()
+
+		var offers int32
+		handleOffer := handleOfferFunc(t, transportA, transportB)
+		transportA.OnOffer(func(sd webrtc.SessionDescription) {
+			atomic.AddInt32(&offers, 1)
+			// answered within the timeout
+			time.Sleep(20 * time.Millisecond)
+			handleOffer(sd)
+		})
+		for i := 0; i < 3; i++ {
+			require.NoError(t, transportA.CreateAndSendOffer(nil))
+			require.Eventually(t, func() bool {
+				return transportA.pc.SignalingState() == webrtc.SignalingStateStable
+			}, time.Second, 5*time.Millisecond)
+		}
+		time.Sleep(100 * time.Millisecond)
+		require.Equal(t, int32(3), atomic.LoadInt32(&offers))
+	})
+}
+
+func TestTransportStats(t *testing.T) {
+	params := TransportParams{
+		Target: livekit.SignalTarget_PUBLISHER,
+		Config: &WebRTCConfig{},
+	}
+	transportA, err := NewPCTransport(params)
+	require.NoError(t, err)
+	defer transportA.Close()
+	_, err = transportA.pc.CreateDataChannel("test", nil)
+	require.NoError(t, err)
+	transportB, err := NewPCTransport(params)
+	require.NoError(t, err)
+	defer transportB.Close()
+
+	stats := transportA.Stats()
+	require.Equal(t, livekit.SignalTarget_PUBLISHER, stats.Target)
+	require.Equal(t, webrtc.ICEConnectionStateNew.String(), stats.ICEState)
+	require.Empty(t, stats.LocalCandidate)
+
+	handleICEExchange(t, transportA, transportB)
+	transportA.OnOffer(handleOfferFunc(t, transportA, transportB))
+	require.NoError(t, transportA.CreateAndSendOffer(nil))
+
+	// updated on connection state changes, without being asked to
+	testutils.WithTimeout(t, "connected stats", func() bool {
+		return transportA.Stats().ConnectionState == webrtc.PeerConnectionStateConnected.String()
+	})
+	stats = transportA.Stats()
+	require.Equal(t, webrtc.ICEConnectionStateConnected.String(), stats.ICEState)
+	require.Equal(t, webrtc.DTLSTransportStateConnected.String(), stats.DTLSState)
+	require.NotEmpty(t, stats.LocalCandidate)
+	require.NotEmpty(t, stats.RemoteCandidate)
+	require.NotZero(t, stats.BytesSent)
+	require.NotZero(t, stats.BytesReceived)
+}
+
 func handleOfferFunc(t *testing.T, current, other *PCTransport) func(sd webrtc.SessionDescription) {
 	return func(sd webrtc.SessionDescription) {
 		t.Logf("handling offer")
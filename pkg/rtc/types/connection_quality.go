@@ -0,0 +1,21 @@
+package types
+
+// ConnectionQuality rates the network of a participant, from best to worst
+type ConnectionQuality int
+
+const (
+	ConnectionQualityExcellent ConnectionQuality = iota
+	ConnectionQualityGood
+	ConnectionQualityPoor
+)
+
+func (q ConnectionQuality) String() string {
+	switch q {
+	case ConnectionQualityExcellent:
+		return "EXCELLENT"
+	case ConnectionQualityGood:
+		return "GOOD"
+	default:
+		return "POOR"
+	}
+}
@@ -0,0 +1,27 @@
+package types
+
+// DisconnectReason tells why a participant left its room
+type DisconnectReason string
+
+const (
+	DisconnectReasonUnknown = DisconnectReason("unknown")
+	// the client sent a leave request
+	DisconnectReasonClientInitiated = DisconnectReason("client_initiated")
+	// the signal connection closed, and wasn't resumed
+	DisconnectReasonSignalClosed          = DisconnectReason("signal_closed")
+	DisconnectReasonReconnectGraceExpired = DisconnectReason("reconnect_grace_expired")
+	// ICE failed, or gathering candidates timed out
+	DisconnectReasonICEFailed          = DisconnectReason("ice_failed")
+	DisconnectReasonNegotiationTimeout = DisconnectReason("negotiation_timeout")
+	DisconnectReasonPublishFailures    = DisconnectReason("publish_failures")
+	DisconnectReasonRTCPFailures       = DisconnectReason("rtcp_failures")
+	// it didn't become active in time after joining
+	DisconnectReasonJoinTimeout = DisconnectReason("join_timeout")
+	DisconnectReasonJoinFailed  = DisconnectReason("join_failed")
+	// another participant joined with the same identity
+	DisconnectReasonDuplicateIdentity = DisconnectReason("duplicate_identity")
+	// removed with the room service, or the bulk api
+	DisconnectReasonRemoved    = DisconnectReason("removed")
+	DisconnectReasonRoomClosed = DisconnectReason("room_closed")
+	DisconnectReasonShutdown   = DisconnectReason("shutdown")
+)
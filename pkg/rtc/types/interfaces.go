@@ -5,6 +5,7 @@ import (
 
 	"github.com/pion/ion-sfu/pkg/sfu"
 	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
 	"github.com/pion/webrtc/v3"
 
 	"github.com/livekit/livekit-server/pkg/routing"
@@ -27,36 +28,117 @@ type Participant interface {
 	State() livekit.ParticipantInfo_State
 	ProtocolVersion() ProtocolVersion
 	IsReady() bool
+	// IsInterrupted is true while connectivity is lost, until it recovers or the participant is closed
+	IsInterrupted() bool
 	ConnectedAt() time.Time
 	ToProto() *livekit.ParticipantInfo
 	RTCPChan() chan []rtcp.Packet
-	SetMetadata(metadata string)
+	Metadata() string
+	SetMetadata(metadata string) error
+	// Name is the display name of the participant, empty until it's set
+	Name() string
+	SetName(name string)
+	// SetDisconnectReason records why the participant is leaving, before it's closed. The first reason set is kept
+	SetDisconnectReason(reason DisconnectReason)
+	// DisconnectReason is unknown until one is set
+	DisconnectReason() DisconnectReason
 	SetPermission(permission *livekit.ParticipantPermission)
 	GetResponseSink() routing.MessageSink
 	SetResponseSink(sink routing.MessageSink)
+	SignalGeneration() uint32
+	ReconnectGrace() time.Duration
+	SetReconnectGrace(grace time.Duration)
+	// HandleSignalLost returns false when the participant should be closed right away
+	HandleSignalLost(generation uint32) bool
+	MaxUploadBitrate() uint64
+	SetMaxUploadBitrate(bitrate uint64)
+	MaxDownloadBitrate() uint64
+	SetMaxDownloadBitrate(bitrate uint64)
+	// AdmitSubscription returns an error when a video subscription of bitrate (bps) doesn't fit in the participant's
+	// download budget, and its policy is to reject it
+	AdmitSubscription(trackID string, bitrate uint64) error
+	// NackWindow returns the number of packets sent to the participant that could be retransmitted
+	NackWindow() int
+	SetNackWindow(packets int)
+	SetDeviceClass(class string)
+	// SupportsCodec returns true when the participant could decode the codec of the mime type
+	SupportsCodec(mimeType string) bool
+	// SubscribesToMixedAudio is true when the participant receives a mix of the loudest speakers, rather than a
+	// track per speaker
+	SubscribesToMixedAudio() bool
+	// DefaultVideoQuality returns the quality video subscriptions start at, false to pick it by the number of
+	// subscribers
+	DefaultVideoQuality() (livekit.VideoQuality, bool)
+	// PinnedVideoQuality returns the quality video subscriptions are kept at regardless of requests and
+	// bandwidth, as for recorders. false when they're adapted
+	PinnedVideoQuality() (livekit.VideoQuality, bool)
+	// IsSpeaking returns true while the participant is speaking on any of its audio tracks
+	IsSpeaking() bool
 	SubscriberMediaEngine() *webrtc.MediaEngine
+	// MapSubscriberSSRC returns the SSRC a stream sent to the participant is known by on its end, derived from key
+	// when the room sends streams on stable SSRCs
+	MapSubscriberSSRC(ssrc uint32, key string) uint32
+	// MapSubscriberCaptureTime sets the ID of abs-capture-time in packets of a stream sent to the participant, as
+	// negotiated with its publisher
+	MapSubscriberCaptureTime(ssrc uint32, sourceID uint8)
 	Negotiate()
-	ICERestart() error
+	// ICERestart restarts ICE of the subscriber connection, and returns the offer sent to the client
+	ICERestart() (webrtc.SessionDescription, error)
+	// IsReconnecting is true while the client restarts ICE after its network changed, until ICE connects again.
+	// Tracks and subscriptions are kept, and the participant keeps its state
+	IsReconnecting() bool
 
 	AddTrack(req *livekit.AddTrackRequest)
 	GetPublishedTracks() []PublishedTrack
 	GetSubscribedTracks() []SubscribedTrack
-	HandleOffer(sdp webrtc.SessionDescription) (answer webrtc.SessionDescription, err error)
-	HandleAnswer(sdp webrtc.SessionDescription) error
-	AddICECandidate(candidate webrtc.ICECandidateInit, target livekit.SignalTarget) error
+	// SubscribedTrackIDs returns sids of tracks the participant is receiving, paused ones aside. Once it's closed,
+	// those it was receiving when it was
+	SubscribedTrackIDs() []string
+	// UpdateSubscribedTrackSettings enables or disables a subscribed track and changes its quality
+	UpdateSubscribedTrackSettings(trackID string, enabled bool, quality livekit.VideoQuality)
+	SetSubscribedQuality(trackID string, quality livekit.VideoQuality) error
+	// GetTrackStats returns inbound buffer statistics of published tracks, keyed by track ID
+	GetTrackStats() map[string][]BufferStats
+	// GetTransportStats returns stats of the publisher and subscriber peer connections, as of their last update
+	GetTransportStats() []TransportStats
+	// negotiation messages are only handled when generation matches the current signal connection
+	HandleOffer(sdp webrtc.SessionDescription, generation uint32) (answer webrtc.SessionDescription, err error)
+	HandleAnswer(sdp webrtc.SessionDescription, generation uint32) error
+	AddICECandidate(candidate webrtc.ICECandidateInit, target livekit.SignalTarget, generation uint32) error
 	AddSubscriber(op Participant) (int, error)
+	// SubscribeToTrack subscribes op to a single track the participant published, UnsubscribeFromTrack removes it
+	SubscribeToTrack(op Participant, trackID string) error
+	UnsubscribeFromTrack(op Participant, trackID string) error
 	RemoveSubscriber(peerId string)
-	SendJoinResponse(info *livekit.Room, otherParticipants []Participant, iceServers []*livekit.ICEServer) error
+	// recording is whether the room is being recorded, the participant is told once its data channel is open
+	SendJoinResponse(info *livekit.Room, otherParticipants []Participant, iceServers []*livekit.ICEServer, recording bool) error
+	// SetRecording tells the participant when the room starts or stops being recorded
+	SetRecording(recording bool)
 	SendParticipantUpdate(participants []*livekit.ParticipantInfo) error
 	SendActiveSpeakers(speakers []*livekit.SpeakerInfo) error
 	SendDataPacket(packet *livekit.DataPacket) error
+	// SendData sends a message from the server to the participant over a data channel
+	SendData(payload []byte, reliable bool) error
 	SetTrackMuted(trackId string, muted bool)
+	// DebugLogging logs debug messages of the participant regardless of the log level for d, 0 to stop
+	DebugLogging(d time.Duration)
+	// MutePublishedTrack mutes a published track on behalf of the server, the participant can't unmute it
+	MutePublishedTrack(trackID string, muted bool) error
+	// AttachSink taps the RTP packets of one of the participant's published tracks, DetachSink stops it
+	AttachSink(trackID string, sink TrackSink) error
+	DetachSink(trackID string, sink TrackSink) error
 	GetAudioLevel() (level uint8, active bool)
 
 	// permissions
 
 	CanPublish() bool
 	CanSubscribe() bool
+	// CanPublishData is true when the participant could send data packets to others
+	CanPublishData() bool
+
+	// ForwardedLayers returns the spatial layer forwarded to the participant for each video track it's subscribed
+	// to, by track sid
+	ForwardedLayers() map[string]int32
 
 	Start()
 	Close() error
@@ -64,12 +146,29 @@ type Participant interface {
 	// callbacks
 
 	OnStateChange(func(p Participant, oldState livekit.ParticipantInfo_State))
+	// OnInterruptionChange - connectivity has been lost or recovered
+	OnInterruptionChange(callback func(Participant))
+	// OnReconnectingChange - the participant started or finished restarting ICE
+	OnReconnectingChange(callback func(Participant))
 	// OnTrackPublished - remote added a remoteTrack
 	OnTrackPublished(func(Participant, PublishedTrack))
 	// OnTrackUpdated - one of its publishedTracks changed in status
 	OnTrackUpdated(callback func(Participant, PublishedTrack))
+	// OnFirstMediaReceived - first RTP packet of a published track has been received
+	OnFirstMediaReceived(callback func(Participant, PublishedTrack))
 	OnMetadataUpdate(callback func(Participant))
+	// OnNameUpdate - participant was renamed
+	OnNameUpdate(callback func(Participant))
 	OnDataPacket(callback func(Participant, *livekit.DataPacket))
+	// OnPublisherCongested - participant became unable to send its target bitrate, or recovered
+	OnPublisherCongested(callback func(p Participant, congested bool))
+	// OnSpeaking - participant started speaking on one of its audio tracks, or stopped on all of them
+	OnSpeaking(callback func(p Participant, speaking bool))
+	// OnConnectionQualityChange - rating of the participant's network changed
+	OnConnectionQualityChange(callback func(p Participant, quality ConnectionQuality))
+	// ConnectionQuality rates the network of the participant from the loss, jitter and round trip time of its
+	// streams
+	ConnectionQuality() ConnectionQuality
 	OnClose(func(Participant))
 
 	// package methods
@@ -84,19 +183,35 @@ type Participant interface {
 
 // PublishedTrack is the main interface representing a track published to the room
 // it's responsible for managing subscribers and forwarding data from the input track to all subscribers
+//
 //counterfeiter:generate . PublishedTrack
 type PublishedTrack interface {
 	Start()
 	ID() string
 	Kind() livekit.TrackType
 	Name() string
+	// Source returns what the track captures, as inferred from its kind and name
+	Source() TrackSource
 	IsMuted() bool
 	SetMuted(muted bool)
+	// IsSilent is true while the publisher is sending silent audio or black camera video
+	IsSilent() bool
 	SetSimulcastLayers(layers []livekit.VideoQuality)
 	AddSubscriber(participant Participant) error
 	RemoveSubscriber(participantId string)
 	IsSubscriber(subId string) bool
 	RemoveAllSubscribers()
+	AddUDPForwarder(addr string) error
+	RemoveUDPForwarder(addr string) error
+	// AddSink starts writing copies of the RTP packets of the track to sink, as they're sent to subscribers
+	AddSink(sink TrackSink) error
+	RemoveSink(sink TrackSink) error
+	GetBufferStats() []BufferStats
+	// EstimateSubscription returns the quality and bitrate (bps) a new subscriber would receive by default
+	EstimateSubscription() (livekit.VideoQuality, uint64)
+	// Layers returns the layers of video as the publisher last described them, empty until it does
+	Layers() []VideoLayerInfo
+	SetLayers(layers []VideoLayerInfo)
 	ToProto() *livekit.TrackInfo
 
 	// callbacks
@@ -107,12 +222,33 @@ type PublishedTrack interface {
 type SubscribedTrack interface {
 	ID() string
 	DownTrack() *sfu.DownTrack
+	// IsBound returns true once the subscriber has negotiated the track
+	IsBound() bool
+	// Codec returns the codec negotiated with the subscriber, empty until bound
+	Codec() webrtc.RTPCodecParameters
+	OnCodecChange(f func(codec webrtc.RTPCodecParameters))
 	IsMuted() bool
+	IsPublisherMuted() bool
 	SetPublisherMuted(muted bool)
+	IsPaused() bool
+	SetPaused(paused bool)
+	// ForwardedLayer is the spatial layer of video sent to the subscriber, -1 while none is
+	ForwardedLayer() int32
 	UpdateSubscriberSettings(enabled bool, quality livekit.VideoQuality)
 }
 
+// TrackSink receives the RTP packets of a published track without being a WebRTC peer, to record or relay it.
+// Packets are written in the order they're sent to subscribers, by a single goroutine
+//
+//counterfeiter:generate . TrackSink
+type TrackSink interface {
+	WriteRTP(pkt *rtp.Packet) error
+	// Close is called once the sink is detached, or the track is unpublished
+	Close() error
+}
+
 // interface for properties of webrtc.TrackRemote
+//
 //counterfeiter:generate . TrackRemote
 type TrackRemote interface {
 	SSRC() webrtc.SSRC
@@ -120,3 +256,41 @@ type TrackRemote interface {
 	Kind() webrtc.RTPCodecType
 	Codec() webrtc.RTPCodecParameters
 }
+
+// BufferStats are statistics of a published stream, measured by its receive buffer
+type BufferStats struct {
+	SSRC        uint32
+	PacketCount uint32
+	// fraction of packets lost during the last report interval
+	LostRate float32
+	// interarrival jitter, in RTP timestamp units
+	Jitter  float64
+	Bitrate uint64
+}
+
+// VideoLayerInfo describes a layer of published video as the publisher encodes it, which could change as it scales
+// its encoding down, e.g. when its CPU is throttled
+type VideoLayerInfo struct {
+	Quality livekit.VideoQuality `json:"quality"`
+	Width   uint32               `json:"width"`
+	Height  uint32               `json:"height"`
+	// frames per second, 0 when unknown
+	Framerate float64 `json:"framerate,omitempty"`
+	// bps, 0 when unknown
+	Bitrate uint64 `json:"bitrate,omitempty"`
+}
+
+// TransportStats are transport level stats of a peer connection, beyond the RTP stats of its tracks
+type TransportStats struct {
+	Target          livekit.SignalTarget
+	ConnectionState string
+	ICEState        string
+	DTLSState       string
+	// sent and received over the ICE transport, including RTCP and data channels
+	BytesSent     uint64
+	BytesReceived uint64
+	// selected candidate pair, empty until ICE connects
+	LocalCandidate  string
+	RemoteCandidate string
+	UpdatedAt       time.Time
+}
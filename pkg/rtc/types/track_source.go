@@ -0,0 +1,51 @@
+package types
+
+import (
+	"strings"
+
+	livekit "github.com/livekit/livekit-server/proto"
+)
+
+// TrackSource is what a published track captures. The protocol doesn't carry it, it's inferred from the kind of the
+// track and the name the client gave it
+type TrackSource int
+
+const (
+	TrackSourceUnknown TrackSource = iota
+	TrackSourceCamera
+	TrackSourceMicrophone
+	TrackSourceScreenShare
+	TrackSourceScreenShareAudio
+)
+
+// TrackSourceFor infers the source of a track, clients name screen shares and their audio as such
+func TrackSourceFor(kind livekit.TrackType, name string) TrackSource {
+	screen := strings.Contains(strings.ToLower(name), "screen")
+	switch {
+	case kind == livekit.TrackType_VIDEO && screen:
+		return TrackSourceScreenShare
+	case kind == livekit.TrackType_VIDEO:
+		return TrackSourceCamera
+	case kind == livekit.TrackType_AUDIO && screen:
+		return TrackSourceScreenShareAudio
+	case kind == livekit.TrackType_AUDIO:
+		return TrackSourceMicrophone
+	default:
+		return TrackSourceUnknown
+	}
+}
+
+func (s TrackSource) String() string {
+	switch s {
+	case TrackSourceCamera:
+		return "CAMERA"
+	case TrackSourceMicrophone:
+		return "MICROPHONE"
+	case TrackSourceScreenShare:
+		return "SCREEN_SHARE"
+	case TrackSourceScreenShareAudio:
+		return "SCREEN_SHARE_AUDIO"
+	default:
+		return "UNKNOWN"
+	}
+}
@@ -13,11 +13,12 @@ import (
 )
 
 type FakeParticipant struct {
-	AddICECandidateStub        func(webrtc.ICECandidateInit, livekit.SignalTarget) error
+	AddICECandidateStub        func(webrtc.ICECandidateInit, livekit.SignalTarget, uint32) error
 	addICECandidateMutex       sync.RWMutex
 	addICECandidateArgsForCall []struct {
 		arg1 webrtc.ICECandidateInit
 		arg2 livekit.SignalTarget
+		arg3 uint32
 	}
 	addICECandidateReturns struct {
 		result1 error
@@ -49,6 +50,30 @@ type FakeParticipant struct {
 	addTrackArgsForCall []struct {
 		arg1 *livekit.AddTrackRequest
 	}
+	AdmitSubscriptionStub        func(string, uint64) error
+	admitSubscriptionMutex       sync.RWMutex
+	admitSubscriptionArgsForCall []struct {
+		arg1 string
+		arg2 uint64
+	}
+	admitSubscriptionReturns struct {
+		result1 error
+	}
+	admitSubscriptionReturnsOnCall map[int]struct {
+		result1 error
+	}
+	AttachSinkStub        func(string, types.TrackSink) error
+	attachSinkMutex       sync.RWMutex
+	attachSinkArgsForCall []struct {
+		arg1 string
+		arg2 types.TrackSink
+	}
+	attachSinkReturns struct {
+		result1 error
+	}
+	attachSinkReturnsOnCall map[int]struct {
+		result1 error
+	}
 	CanPublishStub        func() bool
 	canPublishMutex       sync.RWMutex
 	canPublishArgsForCall []struct {
@@ -59,6 +84,16 @@ type FakeParticipant struct {
 	canPublishReturnsOnCall map[int]struct {
 		result1 bool
 	}
+	CanPublishDataStub        func() bool
+	canPublishDataMutex       sync.RWMutex
+	canPublishDataArgsForCall []struct {
+	}
+	canPublishDataReturns struct {
+		result1 bool
+	}
+	canPublishDataReturnsOnCall map[int]struct {
+		result1 bool
+	}
 	CanSubscribeStub        func() bool
 	canSubscribeMutex       sync.RWMutex
 	canSubscribeArgsForCall []struct {
@@ -89,6 +124,16 @@ type FakeParticipant struct {
 	connectedAtReturnsOnCall map[int]struct {
 		result1 time.Time
 	}
+	ConnectionQualityStub        func() types.ConnectionQuality
+	connectionQualityMutex       sync.RWMutex
+	connectionQualityArgsForCall []struct {
+	}
+	connectionQualityReturns struct {
+		result1 types.ConnectionQuality
+	}
+	connectionQualityReturnsOnCall map[int]struct {
+		result1 types.ConnectionQuality
+	}
 	DebugInfoStub        func() map[string]interface{}
 	debugInfoMutex       sync.RWMutex
 	debugInfoArgsForCall []struct {
@@ -99,6 +144,55 @@ type FakeParticipant struct {
 	debugInfoReturnsOnCall map[int]struct {
 		result1 map[string]interface{}
 	}
+	DebugLoggingStub        func(time.Duration)
+	debugLoggingMutex       sync.RWMutex
+	debugLoggingArgsForCall []struct {
+		arg1 time.Duration
+	}
+	DefaultVideoQualityStub        func() (livekit.VideoQuality, bool)
+	defaultVideoQualityMutex       sync.RWMutex
+	defaultVideoQualityArgsForCall []struct {
+	}
+	defaultVideoQualityReturns struct {
+		result1 livekit.VideoQuality
+		result2 bool
+	}
+	defaultVideoQualityReturnsOnCall map[int]struct {
+		result1 livekit.VideoQuality
+		result2 bool
+	}
+	DetachSinkStub        func(string, types.TrackSink) error
+	detachSinkMutex       sync.RWMutex
+	detachSinkArgsForCall []struct {
+		arg1 string
+		arg2 types.TrackSink
+	}
+	detachSinkReturns struct {
+		result1 error
+	}
+	detachSinkReturnsOnCall map[int]struct {
+		result1 error
+	}
+	DisconnectReasonStub        func() types.DisconnectReason
+	disconnectReasonMutex       sync.RWMutex
+	disconnectReasonArgsForCall []struct {
+	}
+	disconnectReasonReturns struct {
+		result1 types.DisconnectReason
+	}
+	disconnectReasonReturnsOnCall map[int]struct {
+		result1 types.DisconnectReason
+	}
+	ForwardedLayersStub        func() map[string]int32
+	forwardedLayersMutex       sync.RWMutex
+	forwardedLayersArgsForCall []struct {
+	}
+	forwardedLayersReturns struct {
+		result1 map[string]int32
+	}
+	forwardedLayersReturnsOnCall map[int]struct {
+		result1 map[string]int32
+	}
 	GetAudioLevelStub        func() (uint8, bool)
 	getAudioLevelMutex       sync.RWMutex
 	getAudioLevelArgsForCall []struct {
@@ -141,10 +235,31 @@ type FakeParticipant struct {
 	getSubscribedTracksReturnsOnCall map[int]struct {
 		result1 []types.SubscribedTrack
 	}
-	HandleAnswerStub        func(webrtc.SessionDescription) error
+	GetTrackStatsStub        func() map[string][]types.BufferStats
+	getTrackStatsMutex       sync.RWMutex
+	getTrackStatsArgsForCall []struct {
+	}
+	getTrackStatsReturns struct {
+		result1 map[string][]types.BufferStats
+	}
+	getTrackStatsReturnsOnCall map[int]struct {
+		result1 map[string][]types.BufferStats
+	}
+	GetTransportStatsStub        func() []types.TransportStats
+	getTransportStatsMutex       sync.RWMutex
+	getTransportStatsArgsForCall []struct {
+	}
+	getTransportStatsReturns struct {
+		result1 []types.TransportStats
+	}
+	getTransportStatsReturnsOnCall map[int]struct {
+		result1 []types.TransportStats
+	}
+	HandleAnswerStub        func(webrtc.SessionDescription, uint32) error
 	handleAnswerMutex       sync.RWMutex
 	handleAnswerArgsForCall []struct {
 		arg1 webrtc.SessionDescription
+		arg2 uint32
 	}
 	handleAnswerReturns struct {
 		result1 error
@@ -152,10 +267,11 @@ type FakeParticipant struct {
 	handleAnswerReturnsOnCall map[int]struct {
 		result1 error
 	}
-	HandleOfferStub        func(webrtc.SessionDescription) (webrtc.SessionDescription, error)
+	HandleOfferStub        func(webrtc.SessionDescription, uint32) (webrtc.SessionDescription, error)
 	handleOfferMutex       sync.RWMutex
 	handleOfferArgsForCall []struct {
 		arg1 webrtc.SessionDescription
+		arg2 uint32
 	}
 	handleOfferReturns struct {
 		result1 webrtc.SessionDescription
@@ -165,15 +281,28 @@ type FakeParticipant struct {
 		result1 webrtc.SessionDescription
 		result2 error
 	}
-	ICERestartStub        func() error
+	HandleSignalLostStub        func(uint32) bool
+	handleSignalLostMutex       sync.RWMutex
+	handleSignalLostArgsForCall []struct {
+		arg1 uint32
+	}
+	handleSignalLostReturns struct {
+		result1 bool
+	}
+	handleSignalLostReturnsOnCall map[int]struct {
+		result1 bool
+	}
+	ICERestartStub        func() (webrtc.SessionDescription, error)
 	iCERestartMutex       sync.RWMutex
 	iCERestartArgsForCall []struct {
 	}
 	iCERestartReturns struct {
-		result1 error
+		result1 webrtc.SessionDescription
+		result2 error
 	}
 	iCERestartReturnsOnCall map[int]struct {
-		result1 error
+		result1 webrtc.SessionDescription
+		result2 error
 	}
 	IDStub        func() string
 	iDMutex       sync.RWMutex
@@ -195,6 +324,16 @@ type FakeParticipant struct {
 	identityReturnsOnCall map[int]struct {
 		result1 string
 	}
+	IsInterruptedStub        func() bool
+	isInterruptedMutex       sync.RWMutex
+	isInterruptedArgsForCall []struct {
+	}
+	isInterruptedReturns struct {
+		result1 bool
+	}
+	isInterruptedReturnsOnCall map[int]struct {
+		result1 bool
+	}
 	IsReadyStub        func() bool
 	isReadyMutex       sync.RWMutex
 	isReadyArgsForCall []struct {
@@ -205,6 +344,106 @@ type FakeParticipant struct {
 	isReadyReturnsOnCall map[int]struct {
 		result1 bool
 	}
+	IsReconnectingStub        func() bool
+	isReconnectingMutex       sync.RWMutex
+	isReconnectingArgsForCall []struct {
+	}
+	isReconnectingReturns struct {
+		result1 bool
+	}
+	isReconnectingReturnsOnCall map[int]struct {
+		result1 bool
+	}
+	IsSpeakingStub        func() bool
+	isSpeakingMutex       sync.RWMutex
+	isSpeakingArgsForCall []struct {
+	}
+	isSpeakingReturns struct {
+		result1 bool
+	}
+	isSpeakingReturnsOnCall map[int]struct {
+		result1 bool
+	}
+	MapSubscriberCaptureTimeStub        func(uint32, uint8)
+	mapSubscriberCaptureTimeMutex       sync.RWMutex
+	mapSubscriberCaptureTimeArgsForCall []struct {
+		arg1 uint32
+		arg2 uint8
+	}
+	MapSubscriberSSRCStub        func(uint32, string) uint32
+	mapSubscriberSSRCMutex       sync.RWMutex
+	mapSubscriberSSRCArgsForCall []struct {
+		arg1 uint32
+		arg2 string
+	}
+	mapSubscriberSSRCReturns struct {
+		result1 uint32
+	}
+	mapSubscriberSSRCReturnsOnCall map[int]struct {
+		result1 uint32
+	}
+	MaxDownloadBitrateStub        func() uint64
+	maxDownloadBitrateMutex       sync.RWMutex
+	maxDownloadBitrateArgsForCall []struct {
+	}
+	maxDownloadBitrateReturns struct {
+		result1 uint64
+	}
+	maxDownloadBitrateReturnsOnCall map[int]struct {
+		result1 uint64
+	}
+	MaxUploadBitrateStub        func() uint64
+	maxUploadBitrateMutex       sync.RWMutex
+	maxUploadBitrateArgsForCall []struct {
+	}
+	maxUploadBitrateReturns struct {
+		result1 uint64
+	}
+	maxUploadBitrateReturnsOnCall map[int]struct {
+		result1 uint64
+	}
+	MetadataStub        func() string
+	metadataMutex       sync.RWMutex
+	metadataArgsForCall []struct {
+	}
+	metadataReturns struct {
+		result1 string
+	}
+	metadataReturnsOnCall map[int]struct {
+		result1 string
+	}
+	MutePublishedTrackStub        func(string, bool) error
+	mutePublishedTrackMutex       sync.RWMutex
+	mutePublishedTrackArgsForCall []struct {
+		arg1 string
+		arg2 bool
+	}
+	mutePublishedTrackReturns struct {
+		result1 error
+	}
+	mutePublishedTrackReturnsOnCall map[int]struct {
+		result1 error
+	}
+	NackWindowStub        func() int
+	nackWindowMutex       sync.RWMutex
+	nackWindowArgsForCall []struct {
+	}
+	nackWindowReturns struct {
+		result1 int
+	}
+	nackWindowReturnsOnCall map[int]struct {
+		result1 int
+	}
+	NameStub        func() string
+	nameMutex       sync.RWMutex
+	nameArgsForCall []struct {
+	}
+	nameReturns struct {
+		result1 string
+	}
+	nameReturnsOnCall map[int]struct {
+		result1 string
+	}
 	NegotiateStub        func()
 	negotiateMutex       sync.RWMutex
 	negotiateArgsForCall []struct {
@@ -214,16 +453,51 @@ type FakeParticipant struct {
 	onCloseArgsForCall []struct {
 		arg1 func(types.Participant)
 	}
+	OnConnectionQualityChangeStub        func(func(p types.Participant, quality types.ConnectionQuality))
+	onConnectionQualityChangeMutex       sync.RWMutex
+	onConnectionQualityChangeArgsForCall []struct {
+		arg1 func(p types.Participant, quality types.ConnectionQuality)
+	}
 	OnDataPacketStub        func(func(types.Participant, *livekit.DataPacket))
 	onDataPacketMutex       sync.RWMutex
 	onDataPacketArgsForCall []struct {
 		arg1 func(types.Participant, *livekit.DataPacket)
 	}
+	OnFirstMediaReceivedStub        func(func(types.Participant, types.PublishedTrack))
+	onFirstMediaReceivedMutex       sync.RWMutex
+	onFirstMediaReceivedArgsForCall []struct {
+		arg1 func(types.Participant, types.PublishedTrack)
+	}
+	OnInterruptionChangeStub        func(func(types.Participant))
+	onInterruptionChangeMutex       sync.RWMutex
+	onInterruptionChangeArgsForCall []struct {
+		arg1 func(types.Participant)
+	}
 	OnMetadataUpdateStub        func(func(types.Participant))
 	onMetadataUpdateMutex       sync.RWMutex
 	onMetadataUpdateArgsForCall []struct {
 		arg1 func(types.Participant)
 	}
+	OnNameUpdateStub        func(func(types.Participant))
+	onNameUpdateMutex       sync.RWMutex
+	onNameUpdateArgsForCall []struct {
+		arg1 func(types.Participant)
+	}
+	OnPublisherCongestedStub        func(func(p types.Participant, congested bool))
+	onPublisherCongestedMutex       sync.RWMutex
+	onPublisherCongestedArgsForCall []struct {
+		arg1 func(p types.Participant, congested bool)
+	}
+	OnReconnectingChangeStub        func(func(types.Participant))
+	onReconnectingChangeMutex       sync.RWMutex
+	onReconnectingChangeArgsForCall []struct {
+		arg1 func(types.Participant)
+	}
+	OnSpeakingStub        func(func(p types.Participant, speaking bool))
+	onSpeakingMutex       sync.RWMutex
+	onSpeakingArgsForCall []struct {
+		arg1 func(p types.Participant, speaking bool)
+	}
 	OnStateChangeStub        func(func(p types.Participant, oldState livekit.ParticipantInfo_State))
 	onStateChangeMutex       sync.RWMutex
 	onStateChangeArgsForCall []struct {
@@ -239,6 +513,18 @@ type FakeParticipant struct {
 	onTrackUpdatedArgsForCall []struct {
 		arg1 func(types.Participant, types.PublishedTrack)
 	}
+	PinnedVideoQualityStub        func() (livekit.VideoQuality, bool)
+	pinnedVideoQualityMutex       sync.RWMutex
+	pinnedVideoQualityArgsForCall []struct {
+	}
+	pinnedVideoQualityReturns struct {
+		result1 livekit.VideoQuality
+		result2 bool
+	}
+	pinnedVideoQualityReturnsOnCall map[int]struct {
+		result1 livekit.VideoQuality
+		result2 bool
+	}
 	ProtocolVersionStub        func() types.ProtocolVersion
 	protocolVersionMutex       sync.RWMutex
 	protocolVersionArgsForCall []struct {
@@ -259,6 +545,16 @@ type FakeParticipant struct {
 	rTCPChanReturnsOnCall map[int]struct {
 		result1 chan []rtcp.Packet
 	}
+	ReconnectGraceStub        func() time.Duration
+	reconnectGraceMutex       sync.RWMutex
+	reconnectGraceArgsForCall []struct {
+	}
+	reconnectGraceReturns struct {
+		result1 time.Duration
+	}
+	reconnectGraceReturnsOnCall map[int]struct {
+		result1 time.Duration
+	}
 	RemoveSubscribedTrackStub        func(string, types.SubscribedTrack)
 	removeSubscribedTrackMutex       sync.RWMutex
 	removeSubscribedTrackArgsForCall []struct {
@@ -281,6 +577,18 @@ type FakeParticipant struct {
 	sendActiveSpeakersReturnsOnCall map[int]struct {
 		result1 error
 	}
+	SendDataStub        func([]byte, bool) error
+	sendDataMutex       sync.RWMutex
+	sendDataArgsForCall []struct {
+		arg1 []byte
+		arg2 bool
+	}
+	sendDataReturns struct {
+		result1 error
+	}
+	sendDataReturnsOnCall map[int]struct {
+		result1 error
+	}
 	SendDataPacketStub        func(*livekit.DataPacket) error
 	sendDataPacketMutex       sync.RWMutex
 	sendDataPacketArgsForCall []struct {
@@ -292,12 +600,13 @@ type FakeParticipant struct {
 	sendDataPacketReturnsOnCall map[int]struct {
 		result1 error
 	}
-	SendJoinResponseStub        func(*livekit.Room, []types.Participant, []*livekit.ICEServer) error
+	SendJoinResponseStub        func(*livekit.Room, []types.Participant, []*livekit.ICEServer, bool) error
 	sendJoinResponseMutex       sync.RWMutex
 	sendJoinResponseArgsForCall []struct {
 		arg1 *livekit.Room
 		arg2 []types.Participant
 		arg3 []*livekit.ICEServer
+		arg4 bool
 	}
 	sendJoinResponseReturns struct {
 		result1 error
@@ -316,27 +625,95 @@ type FakeParticipant struct {
 	sendParticipantUpdateReturnsOnCall map[int]struct {
 		result1 error
 	}
-	SetMetadataStub        func(string)
+	SetDeviceClassStub        func(string)
+	setDeviceClassMutex       sync.RWMutex
+	setDeviceClassArgsForCall []struct {
+		arg1 string
+	}
+	SetDisconnectReasonStub        func(types.DisconnectReason)
+	setDisconnectReasonMutex       sync.RWMutex
+	setDisconnectReasonArgsForCall []struct {
+		arg1 types.DisconnectReason
+	}
+	SetMaxDownloadBitrateStub        func(uint64)
+	setMaxDownloadBitrateMutex       sync.RWMutex
+	setMaxDownloadBitrateArgsForCall []struct {
+		arg1 uint64
+	}
+	SetMaxUploadBitrateStub        func(uint64)
+	setMaxUploadBitrateMutex       sync.RWMutex
+	setMaxUploadBitrateArgsForCall []struct {
+		arg1 uint64
+	}
+	SetMetadataStub        func(string) error
 	setMetadataMutex       sync.RWMutex
 	setMetadataArgsForCall []struct {
 		arg1 string
 	}
+	setMetadataReturns struct {
+		result1 error
+	}
+	setMetadataReturnsOnCall map[int]struct {
+		result1 error
+	}
+	SetNackWindowStub        func(int)
+	setNackWindowMutex       sync.RWMutex
+	setNackWindowArgsForCall []struct {
+		arg1 int
+	}
+	SetNameStub        func(string)
+	setNameMutex       sync.RWMutex
+	setNameArgsForCall []struct {
+		arg1 string
+	}
 	SetPermissionStub        func(*livekit.ParticipantPermission)
 	setPermissionMutex       sync.RWMutex
 	setPermissionArgsForCall []struct {
 		arg1 *livekit.ParticipantPermission
 	}
+	SetReconnectGraceStub        func(time.Duration)
+	setReconnectGraceMutex       sync.RWMutex
+	setReconnectGraceArgsForCall []struct {
+		arg1 time.Duration
+	}
+	SetRecordingStub        func(bool)
+	setRecordingMutex       sync.RWMutex
+	setRecordingArgsForCall []struct {
+		arg1 bool
+	}
 	SetResponseSinkStub        func(routing.MessageSink)
 	setResponseSinkMutex       sync.RWMutex
 	setResponseSinkArgsForCall []struct {
 		arg1 routing.MessageSink
 	}
+	SetSubscribedQualityStub        func(string, livekit.VideoQuality) error
+	setSubscribedQualityMutex       sync.RWMutex
+	setSubscribedQualityArgsForCall []struct {
+		arg1 string
+		arg2 livekit.VideoQuality
+	}
+	setSubscribedQualityReturns struct {
+		result1 error
+	}
+	setSubscribedQualityReturnsOnCall map[int]struct {
+		result1 error
+	}
 	SetTrackMutedStub        func(string, bool)
 	setTrackMutedMutex       sync.RWMutex
 	setTrackMutedArgsForCall []struct {
 		arg1 string
 		arg2 bool
 	}
+	SignalGenerationStub        func() uint32
+	signalGenerationMutex       sync.RWMutex
+	signalGenerationArgsForCall []struct {
+	}
+	signalGenerationReturns struct {
+		result1 uint32
+	}
+	signalGenerationReturnsOnCall map[int]struct {
+		result1 uint32
+	}
 	StartStub        func()
 	startMutex       sync.RWMutex
 	startArgsForCall []struct {
@@ -351,6 +728,28 @@ type FakeParticipant struct {
 	stateReturnsOnCall map[int]struct {
 		result1 livekit.ParticipantInfo_State
 	}
+	SubscribeToTrackStub        func(types.Participant, string) error
+	subscribeToTrackMutex       sync.RWMutex
+	subscribeToTrackArgsForCall []struct {
+		arg1 types.Participant
+		arg2 string
+	}
+	subscribeToTrackReturns struct {
+		result1 error
+	}
+	subscribeToTrackReturnsOnCall map[int]struct {
+		result1 error
+	}
+	SubscribedTrackIDsStub        func() []string
+	subscribedTrackIDsMutex       sync.RWMutex
+	subscribedTrackIDsArgsForCall []struct {
+	}
+	subscribedTrackIDsReturns struct {
+		result1 []string
+	}
+	subscribedTrackIDsReturnsOnCall map[int]struct {
+		result1 []string
+	}
 	SubscriberMediaEngineStub        func() *webrtc.MediaEngine
 	subscriberMediaEngineMutex       sync.RWMutex
 	subscriberMediaEngineArgsForCall []struct {
@@ -371,6 +770,27 @@ type FakeParticipant struct {
 	subscriberPCReturnsOnCall map[int]struct {
 		result1 *webrtc.PeerConnection
 	}
+	SubscribesToMixedAudioStub        func() bool
+	subscribesToMixedAudioMutex       sync.RWMutex
+	subscribesToMixedAudioArgsForCall []struct {
+	}
+	subscribesToMixedAudioReturns struct {
+		result1 bool
+	}
+	subscribesToMixedAudioReturnsOnCall map[int]struct {
+		result1 bool
+	}
+	SupportsCodecStub        func(string) bool
+	supportsCodecMutex       sync.RWMutex
+	supportsCodecArgsForCall []struct {
+		arg1 string
+	}
+	supportsCodecReturns struct {
+		result1 bool
+	}
+	supportsCodecReturnsOnCall map[int]struct {
+		result1 bool
+	}
 	ToProtoStub        func() *livekit.ParticipantInfo
 	toProtoMutex       sync.RWMutex
 	toProtoArgsForCall []struct {
@@ -381,6 +801,18 @@ type FakeParticipant struct {
 	toProtoReturnsOnCall map[int]struct {
 		result1 *livekit.ParticipantInfo
 	}
+	UnsubscribeFromTrackStub        func(types.Participant, string) error
+	unsubscribeFromTrackMutex       sync.RWMutex
+	unsubscribeFromTrackArgsForCall []struct {
+		arg1 types.Participant
+		arg2 string
+	}
+	unsubscribeFromTrackReturns struct {
+		result1 error
+	}
+	unsubscribeFromTrackReturnsOnCall map[int]struct {
+		result1 error
+	}
 	UpdateAfterActiveStub        func() bool
 	updateAfterActiveMutex       sync.RWMutex
 	updateAfterActiveArgsForCall []struct {
@@ -391,23 +823,31 @@ type FakeParticipant struct {
 	updateAfterActiveReturnsOnCall map[int]struct {
 		result1 bool
 	}
+	UpdateSubscribedTrackSettingsStub        func(string, bool, livekit.VideoQuality)
+	updateSubscribedTrackSettingsMutex       sync.RWMutex
+	updateSubscribedTrackSettingsArgsForCall []struct {
+		arg1 string
+		arg2 bool
+		arg3 livekit.VideoQuality
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
 
-func (fake *FakeParticipant) AddICECandidate(arg1 webrtc.ICECandidateInit, arg2 livekit.SignalTarget) error {
+func (fake *FakeParticipant) AddICECandidate(arg1 webrtc.ICECandidateInit, arg2 livekit.SignalTarget, arg3 uint32) error {
 	fake.addICECandidateMutex.Lock()
 	ret, specificReturn := fake.addICECandidateReturnsOnCall[len(fake.addICECandidateArgsForCall)]
 	fake.addICECandidateArgsForCall = append(fake.addICECandidateArgsForCall, struct {
 		arg1 webrtc.ICECandidateInit
 		arg2 livekit.SignalTarget
-	}{arg1, arg2})
+		arg3 uint32
+	}{arg1, arg2, arg3})
 	stub := fake.AddICECandidateStub
 	fakeReturns := fake.addICECandidateReturns
-	fake.recordInvocation("AddICECandidate", []interface{}{arg1, arg2})
+	fake.recordInvocation("AddICECandidate", []interface{}{arg1, arg2, arg3})
 	fake.addICECandidateMutex.Unlock()
 	if stub != nil {
-		return stub(arg1, arg2)
+		return stub(arg1, arg2, arg3)
 	}
 	if specificReturn {
 		return ret.result1
@@ -421,17 +861,17 @@ func (fake *FakeParticipant) AddICECandidateCallCount() int {
 	return len(fake.addICECandidateArgsForCall)
 }
 
-func (fake *FakeParticipant) AddICECandidateCalls(stub func(webrtc.ICECandidateInit, livekit.SignalTarget) error) {
+func (fake *FakeParticipant) AddICECandidateCalls(stub func(webrtc.ICECandidateInit, livekit.SignalTarget, uint32) error) {
 	fake.addICECandidateMutex.Lock()
 	defer fake.addICECandidateMutex.Unlock()
 	fake.AddICECandidateStub = stub
 }
 
-func (fake *FakeParticipant) AddICECandidateArgsForCall(i int) (webrtc.ICECandidateInit, livekit.SignalTarget) {
+func (fake *FakeParticipant) AddICECandidateArgsForCall(i int) (webrtc.ICECandidateInit, livekit.SignalTarget, uint32) {
 	fake.addICECandidateMutex.RLock()
 	defer fake.addICECandidateMutex.RUnlock()
 	argsForCall := fake.addICECandidateArgsForCall[i]
-	return argsForCall.arg1, argsForCall.arg2
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
 }
 
 func (fake *FakeParticipant) AddICECandidateReturns(result1 error) {
@@ -586,6 +1026,130 @@ func (fake *FakeParticipant) AddTrackArgsForCall(i int) *livekit.AddTrackRequest
 	return argsForCall.arg1
 }
 
+func (fake *FakeParticipant) AdmitSubscription(arg1 string, arg2 uint64) error {
+	fake.admitSubscriptionMutex.Lock()
+	ret, specificReturn := fake.admitSubscriptionReturnsOnCall[len(fake.admitSubscriptionArgsForCall)]
+	fake.admitSubscriptionArgsForCall = append(fake.admitSubscriptionArgsForCall, struct {
+		arg1 string
+		arg2 uint64
+	}{arg1, arg2})
+	stub := fake.AdmitSubscriptionStub
+	fakeReturns := fake.admitSubscriptionReturns
+	fake.recordInvocation("AdmitSubscription", []interface{}{arg1, arg2})
+	fake.admitSubscriptionMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeParticipant) AdmitSubscriptionCallCount() int {
+	fake.admitSubscriptionMutex.RLock()
+	defer fake.admitSubscriptionMutex.RUnlock()
+	return len(fake.admitSubscriptionArgsForCall)
+}
+
+func (fake *FakeParticipant) AdmitSubscriptionCalls(stub func(string, uint64) error) {
+	fake.admitSubscriptionMutex.Lock()
+	defer fake.admitSubscriptionMutex.Unlock()
+	fake.AdmitSubscriptionStub = stub
+}
+
+func (fake *FakeParticipant) AdmitSubscriptionArgsForCall(i int) (string, uint64) {
+	fake.admitSubscriptionMutex.RLock()
+	defer fake.admitSubscriptionMutex.RUnlock()
+	argsForCall := fake.admitSubscriptionArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeParticipant) AdmitSubscriptionReturns(result1 error) {
+	fake.admitSubscriptionMutex.Lock()
+	defer fake.admitSubscriptionMutex.Unlock()
+	fake.AdmitSubscriptionStub = nil
+	fake.admitSubscriptionReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeParticipant) AdmitSubscriptionReturnsOnCall(i int, result1 error) {
+	fake.admitSubscriptionMutex.Lock()
+	defer fake.admitSubscriptionMutex.Unlock()
+	fake.AdmitSubscriptionStub = nil
+	if fake.admitSubscriptionReturnsOnCall == nil {
+		fake.admitSubscriptionReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.admitSubscriptionReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeParticipant) AttachSink(arg1 string, arg2 types.TrackSink) error {
+	fake.attachSinkMutex.Lock()
+	ret, specificReturn := fake.attachSinkReturnsOnCall[len(fake.attachSinkArgsForCall)]
+	fake.attachSinkArgsForCall = append(fake.attachSinkArgsForCall, struct {
+		arg1 string
+		arg2 types.TrackSink
+	}{arg1, arg2})
+	stub := fake.AttachSinkStub
+	fakeReturns := fake.attachSinkReturns
+	fake.recordInvocation("AttachSink", []interface{}{arg1, arg2})
+	fake.attachSinkMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeParticipant) AttachSinkCallCount() int {
+	fake.attachSinkMutex.RLock()
+	defer fake.attachSinkMutex.RUnlock()
+	return len(fake.attachSinkArgsForCall)
+}
+
+func (fake *FakeParticipant) AttachSinkCalls(stub func(string, types.TrackSink) error) {
+	fake.attachSinkMutex.Lock()
+	defer fake.attachSinkMutex.Unlock()
+	fake.AttachSinkStub = stub
+}
+
+func (fake *FakeParticipant) AttachSinkArgsForCall(i int) (string, types.TrackSink) {
+	fake.attachSinkMutex.RLock()
+	defer fake.attachSinkMutex.RUnlock()
+	argsForCall := fake.attachSinkArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeParticipant) AttachSinkReturns(result1 error) {
+	fake.attachSinkMutex.Lock()
+	defer fake.attachSinkMutex.Unlock()
+	fake.AttachSinkStub = nil
+	fake.attachSinkReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeParticipant) AttachSinkReturnsOnCall(i int, result1 error) {
+	fake.attachSinkMutex.Lock()
+	defer fake.attachSinkMutex.Unlock()
+	fake.AttachSinkStub = nil
+	if fake.attachSinkReturnsOnCall == nil {
+		fake.attachSinkReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.attachSinkReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeParticipant) CanPublish() bool {
 	fake.canPublishMutex.Lock()
 	ret, specificReturn := fake.canPublishReturnsOnCall[len(fake.canPublishArgsForCall)]
@@ -639,15 +1203,15 @@ func (fake *FakeParticipant) CanPublishReturnsOnCall(i int, result1 bool) {
 	}{result1}
 }
 
-func (fake *FakeParticipant) CanSubscribe() bool {
-	fake.canSubscribeMutex.Lock()
-	ret, specificReturn := fake.canSubscribeReturnsOnCall[len(fake.canSubscribeArgsForCall)]
-	fake.canSubscribeArgsForCall = append(fake.canSubscribeArgsForCall, struct {
+func (fake *FakeParticipant) CanPublishData() bool {
+	fake.canPublishDataMutex.Lock()
+	ret, specificReturn := fake.canPublishDataReturnsOnCall[len(fake.canPublishDataArgsForCall)]
+	fake.canPublishDataArgsForCall = append(fake.canPublishDataArgsForCall, struct {
 	}{})
-	stub := fake.CanSubscribeStub
-	fakeReturns := fake.canSubscribeReturns
-	fake.recordInvocation("CanSubscribe", []interface{}{})
-	fake.canSubscribeMutex.Unlock()
+	stub := fake.CanPublishDataStub
+	fakeReturns := fake.canPublishDataReturns
+	fake.recordInvocation("CanPublishData", []interface{}{})
+	fake.canPublishDataMutex.Unlock()
 	if stub != nil {
 		return stub()
 	}
@@ -657,14 +1221,67 @@ func (fake *FakeParticipant) CanSubscribe() bool {
 	return fakeReturns.result1
 }
 
-func (fake *FakeParticipant) CanSubscribeCallCount() int {
-	fake.canSubscribeMutex.RLock()
-	defer fake.canSubscribeMutex.RUnlock()
-	return len(fake.canSubscribeArgsForCall)
+func (fake *FakeParticipant) CanPublishDataCallCount() int {
+	fake.canPublishDataMutex.RLock()
+	defer fake.canPublishDataMutex.RUnlock()
+	return len(fake.canPublishDataArgsForCall)
 }
 
-func (fake *FakeParticipant) CanSubscribeCalls(stub func() bool) {
-	fake.canSubscribeMutex.Lock()
+func (fake *FakeParticipant) CanPublishDataCalls(stub func() bool) {
+	fake.canPublishDataMutex.Lock()
+	defer fake.canPublishDataMutex.Unlock()
+	fake.CanPublishDataStub = stub
+}
+
+func (fake *FakeParticipant) CanPublishDataReturns(result1 bool) {
+	fake.canPublishDataMutex.Lock()
+	defer fake.canPublishDataMutex.Unlock()
+	fake.CanPublishDataStub = nil
+	fake.canPublishDataReturns = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *FakeParticipant) CanPublishDataReturnsOnCall(i int, result1 bool) {
+	fake.canPublishDataMutex.Lock()
+	defer fake.canPublishDataMutex.Unlock()
+	fake.CanPublishDataStub = nil
+	if fake.canPublishDataReturnsOnCall == nil {
+		fake.canPublishDataReturnsOnCall = make(map[int]struct {
+			result1 bool
+		})
+	}
+	fake.canPublishDataReturnsOnCall[i] = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *FakeParticipant) CanSubscribe() bool {
+	fake.canSubscribeMutex.Lock()
+	ret, specificReturn := fake.canSubscribeReturnsOnCall[len(fake.canSubscribeArgsForCall)]
+	fake.canSubscribeArgsForCall = append(fake.canSubscribeArgsForCall, struct {
+	}{})
+	stub := fake.CanSubscribeStub
+	fakeReturns := fake.canSubscribeReturns
+	fake.recordInvocation("CanSubscribe", []interface{}{})
+	fake.canSubscribeMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeParticipant) CanSubscribeCallCount() int {
+	fake.canSubscribeMutex.RLock()
+	defer fake.canSubscribeMutex.RUnlock()
+	return len(fake.canSubscribeArgsForCall)
+}
+
+func (fake *FakeParticipant) CanSubscribeCalls(stub func() bool) {
+	fake.canSubscribeMutex.Lock()
 	defer fake.canSubscribeMutex.Unlock()
 	fake.CanSubscribeStub = stub
 }
@@ -798,6 +1415,59 @@ func (fake *FakeParticipant) ConnectedAtReturnsOnCall(i int, result1 time.Time)
 	}{result1}
 }
 
+func (fake *FakeParticipant) ConnectionQuality() types.ConnectionQuality {
+	fake.connectionQualityMutex.Lock()
+	ret, specificReturn := fake.connectionQualityReturnsOnCall[len(fake.connectionQualityArgsForCall)]
+	fake.connectionQualityArgsForCall = append(fake.connectionQualityArgsForCall, struct {
+	}{})
+	stub := fake.ConnectionQualityStub
+	fakeReturns := fake.connectionQualityReturns
+	fake.recordInvocation("ConnectionQuality", []interface{}{})
+	fake.connectionQualityMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeParticipant) ConnectionQualityCallCount() int {
+	fake.connectionQualityMutex.RLock()
+	defer fake.connectionQualityMutex.RUnlock()
+	return len(fake.connectionQualityArgsForCall)
+}
+
+func (fake *FakeParticipant) ConnectionQualityCalls(stub func() types.ConnectionQuality) {
+	fake.connectionQualityMutex.Lock()
+	defer fake.connectionQualityMutex.Unlock()
+	fake.ConnectionQualityStub = stub
+}
+
+func (fake *FakeParticipant) ConnectionQualityReturns(result1 types.ConnectionQuality) {
+	fake.connectionQualityMutex.Lock()
+	defer fake.connectionQualityMutex.Unlock()
+	fake.ConnectionQualityStub = nil
+	fake.connectionQualityReturns = struct {
+		result1 types.ConnectionQuality
+	}{result1}
+}
+
+func (fake *FakeParticipant) ConnectionQualityReturnsOnCall(i int, result1 types.ConnectionQuality) {
+	fake.connectionQualityMutex.Lock()
+	defer fake.connectionQualityMutex.Unlock()
+	fake.ConnectionQualityStub = nil
+	if fake.connectionQualityReturnsOnCall == nil {
+		fake.connectionQualityReturnsOnCall = make(map[int]struct {
+			result1 types.ConnectionQuality
+		})
+	}
+	fake.connectionQualityReturnsOnCall[i] = struct {
+		result1 types.ConnectionQuality
+	}{result1}
+}
+
 func (fake *FakeParticipant) DebugInfo() map[string]interface{} {
 	fake.debugInfoMutex.Lock()
 	ret, specificReturn := fake.debugInfoReturnsOnCall[len(fake.debugInfoArgsForCall)]
@@ -851,6 +1521,262 @@ func (fake *FakeParticipant) DebugInfoReturnsOnCall(i int, result1 map[string]in
 	}{result1}
 }
 
+func (fake *FakeParticipant) DebugLogging(arg1 time.Duration) {
+	fake.debugLoggingMutex.Lock()
+	fake.debugLoggingArgsForCall = append(fake.debugLoggingArgsForCall, struct {
+		arg1 time.Duration
+	}{arg1})
+	stub := fake.DebugLoggingStub
+	fake.recordInvocation("DebugLogging", []interface{}{arg1})
+	fake.debugLoggingMutex.Unlock()
+	if stub != nil {
+		fake.DebugLoggingStub(arg1)
+	}
+}
+
+func (fake *FakeParticipant) DebugLoggingCallCount() int {
+	fake.debugLoggingMutex.RLock()
+	defer fake.debugLoggingMutex.RUnlock()
+	return len(fake.debugLoggingArgsForCall)
+}
+
+func (fake *FakeParticipant) DebugLoggingCalls(stub func(time.Duration)) {
+	fake.debugLoggingMutex.Lock()
+	defer fake.debugLoggingMutex.Unlock()
+	fake.DebugLoggingStub = stub
+}
+
+func (fake *FakeParticipant) DebugLoggingArgsForCall(i int) time.Duration {
+	fake.debugLoggingMutex.RLock()
+	defer fake.debugLoggingMutex.RUnlock()
+	argsForCall := fake.debugLoggingArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeParticipant) DefaultVideoQuality() (livekit.VideoQuality, bool) {
+	fake.defaultVideoQualityMutex.Lock()
+	ret, specificReturn := fake.defaultVideoQualityReturnsOnCall[len(fake.defaultVideoQualityArgsForCall)]
+	fake.defaultVideoQualityArgsForCall = append(fake.defaultVideoQualityArgsForCall, struct {
+	}{})
+	stub := fake.DefaultVideoQualityStub
+	fakeReturns := fake.defaultVideoQualityReturns
+	fake.recordInvocation("DefaultVideoQuality", []interface{}{})
+	fake.defaultVideoQualityMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeParticipant) DefaultVideoQualityCallCount() int {
+	fake.defaultVideoQualityMutex.RLock()
+	defer fake.defaultVideoQualityMutex.RUnlock()
+	return len(fake.defaultVideoQualityArgsForCall)
+}
+
+func (fake *FakeParticipant) DefaultVideoQualityCalls(stub func() (livekit.VideoQuality, bool)) {
+	fake.defaultVideoQualityMutex.Lock()
+	defer fake.defaultVideoQualityMutex.Unlock()
+	fake.DefaultVideoQualityStub = stub
+}
+
+func (fake *FakeParticipant) DefaultVideoQualityReturns(result1 livekit.VideoQuality, result2 bool) {
+	fake.defaultVideoQualityMutex.Lock()
+	defer fake.defaultVideoQualityMutex.Unlock()
+	fake.DefaultVideoQualityStub = nil
+	fake.defaultVideoQualityReturns = struct {
+		result1 livekit.VideoQuality
+		result2 bool
+	}{result1, result2}
+}
+
+func (fake *FakeParticipant) DefaultVideoQualityReturnsOnCall(i int, result1 livekit.VideoQuality, result2 bool) {
+	fake.defaultVideoQualityMutex.Lock()
+	defer fake.defaultVideoQualityMutex.Unlock()
+	fake.DefaultVideoQualityStub = nil
+	if fake.defaultVideoQualityReturnsOnCall == nil {
+		fake.defaultVideoQualityReturnsOnCall = make(map[int]struct {
+			result1 livekit.VideoQuality
+			result2 bool
+		})
+	}
+	fake.defaultVideoQualityReturnsOnCall[i] = struct {
+		result1 livekit.VideoQuality
+		result2 bool
+	}{result1, result2}
+}
+
+func (fake *FakeParticipant) DetachSink(arg1 string, arg2 types.TrackSink) error {
+	fake.detachSinkMutex.Lock()
+	ret, specificReturn := fake.detachSinkReturnsOnCall[len(fake.detachSinkArgsForCall)]
+	fake.detachSinkArgsForCall = append(fake.detachSinkArgsForCall, struct {
+		arg1 string
+		arg2 types.TrackSink
+	}{arg1, arg2})
+	stub := fake.DetachSinkStub
+	fakeReturns := fake.detachSinkReturns
+	fake.recordInvocation("DetachSink", []interface{}{arg1, arg2})
+	fake.detachSinkMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeParticipant) DetachSinkCallCount() int {
+	fake.detachSinkMutex.RLock()
+	defer fake.detachSinkMutex.RUnlock()
+	return len(fake.detachSinkArgsForCall)
+}
+
+func (fake *FakeParticipant) DetachSinkCalls(stub func(string, types.TrackSink) error) {
+	fake.detachSinkMutex.Lock()
+	defer fake.detachSinkMutex.Unlock()
+	fake.DetachSinkStub = stub
+}
+
+func (fake *FakeParticipant) DetachSinkArgsForCall(i int) (string, types.TrackSink) {
+	fake.detachSinkMutex.RLock()
+	defer fake.detachSinkMutex.RUnlock()
+	argsForCall := fake.detachSinkArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeParticipant) DetachSinkReturns(result1 error) {
+	fake.detachSinkMutex.Lock()
+	defer fake.detachSinkMutex.Unlock()
+	fake.DetachSinkStub = nil
+	fake.detachSinkReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeParticipant) DetachSinkReturnsOnCall(i int, result1 error) {
+	fake.detachSinkMutex.Lock()
+	defer fake.detachSinkMutex.Unlock()
+	fake.DetachSinkStub = nil
+	if fake.detachSinkReturnsOnCall == nil {
+		fake.detachSinkReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.detachSinkReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeParticipant) DisconnectReason() types.DisconnectReason {
+	fake.disconnectReasonMutex.Lock()
+	ret, specificReturn := fake.disconnectReasonReturnsOnCall[len(fake.disconnectReasonArgsForCall)]
+	fake.disconnectReasonArgsForCall = append(fake.disconnectReasonArgsForCall, struct {
+	}{})
+	stub := fake.DisconnectReasonStub
+	fakeReturns := fake.disconnectReasonReturns
+	fake.recordInvocation("DisconnectReason", []interface{}{})
+	fake.disconnectReasonMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeParticipant) DisconnectReasonCallCount() int {
+	fake.disconnectReasonMutex.RLock()
+	defer fake.disconnectReasonMutex.RUnlock()
+	return len(fake.disconnectReasonArgsForCall)
+}
+
+func (fake *FakeParticipant) DisconnectReasonCalls(stub func() types.DisconnectReason) {
+	fake.disconnectReasonMutex.Lock()
+	defer fake.disconnectReasonMutex.Unlock()
+	fake.DisconnectReasonStub = stub
+}
+
+func (fake *FakeParticipant) DisconnectReasonReturns(result1 types.DisconnectReason) {
+	fake.disconnectReasonMutex.Lock()
+	defer fake.disconnectReasonMutex.Unlock()
+	fake.DisconnectReasonStub = nil
+	fake.disconnectReasonReturns = struct {
+		result1 types.DisconnectReason
+	}{result1}
+}
+
+func (fake *FakeParticipant) DisconnectReasonReturnsOnCall(i int, result1 types.DisconnectReason) {
+	fake.disconnectReasonMutex.Lock()
+	defer fake.disconnectReasonMutex.Unlock()
+	fake.DisconnectReasonStub = nil
+	if fake.disconnectReasonReturnsOnCall == nil {
+		fake.disconnectReasonReturnsOnCall = make(map[int]struct {
+			result1 types.DisconnectReason
+		})
+	}
+	fake.disconnectReasonReturnsOnCall[i] = struct {
+		result1 types.DisconnectReason
+	}{result1}
+}
+
+func (fake *FakeParticipant) ForwardedLayers() map[string]int32 {
+	fake.forwardedLayersMutex.Lock()
+	ret, specificReturn := fake.forwardedLayersReturnsOnCall[len(fake.forwardedLayersArgsForCall)]
+	fake.forwardedLayersArgsForCall = append(fake.forwardedLayersArgsForCall, struct {
+	}{})
+	stub := fake.ForwardedLayersStub
+	fakeReturns := fake.forwardedLayersReturns
+	fake.recordInvocation("ForwardedLayers", []interface{}{})
+	fake.forwardedLayersMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeParticipant) ForwardedLayersCallCount() int {
+	fake.forwardedLayersMutex.RLock()
+	defer fake.forwardedLayersMutex.RUnlock()
+	return len(fake.forwardedLayersArgsForCall)
+}
+
+func (fake *FakeParticipant) ForwardedLayersCalls(stub func() map[string]int32) {
+	fake.forwardedLayersMutex.Lock()
+	defer fake.forwardedLayersMutex.Unlock()
+	fake.ForwardedLayersStub = stub
+}
+
+func (fake *FakeParticipant) ForwardedLayersReturns(result1 map[string]int32) {
+	fake.forwardedLayersMutex.Lock()
+	defer fake.forwardedLayersMutex.Unlock()
+	fake.ForwardedLayersStub = nil
+	fake.forwardedLayersReturns = struct {
+		result1 map[string]int32
+	}{result1}
+}
+
+func (fake *FakeParticipant) ForwardedLayersReturnsOnCall(i int, result1 map[string]int32) {
+	fake.forwardedLayersMutex.Lock()
+	defer fake.forwardedLayersMutex.Unlock()
+	fake.ForwardedLayersStub = nil
+	if fake.forwardedLayersReturnsOnCall == nil {
+		fake.forwardedLayersReturnsOnCall = make(map[int]struct {
+			result1 map[string]int32
+		})
+	}
+	fake.forwardedLayersReturnsOnCall[i] = struct {
+		result1 map[string]int32
+	}{result1}
+}
+
 func (fake *FakeParticipant) GetAudioLevel() (uint8, bool) {
 	fake.getAudioLevelMutex.Lock()
 	ret, specificReturn := fake.getAudioLevelReturnsOnCall[len(fake.getAudioLevelArgsForCall)]
@@ -1066,18 +1992,125 @@ func (fake *FakeParticipant) GetSubscribedTracksReturnsOnCall(i int, result1 []t
 	}{result1}
 }
 
-func (fake *FakeParticipant) HandleAnswer(arg1 webrtc.SessionDescription) error {
+func (fake *FakeParticipant) GetTrackStats() map[string][]types.BufferStats {
+	fake.getTrackStatsMutex.Lock()
+	ret, specificReturn := fake.getTrackStatsReturnsOnCall[len(fake.getTrackStatsArgsForCall)]
+	fake.getTrackStatsArgsForCall = append(fake.getTrackStatsArgsForCall, struct {
+	}{})
+	stub := fake.GetTrackStatsStub
+	fakeReturns := fake.getTrackStatsReturns
+	fake.recordInvocation("GetTrackStats", []interface{}{})
+	fake.getTrackStatsMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeParticipant) GetTrackStatsCallCount() int {
+	fake.getTrackStatsMutex.RLock()
+	defer fake.getTrackStatsMutex.RUnlock()
+	return len(fake.getTrackStatsArgsForCall)
+}
+
+func (fake *FakeParticipant) GetTrackStatsCalls(stub func() map[string][]types.BufferStats) {
+	fake.getTrackStatsMutex.Lock()
+	defer fake.getTrackStatsMutex.Unlock()
+	fake.GetTrackStatsStub = stub
+}
+
+func (fake *FakeParticipant) GetTrackStatsReturns(result1 map[string][]types.BufferStats) {
+	fake.getTrackStatsMutex.Lock()
+	defer fake.getTrackStatsMutex.Unlock()
+	fake.GetTrackStatsStub = nil
+	fake.getTrackStatsReturns = struct {
+		result1 map[string][]types.BufferStats
+	}{result1}
+}
+
+func (fake *FakeParticipant) GetTrackStatsReturnsOnCall(i int, result1 map[string][]types.BufferStats) {
+	fake.getTrackStatsMutex.Lock()
+	defer fake.getTrackStatsMutex.Unlock()
+	fake.GetTrackStatsStub = nil
+	if fake.getTrackStatsReturnsOnCall == nil {
+		fake.getTrackStatsReturnsOnCall = make(map[int]struct {
+			result1 map[string][]types.BufferStats
+		})
+	}
+	fake.getTrackStatsReturnsOnCall[i] = struct {
+		result1 map[string][]types.BufferStats
+	}{result1}
+}
+
+func (fake *FakeParticipant) GetTransportStats() []types.TransportStats {
+	fake.getTransportStatsMutex.Lock()
+	ret, specificReturn := fake.getTransportStatsReturnsOnCall[len(fake.getTransportStatsArgsForCall)]
+	fake.getTransportStatsArgsForCall = append(fake.getTransportStatsArgsForCall, struct {
+	}{})
+	stub := fake.GetTransportStatsStub
+	fakeReturns := fake.getTransportStatsReturns
+	fake.recordInvocation("GetTransportStats", []interface{}{})
+	fake.getTransportStatsMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeParticipant) GetTransportStatsCallCount() int {
+	fake.getTransportStatsMutex.RLock()
+	defer fake.getTransportStatsMutex.RUnlock()
+	return len(fake.getTransportStatsArgsForCall)
+}
+
+func (fake *FakeParticipant) GetTransportStatsCalls(stub func() []types.TransportStats) {
+	fake.getTransportStatsMutex.Lock()
+	defer fake.getTransportStatsMutex.Unlock()
+	fake.GetTransportStatsStub = stub
+}
+
+func (fake *FakeParticipant) GetTransportStatsReturns(result1 []types.TransportStats) {
+	fake.getTransportStatsMutex.Lock()
+	defer fake.getTransportStatsMutex.Unlock()
+	fake.GetTransportStatsStub = nil
+	fake.getTransportStatsReturns = struct {
+		result1 []types.TransportStats
+	}{result1}
+}
+
+func (fake *FakeParticipant) GetTransportStatsReturnsOnCall(i int, result1 []types.TransportStats) {
+	fake.getTransportStatsMutex.Lock()
+	defer fake.getTransportStatsMutex.Unlock()
+	fake.GetTransportStatsStub = nil
+	if fake.getTransportStatsReturnsOnCall == nil {
+		fake.getTransportStatsReturnsOnCall = make(map[int]struct {
+			result1 []types.TransportStats
+		})
+	}
+	fake.getTransportStatsReturnsOnCall[i] = struct {
+		result1 []types.TransportStats
+	}{result1}
+}
+
+func (fake *FakeParticipant) HandleAnswer(arg1 webrtc.SessionDescription, arg2 uint32) error {
 	fake.handleAnswerMutex.Lock()
 	ret, specificReturn := fake.handleAnswerReturnsOnCall[len(fake.handleAnswerArgsForCall)]
 	fake.handleAnswerArgsForCall = append(fake.handleAnswerArgsForCall, struct {
 		arg1 webrtc.SessionDescription
-	}{arg1})
+		arg2 uint32
+	}{arg1, arg2})
 	stub := fake.HandleAnswerStub
 	fakeReturns := fake.handleAnswerReturns
-	fake.recordInvocation("HandleAnswer", []interface{}{arg1})
+	fake.recordInvocation("HandleAnswer", []interface{}{arg1, arg2})
 	fake.handleAnswerMutex.Unlock()
 	if stub != nil {
-		return stub(arg1)
+		return stub(arg1, arg2)
 	}
 	if specificReturn {
 		return ret.result1
@@ -1091,17 +2124,17 @@ func (fake *FakeParticipant) HandleAnswerCallCount() int {
 	return len(fake.handleAnswerArgsForCall)
 }
 
-func (fake *FakeParticipant) HandleAnswerCalls(stub func(webrtc.SessionDescription) error) {
+func (fake *FakeParticipant) HandleAnswerCalls(stub func(webrtc.SessionDescription, uint32) error) {
 	fake.handleAnswerMutex.Lock()
 	defer fake.handleAnswerMutex.Unlock()
 	fake.HandleAnswerStub = stub
 }
 
-func (fake *FakeParticipant) HandleAnswerArgsForCall(i int) webrtc.SessionDescription {
+func (fake *FakeParticipant) HandleAnswerArgsForCall(i int) (webrtc.SessionDescription, uint32) {
 	fake.handleAnswerMutex.RLock()
 	defer fake.handleAnswerMutex.RUnlock()
 	argsForCall := fake.handleAnswerArgsForCall[i]
-	return argsForCall.arg1
+	return argsForCall.arg1, argsForCall.arg2
 }
 
 func (fake *FakeParticipant) HandleAnswerReturns(result1 error) {
@@ -1127,18 +2160,19 @@ func (fake *FakeParticipant) HandleAnswerReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
-func (fake *FakeParticipant) HandleOffer(arg1 webrtc.SessionDescription) (webrtc.SessionDescription, error) {
+func (fake *FakeParticipant) HandleOffer(arg1 webrtc.SessionDescription, arg2 uint32) (webrtc.SessionDescription, error) {
 	fake.handleOfferMutex.Lock()
 	ret, specificReturn := fake.handleOfferReturnsOnCall[len(fake.handleOfferArgsForCall)]
 	fake.handleOfferArgsForCall = append(fake.handleOfferArgsForCall, struct {
 		arg1 webrtc.SessionDescription
-	}{arg1})
+		arg2 uint32
+	}{arg1, arg2})
 	stub := fake.HandleOfferStub
 	fakeReturns := fake.handleOfferReturns
-	fake.recordInvocation("HandleOffer", []interface{}{arg1})
+	fake.recordInvocation("HandleOffer", []interface{}{arg1, arg2})
 	fake.handleOfferMutex.Unlock()
 	if stub != nil {
-		return stub(arg1)
+		return stub(arg1, arg2)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
@@ -1152,17 +2186,17 @@ func (fake *FakeParticipant) HandleOfferCallCount() int {
 	return len(fake.handleOfferArgsForCall)
 }
 
-func (fake *FakeParticipant) HandleOfferCalls(stub func(webrtc.SessionDescription) (webrtc.SessionDescription, error)) {
+func (fake *FakeParticipant) HandleOfferCalls(stub func(webrtc.SessionDescription, uint32) (webrtc.SessionDescription, error)) {
 	fake.handleOfferMutex.Lock()
 	defer fake.handleOfferMutex.Unlock()
 	fake.HandleOfferStub = stub
 }
 
-func (fake *FakeParticipant) HandleOfferArgsForCall(i int) webrtc.SessionDescription {
+func (fake *FakeParticipant) HandleOfferArgsForCall(i int) (webrtc.SessionDescription, uint32) {
 	fake.handleOfferMutex.RLock()
 	defer fake.handleOfferMutex.RUnlock()
 	argsForCall := fake.handleOfferArgsForCall[i]
-	return argsForCall.arg1
+	return argsForCall.arg1, argsForCall.arg2
 }
 
 func (fake *FakeParticipant) HandleOfferReturns(result1 webrtc.SessionDescription, result2 error) {
@@ -1191,17 +2225,18 @@ func (fake *FakeParticipant) HandleOfferReturnsOnCall(i int, result1 webrtc.Sess
 	}{result1, result2}
 }
 
-func (fake *FakeParticipant) ICERestart() error {
-	fake.iCERestartMutex.Lock()
-	ret, specificReturn := fake.iCERestartReturnsOnCall[len(fake.iCERestartArgsForCall)]
-	fake.iCERestartArgsForCall = append(fake.iCERestartArgsForCall, struct {
-	}{})
-	stub := fake.ICERestartStub
-	fakeReturns := fake.iCERestartReturns
-	fake.recordInvocation("ICERestart", []interface{}{})
-	fake.iCERestartMutex.Unlock()
+func (fake *FakeParticipant) HandleSignalLost(arg1 uint32) bool {
+	fake.handleSignalLostMutex.Lock()
+	ret, specificReturn := fake.handleSignalLostReturnsOnCall[len(fake.handleSignalLostArgsForCall)]
+	fake.handleSignalLostArgsForCall = append(fake.handleSignalLostArgsForCall, struct {
+		arg1 uint32
+	}{arg1})
+	stub := fake.HandleSignalLostStub
+	fakeReturns := fake.handleSignalLostReturns
+	fake.recordInvocation("HandleSignalLost", []interface{}{arg1})
+	fake.handleSignalLostMutex.Unlock()
 	if stub != nil {
-		return stub()
+		return stub(arg1)
 	}
 	if specificReturn {
 		return ret.result1
@@ -1209,39 +2244,102 @@ func (fake *FakeParticipant) ICERestart() error {
 	return fakeReturns.result1
 }
 
-func (fake *FakeParticipant) ICERestartCallCount() int {
-	fake.iCERestartMutex.RLock()
-	defer fake.iCERestartMutex.RUnlock()
-	return len(fake.iCERestartArgsForCall)
+func (fake *FakeParticipant) HandleSignalLostCallCount() int {
+	fake.handleSignalLostMutex.RLock()
+	defer fake.handleSignalLostMutex.RUnlock()
+	return len(fake.handleSignalLostArgsForCall)
 }
 
-func (fake *FakeParticipant) ICERestartCalls(stub func() error) {
-	fake.iCERestartMutex.Lock()
-	defer fake.iCERestartMutex.Unlock()
-	fake.ICERestartStub = stub
+func (fake *FakeParticipant) HandleSignalLostCalls(stub func(uint32) bool) {
+	fake.handleSignalLostMutex.Lock()
+	defer fake.handleSignalLostMutex.Unlock()
+	fake.HandleSignalLostStub = stub
 }
 
-func (fake *FakeParticipant) ICERestartReturns(result1 error) {
-	fake.iCERestartMutex.Lock()
-	defer fake.iCERestartMutex.Unlock()
-	fake.ICERestartStub = nil
-	fake.iCERestartReturns = struct {
-		result1 error
+func (fake *FakeParticipant) HandleSignalLostArgsForCall(i int) uint32 {
+	fake.handleSignalLostMutex.RLock()
+	defer fake.handleSignalLostMutex.RUnlock()
+	argsForCall := fake.handleSignalLostArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeParticipant) HandleSignalLostReturns(result1 bool) {
+	fake.handleSignalLostMutex.Lock()
+	defer fake.handleSignalLostMutex.Unlock()
+	fake.HandleSignalLostStub = nil
+	fake.handleSignalLostReturns = struct {
+		result1 bool
 	}{result1}
 }
 
-func (fake *FakeParticipant) ICERestartReturnsOnCall(i int, result1 error) {
-	fake.iCERestartMutex.Lock()
-	defer fake.iCERestartMutex.Unlock()
+func (fake *FakeParticipant) HandleSignalLostReturnsOnCall(i int, result1 bool) {
+	fake.handleSignalLostMutex.Lock()
+	defer fake.handleSignalLostMutex.Unlock()
+	fake.HandleSignalLostStub = nil
+	if fake.handleSignalLostReturnsOnCall == nil {
+		fake.handleSignalLostReturnsOnCall = make(map[int]struct {
+			result1 bool
+		})
+	}
+	fake.handleSignalLostReturnsOnCall[i] = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *FakeParticipant) ICERestart() (webrtc.SessionDescription, error) {
+	fake.iCERestartMutex.Lock()
+	ret, specificReturn := fake.iCERestartReturnsOnCall[len(fake.iCERestartArgsForCall)]
+	fake.iCERestartArgsForCall = append(fake.iCERestartArgsForCall, struct {
+	}{})
+	stub := fake.ICERestartStub
+	fakeReturns := fake.iCERestartReturns
+	fake.recordInvocation("ICERestart", []interface{}{})
+	fake.iCERestartMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeParticipant) ICERestartCallCount() int {
+	fake.iCERestartMutex.RLock()
+	defer fake.iCERestartMutex.RUnlock()
+	return len(fake.iCERestartArgsForCall)
+}
+
+func (fake *FakeParticipant) ICERestartCalls(stub func() (webrtc.SessionDescription, error)) {
+	fake.iCERestartMutex.Lock()
+	defer fake.iCERestartMutex.Unlock()
+	fake.ICERestartStub = stub
+}
+
+func (fake *FakeParticipant) ICERestartReturns(result1 webrtc.SessionDescription, result2 error) {
+	fake.iCERestartMutex.Lock()
+	defer fake.iCERestartMutex.Unlock()
+	fake.ICERestartStub = nil
+	fake.iCERestartReturns = struct {
+		result1 webrtc.SessionDescription
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeParticipant) ICERestartReturnsOnCall(i int, result1 webrtc.SessionDescription, result2 error) {
+	fake.iCERestartMutex.Lock()
+	defer fake.iCERestartMutex.Unlock()
 	fake.ICERestartStub = nil
 	if fake.iCERestartReturnsOnCall == nil {
 		fake.iCERestartReturnsOnCall = make(map[int]struct {
-			result1 error
+			result1 webrtc.SessionDescription
+			result2 error
 		})
 	}
 	fake.iCERestartReturnsOnCall[i] = struct {
-		result1 error
-	}{result1}
+		result1 webrtc.SessionDescription
+		result2 error
+	}{result1, result2}
 }
 
 func (fake *FakeParticipant) ID() string {
@@ -1350,6 +2448,59 @@ func (fake *FakeParticipant) IdentityReturnsOnCall(i int, result1 string) {
 	}{result1}
 }
 
+func (fake *FakeParticipant) IsInterrupted() bool {
+	fake.isInterruptedMutex.Lock()
+	ret, specificReturn := fake.isInterruptedReturnsOnCall[len(fake.isInterruptedArgsForCall)]
+	fake.isInterruptedArgsForCall = append(fake.isInterruptedArgsForCall, struct {
+	}{})
+	stub := fake.IsInterruptedStub
+	fakeReturns := fake.isInterruptedReturns
+	fake.recordInvocation("IsInterrupted", []interface{}{})
+	fake.isInterruptedMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeParticipant) IsInterruptedCallCount() int {
+	fake.isInterruptedMutex.RLock()
+	defer fake.isInterruptedMutex.RUnlock()
+	return len(fake.isInterruptedArgsForCall)
+}
+
+func (fake *FakeParticipant) IsInterruptedCalls(stub func() bool) {
+	fake.isInterruptedMutex.Lock()
+	defer fake.isInterruptedMutex.Unlock()
+	fake.IsInterruptedStub = stub
+}
+
+func (fake *FakeParticipant) IsInterruptedReturns(result1 bool) {
+	fake.isInterruptedMutex.Lock()
+	defer fake.isInterruptedMutex.Unlock()
+	fake.IsInterruptedStub = nil
+	fake.isInterruptedReturns = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *FakeParticipant) IsInterruptedReturnsOnCall(i int, result1 bool) {
+	fake.isInterruptedMutex.Lock()
+	defer fake.isInterruptedMutex.Unlock()
+	fake.IsInterruptedStub = nil
+	if fake.isInterruptedReturnsOnCall == nil {
+		fake.isInterruptedReturnsOnCall = make(map[int]struct {
+			result1 bool
+		})
+	}
+	fake.isInterruptedReturnsOnCall[i] = struct {
+		result1 bool
+	}{result1}
+}
+
 func (fake *FakeParticipant) IsReady() bool {
 	fake.isReadyMutex.Lock()
 	ret, specificReturn := fake.isReadyReturnsOnCall[len(fake.isReadyArgsForCall)]
@@ -1403,6 +2554,534 @@ func (fake *FakeParticipant) IsReadyReturnsOnCall(i int, result1 bool) {
 	}{result1}
 }
 
+func (fake *FakeParticipant) IsReconnecting() bool {
+	fake.isReconnectingMutex.Lock()
+	ret, specificReturn := fake.isReconnectingReturnsOnCall[len(fake.isReconnectingArgsForCall)]
+	fake.isReconnectingArgsForCall = append(fake.isReconnectingArgsForCall, struct {
+	}{})
+	stub := fake.IsReconnectingStub
+	fakeReturns := fake.isReconnectingReturns
+	fake.recordInvocation("IsReconnecting", []interface{}{})
+	fake.isReconnectingMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeParticipant) IsReconnectingCallCount() int {
+	fake.isReconnectingMutex.RLock()
+	defer fake.isReconnectingMutex.RUnlock()
+	return len(fake.isReconnectingArgsForCall)
+}
+
+func (fake *FakeParticipant) IsReconnectingCalls(stub func() bool) {
+	fake.isReconnectingMutex.Lock()
+	defer fake.isReconnectingMutex.Unlock()
+	fake.IsReconnectingStub = stub
+}
+
+func (fake *FakeParticipant) IsReconnectingReturns(result1 bool) {
+	fake.isReconnectingMutex.Lock()
+	defer fake.isReconnectingMutex.Unlock()
+	fake.IsReconnectingStub = nil
+	fake.isReconnectingReturns = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *FakeParticipant) IsReconnectingReturnsOnCall(i int, result1 bool) {
+	fake.isReconnectingMutex.Lock()
+	defer fake.isReconnectingMutex.Unlock()
+	fake.IsReconnectingStub = nil
+	if fake.isReconnectingReturnsOnCall == nil {
+		fake.isReconnectingReturnsOnCall = make(map[int]struct {
+			result1 bool
+		})
+	}
+	fake.isReconnectingReturnsOnCall[i] = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *FakeParticipant) IsSpeaking() bool {
+	fake.isSpeakingMutex.Lock()
+	ret, specificReturn := fake.isSpeakingReturnsOnCall[len(fake.isSpeakingArgsForCall)]
+	fake.isSpeakingArgsForCall = append(fake.isSpeakingArgsForCall, struct {
+	}{})
+	stub := fake.IsSpeakingStub
+	fakeReturns := fake.isSpeakingReturns
+	fake.recordInvocation("IsSpeaking", []interface{}{})
+	fake.isSpeakingMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeParticipant) IsSpeakingCallCount() int {
+	fake.isSpeakingMutex.RLock()
+	defer fake.isSpeakingMutex.RUnlock()
+	return len(fake.isSpeakingArgsForCall)
+}
+
+func (fake *FakeParticipant) IsSpeakingCalls(stub func() bool) {
+	fake.isSpeakingMutex.Lock()
+	defer fake.isSpeakingMutex.Unlock()
+	fake.IsSpeakingStub = stub
+}
+
+func (fake *FakeParticipant) IsSpeakingReturns(result1 bool) {
+	fake.isSpeakingMutex.Lock()
+	defer fake.isSpeakingMutex.Unlock()
+	fake.IsSpeakingStub = nil
+	fake.isSpeakingReturns = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *FakeParticipant) IsSpeakingReturnsOnCall(i int, result1 bool) {
+	fake.isSpeakingMutex.Lock()
+	defer fake.isSpeakingMutex.Unlock()
+	fake.IsSpeakingStub = nil
+	if fake.isSpeakingReturnsOnCall == nil {
+		fake.isSpeakingReturnsOnCall = make(map[int]struct {
+			result1 bool
+		})
+	}
+	fake.isSpeakingReturnsOnCall[i] = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *FakeParticipant) MapSubscriberCaptureTime(arg1 uint32, arg2 uint8) {
+	fake.mapSubscriberCaptureTimeMutex.Lock()
+	fake.mapSubscriberCaptureTimeArgsForCall = append(fake.mapSubscriberCaptureTimeArgsForCall, struct {
+		arg1 uint32
+		arg2 uint8
+	}{arg1, arg2})
+	stub := fake.MapSubscriberCaptureTimeStub
+	fake.recordInvocation("MapSubscriberCaptureTime", []interface{}{arg1, arg2})
+	fake.mapSubscriberCaptureTimeMutex.Unlock()
+	if stub != nil {
+		fake.MapSubscriberCaptureTimeStub(arg1, arg2)
+	}
+}
+
+func (fake *FakeParticipant) MapSubscriberCaptureTimeCallCount() int {
+	fake.mapSubscriberCaptureTimeMutex.RLock()
+	defer fake.mapSubscriberCaptureTimeMutex.RUnlock()
+	return len(fake.mapSubscriberCaptureTimeArgsForCall)
+}
+
+func (fake *FakeParticipant) MapSubscriberCaptureTimeCalls(stub func(uint32, uint8)) {
+	fake.mapSubscriberCaptureTimeMutex.Lock()
+	defer fake.mapSubscriberCaptureTimeMutex.Unlock()
+	fake.MapSubscriberCaptureTimeStub = stub
+}
+
+func (fake *FakeParticipant) MapSubscriberCaptureTimeArgsForCall(i int) (uint32, uint8) {
+	fake.mapSubscriberCaptureTimeMutex.RLock()
+	defer fake.mapSubscriberCaptureTimeMutex.RUnlock()
+	argsForCall := fake.mapSubscriberCaptureTimeArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeParticipant) MapSubscriberSSRC(arg1 uint32, arg2 string) uint32 {
+	fake.mapSubscriberSSRCMutex.Lock()
+	ret, specificReturn := fake.mapSubscriberSSRCReturnsOnCall[len(fake.mapSubscriberSSRCArgsForCall)]
+	fake.mapSubscriberSSRCArgsForCall = append(fake.mapSubscriberSSRCArgsForCall, struct {
+		arg1 uint32
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.MapSubscriberSSRCStub
+	fakeReturns := fake.mapSubscriberSSRCReturns
+	fake.recordInvocation("MapSubscriberSSRC", []interface{}{arg1, arg2})
+	fake.mapSubscriberSSRCMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeParticipant) MapSubscriberSSRCCallCount() int {
+	fake.mapSubscriberSSRCMutex.RLock()
+	defer fake.mapSubscriberSSRCMutex.RUnlock()
+	return len(fake.mapSubscriberSSRCArgsForCall)
+}
+
+func (fake *FakeParticipant) MapSubscriberSSRCCalls(stub func(uint32, string) uint32) {
+	fake.mapSubscriberSSRCMutex.Lock()
+	defer fake.mapSubscriberSSRCMutex.Unlock()
+	fake.MapSubscriberSSRCStub = stub
+}
+
+func (fake *FakeParticipant) MapSubscriberSSRCArgsForCall(i int) (uint32, string) {
+	fake.mapSubscriberSSRCMutex.RLock()
+	defer fake.mapSubscriberSSRCMutex.RUnlock()
+	argsForCall := fake.mapSubscriberSSRCArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeParticipant) MapSubscriberSSRCReturns(result1 uint32) {
+	fake.mapSubscriberSSRCMutex.Lock()
+	defer fake.mapSubscriberSSRCMutex.Unlock()
+	fake.MapSubscriberSSRCStub = nil
+	fake.mapSubscriberSSRCReturns = struct {
+		result1 uint32
+	}{result1}
+}
+
+func (fake *FakeParticipant) MapSubscriberSSRCReturnsOnCall(i int, result1 uint32) {
+	fake.mapSubscriberSSRCMutex.Lock()
+	defer fake.mapSubscriberSSRCMutex.Unlock()
+	fake.MapSubscriberSSRCStub = nil
+	if fake.mapSubscriberSSRCReturnsOnCall == nil {
+		fake.mapSubscriberSSRCReturnsOnCall = make(map[int]struct {
+			result1 uint32
+		})
+	}
+	fake.mapSubscriberSSRCReturnsOnCall[i] = struct {
+		result1 uint32
+	}{result1}
+}
+
+func (fake *FakeParticipant) MaxDownloadBitrate() uint64 {
+	fake.maxDownloadBitrateMutex.Lock()
+	ret, specificReturn := fake.maxDownloadBitrateReturnsOnCall[len(fake.maxDownloadBitrateArgsForCall)]
+	fake.maxDownloadBitrateArgsForCall = append(fake.maxDownloadBitrateArgsForCall, struct {
+	}{})
+	stub := fake.MaxDownloadBitrateStub
+	fakeReturns := fake.maxDownloadBitrateReturns
+	fake.recordInvocation("MaxDownloadBitrate", []interface{}{})
+	fake.maxDownloadBitrateMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeParticipant) MaxDownloadBitrateCallCount() int {
+	fake.maxDownloadBitrateMutex.RLock()
+	defer fake.maxDownloadBitrateMutex.RUnlock()
+	return len(fake.maxDownloadBitrateArgsForCall)
+}
+
+func (fake *FakeParticipant) MaxDownloadBitrateCalls(stub func() uint64) {
+	fake.maxDownloadBitrateMutex.Lock()
+	defer fake.maxDownloadBitrateMutex.Unlock()
+	fake.MaxDownloadBitrateStub = stub
+}
+
+func (fake *FakeParticipant) MaxDownloadBitrateReturns(result1 uint64) {
+	fake.maxDownloadBitrateMutex.Lock()
+	defer fake.maxDownloadBitrateMutex.Unlock()
+	fake.MaxDownloadBitrateStub = nil
+	fake.maxDownloadBitrateReturns = struct {
+		result1 uint64
+	}{result1}
+}
+
+func (fake *FakeParticipant) MaxDownloadBitrateReturnsOnCall(i int, result1 uint64) {
+	fake.maxDownloadBitrateMutex.Lock()
+	defer fake.maxDownloadBitrateMutex.Unlock()
+	fake.MaxDownloadBitrateStub = nil
+	if fake.maxDownloadBitrateReturnsOnCall == nil {
+		fake.maxDownloadBitrateReturnsOnCall = make(map[int]struct {
+			result1 uint64
+		})
+	}
+	fake.maxDownloadBitrateReturnsOnCall[i] = struct {
+		result1 uint64
+	}{result1}
+}
+
+func (fake *FakeParticipant) MaxUploadBitrate() uint64 {
+	fake.maxUploadBitrateMutex.Lock()
+	ret, specificReturn := fake.maxUploadBitrateReturnsOnCall[len(fake.maxUploadBitrateArgsForCall)]
+	fake.maxUploadBitrateArgsForCall = append(fake.maxUploadBitrateArgsForCall, struct {
+	}{})
+	stub := fake.MaxUploadBitrateStub
+	fakeReturns := fake.maxUploadBitrateReturns
+	fake.recordInvocation("MaxUploadBitrate", []interface{}{})
+	fake.maxUploadBitrateMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeParticipant) MaxUploadBitrateCallCount() int {
+	fake.maxUploadBitrateMutex.RLock()
+	defer fake.maxUploadBitrateMutex.RUnlock()
+	return len(fake.maxUploadBitrateArgsForCall)
+}
+
+func (fake *FakeParticipant) MaxUploadBitrateCalls(stub func() uint64) {
+	fake.maxUploadBitrateMutex.Lock()
+	defer fake.maxUploadBitrateMutex.Unlock()
+	fake.MaxUploadBitrateStub = stub
+}
+
+func (fake *FakeParticipant) MaxUploadBitrateReturns(result1 uint64) {
+	fake.maxUploadBitrateMutex.Lock()
+	defer fake.maxUploadBitrateMutex.Unlock()
+	fake.MaxUploadBitrateStub = nil
+	fake.maxUploadBitrateReturns = struct {
+		result1 uint64
+	}{result1}
+}
+
+func (fake *FakeParticipant) MaxUploadBitrateReturnsOnCall(i int, result1 uint64) {
+	fake.maxUploadBitrateMutex.Lock()
+	defer fake.maxUploadBitrateMutex.Unlock()
+	fake.MaxUploadBitrateStub = nil
+	if fake.maxUploadBitrateReturnsOnCall == nil {
+		fake.maxUploadBitrateReturnsOnCall = make(map[int]struct {
+			result1 uint64
+		})
+	}
+	fake.maxUploadBitrateReturnsOnCall[i] = struct {
+		result1 uint64
+	}{result1}
+}
+
+func (fake *FakeParticipant) Metadata() string {
+	fake.metadataMutex.Lock()
+	ret, specificReturn := fake.metadataReturnsOnCall[len(fake.metadataArgsForCall)]
+	fake.metadataArgsForCall = append(fake.metadataArgsForCall, struct {
+	}{})
+	stub := fake.MetadataStub
+	fakeReturns := fake.metadataReturns
+	fake.recordInvocation("Metadata", []interface{}{})
+	fake.metadataMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeParticipant) MetadataCallCount() int {
+	fake.metadataMutex.RLock()
+	defer fake.metadataMutex.RUnlock()
+	return len(fake.metadataArgsForCall)
+}
+
+func (fake *FakeParticipant) MetadataCalls(stub func() string) {
+	fake.metadataMutex.Lock()
+	defer fake.metadataMutex.Unlock()
+	fake.MetadataStub = stub
+}
+
+func (fake *FakeParticipant) MetadataReturns(result1 string) {
+	fake.metadataMutex.Lock()
+	defer fake.metadataMutex.Unlock()
+	fake.MetadataStub = nil
+	fake.metadataReturns = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *FakeParticipant) MetadataReturnsOnCall(i int, result1 string) {
+	fake.metadataMutex.Lock()
+	defer fake.metadataMutex.Unlock()
+	fake.MetadataStub = nil
+	if fake.metadataReturnsOnCall == nil {
+		fake.metadataReturnsOnCall = make(map[int]struct {
+			result1 string
+		})
+	}
+	fake.metadataReturnsOnCall[i] = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *FakeParticipant) MutePublishedTrack(arg1 string, arg2 bool) error {
+	fake.mutePublishedTrackMutex.Lock()
+	ret, specificReturn := fake.mutePublishedTrackReturnsOnCall[len(fake.mutePublishedTrackArgsForCall)]
+	fake.mutePublishedTrackArgsForCall = append(fake.mutePublishedTrackArgsForCall, struct {
+		arg1 string
+		arg2 bool
+	}{arg1, arg2})
+	stub := fake.MutePublishedTrackStub
+	fakeReturns := fake.mutePublishedTrackReturns
+	fake.recordInvocation("MutePublishedTrack", []interface{}{arg1, arg2})
+	fake.mutePublishedTrackMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeParticipant) MutePublishedTrackCallCount() int {
+	fake.mutePublishedTrackMutex.RLock()
+	defer fake.mutePublishedTrackMutex.RUnlock()
+	return len(fake.mutePublishedTrackArgsForCall)
+}
+
+func (fake *FakeParticipant) MutePublishedTrackCalls(stub func(string, bool) error) {
+	fake.mutePublishedTrackMutex.Lock()
+	defer fake.mutePublishedTrackMutex.Unlock()
+	fake.MutePublishedTrackStub = stub
+}
+
+func (fake *FakeParticipant) MutePublishedTrackArgsForCall(i int) (string, bool) {
+	fake.mutePublishedTrackMutex.RLock()
+	defer fake.mutePublishedTrackMutex.RUnlock()
+	argsForCall := fake.mutePublishedTrackArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeParticipant) MutePublishedTrackReturns(result1 error) {
+	fake.mutePublishedTrackMutex.Lock()
+	defer fake.mutePublishedTrackMutex.Unlock()
+	fake.MutePublishedTrackStub = nil
+	fake.mutePublishedTrackReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeParticipant) MutePublishedTrackReturnsOnCall(i int, result1 error) {
+	fake.mutePublishedTrackMutex.Lock()
+	defer fake.mutePublishedTrackMutex.Unlock()
+	fake.MutePublishedTrackStub = nil
+	if fake.mutePublishedTrackReturnsOnCall == nil {
+		fake.mutePublishedTrackReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.mutePublishedTrackReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeParticipant) NackWindow() int {
+	fake.nackWindowMutex.Lock()
+	ret, specificReturn := fake.nackWindowReturnsOnCall[len(fake.nackWindowArgsForCall)]
+	fake.nackWindowArgsForCall = append(fake.nackWindowArgsForCall, struct {
+	}{})
+	stub := fake.NackWindowStub
+	fakeReturns := fake.nackWindowReturns
+	fake.recordInvocation("NackWindow", []interface{}{})
+	fake.nackWindowMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeParticipant) NackWindowCallCount() int {
+	fake.nackWindowMutex.RLock()
+	defer fake.nackWindowMutex.RUnlock()
+	return len(fake.nackWindowArgsForCall)
+}
+
+func (fake *FakeParticipant) NackWindowCalls(stub func() int) {
+	fake.nackWindowMutex.Lock()
+	defer fake.nackWindowMutex.Unlock()
+	fake.NackWindowStub = stub
+}
+
+func (fake *FakeParticipant) NackWindowReturns(result1 int) {
+	fake.nackWindowMutex.Lock()
+	defer fake.nackWindowMutex.Unlock()
+	fake.NackWindowStub = nil
+	fake.nackWindowReturns = struct {
+		result1 int
+	}{result1}
+}
+
+func (fake *FakeParticipant) NackWindowReturnsOnCall(i int, result1 int) {
+	fake.nackWindowMutex.Lock()
+	defer fake.nackWindowMutex.Unlock()
+	fake.NackWindowStub = nil
+	if fake.nackWindowReturnsOnCall == nil {
+		fake.nackWindowReturnsOnCall = make(map[int]struct {
+			result1 int
+		})
+	}
+	fake.nackWindowReturnsOnCall[i] = struct {
+		result1 int
+	}{result1}
+}
+
+func (fake *FakeParticipant) Name() string {
+	fake.nameMutex.Lock()
+	ret, specificReturn := fake.nameReturnsOnCall[len(fake.nameArgsForCall)]
+	fake.nameArgsForCall = append(fake.nameArgsForCall, struct {
+	}{})
+	stub := fake.NameStub
+	fakeReturns := fake.nameReturns
+	fake.recordInvocation("Name", []interface{}{})
+	fake.nameMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeParticipant) NameCallCount() int {
+	fake.nameMutex.RLock()
+	defer fake.nameMutex.RUnlock()
+	return len(fake.nameArgsForCall)
+}
+
+func (fake *FakeParticipant) NameCalls(stub func() string) {
+	fake.nameMutex.Lock()
+	defer fake.nameMutex.Unlock()
+	fake.NameStub = stub
+}
+
+func (fake *FakeParticipant) NameReturns(result1 string) {
+	fake.nameMutex.Lock()
+	defer fake.nameMutex.Unlock()
+	fake.NameStub = nil
+	fake.nameReturns = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *FakeParticipant) NameReturnsOnCall(i int, result1 string) {
+	fake.nameMutex.Lock()
+	defer fake.nameMutex.Unlock()
+	fake.NameStub = nil
+	if fake.nameReturnsOnCall == nil {
+		fake.nameReturnsOnCall = make(map[int]struct {
+			result1 string
+		})
+	}
+	fake.nameReturnsOnCall[i] = struct {
+		result1 string
+	}{result1}
+}
+
 func (fake *FakeParticipant) Negotiate() {
 	fake.negotiateMutex.Lock()
 	fake.negotiateArgsForCall = append(fake.negotiateArgsForCall, struct {
@@ -1452,10 +3131,42 @@ func (fake *FakeParticipant) OnCloseCalls(stub func(func(types.Participant))) {
 	fake.OnCloseStub = stub
 }
 
-func (fake *FakeParticipant) OnCloseArgsForCall(i int) func(types.Participant) {
-	fake.onCloseMutex.RLock()
-	defer fake.onCloseMutex.RUnlock()
-	argsForCall := fake.onCloseArgsForCall[i]
+func (fake *FakeParticipant) OnCloseArgsForCall(i int) func(types.Participant) {
+	fake.onCloseMutex.RLock()
+	defer fake.onCloseMutex.RUnlock()
+	argsForCall := fake.onCloseArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeParticipant) OnConnectionQualityChange(arg1 func(p types.Participant, quality types.ConnectionQuality)) {
+	fake.onConnectionQualityChangeMutex.Lock()
+	fake.onConnectionQualityChangeArgsForCall = append(fake.onConnectionQualityChangeArgsForCall, struct {
+		arg1 func(p types.Participant, quality types.ConnectionQuality)
+	}{arg1})
+	stub := fake.OnConnectionQualityChangeStub
+	fake.recordInvocation("OnConnectionQualityChange", []interface{}{arg1})
+	fake.onConnectionQualityChangeMutex.Unlock()
+	if stub != nil {
+		fake.OnConnectionQualityChangeStub(arg1)
+	}
+}
+
+func (fake *FakeParticipant) OnConnectionQualityChangeCallCount() int {
+	fake.onConnectionQualityChangeMutex.RLock()
+	defer fake.onConnectionQualityChangeMutex.RUnlock()
+	return len(fake.onConnectionQualityChangeArgsForCall)
+}
+
+func (fake *FakeParticipant) OnConnectionQualityChangeCalls(stub func(func(p types.Participant, quality types.ConnectionQuality))) {
+	fake.onConnectionQualityChangeMutex.Lock()
+	defer fake.onConnectionQualityChangeMutex.Unlock()
+	fake.OnConnectionQualityChangeStub = stub
+}
+
+func (fake *FakeParticipant) OnConnectionQualityChangeArgsForCall(i int) func(p types.Participant, quality types.ConnectionQuality) {
+	fake.onConnectionQualityChangeMutex.RLock()
+	defer fake.onConnectionQualityChangeMutex.RUnlock()
+	argsForCall := fake.onConnectionQualityChangeArgsForCall[i]
 	return argsForCall.arg1
 }
 
@@ -1491,6 +3202,70 @@ func (fake *FakeParticipant) OnDataPacketArgsForCall(i int) func(types.Participa
 	return argsForCall.arg1
 }
 
+func (fake *FakeParticipant) OnFirstMediaReceived(arg1 func(types.Participant, types.PublishedTrack)) {
+	fake.onFirstMediaReceivedMutex.Lock()
+	fake.onFirstMediaReceivedArgsForCall = append(fake.onFirstMediaReceivedArgsForCall, struct {
+		arg1 func(types.Participant, types.PublishedTrack)
+	}{arg1})
+	stub := fake.OnFirstMediaReceivedStub
+	fake.recordInvocation("OnFirstMediaReceived", []interface{}{arg1})
+	fake.onFirstMediaReceivedMutex.Unlock()
+	if stub != nil {
+		fake.OnFirstMediaReceivedStub(arg1)
+	}
+}
+
+func (fake *FakeParticipant) OnFirstMediaReceivedCallCount() int {
+	fake.onFirstMediaReceivedMutex.RLock()
+	defer fake.onFirstMediaReceivedMutex.RUnlock()
+	return len(fake.onFirstMediaReceivedArgsForCall)
+}
+
+func (fake *FakeParticipant) OnFirstMediaReceivedCalls(stub func(func(types.Participant, types.PublishedTrack))) {
+	fake.onFirstMediaReceivedMutex.Lock()
+	defer fake.onFirstMediaReceivedMutex.Unlock()
+	fake.OnFirstMediaReceivedStub = stub
+}
+
+func (fake *FakeParticipant) OnFirstMediaReceivedArgsForCall(i int) func(types.Participant, types.PublishedTrack) {
+	fake.onFirstMediaReceivedMutex.RLock()
+	defer fake.onFirstMediaReceivedMutex.RUnlock()
+	argsForCall := fake.onFirstMediaReceivedArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeParticipant) OnInterruptionChange(arg1 func(types.Participant)) {
+	fake.onInterruptionChangeMutex.Lock()
+	fake.onInterruptionChangeArgsForCall = append(fake.onInterruptionChangeArgsForCall, struct {
+		arg1 func(types.Participant)
+	}{arg1})
+	stub := fake.OnInterruptionChangeStub
+	fake.recordInvocation("OnInterruptionChange", []interface{}{arg1})
+	fake.onInterruptionChangeMutex.Unlock()
+	if stub != nil {
+		fake.OnInterruptionChangeStub(arg1)
+	}
+}
+
+func (fake *FakeParticipant) OnInterruptionChangeCallCount() int {
+	fake.onInterruptionChangeMutex.RLock()
+	defer fake.onInterruptionChangeMutex.RUnlock()
+	return len(fake.onInterruptionChangeArgsForCall)
+}
+
+func (fake *FakeParticipant) OnInterruptionChangeCalls(stub func(func(types.Participant))) {
+	fake.onInterruptionChangeMutex.Lock()
+	defer fake.onInterruptionChangeMutex.Unlock()
+	fake.OnInterruptionChangeStub = stub
+}
+
+func (fake *FakeParticipant) OnInterruptionChangeArgsForCall(i int) func(types.Participant) {
+	fake.onInterruptionChangeMutex.RLock()
+	defer fake.onInterruptionChangeMutex.RUnlock()
+	argsForCall := fake.onInterruptionChangeArgsForCall[i]
+	return argsForCall.arg1
+}
+
 func (fake *FakeParticipant) OnMetadataUpdate(arg1 func(types.Participant)) {
 	fake.onMetadataUpdateMutex.Lock()
 	fake.onMetadataUpdateArgsForCall = append(fake.onMetadataUpdateArgsForCall, struct {
@@ -1523,6 +3298,134 @@ func (fake *FakeParticipant) OnMetadataUpdateArgsForCall(i int) func(types.Parti
 	return argsForCall.arg1
 }
 
+func (fake *FakeParticipant) OnNameUpdate(arg1 func(types.Participant)) {
+	fake.onNameUpdateMutex.Lock()
+	fake.onNameUpdateArgsForCall = append(fake.onNameUpdateArgsForCall, struct {
+		arg1 func(types.Participant)
+	}{arg1})
+	stub := fake.OnNameUpdateStub
+	fake.recordInvocation("OnNameUpdate", []interface{}{arg1})
+	fake.onNameUpdateMutex.Unlock()
+	if stub != nil {
+		fake.OnNameUpdateStub(arg1)
+	}
+}
+
+func (fake *FakeParticipant) OnNameUpdateCallCount() int {
+	fake.onNameUpdateMutex.RLock()
+	defer fake.onNameUpdateMutex.RUnlock()
+	return len(fake.onNameUpdateArgsForCall)
+}
+
+func (fake *FakeParticipant) OnNameUpdateCalls(stub func(func(types.Participant))) {
+	fake.onNameUpdateMutex.Lock()
+	defer fake.onNameUpdateMutex.Unlock()
+	fake.OnNameUpdateStub = stub
+}
+
+func (fake *FakeParticipant) OnNameUpdateArgsForCall(i int) func(types.Participant) {
+	fake.onNameUpdateMutex.RLock()
+	defer fake.onNameUpdateMutex.RUnlock()
+	argsForCall := fake.onNameUpdateArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeParticipant) OnPublisherCongested(arg1 func(p types.Participant, congested bool)) {
+	fake.onPublisherCongestedMutex.Lock()
+	fake.onPublisherCongestedArgsForCall = append(fake.onPublisherCongestedArgsForCall, struct {
+		arg1 func(p types.Participant, congested bool)
+	}{arg1})
+	stub := fake.OnPublisherCongestedStub
+	fake.recordInvocation("OnPublisherCongested", []interface{}{arg1})
+	fake.onPublisherCongestedMutex.Unlock()
+	if stub != nil {
+		fake.OnPublisherCongestedStub(arg1)
+	}
+}
+
+func (fake *FakeParticipant) OnPublisherCongestedCallCount() int {
+	fake.onPublisherCongestedMutex.RLock()
+	defer fake.onPublisherCongestedMutex.RUnlock()
+	return len(fake.onPublisherCongestedArgsForCall)
+}
+
+func (fake *FakeParticipant) OnPublisherCongestedCalls(stub func(func(p types.Participant, congested bool))) {
+	fake.onPublisherCongestedMutex.Lock()
+	defer fake.onPublisherCongestedMutex.Unlock()
+	fake.OnPublisherCongestedStub = stub
+}
+
+func (fake *FakeParticipant) OnPublisherCongestedArgsForCall(i int) func(p types.Participant, congested bool) {
+	fake.onPublisherCongestedMutex.RLock()
+	defer fake.onPublisherCongestedMutex.RUnlock()
+	argsForCall := fake.onPublisherCongestedArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeParticipant) OnReconnectingChange(arg1 func(types.Participant)) {
+	fake.onReconnectingChangeMutex.Lock()
+	fake.onReconnectingChangeArgsForCall = append(fake.onReconnectingChangeArgsForCall, struct {
+		arg1 func(types.Participant)
+	}{arg1})
+	stub := fake.OnReconnectingChangeStub
+	fake.recordInvocation("OnReconnectingChange", []interface{}{arg1})
+	fake.onReconnectingChangeMutex.Unlock()
+	if stub != nil {
+		fake.OnReconnectingChangeStub(arg1)
+	}
+}
+
+func (fake *FakeParticipant) OnReconnectingChangeCallCount() int {
+	fake.onReconnectingChangeMutex.RLock()
+	defer fake.onReconnectingChangeMutex.RUnlock()
+	return len(fake.onReconnectingChangeArgsForCall)
+}
+
+func (fake *FakeParticipant) OnReconnectingChangeCalls(stub func(func(types.Participant))) {
+	fake.onReconnectingChangeMutex.Lock()
+	defer fake.onReconnectingChangeMutex.Unlock()
+	fake.OnReconnectingChangeStub = stub
+}
+
+func (fake *FakeParticipant) OnReconnectingChangeArgsForCall(i int) func(types.Participant) {
+	fake.onReconnectingChangeMutex.RLock()
+	defer fake.onReconnectingChangeMutex.RUnlock()
+	argsForCall := fake.onReconnectingChangeArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeParticipant) OnSpeaking(arg1 func(p types.Participant, speaking bool)) {
+	fake.onSpeakingMutex.Lock()
+	fake.onSpeakingArgsForCall = append(fake.onSpeakingArgsForCall, struct {
+		arg1 func(p types.Participant, speaking bool)
+	}{arg1})
+	stub := fake.OnSpeakingStub
+	fake.recordInvocation("OnSpeaking", []interface{}{arg1})
+	fake.onSpeakingMutex.Unlock()
+	if stub != nil {
+		fake.OnSpeakingStub(arg1)
+	}
+}
+
+func (fake *FakeParticipant) OnSpeakingCallCount() int {
+	fake.onSpeakingMutex.RLock()
+	defer fake.onSpeakingMutex.RUnlock()
+	return len(fake.onSpeakingArgsForCall)
+}
+
+func (fake *FakeParticipant) OnSpeakingCalls(stub func(func(p types.Participant, speaking bool))) {
+	fake.onSpeakingMutex.Lock()
+	defer fake.onSpeakingMutex.Unlock()
+	fake.OnSpeakingStub = stub
+}
+
+func (fake *FakeParticipant) OnSpeakingArgsForCall(i int) func(p types.Participant, speaking bool) {
+	fake.onSpeakingMutex.RLock()
+	defer fake.onSpeakingMutex.RUnlock()
+	argsForCall := fake.onSpeakingArgsForCall[i]
+	return argsForCall.arg1
+}
+
 func (fake *FakeParticipant) OnStateChange(arg1 func(p types.Participant, oldState livekit.ParticipantInfo_State)) {
 	fake.onStateChangeMutex.Lock()
 	fake.onStateChangeArgsForCall = append(fake.onStateChangeArgsForCall, struct {
@@ -1619,6 +3522,62 @@ func (fake *FakeParticipant) OnTrackUpdatedArgsForCall(i int) func(types.Partici
 	return argsForCall.arg1
 }
 
+func (fake *FakeParticipant) PinnedVideoQuality() (livekit.VideoQuality, bool) {
+	fake.pinnedVideoQualityMutex.Lock()
+	ret, specificReturn := fake.pinnedVideoQualityReturnsOnCall[len(fake.pinnedVideoQualityArgsForCall)]
+	fake.pinnedVideoQualityArgsForCall = append(fake.pinnedVideoQualityArgsForCall, struct {
+	}{})
+	stub := fake.PinnedVideoQualityStub
+	fakeReturns := fake.pinnedVideoQualityReturns
+	fake.recordInvocation("PinnedVideoQuality", []interface{}{})
+	fake.pinnedVideoQualityMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeParticipant) PinnedVideoQualityCallCount() int {
+	fake.pinnedVideoQualityMutex.RLock()
+	defer fake.pinnedVideoQualityMutex.RUnlock()
+	return len(fake.pinnedVideoQualityArgsForCall)
+}
+
+func (fake *FakeParticipant) PinnedVideoQualityCalls(stub func() (livekit.VideoQuality, bool)) {
+	fake.pinnedVideoQualityMutex.Lock()
+	defer fake.pinnedVideoQualityMutex.Unlock()
+	fake.PinnedVideoQualityStub = stub
+}
+
+func (fake *FakeParticipant) PinnedVideoQualityReturns(result1 livekit.VideoQuality, result2 bool) {
+	fake.pinnedVideoQualityMutex.Lock()
+	defer fake.pinnedVideoQualityMutex.Unlock()
+	fake.PinnedVideoQualityStub = nil
+	fake.pinnedVideoQualityReturns = struct {
+		result1 livekit.VideoQuality
+		result2 bool
+	}{result1, result2}
+}
+
+func (fake *FakeParticipant) PinnedVideoQualityReturnsOnCall(i int, result1 livekit.VideoQuality, result2 bool) {
+	fake.pinnedVideoQualityMutex.Lock()
+	defer fake.pinnedVideoQualityMutex.Unlock()
+	fake.PinnedVideoQualityStub = nil
+	if fake.pinnedVideoQualityReturnsOnCall == nil {
+		fake.pinnedVideoQualityReturnsOnCall = make(map[int]struct {
+			result1 livekit.VideoQuality
+			result2 bool
+		})
+	}
+	fake.pinnedVideoQualityReturnsOnCall[i] = struct {
+		result1 livekit.VideoQuality
+		result2 bool
+	}{result1, result2}
+}
+
 func (fake *FakeParticipant) ProtocolVersion() types.ProtocolVersion {
 	fake.protocolVersionMutex.Lock()
 	ret, specificReturn := fake.protocolVersionReturnsOnCall[len(fake.protocolVersionArgsForCall)]
@@ -1725,6 +3684,59 @@ func (fake *FakeParticipant) RTCPChanReturnsOnCall(i int, result1 chan []rtcp.Pa
 	}{result1}
 }
 
+func (fake *FakeParticipant) ReconnectGrace() time.Duration {
+	fake.reconnectGraceMutex.Lock()
+	ret, specificReturn := fake.reconnectGraceReturnsOnCall[len(fake.reconnectGraceArgsForCall)]
+	fake.reconnectGraceArgsForCall = append(fake.reconnectGraceArgsForCall, struct {
+	}{})
+	stub := fake.ReconnectGraceStub
+	fakeReturns := fake.reconnectGraceReturns
+	fake.recordInvocation("ReconnectGrace", []interface{}{})
+	fake.reconnectGraceMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeParticipant) ReconnectGraceCallCount() int {
+	fake.reconnectGraceMutex.RLock()
+	defer fake.reconnectGraceMutex.RUnlock()
+	return len(fake.reconnectGraceArgsForCall)
+}
+
+func (fake *FakeParticipant) ReconnectGraceCalls(stub func() time.Duration) {
+	fake.reconnectGraceMutex.Lock()
+	defer fake.reconnectGraceMutex.Unlock()
+	fake.ReconnectGraceStub = stub
+}
+
+func (fake *FakeParticipant) ReconnectGraceReturns(result1 time.Duration) {
+	fake.reconnectGraceMutex.Lock()
+	defer fake.reconnectGraceMutex.Unlock()
+	fake.ReconnectGraceStub = nil
+	fake.reconnectGraceReturns = struct {
+		result1 time.Duration
+	}{result1}
+}
+
+func (fake *FakeParticipant) ReconnectGraceReturnsOnCall(i int, result1 time.Duration) {
+	fake.reconnectGraceMutex.Lock()
+	defer fake.reconnectGraceMutex.Unlock()
+	fake.ReconnectGraceStub = nil
+	if fake.reconnectGraceReturnsOnCall == nil {
+		fake.reconnectGraceReturnsOnCall = make(map[int]struct {
+			result1 time.Duration
+		})
+	}
+	fake.reconnectGraceReturnsOnCall[i] = struct {
+		result1 time.Duration
+	}{result1}
+}
+
 func (fake *FakeParticipant) RemoveSubscribedTrack(arg1 string, arg2 types.SubscribedTrack) {
 	fake.removeSubscribedTrackMutex.Lock()
 	fake.removeSubscribedTrackArgsForCall = append(fake.removeSubscribedTrackArgsForCall, struct {
@@ -1856,6 +3868,73 @@ func (fake *FakeParticipant) SendActiveSpeakersReturnsOnCall(i int, result1 erro
 	}{result1}
 }
 
+func (fake *FakeParticipant) SendData(arg1 []byte, arg2 bool) error {
+	var arg1Copy []byte
+	if arg1 != nil {
+		arg1Copy = make([]byte, len(arg1))
+		copy(arg1Copy, arg1)
+	}
+	fake.sendDataMutex.Lock()
+	ret, specificReturn := fake.sendDataReturnsOnCall[len(fake.sendDataArgsForCall)]
+	fake.sendDataArgsForCall = append(fake.sendDataArgsForCall, struct {
+		arg1 []byte
+		arg2 bool
+	}{arg1Copy, arg2})
+	stub := fake.SendDataStub
+	fakeReturns := fake.sendDataReturns
+	fake.recordInvocation("SendData", []interface{}{arg1Copy, arg2})
+	fake.sendDataMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeParticipant) SendDataCallCount() int {
+	fake.sendDataMutex.RLock()
+	defer fake.sendDataMutex.RUnlock()
+	return len(fake.sendDataArgsForCall)
+}
+
+func (fake *FakeParticipant) SendDataCalls(stub func([]byte, bool) error) {
+	fake.sendDataMutex.Lock()
+	defer fake.sendDataMutex.Unlock()
+	fake.SendDataStub = stub
+}
+
+func (fake *FakeParticipant) SendDataArgsForCall(i int) ([]byte, bool) {
+	fake.sendDataMutex.RLock()
+	defer fake.sendDataMutex.RUnlock()
+	argsForCall := fake.sendDataArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeParticipant) SendDataReturns(result1 error) {
+	fake.sendDataMutex.Lock()
+	defer fake.sendDataMutex.Unlock()
+	fake.SendDataStub = nil
+	fake.sendDataReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeParticipant) SendDataReturnsOnCall(i int, result1 error) {
+	fake.sendDataMutex.Lock()
+	defer fake.sendDataMutex.Unlock()
+	fake.SendDataStub = nil
+	if fake.sendDataReturnsOnCall == nil {
+		fake.sendDataReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.sendDataReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeParticipant) SendDataPacket(arg1 *livekit.DataPacket) error {
 	fake.sendDataPacketMutex.Lock()
 	ret, specificReturn := fake.sendDataPacketReturnsOnCall[len(fake.sendDataPacketArgsForCall)]
@@ -1917,7 +3996,7 @@ func (fake *FakeParticipant) SendDataPacketReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
-func (fake *FakeParticipant) SendJoinResponse(arg1 *livekit.Room, arg2 []types.Participant, arg3 []*livekit.ICEServer) error {
+func (fake *FakeParticipant) SendJoinResponse(arg1 *livekit.Room, arg2 []types.Participant, arg3 []*livekit.ICEServer, arg4 bool) error {
 	var arg2Copy []types.Participant
 	if arg2 != nil {
 		arg2Copy = make([]types.Participant, len(arg2))
@@ -1934,13 +4013,14 @@ func (fake *FakeParticipant) SendJoinResponse(arg1 *livekit.Room, arg2 []types.P
 		arg1 *livekit.Room
 		arg2 []types.Participant
 		arg3 []*livekit.ICEServer
-	}{arg1, arg2Copy, arg3Copy})
+		arg4 bool
+	}{arg1, arg2Copy, arg3Copy, arg4})
 	stub := fake.SendJoinResponseStub
 	fakeReturns := fake.sendJoinResponseReturns
-	fake.recordInvocation("SendJoinResponse", []interface{}{arg1, arg2Copy, arg3Copy})
+	fake.recordInvocation("SendJoinResponse", []interface{}{arg1, arg2Copy, arg3Copy, arg4})
 	fake.sendJoinResponseMutex.Unlock()
 	if stub != nil {
-		return stub(arg1, arg2, arg3)
+		return stub(arg1, arg2, arg3, arg4)
 	}
 	if specificReturn {
 		return ret.result1
@@ -1954,17 +4034,17 @@ func (fake *FakeParticipant) SendJoinResponseCallCount() int {
 	return len(fake.sendJoinResponseArgsForCall)
 }
 
-func (fake *FakeParticipant) SendJoinResponseCalls(stub func(*livekit.Room, []types.Participant, []*livekit.ICEServer) error) {
+func (fake *FakeParticipant) SendJoinResponseCalls(stub func(*livekit.Room, []types.Participant, []*livekit.ICEServer, bool) error) {
 	fake.sendJoinResponseMutex.Lock()
 	defer fake.sendJoinResponseMutex.Unlock()
 	fake.SendJoinResponseStub = stub
 }
 
-func (fake *FakeParticipant) SendJoinResponseArgsForCall(i int) (*livekit.Room, []types.Participant, []*livekit.ICEServer) {
+func (fake *FakeParticipant) SendJoinResponseArgsForCall(i int) (*livekit.Room, []types.Participant, []*livekit.ICEServer, bool) {
 	fake.sendJoinResponseMutex.RLock()
 	defer fake.sendJoinResponseMutex.RUnlock()
 	argsForCall := fake.sendJoinResponseArgsForCall[i]
-	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
 }
 
 func (fake *FakeParticipant) SendJoinResponseReturns(result1 error) {
@@ -2051,40 +4131,261 @@ func (fake *FakeParticipant) SendParticipantUpdateReturnsOnCall(i int, result1 e
 			result1 error
 		})
 	}
-	fake.sendParticipantUpdateReturnsOnCall[i] = struct {
+	fake.sendParticipantUpdateReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeParticipant) SetDeviceClass(arg1 string) {
+	fake.setDeviceClassMutex.Lock()
+	fake.setDeviceClassArgsForCall = append(fake.setDeviceClassArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.SetDeviceClassStub
+	fake.recordInvocation("SetDeviceClass", []interface{}{arg1})
+	fake.setDeviceClassMutex.Unlock()
+	if stub != nil {
+		fake.SetDeviceClassStub(arg1)
+	}
+}
+
+func (fake *FakeParticipant) SetDeviceClassCallCount() int {
+	fake.setDeviceClassMutex.RLock()
+	defer fake.setDeviceClassMutex.RUnlock()
+	return len(fake.setDeviceClassArgsForCall)
+}
+
+func (fake *FakeParticipant) SetDeviceClassCalls(stub func(string)) {
+	fake.setDeviceClassMutex.Lock()
+	defer fake.setDeviceClassMutex.Unlock()
+	fake.SetDeviceClassStub = stub
+}
+
+func (fake *FakeParticipant) SetDeviceClassArgsForCall(i int) string {
+	fake.setDeviceClassMutex.RLock()
+	defer fake.setDeviceClassMutex.RUnlock()
+	argsForCall := fake.setDeviceClassArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeParticipant) SetDisconnectReason(arg1 types.DisconnectReason) {
+	fake.setDisconnectReasonMutex.Lock()
+	fake.setDisconnectReasonArgsForCall = append(fake.setDisconnectReasonArgsForCall, struct {
+		arg1 types.DisconnectReason
+	}{arg1})
+	stub := fake.SetDisconnectReasonStub
+	fake.recordInvocation("SetDisconnectReason", []interface{}{arg1})
+	fake.setDisconnectReasonMutex.Unlock()
+	if stub != nil {
+		fake.SetDisconnectReasonStub(arg1)
+	}
+}
+
+func (fake *FakeParticipant) SetDisconnectReasonCallCount() int {
+	fake.setDisconnectReasonMutex.RLock()
+	defer fake.setDisconnectReasonMutex.RUnlock()
+	return len(fake.setDisconnectReasonArgsForCall)
+}
+
+func (fake *FakeParticipant) SetDisconnectReasonCalls(stub func(types.DisconnectReason)) {
+	fake.setDisconnectReasonMutex.Lock()
+	defer fake.setDisconnectReasonMutex.Unlock()
+	fake.SetDisconnectReasonStub = stub
+}
+
+func (fake *FakeParticipant) SetDisconnectReasonArgsForCall(i int) types.DisconnectReason {
+	fake.setDisconnectReasonMutex.RLock()
+	defer fake.setDisconnectReasonMutex.RUnlock()
+	argsForCall := fake.setDisconnectReasonArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeParticipant) SetMaxDownloadBitrate(arg1 uint64) {
+	fake.setMaxDownloadBitrateMutex.Lock()
+	fake.setMaxDownloadBitrateArgsForCall = append(fake.setMaxDownloadBitrateArgsForCall, struct {
+		arg1 uint64
+	}{arg1})
+	stub := fake.SetMaxDownloadBitrateStub
+	fake.recordInvocation("SetMaxDownloadBitrate", []interface{}{arg1})
+	fake.setMaxDownloadBitrateMutex.Unlock()
+	if stub != nil {
+		fake.SetMaxDownloadBitrateStub(arg1)
+	}
+}
+
+func (fake *FakeParticipant) SetMaxDownloadBitrateCallCount() int {
+	fake.setMaxDownloadBitrateMutex.RLock()
+	defer fake.setMaxDownloadBitrateMutex.RUnlock()
+	return len(fake.setMaxDownloadBitrateArgsForCall)
+}
+
+func (fake *FakeParticipant) SetMaxDownloadBitrateCalls(stub func(uint64)) {
+	fake.setMaxDownloadBitrateMutex.Lock()
+	defer fake.setMaxDownloadBitrateMutex.Unlock()
+	fake.SetMaxDownloadBitrateStub = stub
+}
+
+func (fake *FakeParticipant) SetMaxDownloadBitrateArgsForCall(i int) uint64 {
+	fake.setMaxDownloadBitrateMutex.RLock()
+	defer fake.setMaxDownloadBitrateMutex.RUnlock()
+	argsForCall := fake.setMaxDownloadBitrateArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeParticipant) SetMaxUploadBitrate(arg1 uint64) {
+	fake.setMaxUploadBitrateMutex.Lock()
+	fake.setMaxUploadBitrateArgsForCall = append(fake.setMaxUploadBitrateArgsForCall, struct {
+		arg1 uint64
+	}{arg1})
+	stub := fake.SetMaxUploadBitrateStub
+	fake.recordInvocation("SetMaxUploadBitrate", []interface{}{arg1})
+	fake.setMaxUploadBitrateMutex.Unlock()
+	if stub != nil {
+		fake.SetMaxUploadBitrateStub(arg1)
+	}
+}
+
+func (fake *FakeParticipant) SetMaxUploadBitrateCallCount() int {
+	fake.setMaxUploadBitrateMutex.RLock()
+	defer fake.setMaxUploadBitrateMutex.RUnlock()
+	return len(fake.setMaxUploadBitrateArgsForCall)
+}
+
+func (fake *FakeParticipant) SetMaxUploadBitrateCalls(stub func(uint64)) {
+	fake.setMaxUploadBitrateMutex.Lock()
+	defer fake.setMaxUploadBitrateMutex.Unlock()
+	fake.SetMaxUploadBitrateStub = stub
+}
+
+func (fake *FakeParticipant) SetMaxUploadBitrateArgsForCall(i int) uint64 {
+	fake.setMaxUploadBitrateMutex.RLock()
+	defer fake.setMaxUploadBitrateMutex.RUnlock()
+	argsForCall := fake.setMaxUploadBitrateArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeParticipant) SetMetadata(arg1 string) error {
+	fake.setMetadataMutex.Lock()
+	ret, specificReturn := fake.setMetadataReturnsOnCall[len(fake.setMetadataArgsForCall)]
+	fake.setMetadataArgsForCall = append(fake.setMetadataArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.SetMetadataStub
+	fakeReturns := fake.setMetadataReturns
+	fake.recordInvocation("SetMetadata", []interface{}{arg1})
+	fake.setMetadataMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeParticipant) SetMetadataCallCount() int {
+	fake.setMetadataMutex.RLock()
+	defer fake.setMetadataMutex.RUnlock()
+	return len(fake.setMetadataArgsForCall)
+}
+
+func (fake *FakeParticipant) SetMetadataCalls(stub func(string) error) {
+	fake.setMetadataMutex.Lock()
+	defer fake.setMetadataMutex.Unlock()
+	fake.SetMetadataStub = stub
+}
+
+func (fake *FakeParticipant) SetMetadataArgsForCall(i int) string {
+	fake.setMetadataMutex.RLock()
+	defer fake.setMetadataMutex.RUnlock()
+	argsForCall := fake.setMetadataArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeParticipant) SetMetadataReturns(result1 error) {
+	fake.setMetadataMutex.Lock()
+	defer fake.setMetadataMutex.Unlock()
+	fake.SetMetadataStub = nil
+	fake.setMetadataReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeParticipant) SetMetadataReturnsOnCall(i int, result1 error) {
+	fake.setMetadataMutex.Lock()
+	defer fake.setMetadataMutex.Unlock()
+	fake.SetMetadataStub = nil
+	if fake.setMetadataReturnsOnCall == nil {
+		fake.setMetadataReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.setMetadataReturnsOnCall[i] = struct {
 		result1 error
 	}{result1}
 }
 
-func (fake *FakeParticipant) SetMetadata(arg1 string) {
-	fake.setMetadataMutex.Lock()
-	fake.setMetadataArgsForCall = append(fake.setMetadataArgsForCall, struct {
+func (fake *FakeParticipant) SetNackWindow(arg1 int) {
+	fake.setNackWindowMutex.Lock()
+	fake.setNackWindowArgsForCall = append(fake.setNackWindowArgsForCall, struct {
+		arg1 int
+	}{arg1})
+	stub := fake.SetNackWindowStub
+	fake.recordInvocation("SetNackWindow", []interface{}{arg1})
+	fake.setNackWindowMutex.Unlock()
+	if stub != nil {
+		fake.SetNackWindowStub(arg1)
+	}
+}
+
+func (fake *FakeParticipant) SetNackWindowCallCount() int {
+	fake.setNackWindowMutex.RLock()
+	defer fake.setNackWindowMutex.RUnlock()
+	return len(fake.setNackWindowArgsForCall)
+}
+
+func (fake *FakeParticipant) SetNackWindowCalls(stub func(int)) {
+	fake.setNackWindowMutex.Lock()
+	defer fake.setNackWindowMutex.Unlock()
+	fake.SetNackWindowStub = stub
+}
+
+func (fake *FakeParticipant) SetNackWindowArgsForCall(i int) int {
+	fake.setNackWindowMutex.RLock()
+	defer fake.setNackWindowMutex.RUnlock()
+	argsForCall := fake.setNackWindowArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeParticipant) SetName(arg1 string) {
+	fake.setNameMutex.Lock()
+	fake.setNameArgsForCall = append(fake.setNameArgsForCall, struct {
 		arg1 string
 	}{arg1})
-	stub := fake.SetMetadataStub
-	fake.recordInvocation("SetMetadata", []interface{}{arg1})
-	fake.setMetadataMutex.Unlock()
+	stub := fake.SetNameStub
+	fake.recordInvocation("SetName", []interface{}{arg1})
+	fake.setNameMutex.Unlock()
 	if stub != nil {
-		fake.SetMetadataStub(arg1)
+		fake.SetNameStub(arg1)
 	}
 }
 
-func (fake *FakeParticipant) SetMetadataCallCount() int {
-	fake.setMetadataMutex.RLock()
-	defer fake.setMetadataMutex.RUnlock()
-	return len(fake.setMetadataArgsForCall)
+func (fake *FakeParticipant) SetNameCallCount() int {
+	fake.setNameMutex.RLock()
+	defer fake.setNameMutex.RUnlock()
+	return len(fake.setNameArgsForCall)
 }
 
-func (fake *FakeParticipant) SetMetadataCalls(stub func(string)) {
-	fake.setMetadataMutex.Lock()
-	defer fake.setMetadataMutex.Unlock()
-	fake.SetMetadataStub = stub
+func (fake *FakeParticipant) SetNameCalls(stub func(string)) {
+	fake.setNameMutex.Lock()
+	defer fake.setNameMutex.Unlock()
+	fake.SetNameStub = stub
 }
 
-func (fake *FakeParticipant) SetMetadataArgsForCall(i int) string {
-	fake.setMetadataMutex.RLock()
-	defer fake.setMetadataMutex.RUnlock()
-	argsForCall := fake.setMetadataArgsForCall[i]
+func (fake *FakeParticipant) SetNameArgsForCall(i int) string {
+	fake.setNameMutex.RLock()
+	defer fake.setNameMutex.RUnlock()
+	argsForCall := fake.setNameArgsForCall[i]
 	return argsForCall.arg1
 }
 
@@ -2120,6 +4421,70 @@ func (fake *FakeParticipant) SetPermissionArgsForCall(i int) *livekit.Participan
 	return argsForCall.arg1
 }
 
+func (fake *FakeParticipant) SetReconnectGrace(arg1 time.Duration) {
+	fake.setReconnectGraceMutex.Lock()
+	fake.setReconnectGraceArgsForCall = append(fake.setReconnectGraceArgsForCall, struct {
+		arg1 time.Duration
+	}{arg1})
+	stub := fake.SetReconnectGraceStub
+	fake.recordInvocation("SetReconnectGrace", []interface{}{arg1})
+	fake.setReconnectGraceMutex.Unlock()
+	if stub != nil {
+		fake.SetReconnectGraceStub(arg1)
+	}
+}
+
+func (fake *FakeParticipant) SetReconnectGraceCallCount() int {
+	fake.setReconnectGraceMutex.RLock()
+	defer fake.setReconnectGraceMutex.RUnlock()
+	return len(fake.setReconnectGraceArgsForCall)
+}
+
+func (fake *FakeParticipant) SetReconnectGraceCalls(stub func(time.Duration)) {
+	fake.setReconnectGraceMutex.Lock()
+	defer fake.setReconnectGraceMutex.Unlock()
+	fake.SetReconnectGraceStub = stub
+}
+
+func (fake *FakeParticipant) SetReconnectGraceArgsForCall(i int) time.Duration {
+	fake.setReconnectGraceMutex.RLock()
+	defer fake.setReconnectGraceMutex.RUnlock()
+	argsForCall := fake.setReconnectGraceArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeParticipant) SetRecording(arg1 bool) {
+	fake.setRecordingMutex.Lock()
+	fake.setRecordingArgsForCall = append(fake.setRecordingArgsForCall, struct {
+		arg1 bool
+	}{arg1})
+	stub := fake.SetRecordingStub
+	fake.recordInvocation("SetRecording", []interface{}{arg1})
+	fake.setRecordingMutex.Unlock()
+	if stub != nil {
+		fake.SetRecordingStub(arg1)
+	}
+}
+
+func (fake *FakeParticipant) SetRecordingCallCount() int {
+	fake.setRecordingMutex.RLock()
+	defer fake.setRecordingMutex.RUnlock()
+	return len(fake.setRecordingArgsForCall)
+}
+
+func (fake *FakeParticipant) SetRecordingCalls(stub func(bool)) {
+	fake.setRecordingMutex.Lock()
+	defer fake.setRecordingMutex.Unlock()
+	fake.SetRecordingStub = stub
+}
+
+func (fake *FakeParticipant) SetRecordingArgsForCall(i int) bool {
+	fake.setRecordingMutex.RLock()
+	defer fake.setRecordingMutex.RUnlock()
+	argsForCall := fake.setRecordingArgsForCall[i]
+	return argsForCall.arg1
+}
+
 func (fake *FakeParticipant) SetResponseSink(arg1 routing.MessageSink) {
 	fake.setResponseSinkMutex.Lock()
 	fake.setResponseSinkArgsForCall = append(fake.setResponseSinkArgsForCall, struct {
@@ -2152,6 +4517,68 @@ func (fake *FakeParticipant) SetResponseSinkArgsForCall(i int) routing.MessageSi
 	return argsForCall.arg1
 }
 
+func (fake *FakeParticipant) SetSubscribedQuality(arg1 string, arg2 livekit.VideoQuality) error {
+	fake.setSubscribedQualityMutex.Lock()
+	ret, specificReturn := fake.setSubscribedQualityReturnsOnCall[len(fake.setSubscribedQualityArgsForCall)]
+	fake.setSubscribedQualityArgsForCall = append(fake.setSubscribedQualityArgsForCall, struct {
+		arg1 string
+		arg2 livekit.VideoQuality
+	}{arg1, arg2})
+	stub := fake.SetSubscribedQualityStub
+	fakeReturns := fake.setSubscribedQualityReturns
+	fake.recordInvocation("SetSubscribedQuality", []interface{}{arg1, arg2})
+	fake.setSubscribedQualityMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeParticipant) SetSubscribedQualityCallCount() int {
+	fake.setSubscribedQualityMutex.RLock()
+	defer fake.setSubscribedQualityMutex.RUnlock()
+	return len(fake.setSubscribedQualityArgsForCall)
+}
+
+func (fake *FakeParticipant) SetSubscribedQualityCalls(stub func(string, livekit.VideoQuality) error) {
+	fake.setSubscribedQualityMutex.Lock()
+	defer fake.setSubscribedQualityMutex.Unlock()
+	fake.SetSubscribedQualityStub = stub
+}
+
+func (fake *FakeParticipant) SetSubscribedQualityArgsForCall(i int) (string, livekit.VideoQuality) {
+	fake.setSubscribedQualityMutex.RLock()
+	defer fake.setSubscribedQualityMutex.RUnlock()
+	argsForCall := fake.setSubscribedQualityArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeParticipant) SetSubscribedQualityReturns(result1 error) {
+	fake.setSubscribedQualityMutex.Lock()
+	defer fake.setSubscribedQualityMutex.Unlock()
+	fake.SetSubscribedQualityStub = nil
+	fake.setSubscribedQualityReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeParticipant) SetSubscribedQualityReturnsOnCall(i int, result1 error) {
+	fake.setSubscribedQualityMutex.Lock()
+	defer fake.setSubscribedQualityMutex.Unlock()
+	fake.SetSubscribedQualityStub = nil
+	if fake.setSubscribedQualityReturnsOnCall == nil {
+		fake.setSubscribedQualityReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.setSubscribedQualityReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeParticipant) SetTrackMuted(arg1 string, arg2 bool) {
 	fake.setTrackMutedMutex.Lock()
 	fake.setTrackMutedArgsForCall = append(fake.setTrackMutedArgsForCall, struct {
@@ -2185,6 +4612,59 @@ func (fake *FakeParticipant) SetTrackMutedArgsForCall(i int) (string, bool) {
 	return argsForCall.arg1, argsForCall.arg2
 }
 
+func (fake *FakeParticipant) SignalGeneration() uint32 {
+	fake.signalGenerationMutex.Lock()
+	ret, specificReturn := fake.signalGenerationReturnsOnCall[len(fake.signalGenerationArgsForCall)]
+	fake.signalGenerationArgsForCall = append(fake.signalGenerationArgsForCall, struct {
+	}{})
+	stub := fake.SignalGenerationStub
+	fakeReturns := fake.signalGenerationReturns
+	fake.recordInvocation("SignalGeneration", []interface{}{})
+	fake.signalGenerationMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeParticipant) SignalGenerationCallCount() int {
+	fake.signalGenerationMutex.RLock()
+	defer fake.signalGenerationMutex.RUnlock()
+	return len(fake.signalGenerationArgsForCall)
+}
+
+func (fake *FakeParticipant) SignalGenerationCalls(stub func() uint32) {
+	fake.signalGenerationMutex.Lock()
+	defer fake.signalGenerationMutex.Unlock()
+	fake.SignalGenerationStub = stub
+}
+
+func (fake *FakeParticipant) SignalGenerationReturns(result1 uint32) {
+	fake.signalGenerationMutex.Lock()
+	defer fake.signalGenerationMutex.Unlock()
+	fake.SignalGenerationStub = nil
+	fake.signalGenerationReturns = struct {
+		result1 uint32
+	}{result1}
+}
+
+func (fake *FakeParticipant) SignalGenerationReturnsOnCall(i int, result1 uint32) {
+	fake.signalGenerationMutex.Lock()
+	defer fake.signalGenerationMutex.Unlock()
+	fake.SignalGenerationStub = nil
+	if fake.signalGenerationReturnsOnCall == nil {
+		fake.signalGenerationReturnsOnCall = make(map[int]struct {
+			result1 uint32
+		})
+	}
+	fake.signalGenerationReturnsOnCall[i] = struct {
+		result1 uint32
+	}{result1}
+}
+
 func (fake *FakeParticipant) Start() {
 	fake.startMutex.Lock()
 	fake.startArgsForCall = append(fake.startArgsForCall, struct {
@@ -2262,6 +4742,121 @@ func (fake *FakeParticipant) StateReturnsOnCall(i int, result1 livekit.Participa
 	}{result1}
 }
 
+func (fake *FakeParticipant) SubscribeToTrack(arg1 types.Participant, arg2 string) error {
+	fake.subscribeToTrackMutex.Lock()
+	ret, specificReturn := fake.subscribeToTrackReturnsOnCall[len(fake.subscribeToTrackArgsForCall)]
+	fake.subscribeToTrackArgsForCall = append(fake.subscribeToTrackArgsForCall, struct {
+		arg1 types.Participant
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.SubscribeToTrackStub
+	fakeReturns := fake.subscribeToTrackReturns
+	fake.recordInvocation("SubscribeToTrack", []interface{}{arg1, arg2})
+	fake.subscribeToTrackMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeParticipant) SubscribeToTrackCallCount() int {
+	fake.subscribeToTrackMutex.RLock()
+	defer fake.subscribeToTrackMutex.RUnlock()
+	return len(fake.subscribeToTrackArgsForCall)
+}
+
+func (fake *FakeParticipant) SubscribeToTrackCalls(stub func(types.Participant, string) error) {
+	fake.subscribeToTrackMutex.Lock()
+	defer fake.subscribeToTrackMutex.Unlock()
+	fake.SubscribeToTrackStub = stub
+}
+
+func (fake *FakeParticipant) SubscribeToTrackArgsForCall(i int) (types.Participant, string) {
+	fake.subscribeToTrackMutex.RLock()
+	defer fake.subscribeToTrackMutex.RUnlock()
+	argsForCall := fake.subscribeToTrackArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeParticipant) SubscribeToTrackReturns(result1 error) {
+	fake.subscribeToTrackMutex.Lock()
+	defer fake.subscribeToTrackMutex.Unlock()
+	fake.SubscribeToTrackStub = nil
+	fake.subscribeToTrackReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeParticipant) SubscribeToTrackReturnsOnCall(i int, result1 error) {
+	fake.subscribeToTrackMutex.Lock()
+	defer fake.subscribeToTrackMutex.Unlock()
+	fake.SubscribeToTrackStub = nil
+	if fake.subscribeToTrackReturnsOnCall == nil {
+		fake.subscribeToTrackReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.subscribeToTrackReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeParticipant) SubscribedTrackIDs() []string {
+	fake.subscribedTrackIDsMutex.Lock()
+	ret, specificReturn := fake.subscribedTrackIDsReturnsOnCall[len(fake.subscribedTrackIDsArgsForCall)]
+	fake.subscribedTrackIDsArgsForCall = append(fake.subscribedTrackIDsArgsForCall, struct {
+	}{})
+	stub := fake.SubscribedTrackIDsStub
+	fakeReturns := fake.subscribedTrackIDsReturns
+	fake.recordInvocation("SubscribedTrackIDs", []interface{}{})
+	fake.subscribedTrackIDsMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeParticipant) SubscribedTrackIDsCallCount() int {
+	fake.subscribedTrackIDsMutex.RLock()
+	defer fake.subscribedTrackIDsMutex.RUnlock()
+	return len(fake.subscribedTrackIDsArgsForCall)
+}
+
+func (fake *FakeParticipant) SubscribedTrackIDsCalls(stub func() []string) {
+	fake.subscribedTrackIDsMutex.Lock()
+	defer fake.subscribedTrackIDsMutex.Unlock()
+	fake.SubscribedTrackIDsStub = stub
+}
+
+func (fake *FakeParticipant) SubscribedTrackIDsReturns(result1 []string) {
+	fake.subscribedTrackIDsMutex.Lock()
+	defer fake.subscribedTrackIDsMutex.Unlock()
+	fake.SubscribedTrackIDsStub = nil
+	fake.subscribedTrackIDsReturns = struct {
+		result1 []string
+	}{result1}
+}
+
+func (fake *FakeParticipant) SubscribedTrackIDsReturnsOnCall(i int, result1 []string) {
+	fake.subscribedTrackIDsMutex.Lock()
+	defer fake.subscribedTrackIDsMutex.Unlock()
+	fake.SubscribedTrackIDsStub = nil
+	if fake.subscribedTrackIDsReturnsOnCall == nil {
+		fake.subscribedTrackIDsReturnsOnCall = make(map[int]struct {
+			result1 []string
+		})
+	}
+	fake.subscribedTrackIDsReturnsOnCall[i] = struct {
+		result1 []string
+	}{result1}
+}
+
 func (fake *FakeParticipant) SubscriberMediaEngine() *webrtc.MediaEngine {
 	fake.subscriberMediaEngineMutex.Lock()
 	ret, specificReturn := fake.subscriberMediaEngineReturnsOnCall[len(fake.subscriberMediaEngineArgsForCall)]
@@ -2368,6 +4963,120 @@ func (fake *FakeParticipant) SubscriberPCReturnsOnCall(i int, result1 *webrtc.Pe
 	}{result1}
 }
 
+func (fake *FakeParticipant) SubscribesToMixedAudio() bool {
+	fake.subscribesToMixedAudioMutex.Lock()
+	ret, specificReturn := fake.subscribesToMixedAudioReturnsOnCall[len(fake.subscribesToMixedAudioArgsForCall)]
+	fake.subscribesToMixedAudioArgsForCall = append(fake.subscribesToMixedAudioArgsForCall, struct {
+	}{})
+	stub := fake.SubscribesToMixedAudioStub
+	fakeReturns := fake.subscribesToMixedAudioReturns
+	fake.recordInvocation("SubscribesToMixedAudio", []interface{}{})
+	fake.subscribesToMixedAudioMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeParticipant) SubscribesToMixedAudioCallCount() int {
+	fake.subscribesToMixedAudioMutex.RLock()
+	defer fake.subscribesToMixedAudioMutex.RUnlock()
+	return len(fake.subscribesToMixedAudioArgsForCall)
+}
+
+func (fake *FakeParticipant) SubscribesToMixedAudioCalls(stub func() bool) {
+	fake.subscribesToMixedAudioMutex.Lock()
+	defer fake.subscribesToMixedAudioMutex.Unlock()
+	fake.SubscribesToMixedAudioStub = stub
+}
+
+func (fake *FakeParticipant) SubscribesToMixedAudioReturns(result1 bool) {
+	fake.subscribesToMixedAudioMutex.Lock()
+	defer fake.subscribesToMixedAudioMutex.Unlock()
+	fake.SubscribesToMixedAudioStub = nil
+	fake.subscribesToMixedAudioReturns = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *FakeParticipant) SubscribesToMixedAudioReturnsOnCall(i int, result1 bool) {
+	fake.subscribesToMixedAudioMutex.Lock()
+	defer fake.subscribesToMixedAudioMutex.Unlock()
+	fake.SubscribesToMixedAudioStub = nil
+	if fake.subscribesToMixedAudioReturnsOnCall == nil {
+		fake.subscribesToMixedAudioReturnsOnCall = make(map[int]struct {
+			result1 bool
+		})
+	}
+	fake.subscribesToMixedAudioReturnsOnCall[i] = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *FakeParticipant) SupportsCodec(arg1 string) bool {
+	fake.supportsCodecMutex.Lock()
+	ret, specificReturn := fake.supportsCodecReturnsOnCall[len(fake.supportsCodecArgsForCall)]
+	fake.supportsCodecArgsForCall = append(fake.supportsCodecArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.SupportsCodecStub
+	fakeReturns := fake.supportsCodecReturns
+	fake.recordInvocation("SupportsCodec", []interface{}{arg1})
+	fake.supportsCodecMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeParticipant) SupportsCodecCallCount() int {
+	fake.supportsCodecMutex.RLock()
+	defer fake.supportsCodecMutex.RUnlock()
+	return len(fake.supportsCodecArgsForCall)
+}
+
+func (fake *FakeParticipant) SupportsCodecCalls(stub func(string) bool) {
+	fake.supportsCodecMutex.Lock()
+	defer fake.supportsCodecMutex.Unlock()
+	fake.SupportsCodecStub = stub
+}
+
+func (fake *FakeParticipant) SupportsCodecArgsForCall(i int) string {
+	fake.supportsCodecMutex.RLock()
+	defer fake.supportsCodecMutex.RUnlock()
+	argsForCall := fake.supportsCodecArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeParticipant) SupportsCodecReturns(result1 bool) {
+	fake.supportsCodecMutex.Lock()
+	defer fake.supportsCodecMutex.Unlock()
+	fake.SupportsCodecStub = nil
+	fake.supportsCodecReturns = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *FakeParticipant) SupportsCodecReturnsOnCall(i int, result1 bool) {
+	fake.supportsCodecMutex.Lock()
+	defer fake.supportsCodecMutex.Unlock()
+	fake.SupportsCodecStub = nil
+	if fake.supportsCodecReturnsOnCall == nil {
+		fake.supportsCodecReturnsOnCall = make(map[int]struct {
+			result1 bool
+		})
+	}
+	fake.supportsCodecReturnsOnCall[i] = struct {
+		result1 bool
+	}{result1}
+}
+
 func (fake *FakeParticipant) ToProto() *livekit.ParticipantInfo {
 	fake.toProtoMutex.Lock()
 	ret, specificReturn := fake.toProtoReturnsOnCall[len(fake.toProtoArgsForCall)]
@@ -2421,6 +5130,68 @@ func (fake *FakeParticipant) ToProtoReturnsOnCall(i int, result1 *livekit.Partic
 	}{result1}
 }
 
+func (fake *FakeParticipant) UnsubscribeFromTrack(arg1 types.Participant, arg2 string) error {
+	fake.unsubscribeFromTrackMutex.Lock()
+	ret, specificReturn := fake.unsubscribeFromTrackReturnsOnCall[len(fake.unsubscribeFromTrackArgsForCall)]
+	fake.unsubscribeFromTrackArgsForCall = append(fake.unsubscribeFromTrackArgsForCall, struct {
+		arg1 types.Participant
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.UnsubscribeFromTrackStub
+	fakeReturns := fake.unsubscribeFromTrackReturns
+	fake.recordInvocation("UnsubscribeFromTrack", []interface{}{arg1, arg2})
+	fake.unsubscribeFromTrackMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeParticipant) UnsubscribeFromTrackCallCount() int {
+	fake.unsubscribeFromTrackMutex.RLock()
+	defer fake.unsubscribeFromTrackMutex.RUnlock()
+	return len(fake.unsubscribeFromTrackArgsForCall)
+}
+
+func (fake *FakeParticipant) UnsubscribeFromTrackCalls(stub func(types.Participant, string) error) {
+	fake.unsubscribeFromTrackMutex.Lock()
+	defer fake.unsubscribeFromTrackMutex.Unlock()
+	fake.UnsubscribeFromTrackStub = stub
+}
+
+func (fake *FakeParticipant) UnsubscribeFromTrackArgsForCall(i int) (types.Participant, string) {
+	fake.unsubscribeFromTrackMutex.RLock()
+	defer fake.unsubscribeFromTrackMutex.RUnlock()
+	argsForCall := fake.unsubscribeFromTrackArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeParticipant) UnsubscribeFromTrackReturns(result1 error) {
+	fake.unsubscribeFromTrackMutex.Lock()
+	defer fake.unsubscribeFromTrackMutex.Unlock()
+	fake.UnsubscribeFromTrackStub = nil
+	fake.unsubscribeFromTrackReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeParticipant) UnsubscribeFromTrackReturnsOnCall(i int, result1 error) {
+	fake.unsubscribeFromTrackMutex.Lock()
+	defer fake.unsubscribeFromTrackMutex.Unlock()
+	fake.UnsubscribeFromTrackStub = nil
+	if fake.unsubscribeFromTrackReturnsOnCall == nil {
+		fake.unsubscribeFromTrackReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.unsubscribeFromTrackReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeParticipant) UpdateAfterActive() bool {
 	fake.updateAfterActiveMutex.Lock()
 	ret, specificReturn := fake.updateAfterActiveReturnsOnCall[len(fake.updateAfterActiveArgsForCall)]
@@ -2474,6 +5245,40 @@ func (fake *FakeParticipant) UpdateAfterActiveReturnsOnCall(i int, result1 bool)
 	}{result1}
 }
 
+func (fake *FakeParticipant) UpdateSubscribedTrackSettings(arg1 string, arg2 bool, arg3 livekit.VideoQuality) {
+	fake.updateSubscribedTrackSettingsMutex.Lock()
+	fake.updateSubscribedTrackSettingsArgsForCall = append(fake.updateSubscribedTrackSettingsArgsForCall, struct {
+		arg1 string
+		arg2 bool
+		arg3 livekit.VideoQuality
+	}{arg1, arg2, arg3})
+	stub := fake.UpdateSubscribedTrackSettingsStub
+	fake.recordInvocation("UpdateSubscribedTrackSettings", []interface{}{arg1, arg2, arg3})
+	fake.updateSubscribedTrackSettingsMutex.Unlock()
+	if stub != nil {
+		fake.UpdateSubscribedTrackSettingsStub(arg1, arg2, arg3)
+	}
+}
+
+func (fake *FakeParticipant) UpdateSubscribedTrackSettingsCallCount() int {
+	fake.updateSubscribedTrackSettingsMutex.RLock()
+	defer fake.updateSubscribedTrackSettingsMutex.RUnlock()
+	return len(fake.updateSubscribedTrackSettingsArgsForCall)
+}
+
+func (fake *FakeParticipant) UpdateSubscribedTrackSettingsCalls(stub func(string, bool, livekit.VideoQuality)) {
+	fake.updateSubscribedTrackSettingsMutex.Lock()
+	defer fake.updateSubscribedTrackSettingsMutex.Unlock()
+	fake.UpdateSubscribedTrackSettingsStub = stub
+}
+
+func (fake *FakeParticipant) UpdateSubscribedTrackSettingsArgsForCall(i int) (string, bool, livekit.VideoQuality) {
+	fake.updateSubscribedTrackSettingsMutex.RLock()
+	defer fake.updateSubscribedTrackSettingsMutex.RUnlock()
+	argsForCall := fake.updateSubscribedTrackSettingsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
 func (fake *FakeParticipant) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
@@ -2485,16 +5290,34 @@ func (fake *FakeParticipant) Invocations() map[string][][]interface{} {
 	defer fake.addSubscriberMutex.RUnlock()
 	fake.addTrackMutex.RLock()
 	defer fake.addTrackMutex.RUnlock()
+	fake.admitSubscriptionMutex.RLock()
+	defer fake.admitSubscriptionMutex.RUnlock()
+	fake.attachSinkMutex.RLock()
+	defer fake.attachSinkMutex.RUnlock()
 	fake.canPublishMutex.RLock()
 	defer fake.canPublishMutex.RUnlock()
+	fake.canPublishDataMutex.RLock()
+	defer fake.canPublishDataMutex.RUnlock()
 	fake.canSubscribeMutex.RLock()
 	defer fake.canSubscribeMutex.RUnlock()
 	fake.closeMutex.RLock()
 	defer fake.closeMutex.RUnlock()
 	fake.connectedAtMutex.RLock()
 	defer fake.connectedAtMutex.RUnlock()
+	fake.connectionQualityMutex.RLock()
+	defer fake.connectionQualityMutex.RUnlock()
 	fake.debugInfoMutex.RLock()
 	defer fake.debugInfoMutex.RUnlock()
+	fake.debugLoggingMutex.RLock()
+	defer fake.debugLoggingMutex.RUnlock()
+	fake.defaultVideoQualityMutex.RLock()
+	defer fake.defaultVideoQualityMutex.RUnlock()
+	fake.detachSinkMutex.RLock()
+	defer fake.detachSinkMutex.RUnlock()
+	fake.disconnectReasonMutex.RLock()
+	defer fake.disconnectReasonMutex.RUnlock()
+	fake.forwardedLayersMutex.RLock()
+	defer fake.forwardedLayersMutex.RUnlock()
 	fake.getAudioLevelMutex.RLock()
 	defer fake.getAudioLevelMutex.RUnlock()
 	fake.getPublishedTracksMutex.RLock()
@@ -2503,68 +5326,148 @@ func (fake *FakeParticipant) Invocations() map[string][][]interface{} {
 	defer fake.getResponseSinkMutex.RUnlock()
 	fake.getSubscribedTracksMutex.RLock()
 	defer fake.getSubscribedTracksMutex.RUnlock()
+	fake.getTrackStatsMutex.RLock()
+	defer fake.getTrackStatsMutex.RUnlock()
+	fake.getTransportStatsMutex.RLock()
+	defer fake.getTransportStatsMutex.RUnlock()
 	fake.handleAnswerMutex.RLock()
 	defer fake.handleAnswerMutex.RUnlock()
 	fake.handleOfferMutex.RLock()
 	defer fake.handleOfferMutex.RUnlock()
+	fake.handleSignalLostMutex.RLock()
+	defer fake.handleSignalLostMutex.RUnlock()
 	fake.iCERestartMutex.RLock()
 	defer fake.iCERestartMutex.RUnlock()
 	fake.iDMutex.RLock()
 	defer fake.iDMutex.RUnlock()
 	fake.identityMutex.RLock()
 	defer fake.identityMutex.RUnlock()
+	fake.isInterruptedMutex.RLock()
+	defer fake.isInterruptedMutex.RUnlock()
 	fake.isReadyMutex.RLock()
 	defer fake.isReadyMutex.RUnlock()
+	fake.isReconnectingMutex.RLock()
+	defer fake.isReconnectingMutex.RUnlock()
+	fake.isSpeakingMutex.RLock()
+	defer fake.isSpeakingMutex.RUnlock()
+	fake.mapSubscriberCaptureTimeMutex.RLock()
+	defer fake.mapSubscriberCaptureTimeMutex.RUnlock()
+	fake.mapSubscriberSSRCMutex.RLock()
+	defer fake.mapSubscriberSSRCMutex.RUnlock()
+	fake.maxDownloadBitrateMutex.RLock()
+	defer fake.maxDownloadBitrateMutex.RUnlock()
+	fake.maxUploadBitrateMutex.RLock()
+	defer fake.maxUploadBitrateMutex.RUnlock()
+	fake.metadataMutex.RLock()
+	defer fake.metadataMutex.RUnlock()
+	fake.mutePublishedTrackMutex.RLock()
+	defer fake.mutePublishedTrackMutex.RUnlock()
+	fake.nackWindowMutex.RLock()
+	defer fake.nackWindowMutex.RUnlock()
+	fake.nameMutex.RLock()
+	defer fake.nameMutex.RUnlock()
 	fake.negotiateMutex.RLock()
 	defer fake.negotiateMutex.RUnlock()
 	fake.onCloseMutex.RLock()
 	defer fake.onCloseMutex.RUnlock()
+	fake.onConnectionQualityChangeMutex.RLock()
+	defer fake.onConnectionQualityChangeMutex.RUnlock()
 	fake.onDataPacketMutex.RLock()
 	defer fake.onDataPacketMutex.RUnlock()
+	fake.onFirstMediaReceivedMutex.RLock()
+	defer fake.onFirstMediaReceivedMutex.RUnlock()
+	fake.onInterruptionChangeMutex.RLock()
+	defer fake.onInterruptionChangeMutex.RUnlock()
 	fake.onMetadataUpdateMutex.RLock()
 	defer fake.onMetadataUpdateMutex.RUnlock()
+	fake.onNameUpdateMutex.RLock()
+	defer fake.onNameUpdateMutex.RUnlock()
+	fake.onPublisherCongestedMutex.RLock()
+	defer fake.onPublisherCongestedMutex.RUnlock()
+	fake.onReconnectingChangeMutex.RLock()
+	defer fake.onReconnectingChangeMutex.RUnlock()
+	fake.onSpeakingMutex.RLock()
+	defer fake.onSpeakingMutex.RUnlock()
 	fake.onStateChangeMutex.RLock()
 	defer fake.onStateChangeMutex.RUnlock()
 	fake.onTrackPublishedMutex.RLock()
 	defer fake.onTrackPublishedMutex.RUnlock()
 	fake.onTrackUpdatedMutex.RLock()
 	defer fake.onTrackUpdatedMutex.RUnlock()
+	fake.pinnedVideoQualityMutex.RLock()
+	defer fake.pinnedVideoQualityMutex.RUnlock()
 	fake.protocolVersionMutex.RLock()
 	defer fake.protocolVersionMutex.RUnlock()
 	fake.rTCPChanMutex.RLock()
 	defer fake.rTCPChanMutex.RUnlock()
+	fake.reconnectGraceMutex.RLock()
+	defer fake.reconnectGraceMutex.RUnlock()
 	fake.removeSubscribedTrackMutex.RLock()
 	defer fake.removeSubscribedTrackMutex.RUnlock()
 	fake.removeSubscriberMutex.RLock()
 	defer fake.removeSubscriberMutex.RUnlock()
 	fake.sendActiveSpeakersMutex.RLock()
 	defer fake.sendActiveSpeakersMutex.RUnlock()
+	fake.sendDataMutex.RLock()
+	defer fake.sendDataMutex.RUnlock()
 	fake.sendDataPacketMutex.RLock()
 	defer fake.sendDataPacketMutex.RUnlock()
 	fake.sendJoinResponseMutex.RLock()
 	defer fake.sendJoinResponseMutex.RUnlock()
 	fake.sendParticipantUpdateMutex.RLock()
 	defer fake.sendParticipantUpdateMutex.RUnlock()
+	fake.setDeviceClassMutex.RLock()
+	defer fake.setDeviceClassMutex.RUnlock()
+	fake.setDisconnectReasonMutex.RLock()
+	defer fake.setDisconnectReasonMutex.RUnlock()
+	fake.setMaxDownloadBitrateMutex.RLock()
+	defer fake.setMaxDownloadBitrateMutex.RUnlock()
+	fake.setMaxUploadBitrateMutex.RLock()
+	defer fake.setMaxUploadBitrateMutex.RUnlock()
 	fake.setMetadataMutex.RLock()
 	defer fake.setMetadataMutex.RUnlock()
+	fake.setNackWindowMutex.RLock()
+	defer fake.setNackWindowMutex.RUnlock()
+	fake.setNameMutex.RLock()
+	defer fake.setNameMutex.RUnlock()
 	fake.setPermissionMutex.RLock()
 	defer fake.setPermissionMutex.RUnlock()
+	fake.setReconnectGraceMutex.RLock()
+	defer fake.setReconnectGraceMutex.RUnlock()
+	fake.setRecordingMutex.RLock()
+	defer fake.setRecordingMutex.RUnlock()
 	fake.setResponseSinkMutex.RLock()
 	defer fake.setResponseSinkMutex.RUnlock()
+	fake.setSubscribedQualityMutex.RLock()
+	defer fake.setSubscribedQualityMutex.RUnlock()
 	fake.setTrackMutedMutex.RLock()
 	defer fake.setTrackMutedMutex.RUnlock()
+	fake.signalGenerationMutex.RLock()
+	defer fake.signalGenerationMutex.RUnlock()
 	fake.startMutex.RLock()
 	defer fake.startMutex.RUnlock()
 	fake.stateMutex.RLock()
 	defer fake.stateMutex.RUnlock()
+	fake.subscribeToTrackMutex.RLock()
+	defer fake.subscribeToTrackMutex.RUnlock()
+	fake.subscribedTrackIDsMutex.RLock()
+	defer fake.subscribedTrackIDsMutex.RUnlock()
 	fake.subscriberMediaEngineMutex.RLock()
 	defer fake.subscriberMediaEngineMutex.RUnlock()
 	fake.subscriberPCMutex.RLock()
 	defer fake.subscriberPCMutex.RUnlock()
+	fake.subscribesToMixedAudioMutex.RLock()
+	defer fake.subscribesToMixedAudioMutex.RUnlock()
+	fake.supportsCodecMutex.RLock()
+	defer fake.supportsCodecMutex.RUnlock()
 	fake.toProtoMutex.RLock()
 	defer fake.toProtoMutex.RUnlock()
+	fake.unsubscribeFromTrackMutex.RLock()
+	defer fake.unsubscribeFromTrackMutex.RUnlock()
 	fake.updateAfterActiveMutex.RLock()
 	defer fake.updateAfterActiveMutex.RUnlock()
+	fake.updateSubscribedTrackSettingsMutex.RLock()
+	defer fake.updateSubscribedTrackSettingsMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
@@ -9,6 +9,17 @@ import (
 )
 
 type FakePublishedTrack struct {
+	AddSinkStub        func(types.TrackSink) error
+	addSinkMutex       sync.RWMutex
+	addSinkArgsForCall []struct {
+		arg1 types.TrackSink
+	}
+	addSinkReturns struct {
+		result1 error
+	}
+	addSinkReturnsOnCall map[int]struct {
+		result1 error
+	}
 	AddSubscriberStub        func(types.Participant) error
 	addSubscriberMutex       sync.RWMutex
 	addSubscriberArgsForCall []struct {
@@ -20,6 +31,39 @@ type FakePublishedTrack struct {
 	addSubscriberReturnsOnCall map[int]struct {
 		result1 error
 	}
+	AddUDPForwarderStub        func(string) error
+	addUDPForwarderMutex       sync.RWMutex
+	addUDPForwarderArgsForCall []struct {
+		arg1 string
+	}
+	addUDPForwarderReturns struct {
+		result1 error
+	}
+	addUDPForwarderReturnsOnCall map[int]struct {
+		result1 error
+	}
+	EstimateSubscriptionStub        func() (livekit.VideoQuality, uint64)
+	estimateSubscriptionMutex       sync.RWMutex
+	estimateSubscriptionArgsForCall []struct {
+	}
+	estimateSubscriptionReturns struct {
+		result1 livekit.VideoQuality
+		result2 uint64
+	}
+	estimateSubscriptionReturnsOnCall map[int]struct {
+		result1 livekit.VideoQuality
+		result2 uint64
+	}
+	GetBufferStatsStub        func() []types.BufferStats
+	getBufferStatsMutex       sync.RWMutex
+	getBufferStatsArgsForCall []struct {
+	}
+	getBufferStatsReturns struct {
+		result1 []types.BufferStats
+	}
+	getBufferStatsReturnsOnCall map[int]struct {
+		result1 []types.BufferStats
+	}
 	IDStub        func() string
 	iDMutex       sync.RWMutex
 	iDArgsForCall []struct {
@@ -40,6 +84,16 @@ type FakePublishedTrack struct {
 	isMutedReturnsOnCall map[int]struct {
 		result1 bool
 	}
+	IsSilentStub        func() bool
+	isSilentMutex       sync.RWMutex
+	isSilentArgsForCall []struct {
+	}
+	isSilentReturns struct {
+		result1 bool
+	}
+	isSilentReturnsOnCall map[int]struct {
+		result1 bool
+	}
 	IsSubscriberStub        func(string) bool
 	isSubscriberMutex       sync.RWMutex
 	isSubscriberArgsForCall []struct {
@@ -61,6 +115,16 @@ type FakePublishedTrack struct {
 	kindReturnsOnCall map[int]struct {
 		result1 livekit.TrackType
 	}
+	LayersStub        func() []types.VideoLayerInfo
+	layersMutex       sync.RWMutex
+	layersArgsForCall []struct {
+	}
+	layersReturns struct {
+		result1 []types.VideoLayerInfo
+	}
+	layersReturnsOnCall map[int]struct {
+		result1 []types.VideoLayerInfo
+	}
 	NameStub        func() string
 	nameMutex       sync.RWMutex
 	nameArgsForCall []struct {
@@ -80,11 +144,38 @@ type FakePublishedTrack struct {
 	removeAllSubscribersMutex       sync.RWMutex
 	removeAllSubscribersArgsForCall []struct {
 	}
+	RemoveSinkStub        func(types.TrackSink) error
+	removeSinkMutex       sync.RWMutex
+	removeSinkArgsForCall []struct {
+		arg1 types.TrackSink
+	}
+	removeSinkReturns struct {
+		result1 error
+	}
+	removeSinkReturnsOnCall map[int]struct {
+		result1 error
+	}
 	RemoveSubscriberStub        func(string)
 	removeSubscriberMutex       sync.RWMutex
 	removeSubscriberArgsForCall []struct {
 		arg1 string
 	}
+	RemoveUDPForwarderStub        func(string) error
+	removeUDPForwarderMutex       sync.RWMutex
+	removeUDPForwarderArgsForCall []struct {
+		arg1 string
+	}
+	removeUDPForwarderReturns struct {
+		result1 error
+	}
+	removeUDPForwarderReturnsOnCall map[int]struct {
+		result1 error
+	}
+	SetLayersStub        func([]types.VideoLayerInfo)
+	setLayersMutex       sync.RWMutex
+	setLayersArgsForCall []struct {
+		arg1 []types.VideoLayerInfo
+	}
 	SetMutedStub        func(bool)
 	setMutedMutex       sync.RWMutex
 	setMutedArgsForCall []struct {
@@ -95,6 +186,16 @@ type FakePublishedTrack struct {
 	setSimulcastLayersArgsForCall []struct {
 		arg1 []livekit.VideoQuality
 	}
+	SourceStub        func() types.TrackSource
+	sourceMutex       sync.RWMutex
+	sourceArgsForCall []struct {
+	}
+	sourceReturns struct {
+		result1 types.TrackSource
+	}
+	sourceReturnsOnCall map[int]struct {
+		result1 types.TrackSource
+	}
 	StartStub        func()
 	startMutex       sync.RWMutex
 	startArgsForCall []struct {
@@ -113,6 +214,67 @@ type FakePublishedTrack struct {
 	invocationsMutex sync.RWMutex
 }
 
+func (fake *FakePublishedTrack) AddSink(arg1 types.TrackSink) error {
+	fake.addSinkMutex.Lock()
+	ret, specificReturn := fake.addSinkReturnsOnCall[len(fake.addSinkArgsForCall)]
+	fake.addSinkArgsForCall = append(fake.addSinkArgsForCall, struct {
+		arg1 types.TrackSink
+	}{arg1})
+	stub := fake.AddSinkStub
+	fakeReturns := fake.addSinkReturns
+	fake.recordInvocation("AddSink", []interface{}{arg1})
+	fake.addSinkMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakePublishedTrack) AddSinkCallCount() int {
+	fake.addSinkMutex.RLock()
+	defer fake.addSinkMutex.RUnlock()
+	return len(fake.addSinkArgsForCall)
+}
+
+func (fake *FakePublishedTrack) AddSinkCalls(stub func(types.TrackSink) error) {
+	fake.addSinkMutex.Lock()
+	defer fake.addSinkMutex.Unlock()
+	fake.AddSinkStub = stub
+}
+
+func (fake *FakePublishedTrack) AddSinkArgsForCall(i int) types.TrackSink {
+	fake.addSinkMutex.RLock()
+	defer fake.addSinkMutex.RUnlock()
+	argsForCall := fake.addSinkArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakePublishedTrack) AddSinkReturns(result1 error) {
+	fake.addSinkMutex.Lock()
+	defer fake.addSinkMutex.Unlock()
+	fake.AddSinkStub = nil
+	fake.addSinkReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakePublishedTrack) AddSinkReturnsOnCall(i int, result1 error) {
+	fake.addSinkMutex.Lock()
+	defer fake.addSinkMutex.Unlock()
+	fake.AddSinkStub = nil
+	if fake.addSinkReturnsOnCall == nil {
+		fake.addSinkReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.addSinkReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakePublishedTrack) AddSubscriber(arg1 types.Participant) error {
 	fake.addSubscriberMutex.Lock()
 	ret, specificReturn := fake.addSubscriberReturnsOnCall[len(fake.addSubscriberArgsForCall)]
@@ -174,6 +336,176 @@ func (fake *FakePublishedTrack) AddSubscriberReturnsOnCall(i int, result1 error)
 	}{result1}
 }
 
+func (fake *FakePublishedTrack) AddUDPForwarder(arg1 string) error {
+	fake.addUDPForwarderMutex.Lock()
+	ret, specificReturn := fake.addUDPForwarderReturnsOnCall[len(fake.addUDPForwarderArgsForCall)]
+	fake.addUDPForwarderArgsForCall = append(fake.addUDPForwarderArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.AddUDPForwarderStub
+	fakeReturns := fake.addUDPForwarderReturns
+	fake.recordInvocation("AddUDPForwarder", []interface{}{arg1})
+	fake.addUDPForwarderMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakePublishedTrack) AddUDPForwarderCallCount() int {
+	fake.addUDPForwarderMutex.RLock()
+	defer fake.addUDPForwarderMutex.RUnlock()
+	return len(fake.addUDPForwarderArgsForCall)
+}
+
+func (fake *FakePublishedTrack) AddUDPForwarderCalls(stub func(string) error) {
+	fake.addUDPForwarderMutex.Lock()
+	defer fake.addUDPForwarderMutex.Unlock()
+	fake.AddUDPForwarderStub = stub
+}
+
+func (fake *FakePublishedTrack) AddUDPForwarderArgsForCall(i int) string {
+	fake.addUDPForwarderMutex.RLock()
+	defer fake.addUDPForwarderMutex.RUnlock()
+	argsForCall := fake.addUDPForwarderArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakePublishedTrack) AddUDPForwarderReturns(result1 error) {
+	fake.addUDPForwarderMutex.Lock()
+	defer fake.addUDPForwarderMutex.Unlock()
+	fake.AddUDPForwarderStub = nil
+	fake.addUDPForwarderReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakePublishedTrack) AddUDPForwarderReturnsOnCall(i int, result1 error) {
+	fake.addUDPForwarderMutex.Lock()
+	defer fake.addUDPForwarderMutex.Unlock()
+	fake.AddUDPForwarderStub = nil
+	if fake.addUDPForwarderReturnsOnCall == nil {
+		fake.addUDPForwarderReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.addUDPForwarderReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakePublishedTrack) EstimateSubscription() (livekit.VideoQuality, uint64) {
+	fake.estimateSubscriptionMutex.Lock()
+	ret, specificReturn := fake.estimateSubscriptionReturnsOnCall[len(fake.estimateSubscriptionArgsForCall)]
+	fake.estimateSubscriptionArgsForCall = append(fake.estimateSubscriptionArgsForCall, struct {
+	}{})
+	stub := fake.EstimateSubscriptionStub
+	fakeReturns := fake.estimateSubscriptionReturns
+	fake.recordInvocation("EstimateSubscription", []interface{}{})
+	fake.estimateSubscriptionMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakePublishedTrack) EstimateSubscriptionCallCount() int {
+	fake.estimateSubscriptionMutex.RLock()
+	defer fake.estimateSubscriptionMutex.RUnlock()
+	return len(fake.estimateSubscriptionArgsForCall)
+}
+
+func (fake *FakePublishedTrack) EstimateSubscriptionCalls(stub func() (livekit.VideoQuality, uint64)) {
+	fake.estimateSubscriptionMutex.Lock()
+	defer fake.estimateSubscriptionMutex.Unlock()
+	fake.EstimateSubscriptionStub = stub
+}
+
+func (fake *FakePublishedTrack) EstimateSubscriptionReturns(result1 livekit.VideoQuality, result2 uint64) {
+	fake.estimateSubscriptionMutex.Lock()
+	defer fake.estimateSubscriptionMutex.Unlock()
+	fake.EstimateSubscriptionStub = nil
+	fake.estimateSubscriptionReturns = struct {
+		result1 livekit.VideoQuality
+		result2 uint64
+	}{result1, result2}
+}
+
+func (fake *FakePublishedTrack) EstimateSubscriptionReturnsOnCall(i int, result1 livekit.VideoQuality, result2 uint64) {
+	fake.estimateSubscriptionMutex.Lock()
+	defer fake.estimateSubscriptionMutex.Unlock()
+	fake.EstimateSubscriptionStub = nil
+	if fake.estimateSubscriptionReturnsOnCall == nil {
+		fake.estimateSubscriptionReturnsOnCall = make(map[int]struct {
+			result1 livekit.VideoQuality
+			result2 uint64
+		})
+	}
+	fake.estimateSubscriptionReturnsOnCall[i] = struct {
+		result1 livekit.VideoQuality
+		result2 uint64
+	}{result1, result2}
+}
+
+func (fake *FakePublishedTrack) GetBufferStats() []types.BufferStats {
+	fake.getBufferStatsMutex.Lock()
+	ret, specificReturn := fake.getBufferStatsReturnsOnCall[len(fake.getBufferStatsArgsForCall)]
+	fake.getBufferStatsArgsForCall = append(fake.getBufferStatsArgsForCall, struct {
+	}{})
+	stub := fake.GetBufferStatsStub
+	fakeReturns := fake.getBufferStatsReturns
+	fake.recordInvocation("GetBufferStats", []interface{}{})
+	fake.getBufferStatsMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakePublishedTrack) GetBufferStatsCallCount() int {
+	fake.getBufferStatsMutex.RLock()
+	defer fake.getBufferStatsMutex.RUnlock()
+	return len(fake.getBufferStatsArgsForCall)
+}
+
+func (fake *FakePublishedTrack) GetBufferStatsCalls(stub func() []types.BufferStats) {
+	fake.getBufferStatsMutex.Lock()
+	defer fake.getBufferStatsMutex.Unlock()
+	fake.GetBufferStatsStub = stub
+}
+
+func (fake *FakePublishedTrack) GetBufferStatsReturns(result1 []types.BufferStats) {
+	fake.getBufferStatsMutex.Lock()
+	defer fake.getBufferStatsMutex.Unlock()
+	fake.GetBufferStatsStub = nil
+	fake.getBufferStatsReturns = struct {
+		result1 []types.BufferStats
+	}{result1}
+}
+
+func (fake *FakePublishedTrack) GetBufferStatsReturnsOnCall(i int, result1 []types.BufferStats) {
+	fake.getBufferStatsMutex.Lock()
+	defer fake.getBufferStatsMutex.Unlock()
+	fake.GetBufferStatsStub = nil
+	if fake.getBufferStatsReturnsOnCall == nil {
+		fake.getBufferStatsReturnsOnCall = make(map[int]struct {
+			result1 []types.BufferStats
+		})
+	}
+	fake.getBufferStatsReturnsOnCall[i] = struct {
+		result1 []types.BufferStats
+	}{result1}
+}
+
 func (fake *FakePublishedTrack) ID() string {
 	fake.iDMutex.Lock()
 	ret, specificReturn := fake.iDReturnsOnCall[len(fake.iDArgsForCall)]
@@ -280,6 +612,59 @@ func (fake *FakePublishedTrack) IsMutedReturnsOnCall(i int, result1 bool) {
 	}{result1}
 }
 
+func (fake *FakePublishedTrack) IsSilent() bool {
+	fake.isSilentMutex.Lock()
+	ret, specificReturn := fake.isSilentReturnsOnCall[len(fake.isSilentArgsForCall)]
+	fake.isSilentArgsForCall = append(fake.isSilentArgsForCall, struct {
+	}{})
+	stub := fake.IsSilentStub
+	fakeReturns := fake.isSilentReturns
+	fake.recordInvocation("IsSilent", []interface{}{})
+	fake.isSilentMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakePublishedTrack) IsSilentCallCount() int {
+	fake.isSilentMutex.RLock()
+	defer fake.isSilentMutex.RUnlock()
+	return len(fake.isSilentArgsForCall)
+}
+
+func (fake *FakePublishedTrack) IsSilentCalls(stub func() bool) {
+	fake.isSilentMutex.Lock()
+	defer fake.isSilentMutex.Unlock()
+	fake.IsSilentStub = stub
+}
+
+func (fake *FakePublishedTrack) IsSilentReturns(result1 bool) {
+	fake.isSilentMutex.Lock()
+	defer fake.isSilentMutex.Unlock()
+	fake.IsSilentStub = nil
+	fake.isSilentReturns = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *FakePublishedTrack) IsSilentReturnsOnCall(i int, result1 bool) {
+	fake.isSilentMutex.Lock()
+	defer fake.isSilentMutex.Unlock()
+	fake.IsSilentStub = nil
+	if fake.isSilentReturnsOnCall == nil {
+		fake.isSilentReturnsOnCall = make(map[int]struct {
+			result1 bool
+		})
+	}
+	fake.isSilentReturnsOnCall[i] = struct {
+		result1 bool
+	}{result1}
+}
+
 func (fake *FakePublishedTrack) IsSubscriber(arg1 string) bool {
 	fake.isSubscriberMutex.Lock()
 	ret, specificReturn := fake.isSubscriberReturnsOnCall[len(fake.isSubscriberArgsForCall)]
@@ -394,6 +779,59 @@ func (fake *FakePublishedTrack) KindReturnsOnCall(i int, result1 livekit.TrackTy
 	}{result1}
 }
 
+func (fake *FakePublishedTrack) Layers() []types.VideoLayerInfo {
+	fake.layersMutex.Lock()
+	ret, specificReturn := fake.layersReturnsOnCall[len(fake.layersArgsForCall)]
+	fake.layersArgsForCall = append(fake.layersArgsForCall, struct {
+	}{})
+	stub := fake.LayersStub
+	fakeReturns := fake.layersReturns
+	fake.recordInvocation("Layers", []interface{}{})
+	fake.layersMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakePublishedTrack) LayersCallCount() int {
+	fake.layersMutex.RLock()
+	defer fake.layersMutex.RUnlock()
+	return len(fake.layersArgsForCall)
+}
+
+func (fake *FakePublishedTrack) LayersCalls(stub func() []types.VideoLayerInfo) {
+	fake.layersMutex.Lock()
+	defer fake.layersMutex.Unlock()
+	fake.LayersStub = stub
+}
+
+func (fake *FakePublishedTrack) LayersReturns(result1 []types.VideoLayerInfo) {
+	fake.layersMutex.Lock()
+	defer fake.layersMutex.Unlock()
+	fake.LayersStub = nil
+	fake.layersReturns = struct {
+		result1 []types.VideoLayerInfo
+	}{result1}
+}
+
+func (fake *FakePublishedTrack) LayersReturnsOnCall(i int, result1 []types.VideoLayerInfo) {
+	fake.layersMutex.Lock()
+	defer fake.layersMutex.Unlock()
+	fake.LayersStub = nil
+	if fake.layersReturnsOnCall == nil {
+		fake.layersReturnsOnCall = make(map[int]struct {
+			result1 []types.VideoLayerInfo
+		})
+	}
+	fake.layersReturnsOnCall[i] = struct {
+		result1 []types.VideoLayerInfo
+	}{result1}
+}
+
 func (fake *FakePublishedTrack) Name() string {
 	fake.nameMutex.Lock()
 	ret, specificReturn := fake.nameReturnsOnCall[len(fake.nameArgsForCall)]
@@ -503,6 +941,67 @@ func (fake *FakePublishedTrack) RemoveAllSubscribersCalls(stub func()) {
 	fake.RemoveAllSubscribersStub = stub
 }
 
+func (fake *FakePublishedTrack) RemoveSink(arg1 types.TrackSink) error {
+	fake.removeSinkMutex.Lock()
+	ret, specificReturn := fake.removeSinkReturnsOnCall[len(fake.removeSinkArgsForCall)]
+	fake.removeSinkArgsForCall = append(fake.removeSinkArgsForCall, struct {
+		arg1 types.TrackSink
+	}{arg1})
+	stub := fake.RemoveSinkStub
+	fakeReturns := fake.removeSinkReturns
+	fake.recordInvocation("RemoveSink", []interface{}{arg1})
+	fake.removeSinkMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakePublishedTrack) RemoveSinkCallCount() int {
+	fake.removeSinkMutex.RLock()
+	defer fake.removeSinkMutex.RUnlock()
+	return len(fake.removeSinkArgsForCall)
+}
+
+func (fake *FakePublishedTrack) RemoveSinkCalls(stub func(types.TrackSink) error) {
+	fake.removeSinkMutex.Lock()
+	defer fake.removeSinkMutex.Unlock()
+	fake.RemoveSinkStub = stub
+}
+
+func (fake *FakePublishedTrack) RemoveSinkArgsForCall(i int) types.TrackSink {
+	fake.removeSinkMutex.RLock()
+	defer fake.removeSinkMutex.RUnlock()
+	argsForCall := fake.removeSinkArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakePublishedTrack) RemoveSinkReturns(result1 error) {
+	fake.removeSinkMutex.Lock()
+	defer fake.removeSinkMutex.Unlock()
+	fake.RemoveSinkStub = nil
+	fake.removeSinkReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakePublishedTrack) RemoveSinkReturnsOnCall(i int, result1 error) {
+	fake.removeSinkMutex.Lock()
+	defer fake.removeSinkMutex.Unlock()
+	fake.RemoveSinkStub = nil
+	if fake.removeSinkReturnsOnCall == nil {
+		fake.removeSinkReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.removeSinkReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakePublishedTrack) RemoveSubscriber(arg1 string) {
 	fake.removeSubscriberMutex.Lock()
 	fake.removeSubscriberArgsForCall = append(fake.removeSubscriberArgsForCall, struct {
@@ -535,6 +1034,104 @@ func (fake *FakePublishedTrack) RemoveSubscriberArgsForCall(i int) string {
 	return argsForCall.arg1
 }
 
+func (fake *FakePublishedTrack) RemoveUDPForwarder(arg1 string) error {
+	fake.removeUDPForwarderMutex.Lock()
+	ret, specificReturn := fake.removeUDPForwarderReturnsOnCall[len(fake.removeUDPForwarderArgsForCall)]
+	fake.removeUDPForwarderArgsForCall = append(fake.removeUDPForwarderArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.RemoveUDPForwarderStub
+	fakeReturns := fake.removeUDPForwarderReturns
+	fake.recordInvocation("RemoveUDPForwarder", []interface{}{arg1})
+	fake.removeUDPForwarderMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakePublishedTrack) RemoveUDPForwarderCallCount() int {
+	fake.removeUDPForwarderMutex.RLock()
+	defer fake.removeUDPForwarderMutex.RUnlock()
+	return len(fake.removeUDPForwarderArgsForCall)
+}
+
+func (fake *FakePublishedTrack) RemoveUDPForwarderCalls(stub func(string) error) {
+	fake.removeUDPForwarderMutex.Lock()
+	defer fake.removeUDPForwarderMutex.Unlock()
+	fake.RemoveUDPForwarderStub = stub
+}
+
+func (fake *FakePublishedTrack) RemoveUDPForwarderArgsForCall(i int) string {
+	fake.removeUDPForwarderMutex.RLock()
+	defer fake.removeUDPForwarderMutex.RUnlock()
+	argsForCall := fake.removeUDPForwarderArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakePublishedTrack) RemoveUDPForwarderReturns(result1 error) {
+	fake.removeUDPForwarderMutex.Lock()
+	defer fake.removeUDPForwarderMutex.Unlock()
+	fake.RemoveUDPForwarderStub = nil
+	fake.removeUDPForwarderReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakePublishedTrack) RemoveUDPForwarderReturnsOnCall(i int, result1 error) {
+	fake.removeUDPForwarderMutex.Lock()
+	defer fake.removeUDPForwarderMutex.Unlock()
+	fake.RemoveUDPForwarderStub = nil
+	if fake.removeUDPForwarderReturnsOnCall == nil {
+		fake.removeUDPForwarderReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.removeUDPForwarderReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakePublishedTrack) SetLayers(arg1 []types.VideoLayerInfo) {
+	var arg1Copy []types.VideoLayerInfo
+	if arg1 != nil {
+		arg1Copy = make([]types.VideoLayerInfo, len(arg1))
+		copy(arg1Copy, arg1)
+	}
+	fake.setLayersMutex.Lock()
+	fake.setLayersArgsForCall = append(fake.setLayersArgsForCall, struct {
+		arg1 []types.VideoLayerInfo
+	}{arg1Copy})
+	stub := fake.SetLayersStub
+	fake.recordInvocation("SetLayers", []interface{}{arg1Copy})
+	fake.setLayersMutex.Unlock()
+	if stub != nil {
+		fake.SetLayersStub(arg1)
+	}
+}
+
+func (fake *FakePublishedTrack) SetLayersCallCount() int {
+	fake.setLayersMutex.RLock()
+	defer fake.setLayersMutex.RUnlock()
+	return len(fake.setLayersArgsForCall)
+}
+
+func (fake *FakePublishedTrack) SetLayersCalls(stub func([]types.VideoLayerInfo)) {
+	fake.setLayersMutex.Lock()
+	defer fake.setLayersMutex.Unlock()
+	fake.SetLayersStub = stub
+}
+
+func (fake *FakePublishedTrack) SetLayersArgsForCall(i int) []types.VideoLayerInfo {
+	fake.setLayersMutex.RLock()
+	defer fake.setLayersMutex.RUnlock()
+	argsForCall := fake.setLayersArgsForCall[i]
+	return argsForCall.arg1
+}
+
 func (fake *FakePublishedTrack) SetMuted(arg1 bool) {
 	fake.setMutedMutex.Lock()
 	fake.setMutedArgsForCall = append(fake.setMutedArgsForCall, struct {
@@ -604,6 +1201,59 @@ func (fake *FakePublishedTrack) SetSimulcastLayersArgsForCall(i int) []livekit.V
 	return argsForCall.arg1
 }
 
+func (fake *FakePublishedTrack) Source() types.TrackSource {
+	fake.sourceMutex.Lock()
+	ret, specificReturn := fake.sourceReturnsOnCall[len(fake.sourceArgsForCall)]
+	fake.sourceArgsForCall = append(fake.sourceArgsForCall, struct {
+	}{})
+	stub := fake.SourceStub
+	fakeReturns := fake.sourceReturns
+	fake.recordInvocation("Source", []interface{}{})
+	fake.sourceMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakePublishedTrack) SourceCallCount() int {
+	fake.sourceMutex.RLock()
+	defer fake.sourceMutex.RUnlock()
+	return len(fake.sourceArgsForCall)
+}
+
+func (fake *FakePublishedTrack) SourceCalls(stub func() types.TrackSource) {
+	fake.sourceMutex.Lock()
+	defer fake.sourceMutex.Unlock()
+	fake.SourceStub = stub
+}
+
+func (fake *FakePublishedTrack) SourceReturns(result1 types.TrackSource) {
+	fake.sourceMutex.Lock()
+	defer fake.sourceMutex.Unlock()
+	fake.SourceStub = nil
+	fake.sourceReturns = struct {
+		result1 types.TrackSource
+	}{result1}
+}
+
+func (fake *FakePublishedTrack) SourceReturnsOnCall(i int, result1 types.TrackSource) {
+	fake.sourceMutex.Lock()
+	defer fake.sourceMutex.Unlock()
+	fake.SourceStub = nil
+	if fake.sourceReturnsOnCall == nil {
+		fake.sourceReturnsOnCall = make(map[int]struct {
+			result1 types.TrackSource
+		})
+	}
+	fake.sourceReturnsOnCall[i] = struct {
+		result1 types.TrackSource
+	}{result1}
+}
+
 func (fake *FakePublishedTrack) Start() {
 	fake.startMutex.Lock()
 	fake.startArgsForCall = append(fake.startArgsForCall, struct {
@@ -684,28 +1334,48 @@ func (fake *FakePublishedTrack) ToProtoReturnsOnCall(i int, result1 *livekit.Tra
 func (fake *FakePublishedTrack) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
+	fake.addSinkMutex.RLock()
+	defer fake.addSinkMutex.RUnlock()
 	fake.addSubscriberMutex.RLock()
 	defer fake.addSubscriberMutex.RUnlock()
+	fake.addUDPForwarderMutex.RLock()
+	defer fake.addUDPForwarderMutex.RUnlock()
+	fake.estimateSubscriptionMutex.RLock()
+	defer fake.estimateSubscriptionMutex.RUnlock()
+	fake.getBufferStatsMutex.RLock()
+	defer fake.getBufferStatsMutex.RUnlock()
 	fake.iDMutex.RLock()
 	defer fake.iDMutex.RUnlock()
 	fake.isMutedMutex.RLock()
 	defer fake.isMutedMutex.RUnlock()
+	fake.isSilentMutex.RLock()
+	defer fake.isSilentMutex.RUnlock()
 	fake.isSubscriberMutex.RLock()
 	defer fake.isSubscriberMutex.RUnlock()
 	fake.kindMutex.RLock()
 	defer fake.kindMutex.RUnlock()
+	fake.layersMutex.RLock()
+	defer fake.layersMutex.RUnlock()
 	fake.nameMutex.RLock()
 	defer fake.nameMutex.RUnlock()
 	fake.onCloseMutex.RLock()
 	defer fake.onCloseMutex.RUnlock()
 	fake.removeAllSubscribersMutex.RLock()
 	defer fake.removeAllSubscribersMutex.RUnlock()
+	fake.removeSinkMutex.RLock()
+	defer fake.removeSinkMutex.RUnlock()
 	fake.removeSubscriberMutex.RLock()
 	defer fake.removeSubscriberMutex.RUnlock()
+	fake.removeUDPForwarderMutex.RLock()
+	defer fake.removeUDPForwarderMutex.RUnlock()
+	fake.setLayersMutex.RLock()
+	defer fake.setLayersMutex.RUnlock()
 	fake.setMutedMutex.RLock()
 	defer fake.setMutedMutex.RUnlock()
 	fake.setSimulcastLayersMutex.RLock()
 	defer fake.setSimulcastLayersMutex.RUnlock()
+	fake.sourceMutex.RLock()
+	defer fake.sourceMutex.RUnlock()
 	fake.startMutex.RLock()
 	defer fake.startMutex.RUnlock()
 	fake.toProtoMutex.RLock()
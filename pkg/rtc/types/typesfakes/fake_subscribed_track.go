@@ -7,9 +7,20 @@ import (
 	"github.com/livekit/livekit-server/pkg/rtc/types"
 	livekit "github.com/livekit/livekit-server/proto"
 	"github.com/pion/ion-sfu/pkg/sfu"
+	webrtc "github.com/pion/webrtc/v3"
 )
 
 type FakeSubscribedTrack struct {
+	CodecStub        func() webrtc.RTPCodecParameters
+	codecMutex       sync.RWMutex
+	codecArgsForCall []struct {
+	}
+	codecReturns struct {
+		result1 webrtc.RTPCodecParameters
+	}
+	codecReturnsOnCall map[int]struct {
+		result1 webrtc.RTPCodecParameters
+	}
 	DownTrackStub        func() *sfu.DownTrack
 	downTrackMutex       sync.RWMutex
 	downTrackArgsForCall []struct {
@@ -20,6 +31,16 @@ type FakeSubscribedTrack struct {
 	downTrackReturnsOnCall map[int]struct {
 		result1 *sfu.DownTrack
 	}
+	ForwardedLayerStub        func() int32
+	forwardedLayerMutex       sync.RWMutex
+	forwardedLayerArgsForCall []struct {
+	}
+	forwardedLayerReturns struct {
+		result1 int32
+	}
+	forwardedLayerReturnsOnCall map[int]struct {
+		result1 int32
+	}
 	IDStub        func() string
 	iDMutex       sync.RWMutex
 	iDArgsForCall []struct {
@@ -30,6 +51,16 @@ type FakeSubscribedTrack struct {
 	iDReturnsOnCall map[int]struct {
 		result1 string
 	}
+	IsBoundStub        func() bool
+	isBoundMutex       sync.RWMutex
+	isBoundArgsForCall []struct {
+	}
+	isBoundReturns struct {
+		result1 bool
+	}
+	isBoundReturnsOnCall map[int]struct {
+		result1 bool
+	}
 	IsMutedStub        func() bool
 	isMutedMutex       sync.RWMutex
 	isMutedArgsForCall []struct {
@@ -40,6 +71,36 @@ type FakeSubscribedTrack struct {
 	isMutedReturnsOnCall map[int]struct {
 		result1 bool
 	}
+	IsPausedStub        func() bool
+	isPausedMutex       sync.RWMutex
+	isPausedArgsForCall []struct {
+	}
+	isPausedReturns struct {
+		result1 bool
+	}
+	isPausedReturnsOnCall map[int]struct {
+		result1 bool
+	}
+	IsPublisherMutedStub        func() bool
+	isPublisherMutedMutex       sync.RWMutex
+	isPublisherMutedArgsForCall []struct {
+	}
+	isPublisherMutedReturns struct {
+		result1 bool
+	}
+	isPublisherMutedReturnsOnCall map[int]struct {
+		result1 bool
+	}
+	OnCodecChangeStub        func(func(codec webrtc.RTPCodecParameters))
+	onCodecChangeMutex       sync.RWMutex
+	onCodecChangeArgsForCall []struct {
+		arg1 func(codec webrtc.RTPCodecParameters)
+	}
+	SetPausedStub        func(bool)
+	setPausedMutex       sync.RWMutex
+	setPausedArgsForCall []struct {
+		arg1 bool
+	}
 	SetPublisherMutedStub        func(bool)
 	setPublisherMutedMutex       sync.RWMutex
 	setPublisherMutedArgsForCall []struct {
@@ -55,6 +116,59 @@ type FakeSubscribedTrack struct {
 	invocationsMutex sync.RWMutex
 }
 
+func (fake *FakeSubscribedTrack) Codec() webrtc.RTPCodecParameters {
+	fake.codecMutex.Lock()
+	ret, specificReturn := fake.codecReturnsOnCall[len(fake.codecArgsForCall)]
+	fake.codecArgsForCall = append(fake.codecArgsForCall, struct {
+	}{})
+	stub := fake.CodecStub
+	fakeReturns := fake.codecReturns
+	fake.recordInvocation("Codec", []interface{}{})
+	fake.codecMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeSubscribedTrack) CodecCallCount() int {
+	fake.codecMutex.RLock()
+	defer fake.codecMutex.RUnlock()
+	return len(fake.codecArgsForCall)
+}
+
+func (fake *FakeSubscribedTrack) CodecCalls(stub func() webrtc.RTPCodecParameters) {
+	fake.codecMutex.Lock()
+	defer fake.codecMutex.Unlock()
+	fake.CodecStub = stub
+}
+
+func (fake *FakeSubscribedTrack) CodecReturns(result1 webrtc.RTPCodecParameters) {
+	fake.codecMutex.Lock()
+	defer fake.codecMutex.Unlock()
+	fake.CodecStub = nil
+	fake.codecReturns = struct {
+		result1 webrtc.RTPCodecParameters
+	}{result1}
+}
+
+func (fake *FakeSubscribedTrack) CodecReturnsOnCall(i int, result1 webrtc.RTPCodecParameters) {
+	fake.codecMutex.Lock()
+	defer fake.codecMutex.Unlock()
+	fake.CodecStub = nil
+	if fake.codecReturnsOnCall == nil {
+		fake.codecReturnsOnCall = make(map[int]struct {
+			result1 webrtc.RTPCodecParameters
+		})
+	}
+	fake.codecReturnsOnCall[i] = struct {
+		result1 webrtc.RTPCodecParameters
+	}{result1}
+}
+
 func (fake *FakeSubscribedTrack) DownTrack() *sfu.DownTrack {
 	fake.downTrackMutex.Lock()
 	ret, specificReturn := fake.downTrackReturnsOnCall[len(fake.downTrackArgsForCall)]
@@ -108,6 +222,59 @@ func (fake *FakeSubscribedTrack) DownTrackReturnsOnCall(i int, result1 *sfu.Down
 	}{result1}
 }
 
+func (fake *FakeSubscribedTrack) ForwardedLayer() int32 {
+	fake.forwardedLayerMutex.Lock()
+	ret, specificReturn := fake.forwardedLayerReturnsOnCall[len(fake.forwardedLayerArgsForCall)]
+	fake.forwardedLayerArgsForCall = append(fake.forwardedLayerArgsForCall, struct {
+	}{})
+	stub := fake.ForwardedLayerStub
+	fakeReturns := fake.forwardedLayerReturns
+	fake.recordInvocation("ForwardedLayer", []interface{}{})
+	fake.forwardedLayerMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeSubscribedTrack) ForwardedLayerCallCount() int {
+	fake.forwardedLayerMutex.RLock()
+	defer fake.forwardedLayerMutex.RUnlock()
+	return len(fake.forwardedLayerArgsForCall)
+}
+
+func (fake *FakeSubscribedTrack) ForwardedLayerCalls(stub func() int32) {
+	fake.forwardedLayerMutex.Lock()
+	defer fake.forwardedLayerMutex.Unlock()
+	fake.ForwardedLayerStub = stub
+}
+
+func (fake *FakeSubscribedTrack) ForwardedLayerReturns(result1 int32) {
+	fake.forwardedLayerMutex.Lock()
+	defer fake.forwardedLayerMutex.Unlock()
+	fake.ForwardedLayerStub = nil
+	fake.forwardedLayerReturns = struct {
+		result1 int32
+	}{result1}
+}
+
+func (fake *FakeSubscribedTrack) ForwardedLayerReturnsOnCall(i int, result1 int32) {
+	fake.forwardedLayerMutex.Lock()
+	defer fake.forwardedLayerMutex.Unlock()
+	fake.ForwardedLayerStub = nil
+	if fake.forwardedLayerReturnsOnCall == nil {
+		fake.forwardedLayerReturnsOnCall = make(map[int]struct {
+			result1 int32
+		})
+	}
+	fake.forwardedLayerReturnsOnCall[i] = struct {
+		result1 int32
+	}{result1}
+}
+
 func (fake *FakeSubscribedTrack) ID() string {
 	fake.iDMutex.Lock()
 	ret, specificReturn := fake.iDReturnsOnCall[len(fake.iDArgsForCall)]
@@ -161,6 +328,59 @@ func (fake *FakeSubscribedTrack) IDReturnsOnCall(i int, result1 string) {
 	}{result1}
 }
 
+func (fake *FakeSubscribedTrack) IsBound() bool {
+	fake.isBoundMutex.Lock()
+	ret, specificReturn := fake.isBoundReturnsOnCall[len(fake.isBoundArgsForCall)]
+	fake.isBoundArgsForCall = append(fake.isBoundArgsForCall, struct {
+	}{})
+	stub := fake.IsBoundStub
+	fakeReturns := fake.isBoundReturns
+	fake.recordInvocation("IsBound", []interface{}{})
+	fake.isBoundMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeSubscribedTrack) IsBoundCallCount() int {
+	fake.isBoundMutex.RLock()
+	defer fake.isBoundMutex.RUnlock()
+	return len(fake.isBoundArgsForCall)
+}
+
+func (fake *FakeSubscribedTrack) IsBoundCalls(stub func() bool) {
+	fake.isBoundMutex.Lock()
+	defer fake.isBoundMutex.Unlock()
+	fake.IsBoundStub = stub
+}
+
+func (fake *FakeSubscribedTrack) IsBoundReturns(result1 bool) {
+	fake.isBoundMutex.Lock()
+	defer fake.isBoundMutex.Unlock()
+	fake.IsBoundStub = nil
+	fake.isBoundReturns = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *FakeSubscribedTrack) IsBoundReturnsOnCall(i int, result1 bool) {
+	fake.isBoundMutex.Lock()
+	defer fake.isBoundMutex.Unlock()
+	fake.IsBoundStub = nil
+	if fake.isBoundReturnsOnCall == nil {
+		fake.isBoundReturnsOnCall = make(map[int]struct {
+			result1 bool
+		})
+	}
+	fake.isBoundReturnsOnCall[i] = struct {
+		result1 bool
+	}{result1}
+}
+
 func (fake *FakeSubscribedTrack) IsMuted() bool {
 	fake.isMutedMutex.Lock()
 	ret, specificReturn := fake.isMutedReturnsOnCall[len(fake.isMutedArgsForCall)]
@@ -214,6 +434,176 @@ func (fake *FakeSubscribedTrack) IsMutedReturnsOnCall(i int, result1 bool) {
 	}{result1}
 }
 
+func (fake *FakeSubscribedTrack) IsPaused() bool {
+	fake.isPausedMutex.Lock()
+	ret, specificReturn := fake.isPausedReturnsOnCall[len(fake.isPausedArgsForCall)]
+	fake.isPausedArgsForCall = append(fake.isPausedArgsForCall, struct {
+	}{})
+	stub := fake.IsPausedStub
+	fakeReturns := fake.isPausedReturns
+	fake.recordInvocation("IsPaused", []interface{}{})
+	fake.isPausedMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeSubscribedTrack) IsPausedCallCount() int {
+	fake.isPausedMutex.RLock()
+	defer fake.isPausedMutex.RUnlock()
+	return len(fake.isPausedArgsForCall)
+}
+
+func (fake *FakeSubscribedTrack) IsPausedCalls(stub func() bool) {
+	fake.isPausedMutex.Lock()
+	defer fake.isPausedMutex.Unlock()
+	fake.IsPausedStub = stub
+}
+
+func (fake *FakeSubscribedTrack) IsPausedReturns(result1 bool) {
+	fake.isPausedMutex.Lock()
+	defer fake.isPausedMutex.Unlock()
+	fake.IsPausedStub = nil
+	fake.isPausedReturns = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *FakeSubscribedTrack) IsPausedReturnsOnCall(i int, result1 bool) {
+	fake.isPausedMutex.Lock()
+	defer fake.isPausedMutex.Unlock()
+	fake.IsPausedStub = nil
+	if fake.isPausedReturnsOnCall == nil {
+		fake.isPausedReturnsOnCall = make(map[int]struct {
+			result1 bool
+		})
+	}
+	fake.isPausedReturnsOnCall[i] = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *FakeSubscribedTrack) IsPublisherMuted() bool {
+	fake.isPublisherMutedMutex.Lock()
+	ret, specificReturn := fake.isPublisherMutedReturnsOnCall[len(fake.isPublisherMutedArgsForCall)]
+	fake.isPublisherMutedArgsForCall = append(fake.isPublisherMutedArgsForCall, struct {
+	}{})
+	stub := fake.IsPublisherMutedStub
+	fakeReturns := fake.isPublisherMutedReturns
+	fake.recordInvocation("IsPublisherMuted", []interface{}{})
+	fake.isPublisherMutedMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeSubscribedTrack) IsPublisherMutedCallCount() int {
+	fake.isPublisherMutedMutex.RLock()
+	defer fake.isPublisherMutedMutex.RUnlock()
+	return len(fake.isPublisherMutedArgsForCall)
+}
+
+func (fake *FakeSubscribedTrack) IsPublisherMutedCalls(stub func() bool) {
+	fake.isPublisherMutedMutex.Lock()
+	defer fake.isPublisherMutedMutex.Unlock()
+	fake.IsPublisherMutedStub = stub
+}
+
+func (fake *FakeSubscribedTrack) IsPublisherMutedReturns(result1 bool) {
+	fake.isPublisherMutedMutex.Lock()
+	defer fake.isPublisherMutedMutex.Unlock()
+	fake.IsPublisherMutedStub = nil
+	fake.isPublisherMutedReturns = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *FakeSubscribedTrack) IsPublisherMutedReturnsOnCall(i int, result1 bool) {
+	fake.isPublisherMutedMutex.Lock()
+	defer fake.isPublisherMutedMutex.Unlock()
+	fake.IsPublisherMutedStub = nil
+	if fake.isPublisherMutedReturnsOnCall == nil {
+		fake.isPublisherMutedReturnsOnCall = make(map[int]struct {
+			result1 bool
+		})
+	}
+	fake.isPublisherMutedReturnsOnCall[i] = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *FakeSubscribedTrack) OnCodecChange(arg1 func(codec webrtc.RTPCodecParameters)) {
+	fake.onCodecChangeMutex.Lock()
+	fake.onCodecChangeArgsForCall = append(fake.onCodecChangeArgsForCall, struct {
+		arg1 func(codec webrtc.RTPCodecParameters)
+	}{arg1})
+	stub := fake.OnCodecChangeStub
+	fake.recordInvocation("OnCodecChange", []interface{}{arg1})
+	fake.onCodecChangeMutex.Unlock()
+	if stub != nil {
+		fake.OnCodecChangeStub(arg1)
+	}
+}
+
+func (fake *FakeSubscribedTrack) OnCodecChangeCallCount() int {
+	fake.onCodecChangeMutex.RLock()
+	defer fake.onCodecChangeMutex.RUnlock()
+	return len(fake.onCodecChangeArgsForCall)
+}
+
+func (fake *FakeSubscribedTrack) OnCodecChangeCalls(stub func(func(codec webrtc.RTPCodecParameters))) {
+	fake.onCodecChangeMutex.Lock()
+	defer fake.onCodecChangeMutex.Unlock()
+	fake.OnCodecChangeStub = stub
+}
+
+func (fake *FakeSubscribedTrack) OnCodecChangeArgsForCall(i int) func(codec webrtc.RTPCodecParameters) {
+	fake.onCodecChangeMutex.RLock()
+	defer fake.onCodecChangeMutex.RUnlock()
+	argsForCall := fake.onCodecChangeArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeSubscribedTrack) SetPaused(arg1 bool) {
+	fake.setPausedMutex.Lock()
+	fake.setPausedArgsForCall = append(fake.setPausedArgsForCall, struct {
+		arg1 bool
+	}{arg1})
+	stub := fake.SetPausedStub
+	fake.recordInvocation("SetPaused", []interface{}{arg1})
+	fake.setPausedMutex.Unlock()
+	if stub != nil {
+		fake.SetPausedStub(arg1)
+	}
+}
+
+func (fake *FakeSubscribedTrack) SetPausedCallCount() int {
+	fake.setPausedMutex.RLock()
+	defer fake.setPausedMutex.RUnlock()
+	return len(fake.setPausedArgsForCall)
+}
+
+func (fake *FakeSubscribedTrack) SetPausedCalls(stub func(bool)) {
+	fake.setPausedMutex.Lock()
+	defer fake.setPausedMutex.Unlock()
+	fake.SetPausedStub = stub
+}
+
+func (fake *FakeSubscribedTrack) SetPausedArgsForCall(i int) bool {
+	fake.setPausedMutex.RLock()
+	defer fake.setPausedMutex.RUnlock()
+	argsForCall := fake.setPausedArgsForCall[i]
+	return argsForCall.arg1
+}
+
 func (fake *FakeSubscribedTrack) SetPublisherMuted(arg1 bool) {
 	fake.setPublisherMutedMutex.Lock()
 	fake.setPublisherMutedArgsForCall = append(fake.setPublisherMutedArgsForCall, struct {
@@ -282,12 +672,26 @@ func (fake *FakeSubscribedTrack) UpdateSubscriberSettingsArgsForCall(i int) (boo
 func (fake *FakeSubscribedTrack) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
+	fake.codecMutex.RLock()
+	defer fake.codecMutex.RUnlock()
 	fake.downTrackMutex.RLock()
 	defer fake.downTrackMutex.RUnlock()
+	fake.forwardedLayerMutex.RLock()
+	defer fake.forwardedLayerMutex.RUnlock()
 	fake.iDMutex.RLock()
 	defer fake.iDMutex.RUnlock()
+	fake.isBoundMutex.RLock()
+	defer fake.isBoundMutex.RUnlock()
 	fake.isMutedMutex.RLock()
 	defer fake.isMutedMutex.RUnlock()
+	fake.isPausedMutex.RLock()
+	defer fake.isPausedMutex.RUnlock()
+	fake.isPublisherMutedMutex.RLock()
+	defer fake.isPublisherMutedMutex.RUnlock()
+	fake.onCodecChangeMutex.RLock()
+	defer fake.onCodecChangeMutex.RUnlock()
+	fake.setPausedMutex.RLock()
+	defer fake.setPausedMutex.RUnlock()
 	fake.setPublisherMutedMutex.RLock()
 	defer fake.setPublisherMutedMutex.RUnlock()
 	fake.updateSubscriberSettingsMutex.RLock()
@@ -0,0 +1,176 @@
+package rtc
+
+import (
+	"math/rand"
+	"net"
+
+	"github.com/livekit/protocol/utils"
+	"github.com/pion/interceptor"
+	"github.com/pion/ion-sfu/pkg/buffer"
+	"github.com/pion/ion-sfu/pkg/sfu"
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v3"
+
+	"github.com/livekit/livekit-server/pkg/logger"
+)
+
+// UDPForwarder is a virtual subscriber that writes RTP packets of a track to a UDP sink.
+// Without a PeerConnection, its DownTrack is bound through a standalone RTPSender, and
+// the forwarder intercepts packets that would've been written to the SRTP stream
+type UDPForwarder struct {
+	interceptor.NoOp
+	addr          string
+	conn          *net.UDPConn
+	ssrc          uint32
+	receiver      sfu.Receiver
+	bufferFactory *buffer.Factory
+	sender        *webrtc.RTPSender
+	downTrack     *sfu.DownTrack
+	// set while writes to the sink are failing
+	failing utils.AtomicFlag
+
+	onClose func()
+}
+
+func NewUDPForwarder(addr string, receiver sfu.Receiver, bufferFactory *buffer.Factory, packetBufferSize int) (*UDPForwarder, error) {
+	udpAddr, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	// UDP is connectionless, dialing only fails if the address cannot be used at all
+	conn, err := net.DialUDP("udp", nil, udpAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &UDPForwarder{
+		addr:          addr,
+		conn:          conn,
+		ssrc:          rand.Uint32(),
+		receiver:      receiver,
+		bufferFactory: bufferFactory,
+	}
+	f.downTrack, f.sender, err = bindVirtualDownTrack(receiver, bufferFactory, f.ssrc, "UDP_"+addr, packetBufferSize, f)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	f.downTrack.OnCloseHandler(func() {
+		go f.close()
+	})
+	return f, nil
+}
+
+// bindVirtualDownTrack creates a DownTrack of the receiver that's sent through a standalone RTPSender on ssrc,
+// packets written to it are passed to the writer bound by intercept instead of an SRTP stream
+func bindVirtualDownTrack(receiver sfu.Receiver, bufferFactory *buffer.Factory, ssrc uint32, peerID string,
+	packetBufferSize int, intercept interceptor.Interceptor) (*sfu.DownTrack, *webrtc.RTPSender, error) {
+	codec := receiver.Codec()
+	me := &webrtc.MediaEngine{}
+	if err := me.RegisterCodec(codec, receiver.Kind()); err != nil {
+		return nil, nil, err
+	}
+	ir := &interceptor.Registry{}
+	ir.Add(intercept)
+	api := webrtc.NewAPI(
+		webrtc.WithMediaEngine(me),
+		webrtc.WithSettingEngine(webrtc.SettingEngine{LoggerFactory: logger.LoggerFactory()}),
+		webrtc.WithInterceptorRegistry(ir),
+	)
+	dtls, err := api.NewDTLSTransport(api.NewICETransport(nil), nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	downTrack, err := sfu.NewDownTrack(webrtc.RTPCodecCapability{
+		MimeType:    codec.MimeType,
+		ClockRate:   codec.ClockRate,
+		Channels:    codec.Channels,
+		SDPFmtpLine: codec.SDPFmtpLine,
+	}, receiver, bufferFactory, peerID, packetBufferSize)
+	if err != nil {
+		return nil, nil, err
+	}
+	sender, err := api.NewRTPSender(downTrack, dtls)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := sender.Send(webrtc.RTPSendParameters{
+		Encodings: []webrtc.RTPEncodingParameters{
+			{RTPCodingParameters: webrtc.RTPCodingParameters{
+				SSRC:        webrtc.SSRC(ssrc),
+				PayloadType: codec.PayloadType,
+			}},
+		},
+	}); err != nil {
+		return nil, nil, err
+	}
+	return downTrack, sender, nil
+}
+
+func (f *UDPForwarder) Addr() string {
+	return f.addr
+}
+
+func (f *UDPForwarder) DownTrack() *sfu.DownTrack {
+	return f.downTrack
+}
+
+// Start begins forwarding, and requests a keyframe so the sink could decode right away
+func (f *UDPForwarder) Start() {
+	f.receiver.AddDownTrack(f.downTrack, true)
+	if f.receiver.Kind() == webrtc.RTPCodecTypeVideo {
+		f.receiver.SendRTCP([]rtcp.Packet{
+			&rtcp.PictureLossIndication{
+				SenderSSRC: f.ssrc,
+				MediaSSRC:  f.receiver.SSRC(int(f.downTrack.CurrentSpatialLayer())),
+			},
+		})
+	}
+}
+
+// Stop stops forwarding and closes the socket
+func (f *UDPForwarder) Stop() {
+	f.downTrack.Close()
+}
+
+func (f *UDPForwarder) OnClose(fn func()) {
+	f.onClose = fn
+}
+
+func (f *UDPForwarder) close() {
+	// unbinds DownTrack, removing it from the receiver
+	if err := f.sender.Stop(); err != nil {
+		logger.Debugw("could not stop UDP sender", "addr", f.addr, "error", err)
+	}
+	if rr := f.bufferFactory.GetRTCPReader(f.ssrc); rr != nil {
+		_ = rr.Close()
+	}
+	_ = f.conn.Close()
+	if f.onClose != nil {
+		f.onClose()
+	}
+}
+
+// BindLocalStream replaces the SRTP writer of the RTPSender, implements interceptor.Interceptor
+func (f *UDPForwarder) BindLocalStream(_ *interceptor.StreamInfo, _ interceptor.RTPWriter) interceptor.RTPWriter {
+	return interceptor.RTPWriterFunc(func(header *rtp.Header, payload []byte, _ interceptor.Attributes) (int, error) {
+		pkt := rtp.Packet{Header: *header, Payload: payload}
+		b, err := pkt.Marshal()
+		if err == nil {
+			_, err = f.conn.Write(b)
+		}
+		if err != nil {
+			// the sink could be temporarily unreachable, log once instead of failing the DownTrack
+			if f.failing.TrySet(true) {
+				logger.Warnw("could not write to UDP sink", err, "addr", f.addr)
+			}
+			return len(payload), nil
+		}
+		if f.failing.TrySet(false) {
+			logger.Infow("UDP sink is reachable again", "addr", f.addr)
+		}
+		return len(b), nil
+	})
+}
@@ -0,0 +1,59 @@
+package rtc
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUDPForwarderWrite(t *testing.T) {
+	sink, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+	defer sink.Close()
+
+	conn, err := net.DialUDP("udp", nil, sink.LocalAddr().(*net.UDPAddr))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	f := &UDPForwarder{
+		addr: sink.LocalAddr().String(),
+		conn: conn,
+	}
+	writer := f.BindLocalStream(nil, nil)
+
+	header := &rtp.Header{Version: 2, PayloadType: 96, SequenceNumber: 10, SSRC: 1234}
+	_, err = writer.Write(header, []byte{1, 2, 3}, nil)
+	require.NoError(t, err)
+
+	buf := make([]byte, 1500)
+	require.NoError(t, sink.SetReadDeadline(time.Now().Add(time.Second)))
+	n, err := sink.Read(buf)
+	require.NoError(t, err)
+
+	pkt := rtp.Packet{}
+	require.NoError(t, pkt.Unmarshal(buf[:n]))
+	require.Equal(t, uint16(10), pkt.SequenceNumber)
+	require.Equal(t, uint32(1234), pkt.SSRC)
+	require.Equal(t, []byte{1, 2, 3}, pkt.Payload)
+
+	t.Run("unreachable sink does not fail writes", func(t *testing.T) {
+		addr := sink.LocalAddr().(*net.UDPAddr)
+		require.NoError(t, sink.Close())
+		unreachable, err := net.DialUDP("udp", nil, addr)
+		require.NoError(t, err)
+		defer unreachable.Close()
+
+		f := &UDPForwarder{
+			addr: addr.String(),
+			conn: unreachable,
+		}
+		writer := f.BindLocalStream(nil, nil)
+		for i := 0; i < 3; i++ {
+			_, err = writer.Write(header, []byte{1, 2, 3}, nil)
+			require.NoError(t, err)
+		}
+	})
+}
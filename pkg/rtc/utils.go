@@ -17,6 +17,18 @@ const (
 	trackIdSeparator = "|"
 )
 
+// newSignalPacket encodes a message of the server to participants as a reliable user packet without a sender.
+// The protocol has no signal responses for them, clients tell them apart by the type in their JSON payload
+func newSignalPacket(signal interface{}) *livekit.DataPacket {
+	payload, _ := json.Marshal(signal)
+	return &livekit.DataPacket{
+		Kind: livekit.DataPacket_RELIABLE,
+		Value: &livekit.DataPacket_User{
+			User: &livekit.UserPacket{Payload: payload},
+		},
+	}
+}
+
 func UnpackStreamID(packed string) (participantId string, trackId string) {
 	parts := strings.Split(packed, trackIdSeparator)
 	if len(parts) > 1 {
@@ -93,6 +105,17 @@ func FromProtoTrickle(trickle *livekit.TrickleRequest) (webrtc.ICECandidateInit,
 	return ci, nil
 }
 
+// ICEUfrag returns the first ice-ufrag attribute in the SDP, it changes when the remote side restarts ICE
+func ICEUfrag(sdp string) string {
+	for _, line := range strings.Split(sdp, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "a=ice-ufrag:") {
+			return strings.TrimPrefix(line, "a=ice-ufrag:")
+		}
+	}
+	return ""
+}
+
 func ToProtoTrackKind(kind webrtc.RTPCodecType) livekit.TrackType {
 	switch kind {
 	case webrtc.RTPCodecTypeVideo:
@@ -103,6 +126,12 @@ func ToProtoTrackKind(kind webrtc.RTPCodecType) livekit.TrackType {
 	panic("unsupported track direction")
 }
 
+// videoQualityFromName returns the quality named low, medium or high, false for other names
+func videoQualityFromName(name string) (livekit.VideoQuality, bool) {
+	quality, ok := livekit.VideoQuality_value[strings.ToUpper(name)]
+	return livekit.VideoQuality(quality), ok
+}
+
 func IsEOF(err error) bool {
 	return err == io.ErrClosedPipe || err == io.EOF
 }
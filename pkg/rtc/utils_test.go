@@ -1,9 +1,12 @@
 package rtc
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/stretchr/testify/require"
+
+	livekit "github.com/livekit/livekit-server/proto"
 )
 
 func TestPackStreamId(t *testing.T) {
@@ -27,3 +30,16 @@ func TestPackDataTrackLabel(t *testing.T) {
 	require.Equal(t, trackId, tr)
 	require.Equal(t, label, l)
 }
+
+func TestSignalPacket(t *testing.T) {
+	dp := newSignalPacket(dataRateLimitedSignal{Type: dataRateLimitedPacketType, Kind: dataPacketKindName(livekit.DataPacket_LOSSY), Scope: dataLimitRoom})
+	require.Equal(t, livekit.DataPacket_RELIABLE, dp.Kind)
+	user := dp.GetUser()
+	require.NotNil(t, user)
+	require.Empty(t, user.ParticipantSid)
+
+	var signal map[string]interface{}
+	require.NoError(t, json.Unmarshal(user.Payload, &signal))
+	require.Equal(t, dataRateLimitedPacketType, signal["type"])
+	require.Equal(t, dataLimitRoom, signal["scope"])
+}
@@ -0,0 +1,64 @@
+package rtc
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// warmStandbys tracks subscriptions a subscriber disabled. They're kept bound so they resume at the next keyframe
+// without renegotiating, which costs a DownTrack each, so only the ones disabled most recently are kept
+type warmStandbys struct {
+	max int
+
+	lock sync.Mutex
+	// track id => when it was disabled
+	disabled map[string]time.Time
+}
+
+// newWarmStandbys returns nil when the number of disabled subscriptions isn't limited
+func newWarmStandbys(max int) *warmStandbys {
+	if max <= 0 {
+		return nil
+	}
+	return &warmStandbys{
+		max:      max,
+		disabled: make(map[string]time.Time),
+	}
+}
+
+// disable records the subscription as disabled, returns the tracks to unsubscribe from, the ones disabled the
+// longest once there are more than max
+func (w *warmStandbys) disable(trackID string, now time.Time) []string {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if _, ok := w.disabled[trackID]; !ok {
+		w.disabled[trackID] = now
+	}
+	if len(w.disabled) <= w.max {
+		return nil
+	}
+
+	// the track just disabled is the most recent
+	trackIDs := make([]string, 0, len(w.disabled)-1)
+	for id := range w.disabled {
+		if id != trackID {
+			trackIDs = append(trackIDs, id)
+		}
+	}
+	sort.Slice(trackIDs, func(i, j int) bool {
+		return w.disabled[trackIDs[i]].Before(w.disabled[trackIDs[j]])
+	})
+	evicted := trackIDs[:len(w.disabled)-w.max]
+	for _, id := range evicted {
+		delete(w.disabled, id)
+	}
+	return evicted
+}
+
+// remove is called when the subscription is enabled again or ended
+func (w *warmStandbys) remove(trackID string) {
+	w.lock.Lock()
+	delete(w.disabled, trackID)
+	w.lock.Unlock()
+}
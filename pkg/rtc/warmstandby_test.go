@@ -0,0 +1,43 @@
+package rtc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWarmStandbys(t *testing.T) {
+	t.Run("unlimited when disabled", func(t *testing.T) {
+		require.Nil(t, newWarmStandbys(0))
+	})
+
+	t.Run("evicts the ones disabled the longest", func(t *testing.T) {
+		w := newWarmStandbys(2)
+		now := time.Now()
+		require.Empty(t, w.disable("TR_1", now))
+		require.Empty(t, w.disable("TR_2", now.Add(time.Second)))
+		// disabling again doesn't make it more recent
+		require.Empty(t, w.disable("TR_1", now.Add(2*time.Second)))
+
+		require.Equal(t, []string{"TR_1"}, w.disable("TR_3", now.Add(3*time.Second)))
+		require.Equal(t, []string{"TR_2"}, w.disable("TR_4", now.Add(4*time.Second)))
+	})
+
+	t.Run("keeps the track just disabled", func(t *testing.T) {
+		w := newWarmStandbys(1)
+		now := time.Now()
+		w.disable("TR_1", now)
+		require.Equal(t, []string{"TR_1"}, w.disable("TR_2", now))
+	})
+
+	t.Run("enabled and ended subscriptions don't count", func(t *testing.T) {
+		w := newWarmStandbys(2)
+		now := time.Now()
+		w.disable("TR_1", now)
+		w.disable("TR_2", now.Add(time.Second))
+		w.remove("TR_1")
+		require.Empty(t, w.disable("TR_3", now.Add(2*time.Second)))
+		require.Equal(t, []string{"TR_2"}, w.disable("TR_4", now.Add(3*time.Second)))
+	})
+}
@@ -0,0 +1,147 @@
+package rtc
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultReportWorkers = 4
+	// number of tasks that could be queued before new ones are dropped
+	workerPoolQueueSize = 1024
+)
+
+// WorkerPool runs periodic RTCP and reporting work of a room's participants on a bounded number of goroutines,
+// instead of each participant running its own workers.
+// Tasks of a participant are isolated from others: panics are recovered, and a periodic task is only
+// scheduled again after its previous run completes
+type WorkerPool struct {
+	workers int
+	tasks   chan func()
+	busy    int32
+	dropped uint64
+
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+type WorkerPoolStats struct {
+	Workers int
+	Busy    int32
+	Queued  int
+	Dropped uint64
+}
+
+func NewWorkerPool(workers int) *WorkerPool {
+	p := &WorkerPool{
+		workers: workers,
+		tasks:   make(chan func(), workerPoolQueueSize),
+		closed:  make(chan struct{}),
+	}
+	for i := 0; i < workers; i++ {
+		go p.worker()
+	}
+	workerPoolWorkers.Add(float64(workers))
+	return p
+}
+
+// Submit queues task to be run by a worker. It returns false when the task is dropped because the pool is
+// closed or its queue is full. Without a pool, the task runs on its own goroutine
+func (p *WorkerPool) Submit(task func()) bool {
+	if p == nil {
+		go runTask(task)
+		return true
+	}
+	if p.isClosed() {
+		return false
+	}
+	select {
+	case p.tasks <- task:
+		return true
+	default:
+		atomic.AddUint64(&p.dropped, 1)
+		workerPoolDroppedTotal.Inc()
+		return false
+	}
+}
+
+// Every runs task on the pool each interval, until it returns false or the pool is closed.
+// A run that's dropped because the pool is saturated, or that panics, is retried on the next interval
+func (p *WorkerPool) Every(interval time.Duration, task func() bool) {
+	var run func()
+	schedule := func() {
+		time.AfterFunc(interval, run)
+	}
+	run = func() {
+		submitted := p.Submit(func() {
+			again := true
+			// scheduled before the panic is recovered by the worker
+			defer func() {
+				if again {
+					schedule()
+				}
+			}()
+			again = task()
+		})
+		if !submitted && !p.isClosed() {
+			schedule()
+		}
+	}
+	schedule()
+}
+
+func (p *WorkerPool) Stats() WorkerPoolStats {
+	if p == nil {
+		return WorkerPoolStats{}
+	}
+	return WorkerPoolStats{
+		Workers: p.workers,
+		Busy:    atomic.LoadInt32(&p.busy),
+		Queued:  len(p.tasks),
+		Dropped: atomic.LoadUint64(&p.dropped),
+	}
+}
+
+// Close stops the workers, queued tasks are discarded
+func (p *WorkerPool) Close() {
+	if p == nil {
+		return
+	}
+	p.closeOnce.Do(func() {
+		close(p.closed)
+		workerPoolWorkers.Sub(float64(p.workers))
+	})
+}
+
+func (p *WorkerPool) isClosed() bool {
+	if p == nil {
+		return false
+	}
+	select {
+	case <-p.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+func (p *WorkerPool) worker() {
+	for {
+		select {
+		case <-p.closed:
+			return
+		case task := <-p.tasks:
+			atomic.AddInt32(&p.busy, 1)
+			workerPoolBusy.Inc()
+			runTask(task)
+			workerPoolBusy.Dec()
+			atomic.AddInt32(&p.busy, -1)
+		}
+	}
+}
+
+func runTask(task func()) {
+	defer Recover()
+	task()
+}
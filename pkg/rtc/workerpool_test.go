@@ -0,0 +1,115 @@
+package rtc
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkerPool(t *testing.T) {
+	t.Run("runs tasks on a bounded number of workers", func(t *testing.T) {
+		p := NewWorkerPool(2)
+		defer p.Close()
+
+		block := make(chan struct{})
+		for i := 0; i < 4; i++ {
+			require.True(t, p.Submit(func() { <-block }))
+		}
+		require.Eventually(t, func() bool {
+			stats := p.Stats()
+			return stats.Busy == 2 && stats.Queued == 2
+		}, time.Second, 10*time.Millisecond)
+
+		close(block)
+		require.Eventually(t, func() bool {
+			stats := p.Stats()
+			return stats.Busy == 0 && stats.Queued == 0
+		}, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("drops tasks when saturated", func(t *testing.T) {
+		p := NewWorkerPool(1)
+		defer p.Close()
+
+		block := make(chan struct{})
+		defer close(block)
+		require.True(t, p.Submit(func() { <-block }))
+		require.Eventually(t, func() bool {
+			return p.Stats().Busy == 1
+		}, time.Second, 10*time.Millisecond)
+
+		for i := 0; i < workerPoolQueueSize; i++ {
+			require.True(t, p.Submit(func() {}))
+		}
+		require.False(t, p.Submit(func() {}))
+		require.Equal(t, uint64(1), p.Stats().Dropped)
+	})
+
+	t.Run("isolates panicking tasks", func(t *testing.T) {
+		p := NewWorkerPool(1)
+		defer p.Close()
+
+		done := make(chan struct{})
+		p.Submit(func() { panic("task failed") })
+		p.Submit(func() { close(done) })
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("worker stopped after panic")
+		}
+	})
+
+	t.Run("runs periodic tasks until they're done", func(t *testing.T) {
+		p := NewWorkerPool(1)
+		defer p.Close()
+
+		var runs int32
+		p.Every(time.Millisecond, func() bool {
+			return atomic.AddInt32(&runs, 1) < 3
+		})
+		require.Eventually(t, func() bool {
+			return atomic.LoadInt32(&runs) == 3
+		}, time.Second, 10*time.Millisecond)
+		time.Sleep(20 * time.Millisecond)
+		require.Equal(t, int32(3), atomic.LoadInt32(&runs))
+	})
+
+	t.Run("reschedules periodic tasks that panic", func(t *testing.T) {
+		p := NewWorkerPool(1)
+		defer p.Close()
+
+		var runs int32
+		p.Every(time.Millisecond, func() bool {
+			n := atomic.AddInt32(&runs, 1)
+			if n == 1 {
+				panic("task failed")
+			}
+			return n < 3
+		})
+		require.Eventually(t, func() bool {
+			return atomic.LoadInt32(&runs) == 3
+		}, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("stops periodic tasks once closed", func(t *testing.T) {
+		p := NewWorkerPool(1)
+
+		var runs int32
+		p.Every(time.Millisecond, func() bool {
+			atomic.AddInt32(&runs, 1)
+			return true
+		})
+		require.Eventually(t, func() bool {
+			return atomic.LoadInt32(&runs) > 0
+		}, time.Second, 10*time.Millisecond)
+
+		p.Close()
+		require.False(t, p.Submit(func() {}))
+		time.Sleep(20 * time.Millisecond)
+		stopped := atomic.LoadInt32(&runs)
+		time.Sleep(20 * time.Millisecond)
+		require.Equal(t, stopped, atomic.LoadInt32(&runs))
+	})
+}
@@ -0,0 +1,37 @@
+package rtc
+
+import (
+	"github.com/pion/ion-sfu/pkg/sfu"
+	"github.com/pion/webrtc/v3"
+)
+
+// wrapper around DownTrack, reporting the codec and SSRC negotiated with the subscriber each time it's bound
+
+type WrappedDownTrack struct {
+	*sfu.DownTrack
+	onBind func(codec webrtc.RTPCodecParameters, ssrc webrtc.SSRC)
+	// overrides the kind derived from the codec when set
+	kind webrtc.RTPCodecType
+}
+
+func NewWrappedDownTrack(dt *sfu.DownTrack, onBind func(codec webrtc.RTPCodecParameters, ssrc webrtc.SSRC)) WrappedDownTrack {
+	return WrappedDownTrack{
+		DownTrack: dt,
+		onBind:    onBind,
+	}
+}
+
+func (t WrappedDownTrack) Bind(ctx webrtc.TrackLocalContext) (webrtc.RTPCodecParameters, error) {
+	codec, err := t.DownTrack.Bind(ctx)
+	if err == nil && t.onBind != nil {
+		t.onBind(codec, ctx.SSRC())
+	}
+	return codec, err
+}
+
+func (t WrappedDownTrack) Kind() webrtc.RTPCodecType {
+	if t.kind != 0 {
+		return t.kind
+	}
+	return t.DownTrack.Kind()
+}
@@ -0,0 +1,257 @@
+package service
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/livekit/protocol/auth"
+
+	"github.com/livekit/livekit-server/pkg/routing"
+	livekit "github.com/livekit/livekit-server/proto"
+)
+
+// participants wait on the node handling their signal connection, these are carried out on every node
+const (
+	roomOpListWaitingParticipants = "list_waiting_participants"
+	roomOpAdmitParticipant        = "admit_participant"
+	roomOpRejectParticipant       = "reject_participant"
+)
+
+type AdmissionDecision int
+
+const (
+	AdmissionAdmit AdmissionDecision = iota
+	AdmissionReject
+	// participant is held in the waiting room until a host admits or rejects it
+	AdmissionDefer
+)
+
+type AdmissionRequest struct {
+	Room *livekit.Room
+	// participants currently in the room
+	Participants []*livekit.ParticipantInfo
+	Claims       *auth.ClaimGrants
+}
+
+type AdmissionResult struct {
+	Decision AdmissionDecision
+	// returned to the participant when rejected
+	Reason string
+}
+
+// AdmissionController decides if a participant could join a room.
+// It's invoked for new participants on the node handling their signal connection, before the session is started
+type AdmissionController interface {
+	Admit(req *AdmissionRequest) (AdmissionResult, error)
+}
+
+// waitingRoomController defers participants until a host admits them, room admins are hosts and join right away
+type waitingRoomController struct{}
+
+func (c *waitingRoomController) Admit(req *AdmissionRequest) (AdmissionResult, error) {
+	if video := req.Claims.Video; video != nil && video.RoomAdmin && video.Room == req.Room.Name {
+		return AdmissionResult{Decision: AdmissionAdmit}, nil
+	}
+	return AdmissionResult{Decision: AdmissionDefer}, nil
+}
+
+// LobbyParticipant describes a participant waiting in the lobby of a room
+type LobbyParticipant struct {
+	Identity string `json:"identity"`
+	Metadata string `json:"metadata,omitempty"`
+	// unix timestamp in milliseconds of when it started waiting, the lobby is ordered by it
+	WaitingSince int64 `json:"waitingSince"`
+	// false while the participant is reconnecting
+	Connected bool `json:"connected"`
+}
+
+type ListWaitingParticipantsRequest struct {
+	Room string `json:"room"`
+}
+
+type ListWaitingParticipantsResponse struct {
+	// in the order they started waiting
+	Participants []LobbyParticipant `json:"participants"`
+}
+
+// AdmitParticipantRequest lets a participant waiting in the lobby of a room join it
+type AdmitParticipantRequest struct {
+	Room     string `json:"room"`
+	Identity string `json:"identity"`
+}
+
+type AdmitParticipantResponse struct{}
+
+// RejectParticipantRequest turns away a participant waiting in the lobby of a room, with reason
+type RejectParticipantRequest struct {
+	Room     string `json:"room"`
+	Identity string `json:"identity"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+type RejectParticipantResponse struct{}
+
+type lobbyEntry struct {
+	info LobbyParticipant
+	// receives the decision for the current connection, nil while disconnected
+	result chan AdmissionResult
+	// decision made while disconnected, returned once it reconnects
+	decided *AdmissionResult
+	expiry  *time.Timer
+}
+
+// WaitingRoom is the lobby of deferred participants connected to this node, they're held until admitted or
+// rejected by a host. Participants that disconnect keep their place for the reconnect grace period
+type WaitingRoom struct {
+	reconnectGrace time.Duration
+
+	lock sync.Mutex
+	// room name => identity => entry
+	rooms map[string]map[string]*lobbyEntry
+}
+
+func NewWaitingRoom(reconnectGrace time.Duration) *WaitingRoom {
+	return &WaitingRoom{
+		reconnectGrace: reconnectGrace,
+		rooms:          make(map[string]map[string]*lobbyEntry),
+	}
+}
+
+// Wait blocks until the participant is admitted or rejected. It returns false if done is closed first.
+// A participant waiting with the same identity is replaced by the new connection, which keeps its place
+func (w *WaitingRoom) Wait(roomName string, p LobbyParticipant, done <-chan struct{}) (AdmissionResult, bool) {
+	ch := make(chan AdmissionResult, 1)
+
+	w.lock.Lock()
+	participants := w.rooms[roomName]
+	if participants == nil {
+		participants = make(map[string]*lobbyEntry)
+		w.rooms[roomName] = participants
+	}
+	entry := participants[p.Identity]
+	if entry == nil {
+		p.WaitingSince = time.Now().UnixNano() / int64(time.Millisecond)
+		entry = &lobbyEntry{info: p}
+		participants[p.Identity] = entry
+	} else {
+		if entry.expiry != nil {
+			entry.expiry.Stop()
+			entry.expiry = nil
+		}
+		if entry.decided != nil {
+			result := *entry.decided
+			w.remove(roomName, p.Identity)
+			w.lock.Unlock()
+			return result, true
+		}
+		if entry.result != nil {
+			entry.result <- AdmissionResult{Decision: AdmissionReject, Reason: "replaced by a new connection"}
+		}
+		entry.info.Metadata = p.Metadata
+	}
+	entry.result = ch
+	entry.info.Connected = true
+	w.lock.Unlock()
+
+	select {
+	case result := <-ch:
+		return result, true
+	case <-done:
+		w.lock.Lock()
+		if entry.result == ch {
+			entry.result = nil
+			entry.info.Connected = false
+			entry.expiry = time.AfterFunc(w.reconnectGrace, func() {
+				w.lock.Lock()
+				defer w.lock.Unlock()
+				if w.rooms[roomName][p.Identity] == entry && entry.result == nil {
+					w.remove(roomName, p.Identity)
+				}
+			})
+		}
+		w.lock.Unlock()
+		return AdmissionResult{}, false
+	}
+}
+
+func (w *WaitingRoom) Admit(roomName, identity string) error {
+	return w.decide(roomName, identity, AdmissionResult{Decision: AdmissionAdmit})
+}
+
+func (w *WaitingRoom) Reject(roomName, identity, reason string) error {
+	return w.decide(roomName, identity, AdmissionResult{Decision: AdmissionReject, Reason: reason})
+}
+
+// Waiting returns participants waiting to join the room, in the order they started waiting
+func (w *WaitingRoom) Waiting(roomName string) []LobbyParticipant {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	participants := make([]LobbyParticipant, 0, len(w.rooms[roomName]))
+	for _, entry := range w.rooms[roomName] {
+		if entry.decided != nil {
+			continue
+		}
+		participants = append(participants, entry.info)
+	}
+	sort.Slice(participants, func(i, j int) bool {
+		return participants[i].WaitingSince < participants[j].WaitingSince
+	})
+	return participants
+}
+
+// isWaiting returns true when the participant has a place in the lobby, including while it's reconnecting
+func (w *WaitingRoom) isWaiting(roomName, identity string) bool {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return w.rooms[roomName][identity] != nil
+}
+
+func (w *WaitingRoom) decide(roomName, identity string, result AdmissionResult) error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	entry := w.rooms[roomName][identity]
+	if entry == nil || entry.decided != nil {
+		return ErrParticipantNotFound
+	}
+	if entry.result == nil {
+		// reconnecting, keep the decision until it's back or the grace period is over
+		entry.decided = &result
+		return nil
+	}
+	w.remove(roomName, identity)
+	entry.result <- result
+	return nil
+}
+
+// needs to be called with lock held
+func (w *WaitingRoom) remove(roomName, identity string) {
+	delete(w.rooms[roomName], identity)
+	if len(w.rooms[roomName]) == 0 {
+		delete(w.rooms, roomName)
+	}
+}
+
+func (s *RTCService) handleListWaitingParticipants(op *routing.RoomOperation) (interface{}, error) {
+	return s.waitingRoom.Waiting(op.Room), nil
+}
+
+func (s *RTCService) handleAdmitParticipant(op *routing.RoomOperation) (interface{}, error) {
+	return nil, lobbyDecisionError(s.waitingRoom.Admit(op.Room, op.Identity))
+}
+
+func (s *RTCService) handleRejectParticipant(op *routing.RoomOperation) (interface{}, error) {
+	req := RejectParticipantRequest{}
+	if err := op.DecodeParams(&req); err != nil {
+		return nil, err
+	}
+	return nil, lobbyDecisionError(s.waitingRoom.Reject(op.Room, op.Identity, req.Reason))
+}
+
+// participants wait on a single node, the others skip decisions on them
+func lobbyDecisionError(err error) error {
+	if err == ErrParticipantNotFound {
+		return routing.ErrRoomOperationSkipped
+	}
+	return err
+}
@@ -0,0 +1,133 @@
+package service_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/service"
+)
+
+const reconnectGrace = 100 * time.Millisecond
+
+func TestWaitingRoom(t *testing.T) {
+	t.Run("holds participants until admitted", func(t *testing.T) {
+		w := service.NewWaitingRoom(reconnectGrace)
+		results := waitAsync(w, "room", "p1", nil)
+		waitForLobby(t, w, "room", 1)
+
+		lobby := w.Waiting("room")
+		require.Equal(t, "p1", lobby[0].Identity)
+		require.True(t, lobby[0].Connected)
+		require.NotZero(t, lobby[0].WaitingSince)
+
+		require.NoError(t, w.Admit("room", "p1"))
+		result := <-results
+		require.Equal(t, service.AdmissionAdmit, result.Decision)
+		require.Empty(t, w.Waiting("room"))
+
+		require.ErrorIs(t, w.Admit("room", "p1"), service.ErrParticipantNotFound)
+	})
+
+	t.Run("lists participants in the order they started waiting", func(t *testing.T) {
+		w := service.NewWaitingRoom(reconnectGrace)
+		waitAsync(w, "room", "p1", nil)
+		waitForLobby(t, w, "room", 1)
+		time.Sleep(5 * time.Millisecond)
+		waitAsync(w, "room", "p2", nil)
+		waitForLobby(t, w, "room", 2)
+
+		lobby := w.Waiting("room")
+		require.Equal(t, "p1", lobby[0].Identity)
+		require.Equal(t, "p2", lobby[1].Identity)
+	})
+
+	t.Run("returns reason when rejected", func(t *testing.T) {
+		w := service.NewWaitingRoom(reconnectGrace)
+		results := waitAsync(w, "room", "p1", nil)
+		waitForLobby(t, w, "room", 1)
+
+		require.NoError(t, w.Reject("room", "p1", "room is full"))
+		result := <-results
+		require.Equal(t, service.AdmissionReject, result.Decision)
+		require.Equal(t, "room is full", result.Reason)
+	})
+
+	t.Run("keeps place of participants that reconnect", func(t *testing.T) {
+		w := service.NewWaitingRoom(reconnectGrace)
+		done := make(chan struct{})
+		results := waitAsync(w, "room", "p1", done)
+		waitForLobby(t, w, "room", 1)
+
+		close(done)
+		_, ok := <-results
+		require.False(t, ok)
+		lobby := w.Waiting("room")
+		require.Len(t, lobby, 1)
+		require.False(t, lobby[0].Connected)
+
+		// admitted while reconnecting
+		require.NoError(t, w.Admit("room", "p1"))
+		require.Empty(t, w.Waiting("room"))
+
+		result := <-waitAsync(w, "room", "p1", nil)
+		require.Equal(t, service.AdmissionAdmit, result.Decision)
+
+		// back in the lobby without a decision
+		done = make(chan struct{})
+		waitAsync(w, "room", "p2", done)
+		waitForLobby(t, w, "room", 1)
+		since := w.Waiting("room")[0].WaitingSince
+		close(done)
+		waitAsync(w, "room", "p2", nil)
+		require.Eventually(t, func() bool {
+			lobby := w.Waiting("room")
+			return len(lobby) == 1 && lobby[0].Connected
+		}, time.Second, 10*time.Millisecond)
+		require.Equal(t, since, w.Waiting("room")[0].WaitingSince)
+	})
+
+	t.Run("removes participants that don't reconnect", func(t *testing.T) {
+		w := service.NewWaitingRoom(reconnectGrace)
+		done := make(chan struct{})
+		waitAsync(w, "room", "p1", done)
+		waitForLobby(t, w, "room", 1)
+
+		close(done)
+		require.Eventually(t, func() bool {
+			return len(w.Waiting("room")) == 0
+		}, time.Second, 10*time.Millisecond)
+		require.ErrorIs(t, w.Admit("room", "p1"), service.ErrParticipantNotFound)
+	})
+
+	t.Run("rejects previous connection of the same identity", func(t *testing.T) {
+		w := service.NewWaitingRoom(reconnectGrace)
+		first := waitAsync(w, "room", "p1", nil)
+		waitForLobby(t, w, "room", 1)
+
+		second := waitAsync(w, "room", "p1", nil)
+		require.Equal(t, service.AdmissionReject, (<-first).Decision)
+
+		require.NoError(t, w.Admit("room", "p1"))
+		require.Equal(t, service.AdmissionAdmit, (<-second).Decision)
+	})
+}
+
+// waitAsync waits in the background, the returned channel is closed without a result when done is closed
+func waitAsync(w *service.WaitingRoom, roomName, identity string, done chan struct{}) <-chan service.AdmissionResult {
+	results := make(chan service.AdmissionResult, 1)
+	go func() {
+		defer close(results)
+		if result, ok := w.Wait(roomName, service.LobbyParticipant{Identity: identity}, done); ok {
+			results <- result
+		}
+	}()
+	return results
+}
+
+func waitForLobby(t *testing.T, w *service.WaitingRoom, roomName string, size int) {
+	require.Eventually(t, func() bool {
+		return len(w.Waiting(roomName)) == size
+	}, time.Second, 10*time.Millisecond)
+}
@@ -19,7 +19,8 @@ const (
 )
 
 var (
-	ErrPermissionDenied = errors.New("permissions denied")
+	ErrPermissionDenied     = errors.New("permissions denied")
+	ErrInvalidAuthorization = errors.New("invalid authorization header. Must start with " + bearerPrefix)
 )
 
 // authentication middleware
@@ -37,41 +38,21 @@ func (m *APIKeyAuthMiddleware) ServeHTTP(w http.ResponseWriter, r *http.Request,
 	if r.URL != nil && r.URL.Path == "/rtc/validate" {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 	}
-
-	authHeader := r.Header.Get(authorizationHeader)
-	var authToken string
-
-	if authHeader != "" {
-		if !strings.HasPrefix(authHeader, bearerPrefix) {
-			handleError(w, http.StatusUnauthorized, "invalid authorization header. Must start with "+bearerPrefix)
-			return
-		}
-
-		authToken = authHeader[len(bearerPrefix):]
-	} else {
-		// attempt to find from request header
-		authToken = r.FormValue(accessTokenParam)
+	if r.URL != nil && (r.URL.Path == "/rtc" || r.URL.Path == "/rtc/validate") {
+		// join tokens are validated by the AuthProvider of RTCService, which might not take JWTs
+		next.ServeHTTP(w, r)
+		return
 	}
+	// the same goes for RoomService methods taking join tokens, they only get grants here for admin tokens
+	joinToken := r.URL != nil && isJoinTokenMethodPath(r.URL.Path)
 
-	if authToken != "" {
-		v, err := auth.ParseAPIToken(authToken)
-		if err != nil {
-			handleError(w, http.StatusUnauthorized, "invalid authorization token")
-			return
-		}
-
-		secret := m.provider.GetSecret(v.APIKey())
-		if secret == "" {
-			handleError(w, http.StatusUnauthorized, "invalid API key")
-			return
-		}
-
-		grants, err := v.Verify(secret)
-		if err != nil {
-			handleError(w, http.StatusUnauthorized, "invalid token: "+authToken+", error: "+err.Error())
-			return
-		}
+	grants, err := m.verify(r)
+	if err != nil && !joinToken {
+		handleError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
 
+	if grants != nil {
 		// set grants in context
 		ctx := r.Context()
 		r = r.WithContext(context.WithValue(ctx, grantsKey, grants))
@@ -80,6 +61,41 @@ func (m *APIKeyAuthMiddleware) ServeHTTP(w http.ResponseWriter, r *http.Request,
 	next.ServeHTTP(w, r)
 }
 
+// verify returns grants of the API token of the request, nil when it has none
+func (m *APIKeyAuthMiddleware) verify(r *http.Request) (*auth.ClaimGrants, error) {
+	authToken, err := requestToken(r)
+	if err != nil || authToken == "" {
+		return nil, err
+	}
+
+	v, err := auth.ParseAPIToken(authToken)
+	if err != nil {
+		return nil, errors.New("invalid authorization token")
+	}
+
+	secret := m.provider.GetSecret(v.APIKey())
+	if secret == "" {
+		return nil, errors.New("invalid API key")
+	}
+
+	grants, err := v.Verify(secret)
+	if err != nil {
+		return nil, errors.New("invalid token: " + authToken + ", error: " + err.Error())
+	}
+	return grants, nil
+}
+
+// requestToken returns the token of the request, from its authorization header or access_token param
+func requestToken(r *http.Request) (string, error) {
+	if authHeader := r.Header.Get(authorizationHeader); authHeader != "" {
+		if !strings.HasPrefix(authHeader, bearerPrefix) {
+			return "", ErrInvalidAuthorization
+		}
+		return authHeader[len(bearerPrefix):], nil
+	}
+	return r.FormValue(accessTokenParam), nil
+}
+
 func GetGrants(ctx context.Context) *auth.ClaimGrants {
 	claims, ok := ctx.Value(grantsKey).(*auth.ClaimGrants)
 	if !ok {
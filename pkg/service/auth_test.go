@@ -55,4 +55,27 @@ func TestAuthMiddleware(t *testing.T) {
 	m.ServeHTTP(w, r, handler)
 	require.Nil(t, grants)
 	require.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// join tokens are left to the AuthProvider
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/rtc", nil)
+	service.SetAuthorizationToken(r, "invalid token")
+	m.ServeHTTP(w, r, handler)
+	require.Nil(t, grants)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	// including RoomService methods taking them
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/twirp/livekit.RoomService/UpdateDeviceClass", nil)
+	service.SetAuthorizationToken(r, "invalid token")
+	m.ServeHTTP(w, r, handler)
+	require.Nil(t, grants)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	// other methods still require valid API tokens
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/twirp/livekit.RoomService/BulkUpdate", nil)
+	service.SetAuthorizationToken(r, "invalid token")
+	m.ServeHTTP(w, r, handler)
+	require.Equal(t, http.StatusUnauthorized, w.Code)
 }
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/livekit/protocol/auth"
+)
+
+var (
+	ErrMissingToken    = errors.New("missing access token")
+	ErrInvalidAPIKey   = errors.New("invalid API key")
+	ErrMissingIdentity = errors.New("access token has no identity")
+)
+
+// AuthProvider validates the token of a participant joining a room, and returns the grants it joins with.
+// It's invoked on the node handling the signal connection, before the participant is created
+type AuthProvider interface {
+	Validate(token string) (*auth.ClaimGrants, error)
+}
+
+// JWTAuthProvider validates access tokens signed with one of the configured API keys. Tokens have to be
+// unexpired, grant joining a room, and carry the identity of the participant
+type JWTAuthProvider struct {
+	keyProvider auth.KeyProvider
+}
+
+func NewJWTAuthProvider(keyProvider auth.KeyProvider) *JWTAuthProvider {
+	return &JWTAuthProvider{
+		keyProvider: keyProvider,
+	}
+}
+
+func (p *JWTAuthProvider) Validate(token string) (*auth.ClaimGrants, error) {
+	if token == "" {
+		return nil, ErrMissingToken
+	}
+	v, err := auth.ParseAPIToken(token)
+	if err != nil {
+		return nil, fmt.Errorf("invalid access token: %v", err)
+	}
+
+	var secret string
+	if p.keyProvider != nil {
+		secret = p.keyProvider.GetSecret(v.APIKey())
+	}
+	if secret == "" {
+		return nil, ErrInvalidAPIKey
+	}
+
+	// checks the signature, expiry and not before
+	grants, err := v.Verify(secret)
+	if err != nil {
+		return nil, fmt.Errorf("invalid access token: %v", err)
+	}
+	if grants.Video == nil || !grants.Video.RoomJoin {
+		return nil, ErrPermissionDenied
+	}
+	if grants.Identity == "" {
+		return nil, ErrMissingIdentity
+	}
+	return grants, nil
+}
@@ -0,0 +1,102 @@
+package service_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/livekit/protocol/auth"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/service"
+	livekit "github.com/livekit/livekit-server/proto"
+)
+
+func TestJWTAuthProvider(t *testing.T) {
+	provider := service.NewJWTAuthProvider(auth.NewFileBasedKeyProviderFromMap(map[string]string{"key1": "secret1"}))
+	newToken := func(key, secret, identity string, grant *auth.VideoGrant) string {
+		token, err := auth.NewAccessToken(key, secret).
+			SetIdentity(identity).
+			AddGrant(grant).
+			ToJWT()
+		require.NoError(t, err)
+		return token
+	}
+
+	t.Run("returns the grants of valid tokens", func(t *testing.T) {
+		grants, err := provider.Validate(newToken("key1", "secret1", "alice",
+			&auth.VideoGrant{RoomJoin: true, Room: "room", CanPublish: true}))
+		require.NoError(t, err)
+		require.Equal(t, "alice", grants.Identity)
+		require.Equal(t, "room", grants.Video.Room)
+		require.True(t, grants.Video.CanPublish)
+	})
+
+	t.Run("rejects invalid tokens", func(t *testing.T) {
+		_, err := provider.Validate("")
+		require.Equal(t, service.ErrMissingToken, err)
+
+		_, err = provider.Validate("not a token")
+		require.Error(t, err)
+
+		_, err = provider.Validate(newToken("key2", "secret1", "alice", &auth.VideoGrant{RoomJoin: true}))
+		require.Equal(t, service.ErrInvalidAPIKey, err)
+
+		// signed with another secret
+		_, err = provider.Validate(newToken("key1", "secret2", "alice", &auth.VideoGrant{RoomJoin: true}))
+		require.Error(t, err)
+
+		_, err = provider.Validate(newToken("key1", "secret1", "alice", &auth.VideoGrant{RoomList: true}))
+		require.Equal(t, service.ErrPermissionDenied, err)
+
+		_, err = provider.Validate(newToken("key1", "secret1", "", &auth.VideoGrant{RoomJoin: true}))
+		require.Equal(t, service.ErrMissingIdentity, err)
+	})
+}
+
+func TestRTCServiceAuth(t *testing.T) {
+	conf, err := config.NewConfig("", nil)
+	require.NoError(t, err)
+	provider := service.NewJWTAuthProvider(auth.NewFileBasedKeyProviderFromMap(map[string]string{"key1": "secret1"}))
+	s := service.NewRTCService(conf, nil, nil, nil, provider)
+
+	t.Run("validate reports why tokens are rejected", func(t *testing.T) {
+		token, err := auth.NewAccessToken("key1", "secret1").
+			SetIdentity("alice").
+			AddGrant(&auth.VideoGrant{RoomJoin: true, Room: "room"}).
+			ToJWT()
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+		s.Validate(w, httptest.NewRequest(http.MethodGet, "/rtc/validate?access_token="+token, nil))
+		require.Equal(t, http.StatusOK, w.Code)
+
+		w = httptest.NewRecorder()
+		s.Validate(w, httptest.NewRequest(http.MethodGet, "/rtc/validate", nil))
+		require.Equal(t, http.StatusUnauthorized, w.Code)
+		require.Equal(t, service.ErrMissingToken.Error(), w.Body.String())
+	})
+
+	t.Run("signal connections are told their token was rejected", func(t *testing.T) {
+		server := httptest.NewServer(s)
+		defer server.Close()
+
+		conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/rtc", nil)
+		require.NoError(t, err)
+		defer conn.Close()
+
+		_, payload, err := conn.ReadMessage()
+		require.NoError(t, err)
+		res := &livekit.SignalResponse{}
+		require.NoError(t, protojson.Unmarshal(payload, res))
+		require.NotNil(t, res.GetLeave())
+		require.False(t, res.GetLeave().CanReconnect)
+
+		_, _, err = conn.ReadMessage()
+		require.True(t, websocket.IsCloseError(err, websocket.ClosePolicyViolation))
+		require.Contains(t, err.Error(), service.ErrMissingToken.Error())
+	})
+}
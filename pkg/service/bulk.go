@@ -0,0 +1,104 @@
+package service
+
+import (
+	"time"
+
+	"github.com/livekit/livekit-server/pkg/routing"
+	"github.com/livekit/livekit-server/pkg/rtc"
+)
+
+const roomOpBulkUpdate = "bulk_update"
+
+const (
+	BulkActionMute   = "mute"
+	BulkActionUnmute = "unmute"
+	BulkActionRemove = "remove"
+)
+
+// BulkUpdateRequest applies an action to all participants of a room, except the ones with the given sids
+type BulkUpdateRequest struct {
+	Room string `json:"room"`
+	// mute, unmute or remove
+	Action     string   `json:"action"`
+	ExceptSids []string `json:"except_sids,omitempty"`
+}
+
+// BulkResponse reports participants a bulk operation was applied to, and the ones it failed for
+type BulkResponse struct {
+	Applied []string `json:"applied"`
+	// identity => error
+	Failed map[string]string `json:"failed,omitempty"`
+}
+
+// MuteAll mutes tracks of all participants in a room hosted on this node on behalf of the server, except the ones
+// with the given sids. Participants can't unmute them
+func (r *RoomManager) MuteAll(roomName string, exceptSids []string) (rtc.BulkResult, error) {
+	return r.applyBulk(roomName, func(room *rtc.Room) rtc.BulkResult {
+		return room.SetAllTracksMuted(true, exceptSids)
+	})
+}
+
+// UnmuteAll unmutes tracks of all participants in a room hosted on this node, except the ones with the given sids
+func (r *RoomManager) UnmuteAll(roomName string, exceptSids []string) (rtc.BulkResult, error) {
+	return r.applyBulk(roomName, func(room *rtc.Room) rtc.BulkResult {
+		return room.SetAllTracksMuted(false, exceptSids)
+	})
+}
+
+// RemoveAll removes all participants from a room hosted on this node, except hosts and the ones with the given sids
+func (r *RoomManager) RemoveAll(roomName string, exceptSids []string) (rtc.BulkResult, error) {
+	return r.applyBulk(roomName, func(room *rtc.Room) rtc.BulkResult {
+		return room.RemoveAll(exceptSids)
+	})
+}
+
+// applyBulk applies a bulk operation with the room locked, so it doesn't interleave with participants being admitted
+// to the room, or the room being closed
+func (r *RoomManager) applyBulk(roomName string, op func(room *rtc.Room) rtc.BulkResult) (rtc.BulkResult, error) {
+	token, err := r.roomStore.LockRoom(roomName, 5*time.Second)
+	if err != nil {
+		return rtc.BulkResult{}, err
+	}
+	defer func() {
+		_ = r.roomStore.UnlockRoom(roomName, token)
+	}()
+
+	room := r.GetRoom(roomName)
+	if room == nil {
+		return rtc.BulkResult{}, ErrRoomNotFound
+	}
+	return op(room), nil
+}
+
+// handleBulkUpdate carries out bulk updates of rooms hosted on this node
+func (r *RoomManager) handleBulkUpdate(op *routing.RoomOperation) (interface{}, error) {
+	req := BulkUpdateRequest{}
+	if err := op.DecodeParams(&req); err != nil {
+		return nil, err
+	}
+
+	var result rtc.BulkResult
+	var err error
+	switch req.Action {
+	case BulkActionMute:
+		result, err = r.MuteAll(op.Room, req.ExceptSids)
+	case BulkActionUnmute:
+		result, err = r.UnmuteAll(op.Room, req.ExceptSids)
+	case BulkActionRemove:
+		result, err = r.RemoveAll(op.Room, req.ExceptSids)
+	default:
+		return nil, ErrInvalidBulkAction
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	res := &BulkResponse{Applied: result.Applied}
+	if len(result.Failed) > 0 {
+		res.Failed = make(map[string]string, len(result.Failed))
+		for identity, err := range result.Failed {
+			res.Failed[identity] = err.Error()
+		}
+	}
+	return res, nil
+}
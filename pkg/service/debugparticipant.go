@@ -0,0 +1,50 @@
+package service
+
+import (
+	"time"
+
+	"github.com/livekit/livekit-server/pkg/routing"
+)
+
+const roomOpDebugParticipant = "debug_participant"
+
+// DebugParticipantRequest debugs a single participant without raising the log level of the server. Debug messages
+// are logged for participant_debug_duration, or duration when set, like 10m, and a duration of 0 stops them
+type DebugParticipantRequest struct {
+	Room     string `json:"room"`
+	Sid      string `json:"sid"`
+	Duration string `json:"duration,omitempty"`
+}
+
+type DebugParticipantResponse struct{}
+
+// DebugParticipant logs debug messages of a participant in a room hosted on this node regardless of the log level,
+// for d. 0 stops right away
+func (r *RoomManager) DebugParticipant(roomName, sid string, d time.Duration) error {
+	room := r.GetRoom(roomName)
+	if room == nil {
+		return ErrRoomNotFound
+	}
+	for _, participant := range room.GetParticipants() {
+		if participant.ID() == sid {
+			participant.DebugLogging(d)
+			return nil
+		}
+	}
+	return ErrParticipantNotFound
+}
+
+func (r *RoomManager) handleDebugParticipant(op *routing.RoomOperation) (interface{}, error) {
+	req := DebugParticipantRequest{}
+	if err := op.DecodeParams(&req); err != nil {
+		return nil, err
+	}
+	d := r.config.ParticipantDebugDuration
+	if req.Duration != "" {
+		var err error
+		if d, err = time.ParseDuration(req.Duration); err != nil {
+			return nil, err
+		}
+	}
+	return nil, r.DebugParticipant(op.Room, req.Sid, d)
+}
@@ -0,0 +1,38 @@
+package service
+
+import (
+	"github.com/livekit/livekit-server/pkg/routing"
+)
+
+const roomOpUpdateDeviceClass = "update_device_class"
+
+// UpdateDeviceClassRequest changes the device class of the participant in the join token mid-session, e.g. once
+// it's plugged in
+type UpdateDeviceClassRequest struct {
+	DeviceClass string `json:"device_class"`
+}
+
+type UpdateDeviceClassResponse struct{}
+
+// SetParticipantDeviceClass changes the class of device a participant in a room hosted on this node is on,
+// which moves its video subscriptions to the default quality of the class
+func (r *RoomManager) SetParticipantDeviceClass(roomName, identity, class string) error {
+	room := r.GetRoom(roomName)
+	if room == nil {
+		return ErrRoomNotFound
+	}
+	participant := room.GetParticipant(identity)
+	if participant == nil {
+		return ErrParticipantNotFound
+	}
+	participant.SetDeviceClass(class)
+	return nil
+}
+
+func (r *RoomManager) handleUpdateDeviceClass(op *routing.RoomOperation) (interface{}, error) {
+	req := UpdateDeviceClassRequest{}
+	if err := op.DecodeParams(&req); err != nil {
+		return nil, err
+	}
+	return nil, r.SetParticipantDeviceClass(op.Room, op.Identity, req.DeviceClass)
+}
@@ -0,0 +1,39 @@
+package service
+
+import (
+	"github.com/livekit/livekit-server/pkg/routing"
+)
+
+const roomOpUpdateMaxDownloadBitrate = "update_max_download_bitrate"
+
+// UpdateMaxDownloadBitrateRequest changes the download budget of a participant mid-session, in bits per second
+type UpdateMaxDownloadBitrateRequest struct {
+	Room     string `json:"room"`
+	Identity string `json:"identity"`
+	Bitrate  uint64 `json:"bitrate"`
+}
+
+type UpdateMaxDownloadBitrateResponse struct{}
+
+// SetParticipantMaxDownloadBitrate changes the max bitrate of media sent to a participant in a room hosted on this
+// node, 0 removes the limit
+func (r *RoomManager) SetParticipantMaxDownloadBitrate(roomName, identity string, bitrate uint64) error {
+	room := r.GetRoom(roomName)
+	if room == nil {
+		return ErrRoomNotFound
+	}
+	participant := room.GetParticipant(identity)
+	if participant == nil {
+		return ErrParticipantNotFound
+	}
+	participant.SetMaxDownloadBitrate(bitrate)
+	return nil
+}
+
+func (r *RoomManager) handleUpdateMaxDownloadBitrate(op *routing.RoomOperation) (interface{}, error) {
+	req := UpdateMaxDownloadBitrateRequest{}
+	if err := op.DecodeParams(&req); err != nil {
+		return nil, err
+	}
+	return nil, r.SetParticipantMaxDownloadBitrate(op.Room, op.Identity, req.Bitrate)
+}
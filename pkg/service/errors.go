@@ -8,4 +8,8 @@ var (
 	ErrRoomUnlockFailed    = errors.New("could not unlock room, lock token does not match")
 	ErrParticipantNotFound = errors.New("participant does not exist")
 	ErrTrackNotFound       = errors.New("track is not found")
+	ErrRoomNotHosted       = errors.New("room is not hosted on this node")
+	ErrNotAdmitted         = errors.New("participant was not admitted")
+	ErrRoomFull            = errors.New("room is full")
+	ErrInvalidBulkAction   = errors.New("action must be mute, unmute or remove")
 )
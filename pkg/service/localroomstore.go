@@ -17,6 +17,16 @@ type LocalRoomStore struct {
 	participants map[string]map[string]*livekit.ParticipantInfo
 	lock         sync.RWMutex
 	globalLock   sync.Mutex
+
+	// map of roomName => unix time participants last changed
+	lastActivity map[string]int64
+	// map of roomName => past sessions, oldest first
+	sessions map[string][]sessionRecord
+}
+
+type sessionRecord struct {
+	session *ParticipantSession
+	expires time.Time
 }
 
 func NewLocalRoomStore() *LocalRoomStore {
@@ -25,6 +35,8 @@ func NewLocalRoomStore() *LocalRoomStore {
 		roomIds:      make(map[string]string),
 		participants: make(map[string]map[string]*livekit.ParticipantInfo),
 		lock:         sync.RWMutex{},
+		lastActivity: make(map[string]int64),
+		sessions:     make(map[string][]sessionRecord),
 	}
 }
 
@@ -76,6 +88,7 @@ func (p *LocalRoomStore) DeleteRoom(idOrName string) error {
 	defer p.lock.Unlock()
 
 	delete(p.participants, room.Name)
+	delete(p.lastActivity, room.Name)
 	delete(p.roomIds, room.Name)
 	delete(p.rooms, room.Sid)
 	return nil
@@ -101,6 +114,7 @@ func (p *LocalRoomStore) PersistParticipant(roomName string, participant *liveki
 		p.participants[roomName] = roomParticipants
 	}
 	roomParticipants[participant.Identity] = participant
+	p.lastActivity[roomName] = time.Now().Unix()
 	return nil
 }
 
@@ -144,6 +158,66 @@ func (p *LocalRoomStore) DeleteParticipant(roomName, identity string) error {
 	roomParticipants := p.participants[roomName]
 	if roomParticipants != nil {
 		delete(roomParticipants, identity)
+		p.lastActivity[roomName] = time.Now().Unix()
+	}
+	return nil
+}
+
+func (p *LocalRoomStore) GetRoomStats(idOrName string) (*RoomStats, error) {
+	room, err := p.GetRoom(idOrName)
+	if err != nil {
+		return nil, err
+	}
+
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	stats := &RoomStats{
+		NumParticipants: uint32(len(p.participants[room.Name])),
+		CreationTime:    room.CreationTime,
+		LastActivity:    room.CreationTime,
+	}
+	for _, participant := range p.participants[room.Name] {
+		stats.NumPublishedTracks += uint32(len(participant.Tracks))
 	}
+	if activity := p.lastActivity[room.Name]; activity > stats.LastActivity {
+		stats.LastActivity = activity
+	}
+	return stats, nil
+}
+
+func (p *LocalRoomStore) AppendParticipantSession(roomName string, session *ParticipantSession, maxSessions int, retention time.Duration) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	now := time.Now()
+	records := make([]sessionRecord, 0, len(p.sessions[roomName])+1)
+	for _, record := range p.sessions[roomName] {
+		if record.expires.After(now) {
+			records = append(records, record)
+		}
+	}
+	records = append(records, sessionRecord{session: session, expires: now.Add(retention)})
+	if len(records) > maxSessions {
+		records = records[len(records)-maxSessions:]
+	}
+	p.sessions[roomName] = records
 	return nil
 }
+
+func (p *LocalRoomStore) ListParticipantSessions(roomName, identity string) ([]*ParticipantSession, error) {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	now := time.Now()
+	records := p.sessions[roomName]
+	var sessions []*ParticipantSession
+	for i := len(records) - 1; i >= 0; i-- {
+		record := records[i]
+		if !record.expires.After(now) || (identity != "" && record.session.Identity != identity) {
+			continue
+		}
+		sessions = append(sessions, record.session)
+	}
+	return sessions, nil
+}
@@ -0,0 +1,38 @@
+package service
+
+import (
+	"github.com/livekit/livekit-server/pkg/routing"
+)
+
+const roomOpUpdateNackWindow = "update_nack_window"
+
+// UpdateNackWindowRequest changes the retransmission window of the participant in the join token mid-session, in
+// packets, 0 for the server's
+type UpdateNackWindowRequest struct {
+	NackWindow uint32 `json:"nack_window"`
+}
+
+type UpdateNackWindowResponse struct{}
+
+// SetParticipantNackWindow changes the retransmission window of a participant in a room hosted on this node,
+// for streams it subscribes to from now on
+func (r *RoomManager) SetParticipantNackWindow(roomName, identity string, packets int) error {
+	room := r.GetRoom(roomName)
+	if room == nil {
+		return ErrRoomNotFound
+	}
+	participant := room.GetParticipant(identity)
+	if participant == nil {
+		return ErrParticipantNotFound
+	}
+	participant.SetNackWindow(packets)
+	return nil
+}
+
+func (r *RoomManager) handleUpdateNackWindow(op *routing.RoomOperation) (interface{}, error) {
+	req := UpdateNackWindowRequest{}
+	if err := op.DecodeParams(&req); err != nil {
+		return nil, err
+	}
+	return nil, r.SetParticipantNackWindow(op.Room, op.Identity, int(req.NackWindow))
+}
@@ -0,0 +1,38 @@
+package service
+
+import (
+	"github.com/livekit/livekit-server/pkg/routing"
+)
+
+const roomOpUpdateParticipantName = "update_participant_name"
+
+// UpdateParticipantNameRequest renames a participant mid-session
+type UpdateParticipantNameRequest struct {
+	Room     string `json:"room"`
+	Identity string `json:"identity"`
+	Name     string `json:"name"`
+}
+
+type UpdateParticipantNameResponse struct{}
+
+// SetParticipantName renames a participant in a room hosted on this node
+func (r *RoomManager) SetParticipantName(roomName, identity, name string) error {
+	room := r.GetRoom(roomName)
+	if room == nil {
+		return ErrRoomNotFound
+	}
+	participant := room.GetParticipant(identity)
+	if participant == nil {
+		return ErrParticipantNotFound
+	}
+	participant.SetName(name)
+	return nil
+}
+
+func (r *RoomManager) handleUpdateParticipantName(op *routing.RoomOperation) (interface{}, error) {
+	req := UpdateParticipantNameRequest{}
+	if err := op.DecodeParams(&req); err != nil {
+		return nil, err
+	}
+	return nil, r.SetParticipantName(op.Room, op.Identity, req.Name)
+}
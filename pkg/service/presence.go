@@ -0,0 +1,355 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/livekit/livekit-server/pkg/logger"
+	"github.com/livekit/livekit-server/pkg/routing"
+)
+
+const (
+	roomOpPresenceEvent = "presence_event"
+	roomOpListPresence  = "list_presence"
+)
+
+type Presence string
+
+const (
+	PresenceOnline  Presence = "online"
+	PresenceAway    Presence = "away"
+	PresenceOffline Presence = "offline"
+)
+
+// events buffered for each subscriber, events are dropped for subscribers that fall behind
+const presenceBufferSize = 100
+
+type PresenceEvent struct {
+	Room     string   `json:"room"`
+	Identity string   `json:"identity"`
+	Presence Presence `json:"presence"`
+	// unix timestamp in milliseconds of when the presence was reported
+	Timestamp int64 `json:"timestamp"`
+}
+
+// ListPresenceRequest lists the current presence of participants on every node, of those in room when it's given
+type ListPresenceRequest struct {
+	Room string `json:"room,omitempty"`
+}
+
+type ListPresenceResponse struct {
+	Presence []PresenceEvent `json:"presence"`
+}
+
+type presenceKey struct {
+	room     string
+	identity string
+}
+
+type presenceEntry struct {
+	// last presence that's been reported
+	reported Presence
+	// set while a change to away or offline is being debounced
+	pending *time.Timer
+}
+
+// PresenceTracker reports presence of participants hosted on this node.
+// Participants are reported online right away, while away and offline have to hold for the debounce
+// interval before they are reported, so interruptions that recover quickly don't cause churn
+type PresenceTracker struct {
+	debounce time.Duration
+
+	lock        sync.Mutex
+	entries     map[presenceKey]*presenceEntry
+	subscribers map[chan PresenceEvent]struct{}
+	// set when events are shared with other nodes instead of going to subscribers right away
+	reports  chan PresenceEvent
+	onReport func(PresenceEvent)
+}
+
+func NewPresenceTracker(debounce time.Duration) *PresenceTracker {
+	return &PresenceTracker{
+		debounce:    debounce,
+		entries:     make(map[presenceKey]*presenceEntry),
+		subscribers: make(map[chan PresenceEvent]struct{}),
+	}
+}
+
+// Update records the current presence of a participant
+func (t *PresenceTracker) Update(room, identity string, presence Presence) {
+	key := presenceKey{room: room, identity: identity}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	entry := t.entries[key]
+	if entry == nil {
+		if presence == PresenceOffline {
+			// never reported
+			return
+		}
+		entry = &presenceEntry{}
+		t.entries[key] = entry
+	}
+
+	if entry.pending != nil {
+		entry.pending.Stop()
+		entry.pending = nil
+	}
+	if presence == entry.reported {
+		return
+	}
+	if presence == PresenceOnline || t.debounce <= 0 {
+		t.report(key, entry, presence)
+		return
+	}
+
+	var timer *time.Timer
+	timer = time.AfterFunc(t.debounce, func() {
+		t.lock.Lock()
+		defer t.lock.Unlock()
+		// superseded by a later update
+		if t.entries[key] != entry || entry.pending != timer {
+			return
+		}
+		entry.pending = nil
+		t.report(key, entry, presence)
+	})
+	entry.pending = timer
+}
+
+// OnReport sets where presence changes are reported to, in the order they happen, instead of subscribers of this
+// node. Nodes share them through the router, each delivers them to its own subscribers with Deliver
+func (t *PresenceTracker) OnReport(f func(PresenceEvent)) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.reports == nil {
+		t.reports = make(chan PresenceEvent, presenceBufferSize)
+		go t.reportWorker(t.reports)
+	}
+	t.onReport = f
+}
+
+// Deliver sends an event to subscribers of this node
+func (t *PresenceTracker) Deliver(event PresenceEvent) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.deliver(event)
+}
+
+// List returns the current presence of participants hosted on this node, of those in room when it's given
+func (t *PresenceTracker) List(room string) []PresenceEvent {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.list(room)
+}
+
+// Subscribe returns a channel of presence events, starting with the current presence of all participants.
+// The returned function should be called to stop receiving events
+func (t *PresenceTracker) Subscribe() (<-chan PresenceEvent, func()) {
+	return t.subscribe(true)
+}
+
+// subscribe starts with the current presence of participants on this node when withCurrent is set
+func (t *PresenceTracker) subscribe(withCurrent bool) (<-chan PresenceEvent, func()) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	ch := make(chan PresenceEvent, len(t.entries)+presenceBufferSize)
+	if withCurrent {
+		for _, event := range t.list("") {
+			ch <- event
+		}
+	}
+	t.subscribers[ch] = struct{}{}
+
+	return ch, func() {
+		t.lock.Lock()
+		defer t.lock.Unlock()
+		if _, ok := t.subscribers[ch]; ok {
+			delete(t.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// needs to be called with lock held
+func (t *PresenceTracker) report(key presenceKey, entry *presenceEntry, presence Presence) {
+	entry.reported = presence
+	if presence == PresenceOffline {
+		delete(t.entries, key)
+	}
+	logger.Debugw("participant presence changed",
+		"room", key.room,
+		"participant", key.identity,
+		"presence", presence)
+
+	event := PresenceEvent{
+		Room:      key.room,
+		Identity:  key.identity,
+		Presence:  presence,
+		Timestamp: time.Now().UnixNano() / int64(time.Millisecond),
+	}
+	if t.reports == nil {
+		t.deliver(event)
+		return
+	}
+	select {
+	case t.reports <- event:
+	default:
+		logger.Warnw("dropping presence event, report queue is full", nil,
+			"room", key.room,
+			"participant", key.identity)
+	}
+}
+
+// needs to be called with lock held
+func (t *PresenceTracker) deliver(event PresenceEvent) {
+	for ch := range t.subscribers {
+		select {
+		case ch <- event:
+		default:
+			logger.Debugw("dropping presence event for slow subscriber",
+				"room", event.Room,
+				"participant", event.Identity)
+		}
+	}
+}
+
+// needs to be called with lock held
+func (t *PresenceTracker) list(room string) []PresenceEvent {
+	events := make([]PresenceEvent, 0, len(t.entries))
+	now := time.Now().UnixNano() / int64(time.Millisecond)
+	for key, entry := range t.entries {
+		if entry.reported == "" || (room != "" && key.room != room) {
+			continue
+		}
+		events = append(events, PresenceEvent{
+			Room:      key.room,
+			Identity:  key.identity,
+			Presence:  entry.reported,
+			Timestamp: now,
+		})
+	}
+	return events
+}
+
+func (t *PresenceTracker) reportWorker(reports <-chan PresenceEvent) {
+	for event := range reports {
+		t.lock.Lock()
+		onReport := t.onReport
+		t.lock.Unlock()
+		if onReport != nil {
+			onReport(event)
+		}
+	}
+}
+
+// broadcastPresence shares presence events of participants hosted on this node with every node
+func (r *RoomManager) broadcastPresence(event PresenceEvent) {
+	op, err := routing.NewRoomOperation(roomOpPresenceEvent, event.Room, event.Identity, event)
+	if err == nil {
+		_, err = r.router.BroadcastRoomOperation(context.Background(), op)
+	}
+	if err != nil {
+		logger.Warnw("could not broadcast presence event", err,
+			"room", event.Room,
+			"participant", event.Identity)
+	}
+}
+
+func (r *RoomManager) handlePresenceEvent(op *routing.RoomOperation) (interface{}, error) {
+	event := PresenceEvent{}
+	if err := op.DecodeParams(&event); err != nil {
+		return nil, err
+	}
+	r.presence.Deliver(event)
+	return nil, nil
+}
+
+func (r *RoomManager) handleListPresence(op *routing.RoomOperation) (interface{}, error) {
+	return r.presence.List(op.Room), nil
+}
+
+// ServePresence streams presence events of participants on every node as newline delimited JSON, starting with
+// their current presence, until the client disconnects.
+// Requires list permission, or admin permission when filtered to a single room with ?room=
+func (s *RoomService) ServePresence(w http.ResponseWriter, r *http.Request) {
+	room := r.URL.Query().Get("room")
+	if err := ensurePresencePermission(r.Context(), room); err != nil {
+		handleError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		handleError(w, http.StatusInternalServerError, "streaming is not supported")
+		return
+	}
+
+	// subscribed before listing, so changes in between aren't missed
+	events, unsubscribe := s.roomManager.presence.subscribe(false)
+	defer unsubscribe()
+	current, err := s.listPresence(r.Context(), room)
+	if err != nil {
+		handleError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+
+	encoder := json.NewEncoder(w)
+	for i := range current {
+		if err := encoder.Encode(&current[i]); err != nil {
+			return
+		}
+	}
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event := <-events:
+			if room != "" && event.Room != room {
+				continue
+			}
+			if err := encoder.Encode(&event); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// listPresence gathers the current presence of participants from every node
+func (s *RoomService) listPresence(ctx context.Context, room string) ([]PresenceEvent, error) {
+	op, err := routing.NewRoomOperation(roomOpListPresence, room, "", nil)
+	if err != nil {
+		return nil, err
+	}
+	results, err := s.roomManager.router.BroadcastRoomOperation(ctx, op)
+	if err != nil {
+		return nil, twirpRoomOperationError(err)
+	}
+
+	presence := make([]PresenceEvent, 0)
+	for _, result := range results {
+		var events []PresenceEvent
+		if err := json.Unmarshal(result, &events); err != nil {
+			return nil, err
+		}
+		presence = append(presence, events...)
+	}
+	return presence, nil
+}
+
+func ensurePresencePermission(ctx context.Context, room string) error {
+	if room != "" {
+		return EnsureAdminPermission(ctx, room)
+	}
+	return EnsureListPermission(ctx)
+}
@@ -0,0 +1,110 @@
+package service_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/service"
+)
+
+const presenceDebounce = 50 * time.Millisecond
+
+func TestPresenceTracker(t *testing.T) {
+	t.Run("online is reported right away", func(t *testing.T) {
+		tracker := service.NewPresenceTracker(presenceDebounce)
+		events, unsubscribe := tracker.Subscribe()
+		defer unsubscribe()
+
+		tracker.Update("room", "p1", service.PresenceOnline)
+		event := receivePresence(t, events)
+		require.Equal(t, "room", event.Room)
+		require.Equal(t, "p1", event.Identity)
+		require.Equal(t, service.PresenceOnline, event.Presence)
+		require.NotZero(t, event.Timestamp)
+	})
+
+	t.Run("brief interruptions are not reported", func(t *testing.T) {
+		tracker := service.NewPresenceTracker(presenceDebounce)
+		tracker.Update("room", "p1", service.PresenceOnline)
+		events, unsubscribe := tracker.Subscribe()
+		defer unsubscribe()
+		require.Equal(t, service.PresenceOnline, receivePresence(t, events).Presence)
+
+		tracker.Update("room", "p1", service.PresenceAway)
+		tracker.Update("room", "p1", service.PresenceOnline)
+		tracker.Update("room", "p1", service.PresenceOffline)
+		tracker.Update("room", "p1", service.PresenceOnline)
+		requireNoPresence(t, events)
+	})
+
+	t.Run("away and offline are reported after debounce", func(t *testing.T) {
+		tracker := service.NewPresenceTracker(presenceDebounce)
+		events, unsubscribe := tracker.Subscribe()
+		defer unsubscribe()
+
+		tracker.Update("room", "p1", service.PresenceOnline)
+		require.Equal(t, service.PresenceOnline, receivePresence(t, events).Presence)
+
+		start := time.Now()
+		tracker.Update("room", "p1", service.PresenceAway)
+		require.Equal(t, service.PresenceAway, receivePresence(t, events).Presence)
+		require.GreaterOrEqual(t, int64(time.Since(start)), int64(presenceDebounce))
+
+		tracker.Update("room", "p1", service.PresenceOffline)
+		require.Equal(t, service.PresenceOffline, receivePresence(t, events).Presence)
+
+		// offline participants are no longer included for new subscribers
+		newEvents, unsubscribeNew := tracker.Subscribe()
+		defer unsubscribeNew()
+		requireNoPresence(t, newEvents)
+	})
+
+	t.Run("offline is not reported for participants never online", func(t *testing.T) {
+		tracker := service.NewPresenceTracker(presenceDebounce)
+		events, unsubscribe := tracker.Subscribe()
+		defer unsubscribe()
+
+		tracker.Update("room", "p1", service.PresenceOffline)
+		requireNoPresence(t, events)
+	})
+
+	t.Run("reported events are delivered by the node receiving them", func(t *testing.T) {
+		tracker := service.NewPresenceTracker(presenceDebounce)
+		reported := make(chan service.PresenceEvent, 1)
+		tracker.OnReport(func(event service.PresenceEvent) {
+			reported <- event
+		})
+		events, unsubscribe := tracker.Subscribe()
+		defer unsubscribe()
+
+		tracker.Update("room", "p1", service.PresenceOnline)
+		event := receivePresence(t, reported)
+		require.Equal(t, "p1", event.Identity)
+		requireNoPresence(t, events)
+		require.Len(t, tracker.List("room"), 1)
+		require.Empty(t, tracker.List("other"))
+
+		tracker.Deliver(event)
+		require.Equal(t, service.PresenceOnline, receivePresence(t, events).Presence)
+	})
+}
+
+func receivePresence(t *testing.T, events <-chan service.PresenceEvent) service.PresenceEvent {
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(time.Second):
+		require.Fail(t, "timed out waiting for presence event")
+	}
+	return service.PresenceEvent{}
+}
+
+func requireNoPresence(t *testing.T, events <-chan service.PresenceEvent) {
+	select {
+	case event := <-events:
+		require.Fail(t, "unexpected presence event", "%+v", event)
+	case <-time.After(2 * presenceDebounce):
+	}
+}
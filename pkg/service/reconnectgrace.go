@@ -0,0 +1,42 @@
+package service
+
+import (
+	"time"
+
+	"github.com/livekit/livekit-server/pkg/routing"
+)
+
+const roomOpUpdateReconnectGrace = "update_reconnect_grace"
+
+// UpdateReconnectGraceRequest changes the grace period of a participant mid-session, in seconds. It isn't capped
+// by max_reconnect_grace, 0 closes a disconnected participant right away
+type UpdateReconnectGraceRequest struct {
+	Room     string `json:"room"`
+	Identity string `json:"identity"`
+	Grace    uint32 `json:"grace"`
+}
+
+type UpdateReconnectGraceResponse struct{}
+
+// SetParticipantReconnectGrace changes the reconnect grace period of a participant in a room hosted on this node,
+// including while it's reconnecting
+func (r *RoomManager) SetParticipantReconnectGrace(roomName, identity string, grace time.Duration) error {
+	room := r.GetRoom(roomName)
+	if room == nil {
+		return ErrRoomNotFound
+	}
+	participant := room.GetParticipant(identity)
+	if participant == nil {
+		return ErrParticipantNotFound
+	}
+	participant.SetReconnectGrace(grace)
+	return nil
+}
+
+func (r *RoomManager) handleUpdateReconnectGrace(op *routing.RoomOperation) (interface{}, error) {
+	req := UpdateReconnectGraceRequest{}
+	if err := op.DecodeParams(&req); err != nil {
+		return nil, err
+	}
+	return nil, r.SetParticipantReconnectGrace(op.Room, op.Identity, time.Duration(req.Grace)*time.Second)
+}
@@ -2,12 +2,13 @@ package service
 
 import (
 	"context"
+	"encoding/json"
+	"strconv"
 	"time"
 
 	"github.com/go-redis/redis/v8"
 	"github.com/livekit/protocol/utils"
 	"github.com/pkg/errors"
-	"google.golang.org/protobuf/proto"
 
 	livekit "github.com/livekit/livekit-server/proto"
 )
@@ -25,26 +26,76 @@ const (
 
 	// RoomLockPrefix is a simple key containing a provided lock uid
 	RoomLockPrefix = "room_lock:"
+
+	// RoomStatsPrefix is hash of counters kept along participants, a key for each room.
+	// tracks:<identity> is the number of tracks of a participant, tracks their total,
+	// and last_activity the time participants last changed
+	RoomStatsPrefix = "room_stats:"
+
+	// RoomSessionsPrefix is a sorted set of past sessions of participants as json, scored by the time they ended.
+	// a key for each room, expiring along its last session
+	RoomSessionsPrefix = "room_sessions:"
 )
 
+// stores a participant and updates room stats in a single step, so the track count matches stored participants
+var persistParticipantScript = redis.NewScript(`
+local field = "tracks:" .. ARGV[1]
+local prev = tonumber(redis.call("hget", KEYS[2], field) or "0")
+redis.call("hset", KEYS[1], ARGV[1], ARGV[2])
+redis.call("hset", KEYS[2], field, ARGV[3])
+redis.call("hincrby", KEYS[2], "tracks", tonumber(ARGV[3]) - prev)
+redis.call("hset", KEYS[2], "last_activity", ARGV[4])
+return 0
+`)
+
+var deleteParticipantScript = redis.NewScript(`
+if redis.call("hdel", KEYS[1], ARGV[1]) == 0 then
+	return 0
+end
+local field = "tracks:" .. ARGV[1]
+local prev = tonumber(redis.call("hget", KEYS[2], field) or "0")
+redis.call("hdel", KEYS[2], field)
+redis.call("hincrby", KEYS[2], "tracks", -prev)
+redis.call("hset", KEYS[2], "last_activity", ARGV[2])
+return 1
+`)
+
+// deletes the lock only if it's still held with the given token, in a single step so a lock that expired
+// and was taken by someone else in the meantime isn't released
+var unlockScript = redis.NewScript(`
+if redis.call("get", KEYS[1]) == ARGV[1] then
+	return redis.call("del", KEYS[1])
+end
+if redis.call("exists", KEYS[1]) == 1 then
+	return -1
+end
+return 0
+`)
+
+// RedisRoomStore keeps rooms and participants in redis, so they're shared by all nodes and survive restarts
 type RedisRoomStore struct {
-	rc  *redis.Client
-	ctx context.Context
+	rc     *redis.Client
+	ctx    context.Context
+	format storeFormat
 }
 
-func NewRedisRoomStore(rc *redis.Client) *RedisRoomStore {
+// NewRedisRoomStore returns a store writing rooms and participants in format, protobuf or json
+func NewRedisRoomStore(rc *redis.Client, format string) *RedisRoomStore {
 	return &RedisRoomStore{
-		ctx: context.Background(),
-		rc:  rc,
+		ctx:    context.Background(),
+		rc:     rc,
+		format: storeFormat(format),
 	}
 }
 
+var _ RoomStore = (*RedisRoomStore)(nil)
+
 func (p *RedisRoomStore) CreateRoom(room *livekit.Room) error {
 	if room.CreationTime == 0 {
 		room.CreationTime = time.Now().Unix()
 	}
 
-	data, err := proto.Marshal(room)
+	data, err := p.format.marshal(room)
 	if err != nil {
 		return err
 	}
@@ -75,7 +126,7 @@ func (p *RedisRoomStore) GetRoom(idOrName string) (*livekit.Room, error) {
 	}
 
 	room := livekit.Room{}
-	err = proto.Unmarshal([]byte(data), &room)
+	err = unmarshalStored([]byte(data), &room)
 	if err != nil {
 		return nil, err
 	}
@@ -93,7 +144,7 @@ func (p *RedisRoomStore) ListRooms() ([]*livekit.Room, error) {
 
 	for _, item := range items {
 		room := livekit.Room{}
-		err := proto.Unmarshal([]byte(item), &room)
+		err := unmarshalStored([]byte(item), &room)
 		if err != nil {
 			return nil, err
 		}
@@ -121,6 +172,7 @@ func (p *RedisRoomStore) DeleteRoom(idOrName string) error {
 	pp.HDel(p.ctx, RoomIdMap, sid)
 	pp.HDel(p.ctx, RoomsKey, name)
 	pp.Del(p.ctx, RoomParticipantsPrefix+name)
+	pp.Del(p.ctx, RoomStatsPrefix+name)
 
 	_, err = pp.Exec(p.ctx)
 	return err
@@ -154,29 +206,27 @@ func (p *RedisRoomStore) LockRoom(name string, duration time.Duration) (string,
 func (p *RedisRoomStore) UnlockRoom(name string, uid string) error {
 	key := RoomLockPrefix + name
 
-	val, err := p.rc.Get(p.ctx, key).Result()
-	if err == redis.Nil {
-		// already unlocked
-		return nil
-	} else if err != nil {
+	res, err := unlockScript.Run(p.ctx, p.rc, []string{key}, uid).Int()
+	if err != nil {
 		return err
 	}
-
-	if val != uid {
+	// 0 when it's already unlocked
+	if res < 0 {
 		return ErrRoomUnlockFailed
 	}
-	return p.rc.Del(p.ctx, key).Err()
+	return nil
 }
 
 func (p *RedisRoomStore) PersistParticipant(roomName string, participant *livekit.ParticipantInfo) error {
 	key := RoomParticipantsPrefix + roomName
 
-	data, err := proto.Marshal(participant)
+	data, err := p.format.marshal(participant)
 	if err != nil {
 		return err
 	}
 
-	return p.rc.HSet(p.ctx, key, participant.Identity, data).Err()
+	return persistParticipantScript.Run(p.ctx, p.rc, []string{key, RoomStatsPrefix + roomName},
+		participant.Identity, data, len(participant.Tracks), time.Now().Unix()).Err()
 }
 
 func (p *RedisRoomStore) GetParticipant(roomName, identity string) (*livekit.ParticipantInfo, error) {
@@ -189,7 +239,7 @@ func (p *RedisRoomStore) GetParticipant(roomName, identity string) (*livekit.Par
 	}
 
 	pi := livekit.ParticipantInfo{}
-	if err := proto.Unmarshal([]byte(data), &pi); err != nil {
+	if err := unmarshalStored([]byte(data), &pi); err != nil {
 		return nil, err
 	}
 	return &pi, nil
@@ -207,7 +257,7 @@ func (p *RedisRoomStore) ListParticipants(roomName string) ([]*livekit.Participa
 	participants := make([]*livekit.ParticipantInfo, 0, len(items))
 	for _, item := range items {
 		pi := livekit.ParticipantInfo{}
-		if err := proto.Unmarshal([]byte(item), &pi); err != nil {
+		if err := unmarshalStored([]byte(item), &pi); err != nil {
 			return nil, err
 		}
 		participants = append(participants, &pi)
@@ -218,5 +268,79 @@ func (p *RedisRoomStore) ListParticipants(roomName string) ([]*livekit.Participa
 func (p *RedisRoomStore) DeleteParticipant(roomName, identity string) error {
 	key := RoomParticipantsPrefix + roomName
 
-	return p.rc.HDel(p.ctx, key, identity).Err()
+	return deleteParticipantScript.Run(p.ctx, p.rc, []string{key, RoomStatsPrefix + roomName},
+		identity, time.Now().Unix()).Err()
+}
+
+func (p *RedisRoomStore) GetRoomStats(idOrName string) (*RoomStats, error) {
+	room, err := p.GetRoom(idOrName)
+	if err != nil {
+		return nil, err
+	}
+
+	pp := p.rc.Pipeline()
+	numParticipants := pp.HLen(p.ctx, RoomParticipantsPrefix+room.Name)
+	counters := pp.HMGet(p.ctx, RoomStatsPrefix+room.Name, "tracks", "last_activity")
+	if _, err := pp.Exec(p.ctx); err != nil && err != redis.Nil {
+		return nil, errors.Wrap(err, "could not get room stats")
+	}
+
+	stats := &RoomStats{
+		NumParticipants: uint32(numParticipants.Val()),
+		CreationTime:    room.CreationTime,
+		LastActivity:    room.CreationTime,
+	}
+	values := counters.Val()
+	if tracks, ok := values[0].(string); ok {
+		if n, err := strconv.ParseInt(tracks, 10, 64); err == nil && n > 0 {
+			stats.NumPublishedTracks = uint32(n)
+		}
+	}
+	if activity, ok := values[1].(string); ok {
+		if t, err := strconv.ParseInt(activity, 10, 64); err == nil && t > stats.LastActivity {
+			stats.LastActivity = t
+		}
+	}
+	return stats, nil
+}
+
+func (p *RedisRoomStore) AppendParticipantSession(roomName string, session *ParticipantSession, maxSessions int, retention time.Duration) error {
+	key := RoomSessionsPrefix + roomName
+	data, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+
+	cutoff := time.Now().Add(-retention).Unix()
+	pp := p.rc.TxPipeline()
+	pp.ZAdd(p.ctx, key, &redis.Z{Score: float64(session.LeftAt), Member: data})
+	pp.ZRemRangeByScore(p.ctx, key, "-inf", "("+strconv.FormatInt(cutoff, 10))
+	// keeps the most recent
+	pp.ZRemRangeByRank(p.ctx, key, 0, int64(-maxSessions-1))
+	pp.Expire(p.ctx, key, retention)
+	_, err = pp.Exec(p.ctx)
+	return err
+}
+
+func (p *RedisRoomStore) ListParticipantSessions(roomName, identity string) ([]*ParticipantSession, error) {
+	key := RoomSessionsPrefix + roomName
+	items, err := p.rc.ZRevRange(p.ctx, key, 0, -1).Result()
+	if err == redis.Nil {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var sessions []*ParticipantSession
+	for _, item := range items {
+		session := &ParticipantSession{}
+		if err := json.Unmarshal([]byte(item), session); err != nil {
+			return nil, err
+		}
+		if identity != "" && session.Identity != identity {
+			continue
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, nil
 }
@@ -1,18 +1,22 @@
 package service_test
 
 import (
+	"strings"
 	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/routing"
+	"github.com/livekit/livekit-server/pkg/routing/routingfakes"
 	"github.com/livekit/livekit-server/pkg/service"
 	livekit "github.com/livekit/livekit-server/proto"
 	"github.com/stretchr/testify/require"
 )
 
 func TestParticipantPersistence(t *testing.T) {
-	rs := service.NewRedisRoomStore(redisClient())
+	rs := service.NewRedisRoomStore(redisClient(), config.StoreFormatProtobuf)
 
 	roomName := "room1"
 	rs.DeleteRoom(roomName)
@@ -57,8 +61,106 @@ func TestParticipantPersistence(t *testing.T) {
 	require.Equal(t, err, service.ErrParticipantNotFound)
 }
 
+func TestStoreFormatChange(t *testing.T) {
+	jsonStore := service.NewRedisRoomStore(redisClient(), config.StoreFormatJSON)
+	protoStore := service.NewRedisRoomStore(redisClient(), config.StoreFormatProtobuf)
+
+	roomName := "room_format"
+	_ = protoStore.DeleteRoom(roomName)
+	defer protoStore.DeleteRoom(roomName)
+
+	// entries written in one format are read after switching to the other
+	require.NoError(t, jsonStore.CreateRoom(&livekit.Room{Sid: "RM_format", Name: roomName}))
+	require.NoError(t, protoStore.PersistParticipant(roomName, &livekit.ParticipantInfo{Identity: "proto", Metadata: "p"}))
+	require.NoError(t, jsonStore.PersistParticipant(roomName, &livekit.ParticipantInfo{Identity: "json", Metadata: "j"}))
+	// encoded as '\n' followed by its length, '{'
+	longSid := strings.Repeat("s", '{')
+	require.NoError(t, protoStore.PersistParticipant(roomName, &livekit.ParticipantInfo{Sid: longSid, Identity: "sid"}))
+
+	room, err := protoStore.GetRoom(roomName)
+	require.NoError(t, err)
+	require.Equal(t, "RM_format", room.Sid)
+
+	for _, rs := range []*service.RedisRoomStore{jsonStore, protoStore} {
+		participants, err := rs.ListParticipants(roomName)
+		require.NoError(t, err)
+		require.Len(t, participants, 3)
+
+		p, err := rs.GetParticipant(roomName, "proto")
+		require.NoError(t, err)
+		require.Equal(t, "p", p.Metadata)
+		p, err = rs.GetParticipant(roomName, "json")
+		require.NoError(t, err)
+		require.Equal(t, "j", p.Metadata)
+		p, err = rs.GetParticipant(roomName, "sid")
+		require.NoError(t, err)
+		require.Equal(t, longSid, p.Sid)
+	}
+}
+
+func TestConcurrentRoomCreation(t *testing.T) {
+	rs := service.NewRedisRoomStore(redisClient(), config.StoreFormatProtobuf)
+	roomName := "room_race"
+	_ = rs.DeleteRoom(roomName)
+	defer rs.DeleteRoom(roomName)
+
+	conf, err := config.NewConfig("", nil)
+	require.NoError(t, err)
+	// managers can't share the TCP port
+	conf.RTC.TCPPort = 0
+	node, err := routing.NewLocalNode(conf)
+	require.NoError(t, err)
+
+	// the room is only assigned to a node once, by whoever creates it
+	var nodeLock sync.Mutex
+	var roomNode string
+	router := &routingfakes.FakeRouter{}
+	router.GetNodeForRoomStub = func(string) (*livekit.Node, error) {
+		nodeLock.Lock()
+		defer nodeLock.Unlock()
+		if roomNode == "" {
+			return nil, routing.ErrNotFound
+		}
+		return node, nil
+	}
+	router.SetNodeForRoomStub = func(_ string, nodeId string) error {
+		nodeLock.Lock()
+		defer nodeLock.Unlock()
+		roomNode = nodeId
+		return nil
+	}
+	router.ListNodesReturns([]*livekit.Node{node}, nil)
+
+	// a manager for each node racing to create the room on its first join
+	const numNodes = 4
+	rooms := make([]*livekit.Room, numNodes)
+	var wg sync.WaitGroup
+	for i := 0; i < numNodes; i++ {
+		manager, err := service.NewRoomManager(rs, router, node, &routing.RandomSelector{}, conf, nil)
+		require.NoError(t, err)
+		t.Cleanup(manager.Stop)
+
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			rm, err := manager.CreateRoom(&livekit.CreateRoomRequest{Name: roomName})
+			require.NoError(t, err)
+			rooms[i] = rm
+		}(i)
+	}
+	wg.Wait()
+
+	stored, err := rs.GetRoom(roomName)
+	require.NoError(t, err)
+	for _, rm := range rooms {
+		require.Equal(t, stored.Sid, rm.Sid)
+		require.Equal(t, stored.CreationTime, rm.CreationTime)
+	}
+	require.Equal(t, 1, router.SetNodeForRoomCallCount())
+}
+
 func TestRoomLock(t *testing.T) {
-	rs := service.NewRedisRoomStore(redisClient())
+	rs := service.NewRedisRoomStore(redisClient(), config.StoreFormatProtobuf)
 	lockInterval := 5 * time.Millisecond
 	roomName := "myroom"
 
@@ -94,6 +196,21 @@ func TestRoomLock(t *testing.T) {
 		wg.Wait()
 	})
 
+	t.Run("an expired lock can't release the next one", func(t *testing.T) {
+		token, err := rs.LockRoom(roomName, lockInterval)
+		require.NoError(t, err)
+
+		time.Sleep(lockInterval + time.Millisecond)
+		token2, err := rs.LockRoom(roomName, time.Second)
+		require.NoError(t, err)
+		require.Equal(t, service.ErrRoomUnlockFailed, rs.UnlockRoom(roomName, token))
+
+		_, err = rs.LockRoom(roomName, lockInterval)
+		require.Equal(t, service.ErrRoomLockFailed, err)
+		require.NoError(t, rs.UnlockRoom(roomName, token2))
+		require.NoError(t, rs.UnlockRoom(roomName, token2))
+	})
+
 	t.Run("lock expires", func(t *testing.T) {
 		token, err := rs.LockRoom(roomName, lockInterval)
 		require.NoError(t, err)
@@ -20,6 +20,21 @@ const (
 	roomPurgeSeconds = 24 * 60 * 60
 )
 
+// RoomReconciliation describes differences between participants persisted in RoomStore and live participants
+type RoomReconciliation struct {
+	Room string `json:"room"`
+	// identities persisted in RoomStore without a live participant
+	Orphaned []string `json:"orphaned"`
+	// identities of live participants missing from RoomStore
+	Missing []string `json:"missing"`
+	// when orphaned entries have been removed, and missing participants persisted
+	Cleaned bool `json:"cleaned"`
+}
+
+func (rr *RoomReconciliation) InSync() bool {
+	return len(rr.Orphaned) == 0 && len(rr.Missing) == 0
+}
+
 // RoomManager manages rooms and its interaction with participants.
 // It's responsible for creating, deleting rooms, as well as running sessions for participants
 type RoomManager struct {
@@ -31,14 +46,41 @@ type RoomManager struct {
 	rtcConfig   *rtc.WebRTCConfig
 	config      *config.Config
 	rooms       map[string]*rtc.Room
+	presence    *PresenceTracker
+	// nil when subscriber telemetry is disabled
+	telemetry *rtc.TelemetrySink
+	// nil when room quality sampling is disabled
+	qualitySink *rtc.QualitySink
+	// nil when subscriptions aren't limited
+	subscriptionLimiter *rtc.SubscriptionLimiter
+	// writes participant changes to roomStore, retrying those that fail
+	participantWriter *ParticipantStoreWriter
+	// nil unless one is supplied, subscriptions needing transcoding are then rejected
+	transcoder rtc.Transcoder
+	audioCodec rtc.AudioCodec
+	// records sessions of participants that left to roomStore
+	sessionHistory *SessionHistoryWriter
+	// nil when no webhook is configured
+	webhook *WebHookDispatcher
 }
 
-func NewRoomManager(rp RoomStore, router routing.Router, currentNode routing.LocalNode, selector routing.NodeSelector, conf *config.Config) (*RoomManager, error) {
+func NewRoomManager(rp RoomStore, router routing.Router, currentNode routing.LocalNode, selector routing.NodeSelector, conf *config.Config,
+	webhook *WebHookDispatcher) (*RoomManager, error) {
 	rtcConf, err := rtc.NewWebRTCConfig(conf, currentNode.Ip)
 	if err != nil {
 		return nil, err
 	}
 
+	telemetry, err := rtc.NewTelemetrySink(conf.RTC.SubscriberTelemetry)
+	if err != nil {
+		return nil, err
+	}
+
+	qualitySink, err := rtc.NewQualitySink(conf.Room.QualitySampling)
+	if err != nil {
+		return nil, err
+	}
+
 	return &RoomManager{
 		lock:        sync.RWMutex{},
 		roomStore:   rp,
@@ -48,9 +90,22 @@ func NewRoomManager(rp RoomStore, router routing.Router, currentNode routing.Loc
 		selector:    selector,
 		currentNode: currentNode,
 		rooms:       make(map[string]*rtc.Room),
+		presence:    NewPresenceTracker(time.Duration(conf.Room.PresenceDebounce) * time.Second),
+		telemetry:   telemetry,
+		qualitySink: qualitySink,
+		webhook:     webhook,
+
+		subscriptionLimiter: rtc.NewSubscriptionLimiter(conf.RTC.MaxSubscriptions),
+		participantWriter:   NewParticipantStoreWriter(conf.Room.StoreRetry, rp),
+		sessionHistory:      NewSessionHistoryWriter(conf.Room.SessionHistory, rp),
 	}, nil
 }
 
+// Presence tracks presence of participants in rooms hosted on this node
+func (r *RoomManager) Presence() *PresenceTracker {
+	return r.presence
+}
+
 // CreateRoom creates a new room from a request and allocates it to a node to handle
 // it'll also monitor fits state, and cleans it up when appropriate
 func (r *RoomManager) CreateRoom(req *livekit.CreateRoomRequest) (*livekit.Room, error) {
@@ -64,7 +119,8 @@ func (r *RoomManager) CreateRoom(req *livekit.CreateRoomRequest) (*livekit.Room,
 
 	// find existing room and update it
 	rm, err := r.roomStore.GetRoom(req.Name)
-	if err == ErrRoomNotFound {
+	created := err == ErrRoomNotFound
+	if created {
 		rm = &livekit.Room{
 			Sid:          utils.NewGuid(utils.RoomPrefix),
 			Name:         req.Name,
@@ -85,6 +141,9 @@ func (r *RoomManager) CreateRoom(req *livekit.CreateRoomRequest) (*livekit.Room,
 	if err := r.roomStore.CreateRoom(rm); err != nil {
 		return nil, err
 	}
+	if created {
+		r.notify(&WebHookEvent{Event: WebHookRoomCreated, Room: rm.Name, RoomSid: rm.Sid})
+	}
 
 	// Is that node still available?
 	node, err := r.router.GetNodeForRoom(rm.Name)
@@ -131,8 +190,14 @@ func (r *RoomManager) GetRoom(roomName string) *rtc.Room {
 func (r *RoomManager) DeleteRoom(roomName string) error {
 	logger.Infow("deleting room state", "room", roomName)
 	r.lock.Lock()
+	var roomSid string
+	if room := r.rooms[roomName]; room != nil {
+		roomSid = room.Room.Sid
+	}
 	delete(r.rooms, roomName)
 	r.lock.Unlock()
+	// participants are deleted with the room, retries would bring them back
+	r.participantWriter.DropRoom(roomName)
 
 	var err, err2 error
 	wg := sync.WaitGroup{}
@@ -151,11 +216,43 @@ func (r *RoomManager) DeleteRoom(roomName string) error {
 	wg.Wait()
 	if err2 != nil {
 		err = err2
+	} else {
+		r.notify(&WebHookEvent{Event: WebHookRoomDeleted, Room: roomName, RoomSid: roomSid})
 	}
 
 	return err
 }
 
+// notify posts the event to the webhook when one is configured
+func (r *RoomManager) notify(event *WebHookEvent) {
+	if r.webhook != nil {
+		r.webhook.Notify(event)
+	}
+}
+
+// closeRoom disconnects everyone in a room hosted on this node and deletes it. The room is locked while it's
+// deleted, so a participant joining meanwhile doesn't load it again, but not while participants are disconnected
+func (r *RoomManager) closeRoom(room *rtc.Room, reason string) {
+	roomName := room.Room.Name
+	token, err := r.roomStore.LockRoom(roomName, 5*time.Second)
+	if err != nil {
+		logger.Warnw("could not lock room", err, "room", roomName)
+	}
+	closing := room.StartClose()
+	if closing && r.GetRoom(roomName) == room {
+		if err := r.DeleteRoom(roomName); err != nil {
+			logger.Errorw("could not delete room", err, "room", roomName)
+		}
+	}
+	if err == nil {
+		_ = r.roomStore.UnlockRoom(roomName, token)
+	}
+
+	if closing {
+		room.FinishClose(reason)
+	}
+}
+
 // CleanupRooms cleans up after old rooms that have been around for awhile
 func (r *RoomManager) CleanupRooms() error {
 	// cleanup rooms that have been left for over a day
@@ -175,6 +272,187 @@ func (r *RoomManager) CleanupRooms() error {
 	return nil
 }
 
+// ReconcileRoom compares participants persisted in RoomStore against live participants of the room.
+// Since a room is hosted by a single node, only the hosting node could determine which entries are stale.
+// When cleanup is set, orphaned entries are deleted and missing participants are persisted again.
+func (r *RoomManager) ReconcileRoom(roomName string, cleanup bool) (*RoomReconciliation, error) {
+	node, err := r.router.GetNodeForRoom(roomName)
+	if err != nil {
+		return nil, err
+	}
+	if node.Id != r.currentNode.Id {
+		return nil, ErrRoomNotHosted
+	}
+
+	token, err := r.roomStore.LockRoom(roomName, 5*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = r.roomStore.UnlockRoom(roomName, token)
+	}()
+
+	// list persisted entries before live participants, anyone that joins in between would
+	// show up as live instead of being considered orphaned
+	persisted, err := r.roomStore.ListParticipants(roomName)
+	if err != nil {
+		return nil, err
+	}
+
+	live := make(map[string]types.Participant)
+	room := r.GetRoom(roomName)
+	if room != nil {
+		for _, p := range room.GetParticipants() {
+			if p.State() != livekit.ParticipantInfo_DISCONNECTED {
+				live[p.Identity()] = p
+			}
+		}
+	}
+
+	res := &RoomReconciliation{
+		Room:    roomName,
+		Cleaned: cleanup,
+	}
+	for _, pi := range persisted {
+		if live[pi.Identity] != nil {
+			delete(live, pi.Identity)
+			continue
+		}
+		res.Orphaned = append(res.Orphaned, pi.Identity)
+	}
+	for identity := range live {
+		res.Missing = append(res.Missing, identity)
+	}
+
+	if !cleanup || res.InSync() {
+		return res, nil
+	}
+
+	for _, identity := range res.Orphaned {
+		// check again in case they've joined during reconciliation
+		if room != nil && room.GetParticipant(identity) != nil {
+			continue
+		}
+		if err := r.roomStore.DeleteParticipant(roomName, identity); err != nil {
+			return res, err
+		}
+	}
+	for _, identity := range res.Missing {
+		if p := live[identity]; p.State() != livekit.ParticipantInfo_DISCONNECTED {
+			if err := r.roomStore.PersistParticipant(roomName, p.ToProto()); err != nil {
+				return res, err
+			}
+		}
+	}
+	return res, nil
+}
+
+// ReconcileRooms reconciles RoomStore state for all rooms hosted on this node
+func (r *RoomManager) ReconcileRooms() {
+	r.lock.RLock()
+	roomNames := make([]string, 0, len(r.rooms))
+	for name := range r.rooms {
+		roomNames = append(roomNames, name)
+	}
+	r.lock.RUnlock()
+
+	for _, name := range roomNames {
+		res, err := r.ReconcileRoom(name, true)
+		if err != nil {
+			if err != ErrRoomNotHosted {
+				logger.Warnw("could not reconcile room", err, "room", name)
+			}
+			continue
+		}
+		if !res.InSync() {
+			logger.Infow("reconciled room participants",
+				"room", name,
+				"orphaned", res.Orphaned,
+				"missing", res.Missing)
+		}
+	}
+}
+
+// ForwardTrackToUDP forwards RTP packets of a published track to a UDP sink at addr, the room needs to be hosted
+// on this node. Forwarding stops when the track is unpublished or StopForwardTrackToUDP is called
+func (r *RoomManager) ForwardTrackToUDP(roomName, trackId, addr string) error {
+	track, err := r.findPublishedTrack(roomName, trackId)
+	if err != nil {
+		return err
+	}
+	return track.AddUDPForwarder(addr)
+}
+
+// StopForwardTrackToUDP stops forwarding a track to the UDP sink at addr
+func (r *RoomManager) StopForwardTrackToUDP(roomName, trackId, addr string) error {
+	track, err := r.findPublishedTrack(roomName, trackId)
+	if err != nil {
+		return err
+	}
+	return track.RemoveUDPForwarder(addr)
+}
+
+// SetRoomAutoSubscribe changes the subscription policy of a room hosted on this node at runtime.
+// Existing participants are updated without tearing down their current subscriptions
+func (r *RoomManager) SetRoomAutoSubscribe(roomName string, autoSubscribe bool) error {
+	room := r.GetRoom(roomName)
+	if room == nil {
+		return ErrRoomNotFound
+	}
+	room.SetAutoSubscribe(autoSubscribe)
+	return nil
+}
+
+// PreviewSubscription estimates the tracks and bitrate identity would receive in a room hosted on this node,
+// without subscribing it
+func (r *RoomManager) PreviewSubscription(roomName, identity string) (*rtc.SubscriptionPreview, error) {
+	room := r.GetRoom(roomName)
+	if room == nil {
+		return nil, ErrRoomNotFound
+	}
+	return room.PreviewSubscription(identity), nil
+}
+
+// SetRoomDataPolicy changes who participants of a room hosted on this node could send data packets to
+func (r *RoomManager) SetRoomDataPolicy(roomName string, policy string) error {
+	if err := config.ValidateDataPolicy(policy); err != nil {
+		return err
+	}
+	room := r.GetRoom(roomName)
+	if room == nil {
+		return ErrRoomNotFound
+	}
+	room.SetDataPolicy(policy)
+	return nil
+}
+
+// SetParticipantLanguage changes the preferred caption language of a participant in a room hosted on this node
+func (r *RoomManager) SetParticipantLanguage(roomName, identity, language string) error {
+	room := r.GetRoom(roomName)
+	if room == nil {
+		return ErrRoomNotFound
+	}
+	if !room.SetPreferredLanguage(identity, language) {
+		return ErrParticipantNotFound
+	}
+	return nil
+}
+
+func (r *RoomManager) findPublishedTrack(roomName, trackId string) (types.PublishedTrack, error) {
+	room := r.GetRoom(roomName)
+	if room == nil {
+		return nil, ErrRoomNotFound
+	}
+	for _, p := range room.GetParticipants() {
+		for _, track := range p.GetPublishedTracks() {
+			if track.ID() == trackId {
+				return track, nil
+			}
+		}
+	}
+	return nil, ErrTrackNotFound
+}
+
 func (r *RoomManager) CloseIdleRooms() {
 	r.lock.RLock()
 	rooms := make([]*rtc.Room, 0, len(r.rooms))
@@ -188,6 +466,22 @@ func (r *RoomManager) CloseIdleRooms() {
 	}
 }
 
+// SetTranscoder supplies the transcoder of subscriptions allowed by the room's transcoding config, it applies to
+// participants joining afterwards
+func (r *RoomManager) SetTranscoder(transcoder rtc.Transcoder) {
+	r.lock.Lock()
+	r.transcoder = transcoder
+	r.lock.Unlock()
+}
+
+// SetAudioCodec supplies the Opus codec audio is mixed with, for rooms mixing audio. It applies to rooms created
+// afterwards
+func (r *RoomManager) SetAudioCodec(codec rtc.AudioCodec) {
+	r.lock.Lock()
+	r.audioCodec = codec
+	r.lock.Unlock()
+}
+
 func (r *RoomManager) Stop() {
 	// disconnect all clients
 	r.lock.RLock()
@@ -199,6 +493,7 @@ func (r *RoomManager) Stop() {
 
 	for _, room := range rooms {
 		for _, p := range room.GetParticipants() {
+			p.SetDisconnectReason(types.DisconnectReasonShutdown)
 			_ = p.Close()
 		}
 		room.Close()
@@ -212,6 +507,17 @@ func (r *RoomManager) Stop() {
 			_ = r.rtcConfig.TCPMuxListener.Close()
 		}
 	}
+	if r.telemetry != nil {
+		r.telemetry.Close()
+	}
+	r.participantWriter.Close()
+	if r.qualitySink != nil {
+		r.qualitySink.Close()
+	}
+	r.sessionHistory.Close()
+	if r.webhook != nil {
+		r.webhook.Close()
+	}
 }
 
 // StartSession starts WebRTC session when a new participant is connected, takes place on RTC node
@@ -222,6 +528,18 @@ func (r *RoomManager) StartSession(roomName string, pi routing.ParticipantInit,
 		return
 	}
 
+	// metadata set during a previous session of the participant is restored, unless the token carries its own.
+	// it's read before an existing participant is replaced, as that removes it from the store
+	metadata := pi.Metadata
+	if metadata == "" && !pi.Reconnect {
+		if info, err := r.roomStore.GetParticipant(roomName, pi.Identity); err == nil {
+			metadata = info.Metadata
+		} else if err != ErrParticipantNotFound {
+			logger.Warnw("could not load participant", err,
+				"participant", pi.Identity)
+		}
+	}
+
 	participant := room.GetParticipant(pi.Identity)
 	if participant != nil {
 		// When reconnecting, it means WS has interrupted by underlying peer connection is still ok
@@ -237,20 +555,29 @@ func (r *RoomManager) StartSession(roomName string, pi routing.ParticipantInit,
 			if prevSink != nil {
 				prevSink.Close()
 			}
+			// messages from the previous connection are no longer processed once the new one is bound
 			participant.SetResponseSink(responseSink)
+			if pi.ReconnectGrace > 0 {
+				participant.SetReconnectGrace(pi.ReconnectGrace)
+			}
+			if pi.NackWindow > 0 {
+				participant.SetNackWindow(pi.NackWindow)
+			}
+			go r.rtcSessionWorker(room, participant, requestSource, participant.SignalGeneration())
 
 			if err := participant.SendParticipantUpdate(rtc.ToProtoParticipants(room.GetParticipants())); err != nil {
 				logger.Warnw("failed to send participant update", err,
 					"participant", pi.Identity)
 			}
 
-			if err := participant.ICERestart(); err != nil {
+			if _, err := participant.ICERestart(); err != nil {
 				logger.Warnw("could not restart ICE", err,
 					"participant", pi.Identity)
 			}
 			return
 		} else {
 			// we need to clean up the existing participant, so a new one can join
+			participant.SetDisconnectReason(types.DisconnectReasonDuplicateIdentity)
 			room.RemoveParticipant(participant.Identity())
 		}
 	} else if pi.Reconnect {
@@ -268,6 +595,28 @@ func (r *RoomManager) StartSession(roomName string, pi routing.ParticipantInit,
 		return
 	}
 
+	// rejected before the participant is created, capacity is checked while holding the room lock so it isn't
+	// closing meanwhile. Join checks again, participants racing for the last spot are rejected by it
+	if room.Room.MaxParticipants > 0 {
+		token, err := r.roomStore.LockRoom(roomName, 5*time.Second)
+		if err != nil {
+			logger.Errorw("could not lock room", err, "room", roomName)
+			return
+		}
+		full := room.IsFull()
+		_ = r.roomStore.UnlockRoom(roomName, token)
+
+		if full {
+			logger.Infow("rejecting participant, room is full",
+				"room", roomName,
+				"participant", pi.Identity,
+				"maxParticipants", room.Room.MaxParticipants,
+			)
+			rejectSession(responseSink)
+			return
+		}
+	}
+
 	logger.Debugw("starting RTC session",
 		"room", roomName,
 		"node", r.currentNode.Id,
@@ -277,11 +626,18 @@ func (r *RoomManager) StartSession(roomName string, pi routing.ParticipantInit,
 	)
 
 	pv := types.ProtocolVersion(pi.ProtocolVersion)
+	reconnectGrace := r.config.RTC.ReconnectGrace
+	if pi.ReconnectGrace > 0 {
+		reconnectGrace = pi.ReconnectGrace
+	}
 	rtcConf := *r.rtcConfig
 	rtcConf.SetBufferFactory(room.GetBufferFactor())
 	if pi.UsePlanB {
 		rtcConf.Configuration.SDPSemantics = webrtc.SDPSemanticsPlanB
 	}
+	r.lock.RLock()
+	transcoder := r.transcoder
+	r.lock.RUnlock()
 	participant, err = rtc.NewParticipant(rtc.ParticipantParams{
 		Identity:        pi.Identity,
 		Config:          &rtcConf,
@@ -289,15 +645,60 @@ func (r *RoomManager) StartSession(roomName string, pi routing.ParticipantInit,
 		AudioConfig:     r.config.Audio,
 		ProtocolVersion: pv,
 		Stats:           room.GetStatsReporter(),
+		ReportPool:      room.GetReportPool(),
 		ThrottleConfig:  r.config.RTC.PLIThrottle,
+		TrickleLimit:    r.config.RTC.TrickleLimit,
+		ICEGathering:    r.config.RTC.ICEGathering,
+		SCTP:            r.config.RTC.SCTP,
+		Simulcast:       r.config.Room.Simulcast,
 		EnabledCodecs:   room.Room.EnabledCodecs,
+		RTCPFeedback:    &r.config.Room.RTCPFeedback,
+
+		RejectStaleSignal: r.config.RTC.RejectStaleSignal,
+		Negotiation:       r.config.RTC.Negotiation,
+		Telemetry:         r.telemetry,
+
+		SubscriptionLimiter: r.subscriptionLimiter,
+		ReconnectGrace:      reconnectGrace,
+		MaxUploadBitrate:    r.config.RTC.MaxUploadBitrate,
+		MaxDownloadBitrate:  r.config.RTC.MaxDownloadBitrate,
+		NackWindow:          pi.NackWindow,
+		StableSSRCs:         r.config.Room.UsesStableSSRCs(roomName),
+		DeviceClass:         pi.DeviceClass,
+		DeviceClasses:       r.config.Room.DeviceClasses,
+		PinnedVideoQuality:  r.config.Room.Recorders.PinnedVideoQuality(pi.Identity),
+		PublisherCongestion: r.config.RTC.PublisherCongestion,
+
+		MaxWarmStandbys:      r.config.RTC.MaxWarmStandbys,
+		KeepReceptionReports: r.config.Room.QualitySampling.Enabled,
+		DownloadBudgetPolicy: r.config.RTC.DownloadBudgetPolicy,
+
+		MaxMetadataSize:       r.config.Room.MaxMetadataSize,
+		MaxRosterMetadataSize: r.config.Room.MaxRosterMetadataSize,
+		ConnectionQuality:     r.config.RTC.ConnectionQuality.Enabled,
+		ForwardedLayers:       r.config.RTC.ForwardedLayers.Enabled,
+		BandwidthAdaptation:   r.config.RTC.BandwidthAdaptation,
+
+		SupportedCodecs: pi.Codecs,
+		MixedAudio:      pi.MixedAudio && room.MixesAudio(),
+		Transcoding:     r.config.Room.TranscodingTargets(roomName),
+		Transcoder:      transcoder,
+
+		RecorderKeyframeInterval: r.config.Room.Recorders.KeyframeIntervalFor(pi.Identity),
+		SubscriptionAllocation:   r.config.Room.AllocationStrategy(roomName),
+		PublishFailures:          r.config.Room.PublishFailures.For(roomName),
+		JitterBuffer:             r.config.RTC.JitterBuffer.For(pi.Identity),
 	})
 	if err != nil {
 		logger.Errorw("could not create participant", err)
 		return
 	}
-	if pi.Metadata != "" {
-		participant.SetMetadata(pi.Metadata)
+	if metadata != "" {
+		if err := participant.SetMetadata(metadata); err != nil {
+			logger.Warnw("ignoring metadata of participant", err,
+				"room", roomName,
+				"participant", pi.Identity)
+		}
 	}
 
 	if pi.Permission != nil {
@@ -307,13 +708,35 @@ func (r *RoomManager) StartSession(roomName string, pi routing.ParticipantInit,
 	// join room
 	opts := rtc.ParticipantOptions{
 		AutoSubscribe: pi.AutoSubscribe,
+		Host:          pi.Host,
+		Language:      pi.Language,
+		Recorder:      r.config.Room.Recorders.IsRecorder(pi.Identity),
 	}
 	if err := room.Join(participant, &opts); err != nil {
-		logger.Errorw("could not join room", err)
+		logger.Errorw("could not join room", err,
+			"room", roomName,
+			"participant", pi.Identity)
+		// closing the participant tells the client it won't be able to rejoin, and closes the response sink
+		participant.SetDisconnectReason(types.DisconnectReasonJoinFailed)
+		_ = participant.Close()
 		return
 	}
 
-	go r.rtcSessionWorker(room, participant, requestSource)
+	go r.rtcSessionWorker(room, participant, requestSource, participant.SignalGeneration())
+}
+
+// rejectSession tells a client that it couldn't join and shouldn't retry, before the participant is created
+func rejectSession(responseSink routing.MessageSink) {
+	if err := responseSink.WriteMessage(&livekit.SignalResponse{
+		Message: &livekit.SignalResponse_Leave{
+			Leave: &livekit.LeaveRequest{
+				CanReconnect: false,
+			},
+		},
+	}); err != nil {
+		logger.Warnw("could not send leave", err)
+	}
+	responseSink.Close()
 }
 
 // create the actual room object
@@ -326,6 +749,23 @@ func (r *RoomManager) getOrCreateRoom(roomName string) (*rtc.Room, error) {
 		return room, nil
 	}
 
+	// waits for the room to be deleted if it's being closed, rather than loading it again
+	token, err := r.roomStore.LockRoom(roomName, 5*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = r.roomStore.UnlockRoom(roomName, token)
+	}()
+
+	// another session could have created it while waiting for the lock
+	r.lock.RLock()
+	room = r.rooms[roomName]
+	r.lock.RUnlock()
+	if room != nil {
+		return room, nil
+	}
+
 	// create new room, get details first
 	ri, err := r.roomStore.GetRoom(roomName)
 	if err != nil {
@@ -334,9 +774,50 @@ func (r *RoomManager) getOrCreateRoom(roomName string) (*rtc.Room, error) {
 
 	// construct ice servers
 	room = rtc.NewRoom(ri, *r.rtcConfig, r.iceServersForRoom(ri), &r.config.Audio)
+	if r.config.Room.MaxDuration > 0 {
+		room.OnMaxDuration(func() {
+			r.closeRoom(room, rtc.RoomClosedMaxDuration)
+		})
+		room.SetMaxDuration(time.Duration(r.config.Room.MaxDuration) * time.Second)
+	}
+	room.SetDataPolicy(r.config.Room.DataPolicy)
+	room.SetHosts(r.config.Room.Hosts)
+	room.SetSubscriptionRestoreWindow(r.config.Room.SubscriptionRestoreWindow)
+	if len(r.config.TURN.URLs) > 0 {
+		room.SetParticipantICEServers(func(identity string) []*livekit.ICEServer {
+			return []*livekit.ICEServer{TURNCredentials(r.config.TURN, identity, time.Now())}
+		})
+	}
+	if maxSpeakers := r.config.Room.AudioMixing.MaxSpeakers; maxSpeakers > 0 {
+		r.lock.RLock()
+		codec := r.audioCodec
+		r.lock.RUnlock()
+		if err := room.SetAudioMixing(codec, maxSpeakers); err != nil {
+			logger.Warnw("could not mix audio", err, "room", roomName)
+		}
+	}
+	room.SetCaptions(r.config.Room.Captions)
+	room.SetDataRateLimit(r.config.Room.DataRateLimit)
+	if delay := r.config.Room.FanoutDelay.DelayFor(roomName); delay > 0 {
+		room.SetFanoutDelay(delay, r.config.Room.FanoutDelay.MinParticipants)
+	}
+	if r.qualitySink != nil {
+		room.SetQualitySink(r.qualitySink)
+	}
+	if dir := r.config.Room.DataRecording.Directory; dir != "" {
+		if recorder, err := rtc.NewDataRecorder(dir, roomName); err != nil {
+			logger.Errorw("could not start data recording", err, "room", roomName)
+		} else {
+			logger.Infow("recording data packets", "room", roomName, "path", recorder.Path())
+			room.SetDataRecorder(recorder)
+		}
+	}
 	room.OnClose(func() {
-		if err := r.DeleteRoom(roomName); err != nil {
-			logger.Errorw("could not delete room", err)
+		// rooms closed with closeRoom are deleted before they're closed, one loaded again since is kept
+		if r.GetRoom(roomName) == room {
+			if err := r.DeleteRoom(roomName); err != nil {
+				logger.Errorw("could not delete room", err)
+			}
 		}
 
 		// print stats
@@ -346,15 +827,42 @@ func (r *RoomManager) getOrCreateRoom(roomName string) (*rtc.Room, error) {
 		)
 	})
 	room.OnParticipantChanged(func(p types.Participant) {
-		var err error
 		if p.State() == livekit.ParticipantInfo_DISCONNECTED {
-			err = r.roomStore.DeleteParticipant(roomName, p.Identity())
+			r.participantWriter.Delete(roomName, p.Identity())
+			r.sessionHistory.Record(roomName, p, time.Now())
 		} else {
-			err = r.roomStore.PersistParticipant(roomName, p.ToProto())
+			r.participantWriter.Persist(roomName, p.ToProto())
 		}
-		if err != nil {
-			logger.Errorw("could not handle participant change", err)
+		r.updatePresence(room, p)
+	})
+	room.OnParticipantStateChange(func(p types.Participant, oldState livekit.ParticipantInfo_State) {
+		var event string
+		switch {
+		case p.State() == livekit.ParticipantInfo_ACTIVE && oldState != livekit.ParticipantInfo_ACTIVE:
+			event = WebHookParticipantJoined
+		case p.State() == livekit.ParticipantInfo_DISCONNECTED && oldState == livekit.ParticipantInfo_ACTIVE:
+			// participants that never connected haven't been reported as joined either
+			event = WebHookParticipantLeft
+		default:
+			return
 		}
+		r.notify(&WebHookEvent{
+			Event:          event,
+			Room:           roomName,
+			RoomSid:        room.Room.Sid,
+			ParticipantSid: p.ID(),
+			Identity:       p.Identity(),
+		})
+	})
+	room.OnTrackPublished(func(p types.Participant, track types.PublishedTrack) {
+		r.notify(&WebHookEvent{
+			Event:          WebHookTrackPublished,
+			Room:           roomName,
+			RoomSid:        room.Room.Sid,
+			ParticipantSid: p.ID(),
+			Identity:       p.Identity(),
+			TrackSid:       track.ID(),
+		})
 	})
 	r.lock.Lock()
 	r.rooms[roomName] = room
@@ -363,13 +871,58 @@ func (r *RoomManager) getOrCreateRoom(roomName string) (*rtc.Room, error) {
 	return room, nil
 }
 
-// manages a RTC session for a participant, runs on the RTC node
-func (r *RoomManager) rtcSessionWorker(room *rtc.Room, participant types.Participant, requestSource routing.MessageSource) {
+func (r *RoomManager) updatePresence(room *rtc.Room, p types.Participant) {
+	var presence Presence
+	switch p.State() {
+	case livekit.ParticipantInfo_ACTIVE:
+		presence = PresenceOnline
+		if p.IsInterrupted() {
+			presence = PresenceAway
+		}
+	case livekit.ParticipantInfo_DISCONNECTED:
+		// identity could've already rejoined with a new participant
+		if current := room.GetParticipant(p.Identity()); current != nil && current.ID() != p.ID() {
+			return
+		}
+		presence = PresenceOffline
+	default:
+		// still connecting
+		return
+	}
+	r.presence.Update(room.Room.Name, p.Identity(), presence)
+}
+
+// manages a RTC session for a participant, runs on the RTC node.
+// generation identifies the signal connection requestSource belongs to, when the participant resumes on a new
+// connection, the worker exits without closing the participant
+func (r *RoomManager) rtcSessionWorker(room *rtc.Room, participant types.Participant, requestSource routing.MessageSource, generation uint32) {
+	superseded := false
+	// the signal connection ended, rather than the participant or the session
+	lostSignal := false
 	defer func() {
+		if superseded {
+			logger.Debugw("signal connection superseded",
+				"participant", participant.Identity(),
+				"room", room.Room.Name,
+				"generation", generation,
+			)
+			return
+		}
+		if lostSignal && participant.HandleSignalLost(generation) {
+			logger.Debugw("signal connection lost, waiting for participant to reconnect",
+				"participant", participant.Identity(),
+				"room", room.Room.Name,
+				"grace", participant.ReconnectGrace(),
+			)
+			return
+		}
 		logger.Debugw("RTC session finishing",
 			"participant", participant.Identity(),
 			"room", room.Room.Name,
 		)
+		if lostSignal {
+			participant.SetDisconnectReason(types.DisconnectReasonSignalClosed)
+		}
 		_ = participant.Close()
 	}()
 	defer rtc.Recover()
@@ -381,8 +934,14 @@ func (r *RoomManager) rtcSessionWorker(room *rtc.Room, participant types.Partici
 			if participant.State() == livekit.ParticipantInfo_DISCONNECTED {
 				return
 			}
+			if participant.SignalGeneration() != generation {
+				superseded = true
+				return
+			}
 		case obj := <-requestSource.ReadChan():
 			if obj == nil {
+				superseded = participant.SignalGeneration() != generation
+				lostSignal = true
 				return
 			}
 
@@ -390,10 +949,18 @@ func (r *RoomManager) rtcSessionWorker(room *rtc.Room, participant types.Partici
 
 			switch msg := req.Message.(type) {
 			case *livekit.SignalRequest_Offer:
-				_, err := participant.HandleOffer(rtc.FromProtoSessionDescription(msg.Offer))
-				if err != nil {
-					logger.Errorw("could not handle offer", err, "participant", participant.Identity())
+				_, err := participant.HandleOffer(rtc.FromProtoSessionDescription(msg.Offer), generation)
+				if err == rtc.ErrSignalSuperseded {
+					superseded = true
 					return
+				} else if err != nil {
+					logger.Errorw("could not handle offer", err, "participant", participant.Identity())
+					// once connected, failed renegotiations are counted by the participant, which acts on them
+					// when they keep failing
+					if participant.State() == livekit.ParticipantInfo_JOINING ||
+						r.config.Room.PublishFailures.For(room.Room.Name).Action == config.PublishFailureActionNone {
+						return
+					}
 				}
 			case *livekit.SignalRequest_AddTrack:
 				logger.Debugw("add track request", "participant", participant.Identity(),
@@ -406,7 +973,10 @@ func (r *RoomManager) rtcSessionWorker(room *rtc.Room, participant types.Partici
 					return
 				}
 				sd := rtc.FromProtoSessionDescription(msg.Answer)
-				if err := participant.HandleAnswer(sd); err != nil {
+				if err := participant.HandleAnswer(sd, generation); err == rtc.ErrSignalSuperseded {
+					superseded = true
+					return
+				} else if err != nil {
 					logger.Errorw("could not handle answer", err, "participant", participant.Identity())
 				}
 			case *livekit.SignalRequest_Trickle:
@@ -416,7 +986,10 @@ func (r *RoomManager) rtcSessionWorker(room *rtc.Room, participant types.Partici
 					break
 				}
 				// logger.Debugw("adding peer candidate", "participant", participant.ID())
-				if err := participant.AddICECandidate(candidateInit, msg.Trickle.Target); err != nil {
+				if err := participant.AddICECandidate(candidateInit, msg.Trickle.Target, generation); err == rtc.ErrSignalSuperseded {
+					superseded = true
+					return
+				} else if err != nil {
 					logger.Errorw("could not handle trickle", err, "participant", participant.Identity())
 				}
 			case *livekit.SignalRequest_Mute:
@@ -429,18 +1002,14 @@ func (r *RoomManager) rtcSessionWorker(room *rtc.Room, participant types.Partici
 						"subscribe", msg.Subscription.Subscribe)
 				}
 			case *livekit.SignalRequest_TrackSetting:
-				for _, subTrack := range participant.GetSubscribedTracks() {
-					for _, sid := range msg.TrackSetting.TrackSids {
-						if subTrack.ID() != sid {
-							continue
-						}
-						logger.Debugw("updating track settings",
-							"participant", participant.Identity(),
-							"settings", msg.TrackSetting)
-						subTrack.UpdateSubscriberSettings(!msg.TrackSetting.Disabled, msg.TrackSetting.Quality)
-					}
+				logger.Debugw("updating track settings",
+					"participant", participant.Identity(),
+					"settings", msg.TrackSetting)
+				for _, sid := range msg.TrackSetting.TrackSids {
+					participant.UpdateSubscribedTrackSettings(sid, !msg.TrackSetting.Disabled, msg.TrackSetting.Quality)
 				}
 			case *livekit.SignalRequest_Leave:
+				participant.SetDisconnectReason(types.DisconnectReasonClientInitiated)
 				_ = participant.Close()
 			case *livekit.SignalRequest_Simulcast:
 				for _, track := range participant.GetPublishedTracks() {
@@ -475,24 +1044,31 @@ func (r *RoomManager) handleRTCMessage(roomName, identity string, msg *livekit.R
 	switch rm := msg.Message.(type) {
 	case *livekit.RTCNodeMessage_RemoveParticipant:
 		logger.Infow("removing participant", "room", roomName, "participant", identity)
+		participant.SetDisconnectReason(types.DisconnectReasonRemoved)
 		room.RemoveParticipant(identity)
 	case *livekit.RTCNodeMessage_MuteTrack:
 		logger.Debugw("setting track muted", "room", roomName, "participant", identity,
 			"track", rm.MuteTrack.TrackSid, "muted", rm.MuteTrack.Muted)
-		participant.SetTrackMuted(rm.MuteTrack.TrackSid, rm.MuteTrack.Muted)
+		if err := participant.MutePublishedTrack(rm.MuteTrack.TrackSid, rm.MuteTrack.Muted); err != nil {
+			logger.Warnw("could not mute track", err,
+				"room", roomName,
+				"participant", identity,
+				"track", rm.MuteTrack.TrackSid)
+		}
 	case *livekit.RTCNodeMessage_UpdateParticipant:
 		logger.Debugw("updating participant", "room", roomName, "participant", identity)
 		if rm.UpdateParticipant.Metadata != "" {
-			participant.SetMetadata(rm.UpdateParticipant.Metadata)
+			if err := participant.SetMetadata(rm.UpdateParticipant.Metadata); err != nil {
+				logger.Warnw("could not update participant metadata", err,
+					"room", roomName,
+					"participant", identity)
+			}
 		}
 		if rm.UpdateParticipant.Permission != nil {
 			participant.SetPermission(rm.UpdateParticipant.Permission)
 		}
 	case *livekit.RTCNodeMessage_DeleteRoom:
-		for _, p := range room.GetParticipants() {
-			_ = p.Close()
-		}
-		room.Close()
+		r.closeRoom(room, rtc.RoomClosedDeleted)
 	case *livekit.RTCNodeMessage_UpdateSubscriptions:
 		logger.Debugw("updating participant subscriptions", "room", roomName, "participant", identity)
 		if err := room.UpdateSubscriptions(participant, rm.UpdateSubscriptions.TrackSids, rm.UpdateSubscriptions.Subscribe); err != nil {
@@ -1,6 +1,7 @@
 package service_test
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/livekit/livekit-server/pkg/config"
@@ -23,7 +24,148 @@ func TestCreateRoom(t *testing.T) {
 	})
 }
 
+func TestStartSession(t *testing.T) {
+	t.Run("rejects participants once the room is full", func(t *testing.T) {
+		manager, _, store, _ := newTestRoomManagerWithFakes(t)
+		store.GetRoomReturns(&livekit.Room{Sid: "RM_1", Name: "myroom", MaxParticipants: 1}, nil)
+		store.GetParticipantReturns(nil, service.ErrParticipantNotFound)
+
+		manager.StartSession("myroom", routing.ParticipantInit{Identity: "p1"},
+			&routingfakes.FakeMessageSource{}, &routingfakes.FakeMessageSink{})
+		room := manager.GetRoom("myroom")
+		require.NotNil(t, room)
+		require.True(t, room.IsFull())
+
+		sink := &routingfakes.FakeMessageSink{}
+		manager.StartSession("myroom", routing.ParticipantInit{Identity: "p2"},
+			&routingfakes.FakeMessageSource{}, sink)
+		require.Len(t, room.GetParticipants(), 1)
+		require.Equal(t, 1, sink.WriteMessageCallCount())
+		res, ok := sink.WriteMessageArgsForCall(0).(*livekit.SignalResponse)
+		require.True(t, ok)
+		require.False(t, res.GetLeave().CanReconnect)
+		require.Equal(t, 1, sink.CloseCallCount())
+		require.Equal(t, store.LockRoomCallCount(), store.UnlockRoomCallCount())
+	})
+
+	t.Run("replaces the session of an identity that joins again", func(t *testing.T) {
+		manager, _, store, _ := newTestRoomManagerWithFakes(t)
+		store.GetRoomReturns(&livekit.Room{Sid: "RM_1", Name: "myroom"}, nil)
+		store.GetParticipantReturns(nil, service.ErrParticipantNotFound)
+
+		manager.StartSession("myroom", routing.ParticipantInit{Identity: "p1"},
+			&routingfakes.FakeMessageSource{}, &routingfakes.FakeMessageSink{})
+		room := manager.GetRoom("myroom")
+		require.NotNil(t, room)
+		previous := room.GetParticipant("p1")
+		require.NotNil(t, previous)
+		require.NotEqual(t, "p1", previous.ID())
+
+		manager.StartSession("myroom", routing.ParticipantInit{Identity: "p1"},
+			&routingfakes.FakeMessageSource{}, &routingfakes.FakeMessageSink{})
+		require.Len(t, room.GetParticipants(), 1)
+		current := room.GetParticipant("p1")
+		require.NotNil(t, current)
+		require.NotEqual(t, previous.ID(), current.ID())
+		require.Equal(t, livekit.ParticipantInfo_DISCONNECTED, previous.State())
+	})
+}
+
+func TestReconcileRoom(t *testing.T) {
+	t.Run("reports and cleans up orphaned participants", func(t *testing.T) {
+		manager, _, store, _ := newTestRoomManagerWithFakes(t)
+		store.ListParticipantsReturns([]*livekit.ParticipantInfo{
+			{Identity: "stale"},
+		}, nil)
+
+		res, err := manager.ReconcileRoom("myroom", false)
+		require.NoError(t, err)
+		require.Equal(t, []string{"stale"}, res.Orphaned)
+		require.Empty(t, res.Missing)
+		require.Equal(t, 0, store.DeleteParticipantCallCount())
+
+		res, err = manager.ReconcileRoom("myroom", true)
+		require.NoError(t, err)
+		require.True(t, res.Cleaned)
+		require.Equal(t, 1, store.DeleteParticipantCallCount())
+		roomName, identity := store.DeleteParticipantArgsForCall(0)
+		require.Equal(t, "myroom", roomName)
+		require.Equal(t, "stale", identity)
+		require.Equal(t, store.LockRoomCallCount(), store.UnlockRoomCallCount())
+	})
+
+	t.Run("does not reconcile rooms hosted on other nodes", func(t *testing.T) {
+		manager, _, store, router := newTestRoomManagerWithFakes(t)
+		router.GetNodeForRoomReturns(&livekit.Node{Id: "othernode"}, nil)
+		store.ListParticipantsReturns([]*livekit.ParticipantInfo{
+			{Identity: "remote"},
+		}, nil)
+
+		_, err := manager.ReconcileRoom("myroom", true)
+		require.Equal(t, service.ErrRoomNotHosted, err)
+		require.Equal(t, 0, store.DeleteParticipantCallCount())
+	})
+}
+
+func TestBulkOperations(t *testing.T) {
+	t.Run("applied with the room locked", func(t *testing.T) {
+		manager, _, store, _ := newTestRoomManagerWithFakes(t)
+		store.GetRoomReturns(&livekit.Room{Sid: "RM_1", Name: "myroom"}, nil)
+		store.GetParticipantReturns(nil, service.ErrParticipantNotFound)
+		manager.StartSession("myroom", routing.ParticipantInit{Identity: "p1"},
+			&routingfakes.FakeMessageSource{}, &routingfakes.FakeMessageSink{})
+		locks := store.LockRoomCallCount()
+
+		result, err := manager.MuteAll("myroom", nil)
+		require.NoError(t, err)
+		require.Equal(t, []string{"p1"}, result.Applied)
+		require.Equal(t, locks+1, store.LockRoomCallCount())
+		roomName, _ := store.LockRoomArgsForCall(locks)
+		require.Equal(t, "myroom", roomName)
+		require.Equal(t, store.LockRoomCallCount(), store.UnlockRoomCallCount())
+	})
+
+	t.Run("not applied when the room couldn't be locked", func(t *testing.T) {
+		manager, _, store, _ := newTestRoomManagerWithFakes(t)
+		store.GetRoomReturns(&livekit.Room{Sid: "RM_1", Name: "myroom"}, nil)
+		store.GetParticipantReturns(nil, service.ErrParticipantNotFound)
+		manager.StartSession("myroom", routing.ParticipantInit{Identity: "p1"},
+			&routingfakes.FakeMessageSource{}, &routingfakes.FakeMessageSink{})
+		lockErr := errors.New("could not lock")
+		store.LockRoomReturns("", lockErr)
+		unlocks := store.UnlockRoomCallCount()
+
+		_, err := manager.RemoveAll("myroom", nil)
+		require.Equal(t, lockErr, err)
+		require.NotNil(t, manager.GetRoom("myroom").GetParticipant("p1"))
+		require.Equal(t, unlocks, store.UnlockRoomCallCount())
+	})
+}
+
+func TestForwardTrackToUDP(t *testing.T) {
+	t.Run("room must be hosted on this node", func(t *testing.T) {
+		manager, _ := newTestRoomManager(t)
+		err := manager.ForwardTrackToUDP("myroom", "TR_1", "127.0.0.1:5000")
+		require.Equal(t, service.ErrRoomNotFound, err)
+		err = manager.StopForwardTrackToUDP("myroom", "TR_1", "127.0.0.1:5000")
+		require.Equal(t, service.ErrRoomNotFound, err)
+	})
+}
+
+func TestPreviewSubscription(t *testing.T) {
+	t.Run("room must be hosted on this node", func(t *testing.T) {
+		manager, _ := newTestRoomManager(t)
+		_, err := manager.PreviewSubscription("myroom", "p1")
+		require.Equal(t, service.ErrRoomNotFound, err)
+	})
+}
+
 func newTestRoomManager(t *testing.T) (*service.RoomManager, *config.Config) {
+	rm, conf, _, _ := newTestRoomManagerWithFakes(t)
+	return rm, conf
+}
+
+func newTestRoomManagerWithFakes(t *testing.T) (*service.RoomManager, *config.Config, *servicefakes.FakeRoomStore, *routingfakes.FakeRouter) {
 	store := &servicefakes.FakeRoomStore{}
 	store.GetRoomReturns(nil, service.ErrRoomNotFound)
 	router := &routingfakes.FakeRouter{}
@@ -35,8 +177,9 @@ func newTestRoomManager(t *testing.T) (*service.RoomManager, *config.Config) {
 
 	router.GetNodeForRoomReturns(node, nil)
 
-	rm, err := service.NewRoomManager(store, router, node, selector, conf)
+	rm, err := service.NewRoomManager(store, router, node, selector, conf, nil)
 	require.NoError(t, err)
+	t.Cleanup(rm.Stop)
 
-	return rm, conf
+	return rm, conf, store, router
 }
@@ -2,12 +2,16 @@ package service
 
 import (
 	"context"
+	"encoding/json"
+	"sort"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/thoas/go-funk"
 	"github.com/twitchtv/twirp"
 
 	"github.com/livekit/livekit-server/pkg/routing"
+	"github.com/livekit/livekit-server/pkg/rtc"
 	livekit "github.com/livekit/livekit-server/proto"
 )
 
@@ -184,6 +188,11 @@ func (s *RoomService) UpdateParticipant(ctx context.Context, req *livekit.Update
 		return nil, twirpAuthError(err)
 	}
 
+	if max := s.roomManager.config.Room.MaxMetadataSize; max > 0 && len(req.Metadata) > max {
+		err := &rtc.MetadataTooLargeError{Size: len(req.Metadata), Max: max}
+		return nil, twirp.InvalidArgumentError("metadata", err.Error())
+	}
+
 	rtcSink, err := s.createRTCSink(ctx, req.Room, req.Identity)
 	if err != nil {
 		return nil, err
@@ -225,6 +234,277 @@ func (s *RoomService) UpdateSubscriptions(ctx context.Context, req *livekit.Upda
 	return &livekit.UpdateSubscriptionsResponse{}, nil
 }
 
+// UpdateParticipantName renames a participant, on the node hosting its room
+func (s *RoomService) UpdateParticipantName(ctx context.Context, req *UpdateParticipantNameRequest) (*UpdateParticipantNameResponse, error) {
+	if err := EnsureAdminPermission(ctx, req.Room); err != nil {
+		return nil, twirpAuthError(err)
+	}
+
+	if _, err := s.roomManager.roomStore.GetParticipant(req.Room, req.Identity); err != nil {
+		return nil, err
+	}
+	if err := s.executeRoomOperation(ctx, roomOpUpdateParticipantName, req.Room, req.Identity, req, nil); err != nil {
+		return nil, err
+	}
+	return &UpdateParticipantNameResponse{}, nil
+}
+
+// UpdateReconnectGrace changes the reconnect grace period of a participant, on the node hosting its room
+func (s *RoomService) UpdateReconnectGrace(ctx context.Context, req *UpdateReconnectGraceRequest) (*UpdateReconnectGraceResponse, error) {
+	if err := EnsureAdminPermission(ctx, req.Room); err != nil {
+		return nil, twirpAuthError(err)
+	}
+
+	if _, err := s.roomManager.roomStore.GetParticipant(req.Room, req.Identity); err != nil {
+		return nil, err
+	}
+	if err := s.executeRoomOperation(ctx, roomOpUpdateReconnectGrace, req.Room, req.Identity, req, nil); err != nil {
+		return nil, err
+	}
+	return &UpdateReconnectGraceResponse{}, nil
+}
+
+// UpdateMaxUploadBitrate changes the max bitrate of video published by a participant, on the node hosting its room
+func (s *RoomService) UpdateMaxUploadBitrate(ctx context.Context, req *UpdateMaxUploadBitrateRequest) (*UpdateMaxUploadBitrateResponse, error) {
+	if err := EnsureAdminPermission(ctx, req.Room); err != nil {
+		return nil, twirpAuthError(err)
+	}
+
+	if _, err := s.roomManager.roomStore.GetParticipant(req.Room, req.Identity); err != nil {
+		return nil, err
+	}
+	if err := s.executeRoomOperation(ctx, roomOpUpdateMaxUploadBitrate, req.Room, req.Identity, req, nil); err != nil {
+		return nil, err
+	}
+	return &UpdateMaxUploadBitrateResponse{}, nil
+}
+
+// UpdateDeviceClass changes the device class of the participant calling it, on the node hosting its room
+func (s *RoomService) UpdateDeviceClass(ctx context.Context, req *UpdateDeviceClassRequest) (*UpdateDeviceClassResponse, error) {
+	roomName, err := EnsureJoinPermission(ctx)
+	if err != nil {
+		return nil, twirpAuthError(err)
+	}
+	identity := GetGrants(ctx).Identity
+
+	if _, err := s.roomManager.roomStore.GetParticipant(roomName, identity); err != nil {
+		return nil, err
+	}
+	if err := s.executeRoomOperation(ctx, roomOpUpdateDeviceClass, roomName, identity, req, nil); err != nil {
+		return nil, err
+	}
+	return &UpdateDeviceClassResponse{}, nil
+}
+
+// UpdateNackWindow changes the retransmission window of the participant calling it, on the node hosting its room
+func (s *RoomService) UpdateNackWindow(ctx context.Context, req *UpdateNackWindowRequest) (*UpdateNackWindowResponse, error) {
+	roomName, err := EnsureJoinPermission(ctx)
+	if err != nil {
+		return nil, twirpAuthError(err)
+	}
+	identity := GetGrants(ctx).Identity
+
+	if _, err := s.roomManager.roomStore.GetParticipant(roomName, identity); err != nil {
+		return nil, err
+	}
+	if err := s.executeRoomOperation(ctx, roomOpUpdateNackWindow, roomName, identity, req, nil); err != nil {
+		return nil, err
+	}
+	return &UpdateNackWindowResponse{}, nil
+}
+
+// UpdateMaxDownloadBitrate changes the max bitrate of media sent to a participant, on the node hosting its room
+func (s *RoomService) UpdateMaxDownloadBitrate(ctx context.Context, req *UpdateMaxDownloadBitrateRequest) (*UpdateMaxDownloadBitrateResponse, error) {
+	if err := EnsureAdminPermission(ctx, req.Room); err != nil {
+		return nil, twirpAuthError(err)
+	}
+
+	if _, err := s.roomManager.roomStore.GetParticipant(req.Room, req.Identity); err != nil {
+		return nil, err
+	}
+	if err := s.executeRoomOperation(ctx, roomOpUpdateMaxDownloadBitrate, req.Room, req.Identity, req, nil); err != nil {
+		return nil, err
+	}
+	return &UpdateMaxDownloadBitrateResponse{}, nil
+}
+
+// DebugParticipant logs debug messages of a participant regardless of the log level, on the node hosting its room
+func (s *RoomService) DebugParticipant(ctx context.Context, req *DebugParticipantRequest) (*DebugParticipantResponse, error) {
+	if err := EnsureAdminPermission(ctx, req.Room); err != nil {
+		return nil, twirpAuthError(err)
+	}
+	if req.Duration != "" {
+		if d, err := time.ParseDuration(req.Duration); err != nil || d < 0 {
+			return nil, twirp.InvalidArgumentError("duration", "must be a non-negative duration, like 10m")
+		}
+	}
+
+	if err := s.executeRoomOperation(ctx, roomOpDebugParticipant, req.Room, "", req, nil); err != nil {
+		return nil, err
+	}
+	return &DebugParticipantResponse{}, nil
+}
+
+// ListParticipantSessions lists sessions of participants that left a room, kept in the room store when
+// room.session_history is enabled
+func (s *RoomService) ListParticipantSessions(ctx context.Context, req *ListParticipantSessionsRequest) (*ListParticipantSessionsResponse, error) {
+	if err := EnsureAdminPermission(ctx, req.Room); err != nil {
+		return nil, twirpAuthError(err)
+	}
+	if req.Room == "" {
+		return nil, twirp.RequiredArgumentError("room")
+	}
+
+	sessions, err := s.roomManager.roomStore.ListParticipantSessions(req.Room, req.Identity)
+	if err != nil {
+		return nil, err
+	}
+	if sessions == nil {
+		sessions = []*ParticipantSession{}
+	}
+	return &ListParticipantSessionsResponse{Sessions: sessions}, nil
+}
+
+// PreviewSubscription estimates the tracks and bitrate a participant would receive, on the node hosting the room
+func (s *RoomService) PreviewSubscription(ctx context.Context, req *PreviewSubscriptionRequest) (*rtc.SubscriptionPreview, error) {
+	roomName, identity := req.Room, req.Identity
+	if identity == "" {
+		joinRoom, err := EnsureJoinPermission(ctx)
+		if err == nil && roomName != "" && roomName != joinRoom {
+			err = ErrPermissionDenied
+		}
+		if err != nil {
+			return nil, twirpAuthError(err)
+		}
+		roomName = joinRoom
+		identity = GetGrants(ctx).Identity
+	} else if err := EnsureAdminPermission(ctx, roomName); err != nil {
+		return nil, twirpAuthError(err)
+	}
+
+	preview := &rtc.SubscriptionPreview{}
+	if err := s.executeRoomOperation(ctx, roomOpPreviewSubscription, roomName, identity, nil, preview); err != nil {
+		return nil, err
+	}
+	return preview, nil
+}
+
+// ListPresence lists the current presence of participants on every node
+func (s *RoomService) ListPresence(ctx context.Context, req *ListPresenceRequest) (*ListPresenceResponse, error) {
+	if err := ensurePresencePermission(ctx, req.Room); err != nil {
+		return nil, twirpAuthError(err)
+	}
+
+	presence, err := s.listPresence(ctx, req.Room)
+	if err != nil {
+		return nil, err
+	}
+	return &ListPresenceResponse{Presence: presence}, nil
+}
+
+// ListWaitingParticipants lists participants waiting in the lobby of a room, on every node
+func (s *RoomService) ListWaitingParticipants(ctx context.Context, req *ListWaitingParticipantsRequest) (*ListWaitingParticipantsResponse, error) {
+	if err := EnsureAdminPermission(ctx, req.Room); err != nil {
+		return nil, twirpAuthError(err)
+	}
+
+	op, err := routing.NewRoomOperation(roomOpListWaitingParticipants, req.Room, "", nil)
+	if err != nil {
+		return nil, err
+	}
+	results, err := s.roomManager.router.BroadcastRoomOperation(ctx, op)
+	if err != nil {
+		return nil, twirpRoomOperationError(err)
+	}
+
+	res := &ListWaitingParticipantsResponse{Participants: make([]LobbyParticipant, 0)}
+	for _, result := range results {
+		var participants []LobbyParticipant
+		if err := json.Unmarshal(result, &participants); err != nil {
+			return nil, err
+		}
+		res.Participants = append(res.Participants, participants...)
+	}
+	sort.Slice(res.Participants, func(i, j int) bool {
+		return res.Participants[i].WaitingSince < res.Participants[j].WaitingSince
+	})
+	return res, nil
+}
+
+// AdmitParticipant lets a participant waiting in the lobby of a room join it, on the node it's waiting on
+func (s *RoomService) AdmitParticipant(ctx context.Context, req *AdmitParticipantRequest) (*AdmitParticipantResponse, error) {
+	if err := EnsureAdminPermission(ctx, req.Room); err != nil {
+		return nil, twirpAuthError(err)
+	}
+
+	if err := s.decideOnWaitingParticipant(ctx, roomOpAdmitParticipant, req.Room, req.Identity, req); err != nil {
+		return nil, err
+	}
+	return &AdmitParticipantResponse{}, nil
+}
+
+// RejectParticipant turns away a participant waiting in the lobby of a room, on the node it's waiting on
+func (s *RoomService) RejectParticipant(ctx context.Context, req *RejectParticipantRequest) (*RejectParticipantResponse, error) {
+	if err := EnsureAdminPermission(ctx, req.Room); err != nil {
+		return nil, twirpAuthError(err)
+	}
+
+	if err := s.decideOnWaitingParticipant(ctx, roomOpRejectParticipant, req.Room, req.Identity, req); err != nil {
+		return nil, err
+	}
+	return &RejectParticipantResponse{}, nil
+}
+
+func (s *RoomService) decideOnWaitingParticipant(ctx context.Context, op, room, identity string, params interface{}) error {
+	ro, err := routing.NewRoomOperation(op, room, identity, params)
+	if err != nil {
+		return err
+	}
+	results, err := s.roomManager.router.BroadcastRoomOperation(ctx, ro)
+	if err != nil {
+		return twirpRoomOperationError(err)
+	}
+	if len(results) == 0 {
+		// not waiting on any node
+		return twirp.NotFoundError(ErrParticipantNotFound.Error())
+	}
+	return nil
+}
+
+// BulkUpdate applies an action to all participants of a room, on the node hosting it
+func (s *RoomService) BulkUpdate(ctx context.Context, req *BulkUpdateRequest) (*BulkResponse, error) {
+	if err := EnsureAdminPermission(ctx, req.Room); err != nil {
+		return nil, twirpAuthError(err)
+	}
+	switch req.Action {
+	case BulkActionMute, BulkActionUnmute, BulkActionRemove:
+	default:
+		return nil, twirp.InvalidArgumentError("action", ErrInvalidBulkAction.Error())
+	}
+
+	res := &BulkResponse{}
+	if err := s.executeRoomOperation(ctx, roomOpBulkUpdate, req.Room, "", req, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// executeRoomOperation carries out op on the node hosting the room, and decodes its result into res
+func (s *RoomService) executeRoomOperation(ctx context.Context, op, room, identity string, params, res interface{}) error {
+	ro, err := routing.NewRoomOperation(op, room, identity, params)
+	if err != nil {
+		return err
+	}
+	result, err := s.roomManager.router.ExecuteRoomOperation(ctx, ro)
+	if err != nil {
+		return twirpRoomOperationError(err)
+	}
+	if res == nil {
+		return nil
+	}
+	return json.Unmarshal(result, res)
+}
+
 func (s *RoomService) createRTCSink(ctx context.Context, room, identity string) (routing.MessageSink, error) {
 	if err := EnsureAdminPermission(ctx, room); err != nil {
 		return nil, twirpAuthError(err)
@@ -237,3 +517,35 @@ func (s *RoomService) createRTCSink(ctx context.Context, room, identity string)
 
 	return s.roomManager.router.CreateRTCSink(room, identity)
 }
+
+// errors of room operations carried out on other nodes only keep their message
+var roomOperationErrors = []error{
+	ErrRoomNotFound,
+	ErrParticipantNotFound,
+	ErrTrackNotFound,
+	ErrInvalidBulkAction,
+}
+
+func twirpRoomOperationError(err error) error {
+	if opErr, ok := err.(*routing.RoomOperationError); ok {
+		for _, e := range roomOperationErrors {
+			if opErr.Message == e.Error() {
+				err = e
+				break
+			}
+		}
+	}
+
+	switch err {
+	case ErrRoomNotFound, routing.ErrNotFound:
+		// rooms that aren't hosted by any node
+		return twirp.NotFoundError(ErrRoomNotFound.Error())
+	case ErrParticipantNotFound, ErrTrackNotFound:
+		return twirp.NotFoundError(err.Error())
+	case ErrInvalidBulkAction:
+		return twirp.InvalidArgumentError("action", err.Error())
+	case routing.ErrRoomOperationTimeout:
+		return twirp.NewError(twirp.DeadlineExceeded, err.Error())
+	}
+	return err
+}
@@ -0,0 +1,78 @@
+package service_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/stretchr/testify/require"
+	"github.com/twitchtv/twirp"
+
+	"github.com/livekit/livekit-server/pkg/routing"
+	"github.com/livekit/livekit-server/pkg/service"
+)
+
+func TestBulkUpdate(t *testing.T) {
+	ctx := context.WithValue(context.Background(), "grants", &auth.ClaimGrants{
+		Video: &auth.VideoGrant{Room: "myroom", RoomAdmin: true},
+	})
+
+	t.Run("carried out on the node hosting the room", func(t *testing.T) {
+		manager, _, _, router := newTestRoomManagerWithFakes(t)
+		svc, err := service.NewRoomService(manager)
+		require.NoError(t, err)
+		router.ExecuteRoomOperationReturns(json.RawMessage(`{"applied":["p1"],"failed":{"p2":"closed"}}`), nil)
+
+		res, err := svc.BulkUpdate(ctx, &service.BulkUpdateRequest{
+			Room:       "myroom",
+			Action:     service.BulkActionMute,
+			ExceptSids: []string{"PA_host"},
+		})
+		require.NoError(t, err)
+		require.Equal(t, []string{"p1"}, res.Applied)
+		require.Equal(t, map[string]string{"p2": "closed"}, res.Failed)
+
+		require.Equal(t, 1, router.ExecuteRoomOperationCallCount())
+		_, op := router.ExecuteRoomOperationArgsForCall(0)
+		require.Equal(t, "myroom", op.Room)
+		req := service.BulkUpdateRequest{}
+		require.NoError(t, op.DecodeParams(&req))
+		require.Equal(t, []string{"PA_host"}, req.ExceptSids)
+	})
+
+	t.Run("rejects unknown actions", func(t *testing.T) {
+		manager, _, _, router := newTestRoomManagerWithFakes(t)
+		svc, err := service.NewRoomService(manager)
+		require.NoError(t, err)
+
+		_, err = svc.BulkUpdate(ctx, &service.BulkUpdateRequest{Room: "myroom", Action: "ban"})
+		requireTwirpCode(t, twirp.InvalidArgument, err)
+		require.Zero(t, router.ExecuteRoomOperationCallCount())
+	})
+
+	t.Run("errors of other nodes are translated", func(t *testing.T) {
+		manager, _, _, router := newTestRoomManagerWithFakes(t)
+		svc, err := service.NewRoomService(manager)
+		require.NoError(t, err)
+		router.ExecuteRoomOperationReturns(nil, &routing.RoomOperationError{Message: service.ErrRoomNotFound.Error()})
+
+		_, err = svc.BulkUpdate(ctx, &service.BulkUpdateRequest{Room: "myroom", Action: service.BulkActionRemove})
+		requireTwirpCode(t, twirp.NotFound, err)
+	})
+
+	t.Run("requires admin permission for the room", func(t *testing.T) {
+		manager, _, _, _ := newTestRoomManagerWithFakes(t)
+		svc, err := service.NewRoomService(manager)
+		require.NoError(t, err)
+
+		_, err = svc.BulkUpdate(ctx, &service.BulkUpdateRequest{Room: "other", Action: service.BulkActionMute})
+		requireTwirpCode(t, twirp.Unauthenticated, err)
+	})
+}
+
+func requireTwirpCode(t *testing.T, code twirp.ErrorCode, err error) {
+	twerr, ok := err.(twirp.Error)
+	require.True(t, ok, "expected a twirp error, got %v", err)
+	require.Equal(t, code, twerr.Code())
+}
@@ -0,0 +1,201 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/twitchtv/twirp"
+
+	"github.com/livekit/protocol/auth"
+
+	livekit "github.com/livekit/livekit-server/proto"
+)
+
+// RoomService methods participants call with their join token, validated by the AuthProvider of RTCService like
+// tokens of joining participants. Methods mapped to true take admin tokens as well
+var joinTokenMethods = map[string]bool{
+	"UpdateDeviceClass": false,
+	"UpdateNackWindow":  false,
+	// admins preview for other identities
+	"PreviewSubscription": true,
+}
+
+func isJoinTokenMethodPath(path string) bool {
+	_, ok := joinTokenMethods[strings.TrimPrefix(path, livekit.RoomServicePathPrefix)]
+	return ok
+}
+
+// roomServiceMethod decodes the JSON request of a method, and carries it out
+type roomServiceMethod func(ctx context.Context, body []byte) (interface{}, error)
+
+// roomServiceServer serves RoomService methods that aren't part of the protocol yet, next to the generated
+// twirp server. They take and return JSON the same way the generated methods do
+type roomServiceServer struct {
+	livekit.TwirpServer
+	methods      map[string]roomServiceMethod
+	authenticate func(r *http.Request) (*auth.ClaimGrants, error)
+}
+
+func newRoomServiceServer(roomService *RoomService, rtcService *RTCService) *roomServiceServer {
+	return &roomServiceServer{
+		TwirpServer:  livekit.NewRoomServiceServer(roomService),
+		authenticate: rtcService.authenticate,
+		methods: map[string]roomServiceMethod{
+			"BulkUpdate": func(ctx context.Context, body []byte) (interface{}, error) {
+				req := &BulkUpdateRequest{}
+				if err := decodeRoomServiceRequest(body, req); err != nil {
+					return nil, err
+				}
+				return roomService.BulkUpdate(ctx, req)
+			},
+			"UpdateParticipantName": func(ctx context.Context, body []byte) (interface{}, error) {
+				req := &UpdateParticipantNameRequest{}
+				if err := decodeRoomServiceRequest(body, req); err != nil {
+					return nil, err
+				}
+				return roomService.UpdateParticipantName(ctx, req)
+			},
+			"UpdateReconnectGrace": func(ctx context.Context, body []byte) (interface{}, error) {
+				req := &UpdateReconnectGraceRequest{}
+				if err := decodeRoomServiceRequest(body, req); err != nil {
+					return nil, err
+				}
+				return roomService.UpdateReconnectGrace(ctx, req)
+			},
+			"UpdateMaxUploadBitrate": func(ctx context.Context, body []byte) (interface{}, error) {
+				req := &UpdateMaxUploadBitrateRequest{}
+				if err := decodeRoomServiceRequest(body, req); err != nil {
+					return nil, err
+				}
+				return roomService.UpdateMaxUploadBitrate(ctx, req)
+			},
+			"UpdateDeviceClass": func(ctx context.Context, body []byte) (interface{}, error) {
+				req := &UpdateDeviceClassRequest{}
+				if err := decodeRoomServiceRequest(body, req); err != nil {
+					return nil, err
+				}
+				return roomService.UpdateDeviceClass(ctx, req)
+			},
+			"UpdateNackWindow": func(ctx context.Context, body []byte) (interface{}, error) {
+				req := &UpdateNackWindowRequest{}
+				if err := decodeRoomServiceRequest(body, req); err != nil {
+					return nil, err
+				}
+				return roomService.UpdateNackWindow(ctx, req)
+			},
+			"UpdateMaxDownloadBitrate": func(ctx context.Context, body []byte) (interface{}, error) {
+				req := &UpdateMaxDownloadBitrateRequest{}
+				if err := decodeRoomServiceRequest(body, req); err != nil {
+					return nil, err
+				}
+				return roomService.UpdateMaxDownloadBitrate(ctx, req)
+			},
+			"DebugParticipant": func(ctx context.Context, body []byte) (interface{}, error) {
+				req := &DebugParticipantRequest{}
+				if err := decodeRoomServiceRequest(body, req); err != nil {
+					return nil, err
+				}
+				return roomService.DebugParticipant(ctx, req)
+			},
+			"ListParticipantSessions": func(ctx context.Context, body []byte) (interface{}, error) {
+				req := &ListParticipantSessionsRequest{}
+				if err := decodeRoomServiceRequest(body, req); err != nil {
+					return nil, err
+				}
+				return roomService.ListParticipantSessions(ctx, req)
+			},
+			"PreviewSubscription": func(ctx context.Context, body []byte) (interface{}, error) {
+				req := &PreviewSubscriptionRequest{}
+				if err := decodeRoomServiceRequest(body, req); err != nil {
+					return nil, err
+				}
+				return roomService.PreviewSubscription(ctx, req)
+			},
+			"ListPresence": func(ctx context.Context, body []byte) (interface{}, error) {
+				req := &ListPresenceRequest{}
+				if err := decodeRoomServiceRequest(body, req); err != nil {
+					return nil, err
+				}
+				return roomService.ListPresence(ctx, req)
+			},
+			"ListWaitingParticipants": func(ctx context.Context, body []byte) (interface{}, error) {
+				req := &ListWaitingParticipantsRequest{}
+				if err := decodeRoomServiceRequest(body, req); err != nil {
+					return nil, err
+				}
+				return roomService.ListWaitingParticipants(ctx, req)
+			},
+			"AdmitParticipant": func(ctx context.Context, body []byte) (interface{}, error) {
+				req := &AdmitParticipantRequest{}
+				if err := decodeRoomServiceRequest(body, req); err != nil {
+					return nil, err
+				}
+				return roomService.AdmitParticipant(ctx, req)
+			},
+			"RejectParticipant": func(ctx context.Context, body []byte) (interface{}, error) {
+				req := &RejectParticipantRequest{}
+				if err := decodeRoomServiceRequest(body, req); err != nil {
+					return nil, err
+				}
+				return roomService.RejectParticipant(ctx, req)
+			},
+		},
+	}
+}
+
+func (s *roomServiceServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, s.PathPrefix())
+	method := s.methods[name]
+	if method == nil {
+		s.TwirpServer.ServeHTTP(w, r)
+		return
+	}
+
+	ctx := r.Context()
+	if allowAdmin, ok := joinTokenMethods[name]; ok {
+		grants, err := s.authenticate(r)
+		if err == nil {
+			ctx = context.WithValue(ctx, grantsKey, grants)
+		} else if !allowAdmin || !isAdmin(GetGrants(ctx)) {
+			_ = twirp.WriteError(w, twirpAuthError(err))
+			return
+		}
+	}
+
+	if r.Method != http.MethodPost {
+		_ = twirp.WriteError(w, twirp.NewError(twirp.BadRoute, "unsupported method "+r.Method))
+		return
+	}
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		_ = twirp.WriteError(w, twirp.InternalErrorWith(err))
+		return
+	}
+
+	res, err := method(ctx, body)
+	if err != nil {
+		_ = twirp.WriteError(w, err)
+		return
+	}
+	b, err := json.Marshal(res)
+	if err != nil {
+		_ = twirp.WriteError(w, twirp.InternalErrorWith(err))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(b)
+}
+
+func decodeRoomServiceRequest(body []byte, req interface{}) error {
+	if err := json.Unmarshal(body, req); err != nil {
+		return twirp.NewError(twirp.Malformed, "the json request could not be decoded")
+	}
+	return nil
+}
+
+func isAdmin(grants *auth.ClaimGrants) bool {
+	return grants != nil && grants.Video != nil && grants.Video.RoomAdmin
+}
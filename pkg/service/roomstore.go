@@ -10,6 +10,7 @@ import (
 
 // encapsulates CRUD operations for room settings
 // look up participant
+//
 //counterfeiter:generate . RoomStore
 type RoomStore interface {
 	CreateRoom(room *livekit.Room) error
@@ -26,4 +27,31 @@ type RoomStore interface {
 	GetParticipant(roomName, identity string) (*livekit.ParticipantInfo, error)
 	ListParticipants(roomName string) ([]*livekit.ParticipantInfo, error)
 	DeleteParticipant(roomName, identity string) error
+
+	// summary of a room, without listing its participants
+	GetRoomStats(idOrName string) (*RoomStats, error)
+
+	// records a session that ended, keeping the last maxSessions of the room, each for retention.
+	// sessions aren't removed with the room
+	AppendParticipantSession(roomName string, session *ParticipantSession, maxSessions int, retention time.Duration) error
+	// past sessions of a room, most recent first. only those of identity unless it's empty
+	ListParticipantSessions(roomName, identity string) ([]*ParticipantSession, error)
+}
+
+// RoomStats summarizes a room for dashboards, times are unix timestamps
+type RoomStats struct {
+	NumParticipants    uint32
+	NumPublishedTracks uint32
+	CreationTime       int64
+	// last time a participant joined, left or changed, the creation time until then
+	LastActivity int64
+}
+
+// ParticipantSession is a past session of a participant in a room, times are unix timestamps
+type ParticipantSession struct {
+	Sid              string `json:"sid"`
+	Identity         string `json:"identity"`
+	JoinedAt         int64  `json:"joinedAt"`
+	LeftAt           int64  `json:"leftAt"`
+	DisconnectReason string `json:"disconnectReason"`
 }
@@ -0,0 +1,106 @@
+package service_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/service"
+	livekit "github.com/livekit/livekit-server/proto"
+)
+
+func TestRoomStats(t *testing.T) {
+	stores := map[string]func() service.RoomStore{
+		"local": func() service.RoomStore { return service.NewLocalRoomStore() },
+		"redis": func() service.RoomStore {
+			return service.NewRedisRoomStore(redisClient(), config.StoreFormatProtobuf)
+		},
+	}
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			rs := newStore()
+			roomName := "room_stats"
+			_ = rs.DeleteRoom(roomName)
+			defer rs.DeleteRoom(roomName)
+
+			_, err := rs.GetRoomStats(roomName)
+			require.Equal(t, service.ErrRoomNotFound, err)
+
+			require.NoError(t, rs.CreateRoom(&livekit.Room{Sid: "RM_stats", Name: roomName, CreationTime: 1000}))
+			stats, err := rs.GetRoomStats("RM_stats")
+			require.NoError(t, err)
+			require.Equal(t, service.RoomStats{CreationTime: 1000, LastActivity: 1000}, *stats)
+
+			track := &livekit.TrackInfo{Sid: "TR_1"}
+			require.NoError(t, rs.PersistParticipant(roomName, &livekit.ParticipantInfo{Identity: "a", Tracks: []*livekit.TrackInfo{track}}))
+			require.NoError(t, rs.PersistParticipant(roomName, &livekit.ParticipantInfo{Identity: "b"}))
+			// updates replace the tracks counted for a participant
+			require.NoError(t, rs.PersistParticipant(roomName, &livekit.ParticipantInfo{Identity: "b", Tracks: []*livekit.TrackInfo{track, track}}))
+
+			stats, err = rs.GetRoomStats(roomName)
+			require.NoError(t, err)
+			require.Equal(t, uint32(2), stats.NumParticipants)
+			require.Equal(t, uint32(3), stats.NumPublishedTracks)
+			require.Greater(t, stats.LastActivity, stats.CreationTime)
+
+			require.NoError(t, rs.DeleteParticipant(roomName, "b"))
+			require.NoError(t, rs.DeleteParticipant(roomName, "b"))
+			stats, err = rs.GetRoomStats(roomName)
+			require.NoError(t, err)
+			require.Equal(t, uint32(1), stats.NumParticipants)
+			require.Equal(t, uint32(1), stats.NumPublishedTracks)
+		})
+	}
+}
+
+func TestParticipantSessions(t *testing.T) {
+	stores := map[string]func() service.RoomStore{
+		"local": func() service.RoomStore { return service.NewLocalRoomStore() },
+		"redis": func() service.RoomStore {
+			return service.NewRedisRoomStore(redisClient(), config.StoreFormatProtobuf)
+		},
+	}
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			rs := newStore()
+			roomName := "room_sessions_" + name
+			now := time.Now().Unix()
+
+			sessions, err := rs.ListParticipantSessions(roomName, "")
+			require.NoError(t, err)
+			require.Empty(t, sessions)
+
+			for i, identity := range []string{"a", "b", "a", "c"} {
+				require.NoError(t, rs.AppendParticipantSession(roomName, &service.ParticipantSession{
+					Sid:              fmt.Sprintf("PA_%d", i),
+					Identity:         identity,
+					JoinedAt:         now - 100,
+					LeftAt:           now - int64(10-i),
+					DisconnectReason: "client_initiated",
+				}, 3, time.Minute))
+			}
+
+			// the oldest is dropped beyond the depth
+			sessions, err = rs.ListParticipantSessions(roomName, "")
+			require.NoError(t, err)
+			require.Len(t, sessions, 3)
+			require.Equal(t, "PA_3", sessions[0].Sid)
+			require.Equal(t, "PA_1", sessions[2].Sid)
+			require.Equal(t, "client_initiated", sessions[0].DisconnectReason)
+
+			sessions, err = rs.ListParticipantSessions(roomName, "a")
+			require.NoError(t, err)
+			require.Len(t, sessions, 1)
+			require.Equal(t, "PA_2", sessions[0].Sid)
+
+			// kept after the room is deleted
+			require.NoError(t, rs.DeleteRoom(roomName))
+			sessions, err = rs.ListParticipantSessions(roomName, "")
+			require.NoError(t, err)
+			require.Len(t, sessions, 3)
+		})
+	}
+}
@@ -6,8 +6,10 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gorilla/websocket"
+	"github.com/livekit/protocol/auth"
 
 	"github.com/livekit/livekit-server/pkg/config"
 	"github.com/livekit/livekit-server/pkg/logger"
@@ -23,15 +25,35 @@ type RTCService struct {
 	upgrader    websocket.Upgrader
 	currentNode routing.LocalNode
 	isDev       bool
+	admission   AdmissionController
+	waitingRoom *WaitingRoom
+	// validates tokens of joining participants
+	authProvider AuthProvider
+	// upper bound of grace periods requested by clients
+	maxReconnectGrace time.Duration
 }
 
-func NewRTCService(conf *config.Config, roomManager *RoomManager, router routing.Router, currentNode routing.LocalNode) *RTCService {
+const (
+	// participants in the waiting room aren't read from, pings detect when they go away
+	waitingPingFrequency = time.Second
+	// how long participants that disconnect from the waiting room keep their place
+	waitingReconnectGrace = 30 * time.Second
+)
+
+func NewRTCService(conf *config.Config, roomManager *RoomManager, router routing.Router, currentNode routing.LocalNode, authProvider AuthProvider) *RTCService {
 	s := &RTCService{
-		router:      router,
-		roomManager: roomManager,
-		upgrader:    websocket.Upgrader{},
-		currentNode: currentNode,
-		isDev:       conf.Development,
+		router:       router,
+		roomManager:  roomManager,
+		upgrader:     websocket.Upgrader{},
+		currentNode:  currentNode,
+		isDev:        conf.Development,
+		waitingRoom:  NewWaitingRoom(waitingReconnectGrace),
+		authProvider: authProvider,
+
+		maxReconnectGrace: conf.RTC.MaxReconnectGrace,
+	}
+	if conf.Room.WaitingRoom {
+		s.admission = &waitingRoomController{}
 	}
 
 	// allow connections from any origin, since script may be hosted anywhere
@@ -43,8 +65,23 @@ func NewRTCService(conf *config.Config, roomManager *RoomManager, router routing
 	return s
 }
 
+// SetAdmissionController replaces the controller invoked before new participants join, nil admits everyone
+func (s *RTCService) SetAdmissionController(admission AdmissionController) {
+	s.admission = admission
+}
+
+// SetAuthProvider replaces the provider validating tokens of joining participants
+func (s *RTCService) SetAuthProvider(provider AuthProvider) {
+	s.authProvider = provider
+}
+
 func (s *RTCService) Validate(w http.ResponseWriter, r *http.Request) {
-	_, _, code, err := s.validate(r)
+	claims, err := s.authenticate(r)
+	if err != nil {
+		handleError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+	_, _, code, err := s.validate(r, claims)
 	if err != nil {
 		handleError(w, code, err.Error())
 		return
@@ -52,22 +89,41 @@ func (s *RTCService) Validate(w http.ResponseWriter, r *http.Request) {
 	_, _ = w.Write([]byte("success"))
 }
 
-func (s *RTCService) validate(r *http.Request) (string, routing.ParticipantInit, int, error) {
-	claims := GetGrants(r.Context())
-	// require a claim
-	if claims == nil || claims.Video == nil {
-		return "", routing.ParticipantInit{}, http.StatusUnauthorized, rtc.ErrPermissionDenied
+// authenticate validates the token of the request with the AuthProvider
+func (s *RTCService) authenticate(r *http.Request) (*auth.ClaimGrants, error) {
+	token, err := requestToken(r)
+	if err != nil {
+		return nil, err
+	}
+	if s.authProvider == nil {
+		return nil, rtc.ErrPermissionDenied
 	}
+	return s.authProvider.Validate(token)
+}
 
-	onlyName, err := EnsureJoinPermission(r.Context())
-	if err != nil {
-		return "", routing.ParticipantInit{}, http.StatusUnauthorized, err
+func (s *RTCService) validate(r *http.Request, claims *auth.ClaimGrants) (string, routing.ParticipantInit, int, error) {
+	// the AuthProvider ensures the claims grant joining a room
+	if claims == nil || claims.Video == nil || !claims.Video.RoomJoin {
+		return "", routing.ParticipantInit{}, http.StatusUnauthorized, rtc.ErrPermissionDenied
 	}
+	onlyName := claims.Video.Room
 
 	roomName := r.FormValue("room")
 	reconnectParam := r.FormValue("reconnect")
 	protocolParam := r.FormValue("protocol")
 	autoSubParam := r.FormValue("auto_subscribe")
+	// in seconds
+	reconnectGraceParam := r.FormValue("reconnect_grace")
+	// preferred language of captions
+	languageParam := r.FormValue("language")
+	// picks the default quality of video subscriptions
+	deviceClassParam := r.FormValue("device_class")
+	// in packets, shorter retransmission windows for latency sensitive subscribers
+	nackWindowParam := r.FormValue("nack_window")
+	// comma separated mime types, for clients that can't decode every enabled codec
+	codecsParam := r.FormValue("codecs")
+	// a single mixed audio track, for clients that can't decode many audio streams
+	mixedAudioParam := r.FormValue("mixed_audio")
 	// plan b does not work fully at the moment.
 	planBParam := r.FormValue("planb")
 
@@ -81,6 +137,10 @@ func (s *RTCService) validate(r *http.Request) (string, routing.ParticipantInit,
 		UsePlanB:      boolValue(planBParam),
 		AutoSubscribe: true,
 		Metadata:      claims.Metadata,
+		Host:          claims.Video.RoomAdmin && claims.Video.Room == roomName,
+		Language:      languageParam,
+		DeviceClass:   deviceClassParam,
+		MixedAudio:    boolValue(mixedAudioParam),
 	}
 	if autoSubParam != "" {
 		pi.AutoSubscribe = boolValue(autoSubParam)
@@ -88,6 +148,20 @@ func (s *RTCService) validate(r *http.Request) (string, routing.ParticipantInit,
 	if pv, err := strconv.Atoi(protocolParam); err == nil {
 		pi.ProtocolVersion = int32(pv)
 	}
+	if window, err := strconv.Atoi(nackWindowParam); err == nil && window > 0 {
+		pi.NackWindow = window
+	}
+	for _, codec := range strings.Split(codecsParam, ",") {
+		if codec = strings.ToLower(strings.TrimSpace(codec)); codec != "" {
+			pi.Codecs = append(pi.Codecs, codec)
+		}
+	}
+	if grace, err := strconv.Atoi(reconnectGraceParam); err == nil && grace > 0 {
+		pi.ReconnectGrace = time.Duration(grace) * time.Second
+		if pi.ReconnectGrace > s.maxReconnectGrace {
+			pi.ReconnectGrace = s.maxReconnectGrace
+		}
+	}
 
 	// only use permissions if any of them are set, default permissive
 	if claims.Video.CanPublish || claims.Video.CanSubscribe {
@@ -107,7 +181,12 @@ func (s *RTCService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	roomName, pi, code, err := s.validate(r)
+	claims, err := s.authenticate(r)
+	if err != nil {
+		s.rejectUnauthenticated(w, r, err)
+		return
+	}
+	roomName, pi, code, err := s.validate(r, claims)
 	if err != nil {
 		handleError(w, code, err.Error())
 		return
@@ -120,9 +199,56 @@ func (s *RTCService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// rejected early so the client learns why, the RTC node checks again when the participant joins
+	if rm.MaxParticipants > 0 && !pi.Reconnect {
+		full, err := s.isRoomFull(rm, pi.Identity)
+		if err != nil {
+			handleError(w, http.StatusInternalServerError, "could not check room capacity: "+err.Error())
+			return
+		}
+		if full {
+			handleError(w, http.StatusForbidden, ErrRoomFull.Error())
+			return
+		}
+	}
+
+	admission := AdmissionResult{Decision: AdmissionAdmit}
+	if s.waitingRoom.isWaiting(roomName, pi.Identity) {
+		// reconnected while waiting, resume its place in the lobby
+		admission.Decision = AdmissionDefer
+	} else if s.admission != nil && !pi.Reconnect {
+		// participants resuming a session have already been admitted
+		admission, err = s.admit(claims, rm)
+		if err != nil {
+			handleError(w, http.StatusInternalServerError, "could not admit participant: "+err.Error())
+			return
+		}
+		if admission.Decision == AdmissionReject {
+			handleError(w, http.StatusForbidden, rejectReason(admission))
+			return
+		}
+	}
+
+	var conn *websocket.Conn
+	if admission.Decision == AdmissionDefer {
+		// upgrade right away, so the client could wait for as long as it takes a host to respond
+		conn, err = s.upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			logger.Warnw("could not upgrade to WS", err)
+			return
+		}
+		if !s.waitForAdmission(conn, roomName, LobbyParticipant{Identity: pi.Identity, Metadata: pi.Metadata}) {
+			return
+		}
+	}
+
 	// this needs to be started first *before* using router functions on this node
 	connId, reqSink, resSource, err := s.router.StartParticipantSignal(roomName, pi)
 	if err != nil {
+		if conn != nil {
+			closeWithReason(conn, websocket.CloseInternalServerErr, "could not start session")
+			return
+		}
 		handleError(w, http.StatusInternalServerError, "could not start session: "+err.Error())
 		return
 	}
@@ -136,11 +262,13 @@ func (s *RTCService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}()
 
 	// upgrade only once the basics are good to go
-	conn, err := s.upgrader.Upgrade(w, r, nil)
-	if err != nil {
-		logger.Warnw("could not upgrade to WS", err)
-		handleError(w, http.StatusInternalServerError, err.Error())
-		return
+	if conn == nil {
+		conn, err = s.upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			logger.Warnw("could not upgrade to WS", err)
+			handleError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
 	}
 	sigConn := NewWSSignalConnection(conn)
 	if types.ProtocolVersion(pi.ProtocolVersion).SupportsProtobuf() {
@@ -210,3 +338,112 @@ func (s *RTCService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 }
+
+func (s *RTCService) admit(claims *auth.ClaimGrants, rm *livekit.Room) (AdmissionResult, error) {
+	participants, err := s.roomManager.roomStore.ListParticipants(rm.Name)
+	if err != nil {
+		return AdmissionResult{}, err
+	}
+	return s.admission.Admit(&AdmissionRequest{
+		Room:         rm,
+		Participants: participants,
+		Claims:       claims,
+	})
+}
+
+// rejectUnauthenticated tells the client its token was rejected over the signal connection, as browsers don't
+// expose the status of failed upgrades. It's sent a leave that it can't reconnect from, then the connection is
+// closed with the reason
+func (s *RTCService) rejectUnauthenticated(w http.ResponseWriter, r *http.Request, err error) {
+	logger.Infow("rejecting participant with an invalid token", "error", err.Error())
+	conn, upgradeErr := s.upgrader.Upgrade(w, r, nil)
+	if upgradeErr != nil {
+		logger.Warnw("could not upgrade to WS", upgradeErr)
+		return
+	}
+	sigConn := NewWSSignalConnection(conn)
+	if pv, err := strconv.Atoi(r.FormValue("protocol")); err == nil && types.ProtocolVersion(pv).SupportsProtobuf() {
+		sigConn.useJSON = false
+	}
+	_ = sigConn.WriteResponse(&livekit.SignalResponse{
+		Message: &livekit.SignalResponse_Leave{
+			Leave: &livekit.LeaveRequest{CanReconnect: false},
+		},
+	})
+	closeWithReason(conn, websocket.ClosePolicyViolation, err.Error())
+}
+
+// isRoomFull returns true when the room has no spot left for identity, one that's already in the room would
+// replace itself. Participants are counted rather than listed
+func (s *RTCService) isRoomFull(rm *livekit.Room, identity string) (bool, error) {
+	stats, err := s.roomManager.roomStore.GetRoomStats(rm.Name)
+	if err != nil {
+		return false, err
+	}
+	if stats.NumParticipants < rm.MaxParticipants {
+		return false, nil
+	}
+	if _, err := s.roomManager.roomStore.GetParticipant(rm.Name, identity); err == nil {
+		return false, nil
+	} else if err != ErrParticipantNotFound {
+		return false, err
+	}
+	return true, nil
+}
+
+// waitForAdmission holds the participant in the waiting room, returns true once it's been admitted.
+// the connection is closed when the participant is rejected
+func (s *RTCService) waitForAdmission(conn *websocket.Conn, roomName string, p LobbyParticipant) bool {
+	logger.Infow("participant waiting for admission",
+		"room", roomName,
+		"participant", p.Identity)
+
+	admitted := make(chan struct{})
+	defer close(admitted)
+	disconnected := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(waitingPingFrequency)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-admitted:
+				return
+			case <-ticker.C:
+				if err := conn.WriteControl(websocket.PingMessage, []byte(""), time.Now().Add(pingTimeout)); err != nil {
+					close(disconnected)
+					return
+				}
+			}
+		}
+	}()
+
+	result, ok := s.waitingRoom.Wait(roomName, p, disconnected)
+	if !ok {
+		logger.Infow("participant disconnected from the waiting room",
+			"room", roomName,
+			"participant", p.Identity)
+		_ = conn.Close()
+		return false
+	}
+	if result.Decision == AdmissionReject {
+		logger.Infow("participant rejected from the waiting room",
+			"room", roomName,
+			"participant", p.Identity,
+			"reason", result.Reason)
+		closeWithReason(conn, websocket.ClosePolicyViolation, rejectReason(result))
+		return false
+	}
+	return true
+}
+
+func rejectReason(result AdmissionResult) string {
+	if result.Reason == "" {
+		return ErrNotAdmitted.Error()
+	}
+	return result.Reason
+}
+
+func closeWithReason(conn *websocket.Conn, code int, reason string) {
+	_ = conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(pingTimeout))
+	_ = conn.Close()
+}
@@ -26,7 +26,7 @@ import (
 
 type LivekitServer struct {
 	config      *config.Config
-	roomServer  livekit.TwirpServer
+	roomServer  *roomServiceServer
 	rtcService  *RTCService
 	httpServer  *http.Server
 	promServer  *http.Server
@@ -40,7 +40,7 @@ type LivekitServer struct {
 }
 
 func NewLivekitServer(conf *config.Config,
-	roomService livekit.RoomService,
+	roomService *RoomService,
 	rtcService *RTCService,
 	keyProvider auth.KeyProvider,
 	router routing.Router,
@@ -50,7 +50,7 @@ func NewLivekitServer(conf *config.Config,
 ) (s *LivekitServer, err error) {
 	s = &LivekitServer{
 		config:      conf,
-		roomServer:  livekit.NewRoomServiceServer(roomService),
+		roomServer:  newRoomServiceServer(roomService, rtcService),
 		rtcService:  rtcService,
 		router:      router,
 		roomManager: roomManager,
@@ -72,10 +72,12 @@ func NewLivekitServer(conf *config.Config,
 	mux.Handle(s.roomServer.PathPrefix(), s.roomServer)
 	mux.Handle("/rtc", rtcService)
 	mux.HandleFunc("/rtc/validate", rtcService.Validate)
+	mux.HandleFunc("/presence", roomService.ServePresence)
 	mux.HandleFunc("/", s.healthCheck)
 	if conf.Development {
 		mux.HandleFunc("/debug/goroutine", s.debugGoroutines)
 		mux.HandleFunc("/debug/rooms", s.debugInfo)
+		mux.HandleFunc("/debug/reconcile", s.debugReconcile)
 	}
 
 	s.httpServer = &http.Server{
@@ -93,6 +95,21 @@ func NewLivekitServer(conf *config.Config,
 	// hook up router to the RoomManager
 	router.OnNewParticipantRTC(roomManager.StartSession)
 	router.OnRTCMessage(roomManager.handleRTCMessage)
+	router.OnRoomOperation(roomOpBulkUpdate, roomManager.handleBulkUpdate)
+	router.OnRoomOperation(roomOpUpdateParticipantName, roomManager.handleUpdateParticipantName)
+	router.OnRoomOperation(roomOpUpdateReconnectGrace, roomManager.handleUpdateReconnectGrace)
+	router.OnRoomOperation(roomOpUpdateMaxUploadBitrate, roomManager.handleUpdateMaxUploadBitrate)
+	router.OnRoomOperation(roomOpUpdateDeviceClass, roomManager.handleUpdateDeviceClass)
+	router.OnRoomOperation(roomOpUpdateNackWindow, roomManager.handleUpdateNackWindow)
+	router.OnRoomOperation(roomOpUpdateMaxDownloadBitrate, roomManager.handleUpdateMaxDownloadBitrate)
+	router.OnRoomOperation(roomOpDebugParticipant, roomManager.handleDebugParticipant)
+	router.OnRoomOperation(roomOpPreviewSubscription, roomManager.handlePreviewSubscription)
+	router.OnRoomOperation(roomOpPresenceEvent, roomManager.handlePresenceEvent)
+	router.OnRoomOperation(roomOpListPresence, roomManager.handleListPresence)
+	roomManager.Presence().OnReport(roomManager.broadcastPresence)
+	router.OnRoomOperation(roomOpListWaitingParticipants, rtcService.handleListWaitingParticipants)
+	router.OnRoomOperation(roomOpAdmitParticipant, rtcService.handleAdmitParticipant)
+	router.OnRoomOperation(roomOpRejectParticipant, rtcService.handleRejectParticipant)
 
 	// clean up old rooms on startup
 	if err = roomManager.CleanupRooms(); err != nil {
@@ -242,6 +259,19 @@ func (s *LivekitServer) debugInfo(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// reports differences between RoomStore and live participants, pass cleanup=1 to remove orphaned entries
+func (s *LivekitServer) debugReconcile(w http.ResponseWriter, r *http.Request) {
+	res, err := s.roomManager.ReconcileRoom(r.URL.Query().Get("room"), r.URL.Query().Get("cleanup") == "1")
+	if err != nil {
+		w.WriteHeader(400)
+		_, _ = w.Write([]byte(err.Error()))
+		return
+	}
+
+	b, _ := json.Marshal(res)
+	_, _ = w.Write(b)
+}
+
 func (s *LivekitServer) healthCheck(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
@@ -255,6 +285,7 @@ func (s *LivekitServer) backgroundWorker() {
 			return
 		case <-roomTicker.C:
 			s.roomManager.CloseIdleRooms()
+			s.roomManager.ReconcileRooms()
 		}
 	}
 }
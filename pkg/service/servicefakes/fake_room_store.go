@@ -10,6 +10,20 @@ import (
 )
 
 type FakeRoomStore struct {
+	AppendParticipantSessionStub        func(string, *service.ParticipantSession, int, time.Duration) error
+	appendParticipantSessionMutex       sync.RWMutex
+	appendParticipantSessionArgsForCall []struct {
+		arg1 string
+		arg2 *service.ParticipantSession
+		arg3 int
+		arg4 time.Duration
+	}
+	appendParticipantSessionReturns struct {
+		result1 error
+	}
+	appendParticipantSessionReturnsOnCall map[int]struct {
+		result1 error
+	}
 	CreateRoomStub        func(*livekit.Room) error
 	createRoomMutex       sync.RWMutex
 	createRoomArgsForCall []struct {
@@ -71,6 +85,33 @@ type FakeRoomStore struct {
 		result1 *livekit.Room
 		result2 error
 	}
+	GetRoomStatsStub        func(string) (*service.RoomStats, error)
+	getRoomStatsMutex       sync.RWMutex
+	getRoomStatsArgsForCall []struct {
+		arg1 string
+	}
+	getRoomStatsReturns struct {
+		result1 *service.RoomStats
+		result2 error
+	}
+	getRoomStatsReturnsOnCall map[int]struct {
+		result1 *service.RoomStats
+		result2 error
+	}
+	ListParticipantSessionsStub        func(string, string) ([]*service.ParticipantSession, error)
+	listParticipantSessionsMutex       sync.RWMutex
+	listParticipantSessionsArgsForCall []struct {
+		arg1 string
+		arg2 string
+	}
+	listParticipantSessionsReturns struct {
+		result1 []*service.ParticipantSession
+		result2 error
+	}
+	listParticipantSessionsReturnsOnCall map[int]struct {
+		result1 []*service.ParticipantSession
+		result2 error
+	}
 	ListParticipantsStub        func(string) ([]*livekit.ParticipantInfo, error)
 	listParticipantsMutex       sync.RWMutex
 	listParticipantsArgsForCall []struct {
@@ -138,6 +179,70 @@ type FakeRoomStore struct {
 	invocationsMutex sync.RWMutex
 }
 
+func (fake *FakeRoomStore) AppendParticipantSession(arg1 string, arg2 *service.ParticipantSession, arg3 int, arg4 time.Duration) error {
+	fake.appendParticipantSessionMutex.Lock()
+	ret, specificReturn := fake.appendParticipantSessionReturnsOnCall[len(fake.appendParticipantSessionArgsForCall)]
+	fake.appendParticipantSessionArgsForCall = append(fake.appendParticipantSessionArgsForCall, struct {
+		arg1 string
+		arg2 *service.ParticipantSession
+		arg3 int
+		arg4 time.Duration
+	}{arg1, arg2, arg3, arg4})
+	stub := fake.AppendParticipantSessionStub
+	fakeReturns := fake.appendParticipantSessionReturns
+	fake.recordInvocation("AppendParticipantSession", []interface{}{arg1, arg2, arg3, arg4})
+	fake.appendParticipantSessionMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeRoomStore) AppendParticipantSessionCallCount() int {
+	fake.appendParticipantSessionMutex.RLock()
+	defer fake.appendParticipantSessionMutex.RUnlock()
+	return len(fake.appendParticipantSessionArgsForCall)
+}
+
+func (fake *FakeRoomStore) AppendParticipantSessionCalls(stub func(string, *service.ParticipantSession, int, time.Duration) error) {
+	fake.appendParticipantSessionMutex.Lock()
+	defer fake.appendParticipantSessionMutex.Unlock()
+	fake.AppendParticipantSessionStub = stub
+}
+
+func (fake *FakeRoomStore) AppendParticipantSessionArgsForCall(i int) (string, *service.ParticipantSession, int, time.Duration) {
+	fake.appendParticipantSessionMutex.RLock()
+	defer fake.appendParticipantSessionMutex.RUnlock()
+	argsForCall := fake.appendParticipantSessionArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+}
+
+func (fake *FakeRoomStore) AppendParticipantSessionReturns(result1 error) {
+	fake.appendParticipantSessionMutex.Lock()
+	defer fake.appendParticipantSessionMutex.Unlock()
+	fake.AppendParticipantSessionStub = nil
+	fake.appendParticipantSessionReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeRoomStore) AppendParticipantSessionReturnsOnCall(i int, result1 error) {
+	fake.appendParticipantSessionMutex.Lock()
+	defer fake.appendParticipantSessionMutex.Unlock()
+	fake.AppendParticipantSessionStub = nil
+	if fake.appendParticipantSessionReturnsOnCall == nil {
+		fake.appendParticipantSessionReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.appendParticipantSessionReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeRoomStore) CreateRoom(arg1 *livekit.Room) error {
 	fake.createRoomMutex.Lock()
 	ret, specificReturn := fake.createRoomReturnsOnCall[len(fake.createRoomArgsForCall)]
@@ -451,6 +556,135 @@ func (fake *FakeRoomStore) GetRoomReturnsOnCall(i int, result1 *livekit.Room, re
 	}{result1, result2}
 }
 
+func (fake *FakeRoomStore) GetRoomStats(arg1 string) (*service.RoomStats, error) {
+	fake.getRoomStatsMutex.Lock()
+	ret, specificReturn := fake.getRoomStatsReturnsOnCall[len(fake.getRoomStatsArgsForCall)]
+	fake.getRoomStatsArgsForCall = append(fake.getRoomStatsArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.GetRoomStatsStub
+	fakeReturns := fake.getRoomStatsReturns
+	fake.recordInvocation("GetRoomStats", []interface{}{arg1})
+	fake.getRoomStatsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeRoomStore) GetRoomStatsCallCount() int {
+	fake.getRoomStatsMutex.RLock()
+	defer fake.getRoomStatsMutex.RUnlock()
+	return len(fake.getRoomStatsArgsForCall)
+}
+
+func (fake *FakeRoomStore) GetRoomStatsCalls(stub func(string) (*service.RoomStats, error)) {
+	fake.getRoomStatsMutex.Lock()
+	defer fake.getRoomStatsMutex.Unlock()
+	fake.GetRoomStatsStub = stub
+}
+
+func (fake *FakeRoomStore) GetRoomStatsArgsForCall(i int) string {
+	fake.getRoomStatsMutex.RLock()
+	defer fake.getRoomStatsMutex.RUnlock()
+	argsForCall := fake.getRoomStatsArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeRoomStore) GetRoomStatsReturns(result1 *service.RoomStats, result2 error) {
+	fake.getRoomStatsMutex.Lock()
+	defer fake.getRoomStatsMutex.Unlock()
+	fake.GetRoomStatsStub = nil
+	fake.getRoomStatsReturns = struct {
+		result1 *service.RoomStats
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeRoomStore) GetRoomStatsReturnsOnCall(i int, result1 *service.RoomStats, result2 error) {
+	fake.getRoomStatsMutex.Lock()
+	defer fake.getRoomStatsMutex.Unlock()
+	fake.GetRoomStatsStub = nil
+	if fake.getRoomStatsReturnsOnCall == nil {
+		fake.getRoomStatsReturnsOnCall = make(map[int]struct {
+			result1 *service.RoomStats
+			result2 error
+		})
+	}
+	fake.getRoomStatsReturnsOnCall[i] = struct {
+		result1 *service.RoomStats
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeRoomStore) ListParticipantSessions(arg1 string, arg2 string) ([]*service.ParticipantSession, error) {
+	fake.listParticipantSessionsMutex.Lock()
+	ret, specificReturn := fake.listParticipantSessionsReturnsOnCall[len(fake.listParticipantSessionsArgsForCall)]
+	fake.listParticipantSessionsArgsForCall = append(fake.listParticipantSessionsArgsForCall, struct {
+		arg1 string
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.ListParticipantSessionsStub
+	fakeReturns := fake.listParticipantSessionsReturns
+	fake.recordInvocation("ListParticipantSessions", []interface{}{arg1, arg2})
+	fake.listParticipantSessionsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeRoomStore) ListParticipantSessionsCallCount() int {
+	fake.listParticipantSessionsMutex.RLock()
+	defer fake.listParticipantSessionsMutex.RUnlock()
+	return len(fake.listParticipantSessionsArgsForCall)
+}
+
+func (fake *FakeRoomStore) ListParticipantSessionsCalls(stub func(string, string) ([]*service.ParticipantSession, error)) {
+	fake.listParticipantSessionsMutex.Lock()
+	defer fake.listParticipantSessionsMutex.Unlock()
+	fake.ListParticipantSessionsStub = stub
+}
+
+func (fake *FakeRoomStore) ListParticipantSessionsArgsForCall(i int) (string, string) {
+	fake.listParticipantSessionsMutex.RLock()
+	defer fake.listParticipantSessionsMutex.RUnlock()
+	argsForCall := fake.listParticipantSessionsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeRoomStore) ListParticipantSessionsReturns(result1 []*service.ParticipantSession, result2 error) {
+	fake.listParticipantSessionsMutex.Lock()
+	defer fake.listParticipantSessionsMutex.Unlock()
+	fake.ListParticipantSessionsStub = nil
+	fake.listParticipantSessionsReturns = struct {
+		result1 []*service.ParticipantSession
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeRoomStore) ListParticipantSessionsReturnsOnCall(i int, result1 []*service.ParticipantSession, result2 error) {
+	fake.listParticipantSessionsMutex.Lock()
+	defer fake.listParticipantSessionsMutex.Unlock()
+	fake.ListParticipantSessionsStub = nil
+	if fake.listParticipantSessionsReturnsOnCall == nil {
+		fake.listParticipantSessionsReturnsOnCall = make(map[int]struct {
+			result1 []*service.ParticipantSession
+			result2 error
+		})
+	}
+	fake.listParticipantSessionsReturnsOnCall[i] = struct {
+		result1 []*service.ParticipantSession
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeRoomStore) ListParticipants(arg1 string) ([]*livekit.ParticipantInfo, error) {
 	fake.listParticipantsMutex.Lock()
 	ret, specificReturn := fake.listParticipantsReturnsOnCall[len(fake.listParticipantsArgsForCall)]
@@ -763,6 +997,8 @@ func (fake *FakeRoomStore) UnlockRoomReturnsOnCall(i int, result1 error) {
 func (fake *FakeRoomStore) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
+	fake.appendParticipantSessionMutex.RLock()
+	defer fake.appendParticipantSessionMutex.RUnlock()
 	fake.createRoomMutex.RLock()
 	defer fake.createRoomMutex.RUnlock()
 	fake.deleteParticipantMutex.RLock()
@@ -773,6 +1009,10 @@ func (fake *FakeRoomStore) Invocations() map[string][][]interface{} {
 	defer fake.getParticipantMutex.RUnlock()
 	fake.getRoomMutex.RLock()
 	defer fake.getRoomMutex.RUnlock()
+	fake.getRoomStatsMutex.RLock()
+	defer fake.getRoomStatsMutex.RUnlock()
+	fake.listParticipantSessionsMutex.RLock()
+	defer fake.listParticipantSessionsMutex.RUnlock()
 	fake.listParticipantsMutex.RLock()
 	defer fake.listParticipantsMutex.RUnlock()
 	fake.listRoomsMutex.RLock()
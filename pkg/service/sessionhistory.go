@@ -0,0 +1,95 @@
+package service
+
+import (
+	"sync"
+	"time"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/logger"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+)
+
+// sessions waiting to be written, more are dropped
+const sessionHistoryQueueSize = 1000
+
+// ListParticipantSessionsRequest lists past sessions of participants of a room, those of identity when it's given
+type ListParticipantSessionsRequest struct {
+	Room     string `json:"room"`
+	Identity string `json:"identity,omitempty"`
+}
+
+type ListParticipantSessionsResponse struct {
+	// most recent first
+	Sessions []*ParticipantSession `json:"sessions"`
+}
+
+type sessionWrite struct {
+	room    string
+	session *ParticipantSession
+}
+
+// SessionHistoryWriter records sessions of participants that left to the room store from its own goroutine, so
+// writing them doesn't hold up removing participants
+type SessionHistoryWriter struct {
+	conf  config.SessionHistoryConfig
+	store RoomStore
+
+	queue chan sessionWrite
+	done  chan struct{}
+	once  sync.Once
+}
+
+func NewSessionHistoryWriter(conf config.SessionHistoryConfig, store RoomStore) *SessionHistoryWriter {
+	w := &SessionHistoryWriter{
+		conf:  conf,
+		store: store,
+		queue: make(chan sessionWrite, sessionHistoryQueueSize),
+		done:  make(chan struct{}),
+	}
+	if conf.Depth > 0 {
+		go w.worker()
+	}
+	return w
+}
+
+// Record queues the session of a participant that left, it's a no-op when history is disabled
+func (w *SessionHistoryWriter) Record(roomName string, p types.Participant, leftAt time.Time) {
+	if w.conf.Depth == 0 {
+		return
+	}
+	session := &ParticipantSession{
+		Sid:              p.ID(),
+		Identity:         p.Identity(),
+		JoinedAt:         p.ConnectedAt().Unix(),
+		LeftAt:           leftAt.Unix(),
+		DisconnectReason: string(p.DisconnectReason()),
+	}
+	select {
+	case w.queue <- sessionWrite{room: roomName, session: session}:
+	default:
+		logger.Warnw("dropping participant session, history queue is full", nil,
+			"room", roomName,
+			"participant", session.Identity)
+	}
+}
+
+func (w *SessionHistoryWriter) Close() {
+	w.once.Do(func() {
+		close(w.done)
+	})
+}
+
+func (w *SessionHistoryWriter) worker() {
+	for {
+		select {
+		case <-w.done:
+			return
+		case sw := <-w.queue:
+			if err := w.store.AppendParticipantSession(sw.room, sw.session, w.conf.Depth, w.conf.Retention); err != nil {
+				logger.Errorw("could not record participant session", err,
+					"room", sw.room,
+					"participant", sw.session.Identity)
+			}
+		}
+	}
+}
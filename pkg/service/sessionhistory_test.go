@@ -0,0 +1,56 @@
+package service_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/rtc/types"
+	"github.com/livekit/livekit-server/pkg/rtc/types/typesfakes"
+	"github.com/livekit/livekit-server/pkg/service"
+	"github.com/livekit/livekit-server/pkg/service/servicefakes"
+)
+
+func TestSessionHistoryWriter(t *testing.T) {
+	p := &typesfakes.FakeParticipant{}
+	p.IDReturns("PA_1")
+	p.IdentityReturns("a")
+	p.ConnectedAtReturns(time.Unix(1000, 0))
+	p.DisconnectReasonReturns(types.DisconnectReasonClientInitiated)
+
+	t.Run("writes sessions in the background", func(t *testing.T) {
+		store := &servicefakes.FakeRoomStore{}
+		conf := config.SessionHistoryConfig{Depth: 10, Retention: time.Hour}
+		w := service.NewSessionHistoryWriter(conf, store)
+		defer w.Close()
+
+		w.Record("room", p, time.Unix(1060, 0))
+		require.Eventually(t, func() bool {
+			return store.AppendParticipantSessionCallCount() == 1
+		}, time.Second, time.Millisecond)
+
+		roomName, session, depth, retention := store.AppendParticipantSessionArgsForCall(0)
+		require.Equal(t, "room", roomName)
+		require.Equal(t, service.ParticipantSession{
+			Sid:              "PA_1",
+			Identity:         "a",
+			JoinedAt:         1000,
+			LeftAt:           1060,
+			DisconnectReason: "client_initiated",
+		}, *session)
+		require.Equal(t, 10, depth)
+		require.Equal(t, time.Hour, retention)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		store := &servicefakes.FakeRoomStore{}
+		w := service.NewSessionHistoryWriter(config.SessionHistoryConfig{}, store)
+		defer w.Close()
+
+		w.Record("room", p, time.Now())
+		time.Sleep(10 * time.Millisecond)
+		require.Equal(t, 0, store.AppendParticipantSessionCallCount())
+	})
+}
@@ -0,0 +1,30 @@
+package service
+
+import (
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/livekit-server/pkg/config"
+)
+
+// storeFormat encodes rooms and participants kept in the store. Either format is decoded whatever it's set to,
+// so entries written before the format was changed are still read
+type storeFormat string
+
+func (f storeFormat) marshal(m proto.Message) ([]byte, error) {
+	if f == config.StoreFormatJSON {
+		return protojson.Marshal(m)
+	}
+	return proto.Marshal(m)
+}
+
+// unmarshalStored decodes data in either format. protojson writes objects starting with '{', which can't start a
+// protobuf message without a field 15 encoded as a group, a wire type proto3 doesn't use. Whitespace isn't skipped,
+// as '\n' starts protobuf messages with a length-delimited field 1
+func unmarshalStored(data []byte, m proto.Message) error {
+	if len(data) > 0 && data[0] == '{' {
+		// fields added by newer versions are skipped, as they would be with protobuf
+		return protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(data, m)
+	}
+	return proto.Unmarshal(data, m)
+}
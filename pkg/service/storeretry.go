@@ -0,0 +1,253 @@
+package service
+
+import (
+	"sync"
+	"time"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/logger"
+	livekit "github.com/livekit/livekit-server/proto"
+)
+
+// participantWrite is the latest state of a participant to be written to the room store, nil info to delete it
+type participantWrite struct {
+	room     string
+	identity string
+	info     *livekit.ParticipantInfo
+	// bumped when a newer write replaces it
+	version uint64
+	// false while its first attempt is applied by the caller, queued for retry by the worker once it fails
+	queued   bool
+	attempts int
+	failedAt time.Time
+	next     time.Time
+	backoff  time.Duration
+}
+
+// ParticipantStoreWriter writes participant updates and removals to the room store. Writes that fail are retried
+// from a single goroutine with backoff, so the final state of participants lands once the store recovers, without
+// holding up rooms in the meantime.
+// Writes of a participant are applied one at a time. Only the latest is kept while one is in progress or pending,
+// later writes replace it rather than racing it, so they land in order
+type ParticipantStoreWriter struct {
+	conf  config.StoreRetryConfig
+	store RoomStore
+
+	lock sync.Mutex
+	// room/identity => write in progress or pending
+	pending map[string]*participantWrite
+	wake    chan struct{}
+	done    chan struct{}
+	once    sync.Once
+}
+
+func NewParticipantStoreWriter(conf config.StoreRetryConfig, store RoomStore) *ParticipantStoreWriter {
+	w := &ParticipantStoreWriter{
+		conf:    conf,
+		store:   store,
+		pending: make(map[string]*participantWrite),
+		wake:    make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+	if conf.QueueSize > 0 {
+		go w.worker()
+	}
+	return w
+}
+
+// Persist writes the participant to the store, or queues it for retry when it fails
+func (w *ParticipantStoreWriter) Persist(roomName string, info *livekit.ParticipantInfo) {
+	w.write(roomName, info.Identity, info)
+}
+
+// Delete removes the participant from the store, or queues it for retry when it fails
+func (w *ParticipantStoreWriter) Delete(roomName, identity string) {
+	w.write(roomName, identity, nil)
+}
+
+// DropRoom discards pending writes of participants of a room that's been deleted
+func (w *ParticipantStoreWriter) DropRoom(roomName string) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	for key, pw := range w.pending {
+		if pw.room == roomName {
+			delete(w.pending, key)
+		}
+	}
+}
+
+// Pending returns the number of writes waiting to be retried
+func (w *ParticipantStoreWriter) Pending() int {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return w.queuedLocked()
+}
+
+func (w *ParticipantStoreWriter) queuedLocked() int {
+	queued := 0
+	for _, pw := range w.pending {
+		if pw.queued {
+			queued++
+		}
+	}
+	return queued
+}
+
+func (w *ParticipantStoreWriter) Close() {
+	w.once.Do(func() {
+		close(w.done)
+	})
+}
+
+func (w *ParticipantStoreWriter) write(roomName, identity string, info *livekit.ParticipantInfo) {
+	key := roomName + "/" + identity
+	w.lock.Lock()
+	if pw := w.pending[key]; pw != nil {
+		// replaces the one in progress or pending, applied once that's done or retried right away
+		pw.info = info
+		pw.version++
+		pw.next = time.Now()
+		w.lock.Unlock()
+		w.notify()
+		return
+	}
+	pw := &participantWrite{
+		room:     roomName,
+		identity: identity,
+		info:     info,
+	}
+	w.pending[key] = pw
+	w.lock.Unlock()
+
+	var version uint64
+	for {
+		err := w.apply(roomName, identity, info)
+
+		w.lock.Lock()
+		switch {
+		case w.pending[key] != pw:
+			// dropped with its room
+		case pw.version != version:
+			// replaced while applying, the newer write goes next
+			info, version = pw.info, pw.version
+			w.lock.Unlock()
+			continue
+		case err == nil:
+			delete(w.pending, key)
+		case w.conf.QueueSize == 0:
+			delete(w.pending, key)
+			logger.Errorw("could not handle participant change", err, "room", roomName, "participant", identity)
+		case w.queuedLocked() >= w.conf.QueueSize:
+			delete(w.pending, key)
+			logger.Errorw("dropping participant change, store retry queue is full", err,
+				"room", roomName,
+				"participant", identity)
+		default:
+			now := time.Now()
+			logger.Warnw("could not handle participant change, retrying", err, "room", roomName, "participant", identity)
+			pw.queued = true
+			pw.attempts = 1
+			pw.failedAt = now
+			pw.next = now.Add(w.conf.RetryBackoff)
+			pw.backoff = w.conf.RetryBackoff
+			w.notify()
+		}
+		w.lock.Unlock()
+		return
+	}
+}
+
+func (w *ParticipantStoreWriter) apply(roomName, identity string, info *livekit.ParticipantInfo) error {
+	if info == nil {
+		return w.store.DeleteParticipant(roomName, identity)
+	}
+	return w.store.PersistParticipant(roomName, info)
+}
+
+func (w *ParticipantStoreWriter) notify() {
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (w *ParticipantStoreWriter) worker() {
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+	for {
+		wait := w.retryDue()
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(wait)
+		select {
+		case <-w.done:
+			return
+		case <-w.wake:
+		case <-timer.C:
+		}
+	}
+}
+
+// retryDue retries writes that are due, and returns how long until the next one is
+func (w *ParticipantStoreWriter) retryDue() time.Duration {
+	for {
+		w.lock.Lock()
+		now := time.Now()
+		var due *participantWrite
+		wait := time.Hour
+		for _, pw := range w.pending {
+			if !pw.queued {
+				continue
+			}
+			if !pw.next.After(now) {
+				due = pw
+				break
+			}
+			if d := pw.next.Sub(now); d < wait {
+				wait = d
+			}
+		}
+		if due == nil {
+			w.lock.Unlock()
+			return wait
+		}
+		key := due.room + "/" + due.identity
+		info, version := due.info, due.version
+		w.lock.Unlock()
+
+		err := w.apply(due.room, due.identity, info)
+
+		w.lock.Lock()
+		switch {
+		case w.pending[key] != due:
+			// dropped with its room
+		case due.version != version:
+			// replaced while retrying, the newer write goes next
+			due.failedAt = time.Now()
+		case err == nil:
+			delete(w.pending, key)
+			logger.Infow("handled participant change after retrying",
+				"room", due.room,
+				"participant", due.identity,
+				"attempts", due.attempts+1)
+		case time.Since(due.failedAt) >= w.conf.MaxAge:
+			delete(w.pending, key)
+			logger.Errorw("dropping participant change, store is still failing", err,
+				"room", due.room,
+				"participant", due.identity,
+				"attempts", due.attempts+1)
+		default:
+			due.attempts++
+			due.backoff *= 2
+			if due.backoff > w.conf.MaxBackoff {
+				due.backoff = w.conf.MaxBackoff
+			}
+			due.next = time.Now().Add(due.backoff)
+		}
+		w.lock.Unlock()
+	}
+}
@@ -0,0 +1,142 @@
+package service_test
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/service"
+	"github.com/livekit/livekit-server/pkg/service/servicefakes"
+	livekit "github.com/livekit/livekit-server/proto"
+)
+
+func TestParticipantStoreWriter(t *testing.T) {
+	conf := config.StoreRetryConfig{
+		QueueSize:    2,
+		RetryBackoff: time.Millisecond,
+		MaxBackoff:   5 * time.Millisecond,
+		MaxAge:       time.Second,
+	}
+	errUnavailable := errors.New("store unavailable")
+
+	t.Run("retries until the store recovers", func(t *testing.T) {
+		store := &servicefakes.FakeRoomStore{}
+		var failures int32 = 3
+		store.DeleteParticipantStub = func(string, string) error {
+			if atomic.AddInt32(&failures, -1) >= 0 {
+				return errUnavailable
+			}
+			return nil
+		}
+		w := service.NewParticipantStoreWriter(conf, store)
+		defer w.Close()
+
+		w.Delete("room", "a")
+		require.Equal(t, 1, w.Pending())
+		require.Eventually(t, func() bool {
+			return w.Pending() == 0
+		}, time.Second, time.Millisecond)
+		require.Equal(t, 4, store.DeleteParticipantCallCount())
+	})
+
+	t.Run("later writes replace pending ones", func(t *testing.T) {
+		store := &servicefakes.FakeRoomStore{}
+		var down int32 = 1
+		store.PersistParticipantStub = func(string, *livekit.ParticipantInfo) error {
+			if atomic.LoadInt32(&down) == 1 {
+				return errUnavailable
+			}
+			return nil
+		}
+		store.DeleteParticipantReturns(errUnavailable)
+		w := service.NewParticipantStoreWriter(conf, store)
+		defer w.Close()
+
+		w.Persist("room", &livekit.ParticipantInfo{Identity: "a", Metadata: "1"})
+		w.Persist("room", &livekit.ParticipantInfo{Identity: "a", Metadata: "2"})
+		// doesn't race the pending write
+		require.Equal(t, 1, store.PersistParticipantCallCount())
+		require.Equal(t, 1, w.Pending())
+
+		atomic.StoreInt32(&down, 0)
+		require.Eventually(t, func() bool {
+			return w.Pending() == 0
+		}, time.Second, time.Millisecond)
+		_, info := store.PersistParticipantArgsForCall(store.PersistParticipantCallCount() - 1)
+		require.Equal(t, "2", info.Metadata)
+	})
+
+	t.Run("applies writes of a participant one at a time", func(t *testing.T) {
+		store := &servicefakes.FakeRoomStore{}
+		applying := make(chan struct{})
+		release := make(chan struct{})
+		store.PersistParticipantStub = func(_ string, info *livekit.ParticipantInfo) error {
+			if info.Metadata == "1" {
+				close(applying)
+				<-release
+			}
+			return nil
+		}
+		w := service.NewParticipantStoreWriter(conf, store)
+		defer w.Close()
+
+		go w.Persist("room", &livekit.ParticipantInfo{Identity: "a", Metadata: "1"})
+		<-applying
+		w.Persist("room", &livekit.ParticipantInfo{Identity: "a", Metadata: "2"})
+		w.Persist("room", &livekit.ParticipantInfo{Identity: "a", Metadata: "3"})
+		// waits for the write in progress
+		require.Equal(t, 1, store.PersistParticipantCallCount())
+
+		close(release)
+		require.Eventually(t, func() bool {
+			return store.PersistParticipantCallCount() == 2
+		}, time.Second, time.Millisecond)
+		_, info := store.PersistParticipantArgsForCall(1)
+		require.Equal(t, "3", info.Metadata)
+		require.Equal(t, 0, w.Pending())
+	})
+
+	t.Run("drops writes when the queue is full or they're too old", func(t *testing.T) {
+		store := &servicefakes.FakeRoomStore{}
+		store.DeleteParticipantReturns(errUnavailable)
+		conf := conf
+		conf.MaxAge = 20 * time.Millisecond
+		w := service.NewParticipantStoreWriter(conf, store)
+		defer w.Close()
+
+		w.Delete("room", "a")
+		w.Delete("room", "b")
+		w.Delete("room", "c")
+		require.Equal(t, 2, w.Pending())
+		require.Eventually(t, func() bool {
+			return w.Pending() == 0
+		}, time.Second, time.Millisecond)
+	})
+
+	t.Run("drops writes of deleted rooms", func(t *testing.T) {
+		store := &servicefakes.FakeRoomStore{}
+		store.DeleteParticipantReturns(errUnavailable)
+		w := service.NewParticipantStoreWriter(conf, store)
+		defer w.Close()
+
+		w.Delete("room", "a")
+		w.Delete("other", "b")
+		w.DropRoom("room")
+		require.Equal(t, 1, w.Pending())
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		store := &servicefakes.FakeRoomStore{}
+		store.DeleteParticipantReturns(errUnavailable)
+		w := service.NewParticipantStoreWriter(config.StoreRetryConfig{}, store)
+		defer w.Close()
+
+		w.Delete("room", "a")
+		require.Equal(t, 0, w.Pending())
+		require.Equal(t, 1, store.DeleteParticipantCallCount())
+	})
+}
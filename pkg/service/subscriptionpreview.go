@@ -0,0 +1,19 @@
+package service
+
+import (
+	"github.com/livekit/livekit-server/pkg/routing"
+)
+
+const roomOpPreviewSubscription = "preview_subscription"
+
+// PreviewSubscriptionRequest returns what subscribing to a room would produce, letting clients check the expected
+// bandwidth before joining. Participants preview the room in their join token as its identity, room admins could
+// preview for any identity
+type PreviewSubscriptionRequest struct {
+	Room     string `json:"room,omitempty"`
+	Identity string `json:"identity,omitempty"`
+}
+
+func (r *RoomManager) handlePreviewSubscription(op *routing.RoomOperation) (interface{}, error) {
+	return r.PreviewSubscription(op.Room, op.Identity)
+}
@@ -1,9 +1,14 @@
 package service
 
 import (
+	"crypto/hmac"
+	"crypto/sha1"
 	"crypto/tls"
+	"encoding/base64"
+	"fmt"
 	"net"
 	"strconv"
+	"time"
 
 	"github.com/pion/turn/v2"
 	"github.com/pkg/errors"
@@ -11,6 +16,7 @@ import (
 	"github.com/livekit/livekit-server/pkg/config"
 	"github.com/livekit/livekit-server/pkg/logger"
 	"github.com/livekit/livekit-server/pkg/routing"
+	livekit "github.com/livekit/livekit-server/proto"
 )
 
 const (
@@ -68,6 +74,20 @@ func NewTurnServer(conf *config.Config, roomStore RoomStore, node routing.LocalN
 	return turn.NewServer(serverConfig)
 }
 
+// TURNCredentials returns the external TURN servers of conf, with time-limited credentials of a participant. The
+// username is the expiry as a unix timestamp and the identity, the credential is the base64 HMAC-SHA1 of the username
+// keyed with the shared secret, which TURN servers check without knowing of the participant
+func TURNCredentials(conf config.TURNConfig, identity string, now time.Time) *livekit.ICEServer {
+	username := fmt.Sprintf("%d:%s", now.Add(conf.CredentialTTL).Unix(), identity)
+	mac := hmac.New(sha1.New, []byte(conf.Secret))
+	_, _ = mac.Write([]byte(username))
+	return &livekit.ICEServer{
+		Urls:       conf.URLs,
+		Username:   username,
+		Credential: base64.StdEncoding.EncodeToString(mac.Sum(nil)),
+	}
+}
+
 func newTurnAuthHandler(roomStore RoomStore) turn.AuthHandler {
 	return func(username, realm string, srcAddr net.Addr) (key []byte, ok bool) {
 		// room id should be the username, create a hashed room id
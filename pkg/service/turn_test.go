@@ -0,0 +1,34 @@
+package service_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/service"
+)
+
+func TestTURNCredentials(t *testing.T) {
+	conf := config.TURNConfig{
+		URLs:          []string{"turn:turn.example.com:3478?transport=udp"},
+		Secret:        "secret",
+		CredentialTTL: time.Hour,
+	}
+	now := time.Unix(1600000000, 0)
+
+	server := service.TURNCredentials(conf, "alice", now)
+	require.Equal(t, conf.URLs, server.Urls)
+	require.Equal(t, "1600003600:alice", server.Username)
+
+	mac := hmac.New(sha1.New, []byte("secret"))
+	mac.Write([]byte("1600003600:alice"))
+	require.Equal(t, base64.StdEncoding.EncodeToString(mac.Sum(nil)), server.Credential)
+
+	// each participant gets its own
+	require.NotEqual(t, server.Credential, service.TURNCredentials(conf, "bob", now).Credential)
+}
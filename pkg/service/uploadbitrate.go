@@ -0,0 +1,39 @@
+package service
+
+import (
+	"github.com/livekit/livekit-server/pkg/routing"
+)
+
+const roomOpUpdateMaxUploadBitrate = "update_max_upload_bitrate"
+
+// UpdateMaxUploadBitrateRequest changes the max upload bitrate of a participant mid-session, in bits per second
+type UpdateMaxUploadBitrateRequest struct {
+	Room     string `json:"room"`
+	Identity string `json:"identity"`
+	Bitrate  uint64 `json:"bitrate"`
+}
+
+type UpdateMaxUploadBitrateResponse struct{}
+
+// SetParticipantMaxUploadBitrate changes the max bitrate of video published by a participant in a room hosted
+// on this node, 0 removes the limit
+func (r *RoomManager) SetParticipantMaxUploadBitrate(roomName, identity string, bitrate uint64) error {
+	room := r.GetRoom(roomName)
+	if room == nil {
+		return ErrRoomNotFound
+	}
+	participant := room.GetParticipant(identity)
+	if participant == nil {
+		return ErrParticipantNotFound
+	}
+	participant.SetMaxUploadBitrate(bitrate)
+	return nil
+}
+
+func (r *RoomManager) handleUpdateMaxUploadBitrate(op *routing.RoomOperation) (interface{}, error) {
+	req := UpdateMaxUploadBitrateRequest{}
+	if err := op.DecodeParams(&req); err != nil {
+		return nil, err
+	}
+	return nil, r.SetParticipantMaxUploadBitrate(op.Room, op.Identity, req.Bitrate)
+}
@@ -7,7 +7,6 @@ import (
 
 	"github.com/livekit/livekit-server/pkg/config"
 	"github.com/livekit/livekit-server/pkg/logger"
-	livekit "github.com/livekit/livekit-server/proto"
 )
 
 var ServiceSet = wire.NewSet(
@@ -15,9 +14,11 @@ var ServiceSet = wire.NewSet(
 	NewRTCService,
 	NewLivekitServer,
 	NewRoomManager,
+	NewWebHookDispatcher,
+	NewJWTAuthProvider,
 	NewTurnServer,
 	config.GetAudioConfig,
-	wire.Bind(new(livekit.RoomService), new(*RoomService)),
+	wire.Bind(new(AuthProvider), new(*JWTAuthProvider)),
 )
 
 func handleError(w http.ResponseWriter, status int, msg string) {
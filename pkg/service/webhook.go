@@ -0,0 +1,189 @@
+package service
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/livekit/protocol/auth"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/logger"
+)
+
+// events posted to the webhook
+const (
+	WebHookRoomCreated       = "room_created"
+	WebHookRoomDeleted       = "room_deleted"
+	WebHookParticipantJoined = "participant_joined"
+	WebHookParticipantLeft   = "participant_left"
+	WebHookTrackPublished    = "track_published"
+)
+
+const (
+	// hex encoded HMAC-SHA256 of the body
+	WebHookSignatureHeader = "X-Livekit-Signature"
+	// key whose secret the body is signed with
+	WebHookAPIKeyHeader = "X-Livekit-Api-Key"
+
+	webHookQueueSize = 1000
+	// bytes of error responses logged
+	webHookMaxResponseLogSize = 256
+)
+
+var ErrWebHookKeyNotFound = errors.New("webhook api_key isn't one of the configured keys")
+
+type WebHookEvent struct {
+	Event          string `json:"event"`
+	Room           string `json:"room"`
+	RoomSid        string `json:"roomSid,omitempty"`
+	ParticipantSid string `json:"participantSid,omitempty"`
+	Identity       string `json:"identity,omitempty"`
+	TrackSid       string `json:"trackSid,omitempty"`
+	// unix timestamp in milliseconds of when the event happened
+	Timestamp int64 `json:"timestamp"`
+}
+
+// WebHookDispatcher posts events to the configured URL from a single goroutine, so they're received in the order
+// they happened on this node. The body is signed with HMAC-SHA256 using the secret of the configured API key, sent
+// hex encoded in the X-Livekit-Signature header. Events are dropped when the queue is full, rather than holding up
+// rooms while the receiver is slow or down
+type WebHookDispatcher struct {
+	conf   config.WebHookConfig
+	secret []byte
+	client *http.Client
+	events chan *WebHookEvent
+	done   chan struct{}
+
+	dropped uint64
+	once    sync.Once
+}
+
+// NewWebHookDispatcher returns nil when no webhook URL is configured
+func NewWebHookDispatcher(conf *config.Config, keyProvider auth.KeyProvider) (*WebHookDispatcher, error) {
+	if conf.WebHook.URL == "" {
+		return nil, nil
+	}
+	secret := keyProvider.GetSecret(conf.WebHook.APIKey)
+	if secret == "" {
+		return nil, ErrWebHookKeyNotFound
+	}
+	d := &WebHookDispatcher{
+		conf:   conf.WebHook,
+		secret: []byte(secret),
+		client: &http.Client{Timeout: conf.WebHook.Timeout},
+		events: make(chan *WebHookEvent, webHookQueueSize),
+		done:   make(chan struct{}),
+	}
+	go d.worker()
+	return d, nil
+}
+
+// Notify queues the event, returns false when it's dropped
+func (d *WebHookDispatcher) Notify(event *WebHookEvent) bool {
+	if event.Timestamp == 0 {
+		event.Timestamp = time.Now().UnixNano() / int64(time.Millisecond)
+	}
+	select {
+	case <-d.done:
+		return false
+	default:
+	}
+	select {
+	case d.events <- event:
+		return true
+	default:
+		atomic.AddUint64(&d.dropped, 1)
+		logger.Warnw("dropping webhook event, queue is full", nil,
+			"event", event.Event,
+			"room", event.Room)
+		return false
+	}
+}
+
+// Dropped returns the number of events dropped because the queue was full
+func (d *WebHookDispatcher) Dropped() uint64 {
+	return atomic.LoadUint64(&d.dropped)
+}
+
+func (d *WebHookDispatcher) Close() {
+	d.once.Do(func() {
+		close(d.done)
+	})
+}
+
+func (d *WebHookDispatcher) worker() {
+	for {
+		select {
+		case <-d.done:
+			return
+		case event := <-d.events:
+			d.send(event)
+		}
+	}
+}
+
+func (d *WebHookDispatcher) send(event *WebHookEvent) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		logger.Errorw("could not marshal webhook event", err)
+		return
+	}
+	signature := d.sign(body)
+
+	backoff := d.conf.RetryBackoff
+	for attempt := 0; ; attempt++ {
+		err = d.post(body, signature)
+		if err == nil {
+			return
+		}
+		if attempt == d.conf.MaxRetries {
+			break
+		}
+		select {
+		case <-d.done:
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+	logger.Warnw("could not post webhook event", err,
+		"event", event.Event,
+		"room", event.Room,
+		"attempts", d.conf.MaxRetries+1)
+}
+
+func (d *WebHookDispatcher) sign(body []byte) string {
+	mac := hmac.New(sha256.New, d.secret)
+	_, _ = mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (d *WebHookDispatcher) post(body []byte, signature string) error {
+	req, err := http.NewRequest(http.MethodPost, d.conf.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebHookAPIKeyHeader, d.conf.APIKey)
+	req.Header.Set(WebHookSignatureHeader, signature)
+	res, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		msg := make([]byte, webHookMaxResponseLogSize)
+		n, _ := res.Body.Read(msg)
+		return fmt.Errorf("webhook responded with %d: %s", res.StatusCode, msg[:n])
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package service_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/livekit/protocol/auth"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/livekit-server/pkg/config"
+	"github.com/livekit/livekit-server/pkg/service"
+)
+
+func TestWebHookDispatcher(t *testing.T) {
+	keys := auth.NewFileBasedKeyProviderFromMap(map[string]string{"key1": "secret1"})
+	newConfig := func(url string) *config.Config {
+		conf, err := config.NewConfig("", nil)
+		require.NoError(t, err)
+		conf.WebHook.URL = url
+		conf.WebHook.APIKey = "key1"
+		conf.WebHook.RetryBackoff = time.Millisecond
+		return conf
+	}
+
+	t.Run("disabled without a URL", func(t *testing.T) {
+		d, err := service.NewWebHookDispatcher(newConfig(""), keys)
+		require.NoError(t, err)
+		require.Nil(t, d)
+	})
+
+	t.Run("requires a configured key", func(t *testing.T) {
+		conf := newConfig("http://localhost/hook")
+		conf.WebHook.APIKey = "key2"
+		_, err := service.NewWebHookDispatcher(conf, keys)
+		require.Equal(t, service.ErrWebHookKeyNotFound, err)
+	})
+
+	t.Run("posts signed events and retries failures", func(t *testing.T) {
+		var attempts int32
+		received := make(chan service.WebHookEvent, 10)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, err := ioutil.ReadAll(r.Body)
+			require.NoError(t, err)
+			mac := hmac.New(sha256.New, []byte("secret1"))
+			_, _ = mac.Write(body)
+			require.Equal(t, hex.EncodeToString(mac.Sum(nil)), r.Header.Get(service.WebHookSignatureHeader))
+			require.Equal(t, "key1", r.Header.Get(service.WebHookAPIKeyHeader))
+
+			if atomic.AddInt32(&attempts, 1) <= 2 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			var event service.WebHookEvent
+			require.NoError(t, json.Unmarshal(body, &event))
+			received <- event
+		}))
+		defer server.Close()
+
+		d, err := service.NewWebHookDispatcher(newConfig(server.URL), keys)
+		require.NoError(t, err)
+		defer d.Close()
+
+		require.True(t, d.Notify(&service.WebHookEvent{Event: service.WebHookRoomCreated, Room: "room"}))
+		require.True(t, d.Notify(&service.WebHookEvent{
+			Event:          service.WebHookParticipantJoined,
+			Room:           "room",
+			ParticipantSid: "PA_1",
+		}))
+
+		first := receiveWebHook(t, received)
+		require.Equal(t, service.WebHookRoomCreated, first.Event)
+		require.Equal(t, "room", first.Room)
+		require.NotZero(t, first.Timestamp)
+		second := receiveWebHook(t, received)
+		require.Equal(t, service.WebHookParticipantJoined, second.Event)
+		require.Equal(t, "PA_1", second.ParticipantSid)
+		require.Equal(t, int32(4), atomic.LoadInt32(&attempts))
+	})
+
+	t.Run("gives up after max retries", func(t *testing.T) {
+		var attempts int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&attempts, 1)
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer server.Close()
+
+		conf := newConfig(server.URL)
+		conf.WebHook.MaxRetries = 1
+		d, err := service.NewWebHookDispatcher(conf, keys)
+		require.NoError(t, err)
+		defer d.Close()
+
+		require.True(t, d.Notify(&service.WebHookEvent{Event: service.WebHookRoomDeleted, Room: "room"}))
+		require.Eventually(t, func() bool {
+			return atomic.LoadInt32(&attempts) == 2
+		}, time.Second, 5*time.Millisecond)
+		time.Sleep(20 * time.Millisecond)
+		require.Equal(t, int32(2), atomic.LoadInt32(&attempts))
+	})
+}
+
+func receiveWebHook(t *testing.T, events <-chan service.WebHookEvent) service.WebHookEvent {
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(time.Second):
+		require.Fail(t, "webhook event not received")
+		return service.WebHookEvent{}
+	}
+}
@@ -1,7 +1,8 @@
 // Code generated by Wire. DO NOT EDIT.
 
 //go:generate go run github.com/google/wire/cmd/wire
-//+build !wireinject
+//go:build !wireinject
+// +build !wireinject
 
 package service
 
@@ -14,7 +15,11 @@ import (
 // Injectors from wire.go:
 
 func InitializeServer(conf *config.Config, keyProvider auth.KeyProvider, roomStore RoomStore, router routing.Router, currentNode routing.LocalNode, selector routing.NodeSelector) (*LivekitServer, error) {
-	roomManager, err := NewRoomManager(roomStore, router, currentNode, selector, conf)
+	webHookDispatcher, err := NewWebHookDispatcher(conf, keyProvider)
+	if err != nil {
+		return nil, err
+	}
+	roomManager, err := NewRoomManager(roomStore, router, currentNode, selector, conf, webHookDispatcher)
 	if err != nil {
 		return nil, err
 	}
@@ -22,7 +27,8 @@ func InitializeServer(conf *config.Config, keyProvider auth.KeyProvider, roomSto
 	if err != nil {
 		return nil, err
 	}
-	rtcService := NewRTCService(conf, roomManager, router, currentNode)
+	jwtAuthProvider := NewJWTAuthProvider(keyProvider)
+	rtcService := NewRTCService(conf, roomManager, router, currentNode, jwtAuthProvider)
 	server, err := NewTurnServer(conf, roomStore, currentNode)
 	if err != nil {
 		return nil, err
@@ -186,7 +186,7 @@ func createMultiNodeServer(nodeId string, port uint32) *service.LivekitServer {
 	}
 
 	router := routing.NewRedisRouter(currentNode, rc)
-	roomStore := service.NewRedisRoomStore(rc)
+	roomStore := service.NewRedisRoomStore(rc, conf.Redis.StoreFormat)
 	_ = roomStore.DeleteRoom(testRoom)
 	s, err := service.InitializeServer(conf, &StaticKeyProvider{}, roomStore, router, currentNode, &routing.RandomSelector{})
 	if err != nil {